@@ -0,0 +1,14 @@
+// Package api holds the machine-readable API contract (JSON Schemas, and the OpenAPI
+// document that references them). Files are embedded so the binary validates
+// requests against exactly the schemas that are published.
+package api
+
+import "embed"
+
+//go:embed schemas/*.json
+var Schemas embed.FS
+
+// OpenAPI is the OpenAPI 3.1 document. Its schemas $ref the files in schemas/.
+//
+//go:embed openapi.json
+var OpenAPI []byte
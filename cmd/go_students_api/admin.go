@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/middleware"
+)
+
+// adminTLSConfig loads the admin listener's certificate and client CA.
+// It returns nil when TLS isn't configured, and an error when neither a token nor mutual TLS
+// would protect the admin routes.
+func adminTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	a := cfg.AdminServer
+	if (a.CertFile == "") != (a.KeyFile == "") {
+		return nil, errors.New("admin_server.cert_file and key_file must be set together")
+	}
+	if a.ClientCAFile != "" && a.CertFile == "" {
+		return nil, errors.New("admin_server.client_ca_file requires cert_file and key_file")
+	}
+	if a.Token == "" && a.ClientCAFile == "" {
+		return nil, errors.New("admin server needs a token (ADMIN_TOKEN) or client_ca_file for mutual TLS")
+	}
+	if a.CertFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(a.CertFile, a.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading admin certificate: %w", err)
+	}
+	tlsCfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if a.ClientCAFile != "" {
+		pem, err := os.ReadFile(a.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading admin client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", a.ClientCAFile)
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsCfg, nil
+}
+
+// newAdminServer binds the admin listener and wraps handler in the configured authentication
+func newAdminServer(cfg *config.Config, handler http.Handler) (*http.Server, net.Listener, error) {
+	tlsCfg, err := adminTLSConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.AdminServer.Token != "" {
+		handler = middleware.Chain(handler, middleware.RequireBearerToken(cfg.AdminServer.Token))
+	}
+
+	addr := fmt.Sprintf("%s:%d", cfg.AdminServer.Host, cfg.AdminServer.Port)
+	ln, _, err := listen(cfg, addr, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tlsCfg != nil {
+		ln = tls.NewListener(ln, tlsCfg)
+	}
+
+	server := &http.Server{
+		Addr:        addr,
+		Handler:     handler,
+		ReadTimeout: cfg.HTTPServer.Timeout,
+		IdleTimeout: cfg.HTTPServer.IdleTimeout,
+	}
+	return server, ln, nil
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/export"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/router"
+	"github.com/prashantkumbhar2002/go_students_api/internal/idgen"
+	"github.com/prashantkumbhar2002/go_students_api/internal/retention"
+	"github.com/prashantkumbhar2002/go_students_api/internal/scheduler"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/sqlite"
+	"github.com/prashantkumbhar2002/go_students_api/internal/tenant"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// validateConfig finds config mistakes that would otherwise surface later: at the first tenant
+// lookup, the first scheduled run or the first email. All problems are reported together.
+func validateConfig(cfg *config.Config) error {
+	var errs []error
+
+	if cfg.Tenancy.Enabled {
+		if len(cfg.Tenancy.Tenants) == 0 {
+			errs = append(errs, errors.New("tenancy is enabled but no tenants are configured"))
+		}
+		ids := make(map[string]bool)
+		keys := make(map[string]string)
+		for _, t := range cfg.Tenancy.Tenants {
+			switch {
+			case !tenant.ValidID(t.ID):
+				errs = append(errs, fmt.Errorf("invalid tenant ID %q: use lowercase letters, digits and dashes", t.ID))
+			case ids[t.ID]:
+				errs = append(errs, fmt.Errorf("duplicate tenant ID %q", t.ID))
+			case t.StoragePath == "":
+				errs = append(errs, fmt.Errorf("tenant %q has no storage_path", t.ID))
+			case t.MonthlyQuota < 0:
+				errs = append(errs, fmt.Errorf("tenant %q has a negative monthly_quota", t.ID))
+			}
+			ids[t.ID] = true
+			for _, key := range t.APIKeys {
+				if other, ok := keys[key]; ok {
+					errs = append(errs, fmt.Errorf("an API key is configured for both tenants %q and %q", other, t.ID))
+				}
+				keys[key] = t.ID
+			}
+		}
+	}
+
+	if _, err := idgen.New(cfg.IDs.Strategy, cfg.IDs.Node); err != nil {
+		errs = append(errs, fmt.Errorf("ids: %w", err))
+	}
+
+	if err := retention.Validate(retentionRules(cfg)); err != nil {
+		errs = append(errs, fmt.Errorf("retention: %w", err))
+	}
+
+	if cfg.Scheduler.Enabled {
+		sch := scheduler.New()
+		noop := func(context.Context) error { return nil }
+		for _, j := range cfg.Scheduler.Jobs {
+			if !slices.Contains(builtinJobs, j.Name) {
+				errs = append(errs, fmt.Errorf("unknown scheduled job %q (available: %s)", j.Name, strings.Join(builtinJobs, ", ")))
+				continue
+			}
+			if err := sch.Add(j.Name, j.Schedule, noop); err != nil {
+				errs = append(errs, fmt.Errorf("scheduler: %w", err))
+			}
+			if j.Name == "export" {
+				errs = append(errs, validateExport(cfg.Export)...)
+			}
+		}
+	}
+
+	if cfg.Mail.Enabled {
+		switch {
+		case cfg.Mail.Transport != "smtp" && cfg.Mail.Transport != "log":
+			errs = append(errs, fmt.Errorf("unknown mail transport %q (want smtp or log)", cfg.Mail.Transport))
+		case cfg.Mail.Transport == "smtp" && cfg.Mail.SMTPHost == "":
+			errs = append(errs, errors.New("mail.smtp_host is required for the smtp transport"))
+		}
+	}
+
+	if cfg.SMS.Enabled {
+		switch {
+		case cfg.SMS.Provider != "twilio" && cfg.SMS.Provider != "log":
+			errs = append(errs, fmt.Errorf("unknown SMS provider %q (want twilio or log)", cfg.SMS.Provider))
+		case cfg.SMS.Provider == "twilio" && (cfg.SMS.AccountSID == "" || cfg.SMS.AuthToken == "" || cfg.SMS.From == ""):
+			errs = append(errs, errors.New("sms.account_sid, sms.auth_token and sms.from are required for the twilio provider"))
+		}
+		// Reports carry no tenant header, so each tenant's must arrive on its own subdomain
+		if cfg.Tenancy.Enabled && cfg.SMS.StatusCallbackURL != "" &&
+			(!strings.Contains(cfg.SMS.StatusCallbackURL, "{tenant}") || cfg.Tenancy.BaseDomain == "") {
+			errs = append(errs, errors.New(`sms.status_callback_url must name the tenant as a "{tenant}" subdomain of tenancy.base_domain`))
+		}
+	}
+
+	if cfg.Webhooks.Enabled {
+		switch {
+		case cfg.Tenancy.Enabled && len(cfg.Webhooks.APIKeys) > 0:
+			errs = append(errs, errors.New("webhooks.api_keys is unused with tenancy: each tenant's api_keys may subscribe"))
+		case !cfg.Tenancy.Enabled && len(cfg.Webhooks.APIKeys) == 0:
+			errs = append(errs, errors.New("webhooks.api_keys is required for webhooks"))
+		}
+	}
+
+	if !slices.Contains(cfg.API.Versions, cfg.API.DefaultVersion) {
+		errs = append(errs, fmt.Errorf("api.default_version %d is not in api.versions", cfg.API.DefaultVersion))
+	}
+	for _, v := range cfg.API.EnvelopeVersions {
+		if !slices.Contains(cfg.API.Versions, v) {
+			errs = append(errs, fmt.Errorf("api.envelope_versions lists %d, which is not in api.versions", v))
+		}
+	}
+
+	if !router.ValidPathNormalization(cfg.HTTPServer.PathNormalization) {
+		errs = append(errs, fmt.Errorf("unknown http_server.path_normalization %q (want %s, %s or %s)",
+			cfg.HTTPServer.PathNormalization, router.PathsRedirect, router.PathsRewrite, router.PathsOff))
+	}
+
+	if !helpers.ValidPaginationStyle(cfg.Pagination.Style) {
+		errs = append(errs, fmt.Errorf("unknown pagination.style %q (want %s or %s)", cfg.Pagination.Style, helpers.PaginationBody, helpers.PaginationHeaders))
+	}
+	if cfg.Pagination.DefaultLimit < types.MinLimit || cfg.Pagination.MaxLimit < cfg.Pagination.DefaultLimit {
+		errs = append(errs, fmt.Errorf("pagination: want %d <= default_limit <= max_limit, got %d and %d",
+			types.MinLimit, cfg.Pagination.DefaultLimit, cfg.Pagination.MaxLimit))
+	}
+
+	if cfg.Profiles.Font != "" {
+		if _, err := os.Stat(cfg.Profiles.Font); err != nil {
+			errs = append(errs, fmt.Errorf("profiles.font: %w", err))
+		}
+	}
+
+	if cfg.RateLimit.Enabled {
+		if _, err := newRateLimiter(cfg); err != nil {
+			errs = append(errs, fmt.Errorf("rate_limit: %w", err))
+		}
+	}
+
+	if cfg.Quota.Enabled && cfg.Quota.Requests < 1 {
+		errs = append(errs, errors.New("quota.requests must be positive"))
+	}
+
+	if cfg.Concurrency.Enabled {
+		if _, err := newConcurrencyLimiter(cfg); err != nil {
+			errs = append(errs, fmt.Errorf("concurrency: %w", err))
+		}
+	}
+
+	if _, err := newDeprecations(cfg); err != nil {
+		errs = append(errs, fmt.Errorf("deprecations: %w", err))
+	}
+
+	if cfg.Recording.Enabled {
+		switch {
+		case !cfg.IsDev():
+			errs = append(errs, fmt.Errorf("recording is for development only, but env is %q", cfg.Env))
+		case cfg.Recording.Keep < 1 || cfg.Recording.MaxBodyBytes < 1:
+			errs = append(errs, errors.New("recording: keep and max_body_bytes must be positive"))
+		}
+	}
+
+	if cfg.Library.LoanDays < 1 {
+		errs = append(errs, errors.New("library.loan_days must be positive"))
+	}
+
+	if cfg.Search.Enabled && cfg.Search.URL == "" {
+		errs = append(errs, errors.New("search.url is required when search is enabled"))
+	}
+
+	if cfg.AdminServer.Enabled {
+		if _, err := adminTLSConfig(cfg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, validateServers(cfg)...)
+
+	return errors.Join(errs...)
+}
+
+// validateServers checks the servers list: unique names, addresses to listen on and known route
+// groups, each served once. A server with the admin or pprof group needs a token, as the admin
+// listener needs one (or mutual TLS, which servers don't offer).
+func validateServers(cfg *config.Config) []error {
+	if len(cfg.Servers) == 0 {
+		return nil
+	}
+	var errs []error
+	if cfg.AdminServer.Enabled {
+		errs = append(errs, errors.New("admin_server can't be enabled alongside servers; give a server the admin and pprof groups instead"))
+	}
+	names := make(map[string]bool)
+	served := make(map[string]string)
+	for i, s := range cfg.Servers {
+		switch {
+		case s.Name == "":
+			errs = append(errs, fmt.Errorf("servers[%d] has no name", i))
+		case names[s.Name]:
+			errs = append(errs, fmt.Errorf("duplicate server name %q", s.Name))
+		}
+		names[s.Name] = true
+		if _, _, err := net.SplitHostPort(s.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("server %q: addr %q is not host:port", s.Name, s.Addr))
+		}
+		if len(s.Groups) == 0 {
+			errs = append(errs, fmt.Errorf("server %q serves no route groups", s.Name))
+		}
+		for _, g := range s.Groups {
+			if !slices.Contains(router.Groups, g) {
+				errs = append(errs, fmt.Errorf("server %q: unknown route group %q (available: %s)", s.Name, g, strings.Join(router.Groups, ", ")))
+				continue
+			}
+			if other, ok := served[g]; ok {
+				errs = append(errs, fmt.Errorf("route group %q is served by both %q and %q", g, other, s.Name))
+			}
+			served[g] = s.Name
+		}
+		if s.Token == "" && (slices.Contains(s.Groups, router.GroupAdmin) || slices.Contains(s.Groups, router.GroupPprof)) {
+			errs = append(errs, fmt.Errorf("server %q serves the admin or pprof group and needs a token", s.Name))
+		}
+	}
+	return errs
+}
+
+// runCheck implements "go_students_api check [-config path]" (also spelled --check).
+// It validates everything startup would, without serving or changing anything, so a deployment
+// pipeline can stop before swapping traffic. It returns the process exit code.
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	configPath := fs.String("config", "config/local.yml", "path to config file (CONFIG_PATH env takes precedence)")
+	timeout := fs.Duration("timeout", 10*time.Second, "time limit for the database checks")
+	fs.Parse(args)
+
+	if env := os.Getenv("CONFIG_PATH"); env != "" {
+		*configPath = env
+	}
+
+	failed := false
+	report := func(name string, err error) {
+		if err != nil {
+			failed = true
+			fmt.Printf("FAIL  %s: %v\n", name, err)
+			return
+		}
+		fmt.Printf("ok    %s\n", name)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		report("config "+*configPath, err)
+		return 1
+	}
+	report("config "+*configPath, validateConfig(cfg))
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	paths := []string{cfg.StoragePath}
+	if cfg.Tenancy.Enabled {
+		paths = paths[:0]
+		for _, t := range cfg.Tenancy.Tenants {
+			paths = append(paths, t.StoragePath)
+		}
+	}
+	for _, path := range paths {
+		status, err := sqlite.InspectSchema(ctx, path)
+		if err == nil && status.Current > status.Latest {
+			err = fmt.Errorf("schema version %d is newer than this binary (%d); was it rolled back?", status.Current, status.Latest)
+		}
+		report(fmt.Sprintf("database %s (schema %d/%d)", path, status.Current, status.Latest), err)
+		if err == nil && status.Pending() > 0 {
+			fmt.Printf("      %d migration(s) will be applied at startup\n", status.Pending())
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// validateExport checks the export job's bucket settings, which only matter once it is scheduled
+func validateExport(cfg config.Export) []error {
+	var errs []error
+	if cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		errs = append(errs, errors.New("export.bucket, export.access_key_id and export.secret_access_key are required for the export job"))
+	}
+	if len(cfg.Formats) == 0 {
+		errs = append(errs, errors.New("export.formats is empty"))
+	}
+	for _, f := range cfg.Formats {
+		if !slices.Contains(export.Formats, f) {
+			errs = append(errs, fmt.Errorf("unknown export format %q (available: %s)", f, strings.Join(export.Formats, ", ")))
+		}
+	}
+	switch cfg.Encryption {
+	case "", export.EncryptionAES256, export.EncryptionKMS:
+	default:
+		errs = append(errs, fmt.Errorf("unknown export.encryption %q (want %s, %s or \"\")", cfg.Encryption, export.EncryptionAES256, export.EncryptionKMS))
+	}
+	if cfg.KMSKeyID != "" && cfg.Encryption != export.EncryptionKMS {
+		errs = append(errs, fmt.Errorf("export.kms_key_id needs export.encryption %s", export.EncryptionKMS))
+	}
+	return errs
+}
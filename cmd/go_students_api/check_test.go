@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+)
+
+func TestValidateServersNeedsATokenForOperationalGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		servers []config.Server
+		wantErr string
+	}{
+		{"api without a token", []config.Server{{Name: "public", Addr: ":8075", Groups: []string{"api", "health"}}}, ""},
+		{"admin with a token", []config.Server{{Name: "admin", Addr: "localhost:8081", Groups: []string{"admin"}, Token: "secret"}}, ""},
+		{"admin without a token", []config.Server{{Name: "admin", Addr: "localhost:8081", Groups: []string{"admin"}}}, `server "admin" serves the admin or pprof group and needs a token`},
+		{"pprof without a token", []config.Server{{Name: "metrics", Addr: "localhost:9090", Groups: []string{"health", "pprof"}}}, `server "metrics" serves the admin or pprof group and needs a token`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateServers(&config.Config{Servers: tt.servers})
+			switch {
+			case tt.wantErr == "" && len(errs) > 0:
+				t.Errorf("validateServers() = %v, want no errors", errs)
+			case tt.wantErr != "" && (len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr)):
+				t.Errorf("validateServers() = %v, want %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
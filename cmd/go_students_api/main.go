@@ -2,20 +2,54 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/concurrency"
 	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/deprecation"
+	"github.com/prashantkumbhar2002/go_students_api/internal/health"
 	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/students"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/middleware"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/router"
+	"github.com/prashantkumbhar2002/go_students_api/internal/idgen"
+	"github.com/prashantkumbhar2002/go_students_api/internal/inflight"
+	"github.com/prashantkumbhar2002/go_students_api/internal/logging"
+	"github.com/prashantkumbhar2002/go_students_api/internal/mail"
+	"github.com/prashantkumbhar2002/go_students_api/internal/profile"
+	"github.com/prashantkumbhar2002/go_students_api/internal/ratelimit"
+	"github.com/prashantkumbhar2002/go_students_api/internal/recorder"
+	"github.com/prashantkumbhar2002/go_students_api/internal/scheduler"
+	"github.com/prashantkumbhar2002/go_students_api/internal/seed"
+	"github.com/prashantkumbhar2002/go_students_api/internal/shutdown"
+	"github.com/prashantkumbhar2002/go_students_api/internal/sms"
 	"github.com/prashantkumbhar2002/go_students_api/internal/storage/sqlite"
+	"github.com/prashantkumbhar2002/go_students_api/internal/systemd"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+	"github.com/prashantkumbhar2002/go_students_api/internal/workerpool"
 )
 
 func main() {
+	// Subcommands run a one-off task and exit instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		runSeed(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && (os.Args[1] == "check" || os.Args[1] == "--check") {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+
 	// Load configuration
 	cfg := config.MustLoad()
 
@@ -24,40 +58,208 @@ func main() {
 	fmt.Printf("Storage Path: %s\n", cfg.StoragePath)
 	fmt.Printf("Server will run on: %s:%d\n", cfg.HTTPServer.Host, cfg.HTTPServer.Port)
 
-	// TODO: Initialize logger
+	// Log lines written while serving a traced request carry its trace_id and span_id
+	slog.SetDefault(slog.New(logging.NewTraceHandler(slog.NewTextHandler(os.Stderr, nil))))
 
+	// Report every config mistake at once, before anything is opened
+	if err := validateConfig(cfg); err != nil {
+		log.Fatalf("Invalid config: %v", err)
+	}
 
-	// Initialize storage (database)
-	storage, err := sqlite.NewSqlite(cfg)
-	if err != nil {
-		log.Fatalf("Error initializing SQLite storage: %v", err)
+	// Apply deployment-specific validation rules
+	validation.SetPolicy(validation.Policy{
+		MinAge:             cfg.Validation.MinAge,
+		MaxAge:             cfg.Validation.MaxAge,
+		RequireDateOfBirth: cfg.Validation.RequireDateOfBirth,
+		RequirePhone:       cfg.Validation.RequirePhone,
+	})
+	validation.SetMode(validation.Mode(cfg.Validation.Mode))
+	setIDGenerator(cfg)
+
+	// Components register their shutdown hooks as they are created; see internal/shutdown for the phases
+	hooks := shutdown.New()
+
+	// Bounded worker pool for asynchronous side effects, so bursts of writes don't spawn unbounded goroutines
+	pool := workerpool.New(cfg.WorkerPool.Workers, cfg.WorkerPool.QueueSize)
+	hooks.Register("workerpool", shutdown.PhaseDrain, pool.Shutdown)
+
+	// Notification emails are delivered on the worker pool, so they drain with it on shutdown
+	var mailer *mail.Mailer
+	if cfg.Mail.Enabled {
+		mailer = mail.New(newMailTransport(cfg), pool, cfg.Mail.MaxAttempts, cfg.Mail.RetryBackoff)
+	}
+
+	if cfg.Import.Dir != "" {
+		if err := os.MkdirAll(cfg.Import.Dir, 0o750); err != nil {
+			log.Fatalf("Error creating import directory: %v", err)
+		}
 	}
+	if cfg.Profiles.Dir != "" {
+		if err := os.MkdirAll(cfg.Profiles.Dir, 0o750); err != nil {
+			log.Fatalf("Error creating profile directory: %v", err)
+		}
+	}
+
+	// One site (database + cache + job runner) per tenant, or a single one without tenancy
+	sites := openSites(cfg, hooks, mailer)
+
+	// Handlers and scheduled jobs share one time source, so they agree on what "now" is
+	clk := clock.Real{}
 
-	log.Println("SQLite storage initialized successfully")
+	// Maintenance jobs declared in config; an unknown job name or bad schedule fails startup
+	var scheduled *scheduler.Scheduler
+	if cfg.Scheduler.Enabled {
+		scheduled = newScheduler(cfg, sites, clk)
+		hooks.Register("scheduler", shutdown.PhaseDrain, scheduled.Stop)
+		scheduled.Start()
+	}
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		var err error
+		if limiter, err = newRateLimiter(cfg); err != nil {
+			log.Fatalf("Error configuring rate limits: %v", err)
+		}
+	}
+	var inFlight *concurrency.Limiter
+	if cfg.Concurrency.Enabled {
+		var err error
+		if inFlight, err = newConcurrencyLimiter(cfg); err != nil {
+			log.Fatalf("Error configuring concurrency limits: %v", err)
+		}
+	}
+
+	// One registry across tenants and both listeners, so GET /admin/deprecations counts every call
+	var deprecations *deprecation.Registry
+	if len(cfg.Deprecations) > 0 {
+		var err error
+		if deprecations, err = newDeprecations(cfg); err != nil {
+			log.Fatalf("Error configuring deprecated routes: %v", err)
+		}
+	}
+
+	// Every request is listed while it is served, across tenants and both listeners, for GET /admin/requests
+	requests := inflight.New()
+
+	// Full request/response pairs for debugging client bugs; validateConfig keeps this to dev environments
+	var recordings *recorder.Recorder
+	if cfg.Recording.Enabled {
+		if cfg.Recording.Dir != "" {
+			if err := os.MkdirAll(cfg.Recording.Dir, 0o750); err != nil {
+				log.Fatalf("Error creating recording directory: %v", err)
+			}
+		}
+		recordings = recorder.New(cfg.Recording.Keep, cfg.Recording.MaxBodyBytes, cfg.Recording.Dir)
+		log.Printf("Recording requests (personal data masked); see /admin/recordings")
+	}
+
+	// Flipped at the start of shutdown so the readiness probe fails before the listener closes
+	readiness := &health.Readiness{}
+
+	// Sharing the public port, the operational routes need the admin token; without one they're left out
+	var adminToken string
+	if !cfg.AdminServer.Enabled && len(cfg.Servers) == 0 {
+		adminToken = cfg.AdminServer.Token
+		if adminToken == "" {
+			log.Printf("No ADMIN_TOKEN set: /admin and /jobs are not served")
+		}
+	}
 
 	// Initialize router & handlers
-	router := http.NewServeMux()
+	deps := func(s *site) router.Deps {
+		return router.Deps{
+			Store:     s.store,
+			Clock:     clk,
+			Pool:      pool,
+			Cache:     s.cache,
+			Readiness: readiness,
+			InFlight:  requests,
+			Recorder:  recordings,
+			Reports:   s.db,
+			Search:    s.search,
+			Jobs:      s.db,
+			JobRunner: s.runner,
+			Import: students.ImportOptions{
+				Dir:           cfg.Import.Dir,
+				MaxBytes:      cfg.Import.MaxBytes,
+				UploadTimeout: cfg.Import.UploadTimeout,
+			},
+			Profiles: students.ProfileOptions{
+				Renderer: profile.Renderer{Font: cfg.Profiles.Font},
+				Dir:      cfg.Profiles.Dir,
+			},
+			LoanDays:  cfg.Library.LoanDays,
+			Scheduler: scheduled,
+			Retention: s.retention,
+			Dev:       cfg.IsDev(),
 
-	router.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("This is Home page,.... It works!"))
-	})
+			SMSReports:       s.smsReports,
+			BulkConfirmAbove: cfg.BulkUpdate.ConfirmAbove,
+			WebhookKeys:      s.webhookKeys,
 
-	router.HandleFunc("POST /students", students.NewStudentHandler(storage))
-	router.HandleFunc("GET /students", students.GetStudentsListHandler(storage))
-	router.HandleFunc("GET /students/{id}", students.GetStudentHandler(storage))
+			PathNormalization: cfg.HTTPServer.PathNormalization,
 
-	router.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
-		time.Sleep(5 * time.Second)
-		w.Write([]byte("This is Slow page,.... It works!"))
-	})
+			PaginationStyle: cfg.Pagination.Style,
+			PageLimits:      types.PaginationLimits{Default: cfg.Pagination.DefaultLimit, Max: cfg.Pagination.MaxLimit},
+			StrictParams:    cfg.Pagination.Strict,
+			RateLimiter:     limiter,
+			ClientIPHeader:  cfg.RateLimit.ClientIPHeader,
+			Quota:           s.quota,
+			Concurrency:     inFlight,
+			Deprecations:    deprecations,
+			API: middleware.APIVersions{
+				Default:   cfg.API.DefaultVersion,
+				Supported: cfg.API.Versions,
+				Envelope:  cfg.API.EnvelopeVersions,
+			},
 
+			SeparateAdmin:     cfg.AdminServer.Enabled,
+			AdminToken:        adminToken,
+			ValidateResponses: cfg.Validation.ValidateResponses,
+		}
+	}
 
-	// Start HTTP server
-	server := &http.Server{
-		Addr:        fmt.Sprintf("%s:%d", cfg.HTTPServer.Host, cfg.HTTPServer.Port),
-		Handler:     router,
-		ReadTimeout: cfg.HTTPServer.Timeout,
-		IdleTimeout: cfg.HTTPServer.IdleTimeout,
+	// Open the sockets up front so bind errors fail startup immediately
+	var servers []listening
+	if len(cfg.Servers) > 0 {
+		var err error
+		servers, err = newServers(cfg, func(groups []string) http.Handler {
+			var public http.Handler
+			if slices.Contains(groups, router.GroupAPI) || slices.Contains(groups, router.GroupHealth) {
+				public = router.NewPublic(deps(sites[0]))
+			}
+			return buildHandler(cfg, sites, func(s *site) http.Handler { return router.NewGroups(deps(s), groups...) }, public)
+		})
+		if err != nil {
+			log.Fatalf("Error starting servers: %v", err)
+		}
+	} else {
+		handler := buildHandler(cfg, sites, func(s *site) http.Handler { return router.New(deps(s)) }, router.NewPublic(deps(sites[0])))
+		ln, addr, err := listen(cfg, fmt.Sprintf("%s:%d", cfg.HTTPServer.Host, cfg.HTTPServer.Port), true)
+		if err != nil {
+			log.Fatalf("Error starting server: %v", err)
+		}
+		server := &http.Server{
+			Addr:        addr,
+			Handler:     handler,
+			ReadTimeout: cfg.HTTPServer.Timeout,
+			IdleTimeout: cfg.HTTPServer.IdleTimeout,
+		}
+		servers = append(servers, listening{name: "http", server: server, ln: ln})
+
+		// Operational routes get their own listener and authentication, off the public port
+		if cfg.AdminServer.Enabled {
+			adminHandler := buildHandler(cfg, sites, func(s *site) http.Handler { return router.NewAdmin(deps(s)) }, nil)
+			adminServer, adminLn, err := newAdminServer(cfg, adminHandler)
+			if err != nil {
+				log.Fatalf("Error starting admin server: %v", err)
+			}
+			servers = append(servers, listening{name: "admin", server: adminServer, ln: adminLn})
+		}
+	}
+	// Every server stops in the same phase, within the one shutdown budget
+	for _, l := range servers {
+		hooks.Register(l.name+" server", shutdown.PhaseListeners, shutdownServer(l.server))
 	}
 
 	// Create context that listens for shutdown signals (Ctrl+C, SIGINT, SIGTERM)
@@ -65,21 +267,29 @@ func main() {
 	defer stop()
 
 	// Buffered channel to receive server errors
-	// Buffer size 1 prevents goroutine from blocking if error occurs before select
-	serverErrors := make(chan error, 1)
+	// One slot per server prevents goroutines from blocking if errors occur before select
+	serverErrors := make(chan error, len(servers))
+
+	// Start servers in goroutines so main thread can listen for shutdown signals
+	if cfg.Systemd.Enabled {
+		// The socket is bound, so connections queue up from here on even before Serve runs
+		systemd.Ready()
+		go systemd.RunWatchdog(ctx)
+	}
 
-	// Start server in goroutine so main thread can listen for shutdown signals
-	go func() {
-		log.Printf("Starting server on %s:%d", cfg.HTTPServer.Host, cfg.HTTPServer.Port)
+	for _, l := range servers {
+		go func() {
+			log.Printf("Starting %s server on %s", l.name, l.server.Addr)
 
-		err := server.ListenAndServe()
+			err := l.server.Serve(l.ln)
 
-		// Only send error if it's NOT the expected shutdown error
-		// http.ErrServerClosed is returned when Shutdown() is called - this is normal
-		if err != nil && err != http.ErrServerClosed {
-			serverErrors <- err
-		}
-	}()
+			// Only send error if it's NOT the expected shutdown error
+			// http.ErrServerClosed is returned when Shutdown() is called - this is normal
+			if err != nil && err != http.ErrServerClosed {
+				serverErrors <- fmt.Errorf("%s server: %w", l.name, err)
+			}
+		}()
+	}
 
 	// Wait for either:
 	// 1. Server error (startup failure or runtime error)
@@ -93,19 +303,180 @@ func main() {
 		// Shutdown signal received
 		log.Println("Shutdown signal received, initiating graceful shutdown...")
 
+		// Fail the readiness probe but keep serving for a while, so load balancers
+		// stop routing here before connections start being refused
+		readiness.SetDraining()
+		if cfg.Systemd.Enabled {
+			systemd.Stopping()
+		}
+		if cfg.HTTPServer.DrainDelay > 0 {
+			log.Printf("Draining for %s before closing the listener", cfg.HTTPServer.DrainDelay)
+			time.Sleep(cfg.HTTPServer.DrainDelay)
+		}
+
 		// Create a context with timeout for the shutdown process
 		// Server has shutdown timeout to finish active requests
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
 		defer cancel()
 
-		// Attempt graceful shutdown
-		// This stops accepting new requests and waits for active ones to complete
-		if err := server.Shutdown(shutdownCtx); err != nil {
-			log.Printf("Error during shutdown: %v", err)
+		// Stop the HTTP server, drain background work, then close the database - all within one budget
+		if err := hooks.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Shutdown finished with errors: %v", err)
+			return
+		}
+
+		log.Println("Server stopped gracefully")
+	}
+}
+
+// shutdownServer stops accepting new requests and waits for active ones to complete
+func shutdownServer(server *http.Server) shutdown.Hook {
+	return func(ctx context.Context) error {
+		if err := server.Shutdown(ctx); err != nil {
 			// Force close if graceful shutdown fails
 			server.Close()
+			return err
 		}
+		return nil
+	}
+}
 
-		log.Println("Server stopped gracefully")
+// newMailTransport picks the mail transport from config
+func newMailTransport(cfg *config.Config) mail.Transport {
+	switch cfg.Mail.Transport {
+	case "log":
+		return mail.LogTransport{}
+	case "smtp":
+		return &mail.SMTPTransport{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			From:     cfg.Mail.From,
+			Timeout:  cfg.Mail.Timeout,
+		}
+	}
+	log.Fatalf("Unknown mail transport %q (want smtp or log)", cfg.Mail.Transport)
+	return nil
+}
+
+// newSMSSender picks the SMS provider from config. Each tenant's provider asks for delivery
+// reports at the tenant's own callback URL, so they reach that tenant's database.
+func newSMSSender(cfg *config.Config, tenantID string) sms.Sender {
+	switch cfg.SMS.Provider {
+	case "log":
+		return sms.LogSender{}
+	case "twilio":
+		return &sms.Twilio{
+			BaseURL:        cfg.SMS.BaseURL,
+			AccountSID:     cfg.SMS.AccountSID,
+			AuthToken:      cfg.SMS.AuthToken,
+			From:           cfg.SMS.From,
+			StatusCallback: strings.ReplaceAll(cfg.SMS.StatusCallbackURL, "{tenant}", tenantID),
+			HTTP:           &http.Client{Timeout: cfg.SMS.Timeout},
+		}
 	}
+	log.Fatalf("Unknown SMS provider %q (want twilio or log)", cfg.SMS.Provider)
+	return nil
+}
+
+// newRateLimiter builds the rate limiter from config; validateConfig has already checked the rules
+func newRateLimiter(cfg *config.Config) (*ratelimit.Limiter, error) {
+	routes := make(map[string]ratelimit.Rule, len(cfg.RateLimit.Routes))
+	for _, r := range cfg.RateLimit.Routes {
+		if _, dup := routes[r.Route]; dup {
+			return nil, fmt.Errorf("route %q is listed twice", r.Route)
+		}
+		routes[r.Route] = ratelimit.Rule{Requests: r.Requests, Window: r.Window}
+	}
+	return ratelimit.New(ratelimit.Rule{Requests: cfg.RateLimit.Requests, Window: cfg.RateLimit.Window}, routes)
+}
+
+// newConcurrencyLimiter builds the concurrency limiter from config; validateConfig has already checked the rules
+func newConcurrencyLimiter(cfg *config.Config) (*concurrency.Limiter, error) {
+	routes := make(map[string]concurrency.Rule, len(cfg.Concurrency.Routes))
+	for _, r := range cfg.Concurrency.Routes {
+		if _, dup := routes[r.Route]; dup {
+			return nil, fmt.Errorf("route %q is listed twice", r.Route)
+		}
+		routes[r.Route] = concurrency.Rule{Limit: r.Limit, Weight: r.Weight}
+	}
+	return concurrency.New(cfg.Concurrency.Capacity, cfg.Concurrency.Wait, routes)
+}
+
+// newDeprecations builds the registry of deprecated routes from config; validateConfig has already checked them
+func newDeprecations(cfg *config.Config) (*deprecation.Registry, error) {
+	routes := make(map[string]deprecation.Route, len(cfg.Deprecations))
+	for _, d := range cfg.Deprecations {
+		if _, dup := routes[d.Route]; dup {
+			return nil, fmt.Errorf("route %q is listed twice", d.Route)
+		}
+		var rt deprecation.Route
+		var err error
+		if rt.Since, err = time.Parse(types.DateLayout, d.Since); err != nil {
+			return nil, fmt.Errorf("route %q: since %q is not a YYYY-MM-DD date", d.Route, d.Since)
+		}
+		if d.Sunset != "" {
+			if rt.Sunset, err = time.Parse(types.DateLayout, d.Sunset); err != nil {
+				return nil, fmt.Errorf("route %q: sunset %q is not a YYYY-MM-DD date", d.Route, d.Sunset)
+			}
+		}
+		rt.Link = d.Link
+		routes[d.Route] = rt
+	}
+	return deprecation.New(routes)
+}
+
+// setIDGenerator makes up every new record's IDs as ids.strategy says
+func setIDGenerator(cfg *config.Config) {
+	g, err := idgen.New(cfg.IDs.Strategy, cfg.IDs.Node)
+	if err != nil {
+		log.Fatalf("Error configuring IDs: %v", err)
+	}
+	idgen.SetDefault(g)
+}
+
+// runSeed implements "go_students_api seed [-config path] [-count N] [-seed S]"
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	configPath := fs.String("config", "config/local.yml", "path to config file (CONFIG_PATH env takes precedence)")
+	count := fs.Int("count", 100, "number of students to generate")
+	seedValue := fs.Int64("seed", 0, "random seed; the same non-zero seed generates the same students (0 = random)")
+	tenantID := fs.String("tenant", "", "tenant whose database to seed (required when tenancy is enabled)")
+	fs.Parse(args)
+
+	if env := os.Getenv("CONFIG_PATH"); env != "" {
+		*configPath = env
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("cannot read config: %v", err)
+	}
+
+	if cfg.Tenancy.Enabled {
+		found := false
+		for _, t := range cfg.Tenancy.Tenants {
+			if t.ID == *tenantID {
+				cfg.StoragePath, found = t.StoragePath, true
+			}
+		}
+		if !found {
+			log.Fatalf("tenancy is enabled: pass -tenant with one of the configured tenant IDs")
+		}
+	}
+
+	setIDGenerator(cfg)
+	db, err := sqlite.NewSqlite(cfg)
+	if err != nil {
+		log.Fatalf("Error initializing SQLite storage: %v", err)
+	}
+	defer db.Close()
+
+	students, err := seed.Run(context.Background(), db, *count, *seedValue)
+	if err != nil {
+		log.Fatalf("Error seeding database: %v", err)
+	}
+
+	log.Printf("Seeded %d students (ids %d-%d)", len(students), students[0].ID, students[len(students)-1].ID)
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/middleware"
+	"github.com/prashantkumbhar2002/go_students_api/internal/listener"
+	"github.com/prashantkumbhar2002/go_students_api/internal/systemd"
+)
+
+// listening is an HTTP server and the socket it serves on
+type listening struct {
+	name   string
+	server *http.Server
+	ln     net.Listener
+}
+
+// listen binds addr and returns the address actually listened on. With socketActivated, under
+// systemd socket activation the socket already exists and addr is ignored.
+func listen(cfg *config.Config, addr string, socketActivated bool) (net.Listener, string, error) {
+	if socketActivated && cfg.Systemd.Enabled {
+		ln, err := systemd.Listener()
+		if err != nil {
+			return nil, "", fmt.Errorf("using systemd socket: %w", err)
+		}
+		if ln != nil {
+			log.Printf("Using socket-activated listener on %s", ln.Addr())
+			return ln, ln.Addr().String(), nil
+		}
+	}
+	ln, err := listener.Listen(context.Background(), addr, listener.Options{ReusePort: cfg.HTTPServer.ReusePort})
+	if err != nil {
+		return nil, "", fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	return ln, addr, nil
+}
+
+// newServers binds a listener for each of cfg.Servers, serving the handler for its route groups
+// behind its token. The first takes the systemd socket when there is one. On error the listeners
+// already bound are closed.
+func newServers(cfg *config.Config, handler func(groups []string) http.Handler) ([]listening, error) {
+	servers := make([]listening, 0, len(cfg.Servers))
+	for i, s := range cfg.Servers {
+		ln, addr, err := listen(cfg, s.Addr, i == 0)
+		if err != nil {
+			for _, l := range servers {
+				l.ln.Close()
+			}
+			return nil, fmt.Errorf("server %q: %w", s.Name, err)
+		}
+
+		h := handler(s.Groups)
+		if s.Token != "" {
+			h = middleware.Chain(h, middleware.RequireBearerToken(s.Token))
+		}
+		servers = append(servers, listening{
+			name: s.Name,
+			server: &http.Server{
+				Addr:        addr,
+				Handler:     h,
+				ReadTimeout: cmp.Or(s.Timeout, cfg.HTTPServer.Timeout),
+				IdleTimeout: cmp.Or(s.IdleTimeout, cfg.HTTPServer.IdleTimeout),
+			},
+			ln: ln,
+		})
+	}
+	return servers, nil
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"net/http"
+	"path/filepath"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/announce"
+	"github.com/prashantkumbhar2002/go_students_api/internal/backup"
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/events"
+	"github.com/prashantkumbhar2002/go_students_api/internal/export"
+	"github.com/prashantkumbhar2002/go_students_api/internal/importer"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/mail"
+	"github.com/prashantkumbhar2002/go_students_api/internal/profile"
+	"github.com/prashantkumbhar2002/go_students_api/internal/quota"
+	"github.com/prashantkumbhar2002/go_students_api/internal/retention"
+	"github.com/prashantkumbhar2002/go_students_api/internal/scheduler"
+	"github.com/prashantkumbhar2002/go_students_api/internal/search"
+	"github.com/prashantkumbhar2002/go_students_api/internal/shutdown"
+	"github.com/prashantkumbhar2002/go_students_api/internal/sms"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/cache"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/sqlite"
+	"github.com/prashantkumbhar2002/go_students_api/internal/tenant"
+	"github.com/prashantkumbhar2002/go_students_api/internal/webhooks"
+)
+
+// site is everything tied to one database: the SQLite handle, the storage stack handlers use,
+// and the job runner draining that database's jobs table. Without tenancy there is exactly one
+// site; with tenancy every tenant gets its own, so their data never mixes.
+type site struct {
+	// tenant is the tenant ID, or "" when tenancy is disabled
+	tenant string
+	db     *sqlite.Sqlite
+	// store is db wrapped in its decorators; handlers only ever see this
+	store     storage.Storage
+	cache     *cache.Cache
+	runner    *jobs.Runner
+	retention *retention.Engine
+	// search serves GET /students/search: OpenSearch with SQL fallback, or SQL alone
+	search storage.Searcher
+	// events announces the writes made through store; side effects subscribe to it
+	events *events.Bus
+	// texter sends announcements by SMS, and smsReports reads the provider's delivery reports;
+	// nil when SMS (or its status callback) is not configured
+	texter     sms.Sender
+	smsReports sms.Receiver
+	// webhookKeys are the API keys that may subscribe to this site's webhooks; nil when webhooks
+	// are disabled
+	webhookKeys []string
+	// quota counts the site's clients' requests per month; nil when quotas are disabled
+	quota *quota.Meter
+}
+
+// openSites opens every configured database and registers their shutdown hooks. mailer may be nil.
+// validateConfig has already checked the tenant list.
+func openSites(cfg *config.Config, hooks *shutdown.Manager, mailer *mail.Mailer) []*site {
+	if !cfg.Tenancy.Enabled {
+		return []*site{openSite(cfg, config.Tenant{StoragePath: cfg.StoragePath, APIKeys: cfg.Webhooks.APIKeys}, hooks, mailer)}
+	}
+
+	sites := make([]*site, 0, len(cfg.Tenancy.Tenants))
+	for _, t := range cfg.Tenancy.Tenants {
+		sites = append(sites, openSite(cfg, t, hooks, mailer))
+	}
+	return sites
+}
+
+// openSite opens tenant t's site; without tenancy t has no ID. t's API keys identify its
+// clients, which may subscribe to webhooks and each have a monthly quota.
+func openSite(cfg *config.Config, t config.Tenant, hooks *shutdown.Manager, mailer *mail.Mailer) *site {
+	tenantID, storagePath, apiKeys := t.ID, t.StoragePath, t.APIKeys
+
+	// Hook names say which tenant a slow or failing shutdown step belongs to
+	suffix := ""
+	if tenantID != "" {
+		suffix = ":" + tenantID
+	}
+
+	siteCfg := *cfg
+	siteCfg.StoragePath = storagePath
+	db, err := sqlite.NewSqlite(&siteCfg)
+	if err != nil {
+		log.Fatalf("Error initializing SQLite storage %s: %v", storagePath, err)
+	}
+	log.Printf("SQLite storage initialized successfully: %s", storagePath)
+	hooks.Register("sqlite"+suffix, shutdown.PhaseClose, func(context.Context) error { return db.Close() })
+
+	s := &site{tenant: tenantID, db: db, store: db, events: events.New()}
+	// Every event is numbered and kept for GET /events before any subscriber hears of it; writes
+	// through s.store append theirs in their own transaction (see events.Store)
+	s.events.Log = db
+
+	// Handlers depend on the storage interface, so decorators can be layered on transparently
+	if cfg.Cache.Enabled {
+		s.cache = cache.New(s.store, cfg.Cache.TTL, cfg.Cache.MaxPages)
+		s.cache.CountTTL = cfg.Cache.CountTTL
+		s.store = s.cache
+		// The cache sees writes made through it, but inside the outbox's transaction: hearing of
+		// them again once they commit keeps a read in between from caching what they replaced.
+		// Bulk changes made underneath it arrive as an event too.
+		s.events.Subscribe("cache", func(context.Context, events.Event) { s.cache.Invalidate() },
+			events.StudentCreated, events.StudentUpdated, events.StudentDeleted, events.StudentGraduated, events.StudentsChanged)
+	}
+	// Outermost, so every write through the stack is announced once it has succeeded
+	s.store = events.NewStore(s.store, s.events)
+	if mailer != nil {
+		mailer.Subscribe(s.events)
+	}
+
+	// Persistent background jobs; queued jobs survive restarts and resume here
+	s.runner = jobs.New(db, jobs.Options{
+		Workers:      cfg.JobQueue.Workers,
+		PollInterval: cfg.JobQueue.PollInterval,
+		Lease:        cfg.JobQueue.Lease,
+		MaxAttempts:  cfg.JobQueue.MaxAttempts,
+		BackoffBase:  cfg.JobQueue.BackoffBase,
+		BackoffMax:   cfg.JobQueue.BackoffMax,
+	})
+
+	// Writes are mirrored into the search index by jobs, so they retry while the cluster is down
+	s.search = db
+	var client *search.Client
+	if cfg.Search.Enabled {
+		client = &search.Client{
+			URL:      cfg.Search.URL,
+			Index:    cfg.Search.Index,
+			Username: cfg.Search.Username,
+			Password: cfg.Search.Password,
+			HTTP:     &http.Client{Timeout: cfg.Search.Timeout},
+		}
+		if tenantID != "" {
+			client.Index += "-" + tenantID
+		}
+		s.runner.Register(search.KindIndex, search.IndexHandler(client, db, nil))
+		s.runner.Register(search.KindReindex, search.ReindexHandler(client, db, nil))
+		search.Subscribe(s.events, s.runner)
+		s.search = search.Fallback{Primary: client, Secondary: db}
+	}
+
+	s.runner.Register(importer.Kind, importer.Handler(s.store, nil, cfg.Import.MaxRows))
+	if cfg.SMS.Enabled {
+		s.texter = newSMSSender(cfg, tenantID)
+		if r, ok := s.texter.(sms.Receiver); ok && cfg.SMS.StatusCallbackURL != "" {
+			s.smsReports = r
+		}
+	}
+	// Announcements are sent by jobs, so deliveries still queued resume after a restart. Without
+	// mail or SMS they stay queued.
+	if mailer != nil || s.texter != nil {
+		var sender announce.Sender
+		if mailer != nil {
+			sender = mailer
+		}
+		s.runner.Register(announce.Kind, announce.Handler(db, sender, s.texter))
+		announce.Subscribe(s.events, s.runner)
+	}
+	s.runner.Register(profile.Kind, profile.Handler(s.store, profile.Renderer{Font: cfg.Profiles.Font}))
+	// Webhook deliveries are jobs too, so a receiver that is down gets its events once it is back
+	if cfg.Webhooks.Enabled {
+		s.webhookKeys = apiKeys
+		s.runner.Register(webhooks.Kind, webhooks.Handler(db, &http.Client{Timeout: cfg.Webhooks.Timeout}))
+		webhooks.Subscribe(s.events, db, s.runner)
+	}
+	// Requests are counted in the site's own database, so each tenant's clients have their own
+	if cfg.Quota.Enabled {
+		s.quota = &quota.Meter{Store: db, Limit: cmp.Or(t.MonthlyQuota, cfg.Quota.Requests), Keys: apiKeys}
+	}
+	hooks.Register("job runner"+suffix, shutdown.PhaseDrain, s.runner.Stop)
+	s.runner.Start()
+
+	// A new (or deleted) index, or one from an older version, is filled from the database; an unreachable cluster only
+	// degrades search, so it doesn't stop startup
+	if client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Search.Timeout)
+		created, err := client.EnsureIndex(ctx)
+		cancel()
+		switch {
+		case err != nil:
+			log.Printf("Search index %s unavailable, falling back to SQL search: %v", client.Index, err)
+		case created:
+			log.Printf("Search index %s created or upgraded, queueing a full reindex", client.Index)
+			search.Reindex(context.Background(), s.runner)
+		}
+	}
+
+	s.retention = retention.New(db, retentionRules(cfg))
+
+	return s
+}
+
+func retentionRules(cfg *config.Config) []retention.Rule {
+	rules := make([]retention.Rule, 0, len(cfg.Retention.Rules))
+	for _, r := range cfg.Retention.Rules {
+		rules = append(rules, retention.Rule{
+			Name:          r.Name,
+			Target:        r.Target,
+			Action:        r.Action,
+			OlderThanDays: r.OlderThanDays,
+		})
+	}
+	return rules
+}
+
+// buildHandler returns build's handler for the only site, or a tenant dispatcher over all of them
+func buildHandler(cfg *config.Config, sites []*site, build func(*site) http.Handler, public http.Handler) http.Handler {
+	if !cfg.Tenancy.Enabled {
+		return build(sites[0])
+	}
+	return newTenantHandler(cfg, sites, build, public)
+}
+
+// newTenantHandler builds one router per tenant behind a dispatcher that resolves the tenant.
+// public serves tenant-less requests to "/" and "/readyz"; nil rejects them like any other.
+func newTenantHandler(cfg *config.Config, sites []*site, build func(*site) http.Handler, public http.Handler) http.Handler {
+	h := &tenant.Handler{
+		Resolver: tenant.Resolver{
+			Header:     cfg.Tenancy.Header,
+			BaseDomain: cfg.Tenancy.BaseDomain,
+			APIKeys:    make(map[string]string),
+		},
+		Tenants: make(map[string]http.Handler, len(sites)),
+		// Health checks and the home page don't name a tenant
+		Public:      public,
+		PublicPaths: []string{"/", "/readyz"},
+	}
+
+	for _, t := range cfg.Tenancy.Tenants {
+		for _, key := range t.APIKeys {
+			h.Resolver.APIKeys[key] = t.ID
+		}
+	}
+	for _, s := range sites {
+		h.Tenants[s.tenant] = build(s)
+	}
+	return h
+}
+
+// builtinJobs are the job names newScheduler knows
+var builtinJobs = []string{"backup", "optimize", "retention", "overdue_loans", "export"}
+
+// newScheduler registers the jobs listed in config. Only built-in jobs can be scheduled;
+// config picks which ones run and when. Each job covers every site, and takes the time it stamps
+// backups and exports with, or checks loans against, from clk.
+func newScheduler(cfg *config.Config, sites []*site, clk clock.Clock) *scheduler.Scheduler {
+	bucket := &export.S3{
+		Endpoint:        cfg.Export.Endpoint,
+		Region:          cfg.Export.Region,
+		Bucket:          cfg.Export.Bucket,
+		PathStyle:       cfg.Export.PathStyle,
+		AccessKeyID:     cfg.Export.AccessKeyID,
+		SecretAccessKey: cfg.Export.SecretAccessKey,
+		Encryption:      cfg.Export.Encryption,
+		KMSKeyID:        cfg.Export.KMSKeyID,
+		HTTP:            &http.Client{Timeout: cfg.Export.Timeout},
+	}
+	builtin := map[string]scheduler.Task{
+		// Nightly snapshot of each database, keeping the newest backup_keep files.
+		// Tenants get a subdirectory each so pruning one never touches another's backups.
+		"backup": forEachSite(sites, func(ctx context.Context, s *site) error {
+			dir := cfg.Scheduler.BackupDir
+			if s.tenant != "" {
+				dir = filepath.Join(dir, s.tenant)
+			}
+			path, err := backup.Run(ctx, s.db, dir, cfg.Scheduler.BackupKeep, clk.Now())
+			if err == nil {
+				log.Printf("Database backed up to %s", path)
+			}
+			return err
+		}),
+		// Refresh query planner statistics
+		"optimize": forEachSite(sites, func(ctx context.Context, s *site) error {
+			return s.db.Optimize(ctx)
+		}),
+		// Apply the retention rules for real; GET /admin/retention shows what this will do
+		"retention": forEachSite(sites, func(ctx context.Context, s *site) error {
+			report, err := s.retention.Run(ctx, false)
+			// The rows changed underneath the cache and the search index, even if only some rules succeeded
+			if studentsChanged(report) {
+				s.events.Publish(ctx, events.Event{Kind: events.StudentsChanged})
+			}
+			return err
+		}),
+		// Announce loans past their due date; the mailer sends each borrower a reminder
+		"overdue_loans": forEachSite(sites, func(ctx context.Context, s *site) error {
+			loans, err := s.db.OverdueLoans(ctx, clk.Now())
+			if err != nil || len(loans) == 0 {
+				return err
+			}
+			log.Printf("%d library loans overdue", len(loans))
+			s.events.Publish(ctx, events.Event{Kind: events.LoanOverdue, Loans: loans})
+			return nil
+		}),
+		// Full dumps of the students for the data warehouse, uploaded to the export bucket.
+		// Tenants get a prefix each, like their backups.
+		"export": forEachSite(sites, func(ctx context.Context, s *site) error {
+			prefix := cfg.Export.Prefix
+			if s.tenant != "" {
+				prefix += s.tenant + "/"
+			}
+			keys, err := export.Run(ctx, s.db, bucket, prefix, cfg.Export.Formats, clk.Now())
+			for _, key := range keys {
+				log.Printf("Students exported to s3://%s/%s", cfg.Export.Bucket, key)
+			}
+			return err
+		}),
+	}
+
+	// validateConfig has checked the names and schedules
+	sch := scheduler.New()
+	sch.Clock = clk
+	for _, j := range cfg.Scheduler.Jobs {
+		sch.Add(j.Name, j.Schedule, builtin[j.Name])
+	}
+	return sch
+}
+
+// studentsChanged reports whether a retention run anonymized or deleted any student
+func studentsChanged(report retention.Report) bool {
+	for _, r := range report.Rules {
+		if r.Target == retention.TargetStudents && r.Affected > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// forEachSite runs fn for every site. One site failing doesn't skip the others; the first error is reported.
+func forEachSite(sites []*site, fn func(context.Context, *site) error) scheduler.Task {
+	return func(ctx context.Context) error {
+		var firstErr error
+		for _, s := range sites {
+			if err := fn(ctx, s); err != nil {
+				if s.tenant != "" {
+					log.Printf("Scheduled job failed for tenant %s: %v", s.tenant, err)
+				}
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		return firstErr
+	}
+}
@@ -0,0 +1,181 @@
+// Command loadtest drives a configurable mix of create/read requests against a running
+// Students API instance and reports latency percentiles and error rates per operation.
+//
+//	go run ./cmd/loadtest -url http://localhost:8075 -duration 30s -concurrency 16 -create-ratio 0.2
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+type op string
+
+const (
+	opCreate op = "create"
+	opGet    op = "get"
+	opList   op = "list"
+)
+
+// result is the outcome of one request
+type result struct {
+	op      op
+	latency time.Duration
+	failed  bool
+}
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8075", "base URL of the running API")
+	duration := flag.Duration("duration", 10*time.Second, "how long to generate load")
+	concurrency := flag.Int("concurrency", 8, "number of concurrent clients")
+	createRatio := flag.Float64("create-ratio", 0.2, "fraction of requests that create a student (0..1)")
+	listRatio := flag.Float64("list-ratio", 0.3, "fraction of reads that hit the list endpoint instead of a single student")
+	flag.Parse()
+
+	if *createRatio < 0 || *createRatio > 1 || *listRatio < 0 || *listRatio > 1 {
+		log.Fatal("ratios must be between 0 and 1")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	// Students are addressed by random UUIDs, so reads pick from the ones this run created
+	known := &idPool{}
+
+	results := make(chan result, *concurrency*64)
+	deadline := time.Now().Add(*duration)
+
+	var wg sync.WaitGroup
+	for range *concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				switch {
+				case rand.Float64() < *createRatio || known.len() == 0:
+					results <- create(client, *baseURL, known)
+				case rand.Float64() < *listRatio:
+					results <- get(client, opList, fmt.Sprintf("%s/students?page=%d", *baseURL, rand.IntN(5)+1))
+				default:
+					results <- get(client, opGet, fmt.Sprintf("%s/students/%s", *baseURL, known.random()))
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	latencies := make(map[op][]time.Duration)
+	failures := make(map[op]int)
+	for r := range results {
+		latencies[r.op] = append(latencies[r.op], r.latency)
+		if r.failed {
+			failures[r.op]++
+		}
+	}
+
+	report(os.Stdout, *duration, latencies, failures)
+}
+
+// idPool holds the IDs of students created so far
+type idPool struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (p *idPool) add(id string) {
+	p.mu.Lock()
+	p.ids = append(p.ids, id)
+	p.mu.Unlock()
+}
+
+func (p *idPool) len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.ids)
+}
+
+func (p *idPool) random() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ids[rand.IntN(len(p.ids))]
+}
+
+func create(client *http.Client, baseURL string, known *idPool) result {
+	n := rand.IntN(1_000_000)
+	body, _ := json.Marshal(map[string]any{
+		"name":  fmt.Sprintf("Load Test %d", n),
+		"email": fmt.Sprintf("loadtest%d@example.com", n),
+		"age":   18 + rand.IntN(40),
+	})
+
+	start := time.Now()
+	resp, err := client.Post(baseURL+"/students", "application/json", bytes.NewReader(body))
+	latency := time.Since(start)
+	if err != nil {
+		return result{op: opCreate, latency: latency, failed: true}
+	}
+	defer resp.Body.Close()
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if resp.StatusCode != http.StatusCreated || json.NewDecoder(resp.Body).Decode(&created) != nil {
+		return result{op: opCreate, latency: latency, failed: true}
+	}
+
+	known.add(created.ID)
+	return result{op: opCreate, latency: latency}
+}
+
+func get(client *http.Client, kind op, url string) result {
+	start := time.Now()
+	resp, err := client.Get(url)
+	if err != nil {
+		return result{op: kind, latency: time.Since(start), failed: true}
+	}
+	// Read the full body so latency includes transfer and the connection is reused
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return result{op: kind, latency: time.Since(start), failed: resp.StatusCode >= 400}
+}
+
+func report(out io.Writer, duration time.Duration, latencies map[op][]time.Duration, failures map[op]int) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\trequests\trps\terrors\terror %\tp50\tp90\tp99\tmax\t")
+
+	for _, kind := range []op{opCreate, opGet, opList} {
+		lat := latencies[kind]
+		if len(lat) == 0 {
+			continue
+		}
+		slices.Sort(lat)
+		errRate := 100 * float64(failures[kind]) / float64(len(lat))
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%.2f\t%v\t%v\t%v\t%v\t\n",
+			kind, len(lat), float64(len(lat))/duration.Seconds(), failures[kind], errRate,
+			percentile(lat, 50), percentile(lat, 90), percentile(lat, 99), lat[len(lat)-1].Round(time.Microsecond))
+	}
+	tw.Flush()
+}
+
+// percentile returns the p-th percentile of sorted latencies (nearest-rank method)
+func percentile(sorted []time.Duration, p int) time.Duration {
+	idx := (p*len(sorted) + 99) / 100
+	if idx < 1 {
+		idx = 1
+	}
+	return sorted[idx-1].Round(time.Microsecond)
+}
@@ -0,0 +1,124 @@
+// Package announce emails, or texts, announcements to the students they were posted to.
+//
+// Posting an announcement (POST /announcements) stores a queued delivery for every recipient and
+// publishes AnnouncementPosted. Subscribe turns that into a job, and the job emails or texts the
+// recipients one at a time, recording whether each message went out. A retried job only sends the
+// deliveries still queued, so nobody gets the same announcement twice. Text messages are
+// delivered, or not, after the job is done; the SMS provider's reports record which (see
+// storage.Announcements.ReportDelivery).
+package announce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/events"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/mail"
+	"github.com/prashantkumbhar2002/go_students_api/internal/sms"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Kind is the job kind announcement deliveries are enqueued under
+const Kind = "announcement"
+
+// Payload is the payload of a Kind job
+type Payload struct {
+	AnnouncementID int64  `json:"announcement_id"`
+	PublicID       string `json:"public_id"`
+}
+
+// Result is stored as the job's result
+type Result struct {
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+}
+
+// Enqueuer is the part of jobs.Runner Subscribe needs
+type Enqueuer interface {
+	Enqueue(ctx context.Context, kind string, payload any) (int64, error)
+}
+
+// Sender sends one templated email now and reports whether it went out; *mail.Mailer is one
+type Sender interface {
+	Deliver(ctx context.Context, to, templateName string, data any) error
+}
+
+// Subscribe queues a delivery job for every announcement posted on bus
+func Subscribe(bus events.Subscriber, q Enqueuer) {
+	bus.Subscribe("announcements", func(ctx context.Context, e events.Event) {
+		for _, a := range e.Announcements {
+			if _, err := q.Enqueue(ctx, Kind, Payload{AnnouncementID: a.ID, PublicID: a.PublicID}); err != nil {
+				// The deliveries stay queued and show up as such on the announcement
+				slog.Error("Error queueing announcement delivery", "id", a.PublicID, "error", err)
+			}
+		}
+	}, events.AnnouncementPosted)
+}
+
+// errNoPhone fails the text message to a student without a phone number
+var errNoPhone = errors.New("student has no phone number")
+
+// message is the data of mail.TemplateAnnouncement
+type message struct {
+	Name    string
+	Subject string
+	Body    string
+}
+
+// Handler returns the job handler for Kind jobs. sender emails and texter texts; either may be
+// nil, and announcements on that channel then fail the job for good, staying queued until a
+// deployment configures it. A delivery the mailer or SMS provider refuses is recorded as failed
+// with the reason; the job itself only fails (and is retried) on a database error or when the
+// runner stops, leaving the rest queued.
+func Handler(store storage.Announcements, sender Sender, texter sms.Sender) jobs.Handler {
+	return func(ctx context.Context, job types.Job) (any, error) {
+		var p Payload
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+		a, err := store.GetAnnouncementByPublicID(ctx, p.PublicID)
+		if err != nil {
+			return nil, err
+		}
+		if (a.Channel == types.ChannelSMS && texter == nil) || (a.Channel != types.ChannelSMS && sender == nil) {
+			return nil, jobs.Permanent(fmt.Errorf("no %s sender is configured", a.Channel))
+		}
+		pending, err := store.PendingDeliveries(ctx, p.AnnouncementID)
+		if err != nil {
+			return nil, err
+		}
+
+		var result Result
+		for _, d := range pending {
+			var err error
+			switch {
+			case a.Channel != types.ChannelSMS:
+				err = sender.Deliver(ctx, d.Email, mail.TemplateAnnouncement, message{Name: d.Name, Subject: a.Subject, Body: a.Body})
+			case d.Phone == "":
+				err = errNoPhone
+			default:
+				d.MessageID, err = texter.Send(ctx, d.Phone, a.Subject+"\n\n"+a.Body)
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			d.Status, d.Error = types.DeliverySent, ""
+			if err != nil {
+				d.Status, d.Error = types.DeliveryFailed, err.Error()
+				result.Failed++
+			} else {
+				result.Sent++
+			}
+			if err := store.SetDeliveryStatus(ctx, d); err != nil {
+				return nil, err
+			}
+		}
+		slog.Info("Announcement delivered", "id", a.PublicID, "job_id", job.ID, "sent", result.Sent, "failed", result.Failed)
+		return result, nil
+	}
+}
@@ -0,0 +1,124 @@
+package announce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/events"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+type fakeQueue []Payload
+
+func (q *fakeQueue) Enqueue(ctx context.Context, kind string, payload any) (int64, error) {
+	*q = append(*q, payload.(Payload))
+	return int64(len(*q)), nil
+}
+
+// fakeSender records who was emailed and refuses the addresses in bounce
+type fakeSender struct {
+	sent   []string
+	bounce map[string]bool
+}
+
+func (s *fakeSender) Deliver(ctx context.Context, to, templateName string, data any) error {
+	if s.bounce[to] {
+		return errors.New("mailbox unavailable")
+	}
+	s.sent = append(s.sent, to)
+	return nil
+}
+
+// fakeTexter records who was texted, numbering the messages
+type fakeTexter struct{ sent []string }
+
+func (s *fakeTexter) Send(ctx context.Context, to, body string) (string, error) {
+	s.sent = append(s.sent, to)
+	return fmt.Sprintf("SM%d", len(s.sent)), nil
+}
+
+func TestDeliversAnnouncementOnce(t *testing.T) {
+	ctx := context.Background()
+	store := storagetest.NewFake()
+	for _, email := range []string{"asha@example.com", "ravi@example.com", "meera@example.com"} {
+		store.Put(types.Student{Name: "Student", Email: email, Age: 20})
+	}
+
+	bus := events.New()
+	var q fakeQueue
+	Subscribe(bus, &q)
+	posted, err := events.NewStore(store, bus).PostAnnouncement(ctx, types.Announcement{Subject: "Holiday", Body: "Closed on Friday."}, nil)
+	if err != nil {
+		t.Fatalf("PostAnnouncement: %v", err)
+	}
+	if len(q) != 1 || q[0].PublicID != posted.PublicID {
+		t.Fatalf("queued %+v, want one job for %s", q, posted.PublicID)
+	}
+
+	sender := &fakeSender{bounce: map[string]bool{"ravi@example.com": true}}
+	payload, _ := json.Marshal(q[0])
+	run := Handler(store, sender, nil)
+	result, err := run(ctx, types.Job{ID: 1, Kind: Kind, Payload: payload})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if r := result.(Result); r.Sent != 2 || r.Failed != 1 {
+		t.Errorf("result = %+v, want 2 sent and 1 failed", r)
+	}
+
+	got, err := store.GetAnnouncementByPublicID(ctx, posted.PublicID)
+	if err != nil || got.Deliveries[types.DeliverySent] != 2 || got.Deliveries[types.DeliveryFailed] != 1 {
+		t.Errorf("deliveries = %+v, %v", got.Deliveries, err)
+	}
+
+	// A retry sends nothing already tried
+	if _, err := run(ctx, types.Job{ID: 1, Kind: Kind, Payload: payload}); err != nil {
+		t.Fatalf("handler retry: %v", err)
+	}
+	if len(sender.sent) != 2 {
+		t.Errorf("sent %v, want each address once", sender.sent)
+	}
+}
+
+func TestTextsAnnouncement(t *testing.T) {
+	ctx := context.Background()
+	store := storagetest.NewFake()
+	store.Put(types.Student{Name: "Asha", Email: "asha@example.com", Age: 20, Phone: "+919812345678"})
+	store.Put(types.Student{Name: "Ravi", Email: "ravi@example.com", Age: 20})
+	posted, err := store.PostAnnouncement(ctx, types.Announcement{Subject: "Bus", Body: "Route 4 is late.", Channel: types.ChannelSMS}, nil)
+	if err != nil {
+		t.Fatalf("PostAnnouncement: %v", err)
+	}
+	payload, _ := json.Marshal(Payload{AnnouncementID: posted.ID, PublicID: posted.PublicID})
+	job := types.Job{ID: 1, Kind: Kind, Payload: payload}
+
+	// Without an SMS provider the job fails for good and the deliveries stay queued
+	if _, err := Handler(store, &fakeSender{}, nil)(ctx, job); err == nil {
+		t.Fatal("handler without texter succeeded")
+	}
+	if got, _ := store.GetAnnouncementByPublicID(ctx, posted.PublicID); got.Deliveries[types.DeliveryQueued] != 2 {
+		t.Fatalf("deliveries = %+v, want both still queued", got.Deliveries)
+	}
+
+	texter := &fakeTexter{}
+	result, err := Handler(store, nil, texter)(ctx, job)
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if r := result.(Result); r.Sent != 1 || r.Failed != 1 || len(texter.sent) != 1 || texter.sent[0] != "+919812345678" {
+		t.Errorf("result = %+v, texted %v, want Asha texted and Ravi failed", r, texter.sent)
+	}
+
+	// The message ID is kept for the provider's delivery report
+	if err := store.ReportDelivery(ctx, "SM1", types.DeliveryDelivered, ""); err != nil {
+		t.Errorf("ReportDelivery: %v", err)
+	}
+	got, err := store.GetAnnouncementByPublicID(ctx, posted.PublicID)
+	if err != nil || got.Deliveries[types.DeliveryDelivered] != 1 || got.Deliveries[types.DeliveryFailed] != 1 {
+		t.Errorf("deliveries = %+v, %v", got.Deliveries, err)
+	}
+}
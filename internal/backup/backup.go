@@ -0,0 +1,74 @@
+// Package backup writes timestamped database snapshots to a directory and prunes old ones.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	filePrefix = "students-"
+	fileSuffix = ".db"
+	// timeLayout sorts lexically in chronological order, which pruning relies on
+	timeLayout = "20060102T150405Z"
+)
+
+// Snapshotter can write a consistent copy of its database to a new file
+type Snapshotter interface {
+	Backup(ctx context.Context, path string) error
+}
+
+// Run snapshots db into dir as students-<UTC timestamp>.db and then deletes all but the newest
+// keep snapshots (keep <= 0 keeps everything). It returns the path of the new snapshot.
+func Run(ctx context.Context, db Snapshotter, dir string, keep int, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("create backup dir: %w", err)
+	}
+
+	path := filepath.Join(dir, filePrefix+now.UTC().Format(timeLayout)+fileSuffix)
+	if err := db.Backup(ctx, path); err != nil {
+		// Don't leave a partial file behind for pruning to count as a good snapshot
+		os.Remove(path)
+		return "", err
+	}
+
+	if keep > 0 {
+		if err := prune(dir, keep); err != nil {
+			// The new snapshot is fine; failing to delete old ones shouldn't fail the backup
+			slog.Warn("Error pruning old backups", "dir", dir, "error", err)
+		}
+	}
+	return path, nil
+}
+
+func prune(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var snapshots []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) {
+			snapshots = append(snapshots, name)
+		}
+	}
+	if len(snapshots) <= keep {
+		return nil
+	}
+
+	sort.Strings(snapshots)
+	for _, name := range snapshots[:len(snapshots)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
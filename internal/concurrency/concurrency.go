@@ -0,0 +1,135 @@
+// Package concurrency caps how many requests are in flight at once, so one heavy endpoint can't
+// starve the rest of a small SQLite instance.
+//
+// Every request takes a weight (1 unless its route says otherwise) from one shared capacity. Routes
+// can also declare a limit of their own, such as 2 concurrent imports: their requests need a slot
+// in both. Rules are matched with the same patterns the router uses ("POST /students/import").
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// Rule caps one route at Limit requests in flight, each taking Weight from the shared capacity
+type Rule struct {
+	Limit  int64
+	Weight int64 // 0 means 1
+}
+
+// Errors returned by Acquire when no slot frees up in time
+var (
+	// ErrRouteBusy means the route already has its limit of requests in flight
+	ErrRouteBusy = errors.New("too many concurrent requests for this route")
+	// ErrBusy means the server as a whole is at capacity
+	ErrBusy = errors.New("server at capacity")
+)
+
+// Limiter holds the semaphores
+type Limiter struct {
+	wait   time.Duration
+	shared *semaphore.Weighted
+	routes map[string]*route
+	mux    *http.ServeMux
+}
+
+type route struct {
+	sem    *semaphore.Weighted
+	weight int64
+}
+
+// New returns a limiter with capacity shared by all routes, plus the routes' own limits. A request
+// waits up to wait for a slot (0 = not at all). It fails on an invalid rule, a weight larger than
+// the capacity, or a route that isn't a valid, unique ServeMux pattern.
+func New(capacity int64, wait time.Duration, routes map[string]Rule) (l *Limiter, err error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("capacity must be positive, got %d", capacity)
+	}
+	if wait < 0 {
+		return nil, fmt.Errorf("wait must not be negative, got %s", wait)
+	}
+	l = &Limiter{wait: wait, shared: semaphore.NewWeighted(capacity), routes: make(map[string]*route), mux: http.NewServeMux()}
+
+	// ServeMux panics on malformed or conflicting patterns
+	defer func() {
+		if rec := recover(); rec != nil {
+			l, err = nil, fmt.Errorf("%v", rec)
+		}
+	}()
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for pattern, rule := range routes {
+		if pattern == "" {
+			return nil, errors.New("route pattern is empty")
+		}
+		if rule.Weight == 0 {
+			rule.Weight = 1
+		}
+		if rule.Limit <= 0 || rule.Weight < 0 {
+			return nil, fmt.Errorf("route %q: limit must be positive and weight not negative, got %d and %d", pattern, rule.Limit, rule.Weight)
+		}
+		if rule.Weight > capacity {
+			return nil, fmt.Errorf("route %q: weight %d exceeds capacity %d", pattern, rule.Weight, capacity)
+		}
+		l.mux.Handle(pattern, noop)
+		l.routes[pattern] = &route{sem: semaphore.NewWeighted(rule.Limit), weight: rule.Weight}
+	}
+	return l, nil
+}
+
+// Acquire takes the slots r needs, waiting as configured. On success the caller must call release
+// once the request is done; otherwise the error is ErrRouteBusy, ErrBusy or the request's own
+// context error.
+func (l *Limiter) Acquire(r *http.Request) (release func(), err error) {
+	rt := &route{weight: 1}
+	if _, pattern := l.mux.Handler(r); pattern != "" {
+		rt = l.routes[pattern]
+	}
+
+	ctx := r.Context()
+	if l.wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.wait)
+		defer cancel()
+	}
+
+	if rt.sem != nil {
+		if err := l.acquire(ctx, r.Context(), rt.sem, 1, ErrRouteBusy); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.acquire(ctx, r.Context(), l.shared, rt.weight, ErrBusy); err != nil {
+		if rt.sem != nil {
+			rt.sem.Release(1)
+		}
+		return nil, err
+	}
+	return func() {
+		l.shared.Release(rt.weight)
+		if rt.sem != nil {
+			rt.sem.Release(1)
+		}
+	}, nil
+}
+
+// acquire takes n from sem, failing with busy if the wait runs out first. reqCtx tells a client
+// that went away apart from a wait that timed out.
+func (l *Limiter) acquire(ctx, reqCtx context.Context, sem *semaphore.Weighted, n int64, busy error) error {
+	if l.wait == 0 {
+		if !sem.TryAcquire(n) {
+			return busy
+		}
+		return nil
+	}
+	if err := sem.Acquire(ctx, n); err != nil {
+		if reqCtx.Err() != nil {
+			return reqCtx.Err()
+		}
+		return busy
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiterRoutesAndCapacity(t *testing.T) {
+	l, err := New(4, 0, map[string]Rule{
+		"POST /students/import": {Limit: 2, Weight: 2},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	list := httptest.NewRequest("GET", "/students", nil)
+	imp := httptest.NewRequest("POST", "/students/import", nil)
+
+	release1, err := l.Acquire(imp)
+	if err != nil {
+		t.Fatalf("first import: %v", err)
+	}
+	release2, err := l.Acquire(imp)
+	if err != nil {
+		t.Fatalf("second import: %v", err)
+	}
+	// Both imports together weigh the whole capacity
+	if _, err := l.Acquire(imp); !errors.Is(err, ErrRouteBusy) {
+		t.Fatalf("third import: error = %v, want ErrRouteBusy", err)
+	}
+	if _, err := l.Acquire(list); !errors.Is(err, ErrBusy) {
+		t.Fatalf("list at capacity: error = %v, want ErrBusy", err)
+	}
+
+	release1()
+	releaseList, err := l.Acquire(list)
+	if err != nil {
+		t.Fatalf("list after an import finished: %v", err)
+	}
+	// The route has a free slot but the capacity doesn't; the slot must not leak
+	if _, err := l.Acquire(imp); !errors.Is(err, ErrBusy) {
+		t.Fatalf("import without capacity: error = %v, want ErrBusy", err)
+	}
+	releaseList()
+	if release, err := l.Acquire(imp); err != nil {
+		t.Fatalf("import once capacity is back: %v", err)
+	} else {
+		release()
+	}
+	release2()
+}
+
+func TestLimiterWaits(t *testing.T) {
+	l, err := New(1, 50*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	req := httptest.NewRequest("GET", "/students", nil)
+	release, err := l.Acquire(req)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	// A slot freed within the wait is taken
+	time.AfterFunc(10*time.Millisecond, release)
+	release, err = l.Acquire(req)
+	if err != nil {
+		t.Fatalf("Acquire while a slot frees up: %v", err)
+	}
+
+	if _, err := l.Acquire(req); !errors.Is(err, ErrBusy) {
+		t.Fatalf("Acquire past the wait: error = %v, want ErrBusy", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Acquire(req.WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Acquire for a canceled request: error = %v, want context.Canceled", err)
+	}
+	release()
+}
+
+func TestNewRejectsBadRules(t *testing.T) {
+	for name, routes := range map[string]map[string]Rule{
+		"zero limit":      {"GET /students": {Limit: 0}},
+		"heavy":           {"GET /students": {Limit: 1, Weight: 11}},
+		"empty pattern":   {"": {Limit: 1}},
+		"bad pattern":     {"GET /students/{": {Limit: 1}},
+		"negative weight": {"GET /students": {Limit: 1, Weight: -1}},
+	} {
+		if _, err := New(10, 0, routes); err == nil {
+			t.Errorf("%s: New() succeeded, want an error", name)
+		}
+	}
+	if _, err := New(0, 0, nil); err == nil {
+		t.Error("zero capacity: New() succeeded, want an error")
+	}
+}
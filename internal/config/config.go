@@ -13,7 +13,47 @@ import (
 type Config struct {
 	Env         string `yaml:"env" env:"ENV" env-default:"production"`
 	StoragePath string `yaml:"storage_path" env-required:"true"`
-	HTTPServer `yaml:"http_server"`
+	IDs         `yaml:"ids"`
+	HTTPServer  `yaml:"http_server"`
+	AdminServer `yaml:"admin_server"`
+	// Servers replaces http_server's and admin_server's listeners with one per entry, each serving
+	// the route groups it lists; empty keeps those two
+	Servers     []Server `yaml:"servers"`
+	Validation  `yaml:"validation"`
+	WorkerPool  `yaml:"worker_pool"`
+	Cache       `yaml:"cache"`
+	Systemd     `yaml:"systemd"`
+	Scheduler   `yaml:"scheduler"`
+	Export      `yaml:"export"`
+	JobQueue    `yaml:"job_queue"`
+	Import      `yaml:"import"`
+	Profiles    `yaml:"profiles"`
+	Mail        `yaml:"mail"`
+	SMS         `yaml:"sms"`
+	Tenancy     `yaml:"tenancy"`
+	Retention   `yaml:"retention"`
+	Search      `yaml:"search"`
+	API         `yaml:"api"`
+	Pagination  `yaml:"pagination"`
+	RateLimit   `yaml:"rate_limit"`
+	Quota       `yaml:"quota"`
+	Concurrency `yaml:"concurrency"`
+	Recording   `yaml:"recording"`
+	Library     `yaml:"library"`
+	BulkUpdate  `yaml:"bulk_update"`
+	Webhooks    `yaml:"webhooks"`
+	// Deprecations lists the routes on their way out
+	Deprecations []Deprecation `yaml:"deprecations"`
+}
+
+// IDs picks how new records' IDs are made up (see internal/idgen)
+type IDs struct {
+	// Strategy is auto_increment (the database's sequence and random UUIDs), uuidv7 (the
+	// sequence and time-ordered UUIDs) or snowflake (row IDs made up by each instance)
+	Strategy string `yaml:"strategy" env:"ID_STRATEGY" env-default:"auto_increment"`
+	// Node numbers this instance for snowflake IDs, 0-1023; instances sharing an ID space need
+	// one each
+	Node int64 `yaml:"node" env:"ID_NODE" env-default:"0"`
 }
 
 // HTTPServer contains HTTP server configuration
@@ -22,7 +62,361 @@ type HTTPServer struct {
 	Port        int           `yaml:"port" env-default:"8080"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	// ShutdownTimeout bounds the whole graceful shutdown: in-flight requests, worker pool drain, closing the database
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+	// DrainDelay is how long to keep serving after a shutdown signal while /readyz reports 503.
+	// Behind a load balancer this gives it time to notice and stop sending traffic before the listener closes.
+	// It runs before, and is not part of, ShutdownTimeout.
+	DrainDelay time.Duration `yaml:"drain_delay" env-default:"0s"`
+	// ReusePort sets SO_REUSEPORT so a new binary can bind the same address while this one drains
+	ReusePort bool `yaml:"reuse_port" env-default:"false"`
+	// PathNormalization is what happens to /students/ or //students: redirect (308 to /students),
+	// rewrite (served as /students) or off
+	PathNormalization string `yaml:"path_normalization" env:"HTTP_PATH_NORMALIZATION" env-default:"redirect"`
+}
+
+// AdminServer moves the operational routes (/admin, /jobs, /debug/pprof) to a second listener,
+// keeping them off the public port. Token, mutual TLS or both must be configured.
+type AdminServer struct {
+	Enabled bool   `yaml:"enabled" env:"ADMIN_SERVER_ENABLED" env-default:"false"`
+	Host    string `yaml:"host" env-default:"localhost"`
+	Port    int    `yaml:"port" env-default:"8081"`
+	// Token must be sent as "Authorization: Bearer <token>"; prefer the env var to the config file.
+	// While Enabled is off it guards the operational routes on the public port, which without it
+	// aren't served.
+	Token string `yaml:"token" env:"ADMIN_TOKEN"`
+	// CertFile and KeyFile switch the admin listener to TLS
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile requires clients to present a certificate signed by this CA (mutual TLS)
+	ClientCAFile string `yaml:"client_ca_file"`
+}
+
+// Server is one listener and the route groups it serves: api, health, admin and pprof (see
+// router.Groups). Each group is served by at most one server. Timeouts left at zero are
+// http_server's; draining and the shutdown budget are http_server's too, shared by every server.
+type Server struct {
+	Name        string        `yaml:"name"`
+	Addr        string        `yaml:"addr"` // host:port
+	Groups      []string      `yaml:"groups"`
+	Timeout     time.Duration `yaml:"timeout"`
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// Token, when set, must be sent as "Authorization: Bearer <token>". Servers with the admin or
+	// pprof group must set one.
+	Token string `yaml:"token"`
+}
+
+// Validation contains per-deployment validation policy for student records
+// Some schools admit students under 18, so the age bounds can't be hard-coded
+type Validation struct {
+	// Mode is "struct" (validator tags) or "jsonschema" (embedded JSON Schemas in api/schemas)
+	Mode               string `yaml:"mode" env-default:"struct"`
+	MinAge             int    `yaml:"min_age" env-default:"18"`
+	MaxAge             int    `yaml:"max_age" env-default:"100"`
+	RequireDateOfBirth bool   `yaml:"require_date_of_birth" env-default:"false"`
+	RequirePhone       bool   `yaml:"require_phone" env-default:"false"`
+	// ValidateResponses checks responses against api/openapi.json and logs mismatches (dev aid)
+	ValidateResponses bool `yaml:"validate_responses" env-default:"false"`
+}
+
+// WorkerPool sizes the pool that runs asynchronous side effects (webhooks, emails, indexing)
+type WorkerPool struct {
+	Workers   int `yaml:"workers" env-default:"4"`
+	QueueSize int `yaml:"queue_size" env-default:"256"`
+}
+
+// Cache configures the in-memory cache of the hottest student list pages
+type Cache struct {
+	Enabled  bool          `yaml:"enabled" env-default:"true"`
+	TTL      time.Duration `yaml:"ttl" env-default:"5s"`
+	MaxPages int           `yaml:"max_pages" env-default:"5"`
+	// CountTTL is how long the student count behind every list page is reused; list responses say how old it is
+	CountTTL time.Duration `yaml:"count_ttl" env-default:"2s"`
+}
+
+// Systemd enables integration with systemd-managed hosts; leave it off in containers
+type Systemd struct {
+	// Enabled uses a socket passed via LISTEN_FDS (if any) and sends READY/STOPPING/WATCHDOG notifications
+	Enabled bool `yaml:"enabled" env:"SYSTEMD_ENABLED" env-default:"false"`
+}
+
+// Scheduler declares maintenance jobs and the cron schedules they run on
+type Scheduler struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// BackupDir and BackupKeep configure the "backup" job: where snapshots go and how many are kept
+	BackupDir  string         `yaml:"backup_dir" env-default:"backups"`
+	BackupKeep int            `yaml:"backup_keep" env-default:"7"`
+	Jobs       []ScheduledJob `yaml:"jobs"`
+}
+
+// ScheduledJob runs the named built-in job on a cron expression ("0 3 * * *") or descriptor ("@daily")
+type ScheduledJob struct {
+	Name     string `yaml:"name"`
+	Schedule string `yaml:"schedule"`
+}
+
+// Export configures the "export" job, which uploads full dumps of the students to an object storage
+// bucket (AWS S3, or anything speaking its API such as Google Cloud Storage with HMAC keys)
+type Export struct {
+	// Endpoint is e.g. "https://s3.eu-west-1.amazonaws.com", or "https://storage.googleapis.com" for GCS
+	Endpoint string `yaml:"endpoint" env-default:"https://s3.amazonaws.com"`
+	// Region is the bucket's region, or "auto" for GCS
+	Region string `yaml:"region" env-default:"us-east-1"`
+	Bucket string `yaml:"bucket"`
+	// Prefix starts every object key; tenants' dumps go under <prefix><tenant>/
+	Prefix string `yaml:"prefix" env-default:"students/"`
+	// PathStyle addresses the bucket as https://host/bucket rather than https://bucket.host
+	PathStyle bool `yaml:"path_style" env-default:"false"`
+	// The credentials should come from the environment rather than a committed config file
+	AccessKeyID     string `yaml:"access_key_id" env:"EXPORT_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"secret_access_key" env:"EXPORT_SECRET_ACCESS_KEY"`
+	// Formats are the dumps written each run: "csv", "json" (one student per line) and "parquet"
+	Formats []string `yaml:"formats" env-default:"csv,json"`
+	// Encryption is the server-side encryption asked for: "AES256", "aws:kms" (with KMSKeyID, or
+	// the account's default key) or "" for the bucket's default, which GCS needs
+	Encryption string `yaml:"encryption" env-default:"AES256"`
+	KMSKeyID   string `yaml:"kms_key_id"`
+	// Timeout bounds each upload
+	Timeout time.Duration `yaml:"timeout" env-default:"10m"`
+}
+
+// JobQueue tunes the persistent background job queue (imports, rendering, deliveries)
+type JobQueue struct {
+	Workers      int           `yaml:"workers" env-default:"2"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"1s"`
+	// Lease must exceed the longest job run, or a second worker may pick the job up
+	Lease       time.Duration `yaml:"lease" env-default:"10m"`
+	MaxAttempts int           `yaml:"max_attempts" env-default:"5"`
+	BackoffBase time.Duration `yaml:"backoff_base" env-default:"2s"`
+	BackoffMax  time.Duration `yaml:"backoff_max" env-default:"5m"`
+}
+
+// Import limits asynchronous CSV/JSON imports (POST /students/import)
+type Import struct {
+	// Dir holds uploads until their job has run; "" uses the OS temp dir
+	Dir      string `yaml:"dir" env-default:""`
+	MaxBytes int64  `yaml:"max_bytes" env-default:"104857600"`
+	MaxRows  int    `yaml:"max_rows" env-default:"500000"`
+	// UploadTimeout replaces http_server.timeout while reading an upload
+	UploadTimeout time.Duration `yaml:"upload_timeout" env-default:"5m"`
+}
+
+// Profiles configures student profile PDFs (GET /students/{id}/profile.pdf, POST /students/profiles)
+type Profiles struct {
+	// Dir holds batch-rendered PDFs for download; "" uses the OS temp dir
+	Dir string `yaml:"dir" env-default:""`
+	// Font is a TrueType font covering the scripts of student names; "" uses Helvetica (Latin-1 only)
+	Font string `yaml:"font" env-default:""`
+}
+
+// Mail configures notification emails (welcome mail on student creation, ...)
+type Mail struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// Transport is "smtp" or "log" (print messages instead of sending them; for development)
+	Transport    string `yaml:"transport" env-default:"log"`
+	SMTPHost     string `yaml:"smtp_host" env:"MAIL_SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"MAIL_SMTP_PORT" env-default:"587"`
+	SMTPUsername string `yaml:"smtp_username" env:"MAIL_SMTP_USERNAME"`
+	// SMTPPassword should come from the environment rather than a committed config file
+	SMTPPassword string        `yaml:"smtp_password" env:"MAIL_SMTP_PASSWORD"`
+	From         string        `yaml:"from" env-default:"Students API <no-reply@localhost>"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"3"`
+	RetryBackoff time.Duration `yaml:"retry_backoff" env-default:"2s"`
+}
+
+// SMS configures text messages (announcements sent with "channel": "sms")
+type SMS struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// Provider is "twilio" (Twilio, or a provider with the same API) or "log" (print messages
+	// instead of sending them; for development)
+	Provider string `yaml:"provider" env-default:"log"`
+	// BaseURL points the twilio provider at a compatible service
+	BaseURL    string `yaml:"base_url" env-default:"https://api.twilio.com"`
+	AccountSID string `yaml:"account_sid" env:"SMS_ACCOUNT_SID"`
+	// AuthToken also verifies the delivery reports; it should come from the environment rather
+	// than a committed config file
+	AuthToken string        `yaml:"auth_token" env:"SMS_AUTH_TOKEN"`
+	From      string        `yaml:"from"`
+	Timeout   time.Duration `yaml:"timeout" env-default:"10s"`
+	// StatusCallbackURL is the public URL of POST /webhooks/sms, where the provider reports
+	// whether each message was delivered. With tenancy it must name the tenant by subdomain, as
+	// "{tenant}", e.g. "https://{tenant}.students.example.com/webhooks/sms". "" asks for no
+	// reports, and text messages stay "sent".
+	StatusCallbackURL string `yaml:"status_callback_url"`
+}
+
+// Tenancy hosts several schools on one deployment, each with its own database file.
+// When disabled, StoragePath is the only database.
+type Tenancy struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// Header names the request header carrying the tenant ID
+	Header string `yaml:"header" env-default:"X-Tenant-ID"`
+	// BaseDomain enables subdomain resolution: greenwood.<base_domain> is tenant "greenwood"
+	BaseDomain string   `yaml:"base_domain"`
+	Tenants    []Tenant `yaml:"tenants"`
+}
+
+// Tenant is one school
+type Tenant struct {
+	// ID is lowercase letters, digits and dashes; it is used in hostnames and backup paths
+	ID          string `yaml:"id"`
+	StoragePath string `yaml:"storage_path"`
+	// APIKeys identify this tenant's clients (X-API-Key) without a tenant header
+	APIKeys []string `yaml:"api_keys"`
+	// MonthlyQuota replaces quota.requests for this tenant's clients; 0 keeps it
+	MonthlyQuota int64 `yaml:"monthly_quota"`
+}
+
+// Retention lists data retention rules. They run only when the "retention" scheduled job is
+// configured; GET /admin/retention previews them without changing anything.
+type Retention struct {
+	Rules []RetentionRule `yaml:"rules"`
+}
+
+// RetentionRule anonymizes or deletes rows older than OlderThanDays.
+// Targets: "students" (anonymize or delete, aged from record creation), "deleted_students"
+// (delete, merged duplicates and alumni aged from their soft delete, with everything that
+// depends on them), "jobs" (delete, finished jobs aged from completion), "tombstones" (delete,
+// the deletions GET /students/changes reports, aged from the deletion) and "events" (delete, the
+// event log GET /events serves, aged from publication).
+type RetentionRule struct {
+	Name          string `yaml:"name"`
+	Target        string `yaml:"target"`
+	Action        string `yaml:"action"`
+	OlderThanDays int    `yaml:"older_than_days"`
+}
+
+// Search mirrors students into OpenSearch (or Elasticsearch 7+) for GET /students/search.
+// When disabled, or while the cluster is unreachable, search falls back to SQL substring matching.
+type Search struct {
+	Enabled bool   `yaml:"enabled" env:"SEARCH_ENABLED" env-default:"false"`
+	URL     string `yaml:"url" env:"SEARCH_URL"`
+	// Index is the index name; with tenancy each tenant gets "<index>-<tenant id>"
+	Index    string `yaml:"index" env-default:"students"`
+	Username string `yaml:"username" env:"SEARCH_USERNAME"`
+	// Password should come from the environment rather than a committed config file
+	Password string        `yaml:"password" env:"SEARCH_PASSWORD"`
+	Timeout  time.Duration `yaml:"timeout" env-default:"5s"`
+}
+
+// API selects the response format per request. Clients pick a version with the API-Version
+// header; version 2 wraps every JSON body as {"data": ..., "meta": {"request_id", "duration_ms"}}.
+type API struct {
+	// DefaultVersion is served to requests without an API-Version header
+	DefaultVersion int `yaml:"default_version" env:"API_DEFAULT_VERSION" env-default:"1"`
+	// Versions lists the versions clients may ask for
+	Versions []int `yaml:"versions" env-default:"1,2"`
+	// EnvelopeVersions are the versions whose bodies are wrapped in the envelope
+	EnvelopeVersions []int `yaml:"envelope_versions" env-default:"2"`
+}
+
+// Pagination shapes list responses and sizes their pages. Link and X-Total-Count headers are always sent.
+type Pagination struct {
+	// Style is "body" (the page wrapped with its metadata) or "headers" (the bare array, GitHub style).
+	// Clients can ask for either per request with an Accept profile.
+	Style string `yaml:"style" env-default:"body"`
+	// DefaultLimit is the page size when a request gives no ?limit=; MaxLimit caps what it may ask for
+	DefaultLimit int `yaml:"default_limit" env-default:"20"`
+	MaxLimit     int `yaml:"max_limit" env-default:"100"`
+	// Strict answers 400 to a malformed page or limit instead of using the default. Clients can
+	// choose per request with "Prefer: handling=strict" or "Prefer: handling=lenient".
+	Strict bool `yaml:"strict" env-default:"false"`
+}
+
+// RateLimit throttles each client to Requests per Window across all routes, except routes listed
+// in Routes, which are counted separately under their own (usually stricter) rule
+type RateLimit struct {
+	Enabled  bool          `yaml:"enabled" env-default:"false"`
+	Requests int           `yaml:"requests" env-default:"600"`
+	Window   time.Duration `yaml:"window" env-default:"1m"`
+	// ClientIPHeader is a header set by a trusted proxy holding the client's address, such as
+	// X-Forwarded-For; "" identifies clients by the connection's address
+	ClientIPHeader string           `yaml:"client_ip_header" env-default:""`
+	Routes         []RouteRateLimit `yaml:"routes"`
+}
+
+// RouteRateLimit overrides the rate limit of one route, named by its router pattern ("POST /students/import")
+type RouteRateLimit struct {
+	Route    string        `yaml:"route"`
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+}
+
+// Quota caps each client's requests per calendar month (UTC), counted in the database so the
+// allowance holds across restarts. Clients are the API keys (each tenant's api_keys, or
+// webhooks.api_keys without tenancy); requests without one of them share one allowance.
+type Quota struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// Requests is each client's monthly allowance; a tenant's monthly_quota replaces it
+	Requests int64 `yaml:"requests" env-default:"1000000"`
+}
+
+// Concurrency caps the requests in flight at once. Each takes its route's Weight (1 by default)
+// from Capacity; routes listed in Routes are also capped at Limit requests of their own.
+type Concurrency struct {
+	Enabled  bool  `yaml:"enabled" env-default:"false"`
+	Capacity int64 `yaml:"capacity" env-default:"100"`
+	// Wait is how long a request may queue for a slot before it is turned away (0 = not at all)
+	Wait   time.Duration      `yaml:"wait" env-default:"0s"`
+	Routes []RouteConcurrency `yaml:"routes"`
+}
+
+// RouteConcurrency caps one route, named by its router pattern ("POST /students/import")
+type RouteConcurrency struct {
+	Route  string `yaml:"route"`
+	Limit  int64  `yaml:"limit"`
+	Weight int64  `yaml:"weight"` // 0 = 1
+}
+
+// Deprecation marks one route, named by its router pattern ("GET /students/export"), deprecated.
+// Its responses carry Deprecation, Sunset and Link headers, and GET /admin/deprecations counts
+// its calls. Dates are YYYY-MM-DD.
+type Deprecation struct {
+	Route  string `yaml:"route"`
+	Since  string `yaml:"since"`
+	Sunset string `yaml:"sunset"` // "" while no removal date is set
+	Link   string `yaml:"link"`   // documents the replacement
+}
+
+// Recording keeps full request/response pairs, with personal data masked, for GET /admin/recordings.
+// Development environments only.
+type Recording struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// Keep is how many recordings are held in memory
+	Keep int `yaml:"keep" env-default:"200"`
+	// MaxBodyBytes is how much of each request and response body is kept
+	MaxBodyBytes int `yaml:"max_body_bytes" env-default:"65536"`
+	// Dir also writes every recording there as <seq>.json; "" keeps them in memory only
+	Dir string `yaml:"dir"`
+}
+
+// Library configures book lending; overdue reminders are the overdue_loans scheduled job
+type Library struct {
+	// LoanDays is how long a book is lent for when a checkout gives no due date
+	LoanDays int `yaml:"loan_days" env-default:"14"`
+}
+
+// BulkUpdate configures PATCH /students?filter=
+type BulkUpdate struct {
+	// ConfirmAbove is how many students one request may change before it needs confirm=true
+	ConfirmAbove int `yaml:"confirm_above" env-default:"100"`
+}
+
+// Webhooks lets API-key holders subscribe URLs to student events (POST /webhooks), each key
+// managing its own subscriptions
+type Webhooks struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// APIKeys may subscribe when tenancy is disabled. With tenancy each tenant's api_keys do, and
+	// this must be empty.
+	APIKeys []string `yaml:"api_keys" env:"WEBHOOK_API_KEYS"`
+	// Timeout bounds one delivery, from connecting to the receiver's status
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+// IsDev reports whether this is a development environment, where dev-only tooling is enabled
+func (c *Config) IsDev() bool {
+	return c.Env == "dev" || c.Env == "local"
 }
 
 // MustLoad loads configuration from file and panics on error
@@ -31,7 +425,6 @@ func MustLoad() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
 
-
 		// If config path is not available from env, read it from cmd args or flags
 		flags := flag.String("config", "config/local.yml", "path to config file")
 		flag.Parse()
@@ -67,4 +460,4 @@ func Load(configPath string) (*Config, error) {
 	}
 
 	return &cfg, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,255 @@
+// Package contract checks HTTP responses against the embedded OpenAPI document, so drift
+// between what handlers return and what the spec promises is caught in tests (and, optionally,
+// at runtime in dev).
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/prashantkumbhar2002/go_students_api/api"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// Validator validates responses against the OpenAPI document
+type Validator struct {
+	operations []operation
+}
+
+type operation struct {
+	method    string
+	template  string
+	segments  []string
+	responses map[string]responseSpec // status code, "2XX" or "default"
+}
+
+type responseSpec struct {
+	// content maps media type -> schema (nil when the spec declares no schema for it)
+	content map[string]*jsonschema.Schema
+}
+
+// openAPI is the subset of the document we need
+type openAPI struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Responses map[string]json.RawMessage `json:"responses"`
+	} `json:"components"`
+}
+
+type operationDoc struct {
+	Responses map[string]json.RawMessage `json:"responses"`
+}
+
+type responseDoc struct {
+	Ref     string                     `json:"$ref"`
+	Content map[string]json.RawMessage `json:"content"`
+}
+
+var httpMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true, "trace": true,
+}
+
+// New parses the embedded OpenAPI document and compiles every response schema
+func New() (*Validator, error) {
+	var doc openAPI
+	if err := json.Unmarshal(api.OpenAPI, &doc); err != nil {
+		return nil, fmt.Errorf("parsing openapi.json: %w", err)
+	}
+
+	compiler, err := validation.NewSchemaCompiler()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Validator{}
+	for template, item := range doc.Paths {
+		for method, raw := range item {
+			if !httpMethods[method] {
+				continue
+			}
+			var op operationDoc
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, template, err)
+			}
+
+			o := operation{
+				method:    strings.ToUpper(method),
+				template:  template,
+				segments:  splitPath(template),
+				responses: make(map[string]responseSpec),
+			}
+
+			for status, rawResp := range op.Responses {
+				// Inline responses are located under the operation; $ref'd ones under components
+				pointer := "/paths/" + escape(template) + "/" + method + "/responses/" + escape(status)
+
+				var resp responseDoc
+				if err := json.Unmarshal(rawResp, &resp); err != nil {
+					return nil, fmt.Errorf("%s %s %s: %w", method, template, status, err)
+				}
+				if resp.Ref != "" {
+					name, ok := strings.CutPrefix(resp.Ref, "#/components/responses/")
+					if !ok {
+						return nil, fmt.Errorf("%s %s %s: unsupported $ref %q", method, template, status, resp.Ref)
+					}
+					pointer = "/components/responses/" + escape(name)
+					resp = responseDoc{}
+					if err := json.Unmarshal(doc.Components.Responses[name], &resp); err != nil {
+						return nil, fmt.Errorf("response %s: %w", name, err)
+					}
+				}
+
+				spec := responseSpec{content: make(map[string]*jsonschema.Schema)}
+				for mediaType, rawMedia := range resp.Content {
+					var media struct {
+						Schema json.RawMessage `json:"schema"`
+					}
+					json.Unmarshal(rawMedia, &media)
+
+					var sch *jsonschema.Schema
+					if len(media.Schema) > 0 {
+						loc := validation.OpenAPIURL + "#" + pointer + "/content/" + escape(mediaType) + "/schema"
+						sch, err = compiler.Compile(loc)
+						if err != nil {
+							return nil, fmt.Errorf("%s %s %s: %w", method, template, status, err)
+						}
+					}
+					spec.content[mediaType] = sch
+				}
+				o.responses[status] = spec
+			}
+
+			v.operations = append(v.operations, o)
+		}
+	}
+
+	// Literal segments beat parameters: /students/export must win over /students/{id}
+	sort.Slice(v.operations, func(i, j int) bool {
+		return literalCount(v.operations[i].segments) > literalCount(v.operations[j].segments)
+	})
+
+	return v, nil
+}
+
+// MustNew is New for callers that treat a broken embedded spec as a programming error
+func MustNew() *Validator {
+	v, err := New()
+	if err != nil {
+		panic(fmt.Sprintf("contract: %v", err))
+	}
+	return v
+}
+
+// ValidateResponse checks that the operation is documented, the status is declared,
+// and a JSON body matches the declared schema
+func (v *Validator) ValidateResponse(method, path string, status int, header http.Header, body []byte) error {
+	op := v.find(method, path)
+	if op == nil {
+		return fmt.Errorf("%s %s: operation not documented in openapi.json", method, path)
+	}
+
+	code := strconv.Itoa(status)
+	spec, ok := op.responses[code]
+	if !ok {
+		spec, ok = op.responses[code[:1]+"XX"]
+	}
+	if !ok {
+		spec, ok = op.responses["default"]
+	}
+	if !ok {
+		return fmt.Errorf("%s %s: status %d not documented", method, op.template, status)
+	}
+
+	if len(spec.content) == 0 {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return fmt.Errorf("%s %s %d: body returned but spec declares none", method, op.template, status)
+		}
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	sch, ok := spec.content[mediaType]
+	if !ok {
+		return fmt.Errorf("%s %s %d: content type %q not documented", method, op.template, status, mediaType)
+	}
+	if sch == nil || mediaType != "application/json" {
+		return nil
+	}
+
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s %s %d: body is not valid JSON: %w", method, op.template, status, err)
+	}
+	if err := sch.Validate(inst); err != nil {
+		return fmt.Errorf("%s %s %d: body does not match schema: %w", method, op.template, status, err)
+	}
+	return nil
+}
+
+func (v *Validator) find(method, path string) *operation {
+	segments := splitPath(path)
+	for i := range v.operations {
+		op := &v.operations[i]
+		// HEAD is served by GET handlers
+		if op.method != method && !(method == http.MethodHead && op.method == http.MethodGet) {
+			continue
+		}
+		if matches(op.segments, segments) {
+			return op
+		}
+	}
+	return nil
+}
+
+func matches(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+	for i, t := range template {
+		if isParam(t) {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if t != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+func literalCount(segments []string) int {
+	n := 0
+	for _, s := range segments {
+		if !isParam(s) {
+			n++
+		}
+	}
+	return n
+}
+
+// escape encodes a JSON pointer token (RFC 6901)
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,40 @@
+package contract
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestValidateResponse(t *testing.T) {
+	v, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{"valid student", "GET", "/students/7c9e6679-7425-40de-944b-e07fc1f90ae7", 200, `{"id":"7c9e6679-7425-40de-944b-e07fc1f90ae7","name":"A","email":"a@example.com","age":20}`, false},
+		{"literal route wins over parameter", "GET", "/students/export", 200, `[]`, false},
+		{"undocumented field", "GET", "/students/7c9e6679-7425-40de-944b-e07fc1f90ae7", 200, `{"id":"7c9e6679-7425-40de-944b-e07fc1f90ae7","name":"A","email":"a@example.com","age":20,"x":1}`, true},
+		{"undocumented status", "GET", "/students", 418, `{}`, true},
+		{"undocumented operation", "DELETE", "/students/7", 204, ``, true},
+		{"error body", "GET", "/students/7", 404, `{"error":"student not found","status":"Error"}`, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := v.ValidateResponse(tc.method, tc.path, tc.status, jsonHeader, []byte(tc.body))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ValidateResponse error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Package deprecation keeps the routes that are on their way out: since when they are
+// deprecated, when they will be removed and where their replacement is documented. Responses from
+// those routes say so in headers (RFC 9745's Deprecation, RFC 8594's Sunset and a Link), and every
+// call is counted, so the route can be removed once nobody uses it any more.
+//
+// Routes are named with the same patterns the router uses ("GET /students/export").
+package deprecation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+// Route describes one deprecated route
+type Route struct {
+	// Since is when the route was deprecated
+	Since time.Time
+	// Sunset is when the route will be removed; zero while no date is set
+	Sunset time.Time
+	// Link documents the replacement; "" for none
+	Link string
+}
+
+// Usage is how much a deprecated route is still used
+type Usage struct {
+	Route  string     `json:"route"`
+	Since  time.Time  `json:"since"`
+	Sunset *time.Time `json:"sunset,omitempty"`
+	Link   string     `json:"link,omitempty"`
+	// Requests counts the calls since the server started
+	Requests uint64 `json:"requests"`
+	// LastUsed is nil while the route hasn't been called
+	LastUsed *time.Time `json:"last_used,omitempty"`
+}
+
+// Registry holds the deprecated routes
+type Registry struct {
+	// Clock stamps LastUsed; nil means the system clock
+	Clock clock.Clock
+
+	routes map[string]*route
+	mux    *http.ServeMux
+}
+
+type route struct {
+	Route
+	requests atomic.Uint64
+	lastUsed atomic.Int64 // unix nanoseconds, 0 = never
+}
+
+// New returns a registry of routes. It fails on a route without a Since date, a Sunset that
+// doesn't come after Since, a Link that isn't an absolute http(s) URL, or a route that isn't a
+// valid, unique ServeMux pattern.
+func New(routes map[string]Route) (reg *Registry, err error) {
+	reg = &Registry{routes: make(map[string]*route, len(routes)), mux: http.NewServeMux()}
+
+	// ServeMux panics on malformed or conflicting patterns
+	defer func() {
+		if rec := recover(); rec != nil {
+			reg, err = nil, fmt.Errorf("%v", rec)
+		}
+	}()
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for pattern, rt := range routes {
+		if pattern == "" {
+			return nil, errors.New("route pattern is empty")
+		}
+		if rt.Since.IsZero() {
+			return nil, fmt.Errorf("route %q: since is required", pattern)
+		}
+		if !rt.Sunset.IsZero() && !rt.Sunset.After(rt.Since) {
+			return nil, fmt.Errorf("route %q: sunset %s must come after since %s", pattern, rt.Sunset.Format(time.DateOnly), rt.Since.Format(time.DateOnly))
+		}
+		if rt.Link != "" {
+			if u, err := url.Parse(rt.Link); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("route %q: link %q is not an absolute http(s) URL", pattern, rt.Link)
+			}
+		}
+		reg.mux.Handle(pattern, noop)
+		reg.routes[pattern] = &route{Route: rt}
+	}
+	return reg, nil
+}
+
+// Use looks r's route up and, if it is deprecated, counts the call and returns its description
+func (reg *Registry) Use(r *http.Request) (Route, bool) {
+	_, pattern := reg.mux.Handler(r)
+	rt := reg.routes[pattern]
+	if rt == nil {
+		return Route{}, false
+	}
+	rt.requests.Add(1)
+	rt.lastUsed.Store(clock.OrReal(reg.Clock).Now().UnixNano())
+	return rt.Route, true
+}
+
+// SetHeaders announces rt in h: Deprecation as an RFC 9745 date, Sunset as an HTTP date and
+// Link with rel="deprecation". Link is added to, since paginated lists set their own.
+func SetHeaders(h http.Header, rt Route) {
+	h.Set("Deprecation", fmt.Sprintf("@%d", rt.Since.Unix()))
+	if !rt.Sunset.IsZero() {
+		h.Set("Sunset", rt.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if rt.Link != "" {
+		h.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, rt.Link))
+	}
+}
+
+// Usage reports every deprecated route, soonest sunset first; routes without one come last
+func (reg *Registry) Usage() []Usage {
+	usage := make([]Usage, 0, len(reg.routes))
+	for pattern, rt := range reg.routes {
+		u := Usage{Route: pattern, Since: rt.Since, Link: rt.Link, Requests: rt.requests.Load()}
+		if !rt.Sunset.IsZero() {
+			u.Sunset = &rt.Sunset
+		}
+		if ns := rt.lastUsed.Load(); ns != 0 {
+			t := time.Unix(0, ns).UTC()
+			u.LastUsed = &t
+		}
+		usage = append(usage, u)
+	}
+	slices.SortFunc(usage, func(a, b Usage) int {
+		switch {
+		case a.Sunset == nil && b.Sunset != nil:
+			return 1
+		case a.Sunset != nil && b.Sunset == nil:
+			return -1
+		case a.Sunset != nil && !a.Sunset.Equal(*b.Sunset):
+			return a.Sunset.Compare(*b.Sunset)
+		}
+		return strings.Compare(a.Route, b.Route)
+	})
+	return usage
+}
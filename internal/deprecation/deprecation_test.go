@@ -0,0 +1,71 @@
+package deprecation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+func TestRegistry(t *testing.T) {
+	reg, err := New(map[string]Route{
+		"GET /students/export": {Since: date("2026-01-01"), Sunset: date("2026-12-31"), Link: "https://docs.example.com/export"},
+		"GET /slow":            {Since: date("2026-03-01")},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	reg.Clock = clock.NewFake(now)
+
+	if _, ok := reg.Use(httptest.NewRequest("GET", "/students", nil)); ok {
+		t.Error("Use(GET /students) reported a route that isn't deprecated")
+	}
+	rt, ok := reg.Use(httptest.NewRequest("GET", "/students/export?format=csv", nil))
+	if !ok {
+		t.Fatal("Use(GET /students/export) didn't find the route")
+	}
+	h := http.Header{}
+	h.Add("Link", `</students?offset=10>; rel="next"`)
+	SetHeaders(h, rt)
+	if got := h.Get("Deprecation"); got != "@1767225600" {
+		t.Errorf("Deprecation = %q, want @1767225600", got)
+	}
+	if got := h.Get("Sunset"); got != "Thu, 31 Dec 2026 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if links := h.Values("Link"); len(links) != 2 || links[1] != `<https://docs.example.com/export>; rel="deprecation"; type="text/html"` {
+		t.Errorf("Link = %q, want the pagination link kept and the deprecation link added", links)
+	}
+
+	// Soonest sunset first; only the called route has a last use
+	usage := reg.Usage()
+	if len(usage) != 2 || usage[0].Route != "GET /students/export" || usage[0].Requests != 1 || usage[0].LastUsed == nil || !usage[0].LastUsed.Equal(now) {
+		t.Fatalf("Usage() = %+v, want the export route first with one call", usage)
+	}
+	if usage[1].Requests != 0 || usage[1].LastUsed != nil || usage[1].Sunset != nil {
+		t.Errorf("Usage()[1] = %+v, want an unused route without a sunset", usage[1])
+	}
+}
+
+func TestNewRejectsBadRoutes(t *testing.T) {
+	bad := []map[string]Route{
+		{"": {Since: date("2026-01-01")}},
+		{"GET /students": {}},
+		{"GET /students": {Since: date("2026-01-01"), Sunset: date("2025-12-31")}},
+		{"GET /students": {Since: date("2026-01-01"), Link: "/docs"}},
+		{"GET /students/{id": {Since: date("2026-01-01")}},
+	}
+	for i, routes := range bad {
+		if _, err := New(routes); err == nil {
+			t.Errorf("case %d: New(%+v) succeeded", i, routes)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package duplicates finds students that probably describe the same person: the same email once
+// normalized, or names that differ only by a typo or word order. Matches are suggestions for a
+// person to review; POST /students/{id}/merge/{otherId} acts on them.
+package duplicates
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// NormalizeEmail lowercases an address and drops a "+tag" from the local part,
+// so "Asha+exam@Example.com" and "asha@example.com" compare equal
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return email
+	}
+	local, _, _ = strings.Cut(local, "+")
+	return local + "@" + domain
+}
+
+// NormalizeName lowercases a name, drops punctuation and sorts its words,
+// so "Patil, Asha" and "asha patil" compare equal
+func NormalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// SimilarNames reports whether two normalized names are at most one edit apart per
+// 8 characters (at least one), e.g. "asha patil" and "asha paatil"
+func SimilarNames(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	ra, rb := []rune(a), []rune(b)
+	maxEdits := max(1, min(len(ra), len(rb))/8)
+	if abs(len(ra)-len(rb)) > maxEdits {
+		return false
+	}
+	return levenshtein(ra, rb) <= maxEdits
+}
+
+// Find groups students that match by email or name; a student matching several others ends up
+// in one group with all of them. students must be in ID order (as StreamStudents returns them);
+// groups keep that order. Anonymized students never match: their placeholders are alike by design.
+//
+// Names are only compared within blocks sharing their first two characters, which keeps the
+// work far below comparing every pair but misses typos in those characters.
+func Find(students []types.Student) []types.DuplicateGroup {
+	n := len(students)
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		ri, rj := find(i), find(j)
+		if ri < rj {
+			parent[rj] = ri
+		} else if rj < ri {
+			parent[ri] = rj
+		}
+	}
+
+	// reasons[i] holds the reasons student i matched another student
+	reasons := make([][]string, n)
+	mark := func(i, j int, reason string) {
+		union(i, j)
+		for _, k := range []int{i, j} {
+			if !slices.Contains(reasons[k], reason) {
+				reasons[k] = append(reasons[k], reason)
+			}
+		}
+	}
+
+	byEmail := make(map[string]int)
+	blocks := make(map[string][]int)
+	names := make([]string, n)
+	for i, st := range students {
+		if strings.HasSuffix(st.Email, "@example.invalid") {
+			continue // anonymized; see internal/storage/sqlite/retention.go
+		}
+
+		email := NormalizeEmail(st.Email)
+		if first, ok := byEmail[email]; ok {
+			mark(first, i, types.DuplicateSameEmail)
+		} else {
+			byEmail[email] = i
+		}
+
+		names[i] = NormalizeName(st.Name)
+		key := names[i]
+		if r := []rune(key); len(r) > 2 {
+			key = string(r[:2])
+		}
+		for _, j := range blocks[key] {
+			if SimilarNames(names[i], names[j]) {
+				mark(j, i, types.DuplicateSimilarName)
+			}
+		}
+		blocks[key] = append(blocks[key], i)
+	}
+
+	members := make(map[int][]int)
+	for i := range students {
+		if reasons[i] != nil {
+			root := find(i)
+			members[root] = append(members[root], i)
+		}
+	}
+	roots := make([]int, 0, len(members))
+	for root := range members {
+		roots = append(roots, root)
+	}
+	sort.Ints(roots)
+
+	groups := make([]types.DuplicateGroup, 0, len(roots))
+	for _, root := range roots {
+		g := types.DuplicateGroup{Reasons: []string{}}
+		for _, i := range members[root] {
+			g.Students = append(g.Students, students[i])
+			for _, r := range reasons[i] {
+				if !slices.Contains(g.Reasons, r) {
+					g.Reasons = append(g.Reasons, r)
+				}
+			}
+		}
+		sort.Strings(g.Reasons)
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// levenshtein counts the single-rune insertions, deletions and substitutions turning a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
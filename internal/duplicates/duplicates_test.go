@@ -0,0 +1,63 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func TestNormalize(t *testing.T) {
+	if got := NormalizeEmail(" Asha+Exam@Example.COM "); got != "asha@example.com" {
+		t.Errorf("NormalizeEmail = %q", got)
+	}
+	if got := NormalizeName("Patil,  Asha"); got != "asha patil" {
+		t.Errorf("NormalizeName = %q", got)
+	}
+}
+
+func TestSimilarNames(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"asha patil", "asha paatil", true},
+		{"asha patil", "asha patel", true},
+		{"asha patil", "asha pawar", false},
+		{"ravi", "rav", true},
+		{"ravi", "ram", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := SimilarNames(tt.a, tt.b); got != tt.want {
+			t.Errorf("SimilarNames(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	students := []types.Student{
+		{ID: 1, Name: "Asha Patil", Email: "asha@example.com"},
+		{ID: 2, Name: "Ravi Kumar", Email: "ravi@example.com"},
+		{ID: 3, Name: "A. Patil", Email: "Asha+2024@example.com"},
+		{ID: 4, Name: "Patil Asha", Email: "ap@school.edu"},
+		{ID: 5, Name: "Meera Shah", Email: "meera@example.com"},
+		{ID: 6, Name: "Anonymized student", Email: "anonymized+6@example.invalid"},
+		{ID: 7, Name: "Anonymized student", Email: "anonymized+7@example.invalid"},
+	}
+
+	groups := Find(students)
+	if len(groups) != 1 {
+		t.Fatalf("Find() = %+v, want one group", groups)
+	}
+	var ids []int64
+	for _, st := range groups[0].Students {
+		ids = append(ids, st.ID)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 3, 4}) {
+		t.Errorf("group students = %v, want [1 3 4]", ids)
+	}
+	if want := []string{types.DuplicateSameEmail, types.DuplicateSimilarName}; !reflect.DeepEqual(groups[0].Reasons, want) {
+		t.Errorf("group reasons = %v, want %v", groups[0].Reasons, want)
+	}
+}
@@ -0,0 +1,331 @@
+// Package events announces changes to students inside the service.
+//
+// Store, a storage.Storage decorator, publishes an Event for every successful write; side effects
+// (search indexing, welcome emails, webhook deliveries, and later SSE streams or a Kafka
+// producer) subscribe to the Bus instead of each handler or decorator calling them directly. One
+// Bus serves one database, so with tenancy every tenant's events stay with its own subscribers.
+//
+// A Bus with a Log also keeps every event there, numbered per entity (GET /events), so a
+// consumer that was down or missed one can tell from the gap in the numbers and backfill. When
+// the storage has transactions, Store appends a write's events in the write's own transaction
+// (an outbox), so the log has an event if and only if its write committed; the Bus then delivers
+// events as the log has them, in order.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Kind names what happened
+type Kind string
+
+const (
+	StudentCreated Kind = "student.created"
+	StudentUpdated Kind = "student.updated"
+	StudentDeleted Kind = "student.deleted"
+	// StudentGraduated means a student became an alumnus and left every student read
+	StudentGraduated Kind = "student.graduated"
+	// StudentsChanged means an unknown set of students changed at once (an admin reset, a retention
+	// run, a custom field's deletion, a bulk update); subscribers that mirror students should resync from the database
+	StudentsChanged Kind = "students.changed"
+	// LoanOverdue lists library loans past their due date. The overdue_loans scheduled job
+	// publishes it on every run, so each run reminds borrowers again.
+	LoanOverdue Kind = "loan.overdue"
+	// AnnouncementPosted carries an announcement whose deliveries are queued
+	AnnouncementPosted Kind = "announcement.posted"
+)
+
+// Event is one change. Events are values; subscribers must not modify Students, Loans or Announcements.
+type Event struct {
+	Kind Kind
+	// Students are the students affected, as written. Deleted students may carry only their IDs;
+	// StudentsChanged carries none.
+	Students []types.Student
+	// Loans are the loans a LoanOverdue event is about
+	Loans []types.Loan
+	// Announcements are the announcements an AnnouncementPosted event is about
+	Announcements []types.Announcement
+	// Bulk marks events from bulk writes (POST /students/bulk, imports)
+	Bulk bool
+	At   time.Time
+	// Seq numbers the event among its entity's (Kind.Entity) in the Bus's Log; 0 without one
+	Seq int64
+}
+
+// Entity returns which of types.EventEntities a kind's events are numbered among
+func (k Kind) Entity() string {
+	switch k {
+	case LoanOverdue:
+		return types.EntityLoan
+	case AnnouncementPosted:
+		return types.EntityAnnouncement
+	}
+	return types.EntityStudent
+}
+
+// Handler reacts to an event. Handlers run synchronously in a writer's goroutine, after the
+// write has committed (with a Log, possibly another writer's: whichever finds the event in the
+// log first delivers it), so they must hand slow work off (to the job runner, the worker pool)
+// rather than do it inline. ctx is never cancelled.
+type Handler func(ctx context.Context, e Event)
+
+// Publisher is what writers need
+type Publisher interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// Subscriber is what side effects need
+type Subscriber interface {
+	// Subscribe calls h for events of the given kinds, or of every kind if none are given.
+	// name identifies the subscriber in logs.
+	Subscribe(name string, h Handler, kinds ...Kind)
+}
+
+// Recorder keeps events and reads them back; storage.EventLog is one
+type Recorder interface {
+	AppendEvent(ctx context.Context, e types.FeedEvent) (types.FeedEvent, error)
+	EventsAfter(ctx context.Context, entity string, afterSeq int64, limit int) ([]types.FeedEvent, int64, error)
+}
+
+// Bus delivers events to subscribers in the order they subscribed
+type Bus struct {
+	// Clock stamps events published without a time; nil means the system clock
+	Clock clock.Clock
+	// Log keeps every event, and numbers it, before any subscriber hears of it; nil keeps none
+	Log Recorder
+
+	mu   sync.RWMutex
+	subs []subscription
+
+	// flushMu guards delivered, the last seq of each entity delivered from the Log, and pending,
+	// the events appended to it and not delivered yet, by entity and seq
+	flushMu   sync.Mutex
+	delivered map[string]int64
+	pending   map[string]map[int64]*Event
+}
+
+// flushBatch is how many logged events flush reads at a time
+const flushBatch = 100
+
+type subscription struct {
+	name  string
+	h     Handler
+	kinds []Kind
+}
+
+var (
+	_ Publisher  = (*Bus)(nil)
+	_ Subscriber = (*Bus)(nil)
+)
+
+// New returns an empty bus
+func New() *Bus {
+	return &Bus{}
+}
+
+// Subscribe implements Subscriber
+func (b *Bus) Subscribe(name string, h Handler, kinds ...Kind) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, subscription{name: name, h: h, kinds: kinds})
+}
+
+// Publish delivers e to every matching subscriber. A panicking subscriber is logged and skipped;
+// the write it reports has already happened, and the other subscribers still hear about it.
+// With a Log, e is appended to it first and delivered from it; an event that can't be kept is
+// still delivered, unnumbered, and consumers of the log see the write in the next event's data
+// or resync.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	// Subscribers react to a write that has happened; the request ending must not stop them
+	ctx = context.WithoutCancel(ctx)
+	if b.Log == nil {
+		b.deliver(ctx, b.stamp(e))
+		return
+	}
+	staged, err := b.stage(ctx, e)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error recording event", "event", e.Kind, "error", err)
+		b.deliver(ctx, b.stamp(e))
+		return
+	}
+	b.flush(ctx, staged)
+}
+
+// stamp gives e the time it happened, if it has none
+func (b *Bus) stamp(e Event) Event {
+	if e.At.IsZero() {
+		e.At = clock.OrReal(b.Clock).Now()
+	}
+	return e
+}
+
+// stage appends e to b.Log, in the transaction ctx carries if any (see storage.Transactor), and
+// holds it until flush finds it committed. The caller flushes it, or discards it if the
+// transaction rolled back.
+func (b *Bus) stage(ctx context.Context, e Event) (*Event, error) {
+	e = b.stamp(e)
+	var data any
+	switch e.Kind.Entity() {
+	case types.EntityLoan:
+		data = map[string]any{"loans": orEmpty(e.Loans)}
+	case types.EntityAnnouncement:
+		data = map[string]any{"announcements": orEmpty(e.Announcements)}
+	default:
+		data = map[string]any{"students": orEmpty(e.Students)}
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	kept, err := b.Log.AppendEvent(ctx, types.FeedEvent{Entity: e.Kind.Entity(), Type: string(e.Kind), Data: raw})
+	if err != nil {
+		return nil, err
+	}
+	e.Seq = kept.Seq
+
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+	if b.pending == nil {
+		b.pending = make(map[string]map[int64]*Event)
+	}
+	entity := e.Kind.Entity()
+	if b.pending[entity] == nil {
+		b.pending[entity] = make(map[int64]*Event)
+	}
+	// A rolled back event's number goes to the next one
+	b.pending[entity][e.Seq] = &e
+	return &e, nil
+}
+
+// discard drops staged events whose transaction rolled back, unless their numbers have been
+// handed out again since
+func (b *Bus) discard(staged ...*Event) {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+	for _, e := range staged {
+		if b.pending[e.Kind.Entity()][e.Seq] == e {
+			delete(b.pending[e.Kind.Entity()], e.Seq)
+		}
+	}
+}
+
+// flush delivers the pending events of staged's entities that the log has, in the order it
+// numbered them. The first flush of an entity starts from the first event this bus staged: older
+// ones were delivered by an earlier process, or never will be.
+func (b *Bus) flush(ctx context.Context, staged ...*Event) {
+	var ready []Event
+	b.flushMu.Lock()
+	for _, entity := range entities(staged) {
+		pending := b.pending[entity]
+		if len(pending) == 0 {
+			continue
+		}
+		if b.delivered == nil {
+			b.delivered = make(map[string]int64)
+		}
+		first := slices.Min(mapKeys(pending))
+		after, known := b.delivered[entity]
+		if !known {
+			after = first - 1
+		}
+		restarted := !known
+		for {
+			logged, last, err := b.Log.EventsAfter(ctx, entity, after, flushBatch)
+			// Reset (storage.Resetter) or purged since: start over from the pending events
+			if (last < after || errors.Is(err, storage.ErrEventsExpired)) && !restarted {
+				after, restarted = first-1, true
+				continue
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "Error reading the event log", "entity", entity, "error", err)
+				break
+			}
+			for _, l := range logged {
+				if e, ok := pending[l.Seq]; ok {
+					ready = append(ready, *e)
+					delete(pending, l.Seq)
+				}
+				after = l.Seq
+			}
+			if len(logged) < flushBatch {
+				break
+			}
+		}
+		b.delivered[entity] = after
+	}
+	b.flushMu.Unlock()
+
+	// Outside the lock: a subscriber may publish
+	for _, e := range ready {
+		b.deliver(ctx, e)
+	}
+}
+
+// entities lists the entities of events, each once
+func entities(events []*Event) []string {
+	var list []string
+	for _, e := range events {
+		if entity := e.Kind.Entity(); !slices.Contains(list, entity) {
+			list = append(list, entity)
+		}
+	}
+	return list
+}
+
+// mapKeys returns m's keys in no particular order
+func mapKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// deliver hands e to every matching subscriber
+func (b *Bus) deliver(ctx context.Context, e Event) {
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		if len(s.kinds) == 0 || slices.Contains(s.kinds, e.Kind) {
+			deliver(ctx, s, e)
+		}
+	}
+}
+
+// orEmpty returns s, or an empty slice for nil so it encodes as []
+func orEmpty[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+func deliver(ctx context.Context, s subscription, e Event) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			slog.ErrorContext(ctx, "Event subscriber panicked", "subscriber", s.name, "event", e.Kind, "panic", rec)
+		}
+	}()
+	s.h(ctx, e)
+}
+
+// IDs returns the IDs of e's students
+func (e Event) IDs() []int64 {
+	ids := make([]int64, len(e.Students))
+	for i, st := range e.Students {
+		ids[i] = st.ID
+	}
+	return ids
+}
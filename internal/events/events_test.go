@@ -0,0 +1,214 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/sqlite"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// record subscribes to every kind and returns what it heard
+func record(bus *Bus) *[]Event {
+	var got []Event
+	bus.Subscribe("recorder", func(ctx context.Context, e Event) { got = append(got, e) })
+	return &got
+}
+
+func TestBusDelivery(t *testing.T) {
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	bus := New()
+	bus.Clock = clock.NewFake(now)
+
+	var order []string
+	bus.Subscribe("panics", func(context.Context, Event) { panic("boom") })
+	bus.Subscribe("created", func(context.Context, Event) { order = append(order, "created") }, StudentCreated)
+	bus.Subscribe("all", func(ctx context.Context, e Event) {
+		if ctx.Err() != nil {
+			t.Errorf("subscriber context already done: %v", ctx.Err())
+		}
+		if !e.At.Equal(now) {
+			t.Errorf("event At = %v, want %v", e.At, now)
+		}
+		order = append(order, "all:"+string(e.Kind))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bus.Publish(ctx, Event{Kind: StudentCreated})
+	bus.Publish(ctx, Event{Kind: StudentDeleted})
+
+	want := []string{"created", "all:student.created", "all:student.deleted"}
+	if len(order) != len(want) {
+		t.Fatalf("deliveries = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("deliveries = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestBusLog(t *testing.T) {
+	ctx := context.Background()
+	fake := storagetest.NewFake()
+	bus := New()
+	bus.Log = fake
+	got := record(bus)
+
+	bus.Publish(ctx, Event{Kind: StudentCreated, Students: []types.Student{{PublicID: "a"}}})
+	bus.Publish(ctx, Event{Kind: LoanOverdue})
+	bus.Publish(ctx, Event{Kind: StudentsChanged})
+	if seqs := [3]int64{(*got)[0].Seq, (*got)[1].Seq, (*got)[2].Seq}; seqs != [3]int64{1, 1, 2} {
+		t.Errorf("delivered seqs = %v, want students 1 and 2, loans 1", seqs)
+	}
+	logged, last, err := fake.EventsAfter(ctx, types.EntityStudent, 0, 10)
+	if err != nil || last != 2 || logged[0].Type != "student.created" || string(logged[1].Data) != `{"students":[]}` {
+		t.Errorf("student log = %+v, %d, %v, want both student events", logged, last, err)
+	}
+
+	// An event the log can't keep is still delivered, unnumbered
+	fake.FailNext(storagetest.MethodAppendEvent, storage.ErrDatabase)
+	bus.Publish(ctx, Event{Kind: StudentDeleted})
+	if e := (*got)[3]; e.Kind != StudentDeleted || e.Seq != 0 {
+		t.Errorf("event after a log failure = %+v, want StudentDeleted without a seq", e)
+	}
+}
+
+func TestStorePublishesWrites(t *testing.T) {
+	ctx := context.Background()
+	fake := storagetest.NewFake()
+	bus := New()
+	got := record(bus)
+	store := NewStore(fake, bus)
+
+	id, err := store.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 21})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	stored, _ := fake.GetStudent(id)
+	if len(*got) != 1 || (*got)[0].Kind != StudentCreated || (*got)[0].Bulk ||
+		(*got)[0].Students[0].ID != id || (*got)[0].Students[0].PublicID != stored.PublicID || stored.PublicID == "" {
+		t.Fatalf("events = %+v, want StudentCreated for %+v", *got, stored)
+	}
+
+	// Dry runs and failures announce nothing
+	batch := []types.Student{{Name: "Ravi", Email: "ravi@example.com", Age: 20}, {Name: "Meera", Email: "meera@example.com", Age: 19}}
+	if _, err := store.CreateStudents(storage.WithDryRun(ctx), batch); err != nil {
+		t.Fatalf("CreateStudents dry run: %v", err)
+	}
+	fake.FailNext(storagetest.MethodCreateStudents, storage.ErrDatabase)
+	if _, err := store.CreateStudents(ctx, batch); !errors.Is(err, storage.ErrDatabase) {
+		t.Fatalf("CreateStudents error = %v, want ErrDatabase", err)
+	}
+	if len(*got) != 1 {
+		t.Fatalf("events = %+v after a dry run and a failure, want none new", (*got)[1:])
+	}
+
+	ids, err := store.CreateStudents(ctx, batch)
+	if err != nil {
+		t.Fatalf("CreateStudents: %v", err)
+	}
+	e := (*got)[1]
+	if e.Kind != StudentCreated || !e.Bulk || len(e.Students) != 2 || e.Students[1].ID != ids[1] || e.Students[1].PublicID == "" {
+		t.Fatalf("bulk event = %+v, want StudentCreated for %v with public IDs", e, ids)
+	}
+	if batch[0].PublicID != "" {
+		t.Errorf("CreateStudents changed the caller's slice: %+v", batch[0])
+	}
+
+	if _, err := store.MergeStudents(ctx, id, ids[0]); err != nil {
+		t.Fatalf("MergeStudents: %v", err)
+	}
+	if len(*got) != 4 || (*got)[2].Kind != StudentUpdated || (*got)[2].Students[0].ID != id ||
+		(*got)[3].Kind != StudentDeleted || (*got)[3].Students[0].Email != "ravi@example.com" {
+		t.Fatalf("merge events = %+v, want StudentUpdated(%d) then StudentDeleted(%d)", (*got)[2:], id, ids[0])
+	}
+
+	if err := store.Reset(ctx); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if last := (*got)[len(*got)-1]; last.Kind != StudentsChanged {
+		t.Fatalf("last event = %+v, want StudentsChanged", last)
+	}
+}
+
+// failingLog fails AppendEvent while fail is set
+type failingLog struct {
+	Recorder
+	fail bool
+}
+
+func (l *failingLog) AppendEvent(ctx context.Context, e types.FeedEvent) (types.FeedEvent, error) {
+	if l.fail {
+		return types.FeedEvent{}, storage.ErrDatabase
+	}
+	return l.Recorder.AppendEvent(ctx, e)
+}
+
+func TestStoreOutbox(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewSqlite: %v", err)
+	}
+	defer db.Close()
+	log := &failingLog{Recorder: db}
+	bus := New()
+	bus.Log = log
+	got := record(bus)
+	store := NewStore(db, bus)
+
+	// The write and its event commit together, and the event is delivered numbered
+	id, err := store.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 21})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	if len(*got) != 1 || (*got)[0].Seq != 1 || (*got)[0].Students[0].ID != id {
+		t.Fatalf("events = %+v, want StudentCreated(%d) numbered 1", *got, id)
+	}
+
+	// A write whose event can't be kept doesn't happen
+	log.fail = true
+	batch := []types.Student{{Name: "Ravi", Email: "ravi@example.com", Age: 20}}
+	if _, err := store.CreateStudents(ctx, batch); !errors.Is(err, storage.ErrDatabase) {
+		t.Fatalf("CreateStudents with a failing log error = %v, want ErrDatabase", err)
+	}
+	log.fail = false
+	if n, _ := db.GetStudentsCount(); n != 1 || len(*got) != 1 {
+		t.Fatalf("after a failed append: %d students, events %+v; want the write rolled back, nothing delivered", n, *got)
+	}
+
+	// A failed write leaves no event behind
+	if _, err := store.MergeStudents(ctx, id, 999); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("MergeStudents(unknown) error = %v, want ErrNotFound", err)
+	}
+	if _, last, err := db.EventsAfter(ctx, types.EntityStudent, 0, 10); err != nil || last != 1 {
+		t.Fatalf("event log after failed writes = %d, %v, want still 1", last, err)
+	}
+
+	// Numbers stay without gaps, and events published outside a write are delivered from the log too
+	ids, err := store.CreateStudents(ctx, batch)
+	if err != nil {
+		t.Fatalf("CreateStudents: %v", err)
+	}
+	bus.Publish(ctx, Event{Kind: StudentsChanged})
+	if len(*got) != 3 || (*got)[1].Seq != 2 || (*got)[1].Students[0].ID != ids[0] || (*got)[2].Seq != 3 {
+		t.Fatalf("events = %+v, want StudentCreated(%d) numbered 2 and StudentsChanged numbered 3", *got, ids[0])
+	}
+
+	// A reset empties the log along with everything else; numbering starts over with its event
+	if err := store.Reset(ctx); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if e := (*got)[len(*got)-1]; len(*got) != 4 || e.Kind != StudentsChanged || e.Seq != 1 {
+		t.Fatalf("events = %+v, want StudentsChanged numbered 1 after the reset", *got)
+	}
+}
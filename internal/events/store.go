@@ -0,0 +1,422 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Store is a storage.Storage decorator that publishes an event for every successful write.
+// Failed writes and dry runs publish nothing. Store has methods for the capabilities that have
+// writes to publish; they forward to the wrapped storage, and storage.As only returns the Store
+// for them when the wrapped storage has the capability (see Forwards). As reaches the rest on the
+// wrapped storage.
+//
+// When pub is a Bus with a Log and the wrapped storage has transactions (storage.Transactor),
+// the events are appended to the log in the write's transaction and delivered from there: a
+// write whose events can't be kept fails, and a failed write leaves no events behind.
+type Store struct {
+	storage.Storage
+	pub Publisher
+}
+
+// NewStore wraps next
+func NewStore(next storage.Storage, pub Publisher) *Store {
+	return &Store{Storage: next, pub: pub}
+}
+
+// Unwrap returns the wrapped storage
+func (s *Store) Unwrap() storage.Storage {
+	return s.Storage
+}
+
+// Forwards implements storage.Forwarder: every capability the Store has is the wrapped storage's
+func (s *Store) Forwards(capability any) bool {
+	switch capability.(type) {
+	case *storage.Resetter, *storage.Merger, *storage.Alumni, *storage.Admissions, *storage.Announcements,
+		*storage.CustomFields, *storage.Updater, *storage.BulkUpdater, *storage.ImportCheckpointer:
+		return true
+	}
+	return false
+}
+
+// emit runs write and publishes the events it returns once it has succeeded, or with an outbox
+// (see Store) appends them in its transaction and delivers them from the log once it commits.
+// Dry runs publish nothing.
+func (s *Store) emit(ctx context.Context, write func(ctx context.Context) ([]Event, error)) error {
+	bus, tx, ok := s.outbox(ctx)
+	if !ok {
+		events, err := write(ctx)
+		if err == nil && !storage.IsDryRun(ctx) {
+			for _, e := range events {
+				s.pub.Publish(ctx, e)
+			}
+		}
+		return err
+	}
+
+	var staged []*Event
+	err := tx.InTx(ctx, func(ctx context.Context) error {
+		events, err := write(ctx)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			kept, err := bus.stage(ctx, e)
+			if err != nil {
+				return fmt.Errorf("%w: recording %s: %v", storage.ErrDatabase, e.Kind, err)
+			}
+			staged = append(staged, kept)
+		}
+		return nil
+	})
+	if err != nil {
+		bus.discard(staged...)
+		return err
+	}
+	// Subscribers react to a write that has happened; the request ending must not stop them
+	bus.flush(context.WithoutCancel(ctx), staged...)
+	return nil
+}
+
+// outbox returns the bus and the wrapped storage's transactions when writes made with ctx append
+// their events to the log themselves
+func (s *Store) outbox(ctx context.Context) (*Bus, storage.Transactor, bool) {
+	bus, ok := s.pub.(*Bus)
+	if !ok || bus.Log == nil || storage.IsDryRun(ctx) {
+		return nil, nil, false
+	}
+	tx, ok := storage.As[storage.Transactor](s.Storage)
+	return bus, tx, ok
+}
+
+// CreateStudent writes through and publishes StudentCreated. The public ID is assigned here when
+// the caller leaves it empty, so the event carries the one that was stored. CreateStudent takes
+// no context to join a transaction with, so with an outbox it is a one-student CreateStudents.
+func (s *Store) CreateStudent(st types.Student) (int64, error) {
+	if st.PublicID == "" {
+		st.PublicID = types.NewPublicID()
+	}
+	ctx := context.Background()
+	_, _, joined := s.outbox(ctx)
+	err := s.emit(ctx, func(ctx context.Context) ([]Event, error) {
+		var err error
+		if joined {
+			var ids []int64
+			if ids, err = s.Storage.CreateStudents(ctx, []types.Student{st}); err == nil {
+				st.ID = ids[0]
+			}
+		} else {
+			st.ID, err = s.Storage.CreateStudent(st)
+		}
+		return []Event{{Kind: StudentCreated, Students: []types.Student{st}}}, err
+	})
+	return st.ID, err
+}
+
+// CreateStudents writes through and publishes one bulk StudentCreated for the batch
+func (s *Store) CreateStudents(ctx context.Context, students []types.Student) ([]int64, error) {
+	if storage.IsDryRun(ctx) {
+		return s.Storage.CreateStudents(ctx, students)
+	}
+	// Copy before assigning public IDs; the caller's slice is theirs
+	written := make([]types.Student, len(students))
+	for i, st := range students {
+		if st.PublicID == "" {
+			st.PublicID = types.NewPublicID()
+		}
+		written[i] = st
+	}
+	var ids []int64
+	err := s.emit(ctx, func(ctx context.Context) ([]Event, error) {
+		var err error
+		if ids, err = s.Storage.CreateStudents(ctx, written); err != nil || len(ids) == 0 {
+			return nil, err
+		}
+		for i := range written {
+			written[i].ID = ids[i]
+		}
+		return []Event{{Kind: StudentCreated, Students: written, Bulk: true}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Reset forwards to the wrapped storage (if it supports it) and publishes StudentsChanged
+func (s *Store) Reset(ctx context.Context) error {
+	r, ok := storage.As[storage.Resetter](s.Storage)
+	if !ok {
+		return errors.New("storage does not support reset")
+	}
+	return s.emit(ctx, func(ctx context.Context) ([]Event, error) {
+		return []Event{{Kind: StudentsChanged}}, r.Reset(ctx)
+	})
+}
+
+// MergeStudents forwards to the wrapped storage (if it supports it) and publishes StudentUpdated
+// for the kept student and StudentDeleted for the merged one
+func (s *Store) MergeStudents(ctx context.Context, keepID, mergeID int64) (types.Student, error) {
+	m, ok := storage.As[storage.Merger](s.Storage)
+	if !ok {
+		return types.Student{}, errors.New("storage does not support merging")
+	}
+	// Read first: once merged, the student is gone from every read
+	merged, err := s.Storage.GetStudent(mergeID)
+	if err != nil {
+		merged = types.Student{ID: mergeID}
+	}
+	var student types.Student
+	err = s.emit(ctx, func(ctx context.Context) ([]Event, error) {
+		student, err = m.MergeStudents(ctx, keepID, mergeID)
+		return []Event{{Kind: StudentUpdated, Students: []types.Student{student}}, {Kind: StudentDeleted, Students: []types.Student{merged}}}, err
+	})
+	return student, err
+}
+
+// GraduateStudent forwards to the wrapped storage (if it supports it) and publishes
+// StudentGraduated for the graduate
+func (s *Store) GraduateStudent(ctx context.Context, studentID int64, classOf int, graduatedOn string) (types.Alumnus, error) {
+	al, ok := storage.As[storage.Alumni](s.Storage)
+	if !ok {
+		return types.Alumnus{}, errors.New("storage does not support alumni")
+	}
+	var alumnus types.Alumnus
+	err := s.emit(ctx, func(ctx context.Context) (events []Event, err error) {
+		alumnus, err = al.GraduateStudent(ctx, studentID, classOf, graduatedOn)
+		return []Event{{Kind: StudentGraduated, Students: []types.Student{alumnus.Student}}}, err
+	})
+	return alumnus, err
+}
+
+// GetAlumnusByPublicID forwards to the wrapped storage (if it supports it)
+func (s *Store) GetAlumnusByPublicID(ctx context.Context, publicID string) (types.Alumnus, error) {
+	al, ok := storage.As[storage.Alumni](s.Storage)
+	if !ok {
+		return types.Alumnus{}, errors.New("storage does not support alumni")
+	}
+	return al.GetAlumnusByPublicID(ctx, publicID)
+}
+
+// ListAlumni forwards to the wrapped storage (if it supports it)
+func (s *Store) ListAlumni(ctx context.Context, f types.AlumniFilter, offset, limit int) ([]types.Alumnus, int64, error) {
+	al, ok := storage.As[storage.Alumni](s.Storage)
+	if !ok {
+		return nil, 0, errors.New("storage does not support alumni")
+	}
+	return al.ListAlumni(ctx, f, offset, limit)
+}
+
+// SubmitApplication forwards to the wrapped storage (if it supports it)
+func (s *Store) SubmitApplication(ctx context.Context, app types.Application) (types.Application, error) {
+	ad, ok := storage.As[storage.Admissions](s.Storage)
+	if !ok {
+		return types.Application{}, errors.New("storage does not support admissions")
+	}
+	return ad.SubmitApplication(ctx, app)
+}
+
+// GetApplicationByPublicID forwards to the wrapped storage (if it supports it)
+func (s *Store) GetApplicationByPublicID(ctx context.Context, publicID string) (types.Application, error) {
+	ad, ok := storage.As[storage.Admissions](s.Storage)
+	if !ok {
+		return types.Application{}, errors.New("storage does not support admissions")
+	}
+	return ad.GetApplicationByPublicID(ctx, publicID)
+}
+
+// ListApplications forwards to the wrapped storage (if it supports it)
+func (s *Store) ListApplications(ctx context.Context, status string, offset, limit int) ([]types.Application, int64, error) {
+	ad, ok := storage.As[storage.Admissions](s.Storage)
+	if !ok {
+		return nil, 0, errors.New("storage does not support admissions")
+	}
+	return ad.ListApplications(ctx, status, offset, limit)
+}
+
+// SetApplicationStatus forwards to the wrapped storage (if it supports it)
+func (s *Store) SetApplicationStatus(ctx context.Context, id int64, status string) (types.Application, error) {
+	ad, ok := storage.As[storage.Admissions](s.Storage)
+	if !ok {
+		return types.Application{}, errors.New("storage does not support admissions")
+	}
+	return ad.SetApplicationStatus(ctx, id, status)
+}
+
+// ConvertApplication forwards to the wrapped storage (if it supports it) and publishes
+// StudentCreated for the new student
+func (s *Store) ConvertApplication(ctx context.Context, id int64, student types.Student) (types.Application, types.Student, error) {
+	ad, ok := storage.As[storage.Admissions](s.Storage)
+	if !ok {
+		return types.Application{}, types.Student{}, errors.New("storage does not support admissions")
+	}
+	var (
+		app     types.Application
+		created types.Student
+	)
+	err := s.emit(ctx, func(ctx context.Context) (events []Event, err error) {
+		app, created, err = ad.ConvertApplication(ctx, id, student)
+		return []Event{{Kind: StudentCreated, Students: []types.Student{created}}}, err
+	})
+	return app, created, err
+}
+
+// PostAnnouncement forwards to the wrapped storage (if it supports it) and publishes
+// AnnouncementPosted, which queues the deliveries
+func (s *Store) PostAnnouncement(ctx context.Context, a types.Announcement, f *types.Filter) (types.Announcement, error) {
+	an, ok := storage.As[storage.Announcements](s.Storage)
+	if !ok {
+		return types.Announcement{}, errors.New("storage does not support announcements")
+	}
+	var posted types.Announcement
+	err := s.emit(ctx, func(ctx context.Context) (events []Event, err error) {
+		posted, err = an.PostAnnouncement(ctx, a, f)
+		return []Event{{Kind: AnnouncementPosted, Announcements: []types.Announcement{posted}}}, err
+	})
+	return posted, err
+}
+
+// GetAnnouncementByPublicID forwards to the wrapped storage (if it supports it)
+func (s *Store) GetAnnouncementByPublicID(ctx context.Context, publicID string) (types.Announcement, error) {
+	an, ok := storage.As[storage.Announcements](s.Storage)
+	if !ok {
+		return types.Announcement{}, errors.New("storage does not support announcements")
+	}
+	return an.GetAnnouncementByPublicID(ctx, publicID)
+}
+
+// PendingDeliveries forwards to the wrapped storage (if it supports it)
+func (s *Store) PendingDeliveries(ctx context.Context, announcementID int64) ([]types.Delivery, error) {
+	an, ok := storage.As[storage.Announcements](s.Storage)
+	if !ok {
+		return nil, errors.New("storage does not support announcements")
+	}
+	return an.PendingDeliveries(ctx, announcementID)
+}
+
+// SetDeliveryStatus forwards to the wrapped storage (if it supports it)
+func (s *Store) SetDeliveryStatus(ctx context.Context, d types.Delivery) error {
+	an, ok := storage.As[storage.Announcements](s.Storage)
+	if !ok {
+		return errors.New("storage does not support announcements")
+	}
+	return an.SetDeliveryStatus(ctx, d)
+}
+
+// ReportDelivery forwards to the wrapped storage (if it supports it)
+func (s *Store) ReportDelivery(ctx context.Context, messageID, status, detail string) error {
+	an, ok := storage.As[storage.Announcements](s.Storage)
+	if !ok {
+		return errors.New("storage does not support announcements")
+	}
+	return an.ReportDelivery(ctx, messageID, status, detail)
+}
+
+// StudentAnnouncements forwards to the wrapped storage (if it supports it)
+func (s *Store) StudentAnnouncements(ctx context.Context, studentID int64) ([]types.StudentAnnouncement, error) {
+	an, ok := storage.As[storage.Announcements](s.Storage)
+	if !ok {
+		return nil, errors.New("storage does not support announcements")
+	}
+	return an.StudentAnnouncements(ctx, studentID)
+}
+
+// DefineCustomField forwards to the wrapped storage (if it supports it)
+func (s *Store) DefineCustomField(ctx context.Context, field types.CustomField) (types.CustomField, error) {
+	cf, ok := storage.As[storage.CustomFields](s.Storage)
+	if !ok {
+		return types.CustomField{}, errors.New("storage does not support custom fields")
+	}
+	return cf.DefineCustomField(ctx, field)
+}
+
+// ListCustomFields forwards to the wrapped storage (if it supports it)
+func (s *Store) ListCustomFields(ctx context.Context) ([]types.CustomField, error) {
+	cf, ok := storage.As[storage.CustomFields](s.Storage)
+	if !ok {
+		return nil, errors.New("storage does not support custom fields")
+	}
+	return cf.ListCustomFields(ctx)
+}
+
+// DeleteCustomField forwards to the wrapped storage (if it supports it) and publishes
+// StudentsChanged, since the field's values leave every student that had one
+func (s *Store) DeleteCustomField(ctx context.Context, name string) error {
+	cf, ok := storage.As[storage.CustomFields](s.Storage)
+	if !ok {
+		return errors.New("storage does not support custom fields")
+	}
+	return s.emit(ctx, func(ctx context.Context) ([]Event, error) {
+		return []Event{{Kind: StudentsChanged}}, cf.DeleteCustomField(ctx, name)
+	})
+}
+
+// UpdateStudent forwards to the wrapped storage (if it supports it) and publishes StudentUpdated
+// when anything changed, unless it was a dry run
+func (s *Store) UpdateStudent(ctx context.Context, student types.Student) (types.Student, []string, error) {
+	u, ok := storage.As[storage.Updater](s.Storage)
+	if !ok {
+		return types.Student{}, nil, errors.New("storage does not support updates")
+	}
+	var (
+		updated types.Student
+		changed []string
+	)
+	err := s.emit(ctx, func(ctx context.Context) (events []Event, err error) {
+		if updated, changed, err = u.UpdateStudent(ctx, student); err != nil || len(changed) == 0 {
+			return nil, err
+		}
+		return []Event{{Kind: StudentUpdated, Students: []types.Student{updated}}}, nil
+	})
+	return updated, changed, err
+}
+
+// PatchStudents forwards to the wrapped storage (if it supports it) and publishes StudentsChanged
+// when any student changed
+func (s *Store) PatchStudents(ctx context.Context, f *types.Filter, customFields map[string]any, max int) (types.BulkUpdate, error) {
+	b, ok := storage.As[storage.BulkUpdater](s.Storage)
+	if !ok {
+		return types.BulkUpdate{}, errors.New("storage does not support bulk updates")
+	}
+	var result types.BulkUpdate
+	err := s.emit(ctx, func(ctx context.Context) (events []Event, err error) {
+		if result, err = b.PatchStudents(ctx, f, customFields, max); err != nil || result.Updated == 0 {
+			return nil, err
+		}
+		return []Event{{Kind: StudentsChanged}}, nil
+	})
+	return result, err
+}
+
+// ImportProgress forwards to the wrapped storage (if it supports it)
+func (s *Store) ImportProgress(ctx context.Context, jobID int64) (types.ImportProgress, error) {
+	i, ok := storage.As[storage.ImportCheckpointer](s.Storage)
+	if !ok {
+		return types.ImportProgress{}, errors.New("storage does not support import checkpoints")
+	}
+	return i.ImportProgress(ctx, jobID)
+}
+
+// ImportBatch forwards to the wrapped storage (if it supports it) and publishes StudentCreated,
+// as a bulk create, for the students it inserted
+func (s *Store) ImportBatch(ctx context.Context, jobID int64, students []types.Student, row int) (types.ImportProgress, []types.Student, error) {
+	i, ok := storage.As[storage.ImportCheckpointer](s.Storage)
+	if !ok {
+		return types.ImportProgress{}, nil, errors.New("storage does not support import checkpoints")
+	}
+	var (
+		p       types.ImportProgress
+		written []types.Student
+	)
+	err := s.emit(ctx, func(ctx context.Context) (events []Event, err error) {
+		if p, written, err = i.ImportBatch(ctx, jobID, students, row); err != nil || len(written) == 0 {
+			return nil, err
+		}
+		return []Event{{Kind: StudentCreated, Students: written, Bulk: true}}, nil
+	})
+	return p, written, err
+}
@@ -0,0 +1,170 @@
+// Package export writes full dumps of the students, as CSV, newline-delimited JSON or Parquet, and
+// uploads them to an object storage bucket for a data warehouse to load.
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Dump formats
+const (
+	FormatCSV = "csv"
+	// FormatJSON is one student per line (NDJSON), which warehouses load without parsing one huge array
+	FormatJSON = "json"
+	// FormatParquet keeps the columns' types, so DuckDB or BigQuery load it without a schema
+	FormatParquet = "parquet"
+)
+
+// Formats lists the dump formats
+var Formats = []string{FormatCSV, FormatJSON, FormatParquet}
+
+// ManifestSuffix follows a dump's key to name its manifest
+const ManifestSuffix = ".manifest.json"
+
+const (
+	keyPrefix = "students-"
+	// timeLayout matches the backup snapshots' names and sorts in chronological order
+	timeLayout = "20060102T150405Z"
+)
+
+// csvHeader names the CSV columns; custom_fields holds the student's values as a JSON object
+var csvHeader = []string{"id", "name", "email", "age", "date_of_birth", "phone", "custom_fields"}
+
+// Streamer reads every student without loading them all into memory; storage.Storage is one
+type Streamer interface {
+	StreamStudents(ctx context.Context, fn func(types.Student) error) error
+}
+
+// Uploader stores body, size bytes long, as the object key
+type Uploader interface {
+	Upload(ctx context.Context, key, contentType string, body io.ReadSeeker, size int64) error
+}
+
+// Write dumps every student to w in format and returns how many it wrote
+func Write(ctx context.Context, store Streamer, format string, w io.Writer) (int, error) {
+	count := 0
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return 0, err
+		}
+		err := store.StreamStudents(ctx, func(s types.Student) error {
+			custom := ""
+			if len(s.CustomFields) > 0 {
+				b, err := json.Marshal(s.CustomFields)
+				if err != nil {
+					return err
+				}
+				custom = string(b)
+			}
+			count++
+			return cw.Write([]string{s.PublicID, s.Name, s.Email, strconv.Itoa(s.Age), s.DateOfBirth, s.Phone, custom})
+		})
+		if err != nil {
+			return count, err
+		}
+		cw.Flush()
+		return count, cw.Error()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		err := store.StreamStudents(ctx, func(s types.Student) error {
+			count++
+			return enc.Encode(s)
+		})
+		return count, err
+	case FormatParquet:
+		pw := newParquetWriter(w)
+		err := store.StreamStudents(ctx, func(s types.Student) error {
+			count++
+			return pw.Write(s)
+		})
+		if err != nil {
+			return count, err
+		}
+		return count, pw.Close()
+	}
+	return 0, fmt.Errorf("export: unknown format %q", format)
+}
+
+// Run dumps the students once per format and uploads each dump as
+// <prefix>students-<UTC timestamp>.<csv|ndjson|parquet>, followed by its Manifest as
+// <key>.manifest.json. A dump is spooled to a temporary file first, since an upload must know its
+// size and checksum before it starts. The manifest goes up last, so a loader that waits for it
+// never picks up a dump still being uploaded. It returns the dumps' keys.
+func Run(ctx context.Context, store Streamer, up Uploader, prefix string, formats []string, now time.Time) ([]string, error) {
+	var keys []string
+	for _, format := range formats {
+		key := prefix + Name(now, extension(format))
+		if err := dump(ctx, store, up, format, key, now); err != nil {
+			return keys, fmt.Errorf("export %s: %w", key, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Name is a dump's file name: students-<UTC timestamp>.<ext>
+func Name(now time.Time, ext string) string {
+	return keyPrefix + now.UTC().Format(timeLayout) + "." + ext
+}
+
+func dump(ctx context.Context, store Streamer, up Uploader, format, key string, now time.Time) (err error) {
+	f, err := os.CreateTemp("", "students-export-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close(), os.Remove(f.Name()))
+	}()
+
+	cw := &checksumWriter{w: f, sum: sha256.New()}
+	rows, err := Write(ctx, store, format, cw)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := up.Upload(ctx, key, contentType(format), f, cw.n); err != nil {
+		return err
+	}
+
+	m := Manifest{File: path.Base(key), Format: format, Rows: rows, Bytes: cw.n, SHA256: hex.EncodeToString(cw.sum.Sum(nil)), CreatedAt: now.UTC()}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return up.Upload(ctx, key+ManifestSuffix, "application/json", bytes.NewReader(b), int64(len(b)))
+}
+
+func extension(format string) string {
+	if format == FormatJSON {
+		return "ndjson"
+	}
+	return format
+}
+
+func contentType(format string) string {
+	switch format {
+	case FormatJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
@@ -0,0 +1,93 @@
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+type students []types.Student
+
+func (s students) StreamStudents(ctx context.Context, fn func(types.Student) error) error {
+	for _, st := range s {
+		if err := fn(st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bucket keeps what is uploaded to it
+type bucket map[string]string
+
+func (b bucket) Upload(ctx context.Context, key, contentType string, body io.ReadSeeker, size int64) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return errors.New("size doesn't match the body")
+	}
+	b[key] = contentType + "\n" + string(data)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	store := students{
+		{PublicID: "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", Name: "Asha Patil", Email: "asha@example.com", Age: 18,
+			DateOfBirth: "2007-04-12", CustomFields: map[string]any{"section": "B"}},
+		{PublicID: "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", Name: "Kumar, Ravi", Email: "ravi@example.com", Age: 21, Phone: "+919876543210"},
+	}
+	b := bucket{}
+	now := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+	keys, err := Run(context.Background(), store, b, "greenwood/", []string{FormatCSV, FormatJSON}, now)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := bucket{
+		"greenwood/students-20260101T030000Z.csv": "text/csv\n" +
+			"id,name,email,age,date_of_birth,phone,custom_fields\n" +
+			`1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed,Asha Patil,asha@example.com,18,2007-04-12,,"{""section"":""B""}"` + "\n" +
+			`9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d,"Kumar, Ravi",ravi@example.com,21,,+919876543210,` + "\n",
+		"greenwood/students-20260101T030000Z.ndjson": "application/x-ndjson\n" +
+			`{"id":"1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed","name":"Asha Patil","email":"asha@example.com","age":18,"date_of_birth":"2007-04-12","custom_fields":{"section":"B"}}` + "\n" +
+			`{"id":"9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d","name":"Kumar, Ravi","email":"ravi@example.com","age":21,"phone":"+919876543210"}` + "\n",
+	}
+	// Each dump has a manifest with its checksum
+	for key, data := range want {
+		manifest, ok := b[key+ManifestSuffix]
+		if !ok {
+			t.Fatalf("no manifest for %s", key)
+		}
+		delete(b, key+ManifestSuffix)
+		var m Manifest
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(manifest, "application/json\n")), &m); err != nil {
+			t.Fatalf("manifest of %s: %v", key, err)
+		}
+		_, body, _ := strings.Cut(data, "\n")
+		sum := sha256.Sum256([]byte(body))
+		if m.File != path.Base(key) || m.Rows != 2 || m.Bytes != int64(len(body)) || m.SHA256 != hex.EncodeToString(sum[:]) || !m.CreatedAt.Equal(now) {
+			t.Errorf("manifest of %s = %+v", key, m)
+		}
+	}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("uploaded %q\nwant %q", b, want)
+	}
+	if len(keys) != 2 || keys[0] != "greenwood/students-20260101T030000Z.csv" {
+		t.Errorf("keys = %v", keys)
+	}
+
+	if _, err := Run(context.Background(), store, b, "", []string{"xml"}, now); err == nil {
+		t.Error("Run(unknown format) succeeded")
+	}
+}
@@ -0,0 +1,357 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// A Parquet file is written by hand here, since the dump has one fixed schema and needs none of a
+// library's generality: every column is PLAIN encoded and uncompressed, in one data page per row
+// group. The metadata is Thrift's compact protocol, as the format specifies:
+// https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift
+
+// parquetRowGroup is how many students are buffered before they are written out as a row group
+const parquetRowGroup = 10000
+
+const parquetMagic = "PAR1"
+
+// Enum values from parquet.thrift
+const (
+	typeInt32     = 1
+	typeByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedNone = -1
+	convertedUTF8 = 0
+	convertedDate = 6
+	convertedJSON = 19
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageData = 0
+)
+
+// parquetColumn is one column of the schema and the values buffered for the current row group
+type parquetColumn struct {
+	name      string
+	physical  int32
+	converted int32
+	optional  bool
+
+	values bytes.Buffer
+	// present has one entry per row of an optional column: whether it has a value
+	present []bool
+}
+
+type parquetChunk struct {
+	offset, size int64
+}
+
+type parquetRowGroupMeta struct {
+	rows   int64
+	chunks []parquetChunk
+}
+
+// parquetWriter writes students as a Parquet file. The columns mirror the CSV dump's, with types
+// mapped from the student schema: age is an INT32, date_of_birth a DATE and custom_fields JSON.
+type parquetWriter struct {
+	w      io.Writer
+	offset int64
+	err    error
+
+	columns []*parquetColumn
+	rows    int
+	groups  []parquetRowGroupMeta
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	p := &parquetWriter{w: w, columns: []*parquetColumn{
+		{name: "id", physical: typeByteArray, converted: convertedUTF8},
+		{name: "name", physical: typeByteArray, converted: convertedUTF8},
+		{name: "email", physical: typeByteArray, converted: convertedUTF8},
+		{name: "age", physical: typeInt32, converted: convertedNone},
+		{name: "date_of_birth", physical: typeInt32, converted: convertedDate, optional: true},
+		{name: "phone", physical: typeByteArray, converted: convertedUTF8, optional: true},
+		{name: "custom_fields", physical: typeByteArray, converted: convertedJSON, optional: true},
+	}}
+	p.write([]byte(parquetMagic))
+	return p
+}
+
+// Write adds a student, writing out a row group once enough are buffered
+func (p *parquetWriter) Write(s types.Student) error {
+	id, name, email, age, dob, phone, custom := p.columns[0], p.columns[1], p.columns[2], p.columns[3], p.columns[4], p.columns[5], p.columns[6]
+	id.byteArray([]byte(s.PublicID))
+	name.byteArray([]byte(s.Name))
+	email.byteArray([]byte(s.Email))
+	age.int32(int32(s.Age))
+
+	// DATE counts days since the Unix epoch
+	if d, err := time.Parse(types.DateLayout, s.DateOfBirth); err == nil {
+		dob.int32(int32(d.Unix() / 86400))
+	} else {
+		dob.null()
+	}
+	if s.Phone != "" {
+		phone.byteArray([]byte(s.Phone))
+	} else {
+		phone.null()
+	}
+	if len(s.CustomFields) > 0 {
+		b, err := json.Marshal(s.CustomFields)
+		if err != nil {
+			return err
+		}
+		custom.byteArray(b)
+	} else {
+		custom.null()
+	}
+
+	p.rows++
+	if p.rows == parquetRowGroup {
+		p.flush()
+	}
+	return p.err
+}
+
+// Close writes out the buffered students and the footer. It doesn't close the underlying writer.
+func (p *parquetWriter) Close() error {
+	if p.rows > 0 {
+		p.flush()
+	}
+	var total int64
+	for _, g := range p.groups {
+		total += g.rows
+	}
+
+	var c compact
+	c.begin(0)
+	c.i32(1, 1) // version
+	c.list(2, ctStruct, len(p.columns)+1)
+	c.begin(0)
+	c.str(4, "schema")
+	c.i32(5, int32(len(p.columns)))
+	c.end()
+	for _, col := range p.columns {
+		c.begin(0)
+		c.i32(1, col.physical)
+		if col.optional {
+			c.i32(3, repetitionOptional)
+		} else {
+			c.i32(3, repetitionRequired)
+		}
+		c.str(4, col.name)
+		if col.converted != convertedNone {
+			c.i32(6, col.converted)
+		}
+		c.end()
+	}
+	c.i64(3, total)
+	c.list(4, ctStruct, len(p.groups))
+	for _, g := range p.groups {
+		c.begin(0)
+		c.list(1, ctStruct, len(g.chunks))
+		var size int64
+		for i, chunk := range g.chunks {
+			col := p.columns[i]
+			size += chunk.size
+			c.begin(0)
+			c.i64(2, chunk.offset)
+			c.begin(3)
+			c.i32(1, col.physical)
+			encodings := []int32{encodingPlain}
+			if col.optional {
+				encodings = append(encodings, encodingRLE)
+			}
+			c.list(2, ctI32, len(encodings))
+			for _, e := range encodings {
+				c.zigzag(int64(e))
+			}
+			c.list(3, ctBinary, 1)
+			c.binary([]byte(col.name))
+			c.i32(4, 0) // uncompressed
+			c.i64(5, g.rows)
+			c.i64(6, chunk.size)
+			c.i64(7, chunk.size)
+			c.i64(9, chunk.offset)
+			c.end()
+			c.end()
+		}
+		c.i64(2, size)
+		c.i64(3, g.rows)
+		c.end()
+	}
+	c.str(6, "go_students_api")
+	c.end()
+
+	p.write(c.b)
+	p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(c.b))))
+	p.write([]byte(parquetMagic))
+	return p.err
+}
+
+// flush writes the buffered rows as a row group of one data page per column
+func (p *parquetWriter) flush() {
+	g := parquetRowGroupMeta{rows: int64(p.rows)}
+	for _, col := range p.columns {
+		var page []byte
+		if col.optional {
+			levels := definitionLevels(col.present)
+			page = binary.LittleEndian.AppendUint32(page, uint32(len(levels)))
+			page = append(page, levels...)
+		}
+		page = append(page, col.values.Bytes()...)
+
+		var c compact
+		c.begin(0)
+		c.i32(1, pageData)
+		c.i32(2, int32(len(page)))
+		c.i32(3, int32(len(page)))
+		c.begin(5)
+		c.i32(1, int32(p.rows))
+		c.i32(2, encodingPlain)
+		c.i32(3, encodingRLE)
+		c.i32(4, encodingRLE)
+		c.end()
+		c.end()
+
+		chunk := parquetChunk{offset: p.offset, size: int64(len(c.b) + len(page))}
+		p.write(c.b)
+		p.write(page)
+		g.chunks = append(g.chunks, chunk)
+
+		col.values.Reset()
+		col.present = col.present[:0]
+	}
+	p.groups = append(p.groups, g)
+	p.rows = 0
+}
+
+func (p *parquetWriter) write(b []byte) {
+	if p.err != nil {
+		return
+	}
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	p.err = err
+}
+
+func (c *parquetColumn) byteArray(b []byte) {
+	c.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(b))))
+	c.values.Write(b)
+	c.present = append(c.present, true)
+}
+
+func (c *parquetColumn) int32(v int32) {
+	c.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(v)))
+	c.present = append(c.present, true)
+}
+
+// null leaves a row of an optional column without a value; only its definition level records it
+func (c *parquetColumn) null() {
+	c.present = append(c.present, false)
+}
+
+// definitionLevels encodes one bit-wide level per row, 1 for a value and 0 for null, with the
+// RLE half of the RLE/bit-packing hybrid: each run of equal levels is its length and the level
+func definitionLevels(present []bool) []byte {
+	var out []byte
+	for i := 0; i < len(present); {
+		j := i
+		for j < len(present) && present[j] == present[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if present[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+// Thrift compact protocol types
+const (
+	ctI32    = 5
+	ctI64    = 6
+	ctBinary = 8
+	ctList   = 9
+	ctStruct = 12
+)
+
+// compact encodes Thrift structs with the compact protocol. Fields must be written in ascending ID
+// order within each struct, as their headers hold the delta from the previous one.
+type compact struct {
+	b []byte
+	// last holds the ID of the last field written in each open struct
+	last []int16
+}
+
+func (c *compact) field(id int16, typ byte) {
+	last := &c.last[len(c.last)-1]
+	if d := id - *last; d > 0 && d <= 15 {
+		c.b = append(c.b, byte(d)<<4|typ)
+	} else {
+		c.b = append(c.b, typ)
+		c.zigzag(int64(id))
+	}
+	*last = id
+}
+
+// begin opens a struct as field id, or as a list element or the top-level struct when id is 0
+func (c *compact) begin(id int16) {
+	if id != 0 {
+		c.field(id, ctStruct)
+	}
+	c.last = append(c.last, 0)
+}
+
+func (c *compact) end() {
+	c.b = append(c.b, 0)
+	c.last = c.last[:len(c.last)-1]
+}
+
+func (c *compact) i32(id int16, v int32) {
+	c.field(id, ctI32)
+	c.zigzag(int64(v))
+}
+
+func (c *compact) i64(id int16, v int64) {
+	c.field(id, ctI64)
+	c.zigzag(v)
+}
+
+func (c *compact) str(id int16, s string) {
+	c.field(id, ctBinary)
+	c.binary([]byte(s))
+}
+
+// list starts a list field of n elements, which follow without field headers
+func (c *compact) list(id int16, elem byte, n int) {
+	c.field(id, ctList)
+	if n < 15 {
+		c.b = append(c.b, byte(n)<<4|elem)
+		return
+	}
+	c.b = append(c.b, 0xf0|elem)
+	c.b = binary.AppendUvarint(c.b, uint64(n))
+}
+
+func (c *compact) binary(b []byte) {
+	c.b = binary.AppendUvarint(c.b, uint64(len(b)))
+	c.b = append(c.b, b...)
+}
+
+func (c *compact) zigzag(v int64) {
+	c.b = binary.AppendUvarint(c.b, uint64(v<<1^v>>63))
+}
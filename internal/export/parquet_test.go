@@ -0,0 +1,124 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestParquet(t *testing.T) {
+	store := students{
+		{PublicID: "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", Name: "Asha Patil", Email: "asha@example.com", Age: 18, DateOfBirth: "2007-04-12"},
+		{PublicID: "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", Name: "Ravi Kumar", Email: "ravi@example.com", Age: 21, Phone: "+919876543210"},
+	}
+	var buf bytes.Buffer
+	if n, err := Write(context.Background(), store, FormatParquet, &buf); err != nil || n != 2 {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	b := buf.Bytes()
+	if string(b[:4]) != parquetMagic || string(b[len(b)-4:]) != parquetMagic {
+		t.Fatalf("file doesn't start and end with %s", parquetMagic)
+	}
+	size := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	r := &thriftReader{b: b, i: len(b) - 8 - size}
+	meta := r.readStruct()
+	if r.i != len(b)-8 {
+		t.Fatalf("footer is %d bytes, read %d", size, r.i-(len(b)-8-size))
+	}
+
+	if meta[3] != int64(2) {
+		t.Errorf("num_rows = %v, want 2", meta[3])
+	}
+	var names []string
+	for _, el := range meta[2].([]any)[1:] {
+		names = append(names, string(el.(map[int16]any)[4].([]byte)))
+	}
+	if !reflect.DeepEqual(names, csvHeader) {
+		t.Errorf("columns = %v, want %v", names, csvHeader)
+	}
+
+	// The age column's page: INT32 values, PLAIN encoded after the page header
+	chunks := meta[4].([]any)[0].(map[int16]any)[1].([]any)
+	age := chunks[3].(map[int16]any)[3].(map[int16]any)
+	r = &thriftReader{b: b, i: int(age[9].(int64))}
+	header := r.readStruct()
+	page := b[r.i : r.i+int(header[3].(int64))]
+	if got := []int32{int32(binary.LittleEndian.Uint32(page)), int32(binary.LittleEndian.Uint32(page[4:]))}; !reflect.DeepEqual(got, []int32{18, 21}) {
+		t.Errorf("ages = %v, want [18 21]", got)
+	}
+	// date_of_birth is null for the second student: levels 1 then 0, as two runs of one
+	dob := chunks[4].(map[int16]any)[3].(map[int16]any)
+	r = &thriftReader{b: b, i: int(dob[9].(int64))}
+	r.readStruct()
+	levels := b[r.i+4 : r.i+4+int(binary.LittleEndian.Uint32(b[r.i:]))]
+	if !bytes.Equal(levels, []byte{2, 1, 2, 0}) {
+		t.Errorf("date_of_birth definition levels = %v", levels)
+	}
+	if days := int32(binary.LittleEndian.Uint32(b[r.i+4+len(levels):])); days != 13615 {
+		t.Errorf("date_of_birth = %d days, want 13615 (2007-04-12)", days)
+	}
+}
+
+// thriftReader decodes the Thrift compact protocol far enough to check the metadata written.
+// Structs come back as maps from field ID to value.
+type thriftReader struct {
+	b []byte
+	i int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[r.i:])
+	r.i += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) readStruct() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		h := r.b[r.i]
+		r.i++
+		if h == 0 {
+			return fields
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.read(h & 0x0f)
+		last = id
+	}
+}
+
+func (r *thriftReader) read(typ byte) any {
+	switch typ {
+	case ctI32, ctI64:
+		return r.zigzag()
+	case ctBinary:
+		n := int(r.uvarint())
+		r.i += n
+		return r.b[r.i-n : r.i]
+	case ctList:
+		h := r.b[r.i]
+		r.i++
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]any, n)
+		for k := range list {
+			list[k] = r.read(h & 0x0f)
+		}
+		return list
+	case ctStruct:
+		return r.readStruct()
+	}
+	panic("unexpected thrift type")
+}
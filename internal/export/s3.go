@@ -0,0 +1,194 @@
+package export
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Server-side encryption an S3 upload can ask for
+const (
+	EncryptionAES256 = "AES256"
+	EncryptionKMS    = "aws:kms"
+)
+
+// S3 uploads objects with S3's PutObject call, signed with Signature Version 4. Any service
+// speaking that API works: AWS S3, Google Cloud Storage's XML API with HMAC keys, MinIO. No SDK
+// is needed for the one call we make.
+type S3 struct {
+	// Endpoint is e.g. "https://s3.eu-west-1.amazonaws.com" or "https://storage.googleapis.com"
+	Endpoint string
+	// Region signs the requests: the bucket's region for AWS, "auto" for GCS
+	Region string
+	Bucket string
+	// PathStyle puts the bucket in the path (https://host/bucket/key) rather than the host name
+	// (https://bucket.host/key); MinIO and other self-hosted services usually need it
+	PathStyle       bool
+	AccessKeyID     string
+	SecretAccessKey string
+	// Encryption is the server-side encryption to ask for: EncryptionAES256, EncryptionKMS with
+	// KMSKeyID (or the account's default key when it is ""), or "" for the bucket's default.
+	// GCS encrypts every object itself and takes "".
+	Encryption string
+	KMSKeyID   string
+	HTTP       *http.Client
+}
+
+// Upload implements Uploader
+func (s *S3) Upload(ctx context.Context, key, contentType string, body io.ReadSeeker, size int64) error {
+	// The signature covers the payload's hash, so the body is read twice
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return fmt.Errorf("export: endpoint: %w", err)
+	}
+	path := "/" + key
+	if s.PathStyle {
+		path = "/" + s.Bucket + path
+	} else {
+		endpoint.Host = s.Bucket + "." + endpoint.Host
+	}
+	endpoint.Path, endpoint.RawPath = path, uriEncode(path, false)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint.String(), io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	if s.Encryption != "" {
+		req.Header.Set("X-Amz-Server-Side-Encryption", s.Encryption)
+		if s.Encryption == EncryptionKMS && s.KMSKeyID != "" {
+			req.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", s.KMSKeyID)
+		}
+	}
+	signV4(req, hex.EncodeToString(h.Sum(nil)), s.Region, s.AccessKeyID, s.SecretAccessKey, time.Now())
+
+	httpClient := s.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("export: uploading %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	// Errors come back as <Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>
+	var out struct {
+		Code    string
+		Message string
+	}
+	if xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&out) == nil && out.Code != "" {
+		return fmt.Errorf("export: status %d: %s: %s", resp.StatusCode, out.Code, out.Message)
+	}
+	return fmt.Errorf("export: status %d", resp.StatusCode)
+}
+
+// signV4 adds the X-Amz-Date, X-Amz-Content-Sha256 and Authorization headers of AWS Signature
+// Version 4 to req, signing the host and every header already set:
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func signV4(req *http.Request, payloadHash, region, accessKeyID, secret string, now time.Time) {
+	stamp := now.UTC().Format("20060102T150405Z")
+	day := stamp[:8]
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hashHex(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+secret), day)
+	for _, part := range []string{region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+
+		",SignedHeaders="+signedHeaders+",Signature="+signature)
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var parts []string
+	for _, k := range keys {
+		values := slices.Clone(q[k])
+		slices.Sort(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters, as Signature Version 4
+// requires; a slash is kept unless encodeSlash
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hashHex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
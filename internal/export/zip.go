@@ -0,0 +1,70 @@
+package export
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"time"
+)
+
+// ManifestName is the manifest's name inside a zip archive
+const ManifestName = "manifest.json"
+
+// Manifest describes a dump, so whoever unpacks it can tell a complete one from a truncated one:
+// the rows it holds and the size and SHA-256 of its bytes
+type Manifest struct {
+	File      string    `json:"file"`
+	Format    string    `json:"format"`
+	Rows      int       `json:"rows"`
+	Bytes     int64     `json:"bytes"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Zip writes a zip archive to w holding file, whose content write produces, followed by its
+// manifest. write returns the rows it wrote. Both entries are deflated as they are written, so
+// nothing is held in memory beyond the compressor's window; zip's data descriptors spare the
+// writer from seeking back. A failed write leaves the archive without its central directory,
+// which unzip reports as corrupt.
+func Zip(w io.Writer, file, format string, now time.Time, write func(io.Writer) (int, error)) (Manifest, error) {
+	zw := zip.NewWriter(w)
+	m := Manifest{File: file, Format: format, CreatedAt: now.UTC()}
+
+	data, err := zw.CreateHeader(&zip.FileHeader{Name: file, Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return m, err
+	}
+	cw := &checksumWriter{w: data, sum: sha256.New()}
+	if m.Rows, err = write(cw); err != nil {
+		return m, err
+	}
+	m.Bytes, m.SHA256 = cw.n, hex.EncodeToString(cw.sum.Sum(nil))
+
+	mf, err := zw.CreateHeader(&zip.FileHeader{Name: ManifestName, Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return m, err
+	}
+	enc := json.NewEncoder(mf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return m, err
+	}
+	return m, zw.Close()
+}
+
+// checksumWriter hashes and counts what passes through it
+type checksumWriter struct {
+	w   io.Writer
+	sum hash.Hash
+	n   int64
+}
+
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.sum.Write(p[:n])
+	c.n += int64(n)
+	return n, err
+}
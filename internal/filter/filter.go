@@ -0,0 +1,383 @@
+// Package filter is the expression language behind GET /students?filter=...:
+//
+//	GET /students?filter=age >= 18 AND name ~ "kum"
+//	GET /students?filter=(phone = "" OR NOT email ~ "@example.com") AND date_of_birth < "2008-01-01"
+//
+// A comparison is a field, an operator and a literal: a number, true or false, or a string in
+// double quotes (with \" and \\ as escapes). Custom fields are named custom_fields.<name>, and
+// compare like the built-in field of their type. Comparisons combine with AND, OR and NOT (any case) and parentheses;
+// AND binds tighter than OR. Only the fields and operators listed here are accepted, and storages
+// translate each one to a fixed SQL expression with the literal as a bound parameter, so no client
+// input ever reaches the query text.
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Fields that can be filtered on
+const (
+	FieldName        = "name"
+	FieldEmail       = "email"
+	FieldPhone       = "phone"         // "" matches students without a phone
+	FieldAge         = "age"           // derived from the date of birth where known
+	FieldDateOfBirth = "date_of_birth" // YYYY-MM-DD; "" matches students without one
+
+	// CustomPrefix starts the name of a custom field (see types.CustomField) in a filter. String
+	// and date fields compare a missing value as ""; number and boolean comparisons never match it.
+	CustomPrefix = "custom_fields."
+)
+
+// Operators. OpContains (~) is a substring match, case-insensitive for ASCII letters.
+const (
+	OpEq       = "="
+	OpNe       = "!="
+	OpLt       = "<"
+	OpLe       = "<="
+	OpGt       = ">"
+	OpGe       = ">="
+	OpContains = "~"
+
+	OpAnd = "and"
+	OpOr  = "or"
+	OpNot = "not"
+)
+
+// Kinds of field values
+const (
+	KindString = "string"
+	KindNumber = "number"
+	KindDate   = "date"
+	KindBool   = "boolean"
+)
+
+// Field describes a filterable field
+type Field struct {
+	Kind string
+	Ops  []string
+}
+
+// Fields is the allowlist
+var Fields = map[string]Field{
+	FieldName:        {Kind: KindString, Ops: []string{OpEq, OpNe, OpContains}},
+	FieldEmail:       {Kind: KindString, Ops: []string{OpEq, OpNe, OpContains}},
+	FieldPhone:       {Kind: KindString, Ops: []string{OpEq, OpNe, OpContains}},
+	FieldAge:         {Kind: KindNumber, Ops: []string{OpEq, OpNe, OpLt, OpLe, OpGt, OpGe}},
+	FieldDateOfBirth: {Kind: KindDate, Ops: []string{OpEq, OpNe, OpLt, OpLe, OpGt, OpGe}},
+}
+
+// Limits keep a filter cheap to parse and to run
+const (
+	MaxLength      = 1000
+	MaxComparisons = 20
+	MaxDepth       = 10
+)
+
+// ErrInvalidFilter wraps every parse error, so handlers can answer 400
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// customKinds maps a custom field type to the kind of field it filters as
+var customKinds = map[string]Field{
+	types.CustomString:  {Kind: KindString, Ops: []string{OpEq, OpNe, OpContains}},
+	types.CustomNumber:  {Kind: KindNumber, Ops: []string{OpEq, OpNe, OpLt, OpLe, OpGt, OpGe}},
+	types.CustomBoolean: {Kind: KindBool, Ops: []string{OpEq, OpNe}},
+	types.CustomDate:    {Kind: KindDate, Ops: []string{OpEq, OpNe, OpLt, OpLe, OpGt, OpGe}},
+}
+
+// Parse parses and checks a filter expression over the built-in fields
+func Parse(s string) (types.Filter, error) {
+	return ParseWith(s, nil)
+}
+
+// ParseWith parses and checks a filter expression that may also compare the given custom fields
+func ParseWith(s string, custom []types.CustomField) (types.Filter, error) {
+	if len(s) > MaxLength {
+		return types.Filter{}, fmt.Errorf("%w: longer than %d bytes", ErrInvalidFilter, MaxLength)
+	}
+	toks, err := lex(s)
+	if err != nil {
+		return types.Filter{}, err
+	}
+	p := &parser{toks: toks, custom: custom}
+	f, err := p.or(0)
+	if err != nil {
+		return types.Filter{}, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return types.Filter{}, p.errorf(t, "unexpected %s", t)
+	}
+	return f, nil
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokOp
+	tokString
+	tokNumber
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int // byte offset, reported 1-based in errors
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of filter"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func lex(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")", i})
+			i++
+		case c == '=' || c == '~':
+			toks = append(toks, token{tokOp, string(c), i})
+			i++
+		case c == '!' || c == '<' || c == '>':
+			if i+1 < len(s) && s[i+1] == '=' {
+				toks = append(toks, token{tokOp, s[i : i+2], i})
+				i += 2
+			} else if c == '!' {
+				return nil, fmt.Errorf("%w: at %d: '!' must be followed by '='", ErrInvalidFilter, i+1)
+			} else {
+				toks = append(toks, token{tokOp, string(c), i})
+				i++
+			}
+		case c == '"':
+			var b strings.Builder
+			start := i
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, fmt.Errorf("%w: at %d: unterminated string", ErrInvalidFilter, start+1)
+				}
+				if s[i] == '"' {
+					i++
+					break
+				}
+				if s[i] == '\\' {
+					if i+1 >= len(s) || (s[i+1] != '"' && s[i+1] != '\\') {
+						return nil, fmt.Errorf("%w: at %d: only \\\" and \\\\ are valid escapes", ErrInvalidFilter, i+1)
+					}
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			toks = append(toks, token{tokString, b.String(), start})
+		case c == '-' || (c >= '0' && c <= '9'):
+			start := i
+			for i++; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+			}
+			toks = append(toks, token{tokNumber, s[start:i], start})
+		case isIdentByte(c):
+			// Dots join a custom field's name to CustomPrefix
+			start := i
+			for ; i < len(s) && (isIdentByte(s[i]) || (s[i] >= '0' && s[i] <= '9') || s[i] == '.'); i++ {
+			}
+			toks = append(toks, token{tokIdent, s[start:i], start})
+		default:
+			return nil, fmt.Errorf("%w: at %d: unexpected character %q", ErrInvalidFilter, i+1, c)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(s)}), nil
+}
+
+// isIdentByte reports whether c can start a field name or keyword
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+type parser struct {
+	toks        []token
+	i           int
+	comparisons int
+	custom      []types.CustomField
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf("%w: at %d: %s", ErrInvalidFilter, t.pos+1, fmt.Sprintf(format, args...))
+}
+
+// keyword reports whether the next token is the keyword kw, consuming it if so
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokIdent && strings.EqualFold(t.text, kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// or := and ("OR" and)*
+func (p *parser) or(depth int) (types.Filter, error) {
+	return p.chain(depth, OpOr, p.and)
+}
+
+// and := unary ("AND" unary)*
+func (p *parser) and(depth int) (types.Filter, error) {
+	return p.chain(depth, OpAnd, p.unary)
+}
+
+func (p *parser) chain(depth int, op string, operand func(int) (types.Filter, error)) (types.Filter, error) {
+	first, err := operand(depth)
+	if err != nil {
+		return first, err
+	}
+	args := []types.Filter{first}
+	for p.keyword(op) {
+		f, err := operand(depth)
+		if err != nil {
+			return f, err
+		}
+		args = append(args, f)
+	}
+	if len(args) == 1 {
+		return first, nil
+	}
+	return types.Filter{Op: op, Args: args}, nil
+}
+
+// unary := "NOT" unary | "(" or ")" | comparison
+func (p *parser) unary(depth int) (types.Filter, error) {
+	if depth >= MaxDepth {
+		return types.Filter{}, p.errorf(p.peek(), "nested more than %d levels", MaxDepth)
+	}
+	if p.keyword(OpNot) {
+		f, err := p.unary(depth + 1)
+		if err != nil {
+			return f, err
+		}
+		return types.Filter{Op: OpNot, Args: []types.Filter{f}}, nil
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		f, err := p.or(depth + 1)
+		if err != nil {
+			return f, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return f, p.errorf(t, "expected ')', got %s", t)
+		}
+		return f, nil
+	}
+	return p.comparison()
+}
+
+// comparison := field op literal
+func (p *parser) comparison() (types.Filter, error) {
+	ft := p.next()
+	if ft.kind != tokIdent {
+		return types.Filter{}, p.errorf(ft, "expected a field, got %s", ft)
+	}
+	field, ok := p.field(ft.text)
+	if !ok {
+		return types.Filter{}, p.errorf(ft, "unknown field %q (allowed: %s)", ft.text, strings.Join(p.fieldNames(), ", "))
+	}
+
+	ot := p.next()
+	if ot.kind != tokOp {
+		return types.Filter{}, p.errorf(ot, "expected an operator after %s, got %s", ft.text, ot)
+	}
+	if !slices.Contains(field.Ops, ot.text) {
+		return types.Filter{}, p.errorf(ot, "%s does not support %s (allowed: %s)", ft.text, ot.text, strings.Join(field.Ops, " "))
+	}
+
+	vt := p.next()
+	f := types.Filter{Op: ot.text, Field: ft.text}
+	switch {
+	case field.Kind == KindNumber && vt.kind == tokNumber:
+		n, err := strconv.Atoi(vt.text)
+		if err != nil {
+			return f, p.errorf(vt, "%s is not a valid number", vt.text)
+		}
+		f.Value = n
+	case field.Kind == KindDate && vt.kind == tokString:
+		if _, err := time.Parse(types.DateLayout, vt.text); err != nil && vt.text != "" {
+			return f, p.errorf(vt, "%s needs a YYYY-MM-DD date, got %s", ft.text, vt)
+		}
+		if vt.text == "" && ot.text != OpEq && ot.text != OpNe {
+			return f, p.errorf(vt, "%s can only compare \"\" with = and !=", ft.text)
+		}
+		f.Value = vt.text
+	case field.Kind == KindString && vt.kind == tokString:
+		f.Value = vt.text
+	case field.Kind == KindBool && vt.kind == tokIdent && (vt.text == "true" || vt.text == "false"):
+		f.Value = vt.text == "true"
+	default:
+		return f, p.errorf(vt, "%s needs a %s, got %s", ft.text, field.Kind, vt)
+	}
+
+	p.comparisons++
+	if p.comparisons > MaxComparisons {
+		return f, p.errorf(ft, "more than %d comparisons", MaxComparisons)
+	}
+	return f, nil
+}
+
+// field looks up a built-in field, or a custom field named with CustomPrefix
+func (p *parser) field(name string) (Field, bool) {
+	if custom, ok := strings.CutPrefix(name, CustomPrefix); ok {
+		for _, def := range p.custom {
+			if def.Name == custom {
+				return customKinds[def.Type], true
+			}
+		}
+		return Field{}, false
+	}
+	field, ok := Fields[name]
+	return field, ok
+}
+
+// fieldNames lists the fields this parser accepts, built-in ones first
+func (p *parser) fieldNames() []string {
+	names := FieldNames()
+	for _, def := range p.custom {
+		names = append(names, CustomPrefix+def.Name)
+	}
+	return names
+}
+
+// FieldNames lists the filterable fields, sorted
+func FieldNames() []string {
+	names := make([]string, 0, len(Fields))
+	for name := range Fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,160 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func TestParse(t *testing.T) {
+	cmp := func(field, op string, v any) types.Filter { return types.Filter{Op: op, Field: field, Value: v} }
+
+	tests := []struct {
+		in   string
+		want types.Filter
+	}{
+		{`age>=18`, cmp(FieldAge, OpGe, 18)},
+		{`name ~ "kum"`, cmp(FieldName, OpContains, "kum")},
+		{`name = "say \"hi\" \\o/"`, cmp(FieldName, OpEq, `say "hi" \o/`)},
+		{`age >= 18 AND name ~ "kum"`, types.Filter{Op: OpAnd, Args: []types.Filter{
+			cmp(FieldAge, OpGe, 18), cmp(FieldName, OpContains, "kum"),
+		}}},
+		// AND binds tighter than OR; keywords are case-insensitive
+		{`phone = "" or age < 10 and not email ~ "x"`, types.Filter{Op: OpOr, Args: []types.Filter{
+			cmp(FieldPhone, OpEq, ""),
+			{Op: OpAnd, Args: []types.Filter{
+				cmp(FieldAge, OpLt, 10),
+				{Op: OpNot, Args: []types.Filter{cmp(FieldEmail, OpContains, "x")}},
+			}},
+		}}},
+		{`(age = 1 OR age = 2) AND date_of_birth != ""`, types.Filter{Op: OpAnd, Args: []types.Filter{
+			{Op: OpOr, Args: []types.Filter{cmp(FieldAge, OpEq, 1), cmp(FieldAge, OpEq, 2)}},
+			cmp(FieldDateOfBirth, OpNe, ""),
+		}}},
+	}
+	for _, tc := range tests {
+		got, err := Parse(tc.in)
+		if err != nil {
+			t.Errorf("Parse(%s) error = %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Parse(%s) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{``, "expected a field"},
+		{`age`, "expected an operator"},
+		{`age >=`, "needs a number"},
+		{`age >= "18"`, "needs a number"},
+		{`name > "a"`, "does not support >"},
+		{`id = 1`, `unknown field "id"`},
+		{`name = 'x'`, "unexpected character"},
+		{`name = "x`, "unterminated string"},
+		{`name = "\n"`, "valid escapes"},
+		{`age ! 3`, "'!' must be followed by '='"},
+		{`date_of_birth < "2008-13-01"`, "YYYY-MM-DD"},
+		{`date_of_birth < ""`, "only compare"},
+		{`(age = 1`, "expected ')'"},
+		{`age = 1 age = 2`, "unexpected"},
+		{`age = 1; DROP TABLE students`, "unexpected character"},
+		{strings.Repeat("(", MaxDepth+1) + "age = 1" + strings.Repeat(")", MaxDepth+1), "nested more than"},
+		{strings.Repeat("age = 1 OR ", MaxComparisons) + "age = 1", "more than 20 comparisons"},
+		{`name = "` + strings.Repeat("a", MaxLength) + `"`, "longer than"},
+	}
+	for _, tc := range tests {
+		_, err := Parse(tc.in)
+		if !errors.Is(err, ErrInvalidFilter) || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("Parse(%.40s) error = %v, want ErrInvalidFilter mentioning %q", tc.in, err, tc.wantErr)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	s := types.Student{Name: "Asha KUMBHAR", Email: "asha@example.com", Age: 17, DateOfBirth: "2009-01-15"}
+
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{`name ~ "kum"`, true},
+		{`name = "asha kumbhar"`, false},
+		{`age >= 18`, false},
+		{`age = 17 AND date_of_birth < "2010-01-01"`, true},
+		{`phone = ""`, true},
+		{`phone != "" OR NOT email ~ "EXAMPLE"`, false},
+		{`date_of_birth >= "2009-01-15" AND date_of_birth != ""`, true},
+	}
+	for _, tc := range tests {
+		f, err := Parse(tc.in)
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", tc.in, err)
+		}
+		if got := Match(f, s, now); got != tc.want {
+			t.Errorf("Match(%s) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+
+	// A missing date of birth never satisfies an ordering
+	f, _ := Parse(`date_of_birth < "2100-01-01"`)
+	if Match(f, types.Student{Name: "Ravi"}, now) {
+		t.Errorf("Match(%+v) = true for a student without a date of birth", f)
+	}
+}
+
+func TestCustomFields(t *testing.T) {
+	custom := []types.CustomField{
+		{Name: "locker", Type: types.CustomNumber},
+		{Name: "hosteller", Type: types.CustomBoolean},
+		{Name: "school", Type: types.CustomString},
+	}
+	for _, tc := range []struct{ in, wantErr string }{
+		{`custom_fields.locker = "7"`, "needs a number"},
+		{`custom_fields.hosteller > true`, "does not support >"},
+		{`custom_fields.hosteller = 1`, "needs a boolean"},
+		{`custom_fields.caste = "x"`, `unknown field "custom_fields.caste"`},
+	} {
+		if _, err := ParseWith(tc.in, custom); !errors.Is(err, ErrInvalidFilter) || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("ParseWith(%s) error = %v, want ErrInvalidFilter mentioning %q", tc.in, err, tc.wantErr)
+		}
+	}
+	if _, err := Parse(`custom_fields.locker = 7`); err == nil {
+		t.Error("Parse accepted a custom field without definitions")
+	}
+
+	s := types.Student{Name: "Asha", CustomFields: map[string]any{"locker": float64(42), "hosteller": false}}
+	for _, tc := range []struct {
+		in   string
+		want bool
+	}{
+		{`custom_fields.locker > 40`, true},
+		{`custom_fields.hosteller = false`, true},
+		{`custom_fields.school = ""`, true},
+		{`custom_fields.school ~ "dps" OR custom_fields.locker < 10`, false},
+	} {
+		f, err := ParseWith(tc.in, custom)
+		if err != nil {
+			t.Fatalf("ParseWith(%s) error = %v", tc.in, err)
+		}
+		if got := Match(f, s, time.Now()); got != tc.want {
+			t.Errorf("Match(%s) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+
+	// A number or boolean comparison never matches a missing value, even with !=
+	f, _ := ParseWith(`custom_fields.locker != 1`, custom)
+	if Match(f, types.Student{Name: "Ravi"}, time.Now()) {
+		t.Errorf("Match(%+v) = true for a student without the field", f)
+	}
+}
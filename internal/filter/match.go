@@ -0,0 +1,127 @@
+package filter
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Match evaluates f against a student in memory, for storages without a query language. Ages
+// are derived as of now, and results agree with the SQL translation: ordering comparisons never
+// match a missing date of birth, and ~ folds ASCII letters only.
+func Match(f types.Filter, s types.Student, now time.Time) bool {
+	switch f.Op {
+	case OpAnd:
+		for _, a := range f.Args {
+			if !Match(a, s, now) {
+				return false
+			}
+		}
+		return true
+	case OpOr:
+		for _, a := range f.Args {
+			if Match(a, s, now) {
+				return true
+			}
+		}
+		return false
+	case OpNot:
+		return !Match(f.Args[0], s, now)
+	}
+
+	if name, ok := strings.CutPrefix(f.Field, CustomPrefix); ok {
+		return matchCustom(f, s.CustomFields[name])
+	}
+
+	switch f.Field {
+	case FieldAge:
+		s.DeriveAge(now)
+		return compare(s.Age-f.Value.(int), f.Op)
+	case FieldDateOfBirth:
+		want := f.Value.(string)
+		if s.DateOfBirth == "" && f.Op != OpEq && f.Op != OpNe {
+			return false
+		}
+		return compare(strings.Compare(s.DateOfBirth, want), f.Op)
+	}
+
+	var got string
+	switch f.Field {
+	case FieldName:
+		got = s.Name
+	case FieldEmail:
+		got = s.Email
+	case FieldPhone:
+		got = s.Phone
+	}
+	want := f.Value.(string)
+	if f.Op == OpContains {
+		return strings.Contains(asciiLower(got), asciiLower(want))
+	}
+	return compare(strings.Compare(got, want), f.Op)
+}
+
+// matchCustom compares the value of a custom field, as decoded from JSON (nil when the student
+// has none), the way the SQL translation does
+func matchCustom(f types.Filter, v any) bool {
+	switch want := f.Value.(type) {
+	case int:
+		got, ok := v.(float64)
+		if !ok {
+			return false
+		}
+		return compare(cmp.Compare(got, float64(want)), f.Op)
+	case bool:
+		got, ok := v.(bool)
+		return ok && compare(boolDiff(got, want), f.Op)
+	case string:
+		got, _ := v.(string)
+		if got == "" && f.Op != OpEq && f.Op != OpNe && f.Op != OpContains {
+			return false
+		}
+		if f.Op == OpContains {
+			return strings.Contains(asciiLower(got), asciiLower(want))
+		}
+		return compare(strings.Compare(got, want), f.Op)
+	}
+	return false
+}
+
+// boolDiff is 0 when a and b are equal, for compare
+func boolDiff(a, b bool) int {
+	if a == b {
+		return 0
+	}
+	return 1
+}
+
+// compare applies a comparison operator to the sign of a difference
+func compare(diff int, op string) bool {
+	switch op {
+	case OpEq:
+		return diff == 0
+	case OpNe:
+		return diff != 0
+	case OpLt:
+		return diff < 0
+	case OpLe:
+		return diff <= 0
+	case OpGt:
+		return diff > 0
+	case OpGe:
+		return diff >= 0
+	}
+	return false
+}
+
+// asciiLower lowercases A-Z only, like SQLite's lower()
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, s)
+}
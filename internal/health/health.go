@@ -0,0 +1,21 @@
+// Package health tracks whether this instance should receive traffic.
+package health
+
+import "sync/atomic"
+
+// Readiness is flipped to "draining" at the start of shutdown. The readiness probe then fails,
+// so load balancers stop routing new requests here before the listener is actually closed.
+// The zero value is ready.
+type Readiness struct {
+	draining atomic.Bool
+}
+
+// SetDraining marks the instance as shutting down; it never becomes ready again
+func (r *Readiness) SetDraining() {
+	r.draining.Store(true)
+}
+
+// Ready reports whether the instance should receive new traffic. A nil Readiness is always ready.
+func (r *Readiness) Ready() bool {
+	return r == nil || !r.draining.Load()
+}
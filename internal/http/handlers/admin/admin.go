@@ -0,0 +1,257 @@
+package admin
+
+import (
+	"cmp"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/deprecation"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/inflight"
+	"github.com/prashantkumbhar2002/go_students_api/internal/quota"
+	"github.com/prashantkumbhar2002/go_students_api/internal/recorder"
+	"github.com/prashantkumbhar2002/go_students_api/internal/retention"
+	"github.com/prashantkumbhar2002/go_students_api/internal/scheduler"
+	"github.com/prashantkumbhar2002/go_students_api/internal/seed"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/cache"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/workerpool"
+)
+
+// WorkerPoolStatsHandler reports queue depth and rejection counters of the background worker pool
+func WorkerPoolStatsHandler(pool *workerpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, pool.Stats())
+	}
+}
+
+// CacheStatsHandler reports hit/miss counters of the student list cache
+func CacheStatsHandler(c *cache.Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, c.Stats())
+	}
+}
+
+// DeprecationsHandler reports how much each deprecated route is still called, soonest sunset first.
+// A route nobody has called for a while can be removed.
+func DeprecationsHandler(reg *deprecation.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": reg.Usage()})
+	}
+}
+
+// JobsHandler reports the schedule and last-run outcome of every scheduled job
+func JobsHandler(s *scheduler.Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, map[string]any{"jobs": s.Status()})
+	}
+}
+
+// RunJobHandler starts a scheduled job now, off its schedule: POST /admin/jobs/{name}/run
+// The job runs in the background; GET /admin/jobs shows its outcome once it finishes.
+func RunJobHandler(s *scheduler.Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		name := r.PathValue("name")
+		found, started := s.Trigger(name)
+		if !found {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgJobNotFound), i18n.Tf(lang, i18n.MsgNoScheduledJobf, name))
+			return
+		}
+		if !started {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgJobRunning), i18n.Tf(lang, i18n.MsgJobStillRunningf, name))
+			return
+		}
+		slog.InfoContext(r.Context(), "Scheduled job started via admin endpoint", "job", name)
+		response.WriteJson(w, http.StatusAccepted, map[string]any{"name": name, "started": true})
+	}
+}
+
+// InFlightHandler lists the requests being served, oldest first
+func InFlightHandler(reg *inflight.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, map[string]any{"requests": reg.List()})
+	}
+}
+
+// CancelRequestHandler cancels the context of the in-flight request with the ID in the path:
+// POST /admin/requests/{id}/cancel. The handler serving it stops at its next context check, so
+// the request may stay listed for a moment.
+func CancelRequestHandler(reg *inflight.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		n := reg.Cancel(id)
+		if n == 0 {
+			lang := i18n.FromRequest(r)
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgRequestNotFound), i18n.Tf(lang, i18n.MsgNoRequestf, id))
+			return
+		}
+		slog.WarnContext(r.Context(), "Request cancelled via admin endpoint", "request_id", id, "count", n)
+		response.WriteJson(w, http.StatusAccepted, map[string]any{"id": id, "cancelled": n})
+	}
+}
+
+// defaultReplayBase is where replays are sent unless ?base= says otherwise
+const defaultReplayBase = "http://localhost:8080"
+
+// RecordingsHandler lists the recorded requests held in memory, newest first
+func RecordingsHandler(rec *recorder.Recorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, map[string]any{"recordings": rec.List()})
+	}
+}
+
+// RecordingHandler returns one recording, with a curl command that replays its request against a
+// local server: GET /admin/recordings/{seq}?base=http://localhost:8080
+func RecordingHandler(rec *recorder.Recorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		seq, err := strconv.ParseInt(r.PathValue("seq"), 10, 64)
+		recording, ok := rec.Get(seq)
+		if err != nil || !ok {
+			lang := i18n.FromRequest(r)
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgRecordingNotFound), i18n.Tf(lang, i18n.MsgNoRecordingf, r.PathValue("seq")))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"recording": recording, "replay": recording.Curl(cmp.Or(r.URL.Query().Get("base"), defaultReplayBase))})
+	}
+}
+
+// RetentionReportHandler previews the retention rules: how many rows each would anonymize or
+// delete if it ran now. Nothing is changed; the scheduled "retention" job applies the rules.
+func RetentionReportHandler(e *retention.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, err := e.Run(r.Context(), true)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error computing retention report", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(i18n.FromRequest(r), i18n.MsgRetentionError), err.Error())
+			return
+		}
+		response.WriteJson(w, http.StatusOK, report)
+	}
+}
+
+// UsageHandler reports how many requests each client made in a month against its quota, busiest
+// first: GET /admin/usage?month=2026-10 (the current month by default). Clients are named by the
+// SHA-256 of their API key.
+func UsageHandler(m *quota.Meter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		month := quota.Month(m.Now())
+		if v := r.URL.Query().Get("month"); v != "" {
+			if _, err := time.Parse(quota.MonthLayout, v); err != nil {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidMonth), i18n.T(lang, i18n.MsgMonthRule))
+				return
+			}
+			month = v
+		}
+		usage, err := m.Store.ListUsage(r.Context(), month)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing usage", "month", month, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgUsageError), err.Error())
+			return
+		}
+		if usage == nil {
+			usage = []types.Usage{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"month": month, "limit": m.Limit, "data": usage})
+	}
+}
+
+// SeedHandler inserts fake students: POST /admin/seed?count=100&seed=42
+// Only registered in dev environments.
+func SeedHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		count := 100
+		if v := r.URL.Query().Get("count"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > seed.MaxCount {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidCount), i18n.Tf(lang, i18n.MsgOutOfRangef, "count", 1, seed.MaxCount))
+				return
+			}
+			count = n
+		}
+
+		var seedValue int64
+		if v := r.URL.Query().Get("seed"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidSeed), err.Error())
+				return
+			}
+			seedValue = n
+		}
+
+		students, err := seed.Run(r.Context(), store, count, seedValue)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error seeding students", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgSeedError), err.Error())
+			return
+		}
+		ids := make([]string, len(students))
+		for i, st := range students {
+			ids[i] = st.PublicID
+		}
+
+		slog.InfoContext(r.Context(), "Seeded students", "count", len(ids), "seed", seedValue)
+		response.WriteJson(w, http.StatusCreated, map[string]any{"created": len(ids), "ids": ids})
+	}
+}
+
+// ResetHandler wipes all data and re-seeds it: POST /admin/reset?count=100&seed=1
+// Only registered in dev environments, so frontend developers can get back to a known state.
+// The default seed is fixed so every reset produces the same students.
+func ResetHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		resetter, ok := storage.As[storage.Resetter](store)
+		if !ok {
+			response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgResetUnsupported), i18n.T(lang, i18n.MsgCannotReset))
+			return
+		}
+
+		count := 100
+		if v := r.URL.Query().Get("count"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || n > seed.MaxCount {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidCount), i18n.Tf(lang, i18n.MsgOutOfRangef, "count", 0, seed.MaxCount))
+				return
+			}
+			count = n
+		}
+
+		seedValue := int64(1)
+		if v := r.URL.Query().Get("seed"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidSeed), err.Error())
+				return
+			}
+			seedValue = n
+		}
+
+		if err := resetter.Reset(r.Context()); err != nil {
+			slog.ErrorContext(r.Context(), "Error resetting database", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgResetError), err.Error())
+			return
+		}
+
+		seeded := 0
+		if count > 0 {
+			students, err := seed.Run(r.Context(), store, count, seedValue)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error seeding database after reset", "error", err)
+				response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgSeedError), err.Error())
+				return
+			}
+			seeded = len(students)
+		}
+
+		slog.WarnContext(r.Context(), "Database reset via admin endpoint", "seeded", seeded, "seed", seedValue)
+		response.WriteJson(w, http.StatusOK, map[string]any{"reset": true, "seeded": seeded})
+	}
+}
@@ -0,0 +1,263 @@
+package admissions
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/phone"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// statusRequest is the body of POST /applications/{id}/status
+type statusRequest struct {
+	Status string `json:"status"`
+}
+
+// SubmitHandler records an application for admission: POST /applications
+// The body is the one POST /students takes, and is validated by the same rules.
+func SubmitHandler(store storage.Storage, clk clock.Clock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		admissions, ok := admissionsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		var student types.Student
+		if !helpers.DecodeBody(w, r, lang, &student) {
+			return
+		}
+		student.DeriveAge(clk.Now())
+		if err := validation.Struct(student); err != nil {
+			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors), lang)
+			return
+		}
+		// Validation above guarantees this succeeds
+		if student.Phone != "" {
+			student.Phone, _ = phone.Normalize(student.Phone, "")
+		}
+
+		app, err := admissions.SubmitApplication(r.Context(), types.Application{
+			Name:        student.Name,
+			Email:       student.Email,
+			Age:         student.Age,
+			DateOfBirth: student.DateOfBirth,
+			Phone:       student.Phone,
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error submitting application", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Application submitted", "id", app.PublicID)
+		response.WriteJson(w, http.StatusCreated, labelled(app, lang))
+	}
+}
+
+// ListApplicationsHandler serves one page of applications, oldest first: GET /applications?status=submitted&page=1&limit=20
+func ListApplicationsHandler(store storage.Storage, limits types.PaginationLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		admissions, ok := admissionsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		status := r.URL.Query().Get("status")
+		if status != "" && !slices.Contains(types.ApplicationStatuses, status) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidAppStatus),
+				i18n.Tf(lang, i18n.MsgAppStatusf, strings.Join(types.ApplicationStatuses, ", ")))
+			return
+		}
+
+		pagination := helpers.ParsePaginationParams(r, limits)
+		list, total, err := admissions.ListApplications(r.Context(), status, (pagination.Page-1)*pagination.Limit, pagination.Limit)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing applications", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		for i := range list {
+			list[i] = labelled(list[i], lang)
+		}
+		if list == nil {
+			list = []types.Application{}
+		}
+
+		totalPages := int(total) / pagination.Limit
+		if int(total)%pagination.Limit != 0 {
+			totalPages++
+		}
+		helpers.SetPaginationHeaders(w, r, pagination, total, totalPages)
+		response.WriteJson(w, http.StatusOK, types.PaginatedResponse{
+			Data:       list,
+			Page:       pagination.Page,
+			Limit:      pagination.Limit,
+			TotalItems: total,
+			TotalPages: totalPages,
+			HasNext:    pagination.Page < totalPages,
+			HasPrev:    pagination.Page > 1,
+		})
+	}
+}
+
+// GetApplicationHandler returns one application: GET /applications/{id}
+func GetApplicationHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		admissions, ok := admissionsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		app, ok := lookup(w, r, admissions, lang)
+		if !ok {
+			return
+		}
+		response.WriteJson(w, http.StatusOK, labelled(app, lang))
+	}
+}
+
+// SetStatusHandler moves an application along the pipeline: POST /applications/{id}/status {"status": "reviewed"}
+// A submitted application can be reviewed or rejected, and a reviewed one accepted or rejected.
+func SetStatusHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		admissions, ok := admissionsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		app, ok := lookup(w, r, admissions, lang)
+		if !ok {
+			return
+		}
+		var req statusRequest
+		if !helpers.DecodeBody(w, r, lang, &req) {
+			return
+		}
+		if !slices.Contains(types.ApplicationStatuses, req.Status) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidAppStatus),
+				i18n.Tf(lang, i18n.MsgAppStatusf, strings.Join(types.ApplicationStatuses, ", ")))
+			return
+		}
+
+		from := app.Status
+		updated, err := admissions.SetApplicationStatus(r.Context(), app.ID, req.Status)
+		if errors.Is(err, storage.ErrApplicationStatus) {
+			// Re-read so the message names the status that blocked the move, which another
+			// reviewer may have changed since the lookup
+			if current, err := admissions.GetApplicationByPublicID(r.Context(), app.PublicID); err == nil {
+				from = current.Status
+			}
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgStatusNotAllowed),
+				i18n.Tf(lang, i18n.MsgTransitionf, i18n.Label(lang, i18n.EnumAppStatus, from), i18n.Label(lang, i18n.EnumAppStatus, req.Status)))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error changing application status", "id", app.PublicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Application status changed", "id", app.PublicID, "from", from, "to", updated.Status)
+		response.WriteJson(w, http.StatusOK, labelled(updated, lang))
+	}
+}
+
+// ConvertHandler makes the student record for an accepted application: POST /applications/{id}/convert
+// The applicant's details are validated again, since an age derived from the date of birth
+// moves on while the application waits. Answers 201 with the application, whose student_id
+// is the new student.
+func ConvertHandler(store storage.Storage, clk clock.Clock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		admissions, ok := admissionsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		app, ok := lookup(w, r, admissions, lang)
+		if !ok {
+			return
+		}
+		if app.StudentPublicID != "" {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgConverted), i18n.Tf(lang, i18n.MsgConvertedf, app.StudentPublicID))
+			return
+		}
+		if app.Status != types.ApplicationAccepted {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgStatusNotAllowed),
+				i18n.Tf(lang, i18n.MsgNotAcceptedf, i18n.Label(lang, i18n.EnumAppStatus, app.Status)))
+			return
+		}
+
+		student := app.Student()
+		student.DeriveAge(clk.Now())
+		if err := validation.Struct(student); err != nil {
+			response.WriteValidationErrors(w, http.StatusConflict, err.(validator.ValidationErrors), lang)
+			return
+		}
+		student.PublicID = types.NewPublicID()
+
+		app, student, err := admissions.ConvertApplication(r.Context(), app.ID, student)
+		switch {
+		case errors.Is(err, storage.ErrConverted):
+			// Converted by another request since the lookup
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgConverted), err.Error())
+			return
+		case errors.Is(err, storage.ErrApplicationStatus):
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgStatusNotAllowed), err.Error())
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "Error converting application", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Application converted", "id", app.PublicID, "student", student.PublicID)
+		response.WriteJson(w, http.StatusCreated, labelled(app, lang))
+	}
+}
+
+// lookup reads the application named by the {id} path value, writing a 400, 404 or 500 if it can't
+func lookup(w http.ResponseWriter, r *http.Request, admissions storage.Admissions, lang string) (types.Application, bool) {
+	publicID := strings.ToLower(r.PathValue("id"))
+	if !types.ValidPublicID(publicID) {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+		return types.Application{}, false
+	}
+	app, err := admissions.GetApplicationByPublicID(r.Context(), publicID)
+	if errors.Is(err, storage.ErrApplicationNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgAppNotFound), err.Error())
+		return types.Application{}, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up application", "id", publicID, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.Application{}, false
+	}
+	return app, true
+}
+
+// labelled returns app with its status label in lang
+func labelled(app types.Application, lang string) types.Application {
+	app.StatusLabel = i18n.Label(lang, i18n.EnumAppStatus, app.Status)
+	return app
+}
+
+// admissionsOf returns store as a storage.Admissions, or writes a 501 if it keeps no applications
+func admissionsOf(w http.ResponseWriter, store storage.Storage, lang string) (storage.Admissions, bool) {
+	admissions, ok := storage.As[storage.Admissions](store)
+	if !ok {
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgAppsUnsupported), i18n.T(lang, i18n.MsgNoAdmissions))
+	}
+	return admissions, ok
+}
@@ -0,0 +1,188 @@
+package alumni
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Bounds of a class year, in the body of a graduation and in ?class_of=
+const (
+	minClassOf = 1900
+	maxClassOf = 9999
+)
+
+// graduateRequest is the body of POST /students/{id}/graduate; both fields are optional
+type graduateRequest struct {
+	// ClassOf defaults to the year of GraduatedOn
+	ClassOf int `json:"class_of"`
+	// GraduatedOn defaults to today
+	GraduatedOn string `json:"graduated_on"`
+}
+
+// GraduateHandler makes a student an alumnus: POST /students/{id}/graduate {"class_of": 2025, "graduated_on": "2025-04-30"}
+// The body may be left out to graduate today with this year's class. The student then leaves
+// every student read, and their room and bus seat are released.
+func GraduateHandler(store storage.Storage, clk clock.Clock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		alumni, ok := alumniOf(w, store, lang)
+		if !ok {
+			return
+		}
+		publicID := strings.ToLower(r.PathValue("id"))
+		if !types.ValidPublicID(publicID) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+			return
+		}
+		var req graduateRequest
+		if err := helpers.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), helpers.DescribeDecodeError(err, lang))
+			return
+		}
+
+		today := clk.Now().UTC().Format(types.DateLayout)
+		if req.GraduatedOn == "" {
+			req.GraduatedOn = today
+		}
+		graduatedOn, err := time.Parse(types.DateLayout, req.GraduatedOn)
+		if err != nil || req.GraduatedOn > today {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidGraduation), i18n.T(lang, i18n.MsgGraduatedOnRule))
+			return
+		}
+		if req.ClassOf == 0 {
+			req.ClassOf = graduatedOn.Year()
+		}
+		if req.ClassOf < minClassOf || req.ClassOf > maxClassOf {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidClassOf), i18n.Tf(lang, i18n.MsgOutOfRangef, "class_of", minClassOf, maxClassOf))
+			return
+		}
+
+		student, err := store.GetStudentByPublicID(publicID)
+		if errors.Is(err, storage.ErrNotFound) {
+			// A graduate is gone from the students, so say why rather than just "not found"
+			if alumnus, err := alumni.GetAlumnusByPublicID(r.Context(), publicID); err == nil {
+				response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgGraduated), i18n.Tf(lang, i18n.MsgGraduatedf, alumnus.ClassOf))
+				return
+			}
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error looking up student to graduate", "id", publicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+
+		alumnus, err := alumni.GraduateStudent(r.Context(), student.ID, req.ClassOf, req.GraduatedOn)
+		if errors.Is(err, storage.ErrNotFound) {
+			// Graduated (or merged) by another request since the lookup
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error graduating student", "id", publicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Student graduated", "id", publicID, "class_of", alumnus.ClassOf)
+		response.WriteJson(w, http.StatusOK, alumnus)
+	}
+}
+
+// ListAlumniHandler serves one page of alumni, latest class first: GET /alumni?class_of=2025&q=ann&page=1&limit=20
+// q matches part of the name or email.
+func ListAlumniHandler(store storage.Storage, limits types.PaginationLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		alumni, ok := alumniOf(w, store, lang)
+		if !ok {
+			return
+		}
+		f := types.AlumniFilter{Query: strings.TrimSpace(r.URL.Query().Get("q"))}
+		if v := r.URL.Query().Get("class_of"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < minClassOf || n > maxClassOf {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidClassOf), i18n.Tf(lang, i18n.MsgOutOfRangef, "class_of", minClassOf, maxClassOf))
+				return
+			}
+			f.ClassOf = n
+		}
+
+		pagination := helpers.ParsePaginationParams(r, limits)
+		list, total, err := alumni.ListAlumni(r.Context(), f, (pagination.Page-1)*pagination.Limit, pagination.Limit)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing alumni", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if list == nil {
+			list = []types.Alumnus{}
+		}
+
+		totalPages := int(total) / pagination.Limit
+		if int(total)%pagination.Limit != 0 {
+			totalPages++
+		}
+		helpers.SetPaginationHeaders(w, r, pagination, total, totalPages)
+		response.WriteJson(w, http.StatusOK, types.PaginatedResponse{
+			Data:       list,
+			Page:       pagination.Page,
+			Limit:      pagination.Limit,
+			TotalItems: total,
+			TotalPages: totalPages,
+			HasNext:    pagination.Page < totalPages,
+			HasPrev:    pagination.Page > 1,
+		})
+	}
+}
+
+// GetAlumnusHandler returns one alumnus by the ID they had as a student: GET /alumni/{id}
+func GetAlumnusHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		alumni, ok := alumniOf(w, store, lang)
+		if !ok {
+			return
+		}
+		publicID := strings.ToLower(r.PathValue("id"))
+		if !types.ValidPublicID(publicID) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+			return
+		}
+		alumnus, err := alumni.GetAlumnusByPublicID(r.Context(), publicID)
+		if errors.Is(err, storage.ErrAlumnusNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgAlumnusNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error looking up alumnus", "id", publicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		response.WriteJson(w, http.StatusOK, alumnus)
+	}
+}
+
+// alumniOf returns store as a storage.Alumni, or writes a 501 if it keeps no alumni
+func alumniOf(w http.ResponseWriter, store storage.Storage, lang string) (storage.Alumni, bool) {
+	alumni, ok := storage.As[storage.Alumni](store)
+	if !ok {
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgAlumniUnsupported), i18n.T(lang, i18n.MsgNoAlumni))
+	}
+	return alumni, ok
+}
@@ -0,0 +1,199 @@
+package announcements
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/prashantkumbhar2002/go_students_api/internal/filter"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/sms"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// PostHandler sends an announcement: POST /announcements {"subject": "...", "body": "...", "filter": "age >= 18"}
+// filter takes the GET /students?filter= language and picks the recipients; without it the
+// announcement goes to every student. "channel": "sms" texts it to their phones instead of
+// emailing it. It answers once the deliveries are queued; each one's status shows on the
+// announcement as the messages go out.
+func PostHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		notices, ok := announcementsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		var a types.Announcement
+		if !helpers.DecodeBody(w, r, lang, &a) {
+			return
+		}
+		a.Filter = strings.TrimSpace(a.Filter)
+		if err := validation.Struct(a); err != nil {
+			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors), lang)
+			return
+		}
+		if a.Channel == types.ChannelSMS && utf8.RuneCountInString(a.Subject)+2+utf8.RuneCountInString(a.Body) > sms.MaxLength {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgSMSTooLong), i18n.Tf(lang, i18n.MsgSMSLengthf, sms.MaxLength-2))
+			return
+		}
+		var f *types.Filter
+		if a.Filter != "" {
+			// Recipients can be picked by custom field too
+			var defs []types.CustomField
+			if fields, ok := storage.As[storage.CustomFields](store); ok && strings.Contains(a.Filter, filter.CustomPrefix) {
+				var err error
+				if defs, err = fields.ListCustomFields(r.Context()); err != nil {
+					slog.ErrorContext(r.Context(), "Error listing custom fields", "error", err)
+					response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+					return
+				}
+			}
+			parsed, err := filter.ParseWith(a.Filter, defs)
+			if err != nil {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidFilter), err.Error())
+				return
+			}
+			f = &parsed
+		}
+
+		// The public ID is always ours to assign; an "id" in the body is ignored
+		a.PublicID = ""
+		posted, err := notices.PostAnnouncement(r.Context(), a, f)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error posting announcement", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Announcement posted", "id", posted.PublicID, "recipients", posted.Recipients)
+		response.WriteJson(w, http.StatusCreated, posted)
+	}
+}
+
+// GetHandler returns an announcement with its deliveries counted by status: GET /announcements/{id}
+func GetHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		notices, ok := announcementsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		publicID := strings.ToLower(r.PathValue("id"))
+		if !types.ValidPublicID(publicID) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+			return
+		}
+		a, err := notices.GetAnnouncementByPublicID(r.Context(), publicID)
+		if errors.Is(err, storage.ErrAnnouncementNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgNoticeNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error looking up announcement", "id", publicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		response.WriteJson(w, http.StatusOK, a)
+	}
+}
+
+// StudentAnnouncementsHandler lists the announcements sent to a student, newest first, each
+// with how its delivery went: GET /students/{id}/announcements
+func StudentAnnouncementsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		notices, ok := announcementsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		publicID := strings.ToLower(r.PathValue("id"))
+		if !types.ValidPublicID(publicID) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+			return
+		}
+		student, err := store.GetStudentByPublicID(publicID)
+		if errors.Is(err, storage.ErrNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error looking up student", "id", publicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+
+		list, err := notices.StudentAnnouncements(r.Context(), student.ID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing a student's announcements", "id", publicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		for i := range list {
+			list[i].StatusLabel = i18n.Label(lang, i18n.EnumDeliveryStatus, list[i].Status)
+		}
+		if list == nil {
+			list = []types.StudentAnnouncement{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": list})
+	}
+}
+
+// SMSReportHandler records the delivery reports the SMS provider posts for text messages:
+// POST /webhooks/sms. A report the provider didn't sign is refused with 403, and one for a
+// message we never sent with 404. Reports of a message still on its way are acknowledged and
+// otherwise ignored.
+func SMSReportHandler(store storage.Storage, receiver sms.Receiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		notices, ok := announcementsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		report, err := receiver.Receive(r)
+		if errors.Is(err, sms.ErrSignature) {
+			slog.WarnContext(r.Context(), "Refused an unsigned SMS delivery report", "remote_addr", r.RemoteAddr)
+			response.WriteError(w, http.StatusForbidden, i18n.T(lang, i18n.MsgBadSignature), err.Error())
+			return
+		}
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), err.Error())
+			return
+		}
+		if report.Status == "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		err = notices.ReportDelivery(r.Context(), report.MessageID, report.Status, report.Error)
+		if errors.Is(err, storage.ErrDeliveryNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgDeliveryNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error recording SMS delivery report", "message_id", report.MessageID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "SMS delivery reported", "message_id", report.MessageID, "status", report.Status)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// announcementsOf returns store as a storage.Announcements, or writes a 501 if it keeps no announcements
+func announcementsOf(w http.ResponseWriter, store storage.Storage, lang string) (storage.Announcements, bool) {
+	notices, ok := storage.As[storage.Announcements](store)
+	if !ok {
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgNoticesUnsupported), i18n.T(lang, i18n.MsgNoNotices))
+	}
+	return notices, ok
+}
@@ -0,0 +1,110 @@
+package customfields
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// DefineHandler adds a custom field to every student: POST /custom-fields {"name": "locker_number", "type": "number", "required": false}
+// Students then take a value for it under custom_fields, and GET /students can filter on
+// custom_fields.locker_number. A new required field only applies to students written from now on.
+func DefineHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		fields, ok := customFieldsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		var field types.CustomField
+		if !helpers.DecodeBody(w, r, lang, &field) {
+			return
+		}
+		if err := validation.Struct(field); err != nil {
+			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors), lang)
+			return
+		}
+		if !validation.ValidCustomFieldName(field.Name) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidField), i18n.T(lang, i18n.MsgFieldNameRule))
+			return
+		}
+
+		defined, err := fields.DefineCustomField(r.Context(), field)
+		if errors.Is(err, storage.ErrDuplicate) {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgFieldExists), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error defining custom field", "name", field.Name, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Custom field defined", "name", defined.Name, "type", defined.Type)
+		response.WriteJson(w, http.StatusCreated, defined)
+	}
+}
+
+// ListHandler lists the custom fields by name: GET /custom-fields
+func ListHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		fields, ok := customFieldsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		list, err := fields.ListCustomFields(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing custom fields", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if list == nil {
+			list = []types.CustomField{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": list})
+	}
+}
+
+// DeleteHandler removes a custom field and every student's value for it: DELETE /custom-fields/{name}
+func DeleteHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		fields, ok := customFieldsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		name := r.PathValue("name")
+		err := fields.DeleteCustomField(r.Context(), name)
+		if errors.Is(err, storage.ErrCustomFieldNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgFieldNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error deleting custom field", "name", name, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Custom field deleted", "name", name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// customFieldsOf returns store's custom fields, or writes a 501 and returns false
+func customFieldsOf(w http.ResponseWriter, store storage.Storage, lang string) (storage.CustomFields, bool) {
+	fields, ok := storage.As[storage.CustomFields](store)
+	if !ok {
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgFieldsUnsupported), i18n.T(lang, i18n.MsgNoCustomFields))
+	}
+	return fields, ok
+}
@@ -0,0 +1,79 @@
+// Package events serves the event log: every event published on the bus, numbered per entity,
+// for consumers that must not miss one (see internal/events).
+package events
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// ListHandler returns an entity's events after a sequence number, oldest first:
+// GET /events?entity=student&after_seq=41&limit=100
+// A consumer remembers the seq of the last event it handled and asks from there; last_seq is
+// how far the log has got, so while the data's last seq is below it there is more. Seqs have no
+// gaps, so a consumer that sees one skip knows it lost an event. Once events after after_seq
+// have been purged the answer is 410, and the consumer resyncs from the database.
+func ListHandler(store storage.Storage, limits types.PaginationLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		log, ok := storage.As[storage.EventLog](store)
+		if !ok {
+			response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgEventLogUnsupported), i18n.T(lang, i18n.MsgNoEventLog))
+			return
+		}
+		q := r.URL.Query()
+		entity := types.EntityStudent
+		if v := q.Get("entity"); v != "" {
+			entity = v
+		}
+		if !slices.Contains(types.EventEntities, entity) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidEntity),
+				i18n.Tf(lang, i18n.MsgEntityRulef, strings.Join(types.EventEntities, ", ")))
+			return
+		}
+		var afterSeq int64
+		if v := q.Get("after_seq"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidAfterSeq), i18n.T(lang, i18n.MsgAfterSeqRule))
+				return
+			}
+			afterSeq = n
+		}
+		limit := limits.Default
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < types.MinLimit || n > limits.Max {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidLimit),
+					i18n.Tf(lang, i18n.MsgOutOfRangef, "limit", types.MinLimit, limits.Max))
+				return
+			}
+			limit = n
+		}
+
+		events, last, err := log.EventsAfter(r.Context(), entity, afterSeq, limit)
+		if errors.Is(err, storage.ErrEventsExpired) {
+			response.WriteError(w, http.StatusGone, i18n.T(lang, i18n.MsgEventsExpired), i18n.Tf(lang, i18n.MsgResyncEventsf, afterSeq, last))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing events", "entity", entity, "after_seq", afterSeq, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if events == nil {
+			events = []types.FeedEvent{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": events, "last_seq": last})
+	}
+}
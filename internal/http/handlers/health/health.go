@@ -0,0 +1,21 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/health"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+)
+
+// ReadyHandler backs the readiness probe: 200 while serving, 503 once shutdown has begun
+func ReadyHandler(readiness *health.Readiness) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !readiness.Ready() {
+			lang := i18n.FromRequest(r)
+			response.WriteError(w, http.StatusServiceUnavailable, i18n.T(lang, i18n.MsgDraining), i18n.T(lang, i18n.MsgServerShuttingDown))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"status": "ready"})
+	}
+}
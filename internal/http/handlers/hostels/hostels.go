@@ -0,0 +1,331 @@
+package hostels
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// allocateRequest is the body of POST /rooms/{id}/students
+type allocateRequest struct {
+	StudentID string `json:"student_id"`
+}
+
+// AddHostelHandler adds a hostel: POST /hostels {"name": "Narmada"}
+func AddHostelHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		housing, ok := housingOf(w, store, lang)
+		if !ok {
+			return
+		}
+		var hostel types.Hostel
+		if !helpers.DecodeBody(w, r, lang, &hostel) {
+			return
+		}
+		if err := validation.Struct(hostel); err != nil {
+			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors), lang)
+			return
+		}
+		// The public ID is always ours to assign; an "id" in the body is ignored
+		hostel.PublicID = types.NewPublicID()
+
+		hostel, err := housing.AddHostel(r.Context(), hostel)
+		if errors.Is(err, storage.ErrDuplicate) {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgHostelExists), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error adding hostel", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Hostel added", "id", hostel.PublicID)
+		response.WriteJson(w, http.StatusCreated, hostel)
+	}
+}
+
+// OccupancyHandler reports beds and occupants per hostel: GET /hostels/occupancy
+func OccupancyHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		housing, ok := housingOf(w, store, lang)
+		if !ok {
+			return
+		}
+		report, err := housing.HostelOccupancy(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error computing hostel occupancy", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if report == nil {
+			report = []types.HostelOccupancy{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": report})
+	}
+}
+
+// AddRoomHandler adds a room to a hostel: POST /hostels/{id}/rooms {"number": "101", "capacity": 2}
+func AddRoomHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		housing, ok := housingOf(w, store, lang)
+		if !ok {
+			return
+		}
+		hostel, ok := hostelByPath(w, r, housing, lang)
+		if !ok {
+			return
+		}
+		var room types.Room
+		if !helpers.DecodeBody(w, r, lang, &room) {
+			return
+		}
+		if err := validation.Struct(room); err != nil {
+			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors), lang)
+			return
+		}
+		room.PublicID = types.NewPublicID()
+
+		room, err := housing.AddRoom(r.Context(), hostel.ID, room)
+		switch {
+		case err == nil:
+		case errors.Is(err, storage.ErrDuplicate):
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgRoomExists), err.Error())
+			return
+		case errors.Is(err, storage.ErrHostelNotFound):
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgHostelNotFound), err.Error())
+			return
+		default:
+			slog.ErrorContext(r.Context(), "Error adding room", "hostel", hostel.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		response.WriteJson(w, http.StatusCreated, room)
+	}
+}
+
+// RoomsHandler lists a hostel's rooms with their occupancy: GET /hostels/{id}/rooms
+func RoomsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		housing, ok := housingOf(w, store, lang)
+		if !ok {
+			return
+		}
+		hostel, ok := hostelByPath(w, r, housing, lang)
+		if !ok {
+			return
+		}
+		rooms, err := housing.HostelRooms(r.Context(), hostel.ID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing rooms", "hostel", hostel.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if rooms == nil {
+			rooms = []types.Room{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": rooms})
+	}
+}
+
+// AllocateHandler gives a student a bed in a room: POST /rooms/{id}/students {"student_id": "..."}
+// A full room, or a student who has a room already, is a 409.
+func AllocateHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		housing, ok := housingOf(w, store, lang)
+		if !ok {
+			return
+		}
+		room, ok := roomByPath(w, r, housing, lang)
+		if !ok {
+			return
+		}
+		var req allocateRequest
+		if !helpers.DecodeBody(w, r, lang, &req) {
+			return
+		}
+		student, ok := studentByID(w, r, store, lang, "student_id", req.StudentID)
+		if !ok {
+			return
+		}
+
+		allocation, err := housing.AllocateRoom(r.Context(), room.ID, student.ID)
+		switch {
+		case err == nil:
+		case errors.Is(err, storage.ErrRoomFull):
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgRoomFull), err.Error())
+			return
+		case errors.Is(err, storage.ErrHasRoom):
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgHasRoom), err.Error())
+			return
+		case errors.Is(err, storage.ErrRoomNotFound):
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgRoomNotFound), err.Error())
+			return
+		case errors.Is(err, storage.ErrNotFound):
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			return
+		default:
+			slog.ErrorContext(r.Context(), "Error allocating room", "room", room.ID, "student", student.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Room allocated", "room", room.PublicID, "student", student.PublicID)
+		response.WriteJson(w, http.StatusCreated, allocation)
+	}
+}
+
+// OccupantsHandler lists the students allocated a room: GET /rooms/{id}/students
+func OccupantsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		housing, ok := housingOf(w, store, lang)
+		if !ok {
+			return
+		}
+		room, ok := roomByPath(w, r, housing, lang)
+		if !ok {
+			return
+		}
+		allocations, err := housing.RoomAllocations(r.Context(), room.ID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing room occupants", "room", room.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if allocations == nil {
+			allocations = []types.Allocation{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": allocations})
+	}
+}
+
+// VacateHandler frees a student's bed: DELETE /rooms/{id}/students/{studentId}
+func VacateHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		housing, ok := housingOf(w, store, lang)
+		if !ok {
+			return
+		}
+		room, ok := roomByPath(w, r, housing, lang)
+		if !ok {
+			return
+		}
+		student, ok := studentByID(w, r, store, lang, "studentId", r.PathValue("studentId"))
+		if !ok {
+			return
+		}
+
+		err := housing.VacateRoom(r.Context(), room.ID, student.ID)
+		if errors.Is(err, storage.ErrNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgNotAllocated), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error vacating room", "room", room.ID, "student", student.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// housingOf returns store as a storage.Housing, or writes a 501 if it has no hostels
+func housingOf(w http.ResponseWriter, store storage.Storage, lang string) (storage.Housing, bool) {
+	housing, ok := storage.As[storage.Housing](store)
+	if !ok {
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgHostelsUnsupported), i18n.T(lang, i18n.MsgNoHostels))
+	}
+	return housing, ok
+}
+
+// hostelByPath loads the hostel named by the {id} path value, or writes a 400, 404 or 500 and
+// returns false
+func hostelByPath(w http.ResponseWriter, r *http.Request, housing storage.Housing, lang string) (types.Hostel, bool) {
+	publicID, ok := pathID(w, r, lang)
+	if !ok {
+		return types.Hostel{}, false
+	}
+	hostel, err := housing.GetHostelByPublicID(r.Context(), publicID)
+	if errors.Is(err, storage.ErrHostelNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgHostelNotFound), err.Error())
+		return types.Hostel{}, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up hostel", "id", publicID, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.Hostel{}, false
+	}
+	return hostel, true
+}
+
+// roomByPath loads the room named by the {id} path value, or writes a 400, 404 or 500 and
+// returns false
+func roomByPath(w http.ResponseWriter, r *http.Request, housing storage.Housing, lang string) (types.Room, bool) {
+	publicID, ok := pathID(w, r, lang)
+	if !ok {
+		return types.Room{}, false
+	}
+	room, err := housing.GetRoomByPublicID(r.Context(), publicID)
+	if errors.Is(err, storage.ErrRoomNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgRoomNotFound), err.Error())
+		return types.Room{}, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up room", "id", publicID, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.Room{}, false
+	}
+	return room, true
+}
+
+// pathID returns the {id} path value if it is a public ID, or writes a 400 and returns false
+func pathID(w http.ResponseWriter, r *http.Request, lang string) (string, bool) {
+	publicID := strings.ToLower(r.PathValue("id"))
+	if !types.ValidPublicID(publicID) {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+		return "", false
+	}
+	return publicID, true
+}
+
+// studentByID loads the student with public ID id, given by the field or path value name, or
+// writes a 400, 404 or 500 and returns false
+func studentByID(w http.ResponseWriter, r *http.Request, store storage.Storage, lang, name, id string) (types.Student, bool) {
+	id = strings.ToLower(id)
+	if !types.ValidPublicID(id) {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, name))
+		return types.Student{}, false
+	}
+	student, err := store.GetStudentByPublicID(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+		return types.Student{}, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up student", "id", id, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.Student{}, false
+	}
+	return student, true
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// GetJobHandler reports a background job's status for polling: GET /jobs/{id}
+// Clients poll until status is "succeeded" (result holds the outcome) or "dead".
+func GetJobHandler(queue storage.JobQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), err.Error())
+			return
+		}
+
+		job, err := queue.GetJob(r.Context(), id)
+		if errors.Is(err, storage.ErrJobNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgJobNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting job", "id", id, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+
+		// Tell pollers how soon it's worth asking again
+		if job.Status == types.JobQueued || job.Status == types.JobRunning {
+			w.Header().Set("Retry-After", "1")
+		}
+		job.StatusLabel = i18n.Label(lang, i18n.EnumJobStatus, job.Status)
+		response.WriteJson(w, http.StatusOK, job)
+	}
+}
@@ -0,0 +1,244 @@
+package library
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// DefaultLoanDays is how long a book is lent when the deployment doesn't say
+const DefaultLoanDays = 14
+
+// checkoutRequest is the body of POST /library/books/{id}/checkout
+type checkoutRequest struct {
+	StudentID string `json:"student_id"`
+	// DueDate is optional; it defaults to today plus the loan period
+	DueDate string `json:"due_date"`
+}
+
+// AddBookHandler adds a book to the catalogue: POST /library/books {"title": "...", "author": "...", "isbn": "..."}
+func AddBookHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		library, ok := libraryOf(w, store, lang)
+		if !ok {
+			return
+		}
+		var book types.Book
+		if !helpers.DecodeBody(w, r, lang, &book) {
+			return
+		}
+		if err := validation.Struct(book); err != nil {
+			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors), lang)
+			return
+		}
+		// The public ID is always ours to assign; an "id" in the body is ignored
+		book.PublicID = types.NewPublicID()
+
+		book, err := library.AddBook(r.Context(), book)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error adding book", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Book added", "id", book.PublicID)
+		response.WriteJson(w, http.StatusCreated, book)
+	}
+}
+
+// ListBooksHandler serves one page of the catalogue: GET /library/books?page=1&limit=20
+func ListBooksHandler(store storage.Storage, limits types.PaginationLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		library, ok := libraryOf(w, store, lang)
+		if !ok {
+			return
+		}
+		pagination := helpers.ParsePaginationParams(r, limits)
+		books, total, err := library.ListBooks(r.Context(), (pagination.Page-1)*pagination.Limit, pagination.Limit)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing books", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if books == nil {
+			books = []types.Book{}
+		}
+
+		totalPages := int(total) / pagination.Limit
+		if int(total)%pagination.Limit != 0 {
+			totalPages++
+		}
+		helpers.SetPaginationHeaders(w, r, pagination, total, totalPages)
+		response.WriteJson(w, http.StatusOK, types.PaginatedResponse{
+			Data:       books,
+			Page:       pagination.Page,
+			Limit:      pagination.Limit,
+			TotalItems: total,
+			TotalPages: totalPages,
+			HasNext:    pagination.Page < totalPages,
+			HasPrev:    pagination.Page > 1,
+		})
+	}
+}
+
+// CheckOutHandler lends a book to a student: POST /library/books/{id}/checkout {"student_id": "...", "due_date": "2025-06-30"}
+// Without a due date the book is due loanDays from today.
+func CheckOutHandler(store storage.Storage, clk clock.Clock, loanDays int) http.HandlerFunc {
+	if loanDays <= 0 {
+		loanDays = DefaultLoanDays
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		library, ok := libraryOf(w, store, lang)
+		if !ok {
+			return
+		}
+		book, ok := bookByPath(w, r, library, lang)
+		if !ok {
+			return
+		}
+		var req checkoutRequest
+		if !helpers.DecodeBody(w, r, lang, &req) {
+			return
+		}
+		req.StudentID = strings.ToLower(req.StudentID)
+		if !types.ValidPublicID(req.StudentID) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "student_id"))
+			return
+		}
+		today := clk.Now().UTC().Format(types.DateLayout)
+		if req.DueDate == "" {
+			req.DueDate = clk.Now().UTC().AddDate(0, 0, loanDays).Format(types.DateLayout)
+		} else if _, err := time.Parse(types.DateLayout, req.DueDate); err != nil || req.DueDate < today {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidDueDate), i18n.T(lang, i18n.MsgDueDateRule))
+			return
+		}
+
+		student, err := store.GetStudentByPublicID(req.StudentID)
+		if errors.Is(err, storage.ErrNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error looking up borrower", "id", req.StudentID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+
+		loan, err := library.CheckOutBook(r.Context(), book.ID, student.ID, req.DueDate)
+		switch {
+		case err == nil:
+		case errors.Is(err, storage.ErrOnLoan):
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgBookOnLoan), err.Error())
+			return
+		case errors.Is(err, storage.ErrBookNotFound):
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgBookNotFound), err.Error())
+			return
+		case errors.Is(err, storage.ErrNotFound):
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			return
+		default:
+			slog.ErrorContext(r.Context(), "Error checking out book", "book", book.ID, "student", student.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Book checked out", "book", book.PublicID, "student", student.PublicID, "due", loan.DueDate)
+		response.WriteJson(w, http.StatusCreated, loan)
+	}
+}
+
+// ReturnHandler closes a book's open loan: POST /library/books/{id}/return
+func ReturnHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		library, ok := libraryOf(w, store, lang)
+		if !ok {
+			return
+		}
+		book, ok := bookByPath(w, r, library, lang)
+		if !ok {
+			return
+		}
+
+		loan, err := library.ReturnBook(r.Context(), book.ID)
+		if errors.Is(err, storage.ErrNotOnLoan) {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgBookNotOnLoan), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error returning book", "book", book.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Book returned", "book", book.PublicID, "student", loan.StudentPublicID)
+		response.WriteJson(w, http.StatusOK, loan)
+	}
+}
+
+// OverdueHandler lists the open loans past their due date, longest overdue first: GET /library/loans/overdue
+func OverdueHandler(store storage.Storage, clk clock.Clock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		library, ok := libraryOf(w, store, lang)
+		if !ok {
+			return
+		}
+		loans, err := library.OverdueLoans(r.Context(), clk.Now())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing overdue loans", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if loans == nil {
+			loans = []types.Loan{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": loans})
+	}
+}
+
+// libraryOf returns store as a storage.Library, or writes a 501 if it has no library
+func libraryOf(w http.ResponseWriter, store storage.Storage, lang string) (storage.Library, bool) {
+	library, ok := storage.As[storage.Library](store)
+	if !ok {
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgLibraryUnsupported), i18n.T(lang, i18n.MsgNoLibrary))
+	}
+	return library, ok
+}
+
+// bookByPath loads the book named by the public ID in the {id} path value, or writes a 400, 404
+// or 500 and returns false
+func bookByPath(w http.ResponseWriter, r *http.Request, library storage.Library, lang string) (types.Book, bool) {
+	publicID := strings.ToLower(r.PathValue("id"))
+	if !types.ValidPublicID(publicID) {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+		return types.Book{}, false
+	}
+	book, err := library.GetBookByPublicID(r.Context(), publicID)
+	if errors.Is(err, storage.ErrBookNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgBookNotFound), err.Error())
+		return types.Book{}, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up book", "id", publicID, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.Book{}, false
+	}
+	return book, true
+}
@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/reports"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Bounds of the ?months= trend window
+const (
+	defaultMonths = 12
+	maxMonths     = 120
+)
+
+// StudentStatsHandler serves the principal's dashboard figures: GET /stats/students?months=12
+// Counts are computed by the database on every request; nothing is cached.
+func StudentStatsHandler(reporter storage.Reporter, clk clock.Clock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		months := defaultMonths
+		if v := r.URL.Query().Get("months"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxMonths {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidMonths), i18n.Tf(lang, i18n.MsgOutOfRangef, "months", 1, maxMonths))
+				return
+			}
+			months = n
+		}
+
+		stats, err := reporter.StudentStats(r.Context(), clk.Now(), months)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error computing student statistics", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		labelCounts(stats.ByAge, lang, i18n.EnumAgeBucket)
+		labelCounts(stats.ByStatus, lang, i18n.EnumStudentStatus)
+		response.WriteJson(w, http.StatusOK, stats)
+	}
+}
+
+// ReportHandler runs a widget's grouping query: GET /reports?group_by=status&metric=count
+// See internal/reports for the allowed dimensions and metrics.
+func ReportHandler(reporter storage.Reporter, clk clock.Clock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		q, err := reports.Parse(r.URL.Query())
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidReportQuery), err.Error())
+			return
+		}
+
+		report, err := reporter.Report(r.Context(), q, clk.Now())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error running report", "group_by", q.GroupBy, "metrics", q.Metrics, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		labelRows(report.Rows, lang)
+		response.WriteJson(w, http.StatusOK, report)
+	}
+}
+
+func labelCounts(counts []types.Count, lang, enum string) {
+	for i := range counts {
+		counts[i].Label = i18n.Label(lang, enum, counts[i].Key)
+	}
+}
+
+// labelledDims maps the report dimensions whose values are enums to their labels
+var labelledDims = map[string]string{
+	reports.DimAgeBucket:      i18n.EnumAgeBucket,
+	reports.DimStatus:         i18n.EnumStudentStatus,
+	reports.DimHasPhone:       i18n.EnumYesNo,
+	reports.DimHasDateOfBirth: i18n.EnumYesNo,
+}
+
+// labelRows adds "<dimension>_label" next to every enum dimension of a report row
+func labelRows(rows []map[string]any, lang string) {
+	for _, row := range rows {
+		for dim, enum := range labelledDims {
+			if v, ok := row[dim].(string); ok {
+				row[dim+"_label"] = i18n.Label(lang, enum, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package students
+
+import (
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/filter"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// DefaultBulkConfirmAbove is how many students a bulk update may change without confirm=true
+// when the deployment doesn't say
+const DefaultBulkConfirmAbove = 100
+
+// PatchStudentsHandler sets custom fields on every student a filter matches, in one transaction:
+// PATCH /students?filter=age>=18 {"custom_fields": {"section": "B", "locker_number": null}}
+// The body is a JSON merge patch limited to custom_fields, since the other fields belong to one
+// student each. When more than confirmAbove students match, nothing changes unless the request
+// has confirm=true.
+func PatchStudentsHandler(store storage.Storage, confirmAbove int) http.HandlerFunc {
+	if confirmAbove <= 0 {
+		confirmAbove = DefaultBulkConfirmAbove
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		bulk, ok := storage.As[storage.BulkUpdater](store)
+		if !ok {
+			response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgBulkUpdateUnsupported), i18n.T(lang, i18n.MsgCannotBulkUpdate))
+			return
+		}
+		q := r.URL.Query()
+		confirmed := false
+		if v := q.Get("confirm"); v != "" {
+			var err error
+			if confirmed, err = strconv.ParseBool(v); err != nil {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidConfirm), i18n.T(lang, i18n.MsgConfirmBoolean))
+				return
+			}
+		}
+		// Updating everyone takes a filter that says so, e.g. age >= 0
+		expr := q.Get("filter")
+		if expr == "" {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidFilter), i18n.T(lang, i18n.MsgFilterRequired))
+			return
+		}
+
+		var body map[string]any
+		if !decodedUpdate(w, r, lang, helpers.DecodeJSON(r.Body, &body)) {
+			return
+		}
+		values, ok := body["custom_fields"].(map[string]any)
+		if len(body) != 1 || !ok || len(values) == 0 {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), i18n.T(lang, i18n.MsgBulkFieldsRule))
+			return
+		}
+
+		defs, err := customFieldDefs(r.Context(), store)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing custom fields", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		f, err := filter.ParseWith(expr, defs)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidFilter), err.Error())
+			return
+		}
+		// Only the fields the patch names are checked: the rest keep each student's values. Null or
+		// "" clears a field, which a required one can't be.
+		for k, v := range values {
+			if v == "" {
+				values[k] = nil
+			}
+		}
+		named := slices.DeleteFunc(slices.Clone(defs), func(d types.CustomField) bool {
+			_, ok := values[d.Name]
+			return !ok
+		})
+		if errs := validation.CustomFields(named, values, lang); len(errs) > 0 {
+			writeCustomFieldErrors(w, map[int][]string{0: errs}, false, lang)
+			return
+		}
+
+		max := confirmAbove
+		if confirmed {
+			max = -1
+		}
+		result, err := bulk.PatchStudents(r.Context(), &f, values, max)
+		if errors.Is(err, storage.ErrConfirmationRequired) {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgConfirmationRequired),
+				i18n.Tf(lang, i18n.MsgConfirmAbovef, result.Matched, confirmAbove))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error updating students in bulk", "filter", expr, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Students updated in bulk", "filter", expr, "fields", slices.Sorted(maps.Keys(values)),
+			"matched", result.Matched, "updated", result.Updated)
+		response.WriteJson(w, http.StatusOK, result)
+	}
+}
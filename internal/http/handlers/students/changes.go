@@ -0,0 +1,83 @@
+package students
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// ChangesHandler lists the students created, updated or deleted since a time, for clients that
+// sync incrementally: GET /students/changes?since=2024-01-01T00:00:00Z&limit=100
+// Each student appears once, with its latest change. While truncated is true the client asks
+// again with next_since and next_after; once it is false they are where the next sync starts.
+// Once deletions since then have been purged the answer is 410, and the client starts over from
+// GET /students/export.
+func ChangesHandler(store storage.Storage, limits types.PaginationLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		feed, ok := storage.As[storage.Changes](store)
+		if !ok {
+			response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgChangesUnsupported), i18n.T(lang, i18n.MsgNoChanges))
+			return
+		}
+		q := r.URL.Query()
+		since, err := time.Parse(time.RFC3339, q.Get("since"))
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidSince), i18n.T(lang, i18n.MsgSinceRule))
+			return
+		}
+		// Changes are kept to the second, so a finer since would skip some at its second
+		since = since.UTC().Truncate(time.Second)
+		after := strings.ToLower(q.Get("after"))
+		if after != "" && !types.ValidPublicID(after) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "after"))
+			return
+		}
+		limit := limits.Default
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < types.MinLimit || n > limits.Max {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidLimit),
+					i18n.Tf(lang, i18n.MsgOutOfRangef, "limit", types.MinLimit, limits.Max))
+				return
+			}
+			limit = n
+		}
+
+		// One extra tells whether there are more
+		changes, err := feed.StudentChanges(r.Context(), since, after, limit+1)
+		if errors.Is(err, storage.ErrChangesExpired) {
+			response.WriteError(w, http.StatusGone, i18n.T(lang, i18n.MsgChangesExpired), i18n.T(lang, i18n.MsgResyncFromExport))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing student changes", "since", since, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		truncated := len(changes) > limit
+		if truncated {
+			changes = changes[:limit]
+		}
+		if changes == nil {
+			changes = []types.StudentChange{}
+		}
+		if n := len(changes); n > 0 {
+			since, after = changes[n-1].ChangedAt, changes[n-1].ID
+		}
+		body := map[string]any{"data": changes, "truncated": truncated, "next_since": since.Format(time.RFC3339)}
+		if after != "" {
+			body["next_after"] = after
+		}
+		response.WriteJson(w, http.StatusOK, body)
+	}
+}
@@ -0,0 +1,71 @@
+package students
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/filter"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	studentsvc "github.com/prashantkumbhar2002/go_students_api/internal/service/students"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// customFieldDefs returns store's custom field definitions; a store without custom fields has none
+func customFieldDefs(ctx context.Context, store storage.Storage) ([]types.CustomField, error) {
+	fields, ok := storage.As[storage.CustomFields](store)
+	if !ok {
+		return nil, nil
+	}
+	return fields.ListCustomFields(ctx)
+}
+
+// writeInvalid writes a 400 for a *studentsvc.InvalidError and returns true; any other error, nil
+// included, is left to the caller
+func writeInvalid(w http.ResponseWriter, r *http.Request, err error, bulk bool, lang string) bool {
+	var invalid *studentsvc.InvalidError
+	if !errors.As(err, &invalid) {
+		return false
+	}
+	slog.ErrorContext(r.Context(), "Request contains invalid students", "error", err)
+	switch {
+	case len(invalid.Fields) > 0 && bulk:
+		response.WriteBulkValidationErrors(w, http.StatusBadRequest, invalid.Fields, lang)
+	case len(invalid.Fields) > 0:
+		response.WriteValidationErrors(w, http.StatusBadRequest, invalid.Fields[0], lang)
+	default:
+		writeCustomFieldErrors(w, invalid.CustomFields, bulk, lang)
+	}
+	return true
+}
+
+// writeCustomFieldErrors writes custom field failures by student index; in a batch each is prefixed
+// with the student's index in the request, like WriteBulkValidationErrors does
+func writeCustomFieldErrors(w http.ResponseWriter, invalid map[int][]string, bulk bool, lang string) {
+	var msgs []string
+	for _, i := range slices.Sorted(maps.Keys(invalid)) {
+		for _, msg := range invalid[i] {
+			if bulk {
+				msg = fmt.Sprintf("[%d] %s", i, msg)
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+	response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgValidationErrors), strings.Join(msgs, "; "))
+}
+
+// filterFields returns the custom fields a ?filter= expression may compare: none unless it
+// names one, so plain filters cost no extra query
+func filterFields(ctx context.Context, store storage.Storage, expr string) ([]types.CustomField, error) {
+	if !strings.Contains(expr, filter.CustomPrefix) {
+		return nil, nil
+	}
+	return customFieldDefs(ctx, store)
+}
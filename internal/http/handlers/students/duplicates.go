@@ -0,0 +1,109 @@
+package students
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/duplicates"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// DuplicatesHandler lists probable duplicate students for review: GET /students/duplicates?limit=20
+// Every student is compared, so this reads the whole table; it is meant for occasional clean-ups.
+func DuplicatesHandler(store storage.Storage, limits types.PaginationLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		limit := limits.Default
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < types.MinLimit || n > limits.Max {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidLimit),
+					i18n.Tf(lang, i18n.MsgOutOfRangef, "limit", types.MinLimit, limits.Max))
+				return
+			}
+			limit = n
+		}
+
+		var all []types.Student
+		err := store.StreamStudents(r.Context(), func(st types.Student) error {
+			all = append(all, st)
+			return nil
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error reading students for duplicate detection", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+
+		groups := duplicates.Find(all)
+		truncated := len(groups) > limit
+		if truncated {
+			groups = groups[:limit]
+		}
+		for i, g := range groups {
+			groups[i].ReasonLabels = make([]string, len(g.Reasons))
+			for j, reason := range g.Reasons {
+				groups[i].ReasonLabels[j] = i18n.Label(lang, i18n.EnumDuplicateReason, reason)
+			}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": groups, "truncated": truncated})
+	}
+}
+
+// MergeStudentsHandler folds a duplicate into the student to keep: POST /students/{id}/merge/{otherId}
+// otherId is soft-deleted and {id} is returned with any date of birth or phone it lacked filled in.
+func MergeStudentsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		merger, ok := storage.As[storage.Merger](store)
+		if !ok {
+			response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgMergeUnsupported), i18n.T(lang, i18n.MsgCannotMerge))
+			return
+		}
+
+		// Both students are named by public ID; the merge itself works on internal IDs
+		var ids [2]int64
+		for i, name := range []string{"id", "otherId"} {
+			publicID := strings.ToLower(r.PathValue(name))
+			if !types.ValidPublicID(publicID) {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, name))
+				return
+			}
+			st, err := store.GetStudentByPublicID(publicID)
+			if errors.Is(err, storage.ErrNotFound) {
+				response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error looking up student to merge", "id", publicID, "error", err)
+				response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+				return
+			}
+			ids[i] = st.ID
+		}
+		keepID, mergeID := ids[0], ids[1]
+
+		student, err := merger.MergeStudents(r.Context(), keepID, mergeID)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrNotFound):
+				response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			case errors.Is(err, storage.ErrInvalidData):
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), err.Error())
+			default:
+				slog.ErrorContext(r.Context(), "Error merging students", "keep", keepID, "merge", mergeID, "error", err)
+				response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			}
+			return
+		}
+		response.WriteJson(w, http.StatusOK, student)
+	}
+}
@@ -0,0 +1,216 @@
+package students
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/importer"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// ImportOptions limit and place uploads for ImportStudentsHandler
+type ImportOptions struct {
+	// Dir is where uploads are spooled until the job runs ("" = OS temp dir)
+	Dir      string
+	MaxBytes int64
+	// UploadTimeout replaces the server read timeout for this route, since big files take a while
+	UploadTimeout time.Duration
+	// Staging means the storage can stage imports (storage.ImportStager), allowing preview=true
+	Staging bool
+}
+
+// ImportStudentsHandler accepts a CSV (text/csv) or JSON array (application/json) of students,
+// spools it to disk and queues an import job: POST /students/import
+// It responds 202 with the job ID; clients poll GET /jobs/{id} for the outcome. An upload whose
+// SHA-256 doesn't match its Content-Digest header is refused before a job is queued.
+//
+// With preview=true the job stages the valid rows and its result is a preview of the import
+// (importer.PreviewResult); nothing is imported until POST /imports/{id}/confirm.
+func ImportStudentsHandler(runner *jobs.Runner, opts ImportOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		format, err := importFormat(r)
+		if err != nil {
+			response.WriteError(w, http.StatusUnsupportedMediaType, i18n.T(lang, i18n.MsgInvalidRequestBody), err.Error())
+			return
+		}
+
+		preview := false
+		if v := r.URL.Query().Get("preview"); v != "" {
+			if preview, err = strconv.ParseBool(v); err != nil {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidPreview), i18n.T(lang, i18n.MsgPreviewBoolean))
+				return
+			}
+		}
+		if preview && !opts.Staging {
+			response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgPreviewUnsupported), i18n.T(lang, i18n.MsgNoStaging))
+			return
+		}
+
+		want, err := helpers.ParseContentDigest(r)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidContentDigest), err.Error())
+			return
+		}
+
+		if opts.UploadTimeout > 0 {
+			// Not every ResponseWriter supports deadlines; then the server timeout applies
+			http.NewResponseController(w).SetReadDeadline(time.Now().Add(opts.UploadTimeout))
+		}
+
+		f, err := os.CreateTemp(opts.Dir, "import-*."+format)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error creating import spool file", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		path := f.Name()
+
+		// Stream the body straight to disk, hashing it on the way; it is never held in memory
+		sum := sha256.New()
+		n, err := io.Copy(io.MultiWriter(f, sum), http.MaxBytesReader(w, r.Body, opts.MaxBytes))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil && n == 0 {
+			err = errors.New(i18n.T(lang, i18n.MsgEmptyRequestBody))
+		}
+		if err != nil {
+			os.Remove(path)
+			status := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			response.WriteError(w, status, i18n.T(lang, i18n.MsgInvalidRequestBody), err.Error())
+			return
+		}
+		if got := sum.Sum(nil); want != nil && !bytes.Equal(got, want) {
+			os.Remove(path)
+			slog.WarnContext(r.Context(), "Import refused: checksum mismatch", "bytes", n)
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgChecksumMismatch),
+				i18n.Tf(lang, i18n.MsgChecksumMismatchf, hex.EncodeToString(got), hex.EncodeToString(want)))
+			return
+		}
+
+		id, err := runner.Enqueue(r.Context(), importer.Kind, importer.Payload{Path: path, Format: format, Lang: lang, Preview: preview})
+		if err != nil {
+			os.Remove(path)
+			slog.ErrorContext(r.Context(), "Error queueing import job", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+
+		slog.InfoContext(r.Context(), "Import queued", "job_id", id, "format", format, "bytes", n, "verified", want != nil, "preview", preview)
+		w.Header().Set("Location", "/jobs/"+strconv.FormatInt(id, 10))
+		response.WriteJson(w, http.StatusAccepted, map[string]int64{"job_id": id})
+	}
+}
+
+// ConfirmImportHandler queues the import of a preview job's staged rows:
+// POST /imports/{id}/confirm
+// It responds 202 with the new job's ID, like an upload; its result counts the students imported
+// and the rows skipped because their email was taken by then. The preview must have succeeded
+// and not been confirmed or discarded already.
+func ConfirmImportHandler(runner *jobs.Runner, queue storage.JobQueue, stager storage.ImportStager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), err.Error())
+			return
+		}
+
+		job, err := queue.GetJob(r.Context(), id)
+		if errors.Is(err, storage.ErrJobNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgJobNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting job", "id", id, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		if job.Status != types.JobSucceeded {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgPreviewNotFinished),
+				i18n.Tf(lang, i18n.MsgPreviewStatusf, id, i18n.Label(lang, i18n.EnumJobStatus, job.Status)))
+			return
+		}
+		staged, err := stager.StagedRows(r.Context(), id, 0, 1)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error reading staged import", "id", id, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		if len(staged) == 0 {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgNothingStaged), i18n.Tf(lang, i18n.MsgNothingStagedf, id))
+			return
+		}
+
+		jobID, err := runner.Enqueue(r.Context(), importer.Kind, importer.Payload{Lang: lang, Confirm: id})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error queueing import job", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+
+		slog.InfoContext(r.Context(), "Staged import confirmed", "job_id", jobID, "preview_job_id", id)
+		w.Header().Set("Location", "/jobs/"+strconv.FormatInt(jobID, 10))
+		response.WriteJson(w, http.StatusAccepted, map[string]int64{"job_id": jobID})
+	}
+}
+
+// DiscardImportHandler deletes a preview job's staged rows without importing them:
+// DELETE /imports/{id}
+func DiscardImportHandler(stager storage.ImportStager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), err.Error())
+			return
+		}
+
+		err = stager.DiscardImport(r.Context(), id)
+		if errors.Is(err, storage.ErrNothingStaged) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgNothingStaged), i18n.Tf(lang, i18n.MsgNothingStagedf, id))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error discarding staged import", "id", id, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+
+		slog.InfoContext(r.Context(), "Staged import discarded", "preview_job_id", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// importFormat picks the parser from the Content-Type
+func importFormat(r *http.Request) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return importer.FormatCSV, nil
+	case "application/json":
+		return importer.FormatJSON, nil
+	}
+	return "", fmt.Errorf("Content-Type must be text/csv or application/json, got %q", r.Header.Get("Content-Type"))
+}
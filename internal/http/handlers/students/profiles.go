@@ -0,0 +1,182 @@
+package students
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/profile"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// ProfileOptions configure the profile PDF routes
+type ProfileOptions struct {
+	Renderer profile.Renderer
+	// Dir is where batch renders are written ("" = OS temp dir)
+	Dir string
+}
+
+// ProfilePDFHandler renders one student's profile: GET /students/{id}/profile.pdf
+// A single page is cheap enough to render while the client waits.
+func ProfilePDFHandler(store storage.Storage, rd profile.Renderer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		id := strings.ToLower(r.PathValue("id"))
+		if !types.ValidPublicID(id) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+			return
+		}
+
+		student, err := store.GetStudentByPublicID(id)
+		if errors.Is(err, storage.ErrNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting student for profile", "id", id, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+
+		// Render into memory first so a failure can still be answered with a JSON error
+		var buf bytes.Buffer
+		if err := rd.Render(&buf, []types.Student{student}); err != nil {
+			slog.ErrorContext(r.Context(), "Error rendering profile", "id", id, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgRenderError), err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", profile.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="profile-%s.pdf"`, id))
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		w.Write(buf.Bytes())
+	}
+}
+
+// QueueProfilesHandler queues a batch render for printing, e.g. a whole section:
+// POST /students/profiles with {"ids": [...]}, in print order. It responds 202 with the job ID;
+// once GET /jobs/{id} reports success, GET /students/profiles.pdf?job={id} downloads the PDF.
+func QueueProfilesHandler(runner *jobs.Runner, dir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		err := helpers.DecodeJSON(r.Body, &body)
+		if errors.Is(err, io.EOF) || (err == nil && len(body.IDs) == 0) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), i18n.T(lang, i18n.MsgEmptyRequestBody))
+			return
+		}
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), helpers.DescribeDecodeError(err, lang))
+			return
+		}
+		if len(body.IDs) > profile.MaxBatch {
+			response.WriteError(w, http.StatusRequestEntityTooLarge, i18n.T(lang, i18n.MsgInvalidRequestBody),
+				i18n.Tf(lang, i18n.MsgTooManyStudentsf, profile.MaxBatch))
+			return
+		}
+		for i, id := range body.IDs {
+			body.IDs[i] = strings.ToLower(id)
+			if !types.ValidPublicID(body.IDs[i]) {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID),
+					i18n.Tf(lang, i18n.MsgNotUUIDf, fmt.Sprintf("ids[%d]", i)))
+				return
+			}
+		}
+
+		// Reserve the output file now so the job and its retries always write the same one
+		f, err := os.CreateTemp(dir, "profiles-*.pdf")
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error creating profile output file", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		f.Close()
+		path := f.Name()
+
+		id, err := runner.Enqueue(r.Context(), profile.Kind, profile.Payload{IDs: body.IDs, Path: path})
+		if err != nil {
+			os.Remove(path)
+			slog.ErrorContext(r.Context(), "Error queueing profile job", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+
+		slog.InfoContext(r.Context(), "Profile batch queued", "job_id", id, "students", len(body.IDs))
+		w.Header().Set("Location", "/jobs/"+strconv.FormatInt(id, 10))
+		response.WriteJson(w, http.StatusAccepted, map[string]int64{"job_id": id})
+	}
+}
+
+// DownloadProfilesHandler serves the PDF of a finished batch: GET /students/profiles.pdf?job=42
+// It answers 409 while the job is still queued or running, and 404 for jobs of other kinds.
+func DownloadProfilesHandler(queue storage.JobQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		jobID, err := strconv.ParseInt(r.URL.Query().Get("job"), 10, 64)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), err.Error())
+			return
+		}
+
+		job, err := queue.GetJob(r.Context(), jobID)
+		if err == nil && job.Kind != profile.Kind {
+			err = storage.ErrJobNotFound
+		}
+		if errors.Is(err, storage.ErrJobNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgJobNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting profile job", "id", jobID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		if job.Status != types.JobSucceeded {
+			if job.Status == types.JobQueued || job.Status == types.JobRunning {
+				w.Header().Set("Retry-After", "1")
+			}
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgProfilesNotReady),
+				i18n.Tf(lang, i18n.MsgJobStatusf, jobID, i18n.Label(lang, i18n.EnumJobStatus, job.Status)))
+			return
+		}
+
+		var p profile.Payload
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		f, err := os.Open(p.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			// Cleared from the output directory since the job ran
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgJobNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error opening profile batch", "id", jobID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", profile.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="profiles-%d.pdf"`, jobID))
+		if fi, err := f.Stat(); err == nil {
+			w.Header().Set("Content-Length", strconv.FormatInt(fi.Size(), 10))
+		}
+		io.Copy(w, f)
+	}
+}
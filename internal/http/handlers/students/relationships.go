@@ -0,0 +1,269 @@
+package students
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// linkRequest is the body of POST /students/{id}/relationships
+type linkRequest struct {
+	StudentID string `json:"student_id"`
+	Kind      string `json:"kind"`
+}
+
+// LinkStudentHandler links two students: POST /students/{id}/relationships {"student_id": "...", "kind": "sibling"}
+// The link is symmetric, so it is listed from both students; the response is the link as seen from {id}.
+func LinkStudentHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		relater, ok := relaterOf(w, store, lang)
+		if !ok {
+			return
+		}
+		student, ok := studentByPath(w, r, store, lang, "id")
+		if !ok {
+			return
+		}
+
+		var req linkRequest
+		err := helpers.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), i18n.T(lang, i18n.MsgEmptyRequestBody))
+			return
+		}
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), helpers.DescribeDecodeError(err, lang))
+			return
+		}
+		req.StudentID = strings.ToLower(req.StudentID)
+		if !types.ValidPublicID(req.StudentID) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidLink), i18n.Tf(lang, i18n.MsgNotUUIDf, "student_id"))
+			return
+		}
+		if !slices.Contains(types.RelationshipKinds, req.Kind) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidLink),
+				i18n.Tf(lang, i18n.MsgLinkKindf, strings.Join(types.RelationshipKinds, ", ")))
+			return
+		}
+		if req.StudentID == student.PublicID {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidLink), i18n.T(lang, i18n.MsgSelfLink))
+			return
+		}
+		other, err := store.GetStudentByPublicID(req.StudentID)
+		if errors.Is(err, storage.ErrNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error looking up student to link", "id", req.StudentID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+
+		err = relater.LinkStudents(r.Context(), student.ID, other.ID, req.Kind)
+		switch {
+		case err == nil:
+		case errors.Is(err, storage.ErrDuplicate):
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgAlreadyLinked), err.Error())
+			return
+		case errors.Is(err, storage.ErrNotFound):
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			return
+		case errors.Is(err, storage.ErrInvalidData):
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidLink), err.Error())
+			return
+		default:
+			slog.ErrorContext(r.Context(), "Error linking students", "id", student.ID, "other", other.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+
+		response.WriteJson(w, http.StatusCreated, types.Relationship{
+			Kind:      req.Kind,
+			KindLabel: i18n.Label(lang, i18n.EnumLinkKind, req.Kind),
+			PublicID:  other.PublicID,
+			Name:      other.Name,
+		})
+	}
+}
+
+// RelationshipsHandler lists the students linked to one: GET /students/{id}/relationships
+func RelationshipsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		relater, ok := relaterOf(w, store, lang)
+		if !ok {
+			return
+		}
+		student, ok := studentByPath(w, r, store, lang, "id")
+		if !ok {
+			return
+		}
+
+		links, err := relater.StudentRelationships(r.Context(), []int64{student.ID})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing relationships", "id", student.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		rels := labelRelationships(links[student.ID], lang)
+		if rels == nil {
+			rels = []types.Relationship{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": rels})
+	}
+}
+
+// UnlinkStudentHandler removes the link between two students: DELETE /students/{id}/relationships/{otherId}
+func UnlinkStudentHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		relater, ok := relaterOf(w, store, lang)
+		if !ok {
+			return
+		}
+		var ids [2]int64
+		for i, name := range []string{"id", "otherId"} {
+			student, ok := studentByPath(w, r, store, lang, name)
+			if !ok {
+				return
+			}
+			ids[i] = student.ID
+		}
+
+		err := relater.UnlinkStudents(r.Context(), ids[0], ids[1])
+		if errors.Is(err, storage.ErrNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgLinkNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error unlinking students", "id", ids[0], "other", ids[1], "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// relaterOf returns store as a storage.Relater, or writes a 501 if it can't link students
+func relaterOf(w http.ResponseWriter, store storage.Storage, lang string) (storage.Relater, bool) {
+	relater, ok := storage.As[storage.Relater](store)
+	if !ok {
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgLinksUnsupported), i18n.T(lang, i18n.MsgCannotLink))
+	}
+	return relater, ok
+}
+
+// studentByPath loads the student named by the public ID in path value name, or writes a 400,
+// 404 or 500 and returns false
+func studentByPath(w http.ResponseWriter, r *http.Request, store storage.Storage, lang, name string) (types.Student, bool) {
+	publicID := strings.ToLower(r.PathValue(name))
+	if !types.ValidPublicID(publicID) {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, name))
+		return types.Student{}, false
+	}
+	student, err := store.GetStudentByPublicID(publicID)
+	if errors.Is(err, storage.ErrNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+		return types.Student{}, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up student", "id", publicID, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.Student{}, false
+	}
+	return student, true
+}
+
+// labelRelationships sets each relationship's KindLabel in lang
+func labelRelationships(rels []types.Relationship, lang string) []types.Relationship {
+	for i := range rels {
+		rels[i].KindLabel = i18n.Label(lang, i18n.EnumLinkKind, rels[i].Kind)
+	}
+	return rels
+}
+
+// expandable lists what ?expand= can add to students
+var expandable = []string{"relationships"}
+
+// expansion is what ?expand= asked to add to each student
+type expansion struct {
+	relationships bool
+}
+
+// parseExpand reads ?expand=relationships (comma-separated, repeatable), or writes a 400 and
+// returns false for anything it can't expand
+func parseExpand(w http.ResponseWriter, r *http.Request, lang string) (expansion, bool) {
+	return expandFrom(w, r.URL.Query()["expand"], lang)
+}
+
+// expandFrom reads what values (each as in ?expand=) ask for, like parseExpand
+func expandFrom(w http.ResponseWriter, values []string, lang string) (expansion, bool) {
+	var e expansion
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			switch strings.TrimSpace(name) {
+			case "":
+			case "relationships":
+				e.relationships = true
+			default:
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidExpand),
+					i18n.Tf(lang, i18n.MsgUnknownExpandf, strings.TrimSpace(name), strings.Join(expandable, ", ")))
+				return e, false
+			}
+		}
+	}
+	return e, true
+}
+
+// none reports whether nothing was asked for
+func (e expansion) none() bool {
+	return !e.relationships
+}
+
+// apply loads what e asks for, with one storage call for all of students. On failure it writes a
+// 501 or 500 and returns false.
+func (e expansion) apply(w http.ResponseWriter, r *http.Request, store storage.Storage, lang string, students []types.Student) ([]types.ExpandedStudent, bool) {
+	expanded := make([]types.ExpandedStudent, len(students))
+	for i, st := range students {
+		expanded[i].Student = st
+	}
+	if !e.relationships || len(students) == 0 {
+		return expanded, true
+	}
+
+	relater, ok := relaterOf(w, store, lang)
+	if !ok {
+		return nil, false
+	}
+	ids := make([]int64, len(students))
+	for i, st := range students {
+		ids[i] = st.ID
+	}
+	links, err := relater.StudentRelationships(r.Context(), ids)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading relationships", "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return nil, false
+	}
+	for i := range expanded {
+		expanded[i].Relationships = labelRelationships(links[expanded[i].ID], lang)
+		if expanded[i].Relationships == nil {
+			expanded[i].Relationships = []types.Relationship{}
+		}
+	}
+	return expanded, true
+}
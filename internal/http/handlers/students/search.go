@@ -0,0 +1,49 @@
+package students
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// maxSearchQuery caps the length of ?q= so one request can't build an enormous query
+const maxSearchQuery = 200
+
+// SearchStudentsHandler finds students by name or email: GET /students/search?q=asha&limit=20
+// Results are best matches first; with OpenSearch they carry a score and highlighted fragments.
+func SearchStudentsHandler(searcher storage.Searcher, limits types.PaginationLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" || len(query) > maxSearchQuery {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidSearchQuery), i18n.Tf(lang, i18n.MsgSearchQueryf, maxSearchQuery))
+			return
+		}
+
+		limit := limits.Default
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < types.MinLimit || n > limits.Max {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidSearchQuery),
+					i18n.Tf(lang, i18n.MsgOutOfRangef, "limit", types.MinLimit, limits.Max))
+				return
+			}
+			limit = n
+		}
+
+		hits, err := searcher.SearchStudents(r.Context(), query, limit)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error searching students", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": hits})
+	}
+}
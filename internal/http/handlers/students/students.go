@@ -1,127 +1,242 @@
 package students
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/go-playground/validator/v10"
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/export"
 	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
 	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	studentsvc "github.com/prashantkumbhar2002/go_students_api/internal/service/students"
 	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
 	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
 )
 
-func NewStudentHandler(store storage.Storage) http.HandlerFunc {
+// NewStudentHandler creates one student. Side effects such as the welcome email subscribe to the
+// StudentCreated event the storage publishes (see internal/events).
+func NewStudentHandler(svc *studentsvc.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		dryRun, err := helpers.ParseDryRun(r)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidDryRun), err.Error())
+			return
+		}
 
 		var student types.Student
-		// Decode the request body into the student struct
-		err := json.NewDecoder(r.Body).Decode(&student)
+		if validation.SchemaMode() {
+			err = decodeWithSchema(r, validation.SchemaStudent, &student)
+		} else {
+			// Decode the request body into the student struct
+			err = helpers.DecodeJSON(r.Body, &student)
+		}
+
+		var schemaErrs schemaErrors
+		if errors.As(err, &schemaErrs) {
+			slog.ErrorContext(r.Context(), "Request body does not match schema", "error", err)
+			response.WriteSchemaErrors(w, http.StatusBadRequest, schemaErrs, lang)
+			return
+		}
+
 		if errors.Is(err, io.EOF) {
-			slog.Error("Error decoding request body", "error", err)
-			response.WriteError(w, http.StatusBadRequest, "invalid request body", "request body is empty")
+			slog.ErrorContext(r.Context(), "Error decoding request body", "error", err)
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), i18n.T(lang, i18n.MsgEmptyRequestBody))
 			return
 		}
 
 		if err != nil {
-			slog.Error("Error decoding request body", "error", err)
-			response.WriteError(w, http.StatusBadRequest, "invalid request body", err.Error())
+			slog.ErrorContext(r.Context(), "Error decoding request body", "error", err)
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), helpers.DescribeDecodeError(err, lang))
 			return
 		}
 
-		// Request Body validation
-		if err := validator.New().Struct(student); err != nil {
-			slog.Error("Error validating request body", "error", err)
-			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors)) // type assertion to get the ValidationErrors
+		ctx := r.Context()
+		if dryRun {
+			// The insert runs in a transaction that is rolled back, so database constraints are checked too
+			ctx = storage.WithDryRun(ctx)
+		}
+		student, err = svc.Create(ctx, student, lang)
+		if writeInvalid(w, r, err, false, lang) {
 			return
 		}
-
-		// Create the student in the database
-		id, err := store.CreateStudent(student.Name, student.Email, student.Age)
 		if err != nil {
-			slog.Error("Error creating student in the database", "error", err)
-			response.WriteError(w, http.StatusInternalServerError, "error creating student", err.Error())
+			slog.ErrorContext(r.Context(), "Error creating student in the database", "error", err, "dry_run", dryRun)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgCreateStudentError), err.Error())
+			return
+		}
+		if dryRun {
+			writeDryRun(w, 1)
 			return
 		}
 
-		student.ID = id
+		slog.InfoContext(r.Context(), "Student created", "student", student)
 
-		slog.Info("Student created", "student", student)
-		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": student.ID})
+		response.WriteJson(w, http.StatusCreated, map[string]string{"id": student.PublicID})
 	}
 }
 
-func GetStudentHandler(store storage.Storage) http.HandlerFunc {
+func GetStudentHandler(svc *studentsvc.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
 		// id := r.URL.Query().Get("id") // Reading the query parameters
-		id := r.PathValue("id") // Reading the path parameters
-		slog.Info("ID", "id", id)
-		idInt, err := strconv.ParseInt(id, 10, 64)
-		if err != nil {
-			slog.Error("Error parsing ID: " + id + " and error: " + err.Error())
-			response.WriteError(w, http.StatusBadRequest, "invalid ID", err.Error())
+		id := strings.ToLower(r.PathValue("id")) // Reading the path parameters; clients know students by UUID only
+		slog.InfoContext(r.Context(), "ID", "id", id)
+		exp, ok := parseExpand(w, r, lang)
+		if !ok {
 			return
 		}
 
 		// Get the student from the database
-		student, err := store.GetStudent(idInt)
+		student, err := svc.Get(r.Context(), id)
 		if err != nil {
+			if errors.Is(err, studentsvc.ErrInvalidID) {
+				slog.ErrorContext(r.Context(), "Invalid student ID: "+id)
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+				return
+			}
 			// Use errors.Is() to check for domain-specific errors
 			// This decouples the handler from database implementation details
 			if errors.Is(err, storage.ErrNotFound) {
-				slog.Error("Student not found with id: "+id, "error", err)
-				response.WriteError(w, http.StatusNotFound, "student not found", err.Error())
+				slog.ErrorContext(r.Context(), "Student not found with id: "+id, "error", err)
+				response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+				return
+			}
+			slog.ErrorContext(r.Context(), "Error getting student with id: "+id+" and error: "+err.Error())
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Student fetched by ID", "id", id, "student", student)
+		if !exp.none() {
+			expanded, ok := exp.apply(w, r, svc.Store, lang, []types.Student{student})
+			if !ok {
 				return
 			}
-			slog.Error("Error getting student with id: " + id + " and error: " + err.Error())
-			response.WriteError(w, http.StatusInternalServerError, "internal server error", err.Error())
+			response.WriteJson(w, http.StatusOK, expanded[0])
 			return
 		}
-		slog.Info("Student fetched by ID", "id", idInt, "student", student)
 		response.WriteJson(w, http.StatusOK, student)
 	}
 }
 
-func GetStudentsListHandler(store storage.Storage) http.HandlerFunc {
+// ListOptions configure GET /students
+type ListOptions struct {
+	// Style (helpers.PaginationBody or helpers.PaginationHeaders) is the default shape of the
+	// response; an Accept profile can pick the other one per request
+	Style  string
+	Limits types.PaginationLimits
+	// Strict refuses malformed pagination parameters instead of using defaults; a
+	// "Prefer: handling=..." header can choose per request
+	Strict bool
+}
+
+// GetStudentsListHandler serves one page of students: GET /students?page=2&limit=20&filter=age>=18
+// ?view= lists a saved view's students: its filter applies together with any ?filter=, and its
+// expand unless ?expand= is given.
+func GetStudentsListHandler(store storage.Storage, opts ListOptions) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		if helpers.StrictParams(w, r, opts.Strict) {
+			if errs := helpers.PaginationErrors(r, opts.Limits, lang); len(errs) > 0 {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidPagination), strings.Join(errs, "; "))
+				return
+			}
+		}
+		view, ok := requestedView(w, r, store, lang)
+		if !ok {
+			return
+		}
+		expand := r.URL.Query()["expand"]
+		if len(expand) == 0 {
+			expand = view.Expand
+		}
+		exp, ok := expandFrom(w, expand, lang)
+		if !ok {
+			return
+		}
+		f, ok := listFilter(w, r, store, lang, view)
+		if !ok {
+			return
+		}
 		// Parse pagination parameters from query string
-		pagination := helpers.ParsePaginationParams(r)
+		pagination := helpers.ParsePaginationParams(r, opts.Limits)
 
-		slog.Info("Getting students list with pagination", "page", pagination.Page, "limit", pagination.Limit)
+		slog.InfoContext(r.Context(), "Getting students list with pagination", "page", pagination.Page, "limit", pagination.Limit)
 
 		// Calculate offset: (page - 1) * limit
 		// Example: page=1, limit=20 -> offset=0
 		//          page=2, limit=20 -> offset=20
 		offset := (pagination.Page - 1) * pagination.Limit
 
-		// Get total count (for pagination metadata)
-		totalCount, err := store.GetStudentsCount()
-		if err != nil {
-			if errors.Is(err, storage.ErrDatabase) {
-				slog.Error("Database error while getting students count", "error", err)
-				response.WriteError(w, http.StatusInternalServerError, "database error", err.Error())
+		var (
+			students   []types.Student
+			totalCount int64
+			countedAt  time.Time
+			err        error
+		)
+		if f != nil {
+			// ?filter= and ?view= narrow the list; see internal/filter for the language
+			filterer, ok := storage.As[storage.Filterer](store)
+			if !ok {
+				response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgFilterUnsupported), i18n.T(lang, i18n.MsgCannotFilter))
+				return
+			}
+			students, totalCount, err = filterer.FilterStudents(r.Context(), *f, offset, pagination.Limit)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error filtering students", "filter", r.URL.Query().Get("filter"), "view", view.Name, "error", err)
+				response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+				return
+			}
+		} else {
+			// Get total count (for pagination metadata); a cached count says how old it is
+			totalCount, countedAt, err = countStudents(store)
+			if err != nil {
+				if errors.Is(err, storage.ErrDatabase) {
+					slog.ErrorContext(r.Context(), "Database error while getting students count", "error", err)
+					response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+					return
+				}
+				slog.ErrorContext(r.Context(), "Internal server error while getting students count", "error", err)
+				response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+				return
+			}
+
+			// Get paginated students list
+			students, err = store.GetStudentsList(offset, pagination.Limit)
+			if err != nil {
+				if errors.Is(err, storage.ErrDatabase) {
+					slog.ErrorContext(r.Context(), "Database error while getting students list", "error", err)
+					response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+					return
+				}
+				slog.ErrorContext(r.Context(), "Internal server error while getting students list", "error", err)
+				response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
 				return
 			}
-			slog.Error("Internal server error while getting students count", "error", err)
-			response.WriteError(w, http.StatusInternalServerError, "internal server error", err.Error())
-			return
 		}
 
-		// Get paginated students list
-		students, err := store.GetStudentsList(offset, pagination.Limit)
-		if err != nil {
-			if errors.Is(err, storage.ErrDatabase) {
-				slog.Error("Database error while getting students list", "error", err)
-				response.WriteError(w, http.StatusInternalServerError, "database error", err.Error())
+		// ?expand= adds related data to every student on the page
+		var page any = students
+		if !exp.none() {
+			expanded, ok := exp.apply(w, r, store, lang, students)
+			if !ok {
 				return
 			}
-			slog.Error("Internal server error while getting students list", "error", err)
-			response.WriteError(w, http.StatusInternalServerError, "internal server error", err.Error())
-			return
+			page = expanded
 		}
 
 		// Calculate total pages
@@ -130,9 +245,22 @@ func GetStudentsListHandler(store storage.Storage) http.HandlerFunc {
 			totalPages++
 		}
 
+		helpers.SetPaginationHeaders(w, r, pagination, totalCount, totalPages)
+		w.Header().Add("Vary", "Accept")
+		slog.InfoContext(r.Context(), "Students fetched successfully", "returned", len(students), "total", totalCount, "page", pagination.Page, "total_pages", totalPages)
+
+		// Header-style clients get the bare page; the metadata is in Link and X-Total-Count
+		if helpers.PaginationStyle(r, opts.Style) == helpers.PaginationHeaders {
+			if students == nil {
+				page = []types.Student{}
+			}
+			response.WriteJson(w, http.StatusOK, page)
+			return
+		}
+
 		// Build paginated response with metadata
 		paginatedResp := types.PaginatedResponse{
-			Data:       students,
+			Data:       page,
 			Page:       pagination.Page,
 			Limit:      pagination.Limit,
 			TotalItems: totalCount,
@@ -140,8 +268,233 @@ func GetStudentsListHandler(store storage.Storage) http.HandlerFunc {
 			HasNext:    pagination.Page < totalPages,
 			HasPrev:    pagination.Page > 1,
 		}
-
-		slog.Info("Students fetched successfully", "returned", len(students), "total", totalCount, "page", pagination.Page, "total_pages", totalPages)
+		if !countedAt.IsZero() {
+			asOf := countedAt.UTC()
+			paginatedResp.TotalItemsAsOf = &asOf
+		}
 		response.WriteJson(w, http.StatusOK, paginatedResp)
 	}
-}
\ No newline at end of file
+}
+
+// countStudents counts through the cache when the storage has one. countedAt is when a cached
+// count was taken, or the zero time for a fresh one.
+func countStudents(store storage.Storage) (count int64, countedAt time.Time, err error) {
+	if cc, ok := storage.As[storage.CachedCounter](store); ok {
+		return cc.CachedStudentsCount()
+	}
+	count, err = store.GetStudentsCount()
+	return count, time.Time{}, err
+}
+
+// maxBulkCreate caps how many students one bulk request may create
+const maxBulkCreate = 5000
+
+// BulkCreateStudentsHandler creates many students from a JSON array in a single transaction.
+// Every item is validated first; if any item is invalid nothing is inserted.
+func BulkCreateStudentsHandler(svc *studentsvc.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		dryRun, err := helpers.ParseDryRun(r)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidDryRun), err.Error())
+			return
+		}
+
+		var students []types.Student
+		err = helpers.DecodeJSON(r.Body, &students)
+		if errors.Is(err, io.EOF) || (err == nil && len(students) == 0) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), i18n.T(lang, i18n.MsgEmptyRequestBody))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error decoding bulk request body", "error", err)
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), helpers.DescribeDecodeError(err, lang))
+			return
+		}
+		if len(students) > maxBulkCreate {
+			response.WriteError(w, http.StatusRequestEntityTooLarge, i18n.T(lang, i18n.MsgInvalidRequestBody),
+				i18n.Tf(lang, i18n.MsgTooManyStudentsf, maxBulkCreate))
+			return
+		}
+
+		ctx := r.Context()
+		if dryRun {
+			ctx = storage.WithDryRun(ctx)
+		}
+		created, err := svc.CreateMany(ctx, students, lang)
+		if writeInvalid(w, r, err, true, lang) {
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error creating students in bulk", "error", err, "dry_run", dryRun)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgCreateStudentError), err.Error())
+			return
+		}
+		if dryRun {
+			writeDryRun(w, len(created))
+			return
+		}
+
+		publicIDs := make([]string, len(created))
+		for i, st := range created {
+			publicIDs[i] = st.PublicID
+		}
+
+		slog.InfoContext(r.Context(), "Students created in bulk", "count", len(created))
+		response.WriteJson(w, http.StatusCreated, map[string][]string{"ids": publicIDs})
+	}
+}
+
+// writeDryRun answers a dry run that passed every check; nothing was stored
+func writeDryRun(w http.ResponseWriter, count int) {
+	w.Header().Set(helpers.DryRunHeader, "true")
+	response.WriteJson(w, http.StatusOK, map[string]any{"dry_run": true, "would_create": count})
+}
+
+// exportFlushEvery controls how many students are written between flushes during an export
+const exportFlushEvery = 100
+
+// RowCountHeader is the trailer counting an export's rows
+const RowCountHeader = "X-Row-Count"
+
+// Formats and compression of GET /students/export
+const (
+	exportJSON  = "json"
+	exportCSV   = "csv"
+	compressZip = "zip"
+)
+
+// ExportStudentsHandler streams every student as a JSON array, or as CSV with ?format=csv.
+// Unlike the list endpoint it never holds the full result in memory: rows are encoded one at a
+// time straight from the database cursor and flushed periodically so the client sees progress.
+// The row count and the body's SHA-256 follow it as trailers (X-Row-Count, Content-Digest), so a
+// client can tell a complete export from one cut short. With ?compress=zip the file comes in a
+// zip archive next to a manifest holding the same (see export.Zip), still streamed: the archive
+// is compressed as it is written.
+func ExportStudentsHandler(store storage.Storage, clk clock.Clock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		q := r.URL.Query()
+		format := cmp.Or(q.Get("format"), exportJSON)
+		if format != exportJSON && format != exportCSV {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidExportFormat),
+				i18n.Tf(lang, i18n.MsgExportFormatRulef, exportJSON+", "+exportCSV))
+			return
+		}
+		compress := q.Get("compress")
+		if compress != "" && compress != compressZip {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidCompression),
+				i18n.Tf(lang, i18n.MsgCompressionRulef, compressZip))
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		write := func(out io.Writer) (int, error) {
+			if format == exportCSV {
+				return export.Write(r.Context(), store, export.FormatCSV, out)
+			}
+			return writeJSONArray(r.Context(), store, out, rc)
+		}
+
+		if compress == compressZip {
+			now := clk.Now()
+			w.Header().Set("Content-Type", "application/zip")
+			w.Header().Set("Content-Disposition", `attachment; filename="`+export.Name(now, "zip")+`"`)
+			w.WriteHeader(http.StatusOK)
+
+			m, err := export.Zip(w, "students."+format, format, now, write)
+			if err != nil {
+				// Headers are already sent; without its central directory the archive won't open
+				slog.ErrorContext(r.Context(), "Error streaming students export", "error", err, "written", m.Rows)
+				return
+			}
+			slog.InfoContext(r.Context(), "Students export streamed", "count", m.Rows, "format", format, "sha256", m.SHA256)
+			return
+		}
+
+		if format == exportCSV {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		// Only known once the last row is out
+		w.Header().Set("Trailer", helpers.ContentDigestHeader+", "+RowCountHeader)
+		w.WriteHeader(http.StatusOK)
+
+		sum := sha256.New()
+		count, err := write(io.MultiWriter(w, sum))
+		if err != nil {
+			// Headers are already sent, so we can't switch to an error response.
+			// Abort mid-file; clients detect the truncated JSON (or a short CSV).
+			slog.ErrorContext(r.Context(), "Error streaming students export", "error", err, "written", count)
+			return
+		}
+		w.Header().Set(helpers.ContentDigestHeader, helpers.ContentDigest(sum.Sum(nil)))
+		w.Header().Set(RowCountHeader, strconv.Itoa(count))
+		slog.InfoContext(r.Context(), "Students export streamed", "count", count, "format", format)
+	}
+}
+
+// writeJSONArray writes every student to w as a JSON array, flushing rc every exportFlushEvery
+// students, and returns how many it wrote. On error the array is left unclosed.
+func writeJSONArray(ctx context.Context, store storage.Storage, w io.Writer, rc *http.ResponseController) (int, error) {
+	enc := json.NewEncoder(w)
+	count := 0
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return 0, err
+	}
+
+	err := store.StreamStudents(ctx, func(student types.Student) error {
+		if count > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(student); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			// Not every ResponseWriter supports flushing; that only costs us latency
+			rc.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	_, err = io.WriteString(w, "]\n")
+	return count, err
+}
+
+// schemaErrors carries JSON Schema violations out of decodeWithSchema
+type schemaErrors []validation.SchemaError
+
+func (e schemaErrors) Error() string {
+	return fmt.Sprintf("%d schema violation(s)", len(e))
+}
+
+// decodeWithSchema validates the raw body against the named JSON Schema and then decodes it into v.
+// An empty body is reported as io.EOF, matching helpers.DecodeJSON.
+func decodeWithSchema(r *http.Request, schema string, v any) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return io.EOF
+	}
+
+	errs, err := validation.ValidateJSON(schema, body)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return schemaErrors(errs)
+	}
+
+	return helpers.DecodeJSON(bytes.NewReader(body), v)
+}
@@ -0,0 +1,58 @@
+package students
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	studentsvc "github.com/prashantkumbhar2002/go_students_api/internal/service/students"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+var bodySeeds = []string{
+	``,
+	`{}`,
+	`{"name":"A","email":"a@example.com","age":20}`,
+	`{"name":"A","email":"a@example.com","date_of_birth":"2000-02-29","phone":"+91 98765 43210"}`,
+	`{"name":"A","email":"a@example.com","date_of_birth":"9999-99-99"}`,
+	`{"name":1,"email":[],"age":"x"}`,
+	`{"age":1e400}`,
+	`[1,2,3]`,
+	`{"name":"\u0000","email":"@","phone":"00"}`,
+	`{"name":"A"`,
+}
+
+// FuzzNewStudentHandler runs arbitrary bodies through decode + validate + create.
+// The handler must never panic and must answer with 201 or a 4xx/5xx JSON error.
+func FuzzNewStudentHandler(f *testing.F) {
+	for _, seed := range bodySeeds {
+		f.Add(seed)
+	}
+
+	handler := NewStudentHandler(&studentsvc.Service{Store: storagetest.NewFake()})
+
+	f.Fuzz(func(t *testing.T, body string) {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/students", strings.NewReader(body)))
+
+		if w.Code != http.StatusCreated && w.Code < 400 {
+			t.Errorf("body %q: unexpected status %d", body, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("body %q: Content-Type = %q", body, ct)
+		}
+	})
+}
+
+// FuzzValidateJSON checks the JSON Schema path never panics on arbitrary input
+func FuzzValidateJSON(f *testing.F) {
+	for _, seed := range bodySeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, body string) {
+		validation.ValidateJSON(validation.SchemaStudent, []byte(body))
+	})
+}
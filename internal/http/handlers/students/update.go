@@ -0,0 +1,207 @@
+package students
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	studentsvc "github.com/prashantkumbhar2002/go_students_api/internal/service/students"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// ReplaceStudentHandler replaces a student with the body, which is checked as POST /students checks
+// it: PUT /students/{id}. The response is the stored student plus changed_fields, the fields
+// that differ from before; clients skip their downstream syncs when it is empty.
+func ReplaceStudentHandler(svc *studentsvc.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		dryRun, err := helpers.ParseDryRun(r)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidDryRun), err.Error())
+			return
+		}
+		current, ok := studentToUpdate(w, r, svc, lang)
+		if !ok {
+			return
+		}
+
+		var student types.Student
+		if validation.SchemaMode() {
+			err = decodeWithSchema(r, validation.SchemaStudent, &student)
+		} else {
+			err = helpers.DecodeJSON(r.Body, &student)
+		}
+		if !decodedUpdate(w, r, lang, err) {
+			return
+		}
+		updateStudent(w, r, svc, lang, current, student, dryRun)
+	}
+}
+
+// PatchStudentHandler applies a JSON merge patch (RFC 7396) to a student: PATCH /students/{id} {"phone": null}
+// Fields the patch leaves out keep their values and null clears an optional field; custom_fields
+// merges the same way, one field at a time. The result is checked and answered as PUT's is.
+func PatchStudentHandler(svc *studentsvc.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		dryRun, err := helpers.ParseDryRun(r)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidDryRun), err.Error())
+			return
+		}
+		current, ok := studentToUpdate(w, r, svc, lang)
+		if !ok {
+			return
+		}
+
+		var patch map[string]any
+		err = helpers.DecodeJSON(r.Body, &patch)
+		if err == nil && patch == nil {
+			// "null" replaces the whole document, which a student can't be
+			err = &json.UnmarshalTypeError{Value: "null", Type: reflect.TypeFor[map[string]any]()}
+		}
+		if !decodedUpdate(w, r, lang, err) {
+			return
+		}
+
+		doc, err := studentDocument(current)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error encoding student to patch", "id", current.PublicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		merged, err := json.Marshal(mergePatch(doc, patch))
+		if err != nil {
+			// Everything in doc and patch came from JSON, so this can't happen
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+
+		if validation.SchemaMode() {
+			errs, err := validation.ValidateJSON(validation.SchemaStudent, merged)
+			if err == nil && len(errs) > 0 {
+				err = schemaErrors(errs)
+			}
+			if !decodedUpdate(w, r, lang, err) {
+				return
+			}
+		}
+		var student types.Student
+		if !decodedUpdate(w, r, lang, helpers.DecodeJSON(bytes.NewReader(merged), &student)) {
+			return
+		}
+		updateStudent(w, r, svc, lang, current, student, dryRun)
+	}
+}
+
+// studentToUpdate looks up the student named by the path. On failure it writes a 400, 404, 501 or
+// 500 and returns false.
+func studentToUpdate(w http.ResponseWriter, r *http.Request, svc *studentsvc.Service, lang string) (types.Student, bool) {
+	id := strings.ToLower(r.PathValue("id"))
+	current, err := svc.Current(r.Context(), id)
+	switch {
+	case err == nil:
+		return current, true
+	case errors.Is(err, studentsvc.ErrUpdateUnsupported):
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgUpdateUnsupported), i18n.T(lang, i18n.MsgCannotUpdate))
+	case errors.Is(err, studentsvc.ErrInvalidID):
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+	case errors.Is(err, storage.ErrNotFound):
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+	default:
+		slog.ErrorContext(r.Context(), "Error looking up student to update", "id", id, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+	}
+	return types.Student{}, false
+}
+
+// decodedUpdate writes a 400 for a body that failed to decode and returns false; nil passes
+func decodedUpdate(w http.ResponseWriter, r *http.Request, lang string, err error) bool {
+	var schemaErrs schemaErrors
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &schemaErrs):
+		response.WriteSchemaErrors(w, http.StatusBadRequest, schemaErrs, lang)
+	case errors.Is(err, io.EOF):
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), i18n.T(lang, i18n.MsgEmptyRequestBody))
+	default:
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), helpers.DescribeDecodeError(err, lang))
+	}
+	slog.ErrorContext(r.Context(), "Error decoding student update", "error", err)
+	return false
+}
+
+// updateStudent checks student as a replacement for current, stores it and writes the result.
+// The IDs aren't writable; an "id" in the body is ignored as on create. A dry run is rolled back
+// after the write, so database constraints are checked too, and answers with what would have
+// been stored.
+func updateStudent(w http.ResponseWriter, r *http.Request, svc *studentsvc.Service, lang string, current, student types.Student, dryRun bool) {
+	ctx := r.Context()
+	if dryRun {
+		ctx = storage.WithDryRun(ctx)
+	}
+	updated, changed, err := svc.Update(ctx, current, student, lang)
+	if writeInvalid(w, r, err, false, lang) {
+		return
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		// Deleted between the lookup and the update
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error updating student", "id", student.PublicID, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return
+	}
+	if dryRun {
+		w.Header().Set(helpers.DryRunHeader, "true")
+	} else {
+		slog.InfoContext(r.Context(), "Student updated", "id", updated.PublicID, "changed", changed)
+	}
+	response.WriteJson(w, http.StatusOK, types.UpdatedStudent{Student: updated, ChangedFields: changed})
+}
+
+// studentDocument returns student as the JSON object a merge patch applies to
+func studentDocument(student types.Student) (map[string]any, error) {
+	b, err := json.Marshal(student)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	err = json.Unmarshal(b, &doc)
+	return doc, err
+}
+
+// mergePatch applies patch to doc as RFC 7396 describes: null removes a member, an object merges
+// into the member it replaces and anything else replaces it
+func mergePatch(doc, patch map[string]any) map[string]any {
+	if doc == nil {
+		doc = make(map[string]any)
+	}
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(doc, k)
+		case map[string]any:
+			target, _ := doc[k].(map[string]any)
+			doc[k] = mergePatch(target, v)
+		default:
+			doc[k] = v
+		}
+	}
+	return doc
+}
@@ -0,0 +1,230 @@
+package students
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/prashantkumbhar2002/go_students_api/internal/filter"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// SaveViewHandler saves a named view of the student list: POST /views {"name": "scholarship-seniors", "filter": "age >= 17", "expand": ["relationships"]}
+// The filter and expand are checked as GET /students would check them, so a saved view always lists.
+func SaveViewHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		views, ok := viewsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		var view types.View
+		err := helpers.DecodeJSON(r.Body, &view)
+		if errors.Is(err, io.EOF) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), i18n.T(lang, i18n.MsgEmptyRequestBody))
+			return
+		}
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), helpers.DescribeDecodeError(err, lang))
+			return
+		}
+		view.Filter = strings.TrimSpace(view.Filter)
+		// Stored as given to ?expand=, one name per entry
+		var expand []string
+		for _, v := range view.Expand {
+			for _, name := range strings.Split(v, ",") {
+				if name = strings.TrimSpace(name); name != "" && !slices.Contains(expand, name) {
+					expand = append(expand, name)
+				}
+			}
+		}
+		view.Expand = expand
+		if err := validation.Struct(view); err != nil {
+			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors), lang)
+			return
+		}
+		if !validation.ValidViewName(view.Name) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidView), i18n.T(lang, i18n.MsgViewNameRule))
+			return
+		}
+		if _, ok := expandFrom(w, view.Expand, lang); !ok {
+			return
+		}
+		if view.Filter != "" {
+			defs, err := filterFields(r.Context(), store, view.Filter)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error listing custom fields", "error", err)
+				response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+				return
+			}
+			if _, err := filter.ParseWith(view.Filter, defs); err != nil {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidFilter), err.Error())
+				return
+			}
+		}
+
+		saved, err := views.SaveView(r.Context(), view)
+		if errors.Is(err, storage.ErrDuplicate) {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgViewExists), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error saving view", "name", view.Name, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "View saved", "name", saved.Name)
+		response.WriteJson(w, http.StatusCreated, saved)
+	}
+}
+
+// ListViewsHandler lists the saved views by name: GET /views
+func ListViewsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		views, ok := viewsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		list, err := views.ListViews(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing views", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if list == nil {
+			list = []types.View{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": list})
+	}
+}
+
+// GetViewHandler returns one saved view: GET /views/{name}
+func GetViewHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		views, ok := viewsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		view, err := views.GetView(r.Context(), r.PathValue("name"))
+		if errors.Is(err, storage.ErrViewNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgViewNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error looking up view", "name", r.PathValue("name"), "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		response.WriteJson(w, http.StatusOK, view)
+	}
+}
+
+// DeleteViewHandler removes a saved view: DELETE /views/{name}
+func DeleteViewHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		views, ok := viewsOf(w, store, lang)
+		if !ok {
+			return
+		}
+		name := r.PathValue("name")
+		err := views.DeleteView(r.Context(), name)
+		if errors.Is(err, storage.ErrViewNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgViewNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error deleting view", "name", name, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "View deleted", "name", name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// requestedView loads the view named by ?view=, or returns the zero View without one. On failure
+// it writes a 404, 501 or 500 and returns false.
+func requestedView(w http.ResponseWriter, r *http.Request, store storage.Storage, lang string) (types.View, bool) {
+	name := r.URL.Query().Get("view")
+	if name == "" {
+		return types.View{}, true
+	}
+	views, ok := viewsOf(w, store, lang)
+	if !ok {
+		return types.View{}, false
+	}
+	view, err := views.GetView(r.Context(), name)
+	if errors.Is(err, storage.ErrViewNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgViewNotFound), err.Error())
+		return types.View{}, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up view", "name", name, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.View{}, false
+	}
+	return view, true
+}
+
+// listFilter parses the filter of a GET /students request: ?filter=, view's filter, or both
+// joined with AND. nil means every student. On failure it writes a 400 or 500 and returns false.
+func listFilter(w http.ResponseWriter, r *http.Request, store storage.Storage, lang string, view types.View) (*types.Filter, bool) {
+	expr := r.URL.Query().Get("filter")
+	defs, err := filterFields(r.Context(), store, view.Filter+" "+expr)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing custom fields", "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return nil, false
+	}
+
+	var parts []types.Filter
+	if view.Filter != "" {
+		// The view was checked when saved, but a custom field it compares may have gone since
+		f, err := filter.ParseWith(view.Filter, defs)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidFilter), i18n.Tf(lang, i18n.MsgViewFilterf, view.Name, err))
+			return nil, false
+		}
+		parts = append(parts, f)
+	}
+	if expr != "" {
+		f, err := filter.ParseWith(expr, defs)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidFilter), err.Error())
+			return nil, false
+		}
+		parts = append(parts, f)
+	}
+	switch len(parts) {
+	case 0:
+		return nil, true
+	case 1:
+		return &parts[0], true
+	}
+	return &types.Filter{Op: filter.OpAnd, Args: parts}, true
+}
+
+// viewsOf returns store's saved views, or writes a 501 and returns false
+func viewsOf(w http.ResponseWriter, store storage.Storage, lang string) (storage.Views, bool) {
+	views, ok := storage.As[storage.Views](store)
+	if !ok {
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgViewsUnsupported), i18n.T(lang, i18n.MsgNoViews))
+	}
+	return views, ok
+}
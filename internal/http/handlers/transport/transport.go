@@ -0,0 +1,313 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// assignRequest is the body of POST /routes/{id}/students
+type assignRequest struct {
+	StudentID string `json:"student_id"`
+	StopID    string `json:"stop_id"`
+}
+
+// AddRouteHandler adds a bus route with its stops in pick-up order:
+// POST /routes {"name": "Route 4", "capacity": 40, "stops": [{"name": "Depot"}, {"name": "Market"}]}
+func AddRouteHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		transport, ok := transportOf(w, store, lang)
+		if !ok {
+			return
+		}
+		var route types.BusRoute
+		if !helpers.DecodeBody(w, r, lang, &route) {
+			return
+		}
+		if err := validation.Struct(route); err != nil {
+			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors), lang)
+			return
+		}
+		// Public IDs are always ours to assign; any "id" in the body is ignored
+		route.PublicID = types.NewPublicID()
+		for i := range route.Stops {
+			route.Stops[i].PublicID = types.NewPublicID()
+		}
+
+		route, err := transport.AddRoute(r.Context(), route)
+		if errors.Is(err, storage.ErrDuplicate) {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgRouteExists), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error adding route", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Route added", "id", route.PublicID, "stops", len(route.Stops))
+		response.WriteJson(w, http.StatusCreated, route)
+	}
+}
+
+// ListRoutesHandler lists every route with its stops: GET /routes
+func ListRoutesHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		transport, ok := transportOf(w, store, lang)
+		if !ok {
+			return
+		}
+		routes, err := transport.ListRoutes(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing routes", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if routes == nil {
+			routes = []types.BusRoute{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": routes})
+	}
+}
+
+// GetRouteHandler serves one route with its stops: GET /routes/{id}
+func GetRouteHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		transport, ok := transportOf(w, store, lang)
+		if !ok {
+			return
+		}
+		route, ok := routeByPath(w, r, transport, lang)
+		if !ok {
+			return
+		}
+		response.WriteJson(w, http.StatusOK, route)
+	}
+}
+
+// AddStopHandler appends a stop to a route: POST /routes/{id}/stops {"name": "School Gate"}
+func AddStopHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		transport, ok := transportOf(w, store, lang)
+		if !ok {
+			return
+		}
+		route, ok := routeByPath(w, r, transport, lang)
+		if !ok {
+			return
+		}
+		var stop types.Stop
+		if !helpers.DecodeBody(w, r, lang, &stop) {
+			return
+		}
+		if err := validation.Struct(stop); err != nil {
+			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors), lang)
+			return
+		}
+		stop.PublicID = types.NewPublicID()
+
+		stop, err := transport.AddStop(r.Context(), route.ID, stop)
+		if errors.Is(err, storage.ErrRouteNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgRouteNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error adding stop", "route", route.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		response.WriteJson(w, http.StatusCreated, stop)
+	}
+}
+
+// AssignHandler has a student ride a route from one of its stops:
+// POST /routes/{id}/students {"student_id": "...", "stop_id": "..."}
+// A full bus, or a student who rides a route already, is a 409.
+func AssignHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		transport, ok := transportOf(w, store, lang)
+		if !ok {
+			return
+		}
+		route, ok := routeByPath(w, r, transport, lang)
+		if !ok {
+			return
+		}
+		var req assignRequest
+		if !helpers.DecodeBody(w, r, lang, &req) {
+			return
+		}
+		req.StopID = strings.ToLower(req.StopID)
+		if !types.ValidPublicID(req.StopID) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "stop_id"))
+			return
+		}
+		var stop types.Stop
+		for _, st := range route.Stops {
+			if st.PublicID == req.StopID {
+				stop = st
+			}
+		}
+		if stop.ID == 0 {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStopNotFound), i18n.Tf(lang, i18n.MsgStopOffRoutef, req.StopID))
+			return
+		}
+		student, ok := studentByID(w, r, store, lang, "student_id", req.StudentID)
+		if !ok {
+			return
+		}
+
+		assignment, err := transport.AssignRoute(r.Context(), route.ID, stop.ID, student.ID)
+		switch {
+		case err == nil:
+		case errors.Is(err, storage.ErrRouteFull):
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgRouteFull), err.Error())
+			return
+		case errors.Is(err, storage.ErrHasRoute):
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgHasRoute), err.Error())
+			return
+		case errors.Is(err, storage.ErrRouteNotFound):
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgRouteNotFound), err.Error())
+			return
+		case errors.Is(err, storage.ErrStopNotFound):
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStopNotFound), err.Error())
+			return
+		case errors.Is(err, storage.ErrNotFound):
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+			return
+		default:
+			slog.ErrorContext(r.Context(), "Error assigning route", "route", route.ID, "student", student.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Route assigned", "route", route.PublicID, "stop", stop.PublicID, "student", student.PublicID)
+		response.WriteJson(w, http.StatusCreated, assignment)
+	}
+}
+
+// ManifestHandler lists a route's riders in pick-up order, for its driver: GET /routes/{id}/students
+func ManifestHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		transport, ok := transportOf(w, store, lang)
+		if !ok {
+			return
+		}
+		route, ok := routeByPath(w, r, transport, lang)
+		if !ok {
+			return
+		}
+		manifest, err := transport.RouteManifest(r.Context(), route.ID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing route manifest", "route", route.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if manifest == nil {
+			manifest = []types.RouteAssignment{}
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": manifest})
+	}
+}
+
+// UnassignHandler takes a student off a route: DELETE /routes/{id}/students/{studentId}
+func UnassignHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		transport, ok := transportOf(w, store, lang)
+		if !ok {
+			return
+		}
+		route, ok := routeByPath(w, r, transport, lang)
+		if !ok {
+			return
+		}
+		student, ok := studentByID(w, r, store, lang, "studentId", r.PathValue("studentId"))
+		if !ok {
+			return
+		}
+
+		err := transport.UnassignRoute(r.Context(), route.ID, student.ID)
+		if errors.Is(err, storage.ErrNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgNotRiding), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error unassigning route", "route", route.ID, "student", student.ID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// transportOf returns store as a storage.Transport, or writes a 501 if it has no bus routes
+func transportOf(w http.ResponseWriter, store storage.Storage, lang string) (storage.Transport, bool) {
+	transport, ok := storage.As[storage.Transport](store)
+	if !ok {
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgRoutesUnsupported), i18n.T(lang, i18n.MsgNoRoutes))
+	}
+	return transport, ok
+}
+
+// routeByPath loads the route named by the {id} path value, or writes a 400, 404 or 500 and
+// returns false
+func routeByPath(w http.ResponseWriter, r *http.Request, transport storage.Transport, lang string) (types.BusRoute, bool) {
+	publicID := strings.ToLower(r.PathValue("id"))
+	if !types.ValidPublicID(publicID) {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+		return types.BusRoute{}, false
+	}
+	route, err := transport.GetRouteByPublicID(r.Context(), publicID)
+	if errors.Is(err, storage.ErrRouteNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgRouteNotFound), err.Error())
+		return types.BusRoute{}, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up route", "id", publicID, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.BusRoute{}, false
+	}
+	return route, true
+}
+
+// studentByID loads the student with public ID id, given by the field or path value name, or
+// writes a 400, 404 or 500 and returns false
+func studentByID(w http.ResponseWriter, r *http.Request, store storage.Storage, lang, name, id string) (types.Student, bool) {
+	id = strings.ToLower(id)
+	if !types.ValidPublicID(id) {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, name))
+		return types.Student{}, false
+	}
+	student, err := store.GetStudentByPublicID(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgStudentNotFound), err.Error())
+		return types.Student{}, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up student", "id", id, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.Student{}, false
+	}
+	return student, true
+}
@@ -0,0 +1,433 @@
+// Package webhooks serves the webhook subscription API. Every route takes the caller's
+// X-API-Key, which must be one of the deployment's keys, and only ever shows or changes the
+// subscriptions that key created: another key's subscription is answered 404, as if it didn't
+// exist. internal/webhooks delivers the events.
+package webhooks
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/tenant"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+	delivery "github.com/prashantkumbhar2002/go_students_api/internal/webhooks"
+)
+
+// RotationGrace is how long a rotated secret keeps signing deliveries alongside the new one
+const RotationGrace = 24 * time.Hour
+
+// replayBatch is how many failed deliveries ReplayAllHandler reads at a time
+const replayBatch = 100
+
+// CreateHandler subscribes a URL to events: POST /webhooks {"url": "https://example.com/hook", "events": ["student.created"]}
+// The response carries the signing secret, which no later response repeats.
+func CreateHandler(store storage.Storage, keys []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		hooks, ok := webhooksOf(w, store, lang)
+		if !ok {
+			return
+		}
+		owner, ok := ownerOf(w, r, keys, lang)
+		if !ok {
+			return
+		}
+		var hook types.Webhook
+		err := helpers.DecodeJSON(r.Body, &hook)
+		if errors.Is(err, io.EOF) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), i18n.T(lang, i18n.MsgEmptyRequestBody))
+			return
+		}
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), helpers.DescribeDecodeError(err, lang))
+			return
+		}
+		if err := validation.Struct(hook); err != nil {
+			response.WriteValidationErrors(w, http.StatusBadRequest, err.(validator.ValidationErrors), lang)
+			return
+		}
+		if u, err := url.Parse(hook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidWebhook), i18n.T(lang, i18n.MsgWebhookURLRule))
+			return
+		}
+		var events []string
+		for _, e := range hook.Events {
+			if !slices.Contains(delivery.Events, e) {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidWebhook),
+					i18n.Tf(lang, i18n.MsgWebhookEventf, e, strings.Join(delivery.Events, ", ")))
+				return
+			}
+			if !slices.Contains(events, e) {
+				events = append(events, e)
+			}
+		}
+
+		created, err := hooks.CreateWebhook(r.Context(), types.Webhook{Owner: owner, URL: hook.URL, Events: events, Secret: delivery.NewSecret()})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error creating webhook", "url", hook.URL, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Webhook created", "id", created.PublicID, "url", created.URL, "events", created.Events)
+		w.Header().Set("Location", "/webhooks/"+created.PublicID)
+		response.WriteJson(w, http.StatusCreated, created)
+	}
+}
+
+// ListHandler lists the caller's subscriptions, oldest first: GET /webhooks
+func ListHandler(store storage.Storage, keys []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		hooks, ok := webhooksOf(w, store, lang)
+		if !ok {
+			return
+		}
+		owner, ok := ownerOf(w, r, keys, lang)
+		if !ok {
+			return
+		}
+		list, err := hooks.ListWebhooks(r.Context(), owner)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing webhooks", "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if list == nil {
+			list = []types.Webhook{}
+		}
+		for i := range list {
+			list[i].Secret = ""
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": list})
+	}
+}
+
+// GetHandler returns one of the caller's subscriptions: GET /webhooks/{id}
+func GetHandler(store storage.Storage, keys []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		hook, _, ok := ownWebhook(w, r, store, keys, lang)
+		if !ok {
+			return
+		}
+		hook.Secret = ""
+		response.WriteJson(w, http.StatusOK, hook)
+	}
+}
+
+// DeleteHandler unsubscribes: DELETE /webhooks/{id}
+// Deliveries still queued for the subscription are dropped.
+func DeleteHandler(store storage.Storage, keys []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		hook, hooks, ok := ownWebhook(w, r, store, keys, lang)
+		if !ok {
+			return
+		}
+		err := hooks.DeleteWebhook(r.Context(), hook.PublicID)
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgWebhookNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error deleting webhook", "id", hook.PublicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Webhook deleted", "id", hook.PublicID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RotateSecretHandler gives a subscription a new signing secret: POST /webhooks/{id}/rotate-secret
+// Deliveries are signed with the old secret too for RotationGrace, so the receiver can be
+// switched over without rejecting any. The response carries the new secret.
+func RotateSecretHandler(store storage.Storage, keys []string, clk clock.Clock) http.HandlerFunc {
+	clk = clock.OrReal(clk)
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		hook, hooks, ok := ownWebhook(w, r, store, keys, lang)
+		if !ok {
+			return
+		}
+		rotated, err := hooks.RotateWebhookSecret(r.Context(), hook.PublicID, delivery.NewSecret(), clk.Now().Add(RotationGrace))
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgWebhookNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error rotating webhook secret", "id", hook.PublicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Webhook secret rotated", "id", rotated.PublicID)
+		response.WriteJson(w, http.StatusOK, rotated)
+	}
+}
+
+// TestHandler queues a webhook.test delivery to a subscription: POST /webhooks/{id}/test
+// It responds 202 with the job ID; GET /jobs/{id} tells whether the receiver accepted it.
+func TestHandler(store storage.Storage, keys []string, runner *jobs.Runner, clk clock.Clock) http.HandlerFunc {
+	clk = clock.OrReal(clk)
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		hook, hooks, ok := ownWebhook(w, r, store, keys, lang)
+		if !ok {
+			return
+		}
+		id, err := delivery.Test(r.Context(), hooks, runner, hook, clk.Now())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error queueing webhook test", "id", hook.PublicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Webhook test queued", "id", hook.PublicID, "job_id", id)
+		w.Header().Set("Location", "/jobs/"+strconv.FormatInt(id, 10))
+		response.WriteJson(w, http.StatusAccepted, map[string]int64{"job_id": id})
+	}
+}
+
+// DeliveriesHandler lists a subscription's undelivered events, newest first:
+// GET /webhooks/{id}/deliveries?status=failed&limit=20
+// Bodies and responses are left out; GET /webhooks/{id}/deliveries/{deliveryId} has them.
+func DeliveriesHandler(store storage.Storage, keys []string, limits types.PaginationLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		hook, hooks, ok := ownWebhook(w, r, store, keys, lang)
+		if !ok {
+			return
+		}
+		status := r.URL.Query().Get("status")
+		if status != "" && !slices.Contains(types.WebhookDeliveryStatuses, status) {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidDeliveryStatus),
+				i18n.Tf(lang, i18n.MsgAppStatusf, strings.Join(types.WebhookDeliveryStatuses, ", ")))
+			return
+		}
+		limit := limits.Default
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < types.MinLimit || n > limits.Max {
+				response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidLimit),
+					i18n.Tf(lang, i18n.MsgOutOfRangef, "limit", types.MinLimit, limits.Max))
+				return
+			}
+			limit = n
+		}
+
+		list, err := hooks.ListWebhookDeliveries(r.Context(), hook.PublicID, status, 0, limit)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error listing webhook deliveries", "id", hook.PublicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+			return
+		}
+		if list == nil {
+			list = []types.WebhookDelivery{}
+		}
+		for i := range list {
+			list[i].Body, list[i].ResponseBody = nil, ""
+		}
+		response.WriteJson(w, http.StatusOK, map[string]any{"data": list})
+	}
+}
+
+// DeliveryHandler returns one undelivered event with its body and the receiver's last answer:
+// GET /webhooks/{id}/deliveries/{deliveryId}
+func DeliveryHandler(store storage.Storage, keys []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		d, _, ok := ownDelivery(w, r, store, keys, lang)
+		if !ok {
+			return
+		}
+		response.WriteJson(w, http.StatusOK, d)
+	}
+}
+
+// ReplayHandler queues a failed delivery again: POST /webhooks/{id}/deliveries/{deliveryId}/replay
+// It sends the body the event was first sent with, and responds 202 with the job ID. A delivery
+// still queued is answered 409.
+func ReplayHandler(store storage.Storage, keys []string, runner *jobs.Runner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		d, hooks, ok := ownDelivery(w, r, store, keys, lang)
+		if !ok {
+			return
+		}
+		if d.Status != types.DeliveryFailed {
+			response.WriteError(w, http.StatusConflict, i18n.T(lang, i18n.MsgDeliveryNotFailed), i18n.Tf(lang, i18n.MsgDeliveryStatusf, d.PublicID, d.Status))
+			return
+		}
+		id, err := delivery.Replay(r.Context(), hooks, runner, d)
+		if errors.Is(err, storage.ErrDeliveryNotFound) {
+			response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgDeliveryNotFound), err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error replaying webhook delivery", "id", d.WebhookID, "delivery_id", d.PublicID, "error", err)
+			response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Webhook delivery replayed", "id", d.WebhookID, "delivery_id", d.PublicID, "job_id", id)
+		w.Header().Set("Location", "/jobs/"+strconv.FormatInt(id, 10))
+		response.WriteJson(w, http.StatusAccepted, map[string]int64{"job_id": id})
+	}
+}
+
+// ReplayAllHandler queues every failed delivery of a subscription again:
+// POST /webhooks/{id}/deliveries/replay
+// It replays the deliveries that had failed when it started, reading them newest first in pages
+// below the last one read, so a replay that fails again while it works isn't picked up a second
+// time. It responds 202 with how many it queued, which may be 0.
+func ReplayAllHandler(store storage.Storage, keys []string, runner *jobs.Runner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		hook, hooks, ok := ownWebhook(w, r, store, keys, lang)
+		if !ok {
+			return
+		}
+		replayed := 0
+		var before int64
+		for {
+			failed, err := hooks.ListWebhookDeliveries(r.Context(), hook.PublicID, types.DeliveryFailed, before, replayBatch)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error listing failed webhook deliveries", "id", hook.PublicID, "error", err)
+				response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+				return
+			}
+			if len(failed) == 0 {
+				break
+			}
+			for _, d := range failed {
+				_, err := delivery.Replay(r.Context(), hooks, runner, d)
+				if errors.Is(err, storage.ErrDeliveryNotFound) {
+					// Deleted since it was listed: nothing to replay
+					continue
+				}
+				if err != nil {
+					slog.ErrorContext(r.Context(), "Error replaying webhook delivery", "id", hook.PublicID, "delivery_id", d.PublicID, "error", err)
+					response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), err.Error())
+					return
+				}
+				replayed++
+			}
+			before = failed[len(failed)-1].ID
+		}
+		slog.InfoContext(r.Context(), "Webhook deliveries replayed", "id", hook.PublicID, "count", replayed)
+		response.WriteJson(w, http.StatusAccepted, map[string]int{"replayed": replayed})
+	}
+}
+
+// ownDelivery loads the delivery named by the path's deliveryId, which must be to the caller's
+// subscription named by its id. On failure it writes a 400, 401, 404, 501 or 500 and returns false.
+func ownDelivery(w http.ResponseWriter, r *http.Request, store storage.Storage, keys []string, lang string) (types.WebhookDelivery, storage.Webhooks, bool) {
+	hook, hooks, ok := ownWebhook(w, r, store, keys, lang)
+	if !ok {
+		return types.WebhookDelivery{}, nil, false
+	}
+	id := strings.ToLower(r.PathValue("deliveryId"))
+	if !types.ValidPublicID(id) {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "deliveryId"))
+		return types.WebhookDelivery{}, nil, false
+	}
+	d, err := hooks.GetWebhookDeliveryByPublicID(r.Context(), id)
+	if err == nil && d.WebhookID != hook.PublicID {
+		err = storage.ErrDeliveryNotFound
+	}
+	if errors.Is(err, storage.ErrDeliveryNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgDeliveryNotFound), err.Error())
+		return types.WebhookDelivery{}, nil, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up webhook delivery", "id", hook.PublicID, "delivery_id", id, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.WebhookDelivery{}, nil, false
+	}
+	return d, hooks, true
+}
+
+// ownWebhook loads the subscription named by the path's id for the caller. On failure it writes
+// a 400, 401, 404, 501 or 500 and returns false.
+func ownWebhook(w http.ResponseWriter, r *http.Request, store storage.Storage, keys []string, lang string) (types.Webhook, storage.Webhooks, bool) {
+	hooks, ok := webhooksOf(w, store, lang)
+	if !ok {
+		return types.Webhook{}, nil, false
+	}
+	owner, ok := ownerOf(w, r, keys, lang)
+	if !ok {
+		return types.Webhook{}, nil, false
+	}
+	id := strings.ToLower(r.PathValue("id"))
+	if !types.ValidPublicID(id) {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidID), i18n.Tf(lang, i18n.MsgNotUUIDf, "id"))
+		return types.Webhook{}, nil, false
+	}
+	hook, err := hooks.GetWebhook(r.Context(), id)
+	if err == nil && hook.Owner != owner {
+		err = storage.ErrWebhookNotFound
+	}
+	if errors.Is(err, storage.ErrWebhookNotFound) {
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgWebhookNotFound), err.Error())
+		return types.Webhook{}, nil, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up webhook", "id", id, "error", err)
+		response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgDatabaseError), err.Error())
+		return types.Webhook{}, nil, false
+	}
+	return hook, hooks, true
+}
+
+// ownerOf returns what the request's API key owns subscriptions under: its hex SHA-256, so
+// the keys themselves are never stored. Without one of keys it writes a 401 and returns false.
+func ownerOf(w http.ResponseWriter, r *http.Request, keys []string, lang string) (string, bool) {
+	got := r.Header.Get(tenant.APIKeyHeader)
+	known := false
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare([]byte(got), []byte(key)) == 1 {
+			known = true
+		}
+	}
+	if got == "" || !known {
+		response.WriteError(w, http.StatusUnauthorized, i18n.T(lang, i18n.MsgUnauthorized), i18n.T(lang, i18n.MsgAPIKeyRequired))
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(got))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// webhooksOf returns store's webhook subscriptions, or writes a 501 and returns false
+func webhooksOf(w http.ResponseWriter, store storage.Storage, lang string) (storage.Webhooks, bool) {
+	hooks, ok := storage.As[storage.Webhooks](store)
+	if !ok {
+		response.WriteError(w, http.StatusNotImplemented, i18n.T(lang, i18n.MsgWebhooksUnsupported), i18n.T(lang, i18n.MsgNoWebhooks))
+	}
+	return hooks, ok
+}
@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+)
+
+// DecodeJSON decodes one JSON value from body into v. An empty body is reported as io.EOF, like
+// json.Decoder; explain any other error to the client with DescribeDecodeError.
+func DecodeJSON(body io.Reader, v any) error {
+	return json.NewDecoder(body).Decode(v)
+}
+
+// DecodeBody reads the JSON request body into v, or writes a 400 explaining in lang why it
+// couldn't and returns false
+func DecodeBody(w http.ResponseWriter, r *http.Request, lang string, v any) bool {
+	err := DecodeJSON(r.Body, v)
+	if errors.Is(err, io.EOF) {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), i18n.T(lang, i18n.MsgEmptyRequestBody))
+		return false
+	}
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidRequestBody), DescribeDecodeError(err, lang))
+		return false
+	}
+	return true
+}
+
+// DescribeDecodeError explains in lang why DecodeJSON failed, naming the field, the type it must
+// have and the byte offset where a client can find the problem, e.g. "age must be a number (byte 27)".
+// Errors that aren't about the JSON itself (a failed or oversized read) keep their own message.
+func DescribeDecodeError(err error, lang string) string {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = i18n.T(lang, i18n.MsgJSONBody)
+		}
+		return i18n.Tf(lang, i18n.MsgJSONTypef, field, i18n.T(lang, jsonTypeName(typeErr.Type)), typeErr.Offset)
+	case errors.As(err, &syntaxErr):
+		return i18n.Tf(lang, i18n.MsgJSONSyntaxf, syntaxErr.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return i18n.T(lang, i18n.MsgJSONTruncated)
+	}
+	return err.Error()
+}
+
+// jsonTypeName returns the message key of the JSON type a Go value of type t is decoded from
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return i18n.MsgJSONNumber
+	case reflect.String:
+		return i18n.MsgJSONString
+	case reflect.Bool:
+		return i18n.MsgJSONBoolean
+	case reflect.Slice, reflect.Array:
+		return i18n.MsgJSONArray
+	}
+	return i18n.MsgJSONObject
+}
@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+)
+
+func TestDescribeDecodeError(t *testing.T) {
+	type nested struct {
+		Active *bool `json:"active"`
+	}
+	type body struct {
+		Name   string   `json:"name"`
+		Age    int      `json:"age"`
+		IDs    []string `json:"ids"`
+		Nested nested   `json:"nested"`
+	}
+	for _, tc := range []struct {
+		json string
+		want string
+	}{
+		{`{"name": "Asha", "age": "21"}`, "age must be a number (byte 28)"},
+		{`{"name": 7}`, "name must be a string (byte 10)"},
+		{`{"ids": {}}`, "ids must be an array (byte 9)"},
+		{`{"nested": {"active": "yes"}}`, "nested.active must be true or false (byte 27)"},
+		{`[1, 2]`, "the request body must be an object (byte 1)"},
+		{`{"name": "Asha",}`, "malformed JSON at byte 17"},
+		{`{"name": "Asha"`, "the JSON ends before it is complete"},
+	} {
+		var v body
+		err := DecodeJSON(strings.NewReader(tc.json), &v)
+		if err == nil {
+			t.Errorf("DecodeJSON(%s) succeeded, want an error", tc.json)
+			continue
+		}
+		if got := DescribeDecodeError(err, i18n.LangEnglish); got != tc.want {
+			t.Errorf("DecodeJSON(%s): %q, want %q", tc.json, got, tc.want)
+		}
+	}
+
+	// Anything else keeps its own message
+	if got := DescribeDecodeError(errors.New("http: request body too large"), i18n.LangEnglish); got != "http: request body too large" {
+		t.Errorf("read error described as %q", got)
+	}
+}
@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ContentDigestHeader carries a body's checksum (RFC 9530): exports send it as a trailer, imports
+// may send it so a truncated or corrupted upload is refused instead of half imported.
+//
+//	Content-Digest: sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:
+const ContentDigestHeader = "Content-Digest"
+
+// ContentDigest formats a SHA-256 sum as a Content-Digest value
+func ContentDigest(sum []byte) string {
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum) + ":"
+}
+
+// ParseContentDigest returns the SHA-256 sum a request's Content-Digest header declares, or nil
+// without one. Other algorithms are ignored, as RFC 9530 allows, but a header naming none but
+// them is an error: the client asked for a check we can't do.
+func ParseContentDigest(r *http.Request) ([]byte, error) {
+	h := r.Header.Get(ContentDigestHeader)
+	if h == "" {
+		return nil, nil
+	}
+	for _, member := range strings.Split(h, ",") {
+		alg, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if !ok || !strings.EqualFold(alg, "sha-256") {
+			continue
+		}
+		// A byte sequence in structured fields is base64 between colons
+		if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+			return nil, fmt.Errorf("%s sha-256 value %q is not a :base64: byte sequence", ContentDigestHeader, value)
+		}
+		sum, err := base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+		if err != nil || len(sum) != 32 {
+			return nil, fmt.Errorf("%s sha-256 value %q is not a base64 SHA-256 sum", ContentDigestHeader, value)
+		}
+		return sum, nil
+	}
+	return nil, errors.New(ContentDigestHeader + " must include a sha-256 digest")
+}
@@ -1,21 +1,23 @@
 package helpers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/prashantkumbhar2002/go_students_api/internal/types"
 )
 
-// parsePaginationParams extracts and validates pagination parameters from request
-func ParsePaginationParams(r *http.Request) types.PaginationParams {
+// ParsePaginationParams extracts and validates pagination parameters from request.
+// A limit above limits.Max is lowered to it.
+func ParsePaginationParams(r *http.Request, limits types.PaginationLimits) types.PaginationParams {
 	// Get query parameters
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
 
 	// Set defaults
 	page := types.DefaultPage
-	limit := types.DefaultLimit
+	limit := limits.Default
 
 	// Parse page
 	if pageStr != "" {
@@ -29,8 +31,8 @@ func ParsePaginationParams(r *http.Request) types.PaginationParams {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
 			limit = l
 			// Enforce maximum limit to prevent abuse
-			if limit > types.MaxLimit {
-				limit = types.MaxLimit
+			if limit > limits.Max {
+				limit = limits.Max
 			}
 			if limit < types.MinLimit {
 				limit = types.MinLimit
@@ -42,4 +44,26 @@ func ParsePaginationParams(r *http.Request) types.PaginationParams {
 		Page:  page,
 		Limit: limit,
 	}
-}
\ No newline at end of file
+}
+
+// DryRunHeader asks a mutating endpoint to validate the request without storing anything
+const DryRunHeader = "X-Dry-Run"
+
+// ParseDryRun reports whether the request asks for a dry run, via the X-Dry-Run header or the
+// dry_run query parameter. Values are parsed like strconv.ParseBool; anything else is an error,
+// so a typo never turns a rehearsal into a real write.
+func ParseDryRun(r *http.Request) (bool, error) {
+	for _, v := range []string{r.Header.Get(DryRunHeader), r.URL.Query().Get("dry_run")} {
+		if v == "" {
+			continue
+		}
+		dry, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("dry run flag %q is not a boolean", v)
+		}
+		if dry {
+			return true, nil
+		}
+	}
+	return false, nil
+}
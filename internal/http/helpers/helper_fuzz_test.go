@@ -0,0 +1,32 @@
+package helpers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// FuzzParsePaginationParams feeds arbitrary query strings and checks the result is always usable
+func FuzzParsePaginationParams(f *testing.F) {
+	for _, seed := range []string{
+		"", "page=2&limit=50", "page=abc", "limit=-1", "limit=1000000",
+		"page=99999999999999999999", "page=1&page=2", "%zz", "limit=&page=",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, query string) {
+		r := httptest.NewRequest("GET", "/students", nil)
+		r.URL.RawQuery = query
+
+		limits := types.PaginationLimits{Default: 25, Max: 40}
+		p := ParsePaginationParams(r, limits)
+		if p.Page < 1 {
+			t.Errorf("query %q: page = %d, want >= 1", query, p.Page)
+		}
+		if p.Limit < types.MinLimit || p.Limit > limits.Max {
+			t.Errorf("query %q: limit = %d, want within [%d, %d]", query, p.Limit, types.MinLimit, limits.Max)
+		}
+	})
+}
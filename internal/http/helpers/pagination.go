@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Pagination styles of list endpoints. Both send Link and X-Total-Count headers; they differ in the body.
+const (
+	// PaginationBody returns a types.PaginatedResponse: the page plus its metadata
+	PaginationBody = "body"
+	// PaginationHeaders returns the bare array, GitHub style, leaving the metadata to the headers
+	PaginationHeaders = "headers"
+)
+
+// Accept profiles (RFC 6906) choosing the pagination style of one request, overriding the configured one:
+//
+//	Accept: application/json; profile="link-pagination"
+const (
+	ProfileBodyPagination = "body-pagination"
+	ProfileLinkPagination = "link-pagination"
+)
+
+// TotalCountHeader carries the number of items across all pages
+const TotalCountHeader = "X-Total-Count"
+
+// ValidPaginationStyle reports whether style is PaginationBody or PaginationHeaders
+func ValidPaginationStyle(style string) bool {
+	return style == PaginationBody || style == PaginationHeaders
+}
+
+// PaginationStyle returns the style asked for by a profile in the Accept header, or fallback
+func PaginationStyle(r *http.Request, fallback string) string {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			_, params, err := mime.ParseMediaType(mediaRange)
+			if err != nil {
+				continue
+			}
+			// profile may list several space-separated profiles
+			for _, profile := range strings.Fields(params["profile"]) {
+				switch profile {
+				case ProfileBodyPagination:
+					return PaginationBody
+				case ProfileLinkPagination:
+					return PaginationHeaders
+				}
+			}
+		}
+	}
+	return fallback
+}
+
+// SetPaginationHeaders sets X-Total-Count and an RFC 8288 Link header with first, prev, next
+// and last pages. Targets are relative to the request, keeping every other query parameter,
+// so they stay correct behind proxies and on tenant subdomains.
+func SetPaginationHeaders(w http.ResponseWriter, r *http.Request, p types.PaginationParams, totalItems int64, totalPages int) {
+	w.Header().Set(TotalCountHeader, strconv.FormatInt(totalItems, 10))
+
+	link := func(page int, rel string) string {
+		q := r.URL.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set("limit", strconv.Itoa(p.Limit))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, q.Encode(), rel)
+	}
+
+	last := max(totalPages, 1)
+	links := []string{link(1, "first")}
+	if p.Page > 1 {
+		links = append(links, link(min(p.Page-1, last), "prev"))
+	}
+	if p.Page < totalPages {
+		links = append(links, link(p.Page+1, "next"))
+	}
+	links = append(links, link(last, "last"))
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Error handling preferences (RFC 7240) a client can send to choose how malformed query
+// parameters are treated, overriding the configured default:
+//
+//	Prefer: handling=strict
+const (
+	HandlingStrict  = "strict"
+	HandlingLenient = "lenient"
+)
+
+// StrictParams reports whether malformed query parameters should be refused with 400 rather than
+// replaced by defaults. A "Prefer: handling=..." header decides, and is confirmed with
+// Preference-Applied; without one, def applies.
+func StrictParams(w http.ResponseWriter, r *http.Request, def bool) bool {
+	w.Header().Add("Vary", "Prefer")
+	switch handling := preferredHandling(r); handling {
+	case HandlingStrict, HandlingLenient:
+		w.Header().Set("Preference-Applied", "handling="+handling)
+		return handling == HandlingStrict
+	}
+	return def
+}
+
+// preferredHandling returns the value of the handling preference, lowercased, or ""
+func preferredHandling(r *http.Request) string {
+	for _, prefer := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(prefer, ",") {
+			// Preference parameters after ";" don't apply to handling
+			pref, _, _ = strings.Cut(pref, ";")
+			name, value, _ := strings.Cut(pref, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "handling") {
+				return strings.ToLower(strings.Trim(strings.TrimSpace(value), `"`))
+			}
+		}
+	}
+	return ""
+}
+
+// PaginationErrors lists, in lang, what ParsePaginationParams would otherwise quietly replace with
+// a default: a ?page= or ?limit= that is repeated, not a positive integer, or a limit over limits.Max.
+// Each message starts with the parameter's name.
+func PaginationErrors(r *http.Request, limits types.PaginationLimits, lang string) []string {
+	var errs []string
+	query := r.URL.Query()
+	for _, param := range []string{"page", "limit"} {
+		values, ok := query[param]
+		if !ok {
+			continue
+		}
+		if len(values) > 1 {
+			errs = append(errs, i18n.Tf(lang, i18n.MsgRepeatedParamf, param))
+			continue
+		}
+		n, err := strconv.Atoi(values[0])
+		switch {
+		case err != nil || n < 1:
+			errs = append(errs, i18n.Tf(lang, i18n.MsgNotPositiveIntf, param))
+		case param == "limit" && n > limits.Max:
+			errs = append(errs, i18n.Tf(lang, i18n.MsgOutOfRangef, param, types.MinLimit, limits.Max))
+		}
+	}
+	return errs
+}
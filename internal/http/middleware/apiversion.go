@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+)
+
+// APIVersionHeader is how a client picks an API version; responses echo the version served
+const APIVersionHeader = "API-Version"
+
+// APIVersions are the API versions a deployment serves and how their responses differ.
+// The zero value serves only version 1, without an envelope.
+type APIVersions struct {
+	// Default is served to requests without an API-Version header
+	Default int
+	// Supported lists the versions clients may ask for; it always includes Default
+	Supported []int
+	// Envelope lists the versions whose JSON bodies are wrapped as {"data": ..., "meta": {...}}
+	Envelope []int
+}
+
+// APIVersion resolves the API-Version header of each request and, for versions with an
+// envelope, makes response.WriteJson wrap the body. Put it inside RequestID so the envelope
+// can carry the request ID and the time since the request arrived.
+func APIVersion(v APIVersions) Middleware {
+	if v.Default == 0 {
+		v.Default = 1
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			version := v.Default
+			if h := r.Header.Get(APIVersionHeader); h != "" {
+				n, err := strconv.Atoi(h)
+				if err != nil || (n != v.Default && !slices.Contains(v.Supported, n)) {
+					lang := i18n.FromRequest(r)
+					response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgUnsupportedVersion),
+						i18n.Tf(lang, i18n.MsgUnknownVersionf, h))
+					return
+				}
+				version = n
+			}
+
+			w.Header().Set(APIVersionHeader, strconv.Itoa(version))
+			w.Header().Add("Vary", APIVersionHeader)
+			if slices.Contains(v.Envelope, version) {
+				w = response.WithEnvelope(w, RequestIDFrom(r.Context()), requestStart(r.Context()))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/concurrency"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+)
+
+// ConcurrencyLimit turns requests away once too many are in flight: 429 when the route is at its
+// own limit, 503 when the server as a whole is at capacity. Either way the client may retry soon.
+func ConcurrencyLimit(l *concurrency.Limiter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			release, err := l.Acquire(r)
+			if err != nil {
+				if r.Context().Err() != nil {
+					// The client is gone; there's no one to answer
+					return
+				}
+				lang := i18n.FromRequest(r)
+				w.Header().Set("Retry-After", "1")
+				if errors.Is(err, concurrency.ErrRouteBusy) {
+					response.WriteError(w, http.StatusTooManyRequests, i18n.T(lang, i18n.MsgTooManyConcurrent), i18n.Tf(lang, i18n.MsgRetryAfterf, "1"))
+					return
+				}
+				response.WriteError(w, http.StatusServiceUnavailable, i18n.T(lang, i18n.MsgServerBusy), i18n.Tf(lang, i18n.MsgRetryAfterf, "1"))
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
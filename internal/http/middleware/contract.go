@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/contract"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+)
+
+// ContractValidator checks every response against the OpenAPI document and logs violations.
+// The response itself is passed through untouched; this is a dev aid for spotting spec drift,
+// and it buffers a copy of each body, so keep it off in production.
+func ContractValidator(v *contract.Validator) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &teeWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// The document describes bodies without the envelope some API versions add
+			body := rec.body.Bytes()
+			if response.IsEnveloped(w) {
+				body = response.Unenvelope(body)
+			}
+			if err := v.ValidateResponse(r.Method, r.URL.Path, rec.status, w.Header(), body); err != nil {
+				slog.WarnContext(r.Context(), "Response violates API contract", "method", r.Method, "path", r.URL.Path, "error", err)
+			}
+		})
+	}
+}
+
+// teeWriter forwards a response to the client while keeping a copy of the status and body
+type teeWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	// max caps the copy of the body (0 = keep it all); truncated reports that it was reached
+	max       int
+	truncated bool
+}
+
+func (t *teeWriter) WriteHeader(status int) {
+	if !t.wroteHeader {
+		t.status = status
+		t.wroteHeader = true
+	}
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	t.wroteHeader = true
+	t.keep(p)
+	return t.ResponseWriter.Write(p)
+}
+
+// keep copies p, up to max
+func (t *teeWriter) keep(p []byte) {
+	if t.max > 0 && t.body.Len()+len(p) > t.max {
+		p = p[:t.max-t.body.Len()]
+		t.truncated = true
+	}
+	t.body.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (Flush, deadlines)
+func (t *teeWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/deprecation"
+)
+
+// Deprecation tells the callers of deprecated routes so in every response, errors included, and
+// counts the calls for GET /admin/deprecations. Routes past their sunset are still served; they go
+// away when their code does.
+func Deprecation(reg *deprecation.Registry) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rt, ok := reg.Use(r); ok {
+				deprecation.SetHeaders(w.Header(), rt)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
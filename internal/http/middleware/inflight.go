@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/inflight"
+)
+
+// TrackInFlight lists each request in reg, under the ID RequestID gave it, while it is served,
+// so GET /admin/requests can show it and an operator can cancel it. It must run inside RequestID.
+func TrackInFlight(reg *inflight.Registry) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, done := reg.Track(r, RequestIDFrom(r.Context()))
+			defer done()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
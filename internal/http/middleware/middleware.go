@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+)
+
+// Middleware wraps an http.Handler with cross-cutting behaviour
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies middlewares so the first one listed is the outermost
+// Chain(h, A, B) handles a request as A -> B -> h
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// Recoverer turns a panicking handler into a JSON 500 instead of a dropped connection
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				// http.ErrAbortHandler is used deliberately to abort a response; let net/http handle it
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				slog.ErrorContext(r.Context(), "Panic while handling request", "method", r.Method, "path", r.URL.Path, "panic", rec, "stack", string(debug.Stack()))
+				lang := i18n.FromRequest(r)
+				response.WriteError(w, http.StatusInternalServerError, i18n.T(lang, i18n.MsgInternalError), i18n.T(lang, i18n.MsgUnexpectedError))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ContentLanguage reports the language messages and labels are in, which handlers pick from
+// Accept-Language; Vary keeps shared caches from serving one language's response for another
+func ContentLanguage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Language", i18n.FromRequest(r))
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireBearerToken rejects requests whose Authorization header isn't "Bearer <token>" with a 401
+func RequireBearerToken(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				lang := i18n.FromRequest(r)
+				response.WriteError(w, http.StatusUnauthorized, i18n.T(lang, i18n.MsgUnauthorized), i18n.T(lang, i18n.MsgAdminTokenInvalid))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RejectDryRun guards mutating endpoints that can't honour a dry run, so asking for one fails
+// instead of silently performing the real write
+func RejectDryRun(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+		dry, err := helpers.ParseDryRun(r)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgInvalidDryRun), err.Error())
+			return
+		}
+		if dry {
+			response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgDryRunUnsupported), i18n.Tf(lang, i18n.MsgNoDryRunModef, r.Method+" "+r.URL.Path))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
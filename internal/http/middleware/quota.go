@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/quota"
+)
+
+// Quota headers, sent on every counted response like the rate limit headers
+const (
+	QuotaLimitHeader     = "X-Quota-Limit"
+	QuotaRemainingHeader = "X-Quota-Remaining"
+	// QuotaResetHeader is the number of seconds until the month ends and the allowance refills
+	QuotaResetHeader = "X-Quota-Reset"
+)
+
+// Quota answers 429 once a client has used up its monthly allowance. If the count can't be
+// read the request is served uncounted: a database hiccup shouldn't turn every client away.
+func Quota(m *quota.Meter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := m.Take(r)
+			if err != nil {
+				slog.WarnContext(r.Context(), "Error counting request against quota", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			reset := strconv.Itoa(int(math.Ceil(res.Reset.Seconds())))
+			w.Header().Set(QuotaLimitHeader, strconv.FormatInt(res.Limit, 10))
+			w.Header().Set(QuotaRemainingHeader, strconv.FormatInt(res.Remaining, 10))
+			w.Header().Set(QuotaResetHeader, reset)
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", reset)
+				lang := i18n.FromRequest(r)
+				response.WriteError(w, http.StatusTooManyRequests, i18n.T(lang, i18n.MsgQuotaExceeded),
+					i18n.Tf(lang, i18n.MsgQuotaResetf, res.Limit, reset))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
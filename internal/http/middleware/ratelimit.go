@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/ratelimit"
+)
+
+// Rate limit headers, sent on every response so clients can pace themselves before hitting 429
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	// RateLimitResetHeader is the number of seconds until the budget refills
+	RateLimitResetHeader = "X-RateLimit-Reset"
+)
+
+// RateLimit answers 429 once a client has used up its budget for the route. clientIP identifies
+// the client; see ClientIP.
+func RateLimit(l *ratelimit.Limiter, clientIP func(*http.Request) string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res := l.Allow(r, clientIP(r))
+			reset := strconv.Itoa(int(math.Ceil(res.Reset.Seconds())))
+			w.Header().Set(RateLimitLimitHeader, strconv.Itoa(res.Limit))
+			w.Header().Set(RateLimitRemainingHeader, strconv.Itoa(res.Remaining))
+			w.Header().Set(RateLimitResetHeader, reset)
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", reset)
+				lang := i18n.FromRequest(r)
+				response.WriteError(w, http.StatusTooManyRequests, i18n.T(lang, i18n.MsgRateLimited),
+					i18n.Tf(lang, i18n.MsgRetryAfterf, reset))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns how to identify a request's client: by the connection's address, or, behind
+// a proxy that sets it, by the first address in header (e.g. X-Forwarded-For or X-Real-IP).
+// Only name a header the proxy always overwrites; clients can send anything in it otherwise.
+func ClientIP(header string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		if header != "" {
+			if v, _, _ := strings.Cut(r.Header.Get(header), ","); strings.TrimSpace(v) != "" {
+				return strings.TrimSpace(v)
+			}
+		}
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+		return host
+	}
+}
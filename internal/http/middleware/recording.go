@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/recorder"
+)
+
+// Record keeps each request and its response in rec, masked, for GET /admin/recordings.
+// A development aid: it copies every body it sees, so it is only wired up in dev environments.
+// Reading the recordings isn't recorded.
+func Record(rec *recorder.Recorder) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, "/admin/recordings") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			// Only what the handler reads is kept, so an unread upload costs nothing
+			reqBody := &teeWriter{max: rec.MaxBody}
+			if r.Body != nil {
+				r.Body = readCloser{io.TeeReader(r.Body, writerFunc(reqBody.keep)), r.Body}
+			}
+			tee := &teeWriter{ResponseWriter: w, status: http.StatusOK, max: rec.MaxBody}
+			next.ServeHTTP(tee, r)
+
+			req := recorder.NewMessage(r.Header, reqBody.body.Bytes(), reqBody.truncated)
+			req.Method = r.Method
+			req.URL = recorder.MaskURL(r.URL)
+			resp := recorder.NewMessage(w.Header(), tee.body.Bytes(), tee.truncated)
+			resp.Status = tee.status
+			err := rec.Add(recorder.Recording{
+				RequestID: RequestIDFrom(r.Context()),
+				At:        start,
+				Duration:  time.Since(start).Round(time.Microsecond).String(),
+				Request:   req,
+				Response:  resp,
+			})
+			if err != nil {
+				slog.WarnContext(r.Context(), "Error saving recording", "error", err)
+			}
+		})
+	}
+}
+
+// readCloser reads through Reader and closes Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// writerFunc adapts a function that keeps bytes to io.Writer
+type writerFunc func(p []byte)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	f(p)
+	return len(p), nil
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLen bounds a client-supplied request ID, which ends up in logs and responses
+const maxRequestIDLen = 64
+
+type requestKey struct{}
+
+type requestInfo struct {
+	id    string
+	start time.Time
+}
+
+// RequestID tags each request with an ID and its arrival time. An ID sent by the client or a
+// proxy in X-Request-Id is kept so one request can be followed across services; otherwise a
+// new one is generated. The ID is echoed in the response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestKey{}, requestInfo{id: id, start: time.Now()})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFrom returns the ID RequestID assigned, or "" outside of it
+func RequestIDFrom(ctx context.Context) string {
+	info, _ := ctx.Value(requestKey{}).(requestInfo)
+	return info.id
+}
+
+// requestStart returns when RequestID saw the request, or now outside of it
+func requestStart(ctx context.Context) time.Time {
+	if info, ok := ctx.Value(requestKey{}).(requestInfo); ok {
+		return info.start
+	}
+	return time.Now()
+}
+
+// validRequestID accepts short IDs of letters, digits and - _ . : so a client can't inject
+// log lines or oversized headers through it
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TraceContext picks up the trace a request belongs to from its W3C traceparent header, as set
+// by an instrumented client or proxy, so every log line written while serving it carries the
+// caller's trace and span IDs (see logging.TraceHandler). Requests without one are left alone.
+func TraceContext(next http.Handler) http.Handler {
+	propagator := propagation.TraceContext{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,82 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Meta is the metadata block of an enveloped response
+type Meta struct {
+	RequestID string `json:"request_id,omitempty"`
+	// DurationMS is the time from the request reaching the server to the body being encoded
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Envelope is the body of a successful response in API versions that use one
+type Envelope struct {
+	Data any  `json:"data"`
+	Meta Meta `json:"meta"`
+}
+
+// envelopeWriter marks a response as enveloped; WriteJson finds it by unwrapping the writer
+type envelopeWriter struct {
+	http.ResponseWriter
+	requestID string
+	start     time.Time
+}
+
+// WithEnvelope makes WriteJson wrap bodies written to the returned writer as
+// {"data": ..., "meta": {...}}; errors keep their shape and gain a "meta" field instead.
+// Wrappers installed after it must implement Unwrap, like http.ResponseController expects.
+func WithEnvelope(w http.ResponseWriter, requestID string, start time.Time) http.ResponseWriter {
+	return &envelopeWriter{ResponseWriter: w, requestID: requestID, start: start}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (Flush, deadlines)
+func (e *envelopeWriter) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
+
+func (e *envelopeWriter) meta() *Meta {
+	return &Meta{RequestID: e.requestID, DurationMS: float64(time.Since(e.start).Microseconds()) / 1000}
+}
+
+// envelopeOf finds the envelopeWriter in w's chain of wrappers, or nil if the response isn't enveloped
+func envelopeOf(w http.ResponseWriter) *envelopeWriter {
+	for {
+		if e, ok := w.(*envelopeWriter); ok {
+			return e
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+}
+
+// IsEnveloped reports whether bodies written to w are enveloped
+func IsEnveloped(w http.ResponseWriter) bool {
+	return envelopeOf(w) != nil
+}
+
+// Unenvelope returns the body a response would have had without the envelope: the "data" of a
+// success, or an error without its "meta". Bodies of any other shape are returned unchanged.
+func Unenvelope(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+	if _, ok := fields["meta"]; !ok {
+		return body
+	}
+	if data, ok := fields["data"]; ok && len(fields) == 2 {
+		return data
+	}
+	delete(fields, "meta")
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(fields)
+	return buf.Bytes()
+}
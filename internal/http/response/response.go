@@ -1,30 +1,82 @@
 package response
 
 import (
+	"bytes"
 	"encoding/json"
-	// "log/slog"
-	"net/http"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
 )
 
 const (
-	StatusOK = "ok"
+	StatusOK    = "ok"
 	StatusError = "Error"
 )
+
 type ErrResponse struct {
-	Error   string 	`json:"error"`
-	Status  string  `json:"status"`
-	Message string  `json:"message,omitempty"`
+	Error   string `json:"error"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	// Meta is only set in API versions with an envelope
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+// bufPool reuses encoding buffers across responses to avoid an allocation per request
+var bufPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
 }
 
+// maxPooledBufSize stops one huge response from pinning a large buffer in the pool forever
+const maxPooledBufSize = 64 << 10
+
+// encodeFailedBody is sent when data can't be encoded. It is pre-rendered so it can't fail itself.
+var encodeFailedBody = []byte(`{"error":"internal server error","status":"Error","message":"failed to encode response"}` + "\n")
+
+// WriteJson encodes data into a pooled buffer before writing anything.
+// Buffering first lets us set Content-Length and, if encoding fails, still send a clean 500
+// instead of a half-written body with a success status.
+// If the API version uses an envelope (see WithEnvelope), data is wrapped in it here.
 func WriteJson(w http.ResponseWriter, status int, data any) error {
+	if e := envelopeOf(w); e != nil {
+		if er, ok := data.(ErrResponse); ok {
+			er.Meta = e.meta()
+			data = er
+		} else {
+			data = Envelope{Data: data, Meta: *e.meta()}
+		}
+	}
+
 	// slog.Info("Writing JSON response", "status", status, "data", data)
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufSize {
+			bufPool.Put(buf)
+		}
+	}()
+
 	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(buf).Encode(data); err != nil {
+		slog.Error("Error encoding JSON response", "error", err)
+		w.Header().Set("Content-Length", strconv.Itoa(len(encodeFailedBody)))
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(encodeFailedBody)
+		return err
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
 	w.WriteHeader(status)
-	return json.NewEncoder(w).Encode(data)
+	_, err := w.Write(buf.Bytes())
+	return err
 }
 
 func WriteError(w http.ResponseWriter, status int, err string, message string) error {
@@ -35,27 +87,54 @@ func WriteError(w http.ResponseWriter, status int, err string, message string) e
 	})
 }
 
-func WriteValidationErrors(w http.ResponseWriter, status int, errors validator.ValidationErrors) error {
+// WriteValidationErrors writes validation failures translated into lang
+// Unknown languages fall back to English translations
+func WriteValidationErrors(w http.ResponseWriter, status int, errors validator.ValidationErrors, lang string) error {
+	trans := validation.Translator(lang)
+
 	var errMsgs []string
 	for _, err := range errors {
-		switch err.ActualTag() {
-		case "required":
-			errMsgs = append(errMsgs, fmt.Sprintf("%s is required", err.Field()))
-		case "uuid":
-			errMsgs = append(errMsgs, fmt.Sprintf("%s is not a valid UUID", err.Field()))
-		case "min":
-			errMsgs = append(errMsgs, fmt.Sprintf("%s must be greater than %s", err.Field(), err.Param()))
-		case "max":
-			errMsgs = append(errMsgs, fmt.Sprintf("%s must be less than %s", err.Field(), err.Param()))
-		case "email":
-			errMsgs = append(errMsgs, fmt.Sprintf("%s is not a valid email", err.Field()))
-		default:
-			errMsgs = append(errMsgs, fmt.Sprintf("%s is not valid for tag %s", err.Field(), err.ActualTag()))
+		errMsgs = append(errMsgs, err.Translate(trans))
+	}
+	return WriteJson(w, status, ErrResponse{
+		Error:   i18n.T(lang, i18n.MsgValidationErrors),
+		Status:  StatusError,
+		Message: strings.Join(errMsgs, "; "),
+	})
+}
+
+// WriteBulkValidationErrors writes validation failures for a batch, keyed by the item's index in the request
+func WriteBulkValidationErrors(w http.ResponseWriter, status int, errors map[int]validator.ValidationErrors, lang string) error {
+	trans := validation.Translator(lang)
+
+	indexes := make([]int, 0, len(errors))
+	for i := range errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	var errMsgs []string
+	for _, i := range indexes {
+		for _, err := range errors[i] {
+			errMsgs = append(errMsgs, fmt.Sprintf("[%d] %s", i, err.Translate(trans)))
 		}
 	}
 	return WriteJson(w, status, ErrResponse{
-		Error:   "validation errors",
+		Error:   i18n.T(lang, i18n.MsgValidationErrors),
+		Status:  StatusError,
+		Message: strings.Join(errMsgs, "; "),
+	})
+}
+
+// WriteSchemaErrors writes JSON Schema violations, each prefixed with its JSON pointer into the body
+func WriteSchemaErrors(w http.ResponseWriter, status int, errors []validation.SchemaError, lang string) error {
+	var errMsgs []string
+	for _, err := range errors {
+		errMsgs = append(errMsgs, err.String())
+	}
+	return WriteJson(w, status, ErrResponse{
+		Error:   i18n.T(lang, i18n.MsgValidationErrors),
 		Status:  StatusError,
 		Message: strings.Join(errMsgs, "; "),
 	})
-}
\ No newline at end of file
+}
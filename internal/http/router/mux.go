@@ -0,0 +1,277 @@
+package router
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+)
+
+// Ways of normalizing request paths (see Deps.PathNormalization)
+const (
+	// PathsRedirect answers 308 Permanent Redirect to the normalized path, which keeps the method
+	// and body
+	PathsRedirect = "redirect"
+	// PathsRewrite serves the normalized path's route as if it had been asked for
+	PathsRewrite = "rewrite"
+	// PathsOff leaves paths to the ServeMux: duplicate slashes get its 301, a trailing slash 404
+	PathsOff = "off"
+)
+
+// ValidPathNormalization reports whether mode is one of the Paths constants
+func ValidPathNormalization(mode string) bool {
+	return mode == PathsRedirect || mode == PathsRewrite || mode == PathsOff
+}
+
+// mux is the http.ServeMux every handler here routes with. Requests no route takes get the
+// JSON error body every other error has, where the ServeMux would answer in plain text: 404 for a
+// path no route matches, and 405 for a method none of the path's routes takes, with the Allow
+// header the ServeMux computes.
+//
+// Every path answers OPTIONS with 204 and the Allow header, and HEAD runs the GET route (which
+// the ServeMux already matches) without its body but with the Content-Length it would have had,
+// so gateways probing routes see what the GET would send.
+type mux struct {
+	*http.ServeMux
+	// unmetered holds the patterns of routes that don't count against client quotas
+	unmetered map[string]bool
+}
+
+// newMux returns an empty mux
+func newMux() *mux {
+	return &mux{ServeMux: http.NewServeMux(), unmetered: make(map[string]bool)}
+}
+
+// handleUnmetered routes pattern to h like Handle, but keeps it out of client quotas (see metered)
+func (m *mux) handleUnmetered(pattern string, h http.Handler) {
+	m.Handle(pattern, h)
+	m.unmetered[pattern] = true
+}
+
+// metered applies mw to the requests m routes anywhere but an unmetered route, so probes and
+// operators never use up a client's allowance; the rest go straight to next
+func (m *mux) metered(mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		counted := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, pattern := m.Handler(r); m.unmetered[pattern] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			counted.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (m *mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Only the ServeMux's own error handlers come without a pattern; redirects to a cleaned path
+	// or a trailing slash have the pattern they redirect to
+	h, pattern := m.Handler(r)
+	if pattern != "" {
+		if r.Method == http.MethodHead {
+			hw := &headWriter{ResponseWriter: w}
+			m.ServeMux.ServeHTTP(hw, r)
+			hw.finish()
+			return
+		}
+		m.ServeMux.ServeHTTP(w, r)
+		return
+	}
+
+	// Let the ServeMux decide between 404 and 405, and which methods the path allows
+	status, allow := m.unrouted(h, r)
+
+	lang := i18n.FromRequest(r)
+	switch {
+	case status == http.StatusNotFound:
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgNotFound), i18n.Tf(lang, i18n.MsgNoRoutef, r.Method, r.URL.Path))
+	case status == http.StatusMethodNotAllowed && r.Method == http.MethodOptions:
+		w.Header().Set("Allow", allow)
+		w.WriteHeader(http.StatusNoContent)
+	case status == http.StatusMethodNotAllowed:
+		w.Header().Set("Allow", allow)
+		response.WriteError(w, http.StatusMethodNotAllowed, i18n.T(lang, i18n.MsgMethodNotAllowed), i18n.Tf(lang, i18n.MsgAllowedMethodsf, r.URL.Path, allow))
+	default:
+		// Nothing else comes without a pattern today; pass it on untouched
+		m.ServeMux.ServeHTTP(w, r)
+	}
+}
+
+// unrouted runs h, the ServeMux's handler for a request no route takes, and returns its status
+// and, for a 405, the methods the path takes including OPTIONS
+func (m *mux) unrouted(h http.Handler, r *http.Request) (int, string) {
+	rec := &headerRecorder{header: make(http.Header), status: http.StatusOK}
+	h.ServeHTTP(rec, r)
+	if rec.status != http.StatusMethodNotAllowed {
+		return rec.status, ""
+	}
+	methods := strings.Split(rec.header.Get("Allow"), ", ")
+	if !slices.Contains(methods, http.MethodOptions) {
+		methods = append(methods, http.MethodOptions)
+		slices.Sort(methods)
+	}
+	return rec.status, strings.Join(methods, ", ")
+}
+
+// routes reports whether m has a route for r: for its method, or for any method if r is OPTIONS,
+// which every routed path answers
+func (m *mux) routes(r *http.Request) bool {
+	h, pattern := m.Handler(r)
+	if pattern != "" {
+		return true
+	}
+	status, _ := m.unrouted(h, r)
+	return r.Method == http.MethodOptions && status == http.StatusMethodNotAllowed
+}
+
+// normalizer puts request paths in normal form before next sees them, so /students/ and
+// //students reach GET /students: duplicate slashes are collapsed and a trailing slash is
+// dropped, as paths says. It sits in front of the middleware chain, so rate limits, concurrency
+// limits and deprecations match the route the request ends up at.
+//
+// A path is only normalized when one of routers has a route for the result. A trailing slash is
+// only dropped when the path as asked for has no route, so subtree patterns such as /debug/pprof/
+// still match.
+type normalizer struct {
+	paths   string
+	routers []*mux
+	next    http.Handler
+}
+
+// normalizePaths returns next behind a normalizer for paths and the routes in routers; with
+// PathsOff, or "", it returns next as it is and paths are left to the ServeMux
+func normalizePaths(paths string, next http.Handler, routers ...*mux) http.Handler {
+	if paths != PathsRedirect && paths != PathsRewrite {
+		return next
+	}
+	return &normalizer{paths: paths, routers: routers, next: next}
+}
+
+func (n *normalizer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if normal := n.normalize(r); normal != nil {
+		if n.paths == PathsRedirect {
+			http.Redirect(w, r, normal.URL.String(), http.StatusPermanentRedirect)
+			return
+		}
+		r = normal
+	}
+	n.next.ServeHTTP(w, r)
+}
+
+// normalize returns a clone of r at its path without duplicate or trailing slashes if a route
+// takes that path, and nil if r is to be served as it is
+func (n *normalizer) normalize(r *http.Request) *http.Request {
+	// Escaped slashes (%2F) would be unescaped by rewriting Path; leave such paths alone
+	if r.URL.RawPath != "" {
+		return nil
+	}
+	path := normalPath(r.URL.Path)
+	if path == r.URL.Path {
+		return nil
+	}
+	// The ServeMux would answer duplicate slashes with its own redirect, which counts as a route
+	if !strings.Contains(r.URL.Path, "//") && n.routed(r) {
+		return nil
+	}
+	normal := r.Clone(r.Context())
+	normal.URL.Path = path
+	if !n.routed(normal) {
+		return nil
+	}
+	return normal
+}
+
+func (n *normalizer) routed(r *http.Request) bool {
+	return slices.ContainsFunc(n.routers, func(m *mux) bool { return m.routes(r) })
+}
+
+// normalPath collapses runs of slashes in path and drops a trailing one, except the root's
+func normalPath(path string) string {
+	var b strings.Builder
+	b.Grow(len(path))
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 && path[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(path[i])
+	}
+	p := b.String()
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+// sniffLen is how much of a body http.DetectContentType looks at
+const sniffLen = 512
+
+// headWriter serves HEAD with a GET route: it holds the status back until the handler returns and
+// counts the body instead of sending it, so the response has the Content-Length the GET would.
+// Like net/http, it sniffs a Content-Type from the body's start when the handler sets none.
+// Flushing is a no-op, as there is nothing to send early.
+type headWriter struct {
+	http.ResponseWriter
+	status int
+	n      int
+	sniff  []byte
+}
+
+func (h *headWriter) WriteHeader(status int) {
+	// 1xx responses such as 103 Early Hints go out as they come
+	if status < http.StatusOK {
+		h.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if h.status == 0 {
+		h.status = status
+	}
+}
+
+func (h *headWriter) Write(p []byte) (int, error) {
+	h.WriteHeader(http.StatusOK)
+	if room := sniffLen - len(h.sniff); room > 0 {
+		h.sniff = append(h.sniff, p[:min(room, len(p))]...)
+	}
+	h.n += len(p)
+	return len(p), nil
+}
+
+func (h *headWriter) Flush() {}
+
+// Unwrap lets http.ResponseController reach the underlying writer (deadlines)
+func (h *headWriter) Unwrap() http.ResponseWriter { return h.ResponseWriter }
+
+// finish sends the held status with the body's length, unless the handler set its own
+func (h *headWriter) finish() {
+	h.WriteHeader(http.StatusOK)
+	if h.n > 0 && h.Header().Get("Content-Length") == "" {
+		h.Header().Set("Content-Length", strconv.Itoa(h.n))
+	}
+	if _, ok := h.Header()["Content-Type"]; !ok && h.n > 0 {
+		h.Header().Set("Content-Type", http.DetectContentType(h.sniff))
+	}
+	h.ResponseWriter.WriteHeader(h.status)
+}
+
+// headerRecorder keeps the status and headers a handler writes and drops its body
+type headerRecorder struct {
+	header http.Header
+	status int
+	wrote  bool
+}
+
+func (h *headerRecorder) Header() http.Header { return h.header }
+
+func (h *headerRecorder) WriteHeader(status int) {
+	if !h.wrote {
+		h.status, h.wrote = status, true
+	}
+}
+
+func (h *headerRecorder) Write(p []byte) (int, error) {
+	h.wrote = true
+	return len(p), nil
+}
@@ -0,0 +1,415 @@
+package router
+
+import (
+	"cmp"
+	"net/http"
+	"net/http/pprof"
+	"slices"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/concurrency"
+	"github.com/prashantkumbhar2002/go_students_api/internal/contract"
+	"github.com/prashantkumbhar2002/go_students_api/internal/deprecation"
+	"github.com/prashantkumbhar2002/go_students_api/internal/health"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/admin"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/admissions"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/alumni"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/announcements"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/customfields"
+	eventhandlers "github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/events"
+	healthhandlers "github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/health"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/hostels"
+	jobhandlers "github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/library"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/stats"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/students"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/transport"
+	webhookhandlers "github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/webhooks"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/helpers"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/middleware"
+	"github.com/prashantkumbhar2002/go_students_api/internal/inflight"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/quota"
+	"github.com/prashantkumbhar2002/go_students_api/internal/ratelimit"
+	"github.com/prashantkumbhar2002/go_students_api/internal/recorder"
+	"github.com/prashantkumbhar2002/go_students_api/internal/retention"
+	"github.com/prashantkumbhar2002/go_students_api/internal/scheduler"
+	studentsvc "github.com/prashantkumbhar2002/go_students_api/internal/service/students"
+	"github.com/prashantkumbhar2002/go_students_api/internal/sms"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/cache"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/workerpool"
+)
+
+// Deps are the components the HTTP layer needs. Optional ones may be nil.
+type Deps struct {
+	Store storage.Storage
+	Pool  *workerpool.Pool
+	Cache *cache.Cache
+	// Reports backs GET /stats/students and GET /reports; nil disables the routes
+	Reports storage.Reporter
+	// Search backs GET /students/search; nil disables the route
+	Search storage.Searcher
+	// Jobs backs GET /jobs/{id} status polling; nil disables the route
+	Jobs storage.JobQueue
+	// JobRunner queues asynchronous imports; nil disables POST /students/import
+	JobRunner *jobs.Runner
+	Import    students.ImportOptions
+	// Profiles renders profile PDFs; batches also need JobRunner and Jobs
+	Profiles students.ProfileOptions
+	// LoanDays is how long a library book is lent when a checkout names no due date; zero means library.DefaultLoanDays
+	LoanDays int
+	// BulkConfirmAbove is how many students PATCH /students may change without confirm=true; zero
+	// means students.DefaultBulkConfirmAbove
+	BulkConfirmAbove int
+	// WebhookKeys are the API keys that may subscribe to webhooks, each managing its own
+	// subscriptions; empty disables /webhooks. POST /webhooks/{id}/test and the replay routes also
+	// need JobRunner.
+	WebhookKeys []string
+	// SMSReports reads the SMS provider's delivery reports for POST /webhooks/sms; nil disables the route
+	SMSReports sms.Receiver
+	// Scheduler runs maintenance jobs; nil when scheduling is disabled
+	Scheduler *scheduler.Scheduler
+	// Retention backs the GET /admin/retention dry-run report; nil disables the route
+	Retention *retention.Engine
+
+	// InFlight tracks the requests being served for GET /admin/requests; nil disables tracking and the routes
+	InFlight *inflight.Registry
+
+	// Readiness backs GET /readyz; nil means always ready
+	Readiness *health.Readiness
+
+	// Clock is the time source for handlers; nil means the system clock
+	Clock clock.Clock
+
+	// API selects the API version per request and which versions wrap bodies in an envelope
+	API middleware.APIVersions
+	// RateLimiter throttles each client, per route; nil disables rate limiting
+	RateLimiter *ratelimit.Limiter
+	// ClientIPHeader names the proxy header that identifies clients to the rate limiter; "" uses the connection address
+	ClientIPHeader string
+	// Quota caps each client's requests per month and backs GET /admin/usage; nil disables both
+	Quota *quota.Meter
+	// Concurrency caps the requests in flight, per route and overall; nil disables the cap
+	Concurrency *concurrency.Limiter
+	// Deprecations marks routes deprecated in their responses and backs GET /admin/deprecations; nil disables both
+	Deprecations *deprecation.Registry
+	// PaginationStyle is the default shape of list responses (see helpers.PaginationBody); "" means body
+	PaginationStyle string
+	// PageLimits are the default and maximum ?limit= of list endpoints; zero means types.DefaultPaginationLimits
+	PageLimits types.PaginationLimits
+	// StrictParams refuses malformed pagination parameters with 400 by default; clients can choose with "Prefer: handling=..."
+	StrictParams bool
+	// PathNormalization is how /students/ and //students reach /students: PathsRedirect,
+	// PathsRewrite, or PathsOff ("" too) to leave them to the ServeMux
+	PathNormalization string
+
+	// Recorder keeps request/response pairs for GET /admin/recordings; nil disables recording and the routes
+	Recorder *recorder.Recorder
+
+	// Dev enables development-only endpoints such as /admin/seed
+	Dev bool
+	// SeparateAdmin leaves /admin and /jobs out of New; NewAdmin or NewGroups serves them on their own listener
+	SeparateAdmin bool
+	// AdminToken is the bearer token /admin and /jobs ask for when New serves them alongside the
+	// API. Without one New leaves them out, so they are never public.
+	AdminToken string
+	// ValidateResponses logs responses that don't match the OpenAPI document
+	ValidateResponses bool
+}
+
+// Route groups, the parts of the API a listener can serve on its own (see NewGroups)
+const (
+	// GroupAPI is the home page and every resource route
+	GroupAPI = "api"
+	// GroupHealth is the readiness probe, GET /readyz
+	GroupHealth = "health"
+	// GroupAdmin is the operational routes under /admin and /jobs
+	GroupAdmin = "admin"
+	// GroupPprof is the profiler under /debug/pprof
+	GroupPprof = "pprof"
+)
+
+// Groups lists every route group
+var Groups = []string{GroupAPI, GroupHealth, GroupAdmin, GroupPprof}
+
+// New builds the complete HTTP handler: every route plus the middleware chain.
+// main.go and the integration test harness both use it, so tests exercise exactly what ships.
+func New(d Deps) http.Handler {
+	groups := []string{GroupAPI, GroupHealth}
+	if !d.SeparateAdmin && d.AdminToken != "" {
+		groups = append(groups, GroupAdmin)
+	}
+	return NewGroups(d, groups...)
+}
+
+// NewGroups serves only the route groups listed, for a listener of their own, behind the same
+// middleware chain as New. Client quotas only apply to GroupAPI's routes, so probes and operators'
+// requests don't count against them; the profiler isn't JSON, so it sits outside the contract
+// validator.
+// Names not in Groups are ignored.
+func NewGroups(d Deps, groups ...string) http.Handler {
+	router := newMux()
+	for _, g := range groups {
+		switch g {
+		case GroupAPI:
+			registerAPI(router, d)
+		case GroupHealth:
+			registerHealth(router, d)
+		case GroupAdmin:
+			registerAdmin(router, d)
+		}
+	}
+	if !slices.Contains(groups, GroupAPI) {
+		d.Quota = nil
+	}
+	handler := middleware.Chain(router, middlewares(d, router)...)
+	if !slices.Contains(groups, GroupPprof) {
+		return normalizePaths(d.PathNormalization, handler, router)
+	}
+
+	profiler := newMux()
+	profiler.HandleFunc("GET /debug/pprof/", pprof.Index)
+	profiler.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	profiler.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	profiler.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	profiler.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	outer := newMux()
+	outer.Handle("/", handler)
+	outer.Handle("/debug/pprof/", profiler)
+	return normalizePaths(d.PathNormalization, outer, router, profiler)
+}
+
+// registerAPI adds the home page and every resource route; which ones depends on the components
+// present
+func registerAPI(router *mux, d Deps) {
+	clk := clock.OrReal(d.Clock)
+	registerHome(router)
+
+	// A deployment that configures no page sizes gets the built-in ones
+	limits := cmp.Or(d.PageLimits, types.DefaultPaginationLimits)
+
+	svc := &studentsvc.Service{Store: d.Store, Clock: clk}
+	router.HandleFunc("POST /students", students.NewStudentHandler(svc))
+	router.HandleFunc("POST /students/bulk", students.BulkCreateStudentsHandler(svc))
+	if d.JobRunner != nil {
+		// Previews need the staged rows confirmed into checkpointed batches, and the job's status
+		imports := d.Import
+		stager, staging := storage.As[storage.ImportStager](d.Store)
+		_, checkpoints := storage.As[storage.ImportCheckpointer](d.Store)
+		imports.Staging = staging && checkpoints && d.Jobs != nil
+		router.Handle("POST /students/import", middleware.RejectDryRun(students.ImportStudentsHandler(d.JobRunner, imports)))
+		if imports.Staging {
+			router.Handle("POST /imports/{id}/confirm", middleware.RejectDryRun(students.ConfirmImportHandler(d.JobRunner, d.Jobs, stager)))
+			router.Handle("DELETE /imports/{id}", middleware.RejectDryRun(students.DiscardImportHandler(stager)))
+		}
+	}
+	router.HandleFunc("GET /students", students.GetStudentsListHandler(d.Store, students.ListOptions{
+		Style:  cmp.Or(d.PaginationStyle, helpers.PaginationBody),
+		Limits: limits,
+		Strict: d.StrictParams,
+	}))
+	router.HandleFunc("GET /students/export", students.ExportStudentsHandler(d.Store, clk))
+	router.Handle("PATCH /students", middleware.RejectDryRun(students.PatchStudentsHandler(d.Store, d.BulkConfirmAbove)))
+	if d.Search != nil {
+		router.HandleFunc("GET /students/search", students.SearchStudentsHandler(d.Search, limits))
+	}
+	router.Handle("POST /views", middleware.RejectDryRun(students.SaveViewHandler(d.Store)))
+	router.HandleFunc("GET /views", students.ListViewsHandler(d.Store))
+	router.HandleFunc("GET /views/{name}", students.GetViewHandler(d.Store))
+	router.Handle("DELETE /views/{name}", middleware.RejectDryRun(students.DeleteViewHandler(d.Store)))
+	router.HandleFunc("GET /students/duplicates", students.DuplicatesHandler(d.Store, limits))
+	router.HandleFunc("GET /students/changes", students.ChangesHandler(d.Store, limits))
+	router.HandleFunc("GET /events", eventhandlers.ListHandler(d.Store, limits))
+	router.HandleFunc("GET /students/{id}", students.GetStudentHandler(svc))
+	router.HandleFunc("PUT /students/{id}", students.ReplaceStudentHandler(svc))
+	router.HandleFunc("PATCH /students/{id}", students.PatchStudentHandler(svc))
+	router.HandleFunc("GET /students/{id}/profile.pdf", students.ProfilePDFHandler(d.Store, d.Profiles.Renderer))
+	if d.JobRunner != nil && d.Jobs != nil {
+		router.Handle("POST /students/profiles", middleware.RejectDryRun(students.QueueProfilesHandler(d.JobRunner, d.Profiles.Dir)))
+		router.HandleFunc("GET /students/profiles.pdf", students.DownloadProfilesHandler(d.Jobs))
+	}
+	router.Handle("POST /students/{id}/merge/{otherId}", middleware.RejectDryRun(students.MergeStudentsHandler(d.Store)))
+	router.Handle("POST /students/{id}/relationships", middleware.RejectDryRun(students.LinkStudentHandler(d.Store)))
+	router.HandleFunc("GET /students/{id}/relationships", students.RelationshipsHandler(d.Store))
+	router.Handle("DELETE /students/{id}/relationships/{otherId}", middleware.RejectDryRun(students.UnlinkStudentHandler(d.Store)))
+
+	router.Handle("POST /library/books", middleware.RejectDryRun(library.AddBookHandler(d.Store)))
+	router.HandleFunc("GET /library/books", library.ListBooksHandler(d.Store, limits))
+	router.Handle("POST /library/books/{id}/checkout", middleware.RejectDryRun(library.CheckOutHandler(d.Store, clk, d.LoanDays)))
+	router.Handle("POST /library/books/{id}/return", middleware.RejectDryRun(library.ReturnHandler(d.Store)))
+	router.HandleFunc("GET /library/loans/overdue", library.OverdueHandler(d.Store, clk))
+
+	router.Handle("POST /hostels", middleware.RejectDryRun(hostels.AddHostelHandler(d.Store)))
+	router.HandleFunc("GET /hostels/occupancy", hostels.OccupancyHandler(d.Store))
+	router.Handle("POST /hostels/{id}/rooms", middleware.RejectDryRun(hostels.AddRoomHandler(d.Store)))
+	router.HandleFunc("GET /hostels/{id}/rooms", hostels.RoomsHandler(d.Store))
+	router.Handle("POST /rooms/{id}/students", middleware.RejectDryRun(hostels.AllocateHandler(d.Store)))
+	router.HandleFunc("GET /rooms/{id}/students", hostels.OccupantsHandler(d.Store))
+	router.Handle("DELETE /rooms/{id}/students/{studentId}", middleware.RejectDryRun(hostels.VacateHandler(d.Store)))
+
+	router.Handle("POST /routes", middleware.RejectDryRun(transport.AddRouteHandler(d.Store)))
+	router.HandleFunc("GET /routes", transport.ListRoutesHandler(d.Store))
+	router.HandleFunc("GET /routes/{id}", transport.GetRouteHandler(d.Store))
+	router.Handle("POST /routes/{id}/stops", middleware.RejectDryRun(transport.AddStopHandler(d.Store)))
+	router.Handle("POST /routes/{id}/students", middleware.RejectDryRun(transport.AssignHandler(d.Store)))
+	router.HandleFunc("GET /routes/{id}/students", transport.ManifestHandler(d.Store))
+	router.Handle("DELETE /routes/{id}/students/{studentId}", middleware.RejectDryRun(transport.UnassignHandler(d.Store)))
+
+	router.Handle("POST /students/{id}/graduate", middleware.RejectDryRun(alumni.GraduateHandler(d.Store, clk)))
+	router.HandleFunc("GET /alumni", alumni.ListAlumniHandler(d.Store, limits))
+	router.HandleFunc("GET /alumni/{id}", alumni.GetAlumnusHandler(d.Store))
+
+	router.Handle("POST /applications", middleware.RejectDryRun(admissions.SubmitHandler(d.Store, clk)))
+	router.HandleFunc("GET /applications", admissions.ListApplicationsHandler(d.Store, limits))
+	router.HandleFunc("GET /applications/{id}", admissions.GetApplicationHandler(d.Store))
+	router.Handle("POST /applications/{id}/status", middleware.RejectDryRun(admissions.SetStatusHandler(d.Store)))
+	router.Handle("POST /applications/{id}/convert", middleware.RejectDryRun(admissions.ConvertHandler(d.Store, clk)))
+
+	router.Handle("POST /custom-fields", middleware.RejectDryRun(customfields.DefineHandler(d.Store)))
+	router.HandleFunc("GET /custom-fields", customfields.ListHandler(d.Store))
+	router.Handle("DELETE /custom-fields/{name}", middleware.RejectDryRun(customfields.DeleteHandler(d.Store)))
+
+	router.Handle("POST /announcements", middleware.RejectDryRun(announcements.PostHandler(d.Store)))
+	router.HandleFunc("GET /announcements/{id}", announcements.GetHandler(d.Store))
+	router.HandleFunc("GET /students/{id}/announcements", announcements.StudentAnnouncementsHandler(d.Store))
+	if d.SMSReports != nil {
+		router.Handle("POST /webhooks/sms", middleware.RejectDryRun(announcements.SMSReportHandler(d.Store, d.SMSReports)))
+	}
+	if len(d.WebhookKeys) > 0 {
+		router.Handle("POST /webhooks", middleware.RejectDryRun(webhookhandlers.CreateHandler(d.Store, d.WebhookKeys)))
+		router.HandleFunc("GET /webhooks", webhookhandlers.ListHandler(d.Store, d.WebhookKeys))
+		router.HandleFunc("GET /webhooks/{id}", webhookhandlers.GetHandler(d.Store, d.WebhookKeys))
+		router.Handle("DELETE /webhooks/{id}", middleware.RejectDryRun(webhookhandlers.DeleteHandler(d.Store, d.WebhookKeys)))
+		router.HandleFunc("GET /webhooks/{id}/deliveries", webhookhandlers.DeliveriesHandler(d.Store, d.WebhookKeys, limits))
+		router.HandleFunc("GET /webhooks/{id}/deliveries/{deliveryId}", webhookhandlers.DeliveryHandler(d.Store, d.WebhookKeys))
+		router.Handle("POST /webhooks/{id}/rotate-secret", middleware.RejectDryRun(webhookhandlers.RotateSecretHandler(d.Store, d.WebhookKeys, clk)))
+		if d.JobRunner != nil {
+			router.Handle("POST /webhooks/{id}/test", middleware.RejectDryRun(webhookhandlers.TestHandler(d.Store, d.WebhookKeys, d.JobRunner, clk)))
+			router.Handle("POST /webhooks/{id}/deliveries/replay", middleware.RejectDryRun(webhookhandlers.ReplayAllHandler(d.Store, d.WebhookKeys, d.JobRunner)))
+			router.Handle("POST /webhooks/{id}/deliveries/{deliveryId}/replay", middleware.RejectDryRun(webhookhandlers.ReplayHandler(d.Store, d.WebhookKeys, d.JobRunner)))
+		}
+	}
+
+	if d.Reports != nil {
+		router.HandleFunc("GET /stats/students", stats.StudentStatsHandler(d.Reports, clk))
+		router.HandleFunc("GET /reports", stats.ReportHandler(d.Reports, clk))
+	}
+
+	router.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Second)
+		w.Write([]byte("This is Slow page,.... It works!"))
+	})
+}
+
+// NewAdmin serves the operational routes (/admin, /jobs, /debug/pprof) for a separate admin
+// listener; set SeparateAdmin so New leaves them out. It does no authentication of its own:
+// the caller wraps it in RequireBearerToken and/or serves it over mutual TLS.
+func NewAdmin(d Deps) http.Handler {
+	return NewGroups(d, GroupAdmin, GroupPprof)
+}
+
+// middlewares is the chain in front of router's routes
+func middlewares(d Deps, router *mux) []middleware.Middleware {
+	mws := []middleware.Middleware{
+		middleware.TraceContext,
+		middleware.Recoverer,
+		middleware.RequestID,
+	}
+	if d.Recorder != nil {
+		// Outside everything that can answer for the handler, so 429s and envelopes are recorded as sent
+		mws = append(mws, middleware.Record(d.Recorder))
+	}
+	mws = append(mws, middleware.ContentLanguage, middleware.APIVersion(d.API))
+	if d.InFlight != nil {
+		mws = append(mws, middleware.TrackInFlight(d.InFlight))
+	}
+	if d.Deprecations != nil {
+		// Before anything that can turn a request away, so a throttled caller still learns the route is going
+		mws = append(mws, middleware.Deprecation(d.Deprecations))
+	}
+	if d.RateLimiter != nil {
+		mws = append(mws, middleware.RateLimit(d.RateLimiter, middleware.ClientIP(d.ClientIPHeader)))
+	}
+	if d.Quota != nil {
+		// After the rate limiter, so throttled requests don't use up the month's allowance
+		mws = append(mws, router.metered(middleware.Quota(d.Quota)))
+	}
+	if d.Concurrency != nil {
+		mws = append(mws, middleware.ConcurrencyLimit(d.Concurrency))
+	}
+	if d.ValidateResponses {
+		mws = append(mws, middleware.ContractValidator(contract.MustNew()))
+	}
+	return mws
+}
+
+// NewPublic serves the routes that don't belong to any tenant (home page, readiness probe).
+// With multi-tenancy it handles requests that name no tenant; New already includes these routes.
+func NewPublic(d Deps) http.Handler {
+	router := newMux()
+	registerHome(router)
+	registerHealth(router, d)
+	return normalizePaths(d.PathNormalization, middleware.Chain(router, middleware.TraceContext, middleware.Recoverer, middleware.RequestID, middleware.ContentLanguage), router)
+}
+
+func registerHome(router *mux) {
+	router.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("This is Home page,.... It works!"))
+	})
+}
+
+func registerHealth(router *mux, d Deps) {
+	router.handleUnmetered("GET /readyz", healthhandlers.ReadyHandler(d.Readiness))
+}
+
+// registerAdmin adds the operational routes; which ones depends on the components present.
+// With an AdminToken each of them asks for it.
+func registerAdmin(router *mux, d Deps) {
+	handle := router.handleUnmetered
+	if d.AdminToken != "" {
+		auth := middleware.RequireBearerToken(d.AdminToken)
+		handle = func(pattern string, h http.Handler) { router.handleUnmetered(pattern, auth(h)) }
+	}
+
+	if d.Jobs != nil {
+		handle("GET /jobs/{id}", jobhandlers.GetJobHandler(d.Jobs))
+	}
+
+	if d.Pool != nil {
+		handle("GET /admin/workerpool", admin.WorkerPoolStatsHandler(d.Pool))
+	}
+	if d.Cache != nil {
+		handle("GET /admin/cache", admin.CacheStatsHandler(d.Cache))
+	}
+
+	if d.Recorder != nil {
+		handle("GET /admin/recordings", admin.RecordingsHandler(d.Recorder))
+		handle("GET /admin/recordings/{seq}", admin.RecordingHandler(d.Recorder))
+	}
+	if d.InFlight != nil {
+		handle("GET /admin/requests", admin.InFlightHandler(d.InFlight))
+		handle("POST /admin/requests/{id}/cancel", admin.CancelRequestHandler(d.InFlight))
+	}
+
+	if d.Scheduler != nil {
+		handle("GET /admin/jobs", admin.JobsHandler(d.Scheduler))
+		handle("POST /admin/jobs/{name}/run", admin.RunJobHandler(d.Scheduler))
+	}
+	if d.Retention != nil {
+		handle("GET /admin/retention", admin.RetentionReportHandler(d.Retention))
+	}
+	if d.Quota != nil {
+		handle("GET /admin/usage", admin.UsageHandler(d.Quota))
+	}
+	if d.Deprecations != nil {
+		handle("GET /admin/deprecations", admin.DeprecationsHandler(d.Deprecations))
+	}
+
+	if d.Dev {
+		handle("POST /admin/seed", middleware.RejectDryRun(admin.SeedHandler(d.Store)))
+		handle("POST /admin/reset", middleware.RejectDryRun(admin.ResetHandler(d.Store)))
+	}
+}
@@ -0,0 +1,1799 @@
+package router_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/concurrency"
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/deprecation"
+	"github.com/prashantkumbhar2002/go_students_api/internal/events"
+	"github.com/prashantkumbhar2002/go_students_api/internal/export"
+	"github.com/prashantkumbhar2002/go_students_api/internal/health"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/handlers/students"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/middleware"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/router"
+	"github.com/prashantkumbhar2002/go_students_api/internal/importer"
+	"github.com/prashantkumbhar2002/go_students_api/internal/inflight"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/quota"
+	"github.com/prashantkumbhar2002/go_students_api/internal/ratelimit"
+	"github.com/prashantkumbhar2002/go_students_api/internal/recorder"
+	"github.com/prashantkumbhar2002/go_students_api/internal/scheduler"
+	"github.com/prashantkumbhar2002/go_students_api/internal/sms"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/cache"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/sqlite"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/testutil"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/webhooks"
+)
+
+func TestCreateAndGetStudent(t *testing.T) {
+	srv := testutil.NewServer(t)
+
+	id, ok := srv.Do(http.MethodPost, "/students", map[string]any{"name": "Asha", "email": "asha@example.com", "age": 21}).
+		AssertStatus(http.StatusCreated).
+		JSON("id").(string)
+	if !ok || !types.ValidPublicID(id) {
+		t.Fatalf("created id = %q, want a UUID", id)
+	}
+
+	srv.Do(http.MethodGet, "/students/"+id, nil).
+		AssertStatus(http.StatusOK).
+		AssertHeader("Content-Type", "application/json").
+		AssertJSON("id", id).
+		AssertJSON("name", "Asha")
+
+	// Upper case is accepted; internal row IDs are not
+	srv.Do(http.MethodGet, "/students/"+strings.ToUpper(id), nil).AssertStatus(http.StatusOK)
+	srv.Do(http.MethodGet, "/students/1", nil).AssertStatus(http.StatusBadRequest)
+	srv.Do(http.MethodGet, "/students/"+types.NewPublicID(), nil).AssertStatus(http.StatusNotFound)
+}
+
+func TestCreateStudentValidation(t *testing.T) {
+	srv := testutil.NewServer(t)
+
+	srv.Do(http.MethodPost, "/students", map[string]any{"name": "", "email": "nope", "age": 5}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "validation errors")
+
+	srv.Do(http.MethodPost, "/students", nil).AssertStatus(http.StatusBadRequest)
+}
+
+func TestCreateStudentDecodeErrors(t *testing.T) {
+	srv := testutil.NewServer(t)
+
+	srv.Do(http.MethodPost, "/students", `{"name": "Asha", "email": "asha@example.com", "age": "21"}`).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid request body").
+		AssertJSON("message", "age must be a number (byte 57)")
+	srv.Do(http.MethodPost, "/students/bulk", `{"name": "Asha"}`).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", "the request body must be an array (byte 1)")
+	srv.Do(http.MethodPost, "/students", `{"name": "Asha",}`, testutil.WithHeader("Accept-Language", "hi")).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", "बाइट 17 पर JSON गलत है")
+}
+
+func TestListStudentsPagination(t *testing.T) {
+	srv := testutil.NewServer(t)
+	for _, id := range []string{"9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", "6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b"} {
+		st := newStudent()
+		st.PublicID = id
+		srv.Store.Put(st)
+	}
+
+	srv.Do(http.MethodGet, "/students?page=2&limit=2", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("total_items", float64(3)).
+		AssertJSON("has_prev", true).
+		AssertJSON("data.0.id", "6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b")
+}
+
+func TestListStudentsLinkHeaders(t *testing.T) {
+	srv := testutil.NewServer(t)
+	for range 5 {
+		srv.Store.Put(newStudent())
+	}
+
+	resp := srv.Do(http.MethodGet, "/students?page=2&limit=2", nil).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Total-Count", "5").
+		AssertHeader("Link", `</students?limit=2&page=1>; rel="first", </students?limit=2&page=1>; rel="prev", `+
+			`</students?limit=2&page=3>; rel="next", </students?limit=2&page=3>; rel="last"`)
+	resp.AssertJSON("total_items", float64(5))
+
+	// The profile swaps the body for the bare page
+	linkPagination := testutil.WithHeader("Accept", `application/json; profile="link-pagination"`)
+	resp = srv.Do(http.MethodGet, "/students?page=3&limit=2", nil, linkPagination).
+		AssertStatus(http.StatusOK).
+		AssertHeader("Link", `</students?limit=2&page=1>; rel="first", </students?limit=2&page=2>; rel="prev", </students?limit=2&page=3>; rel="last"`)
+	var page []types.Student
+	resp.DecodeJSON(&page)
+	if len(page) != 1 {
+		t.Fatalf("page = %+v, want the last student alone", page)
+	}
+
+	headers := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.PaginationStyle = "headers" }))
+	headers.Do(http.MethodGet, "/students", nil).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Total-Count", "0").
+		AssertJSON("", []any{})
+	headers.Do(http.MethodGet, "/students", nil, testutil.WithHeader("Accept", `application/json;profile="body-pagination"`)).
+		AssertJSON("total_items", float64(0))
+}
+
+func TestConfiguredPageLimits(t *testing.T) {
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) {
+		d.PageLimits = types.PaginationLimits{Default: 2, Max: 3}
+	}))
+	for range 5 {
+		srv.Store.Put(newStudent())
+	}
+
+	srv.Do(http.MethodGet, "/students", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("limit", float64(2)).
+		AssertJSON("total_pages", float64(3))
+	// Larger pages are capped, not refused
+	srv.Do(http.MethodGet, "/students?limit=50", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("limit", float64(3))
+	srv.Do(http.MethodGet, "/students/duplicates?limit=4", nil).
+		AssertStatus(http.StatusBadRequest)
+}
+
+func TestStrictPagination(t *testing.T) {
+	lenient := testutil.NewServer(t)
+	lenient.Do(http.MethodGet, "/students?page=abc&limit=500", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("page", float64(1)).
+		AssertJSON("limit", float64(100))
+
+	// A client can ask for strict handling on a lenient server...
+	lenient.Do(http.MethodGet, "/students?page=abc&limit=500", nil, testutil.WithHeader("Prefer", "handling=strict")).
+		AssertStatus(http.StatusBadRequest).
+		AssertHeader("Preference-Applied", "handling=strict").
+		AssertJSON("error", "invalid pagination parameters").
+		AssertJSON("message", "page must be a positive integer; limit must be between 1 and 100")
+
+	// ...and for lenient handling on a strict one
+	strict := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.StrictParams = true }))
+	strict.Do(http.MethodGet, "/students?page=1&page=2&limit=0", nil).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", "page must be given only once; limit must be a positive integer")
+	strict.Do(http.MethodGet, "/students?limit=0", nil, testutil.WithHeader("Prefer", `respond-async, handling="lenient"`)).
+		AssertStatus(http.StatusOK).
+		AssertHeader("Preference-Applied", "handling=lenient")
+	strict.Do(http.MethodGet, "/students?page=2&limit=5", nil).AssertStatus(http.StatusOK)
+}
+
+func TestListStudentsFilter(t *testing.T) {
+	srv := testutil.NewServer(t)
+	for _, st := range []types.Student{
+		{Name: "Asha Kumbhar", Email: "asha@example.com", Age: 21},
+		{Name: "Ravi Kumar", Email: "ravi@example.com", Age: 16},
+		{Name: "Meera", Email: "meera@example.com", Age: 30},
+		{Name: "Kumud", Email: "kumud@example.com", Age: 19},
+	} {
+		srv.Store.Put(st)
+	}
+
+	q := "/students?limit=1&filter=" + url.QueryEscape(`age>=18 AND name~"kum"`)
+	resp := srv.Do(http.MethodGet, q, nil).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Total-Count", "2").
+		AssertJSON("total_items", float64(2))
+	var page struct{ Data []types.Student }
+	resp.DecodeJSON(&page)
+	if len(page.Data) != 1 || page.Data[0].Name != "Asha Kumbhar" {
+		t.Fatalf("page = %+v, want Asha alone", page.Data)
+	}
+	// Pagination links keep the filter
+	if link := resp.Header.Get("Link"); !strings.Contains(link, "filter=age%3E%3D18") {
+		t.Errorf("Link = %q, want the filter kept", link)
+	}
+
+	srv.Do(http.MethodGet, "/students?filter="+url.QueryEscape(`id = 1`), nil).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid filter")
+}
+
+func TestExportZip(t *testing.T) {
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) {
+		d.Clock = clock.NewFake(time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC))
+	}))
+	for range 3 {
+		srv.Store.Put(newStudent())
+	}
+
+	res := srv.Do(http.MethodGet, "/students/export?format=csv&compress=zip", nil).
+		AssertStatus(http.StatusOK).
+		AssertHeader("Content-Type", "application/zip").
+		AssertHeader("Content-Disposition", `attachment; filename="students-20261016T093000Z.zip"`)
+	zr, err := zip.NewReader(bytes.NewReader(res.Body), int64(len(res.Body)))
+	if err != nil || len(zr.File) != 2 || zr.File[0].Name != "students.csv" || zr.File[1].Name != "manifest.json" {
+		t.Fatalf("archive = %v, %v, want students.csv and manifest.json", zr, err)
+	}
+	read := func(f *zip.File) []byte {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		defer rc.Close()
+		b, _ := io.ReadAll(rc)
+		return b
+	}
+	data := read(zr.File[0])
+	var m export.Manifest
+	if err := json.Unmarshal(read(zr.File[1]), &m); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if m.Rows != 3 || m.Bytes != int64(len(data)) || m.SHA256 != hex.EncodeToString(sum[:]) || m.Format != "csv" {
+		t.Errorf("manifest = %+v, want 3 rows and the checksum of %d bytes", m, len(data))
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 4 {
+		t.Errorf("students.csv has %d lines, want a header and 3 rows", lines)
+	}
+
+	// Unzipped, the checksum and row count follow the body as trailers
+	plain := srv.Do(http.MethodGet, "/students/export?format=csv", nil).
+		AssertStatus(http.StatusOK).
+		AssertHeader("Content-Type", "text/csv; charset=utf-8")
+	if !bytes.Equal(plain.Body, data) || plain.Trailer.Get("Content-Digest") != "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":" ||
+		plain.Trailer.Get("X-Row-Count") != "3" {
+		t.Errorf("trailers = %v, want the zipped file's checksum and 3 rows", plain.Trailer)
+	}
+	srv.Do(http.MethodGet, "/students/export?format=xml", nil).AssertStatus(http.StatusBadRequest)
+	srv.Do(http.MethodGet, "/students/export?compress=gzip", nil).AssertStatus(http.StatusBadRequest)
+}
+
+func TestImportChecksum(t *testing.T) {
+	// Imports are queued to a real job queue; no worker runs them
+	db, err := sqlite.NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "jobs.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	runner := jobs.New(db, jobs.Options{})
+	runner.Register(importer.Kind, func(context.Context, types.Job) (any, error) { return nil, nil })
+	spool := t.TempDir()
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) {
+		d.JobRunner = runner
+		d.Import = students.ImportOptions{Dir: spool, MaxBytes: 1 << 20}
+	}))
+	csv := "name,email\nAsha,asha@example.com\nRavi,ravi@example.com\n"
+	sum := sha256.Sum256([]byte(csv))
+	digest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+	asCSV := testutil.WithHeader("Content-Type", "text/csv")
+
+	srv.Do(http.MethodPost, "/students/import", csv, asCSV, testutil.WithHeader("Content-Digest", digest)).
+		AssertStatus(http.StatusAccepted)
+	// The last row lost on the way
+	srv.Do(http.MethodPost, "/students/import", csv[:len(csv)-19], asCSV, testutil.WithHeader("Content-Digest", digest)).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "checksum mismatch")
+	srv.Do(http.MethodPost, "/students/import", csv, asCSV, testutil.WithHeader("Content-Digest", "sha-256=abc")).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid Content-Digest")
+	srv.Do(http.MethodPost, "/students/import", csv, asCSV, testutil.WithHeader("Content-Digest", "md5=:AAAA:")).
+		AssertStatus(http.StatusBadRequest)
+
+	// Only the verified upload was kept for its job
+	if files, _ := os.ReadDir(spool); len(files) != 1 {
+		t.Errorf("spool holds %d files, want 1", len(files))
+	}
+}
+
+func TestImportPreviewThenConfirm(t *testing.T) {
+	db, err := sqlite.NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "students.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	runner := jobs.New(db, jobs.Options{})
+	runner.Register(importer.Kind, importer.Handler(db, nil, 100))
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) {
+		d.Store, d.Jobs, d.JobRunner = db, db, runner
+		d.Import = students.ImportOptions{Dir: t.TempDir(), MaxBytes: 1 << 20}
+	}))
+	ctx := context.Background()
+	if _, err := db.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 20, PublicID: types.NewPublicID()}); err != nil {
+		t.Fatal(err)
+	}
+	csv := "name,email,age\nAsha,ASHA@example.com,20\nRavi,ravi@example.com,21\nRavi,ravi@example.com,21\n"
+	asCSV := testutil.WithHeader("Content-Type", "text/csv")
+
+	srv.Do(http.MethodPost, "/students/import?preview=maybe", csv, asCSV).
+		AssertStatus(http.StatusBadRequest)
+	var queued struct {
+		JobID int64 `json:"job_id"`
+	}
+	srv.Do(http.MethodPost, "/students/import?preview=true", csv, asCSV).
+		AssertStatus(http.StatusAccepted).
+		DecodeJSON(&queued)
+	preview := strconv.FormatInt(queued.JobID, 10)
+
+	// Not run yet
+	srv.Do(http.MethodPost, "/imports/"+preview+"/confirm", nil).
+		AssertStatus(http.StatusConflict)
+	if ran, err := runner.RunOnce(ctx); !ran || err != nil {
+		t.Fatalf("RunOnce() = %v, %v", ran, err)
+	}
+	srv.Do(http.MethodGet, "/jobs/"+preview, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("result.staged", float64(3)).
+		AssertJSON("result.new", float64(1)).
+		AssertJSON("result.duplicates", float64(1)).
+		AssertJSON("result.conflicts", float64(1))
+	if n, _ := db.GetStudentsCount(); n != 1 {
+		t.Fatalf("students = %d after a preview, want 1", n)
+	}
+
+	srv.Do(http.MethodPost, "/imports/"+preview+"/confirm", nil).
+		AssertStatus(http.StatusAccepted)
+	if ran, err := runner.RunOnce(ctx); !ran || err != nil {
+		t.Fatalf("RunOnce() = %v, %v", ran, err)
+	}
+	if n, _ := db.GetStudentsCount(); n != 2 {
+		t.Errorf("students = %d after confirming, want 2", n)
+	}
+
+	// The staged rows went with the confirmation
+	srv.Do(http.MethodPost, "/imports/"+preview+"/confirm", nil).
+		AssertStatus(http.StatusConflict)
+	srv.Do(http.MethodDelete, "/imports/"+preview, nil).
+		AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodPost, "/imports/999/confirm", nil).
+		AssertStatus(http.StatusNotFound)
+}
+
+func TestStudentProfilePDF(t *testing.T) {
+	srv := testutil.NewServer(t)
+	student := newStudent()
+	student.PublicID = types.NewPublicID()
+	srv.Store.Put(student)
+
+	resp := srv.Do(http.MethodGet, "/students/"+student.PublicID+"/profile.pdf", nil).
+		AssertStatus(http.StatusOK).
+		AssertHeader("Content-Type", "application/pdf")
+	if !bytes.HasPrefix(resp.Body, []byte("%PDF-")) {
+		t.Fatalf("body is not a PDF: %.16q", resp.Body)
+	}
+
+	srv.Do(http.MethodGet, "/students/"+types.NewPublicID()+"/profile.pdf", nil).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "student not found")
+	srv.Do(http.MethodGet, "/students/1/profile.pdf", nil).
+		AssertStatus(http.StatusBadRequest)
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	limiter, err := ratelimit.New(ratelimit.Rule{Requests: 5, Window: time.Minute}, map[string]ratelimit.Rule{
+		"POST /students/bulk": {Requests: 1, Window: time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := testutil.NewServer(t, testutil.WithoutContractValidation(),
+		testutil.WithDeps(func(d *router.Deps) { d.RateLimiter = limiter }))
+
+	srv.Do(http.MethodGet, "/students", nil).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-RateLimit-Limit", "5").
+		AssertHeader("X-RateLimit-Remaining", "4").
+		AssertHeader("X-RateLimit-Reset", "60")
+
+	// The bulk route has its own, stricter budget
+	srv.Do(http.MethodPost, "/students/bulk", []types.Student{newStudent()}).
+		AssertStatus(http.StatusCreated).
+		AssertHeader("X-RateLimit-Limit", "1").
+		AssertHeader("X-RateLimit-Remaining", "0")
+	srv.Do(http.MethodPost, "/students/bulk", []types.Student{newStudent()}).
+		AssertStatus(http.StatusTooManyRequests).
+		AssertHeader("Retry-After", "60").
+		AssertJSON("error", "rate limit exceeded")
+
+	srv.Do(http.MethodGet, "/students", nil).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-RateLimit-Remaining", "3")
+}
+
+func TestMonthlyQuota(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 31, 23, 0, 0, 0, time.UTC))
+	srv := testutil.NewServer(t, testutil.WithoutContractValidation(),
+		testutil.WithDeps(func(d *router.Deps) {
+			d.Quota = &quota.Meter{Store: d.Store.(storage.UsageCounter), Limit: 3, Keys: []string{"key-1"}, Clock: clk}
+		}))
+
+	srv.Do(http.MethodGet, "/students", nil, testutil.WithAPIKey("key-1")).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Quota-Limit", "3").
+		AssertHeader("X-Quota-Remaining", "2").
+		AssertHeader("X-Quota-Reset", "3600")
+	srv.Do(http.MethodGet, "/students", nil, testutil.WithAPIKey("key-1")).AssertStatus(http.StatusOK)
+	srv.Do(http.MethodGet, "/students", nil, testutil.WithAPIKey("key-1")).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Quota-Remaining", "0")
+	srv.Do(http.MethodGet, "/students", nil, testutil.WithAPIKey("key-1")).
+		AssertStatus(http.StatusTooManyRequests).
+		AssertHeader("Retry-After", "3600").
+		AssertJSON("error", "monthly quota exceeded")
+
+	// Requests without a known key share their own allowance
+	srv.Do(http.MethodGet, "/students", nil, testutil.WithAPIKey("made-up")).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Quota-Remaining", "2")
+
+	// Rejected requests aren't counted, and neither are operators'
+	sum := sha256.Sum256([]byte("key-1"))
+	res := srv.Do(http.MethodGet, "/admin/usage", nil).AssertStatus(http.StatusOK).
+		AssertJSON("month", "2026-10").
+		AssertJSON("limit", float64(3))
+	if got := res.JSON("data"); !reflect.DeepEqual(got, []any{
+		map[string]any{"client": hex.EncodeToString(sum[:]), "month": "2026-10", "requests": float64(3)},
+		map[string]any{"client": quota.Anonymous, "month": "2026-10", "requests": float64(1)},
+	}) {
+		t.Errorf("usage = %v, want key-1 at 3 and anonymous at 1", got)
+	}
+	srv.Do(http.MethodGet, "/admin/usage?month=October", nil).AssertStatus(http.StatusBadRequest)
+
+	// A new month refills the allowance
+	clk.Advance(time.Hour)
+	srv.Do(http.MethodGet, "/students", nil, testutil.WithAPIKey("key-1")).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Quota-Remaining", "2")
+}
+
+func TestQuotaLeavesProbesAlone(t *testing.T) {
+	srv := testutil.NewServer(t, testutil.WithoutContractValidation(),
+		testutil.WithDeps(func(d *router.Deps) {
+			d.Quota = &quota.Meter{Store: d.Store.(storage.UsageCounter), Limit: 2}
+		}))
+
+	// Key-less clients use up the anonymous allowance...
+	srv.Do(http.MethodGet, "/students", nil).AssertStatus(http.StatusOK)
+	srv.Do(http.MethodGet, "/students", nil).AssertStatus(http.StatusOK)
+	srv.Do(http.MethodGet, "/students", nil).AssertStatus(http.StatusTooManyRequests)
+
+	// ...which the readiness probe, sending no key either, doesn't share
+	for range 3 {
+		res := srv.Do(http.MethodGet, "/readyz", nil).AssertStatus(http.StatusOK)
+		if got := res.Header.Get("X-Quota-Remaining"); got != "" {
+			t.Errorf("GET /readyz X-Quota-Remaining = %q, want no quota headers", got)
+		}
+	}
+}
+
+func TestDeprecatedRoutes(t *testing.T) {
+	sunset := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)
+	reg, err := deprecation.New(map[string]deprecation.Route{
+		"GET /students/{id}": {Since: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Sunset: sunset, Link: "https://docs.example.com/students"},
+	})
+	if err != nil {
+		t.Fatalf("deprecation.New: %v", err)
+	}
+	srv := testutil.NewServer(t, testutil.WithoutContractValidation(),
+		testutil.WithDeps(func(d *router.Deps) { d.Deprecations = reg }))
+
+	// Errors are announced too: the caller still depends on the route
+	srv.Do(http.MethodGet, "/students/0190f3a2-7b1c-7d3e-8f4a-5b6c7d8e9f01", nil).
+		AssertStatus(http.StatusNotFound).
+		AssertHeader("Deprecation", "@1790812800").
+		AssertHeader("Sunset", "Sun, 31 Jan 2027 00:00:00 GMT").
+		AssertHeader("Link", `<https://docs.example.com/students>; rel="deprecation"; type="text/html"`)
+	if h := srv.Do(http.MethodGet, "/students", nil).AssertStatus(http.StatusOK).Header.Get("Deprecation"); h != "" {
+		t.Errorf("GET /students Deprecation = %q, want none", h)
+	}
+
+	res := srv.Do(http.MethodGet, "/admin/deprecations", nil).AssertStatus(http.StatusOK)
+	data, _ := res.JSON("data").([]any)
+	if len(data) != 1 {
+		t.Fatalf("data = %v, want one route", res.JSON("data"))
+	}
+	if u := data[0].(map[string]any); u["route"] != "GET /students/{id}" || u["requests"] != float64(1) || u["sunset"] != sunset.Format(time.RFC3339) {
+		t.Errorf("usage = %v, want GET /students/{id} called once", u)
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	limiter, err := concurrency.New(2, 0, map[string]concurrency.Rule{
+		"GET /students/{id}": {Limit: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := testutil.NewServer(t, testutil.WithoutContractValidation(),
+		testutil.WithDeps(func(d *router.Deps) { d.Concurrency = limiter }))
+	student := newStudent()
+	student.PublicID = types.NewPublicID()
+	srv.Store.Put(student)
+
+	// Hold the route's only slot, as a slow request would
+	release, err := limiter.Acquire(httptest.NewRequest(http.MethodGet, "/students/"+student.PublicID, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Do(http.MethodGet, "/students/"+student.PublicID, nil).
+		AssertStatus(http.StatusTooManyRequests).
+		AssertHeader("Retry-After", "1").
+		AssertJSON("error", "too many concurrent requests")
+	srv.Do(http.MethodGet, "/students", nil).AssertStatus(http.StatusOK)
+
+	// With the rest of the capacity taken too, every route is turned away
+	releaseList, err := limiter.Acquire(httptest.NewRequest(http.MethodGet, "/students", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Do(http.MethodGet, "/students", nil).
+		AssertStatus(http.StatusServiceUnavailable).
+		AssertJSON("error", "server busy")
+
+	release()
+	releaseList()
+	srv.Do(http.MethodGet, "/students/"+student.PublicID, nil).AssertStatus(http.StatusOK)
+}
+
+func TestInFlightRequests(t *testing.T) {
+	reg := inflight.New()
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.InFlight = reg }))
+
+	// A request stuck in its handler, as a slow import would be
+	ctx, done := reg.Track(httptest.NewRequest(http.MethodPost, "/students/import", nil), "stuck-import")
+	defer done()
+
+	var body struct {
+		Requests []inflight.Request `json:"requests"`
+	}
+	srv.Do(http.MethodGet, "/admin/requests", nil, testutil.WithHeader(middleware.RequestIDHeader, "lister")).
+		AssertStatus(http.StatusOK).
+		DecodeJSON(&body)
+	// The listing request is itself in flight
+	if len(body.Requests) != 2 || body.Requests[0].ID != "stuck-import" || body.Requests[0].Path != "/students/import" ||
+		body.Requests[1].ID != "lister" {
+		t.Fatalf("requests = %+v, want stuck-import then lister", body.Requests)
+	}
+
+	srv.Do(http.MethodPost, "/admin/requests/stuck-import/cancel", nil).
+		AssertStatus(http.StatusAccepted).
+		AssertJSON("cancelled", float64(1))
+	if !errors.Is(context.Cause(ctx), inflight.ErrCancelled) {
+		t.Fatalf("cancelled request's context cause = %v, want ErrCancelled", context.Cause(ctx))
+	}
+
+	srv.Do(http.MethodPost, "/admin/requests/nope/cancel", nil).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "request not found")
+}
+
+func TestRecordings(t *testing.T) {
+	rec := recorder.New(10, 4096, "")
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Recorder = rec }))
+
+	srv.Do(http.MethodPost, "/students", map[string]any{"name": "Asha", "email": "asha@example.com", "age": "21"},
+		testutil.WithHeader(middleware.RequestIDHeader, "bad-age"))
+	srv.Do(http.MethodGet, "/admin/recordings", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("recordings.0.request_id", "bad-age").
+		AssertJSON("recordings.0.request.url", "/students").
+		AssertJSON("recordings.0.request.body", `{"age":"21","email":"***","name":"***"}`).
+		AssertJSON("recordings.0.response.status", float64(http.StatusBadRequest))
+
+	// Reading recordings isn't recorded
+	if n := len(rec.List()); n != 1 {
+		t.Fatalf("%d recordings, want 1", n)
+	}
+	seq := strconv.FormatInt(rec.List()[0].Seq, 10)
+	srv.Do(http.MethodGet, "/admin/recordings/"+seq+"?base=http://127.0.0.1:9000", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("replay", `curl -X POST 'http://127.0.0.1:9000/students' -H 'Content-Type: application/json' --data-raw '{"age":"21","email":"***","name":"***"}'`)
+	srv.Do(http.MethodGet, "/admin/recordings/99", nil).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "recording not found")
+}
+
+func TestRunScheduledJob(t *testing.T) {
+	sch := scheduler.New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	sch.Add("export", "@daily", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Scheduler = sch }))
+
+	srv.Do(http.MethodPost, "/admin/jobs/export/run", nil).
+		AssertStatus(http.StatusAccepted).
+		AssertJSON("name", "export")
+	<-started
+	srv.Do(http.MethodPost, "/admin/jobs/export/run", nil).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "job already running")
+	srv.Do(http.MethodPost, "/admin/jobs/nope/run", nil).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "job not found")
+
+	close(release)
+	if err := sch.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	srv.Do(http.MethodGet, "/admin/jobs", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("jobs.0.runs", float64(1))
+}
+
+func TestListStudentsStorageError(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.Store.SetError(storagetest.MethodGetStudentsCount, storage.ErrDatabase)
+
+	srv.Do(http.MethodGet, "/students", nil).AssertStatus(http.StatusInternalServerError)
+}
+
+func newStudent() types.Student {
+	return types.Student{Name: "Student", Email: "student@example.com", Age: 20}
+}
+
+func TestAdminResetDevOnly(t *testing.T) {
+	prod := testutil.NewServer(t, testutil.WithoutContractValidation())
+	prod.Do(http.MethodPost, "/admin/reset", nil).AssertStatus(http.StatusNotFound)
+
+	dev := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Dev = true }))
+	dev.Store.Put(newStudent())
+
+	dev.Do(http.MethodPost, "/admin/reset?count=3&seed=7", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("seeded", float64(3))
+	dev.Do(http.MethodGet, "/students", nil).AssertJSON("total_items", float64(3))
+}
+
+func TestUnroutedRequestsGetJSONErrors(t *testing.T) {
+	srv := testutil.NewServer(t, testutil.WithoutContractValidation())
+
+	srv.Do(http.MethodGet, "/no-such-thing", nil).
+		AssertStatus(http.StatusNotFound).
+		AssertHeader("Content-Type", "application/json").
+		AssertJSON("error", "not found").
+		AssertJSON("message", "no route matches GET /no-such-thing")
+	srv.Do(http.MethodDelete, "/students", nil).
+		AssertStatus(http.StatusMethodNotAllowed).
+		AssertHeader("Allow", "GET, HEAD, OPTIONS, PATCH, POST").
+		AssertJSON("error", "method not allowed").
+		AssertJSON("status", "Error")
+	srv.Do(http.MethodDelete, "/students", nil, testutil.WithHeader("Accept-Language", "hi")).
+		AssertJSON("error", "method की अनुमति नहीं है")
+
+	// The home page is only the root, not every path nobody else takes
+	srv.Do(http.MethodGet, "/", nil).AssertStatus(http.StatusOK)
+}
+
+func TestNewGroupsServesOnlyItsGroups(t *testing.T) {
+	groups := func(g ...string) testutil.Option {
+		return testutil.WithHandler(func(d router.Deps) http.Handler { return router.NewGroups(d, g...) })
+	}
+	dev := testutil.WithDeps(func(d *router.Deps) { d.Dev = true })
+
+	public := testutil.NewServer(t, dev, groups(router.GroupAPI, router.GroupHealth), testutil.WithoutContractValidation())
+	public.Do(http.MethodPost, "/students", newStudent()).AssertStatus(http.StatusCreated)
+	public.Do(http.MethodGet, "/readyz", nil).AssertStatus(http.StatusOK)
+	public.Do(http.MethodPost, "/admin/reset", nil).AssertStatus(http.StatusNotFound)
+	public.Do(http.MethodGet, "/debug/pprof/", nil).AssertStatus(http.StatusNotFound)
+
+	metrics := testutil.NewServer(t, dev, groups(router.GroupHealth, router.GroupPprof), testutil.WithoutContractValidation())
+	metrics.Do(http.MethodGet, "/readyz", nil).AssertStatus(http.StatusOK)
+	metrics.Do(http.MethodGet, "/debug/pprof/", nil).AssertStatus(http.StatusOK)
+	metrics.Do(http.MethodGet, "/students", nil).AssertStatus(http.StatusNotFound)
+
+	admin := testutil.NewServer(t, dev, groups(router.GroupAdmin), testutil.WithoutContractValidation())
+	admin.Do(http.MethodPost, "/admin/reset?count=2", nil).AssertStatus(http.StatusOK)
+	admin.Do(http.MethodGet, "/readyz", nil).AssertStatus(http.StatusNotFound)
+}
+
+func TestPathNormalization(t *testing.T) {
+	mode := func(paths string) testutil.Option {
+		return testutil.WithDeps(func(d *router.Deps) { d.PathNormalization = paths })
+	}
+
+	redirect := testutil.NewServer(t, mode(router.PathsRedirect), testutil.WithoutContractValidation())
+	resp := redirect.Do(http.MethodGet, "/students/?limit=1", nil).AssertStatus(http.StatusOK)
+	if got := resp.Request.URL.RequestURI(); got != "/students?limit=1" {
+		t.Errorf("redirected to %s, want /students?limit=1", got)
+	}
+	// 308 keeps the method and body
+	resp = redirect.Do(http.MethodPost, "//students/", newStudent()).AssertStatus(http.StatusCreated)
+	if got := resp.Request.URL.Path; got != "/students" {
+		t.Errorf("redirected to %s, want /students", got)
+	}
+	redirect.Do(http.MethodGet, "/no-such-thing/", nil).AssertStatus(http.StatusNotFound)
+	redirect.Do(http.MethodGet, "/", nil).AssertStatus(http.StatusOK)
+
+	rewrite := testutil.NewServer(t, mode(router.PathsRewrite), testutil.WithoutContractValidation())
+	resp = rewrite.Do(http.MethodGet, "/students//", nil).AssertStatus(http.StatusOK)
+	if got := resp.Request.URL.Path; got != "/students//" {
+		t.Errorf("request ended at %s, want /students// served in place", got)
+	}
+
+	// Middleware sees the normalized path too, so a trailing slash doesn't escape a route's limit
+	limiter, err := ratelimit.New(ratelimit.Rule{Requests: 100, Window: time.Minute}, map[string]ratelimit.Rule{
+		"POST /students/bulk": {Requests: 1, Window: time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limited := testutil.NewServer(t, mode(router.PathsRewrite), testutil.WithoutContractValidation(),
+		testutil.WithDeps(func(d *router.Deps) { d.RateLimiter = limiter }))
+	limited.Do(http.MethodPost, "/students/bulk/", []types.Student{newStudent()}).
+		AssertStatus(http.StatusCreated).
+		AssertHeader("X-RateLimit-Limit", "1")
+	limited.Do(http.MethodPost, "/students/bulk/", []types.Student{newStudent()}).
+		AssertStatus(http.StatusTooManyRequests)
+
+	off := testutil.NewServer(t, mode(router.PathsOff), testutil.WithoutContractValidation())
+	off.Do(http.MethodGet, "/students/", nil).AssertStatus(http.StatusNotFound)
+}
+
+func TestOptionsAndHeadForEveryRoute(t *testing.T) {
+	srv := testutil.NewServer(t, testutil.WithoutContractValidation())
+	srv.Do(http.MethodPost, "/students", newStudent()).AssertStatus(http.StatusCreated)
+
+	srv.Do(http.MethodOptions, "/students", nil).
+		AssertStatus(http.StatusNoContent).
+		AssertHeader("Allow", "GET, HEAD, OPTIONS, PATCH, POST")
+	srv.Do(http.MethodOptions, "/readyz", nil).
+		AssertStatus(http.StatusNoContent).
+		AssertHeader("Allow", "GET, HEAD, OPTIONS")
+	srv.Do(http.MethodOptions, "/no-such-thing", nil).AssertStatus(http.StatusNotFound)
+
+	// HEAD has the GET's headers, Content-Length included, and no body; a streamed export has no
+	// Content-Length of its own
+	for _, path := range []string{"/students", "/students/export"} {
+		get := srv.Do(http.MethodGet, path, nil).AssertStatus(http.StatusOK)
+		head := srv.Do(http.MethodHead, path, nil).
+			AssertStatus(http.StatusOK).
+			AssertHeader("Content-Type", get.Header.Get("Content-Type"))
+		if head.ContentLength != int64(len(get.Body)) || len(head.Body) != 0 {
+			t.Errorf("HEAD %s: Content-Length %d and %d body bytes, want %d and none", path, head.ContentLength, len(head.Body), len(get.Body))
+		}
+	}
+}
+
+func TestReadyzFailsWhileDraining(t *testing.T) {
+	readiness := &health.Readiness{}
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Readiness = readiness }))
+
+	srv.Do(http.MethodGet, "/readyz", nil).AssertStatus(http.StatusOK).AssertJSON("status", "ready")
+
+	readiness.SetDraining()
+	srv.Do(http.MethodGet, "/readyz", nil).AssertStatus(http.StatusServiceUnavailable)
+}
+
+func TestSeparateAdminRouter(t *testing.T) {
+	// The admin listener brings its own authentication
+	separate := testutil.WithDeps(func(d *router.Deps) { d.Dev, d.SeparateAdmin, d.AdminToken = true, true, "" })
+
+	public := testutil.NewServer(t, separate, testutil.WithoutContractValidation())
+	public.Do(http.MethodPost, "/admin/reset", nil).AssertStatus(http.StatusNotFound)
+	public.Do(http.MethodPost, "/students", newStudent()).AssertStatus(http.StatusCreated)
+
+	admin := testutil.NewServer(t, separate, testutil.WithHandler(func(d router.Deps) http.Handler {
+		return middleware.Chain(router.NewAdmin(d), middleware.RequireBearerToken("secret"))
+	}))
+	admin.Do(http.MethodPost, "/admin/reset?count=2", nil).AssertStatus(http.StatusUnauthorized)
+	admin.Do(http.MethodPost, "/admin/reset?count=2", nil, testutil.WithBearerToken("wrong")).AssertStatus(http.StatusUnauthorized)
+	admin.Do(http.MethodPost, "/admin/reset?count=2", nil, testutil.WithBearerToken("secret")).
+		AssertStatus(http.StatusOK).
+		AssertJSON("seeded", float64(2))
+}
+
+func TestAdminRoutesOnThePublicPortNeedTheToken(t *testing.T) {
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Dev = true }), testutil.WithoutContractValidation())
+	srv.Do(http.MethodPost, "/admin/reset?count=2", nil, testutil.WithBearerToken("wrong")).
+		AssertStatus(http.StatusUnauthorized).
+		AssertHeader("WWW-Authenticate", `Bearer realm="admin"`)
+	srv.Do(http.MethodPost, "/admin/reset?count=2", nil, testutil.WithHeader("Authorization", "")).AssertStatus(http.StatusUnauthorized)
+	srv.Do(http.MethodPost, "/admin/reset?count=2", nil).AssertStatus(http.StatusOK)
+	// The API itself takes no admin token
+	srv.Do(http.MethodGet, "/students", nil, testutil.WithBearerToken("wrong")).AssertStatus(http.StatusOK)
+
+	// Without a token there is nothing to guard them with, so they aren't served
+	open := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Dev, d.AdminToken = true, "" }), testutil.WithoutContractValidation())
+	open.Do(http.MethodPost, "/admin/reset?count=2", nil).AssertStatus(http.StatusNotFound)
+	open.Do(http.MethodGet, "/students", nil).AssertStatus(http.StatusOK)
+}
+
+func TestDryRunStoresNothing(t *testing.T) {
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Dev = true }))
+
+	srv.Do(http.MethodPost, "/students", newStudent(), testutil.WithHeader("X-Dry-Run", "true")).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Dry-Run", "true").
+		AssertJSON("would_create", float64(1))
+	srv.Do(http.MethodPost, "/students/bulk?dry_run=1", []types.Student{newStudent(), newStudent()}).
+		AssertStatus(http.StatusOK).
+		AssertJSON("would_create", float64(2))
+	srv.Do(http.MethodPost, "/students?dry_run=maybe", newStudent()).AssertStatus(http.StatusBadRequest)
+
+	// Validation still runs
+	srv.Do(http.MethodPost, "/students", map[string]any{"name": "", "email": "nope", "age": 5}, testutil.WithHeader("X-Dry-Run", "true")).
+		AssertStatus(http.StatusBadRequest)
+
+	// Endpoints without a dry run mode refuse instead of writing
+	srv.Do(http.MethodPost, "/admin/seed?count=5", nil, testutil.WithHeader("X-Dry-Run", "true")).
+		AssertStatus(http.StatusBadRequest)
+
+	srv.Do(http.MethodGet, "/students", nil).AssertJSON("total_items", float64(0))
+
+	// Updates answer with what they would have stored
+	student := newStudent()
+	student.PublicID = types.NewPublicID()
+	srv.Store.Put(student)
+	srv.Do(http.MethodPatch, "/students/"+student.PublicID+"?dry_run=true", map[string]any{"name": "Asha Rao"}).
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Dry-Run", "true").
+		AssertJSON("name", "Asha Rao").
+		AssertJSON("changed_fields", []any{"name"})
+	srv.Do(http.MethodPut, "/students/"+student.PublicID, map[string]any{"name": "Asha Rao", "email": "nope", "age": 20}, testutil.WithHeader("X-Dry-Run", "true")).
+		AssertStatus(http.StatusBadRequest)
+	srv.Do(http.MethodGet, "/students/"+student.PublicID, nil).AssertJSON("name", student.Name)
+}
+
+func TestDuplicatesAndMerge(t *testing.T) {
+	srv := testutil.NewServer(t)
+	const keep, dup = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed"
+	srv.Store.Put(types.Student{PublicID: keep, Name: "Asha Patil", Email: "asha@example.com", Age: 20})
+	srv.Store.Put(types.Student{PublicID: dup, Name: "Asha Paatil", Email: "Asha@Example.com", Age: 20, Phone: "+919876543210"})
+	srv.Store.Put(types.Student{Name: "Ravi Kumar", Email: "ravi@example.com", Age: 22})
+
+	srv.Do(http.MethodGet, "/students/duplicates", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.reasons", []any{types.DuplicateSameEmail, types.DuplicateSimilarName}).
+		AssertJSON("data.0.students.1.id", dup).
+		AssertJSON("truncated", false)
+
+	srv.Do(http.MethodPost, "/students/"+keep+"/merge/"+dup, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("phone", "+919876543210")
+	if into, ok := srv.Store.MergedInto(2); !ok || into != 1 {
+		t.Fatalf("MergedInto(2) = %d, %v; want 1", into, ok)
+	}
+
+	srv.Do(http.MethodGet, "/students/"+dup, nil).AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodPost, "/students/"+keep+"/merge/"+dup, nil).AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodPost, "/students/"+keep+"/merge/"+keep, nil).AssertStatus(http.StatusBadRequest)
+	srv.Do(http.MethodPost, "/students/1/merge/2", nil).AssertStatus(http.StatusBadRequest)
+	srv.Do(http.MethodGet, "/students/duplicates", nil).AssertJSON("data", []any{})
+}
+
+func TestRelationships(t *testing.T) {
+	srv := testutil.NewServer(t)
+	const asha, ravi, meera = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", "6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 12})
+	srv.Store.Put(types.Student{PublicID: ravi, Name: "Ravi Patil", Email: "ravi@example.com", Age: 12})
+	srv.Store.Put(types.Student{PublicID: meera, Name: "Meera Patil", Email: "meera@example.com", Age: 9})
+
+	srv.Do(http.MethodPost, "/students/"+asha+"/relationships", map[string]string{"student_id": ravi, "kind": "twin"}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("student_id", ravi).
+		AssertJSON("kind_label", "Twin")
+	srv.Do(http.MethodPost, "/students/"+meera+"/relationships", map[string]string{"student_id": asha, "kind": "sibling"}).
+		AssertStatus(http.StatusCreated)
+
+	srv.Do(http.MethodPost, "/students/"+ravi+"/relationships", map[string]string{"student_id": asha, "kind": "sibling"}).
+		AssertStatus(http.StatusConflict)
+	srv.Do(http.MethodPost, "/students/"+asha+"/relationships", map[string]string{"student_id": asha, "kind": "sibling"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", "a student cannot be linked to itself")
+	srv.Do(http.MethodPost, "/students/"+asha+"/relationships", map[string]string{"student_id": meera, "kind": "cousin"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", "kind must be one of sibling, twin")
+	srv.Do(http.MethodPost, "/students/"+asha+"/relationships", map[string]string{"student_id": types.NewPublicID(), "kind": "sibling"}).
+		AssertStatus(http.StatusNotFound)
+
+	// Links read the same from either student
+	srv.Do(http.MethodGet, "/students/"+asha+"/relationships", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.student_id", ravi).
+		AssertJSON("data.1.student_id", meera).
+		AssertJSON("data.1.kind", types.RelationshipSibling)
+	srv.Do(http.MethodGet, "/students/"+ravi+"?expand=relationships", nil, testutil.WithHeader("Accept-Language", "mr")).
+		AssertStatus(http.StatusOK).
+		AssertJSON("name", "Ravi Patil").
+		AssertJSON("relationships.0.student_id", asha).
+		AssertJSON("relationships.0.kind_label", "जुळे")
+	srv.Do(http.MethodGet, "/students?expand=relationships", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.2.relationships.0.student_id", asha)
+	srv.Do(http.MethodGet, "/students/"+asha+"?expand=guardians", nil).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", `cannot expand "guardians"; expandable: relationships`)
+
+	srv.Do(http.MethodDelete, "/students/"+ravi+"/relationships/"+asha, nil).AssertStatus(http.StatusNoContent)
+	srv.Do(http.MethodDelete, "/students/"+ravi+"/relationships/"+asha, nil).AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodGet, "/students/"+ravi+"?expand=relationships", nil).AssertJSON("relationships", []any{})
+}
+
+func TestLibrary(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC))
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Clock = clk; d.LoanDays = 7 }))
+	const asha, ravi = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 12})
+	srv.Store.Put(types.Student{PublicID: ravi, Name: "Ravi Patil", Email: "ravi@example.com", Age: 12})
+
+	book := srv.Do(http.MethodPost, "/library/books", map[string]string{"title": "Wings of Fire", "author": "A. P. J. Abdul Kalam"}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("on_loan", false).
+		JSON("id").(string)
+	srv.Do(http.MethodPost, "/library/books", map[string]string{"author": "Anonymous"}).AssertStatus(http.StatusBadRequest)
+
+	// Without a due date the book is due LoanDays from today
+	srv.Do(http.MethodPost, "/library/books/"+book+"/checkout", map[string]string{"student_id": asha}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("student_id", asha).
+		AssertJSON("due_date", "2025-03-17")
+	srv.Do(http.MethodPost, "/library/books/"+book+"/checkout", map[string]string{"student_id": ravi}).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "book is on loan")
+	srv.Do(http.MethodGet, "/library/books", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("total_items", 1.0).
+		AssertJSON("data.0.on_loan", true)
+
+	srv.Do(http.MethodGet, "/library/loans/overdue", nil).AssertJSON("data", []any{})
+	clk.Advance(9 * 24 * time.Hour)
+	srv.Do(http.MethodGet, "/library/loans/overdue", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.student_name", "Asha Patil").
+		AssertJSON("data.0.days_overdue", 2.0)
+
+	srv.Do(http.MethodPost, "/library/books/"+book+"/return", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("book_id", book)
+	srv.Do(http.MethodPost, "/library/books/"+book+"/return", nil).AssertStatus(http.StatusConflict)
+	srv.Do(http.MethodGet, "/library/loans/overdue", nil).AssertJSON("data", []any{})
+
+	srv.Do(http.MethodPost, "/library/books/"+book+"/checkout", map[string]string{"student_id": ravi, "due_date": "2025-03-01"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", "due_date must be a date (YYYY-MM-DD), today or later")
+	srv.Do(http.MethodPost, "/library/books/"+book+"/checkout", map[string]string{"student_id": types.NewPublicID()}).
+		AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodPost, "/library/books/"+types.NewPublicID()+"/checkout", map[string]string{"student_id": ravi}).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "book not found")
+}
+
+func TestHostels(t *testing.T) {
+	srv := testutil.NewServer(t)
+	const asha, ravi, meera = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", "6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 16})
+	srv.Store.Put(types.Student{PublicID: ravi, Name: "Ravi Patil", Email: "ravi@example.com", Age: 16})
+	srv.Store.Put(types.Student{PublicID: meera, Name: "Meera Patil", Email: "meera@example.com", Age: 15})
+
+	hostel := srv.Do(http.MethodPost, "/hostels", map[string]string{"name": "Narmada"}).
+		AssertStatus(http.StatusCreated).
+		JSON("id").(string)
+	srv.Do(http.MethodPost, "/hostels", map[string]string{"name": "Narmada"}).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "hostel already exists")
+
+	room := srv.Do(http.MethodPost, "/hostels/"+hostel+"/rooms", map[string]any{"number": "101", "capacity": 2}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("hostel_id", hostel).
+		AssertJSON("occupied", 0.0).
+		JSON("id").(string)
+	srv.Do(http.MethodPost, "/hostels/"+hostel+"/rooms", map[string]any{"number": "102", "capacity": 0}).
+		AssertStatus(http.StatusBadRequest)
+	srv.Do(http.MethodPost, "/hostels/"+types.NewPublicID()+"/rooms", map[string]any{"number": "1", "capacity": 1}).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "hostel not found")
+
+	srv.Do(http.MethodPost, "/rooms/"+room+"/students", map[string]string{"student_id": asha}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("room_number", "101").
+		AssertJSON("student_name", "Asha Patil")
+	srv.Do(http.MethodPost, "/rooms/"+room+"/students", map[string]string{"student_id": asha}).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "student already has a room")
+	srv.Do(http.MethodPost, "/rooms/"+room+"/students", map[string]string{"student_id": ravi}).AssertStatus(http.StatusCreated)
+	srv.Do(http.MethodPost, "/rooms/"+room+"/students", map[string]string{"student_id": meera}, testutil.WithHeader("Accept-Language", "hi")).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "कमरा भरा हुआ है")
+
+	srv.Do(http.MethodGet, "/rooms/"+room+"/students", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.1.student_id", ravi)
+	srv.Do(http.MethodGet, "/hostels/occupancy", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.name", "Narmada").
+		AssertJSON("data.0.occupied", 2.0).
+		AssertJSON("data.0.vacant", 0.0)
+
+	srv.Do(http.MethodDelete, "/rooms/"+room+"/students/"+ravi, nil).AssertStatus(http.StatusNoContent)
+	srv.Do(http.MethodDelete, "/rooms/"+room+"/students/"+ravi, nil).AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodPost, "/rooms/"+room+"/students", map[string]string{"student_id": meera}).AssertStatus(http.StatusCreated)
+	srv.Do(http.MethodGet, "/hostels/"+hostel+"/rooms", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.occupied", 2.0)
+}
+
+func TestBusRoutes(t *testing.T) {
+	srv := testutil.NewServer(t)
+	const asha, ravi, meera = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", "6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 12, Phone: "+919812345678"})
+	srv.Store.Put(types.Student{PublicID: ravi, Name: "Ravi Patil", Email: "ravi@example.com", Age: 12})
+	srv.Store.Put(types.Student{PublicID: meera, Name: "Meera Patil", Email: "meera@example.com", Age: 9})
+
+	resp := srv.Do(http.MethodPost, "/routes", map[string]any{
+		"name":     "Route 4",
+		"capacity": 2,
+		"stops":    []map[string]string{{"name": "Depot"}, {"name": "Market"}},
+	}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("stops.1.position", 2.0).
+		AssertJSON("riders", 0.0)
+	route, depot, market := resp.JSON("id").(string), resp.JSON("stops.0.id").(string), resp.JSON("stops.1.id").(string)
+	srv.Do(http.MethodPost, "/routes", map[string]any{"name": "Route 5", "capacity": 2, "stops": []any{}}).
+		AssertStatus(http.StatusBadRequest)
+	srv.Do(http.MethodPost, "/routes/"+route+"/stops", map[string]string{"name": "School Gate"}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("position", 3.0)
+
+	srv.Do(http.MethodPost, "/routes/"+route+"/students", map[string]string{"student_id": ravi, "stop_id": market}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("stop_name", "Market")
+	srv.Do(http.MethodPost, "/routes/"+route+"/students", map[string]string{"student_id": asha, "stop_id": depot}).
+		AssertStatus(http.StatusCreated)
+	srv.Do(http.MethodPost, "/routes/"+route+"/students", map[string]string{"student_id": asha, "stop_id": depot}).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "student already rides a route")
+	srv.Do(http.MethodPost, "/routes/"+route+"/students", map[string]string{"student_id": meera, "stop_id": depot}).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "route is full")
+	srv.Do(http.MethodPost, "/routes/"+route+"/students", map[string]string{"student_id": meera, "stop_id": types.NewPublicID()}).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "stop not found")
+
+	// The driver's manifest is in pick-up order
+	srv.Do(http.MethodGet, "/routes/"+route+"/students", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.student_name", "Asha Patil").
+		AssertJSON("data.0.student_phone", "+919812345678").
+		AssertJSON("data.1.stop_position", 2.0)
+	srv.Do(http.MethodGet, "/routes", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.riders", 2.0)
+
+	srv.Do(http.MethodDelete, "/routes/"+route+"/students/"+ravi, nil).AssertStatus(http.StatusNoContent)
+	srv.Do(http.MethodDelete, "/routes/"+route+"/students/"+ravi, nil).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "student does not ride this route")
+	srv.Do(http.MethodGet, "/routes/"+route, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("riders", 1.0).
+		AssertJSON("stops.2.name", "School Gate")
+}
+
+func TestAlumni(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 4, 30, 9, 0, 0, 0, time.UTC))
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Clock = clk }))
+	const asha, ravi, meera = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", "6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 18})
+	srv.Store.Put(types.Student{PublicID: ravi, Name: "Ravi Patil", Email: "ravi@example.com", Age: 18})
+	srv.Store.Put(types.Student{PublicID: meera, Name: "Meera Patil", Email: "meera@example.com", Age: 17})
+
+	// Without a body the student graduates today, with this year's class
+	srv.Do(http.MethodPost, "/students/"+asha+"/graduate", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("id", asha).
+		AssertJSON("class_of", 2025.0).
+		AssertJSON("graduated_on", "2025-04-30")
+	srv.Do(http.MethodPost, "/students/"+ravi+"/graduate", map[string]any{"class_of": 2024, "graduated_on": "2024-05-02"}).
+		AssertStatus(http.StatusOK).
+		AssertJSON("class_of", 2024.0)
+	srv.Do(http.MethodPost, "/students/"+asha+"/graduate", nil).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "student has already graduated").
+		AssertJSON("message", "graduated with the class of 2025")
+	srv.Do(http.MethodPost, "/students/"+meera+"/graduate", map[string]string{"graduated_on": "2025-05-01"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", "graduated_on must be a date (YYYY-MM-DD), today or earlier")
+
+	// Graduates are out of the student list
+	srv.Do(http.MethodGet, "/students/"+asha, nil).AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodGet, "/students", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("total_items", 1.0).
+		AssertJSON("data.0.id", meera)
+
+	srv.Do(http.MethodGet, "/alumni", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("total_items", 2.0).
+		AssertJSON("data.0.name", "Asha Patil").
+		AssertJSON("data.1.class_of", 2024.0)
+	srv.Do(http.MethodGet, "/alumni?class_of=2024", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("total_items", 1.0).
+		AssertJSON("data.0.id", ravi)
+	srv.Do(http.MethodGet, "/alumni?q=ASHA", nil).AssertJSON("data.0.id", asha)
+	srv.Do(http.MethodGet, "/alumni?class_of=soon", nil).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid class year")
+
+	srv.Do(http.MethodGet, "/alumni/"+ravi, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("graduated_on", "2024-05-02")
+	srv.Do(http.MethodGet, "/alumni/"+meera, nil).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "alumnus not found")
+}
+
+func TestAdmissions(t *testing.T) {
+	srv := testutil.NewServer(t)
+
+	asha := srv.Do(http.MethodPost, "/applications", map[string]any{"name": "Asha Patil", "email": "asha@example.com", "age": 18}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("status", types.ApplicationSubmitted).
+		AssertJSON("status_label", "Submitted").
+		JSON("id").(string)
+	ravi := srv.Do(http.MethodPost, "/applications", map[string]any{"name": "Ravi Patil", "email": "ravi@example.com", "age": 18}).
+		AssertStatus(http.StatusCreated).
+		JSON("id").(string)
+	srv.Do(http.MethodPost, "/applications", map[string]any{"name": "Meera Patil", "email": "not-an-email", "age": 18}).
+		AssertStatus(http.StatusBadRequest)
+
+	// Applicants are not students yet
+	srv.Do(http.MethodGet, "/students", nil).AssertJSON("total_items", 0.0)
+
+	srv.Do(http.MethodPost, "/applications/"+asha+"/convert", nil).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("message", "only accepted applications can be converted; this one is Submitted")
+	srv.Do(http.MethodPost, "/applications/"+asha+"/status", map[string]string{"status": "accepted"}).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "status change not allowed").
+		AssertJSON("message", "cannot move an application from Submitted to Accepted")
+	srv.Do(http.MethodPost, "/applications/"+asha+"/status", map[string]string{"status": "admitted"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", "status must be one of submitted, reviewed, accepted, rejected")
+	srv.Do(http.MethodPost, "/applications/"+asha+"/status", map[string]string{"status": "reviewed"}).
+		AssertStatus(http.StatusOK).
+		AssertJSON("status_label", "Under review")
+	srv.Do(http.MethodPost, "/applications/"+asha+"/status", map[string]string{"status": "accepted"}).AssertStatus(http.StatusOK)
+	srv.Do(http.MethodPost, "/applications/"+ravi+"/status", map[string]string{"status": "rejected"}).AssertStatus(http.StatusOK)
+
+	student := srv.Do(http.MethodPost, "/applications/"+asha+"/convert", nil).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("id", asha).
+		JSON("student_id").(string)
+	srv.Do(http.MethodGet, "/students/"+student, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("email", "asha@example.com")
+	srv.Do(http.MethodPost, "/applications/"+asha+"/convert", nil).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "application already converted")
+
+	srv.Do(http.MethodGet, "/applications", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("total_items", 2.0).
+		AssertJSON("data.0.id", asha).
+		AssertJSON("data.0.student_id", student)
+	srv.Do(http.MethodGet, "/applications?status=rejected", nil).
+		AssertJSON("total_items", 1.0).
+		AssertJSON("data.0.id", ravi)
+	srv.Do(http.MethodGet, "/applications?status=pending", nil).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid application status")
+	srv.Do(http.MethodGet, "/applications/"+types.NewPublicID(), nil).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "application not found")
+}
+
+func TestAnnouncements(t *testing.T) {
+	srv := testutil.NewServer(t)
+	const asha, ravi = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 18})
+	srv.Store.Put(types.Student{PublicID: ravi, Name: "Ravi Patil", Email: "ravi@example.com", Age: 21})
+
+	everyone := srv.Do(http.MethodPost, "/announcements", map[string]string{"subject": "Holiday", "body": "School is closed on Friday."}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("recipients", 2.0).
+		AssertJSON("deliveries.queued", 2.0).
+		JSON("id").(string)
+	older := srv.Do(http.MethodPost, "/announcements", map[string]string{"subject": "Voter registration", "body": "Forms are at the office.", "filter": "age >= 21"}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("recipients", 1.0).
+		AssertJSON("filter", "age >= 21").
+		JSON("id").(string)
+	srv.Do(http.MethodPost, "/announcements", map[string]string{"subject": "Exams", "body": "Soon.", "filter": "grade = 10"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid filter")
+	srv.Do(http.MethodPost, "/announcements", map[string]string{"body": "No subject"}).
+		AssertStatus(http.StatusBadRequest)
+
+	srv.Do(http.MethodGet, "/announcements/"+everyone, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("subject", "Holiday").
+		AssertJSON("deliveries.sent", 0.0)
+	srv.Do(http.MethodGet, "/announcements/"+types.NewPublicID(), nil).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "announcement not found")
+
+	srv.Do(http.MethodGet, "/students/"+ravi+"/announcements", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.id", older).
+		AssertJSON("data.0.status", types.DeliveryQueued).
+		AssertJSON("data.0.status_label", "Queued").
+		AssertJSON("data.1.id", everyone)
+	srv.Do(http.MethodGet, "/students/"+asha+"/announcements", nil, testutil.WithHeader("Accept-Language", "hi")).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.id", everyone).
+		AssertJSON("data.0.status_label", "कतार में")
+	srv.Do(http.MethodGet, "/students/"+types.NewPublicID()+"/announcements", nil).AssertStatus(http.StatusNotFound)
+}
+
+func TestSMSReports(t *testing.T) {
+	texter := &sms.Twilio{AuthToken: "secret", StatusCallback: "https://greenwood.example.com/webhooks/sms"}
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.SMSReports = texter }))
+	const asha = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 18, Phone: "+919812345678"})
+
+	id := srv.Do(http.MethodPost, "/announcements", map[string]string{"subject": "Bus", "body": "Route 4 is late.", "channel": "sms"}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("channel", types.ChannelSMS).
+		JSON("id").(string)
+	srv.Do(http.MethodPost, "/announcements", map[string]string{"subject": "Bus", "body": strings.Repeat("late ", 400), "channel": "sms"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "announcement too long for a text message")
+	srv.Do(http.MethodPost, "/announcements", map[string]string{"subject": "Bus", "body": "Late.", "channel": "fax"}).
+		AssertStatus(http.StatusBadRequest)
+
+	// The announcement job hands the message to the provider, which answers with its ID
+	ctx := context.Background()
+	a, _ := srv.Store.GetAnnouncementByPublicID(ctx, id)
+	pending, _ := srv.Store.PendingDeliveries(ctx, a.ID)
+	pending[0].Status, pending[0].MessageID = types.DeliverySent, "SM42"
+	if err := srv.Store.SetDeliveryStatus(ctx, pending[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	report := func(params url.Values, signature string) *testutil.Response {
+		return srv.Do(http.MethodPost, "/webhooks/sms", params.Encode(),
+			testutil.WithHeader("Content-Type", "application/x-www-form-urlencoded"),
+			testutil.WithHeader(sms.SignatureHeader, signature))
+	}
+	delivered := url.Values{"MessageSid": {"SM42"}, "MessageStatus": {"delivered"}}
+	report(delivered, "forged").
+		AssertStatus(http.StatusForbidden).
+		AssertJSON("error", "invalid signature")
+	sending := url.Values{"MessageSid": {"SM42"}, "MessageStatus": {"sending"}}
+	report(sending, sms.Signature("secret", texter.StatusCallback, sending)).AssertStatus(http.StatusNoContent)
+	report(delivered, sms.Signature("secret", texter.StatusCallback, delivered)).AssertStatus(http.StatusNoContent)
+	unknown := url.Values{"MessageSid": {"SM99"}, "MessageStatus": {"delivered"}}
+	report(unknown, sms.Signature("secret", texter.StatusCallback, unknown)).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "delivery not found")
+
+	srv.Do(http.MethodGet, "/announcements/"+id, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("deliveries.delivered", 1.0)
+	srv.Do(http.MethodGet, "/students/"+asha+"/announcements", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.channel", types.ChannelSMS).
+		AssertJSON("data.0.status", types.DeliveryDelivered).
+		AssertJSON("data.0.status_label", "Delivered")
+}
+
+func TestCustomFields(t *testing.T) {
+	srv := testutil.NewServer(t)
+
+	srv.Do(http.MethodPost, "/custom-fields", map[string]any{"name": "locker_number", "type": "number"}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("name", "locker_number").
+		AssertJSON("required", false)
+	srv.Do(http.MethodPost, "/custom-fields", map[string]any{"name": "previous_school", "type": "string", "required": true}).
+		AssertStatus(http.StatusCreated)
+	srv.Do(http.MethodPost, "/custom-fields", map[string]any{"name": "locker_number", "type": "string"}).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "custom field already exists")
+	srv.Do(http.MethodPost, "/custom-fields", map[string]any{"name": "Locker Number", "type": "number"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid custom field")
+	srv.Do(http.MethodPost, "/custom-fields", map[string]any{"name": "caste", "type": "enum"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "validation errors")
+	srv.Do(http.MethodGet, "/custom-fields", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.name", "locker_number").
+		AssertJSON("data.1.required", true)
+
+	asha := srv.Do(http.MethodPost, "/students", map[string]any{"name": "Asha Patil", "email": "asha@example.com", "age": 18,
+		"custom_fields": map[string]any{"locker_number": 42, "previous_school": "St. Mary's"}}).
+		AssertStatus(http.StatusCreated).
+		JSON("id").(string)
+	srv.Do(http.MethodGet, "/students/"+asha, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("custom_fields.locker_number", 42.0)
+	srv.Do(http.MethodPost, "/students", map[string]any{"name": "Ravi Patil", "email": "ravi@example.com", "age": 19,
+		"custom_fields": map[string]any{"locker_number": "twelve", "bus_pass": true}}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "validation errors").
+		AssertJSON("message", "custom_fields.locker_number must be a number; custom_fields.previous_school is required; custom_fields.bus_pass is not a defined custom field")
+	srv.Do(http.MethodPost, "/students/bulk", []map[string]any{
+		{"name": "Ravi Patil", "email": "ravi@example.com", "age": 19, "custom_fields": map[string]any{"previous_school": "DPS", "locker_number": 7}},
+		{"name": "Meera Patil", "email": "meera@example.com", "age": 18},
+	}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", "[1] custom_fields.previous_school is required")
+
+	srv.Do(http.MethodGet, "/students?filter=custom_fields.locker_number+%3E+10", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("total_items", 1.0).
+		AssertJSON("data.0.id", asha)
+	srv.Do(http.MethodGet, "/students?filter=custom_fields.hosteller+%3D+true", nil).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid filter")
+
+	srv.Do(http.MethodDelete, "/custom-fields/locker_number", nil).AssertStatus(http.StatusNoContent)
+	srv.Do(http.MethodDelete, "/custom-fields/locker_number", nil).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "custom field not found")
+	srv.Do(http.MethodGet, "/students/"+asha, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("custom_fields", map[string]any{"previous_school": "St. Mary's"})
+}
+
+func TestViews(t *testing.T) {
+	srv := testutil.NewServer(t)
+	const asha, ravi, meera = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", "6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 18})
+	srv.Store.Put(types.Student{PublicID: ravi, Name: "Ravi Kumar", Email: "ravi@example.com", Age: 21})
+	srv.Store.Put(types.Student{PublicID: meera, Name: "Meera Patil", Email: "meera@example.com", Age: 22})
+
+	srv.Do(http.MethodPost, "/views", map[string]any{"name": "patil-seniors", "filter": `name ~ "patil" AND age >= 18`, "expand": []string{"relationships"}}).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("name", "patil-seniors").
+		AssertJSON("expand", []any{"relationships"})
+	srv.Do(http.MethodPost, "/views", map[string]any{"name": "patil-seniors"}).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "view already exists")
+	srv.Do(http.MethodPost, "/views", map[string]any{"name": "Patil Seniors"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid view")
+	srv.Do(http.MethodPost, "/views", map[string]any{"name": "graded", "filter": "grade = 10"}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid filter")
+	srv.Do(http.MethodPost, "/views", map[string]any{"name": "guardians", "expand": []string{"guardians"}}).
+		AssertStatus(http.StatusBadRequest)
+	srv.Do(http.MethodGet, "/views", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.name", "patil-seniors")
+
+	srv.Do(http.MethodGet, "/students?view=patil-seniors", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("total_items", 2.0).
+		AssertJSON("data.0.id", asha).
+		AssertJSON("data.0.relationships", []any{})
+	// ?filter= narrows the view, and ?expand= replaces its expand
+	srv.Do(http.MethodGet, "/students?view=patil-seniors&filter=age+%3E+20&expand=", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("total_items", 1.0).
+		AssertJSON("data.0", map[string]any{"id": meera, "name": "Meera Patil", "email": "meera@example.com", "age": 22.0})
+	srv.Do(http.MethodGet, "/students?view=juniors", nil).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "view not found")
+
+	srv.Do(http.MethodDelete, "/views/patil-seniors", nil).AssertStatus(http.StatusNoContent)
+	srv.Do(http.MethodGet, "/views/patil-seniors", nil).AssertStatus(http.StatusNotFound)
+}
+
+func TestUpdateStudent(t *testing.T) {
+	srv := testutil.NewServer(t)
+	const asha = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 18, Phone: "+919876543210"})
+	srv.Do(http.MethodPost, "/custom-fields", map[string]any{"name": "locker_number", "type": "number"}).
+		AssertStatus(http.StatusCreated)
+
+	srv.Do(http.MethodPatch, "/students/"+asha, map[string]any{"age": 19, "custom_fields": map[string]any{"locker_number": 42}}).
+		AssertStatus(http.StatusOK).
+		AssertJSON("name", "Asha Patil").
+		AssertJSON("phone", "+919876543210").
+		AssertJSON("changed_fields", []any{"age", "custom_fields.locker_number"})
+	// Sending what is already stored changes nothing
+	srv.Do(http.MethodPatch, "/students/"+asha, map[string]any{"age": 19}).
+		AssertStatus(http.StatusOK).
+		AssertJSON("changed_fields", []any{})
+	srv.Do(http.MethodPatch, "/students/"+asha, map[string]any{"phone": nil, "custom_fields": map[string]any{"locker_number": nil}}).
+		AssertStatus(http.StatusOK).
+		AssertJSON("changed_fields", []any{"phone", "custom_fields.locker_number"})
+	srv.Do(http.MethodPatch, "/students/"+asha, map[string]any{"name": nil}).
+		AssertStatus(http.StatusBadRequest)
+	srv.Do(http.MethodPatch, "/students/"+asha, map[string]any{"custom_fields": map[string]any{"locker_number": "A-12"}}).
+		AssertStatus(http.StatusBadRequest)
+
+	// PUT replaces the whole student; the id in the body is ignored
+	srv.Do(http.MethodPut, "/students/"+asha, map[string]any{"id": "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", "name": "Asha P.", "email": "asha.p@example.com", "age": 19}).
+		AssertStatus(http.StatusOK).
+		AssertJSON("id", asha).
+		AssertJSON("changed_fields", []any{"name", "email"})
+	srv.Do(http.MethodGet, "/students/"+asha, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("", map[string]any{"id": asha, "name": "Asha P.", "email": "asha.p@example.com", "age": 19.0})
+	srv.Do(http.MethodPut, "/students/"+asha, map[string]any{"name": "Asha P."}).
+		AssertStatus(http.StatusBadRequest)
+
+	srv.Do(http.MethodPut, "/students/6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b", map[string]any{"name": "Nobody", "email": "nobody@example.com", "age": 18}).
+		AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodPatch, "/students/42", map[string]any{"age": 20}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid ID")
+}
+
+func TestPatchStudents(t *testing.T) {
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.BulkConfirmAbove = 1 }))
+	const asha, ravi, meera = "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", "6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 18})
+	srv.Store.Put(types.Student{PublicID: ravi, Name: "Ravi Kumar", Email: "ravi@example.com", Age: 21})
+	srv.Store.Put(types.Student{PublicID: meera, Name: "Meera Iyer", Email: "meera@example.com", Age: 25})
+	srv.Do(http.MethodPost, "/custom-fields", map[string]any{"name": "section", "type": "string"}).
+		AssertStatus(http.StatusCreated)
+	srv.Do(http.MethodPost, "/custom-fields", map[string]any{"name": "hosteller", "type": "boolean", "required": true}).
+		AssertStatus(http.StatusCreated)
+
+	// Two match, more than the threshold of one
+	const seniors = "/students?filter=age+%3E%3D+20"
+	srv.Do(http.MethodPatch, seniors, map[string]any{"custom_fields": map[string]any{"section": "B"}}).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "confirmation required")
+	srv.Do(http.MethodPatch, seniors+"&confirm=true", map[string]any{"custom_fields": map[string]any{"section": "B"}}).
+		AssertStatus(http.StatusOK).
+		AssertJSON("", map[string]any{"matched": 2.0, "updated": 2.0})
+	srv.Do(http.MethodGet, "/students/"+meera, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("custom_fields", map[string]any{"section": "B"})
+	srv.Do(http.MethodGet, "/students/"+asha, nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("", map[string]any{"id": asha, "name": "Asha Patil", "email": "asha@example.com", "age": 18.0})
+	// Under the threshold no confirmation is needed, and "" clears like null
+	srv.Do(http.MethodPatch, "/students?filter=name+~+%22meera%22", map[string]any{"custom_fields": map[string]any{"section": ""}}).
+		AssertStatus(http.StatusOK).
+		AssertJSON("", map[string]any{"matched": 1.0, "updated": 1.0})
+
+	for _, body := range []map[string]any{
+		{"custom_fields": map[string]any{"section": 7}},
+		{"custom_fields": map[string]any{"hosteller": nil}},
+		{"custom_fields": map[string]any{"nickname": "Ash"}},
+		{"custom_fields": map[string]any{}},
+		{"name": "Everyone"},
+	} {
+		srv.Do(http.MethodPatch, seniors+"&confirm=true", body).
+			AssertStatus(http.StatusBadRequest)
+	}
+	srv.Do(http.MethodPatch, "/students", map[string]any{"custom_fields": map[string]any{"section": "B"}}).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid filter")
+	srv.Do(http.MethodPatch, seniors+"&confirm=maybe", map[string]any{"custom_fields": map[string]any{"section": "B"}}).
+		AssertStatus(http.StatusBadRequest)
+
+	srv.Store.SetError(storagetest.MethodPatchStudents, storage.ErrDatabase)
+	srv.Do(http.MethodPatch, seniors+"&confirm=true", map[string]any{"custom_fields": map[string]any{"section": "C"}}).
+		AssertStatus(http.StatusInternalServerError)
+}
+
+func TestWebhooks(t *testing.T) {
+	// Test deliveries are queued to a real job queue; no worker runs them
+	db, err := sqlite.NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "jobs.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	runner := jobs.New(db, jobs.Options{})
+	runner.Register(webhooks.Kind, func(context.Context, types.Job) (any, error) { return nil, nil })
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) {
+		d.WebhookKeys = []string{"key-a", "key-b"}
+		d.JobRunner = runner
+	}))
+	a, b := testutil.WithAPIKey("key-a"), testutil.WithAPIKey("key-b")
+	hook := map[string]any{"url": "https://example.com/hook", "events": []string{"student.created", "student.deleted"}}
+
+	srv.Do(http.MethodPost, "/webhooks", hook).
+		AssertStatus(http.StatusUnauthorized)
+	srv.Do(http.MethodPost, "/webhooks", hook, testutil.WithAPIKey("key-c")).
+		AssertStatus(http.StatusUnauthorized)
+	created := srv.Do(http.MethodPost, "/webhooks", hook, a).
+		AssertStatus(http.StatusCreated).
+		AssertJSON("events", []any{"student.created", "student.deleted"})
+	id, secret := created.JSON("id").(string), created.JSON("secret").(string)
+	if !strings.HasPrefix(secret, "whsec_") {
+		t.Fatalf("secret = %q, want a whsec_ secret", secret)
+	}
+	created.AssertHeader("Location", "/webhooks/"+id)
+	srv.Do(http.MethodPost, "/webhooks", map[string]any{"url": "https://example.com/hook", "events": []string{"loan.overdue"}}, a).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid webhook")
+	srv.Do(http.MethodPost, "/webhooks", map[string]any{"url": "ftp://example.com/hook", "events": []string{"student.created"}}, a).
+		AssertStatus(http.StatusBadRequest)
+
+	// Each key sees its own subscriptions, never their secrets
+	list := srv.Do(http.MethodGet, "/webhooks", nil, a).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.id", id)
+	if bytes.Contains(list.Body, []byte(secret)) {
+		t.Errorf("GET /webhooks = %s, want no secrets", list.Body)
+	}
+	srv.Do(http.MethodGet, "/webhooks", nil, b).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data", []any{})
+	srv.Do(http.MethodGet, "/webhooks/"+id, nil, b).
+		AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodPost, "/webhooks/"+id+"/test", nil, b).
+		AssertStatus(http.StatusNotFound)
+
+	rotated := srv.Do(http.MethodPost, "/webhooks/"+id+"/rotate-secret", nil, a).
+		AssertStatus(http.StatusOK)
+	if got := rotated.JSON("secret"); got == secret || got == nil || rotated.JSON("previous_secret_expires_at") == nil {
+		t.Errorf("rotated = %s, want a new secret and the old one's expiry", rotated.Body)
+	}
+	srv.Do(http.MethodPost, "/webhooks/"+id+"/test", nil, a).
+		AssertStatus(http.StatusAccepted).
+		AssertHeader("Location", "/jobs/1").
+		AssertJSON("job_id", 1.0)
+
+	// The test delivery is kept until it is accepted; fail it as its job would
+	queued := srv.Do(http.MethodGet, "/webhooks/"+id+"/deliveries", nil, a).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.event", webhooks.EventTest).
+		AssertJSON("data.0.status", types.DeliveryQueued)
+	delivery, _ := queued.JSON("data.0.id").(string)
+	if !types.ValidPublicID(delivery) {
+		t.Fatalf("GET deliveries = %s, want the delivery's UUID as its id", queued.Body)
+	}
+	srv.Do(http.MethodGet, "/webhooks/"+id+"/deliveries/1", nil, a).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid ID")
+	srv.Do(http.MethodPost, "/webhooks/"+id+"/deliveries/"+delivery+"/replay", nil, a).
+		AssertStatus(http.StatusConflict).
+		AssertJSON("error", "delivery has not failed")
+	srv.Store.UpdateWebhookDelivery(context.Background(), types.WebhookDelivery{ID: 1, Status: types.DeliveryFailed, Attempts: 1,
+		ResponseStatus: http.StatusServiceUnavailable, ResponseBody: "down for maintenance", Error: "answered 503"})
+	list = srv.Do(http.MethodGet, "/webhooks/"+id+"/deliveries?status=failed", nil, a).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.id", delivery).
+		AssertJSON("data.0.response_status", 503.0)
+	if bytes.Contains(list.Body, []byte("maintenance")) {
+		t.Errorf("GET deliveries = %s, want no response bodies", list.Body)
+	}
+	srv.Do(http.MethodGet, "/webhooks/"+id+"/deliveries/"+delivery, nil, a).
+		AssertStatus(http.StatusOK).
+		AssertJSON("response_body", "down for maintenance").
+		AssertJSON("body.type", webhooks.EventTest)
+	srv.Do(http.MethodGet, "/webhooks/"+id+"/deliveries/"+delivery, nil, b).
+		AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodGet, "/webhooks/"+id+"/deliveries/"+types.NewPublicID(), nil, a).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "delivery not found")
+	srv.Do(http.MethodGet, "/webhooks/"+id+"/deliveries?status=sent", nil, a).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid delivery status")
+	srv.Do(http.MethodPost, "/webhooks/"+id+"/deliveries/"+delivery+"/replay", nil, a).
+		AssertStatus(http.StatusAccepted).
+		AssertHeader("Location", "/jobs/2")
+	srv.Do(http.MethodGet, "/webhooks/"+id+"/deliveries/"+delivery, nil, a).
+		AssertJSON("status", types.DeliveryQueued)
+	srv.Store.UpdateWebhookDelivery(context.Background(), types.WebhookDelivery{ID: 1, Status: types.DeliveryFailed, Attempts: 2})
+	srv.Do(http.MethodPost, "/webhooks/"+id+"/deliveries/replay", nil, a).
+		AssertStatus(http.StatusAccepted).
+		AssertJSON("replayed", 1.0)
+	srv.Do(http.MethodPost, "/webhooks/"+id+"/deliveries/replay", nil, a).
+		AssertStatus(http.StatusAccepted).
+		AssertJSON("replayed", 0.0)
+
+	// A delivery that stays failed (here: deleted under the replay) is read once and not counted
+	srv.Store.UpdateWebhookDelivery(context.Background(), types.WebhookDelivery{ID: 1, Status: types.DeliveryFailed, Attempts: 3})
+	srv.Store.SetError(storagetest.MethodUpdateDelivery, storage.ErrDeliveryNotFound)
+	srv.Do(http.MethodPost, "/webhooks/"+id+"/deliveries/replay", nil, a).
+		AssertStatus(http.StatusAccepted).
+		AssertJSON("replayed", 0.0)
+	srv.Store.SetError(storagetest.MethodUpdateDelivery, nil)
+
+	srv.Do(http.MethodDelete, "/webhooks/"+id, nil, b).
+		AssertStatus(http.StatusNotFound)
+	srv.Do(http.MethodDelete, "/webhooks/"+id, nil, a).
+		AssertStatus(http.StatusNoContent)
+	srv.Do(http.MethodGet, "/webhooks/"+id, nil, a).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "webhook not found")
+	srv.Do(http.MethodGet, "/webhooks/42", nil, a).
+		AssertStatus(http.StatusBadRequest)
+}
+
+func TestStudentChanges(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC))
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Clock = clk }))
+	srv.Store.SetClock(clk)
+	const asha, ravi = "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"
+	srv.Store.Put(types.Student{PublicID: asha, Name: "Asha Patil", Email: "asha@example.com", Age: 18})
+	srv.Store.Put(types.Student{PublicID: ravi, Name: "Ravi Kumar", Email: "ravi@example.com", Age: 21})
+
+	// Nothing from the current second, which can still be written to
+	srv.Do(http.MethodGet, "/students/changes?since=2024-01-01T00:00:00Z", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data", []any{}).
+		AssertJSON("next_since", "2024-01-01T00:00:00Z")
+	clk.Advance(time.Second)
+	srv.Do(http.MethodGet, "/students/changes?since=2024-01-01T00:00:00Z&limit=1", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.id", asha).
+		AssertJSON("data.0.change", "created").
+		AssertJSON("data.0.student.name", "Asha Patil").
+		AssertJSON("truncated", true).
+		AssertJSON("next_after", asha)
+	clk.Advance(time.Minute - time.Second)
+	srv.Do(http.MethodPatch, "/students/"+asha, map[string]any{"age": 19}).
+		AssertStatus(http.StatusOK)
+	clk.Advance(time.Second)
+	srv.Do(http.MethodGet, "/students/changes?since=2024-01-01T00:00:00Z", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.1.id", asha).
+		AssertJSON("data.1.student.age", 19.0).
+		AssertJSON("data.1.changed_at", "2025-03-10T09:01:00Z").
+		AssertJSON("truncated", false)
+	// Since the first sync, only the update
+	srv.Do(http.MethodGet, "/students/changes?since=2025-03-10T09:00:30Z", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.change", "updated").
+		AssertJSON("next_since", "2025-03-10T09:01:00Z")
+	srv.Do(http.MethodGet, "/students/changes?since=2999-01-01T00:00:00Z", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data", []any{}).
+		AssertJSON("next_since", "2999-01-01T00:00:00Z")
+
+	srv.Do(http.MethodGet, "/students/changes", nil).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid since")
+	srv.Do(http.MethodGet, "/students/changes?since=2024-01-01", nil).
+		AssertStatus(http.StatusBadRequest)
+	srv.Do(http.MethodGet, "/students/changes?since=2024-01-01T00:00:00Z&after=42", nil).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid ID")
+
+	// Deletions since then were purged
+	srv.Store.SetError(storagetest.MethodStudentChanges, storage.ErrChangesExpired)
+	srv.Do(http.MethodGet, "/students/changes?since=2024-01-01T00:00:00Z", nil).
+		AssertStatus(http.StatusGone).
+		AssertJSON("error", "changes expired")
+}
+
+func TestEventLog(t *testing.T) {
+	srv := testutil.NewServer(t)
+	ctx := context.Background()
+	for _, kind := range []string{"student.created", "student.updated", "student.deleted"} {
+		srv.Store.AppendEvent(ctx, types.FeedEvent{Entity: types.EntityStudent, Type: kind, Data: json.RawMessage(`{"students":[]}`)})
+	}
+	srv.Store.AppendEvent(ctx, types.FeedEvent{Entity: types.EntityLoan, Type: "loan.overdue", Data: json.RawMessage(`{"loans":[]}`)})
+
+	srv.Do(http.MethodGet, "/events?after_seq=1&limit=1", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.seq", 2.0).
+		AssertJSON("data.0.type", "student.updated").
+		AssertJSON("data.0.data.students", []any{}).
+		AssertJSON("last_seq", 3.0)
+	srv.Do(http.MethodGet, "/events?entity=loan", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.entity", "loan").
+		AssertJSON("last_seq", 1.0)
+	srv.Do(http.MethodGet, "/events?entity=announcement", nil).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data", []any{}).
+		AssertJSON("last_seq", 0.0)
+
+	srv.Do(http.MethodGet, "/events?entity=hostel", nil).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid entity")
+	srv.Do(http.MethodGet, "/events?after_seq=-1", nil).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "invalid after_seq")
+
+	// Events after after_seq were purged
+	srv.Store.SetError(storagetest.MethodEventsAfter, storage.ErrEventsExpired)
+	srv.Do(http.MethodGet, "/events?after_seq=1", nil).
+		AssertStatus(http.StatusGone).
+		AssertJSON("error", "events expired")
+}
+
+func TestDecoratedStorageWithoutCapabilities(t *testing.T) {
+	// The production stack: events and cache decorators over a storage with only the basics. The
+	// decorators forward alumni, admissions and custom fields, which the storage doesn't have.
+	fake := storagetest.NewFake()
+	basic := struct{ storage.Storage }{fake}
+	store := events.NewStore(cache.New(basic, time.Minute, 5), events.New())
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Store = store }))
+
+	for _, path := range []string{"/alumni", "/applications", "/custom-fields"} {
+		srv.Do(http.MethodGet, path, nil).AssertStatus(http.StatusNotImplemented)
+	}
+	srv.Do(http.MethodGet, "/students", nil).AssertStatus(http.StatusOK)
+}
+
+func TestLocalizedMessages(t *testing.T) {
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) { d.Dev = true }))
+	srv.Store.Put(types.Student{Name: "Asha Patil", Email: "asha@example.com", Age: 20})
+	srv.Store.Put(types.Student{Name: "Asha Patil", Email: "asha+2@example.com", Age: 20})
+
+	hindi := testutil.WithHeader("Accept-Language", "hi-IN,hi;q=0.9")
+	srv.Do(http.MethodGet, "/students/duplicates?limit=0", nil, hindi).
+		AssertStatus(http.StatusBadRequest).
+		AssertHeader("Content-Language", "hi").
+		AssertJSON("error", "अमान्य सीमा").
+		AssertJSON("message", "limit 1 और 100 के बीच होना चाहिए")
+
+	// Middleware errors are localized too
+	srv.Do(http.MethodPost, "/admin/seed", nil, hindi, testutil.WithHeader("X-Dry-Run", "true")).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("error", "ड्राई-रन समर्थित नहीं है")
+
+	// Enum values stay as they are; their labels follow the language
+	srv.Do(http.MethodGet, "/students/duplicates", nil, testutil.WithHeader("Accept-Language", "mr")).
+		AssertStatus(http.StatusOK).
+		AssertJSON("data.0.reasons", []any{types.DuplicateSameEmail, types.DuplicateSimilarName}).
+		AssertJSON("data.0.reason_labels", []any{"समान ईमेल", "मिळतेजुळते नाव"})
+
+	srv.Do(http.MethodGet, "/students/duplicates?limit=0", nil).
+		AssertHeader("Content-Language", "en").
+		AssertJSON("message", "limit must be between 1 and 100")
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	srv := testutil.NewServer(t, testutil.WithDeps(func(d *router.Deps) {
+		d.API = middleware.APIVersions{Default: 1, Supported: []int{1, 2}, Envelope: []int{2}}
+	}))
+	srv.Store.Put(newStudent())
+	v2 := testutil.WithHeader("API-Version", "2")
+
+	srv.Do(http.MethodGet, "/students", nil).
+		AssertStatus(http.StatusOK).
+		AssertHeader("API-Version", "1").
+		AssertJSON("total_items", float64(1))
+
+	resp := srv.Do(http.MethodGet, "/students", nil, v2, testutil.WithHeader("X-Request-Id", "req-42")).
+		AssertStatus(http.StatusOK).
+		AssertHeader("API-Version", "2").
+		AssertHeader("X-Request-Id", "req-42").
+		AssertJSON("data.total_items", float64(1)).
+		AssertJSON("meta.request_id", "req-42")
+	if ms, ok := resp.JSON("meta.duration_ms").(float64); !ok || ms < 0 {
+		t.Fatalf("meta.duration_ms = %v", resp.JSON("meta.duration_ms"))
+	}
+
+	// Errors keep their shape and gain the metadata; request IDs with unsafe characters are replaced
+	resp = srv.Do(http.MethodGet, "/students/"+types.NewPublicID(), nil, v2, testutil.WithHeader("X-Request-Id", "a b\"c")).
+		AssertStatus(http.StatusNotFound).
+		AssertJSON("error", "student not found")
+	if id := resp.Header.Get("X-Request-Id"); !types.ValidPublicID(id) || resp.JSON("meta.request_id") != id {
+		t.Fatalf("request ID = %q, meta = %v; want a generated UUID in both", id, resp.JSON("meta"))
+	}
+
+	// Any path can answer this 400, so the document leaves it out of each operation
+	strict := testutil.NewServer(t, testutil.WithoutContractValidation())
+	strict.Do(http.MethodGet, "/students", nil, v2).
+		AssertStatus(http.StatusBadRequest).
+		AssertJSON("message", `API version "2" is not supported`)
+}
@@ -0,0 +1,925 @@
+package i18n
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported languages. English is the fallback for anything we don't recognise.
+const (
+	LangEnglish = "en"
+	LangHindi   = "hi"
+	LangMarathi = "mr"
+
+	DefaultLang = LangEnglish
+)
+
+// SupportedLangs lists every language the API can respond in
+var SupportedLangs = []string{LangEnglish, LangHindi, LangMarathi}
+
+// Message keys used in error bodies. Keeping them as constants means a typo
+// is a compile error instead of an untranslated message.
+const (
+	MsgInvalidRequestBody    = "invalid_request_body"
+	MsgEmptyRequestBody      = "empty_request_body"
+	MsgValidationErrors      = "validation_errors"
+	MsgInvalidID             = "invalid_id"
+	MsgStudentNotFound       = "student_not_found"
+	MsgInternalError         = "internal_server_error"
+	MsgDatabaseError         = "database_error"
+	MsgCreateStudentError    = "error_creating_student"
+	MsgInvalidDryRun         = "invalid_dry_run"
+	MsgDryRunUnsupported     = "dry_run_not_supported"
+	MsgUnauthorized          = "unauthorized"
+	MsgTenantRequired        = "tenant_required"
+	MsgInvalidTenant         = "invalid_tenant"
+	MsgUnknownTenant         = "unknown_tenant"
+	MsgJobNotFound           = "job_not_found"
+	MsgInvalidLimit          = "invalid_limit"
+	MsgInvalidPagination     = "invalid_pagination"
+	MsgInvalidMonths         = "invalid_months"
+	MsgInvalidCount          = "invalid_count"
+	MsgInvalidSeed           = "invalid_seed"
+	MsgInvalidSearchQuery    = "invalid_search_query"
+	MsgInvalidReportQuery    = "invalid_report_query"
+	MsgMergeUnsupported      = "merge_not_supported"
+	MsgResetUnsupported      = "reset_not_supported"
+	MsgSeedError             = "error_seeding_students"
+	MsgResetError            = "error_resetting_database"
+	MsgRetentionError        = "error_computing_retention_report"
+	MsgDraining              = "draining"
+	MsgUnsupportedVersion    = "unsupported_api_version"
+	MsgRenderError           = "error_rendering_profile"
+	MsgProfilesNotReady      = "profiles_not_ready"
+	MsgRateLimited           = "rate_limited"
+	MsgTooManyConcurrent     = "too_many_concurrent"
+	MsgServerBusy            = "server_busy"
+	MsgInvalidFilter         = "invalid_filter"
+	MsgFilterUnsupported     = "filter_not_supported"
+	MsgRequestNotFound       = "request_not_found"
+	MsgRecordingNotFound     = "recording_not_found"
+	MsgInvalidLink           = "invalid_relationship"
+	MsgLinksUnsupported      = "relationships_not_supported"
+	MsgAlreadyLinked         = "students_already_linked"
+	MsgLinkNotFound          = "relationship_not_found"
+	MsgInvalidExpand         = "invalid_expand"
+	MsgBookNotFound          = "book_not_found"
+	MsgLibraryUnsupported    = "library_not_supported"
+	MsgBookOnLoan            = "book_on_loan"
+	MsgBookNotOnLoan         = "book_not_on_loan"
+	MsgInvalidDueDate        = "invalid_due_date"
+	MsgHostelNotFound        = "hostel_not_found"
+	MsgRoomNotFound          = "room_not_found"
+	MsgHostelsUnsupported    = "hostels_not_supported"
+	MsgHostelExists          = "hostel_exists"
+	MsgRoomExists            = "room_exists"
+	MsgRoomFull              = "room_full"
+	MsgHasRoom               = "already_allocated"
+	MsgNotAllocated          = "not_allocated"
+	MsgRouteNotFound         = "route_not_found"
+	MsgStopNotFound          = "stop_not_found"
+	MsgRoutesUnsupported     = "routes_not_supported"
+	MsgRouteExists           = "route_exists"
+	MsgRouteFull             = "route_full"
+	MsgHasRoute              = "already_riding"
+	MsgNotRiding             = "not_riding"
+	MsgAlumnusNotFound       = "alumnus_not_found"
+	MsgAlumniUnsupported     = "alumni_not_supported"
+	MsgGraduated             = "already_graduated"
+	MsgInvalidGraduation     = "invalid_graduation_date"
+	MsgInvalidClassOf        = "invalid_class_of"
+	MsgAppNotFound           = "application_not_found"
+	MsgAppsUnsupported       = "admissions_not_supported"
+	MsgInvalidAppStatus      = "invalid_application_status"
+	MsgStatusNotAllowed      = "status_not_allowed"
+	MsgConverted             = "application_converted"
+	MsgNoticeNotFound        = "announcement_not_found"
+	MsgNoticesUnsupported    = "announcements_not_supported"
+	MsgDeliveryNotFound      = "delivery_not_found"
+	MsgBadSignature          = "invalid_signature"
+	MsgSMSTooLong            = "sms_too_long"
+	MsgFieldNotFound         = "custom_field_not_found"
+	MsgFieldExists           = "custom_field_exists"
+	MsgFieldsUnsupported     = "custom_fields_not_supported"
+	MsgInvalidField          = "invalid_custom_field"
+	MsgViewNotFound          = "view_not_found"
+	MsgViewExists            = "view_exists"
+	MsgViewsUnsupported      = "views_not_supported"
+	MsgInvalidView           = "invalid_view"
+	MsgUpdateUnsupported     = "update_not_supported"
+	MsgInvalidSince          = "invalid_since"
+	MsgChangesUnsupported    = "changes_not_supported"
+	MsgChangesExpired        = "changes_expired"
+	MsgBulkUpdateUnsupported = "bulk_update_not_supported"
+	MsgConfirmationRequired  = "confirmation_required"
+	MsgInvalidConfirm        = "invalid_confirm"
+	MsgJobRunning            = "job_running"
+	MsgWebhookNotFound       = "webhook_not_found"
+	MsgWebhooksUnsupported   = "webhooks_not_supported"
+	MsgInvalidWebhook        = "invalid_webhook"
+	MsgInvalidDeliveryStatus = "invalid_delivery_status"
+	MsgDeliveryNotFailed     = "delivery_not_failed"
+	MsgEventLogUnsupported   = "event_log_unsupported"
+	MsgInvalidEntity         = "invalid_entity"
+	MsgInvalidAfterSeq       = "invalid_after_seq"
+	MsgEventsExpired         = "events_expired"
+	MsgQuotaExceeded         = "quota_exceeded"
+	MsgInvalidMonth          = "invalid_month"
+	MsgUsageError            = "usage_error"
+	MsgInvalidExportFormat   = "invalid_export_format"
+	MsgInvalidCompression    = "invalid_compression"
+	MsgInvalidContentDigest  = "invalid_content_digest"
+	MsgChecksumMismatch      = "checksum_mismatch"
+	MsgNotFound              = "not_found"
+	MsgMethodNotAllowed      = "method_not_allowed"
+	MsgInvalidPreview        = "invalid_preview"
+	MsgPreviewUnsupported    = "preview_unsupported"
+	MsgNothingStaged         = "nothing_staged"
+	MsgPreviewNotFinished    = "preview_not_finished"
+)
+
+// Keys of the explanations sent as an error's message. Those ending in "f" are formats for Tf.
+const (
+	MsgOutOfRangef        = "out_of_range"
+	MsgNotPositiveIntf    = "not_positive_int"
+	MsgRepeatedParamf     = "repeated_param"
+	MsgNotUUIDf           = "not_uuid"
+	MsgSearchQueryf       = "search_query_length"
+	MsgTooManyStudentsf   = "too_many_students"
+	MsgNoDryRunModef      = "no_dry_run_mode"
+	MsgAdminTokenInvalid  = "admin_token_invalid"
+	MsgCannotMerge        = "storage_cannot_merge"
+	MsgCannotReset        = "storage_cannot_reset"
+	MsgServerShuttingDown = "server_shutting_down"
+	MsgUnexpectedError    = "unexpected_error"
+	MsgUnknownVersionf    = "unknown_api_version"
+	MsgJobStatusf         = "job_status"
+	MsgRetryAfterf        = "retry_after"
+	MsgCannotFilter       = "storage_cannot_filter"
+	MsgNoRequestf         = "no_request_in_flight"
+	MsgNoRecordingf       = "no_recording"
+	MsgJSONTypef          = "json_type"
+	MsgJSONSyntaxf        = "json_syntax"
+	MsgJSONTruncated      = "json_truncated"
+	MsgJSONBody           = "json_body"
+	MsgJSONNumber         = "json_number"
+	MsgJSONString         = "json_string"
+	MsgJSONBoolean        = "json_boolean"
+	MsgJSONArray          = "json_array"
+	MsgJSONObject         = "json_object"
+	MsgCannotLink         = "storage_cannot_relate"
+	MsgLinkKindf          = "relationship_kind"
+	MsgSelfLink           = "self_link"
+	MsgUnknownExpandf     = "unknown_expand"
+	MsgNoLibrary          = "storage_has_no_library"
+	MsgDueDateRule        = "due_date_rule"
+	MsgNoHostels          = "storage_has_no_hostels"
+	MsgNoRoutes           = "storage_has_no_routes"
+	MsgStopOffRoutef      = "stop_off_route"
+	MsgNoAlumni           = "storage_has_no_alumni"
+	MsgGraduatedOnRule    = "graduated_on_rule"
+	MsgGraduatedf         = "graduated_class_of"
+	MsgNoAdmissions       = "storage_has_no_admissions"
+	MsgAppStatusf         = "application_status_rule"
+	MsgTransitionf        = "status_transition"
+	MsgNotAcceptedf       = "application_not_accepted"
+	MsgConvertedf         = "application_converted_to"
+	MsgNoNotices          = "storage_has_no_announcements"
+	MsgSMSLengthf         = "sms_length"
+	MsgNoCustomFields     = "storage_has_no_custom_fields"
+	MsgFieldNameRule      = "custom_field_name_rule"
+	MsgCustomRequiredf    = "custom_field_required"
+	MsgCustomTypef        = "custom_field_type"
+	MsgCustomUnknownf     = "custom_field_unknown"
+	MsgNoViews            = "storage_has_no_views"
+	MsgViewNameRule       = "view_name_rule"
+	MsgViewFilterf        = "view_filter_invalid"
+	MsgCannotUpdate       = "storage_cannot_update"
+	MsgSinceRule          = "since_rule"
+	MsgNoChanges          = "storage_has_no_changes"
+	MsgResyncFromExport   = "resync_from_export"
+	MsgCannotBulkUpdate   = "storage_cannot_bulk_update"
+	MsgConfirmAbovef      = "confirm_above"
+	MsgConfirmBoolean     = "confirm_boolean"
+	MsgBulkFieldsRule     = "bulk_fields_rule"
+	MsgFilterRequired     = "filter_required"
+	MsgNoScheduledJobf    = "no_scheduled_job"
+	MsgJobStillRunningf   = "job_still_running"
+	MsgNoWebhooks         = "storage_has_no_webhooks"
+	MsgAPIKeyRequired     = "api_key_required"
+	MsgWebhookURLRule     = "webhook_url_rule"
+	MsgWebhookEventf      = "webhook_event_unknown"
+	MsgDeliveryStatusf    = "delivery_status"
+	MsgNoEventLog         = "no_event_log"
+	MsgEntityRulef        = "entity_rule"
+	MsgAfterSeqRule       = "after_seq_rule"
+	MsgResyncEventsf      = "resync_events"
+	MsgQuotaResetf        = "quota_reset"
+	MsgMonthRule          = "month_rule"
+	MsgExportFormatRulef  = "export_format_rule"
+	MsgCompressionRulef   = "compression_rule"
+	MsgChecksumMismatchf  = "checksum_mismatch_detail"
+	MsgNoRoutef           = "no_route"
+	MsgAllowedMethodsf    = "allowed_methods"
+	MsgPreviewBoolean     = "preview_boolean"
+	MsgNoStaging          = "no_import_staging"
+	MsgNothingStagedf     = "nothing_staged_detail"
+	MsgPreviewStatusf     = "preview_status"
+)
+
+// catalog holds the translated strings: lang -> key -> message
+var catalog = map[string]map[string]string{
+	LangEnglish: {
+		MsgInvalidRequestBody:    "invalid request body",
+		MsgEmptyRequestBody:      "request body is empty",
+		MsgValidationErrors:      "validation errors",
+		MsgInvalidID:             "invalid ID",
+		MsgStudentNotFound:       "student not found",
+		MsgInternalError:         "internal server error",
+		MsgDatabaseError:         "database error",
+		MsgCreateStudentError:    "error creating student",
+		MsgInvalidDryRun:         "invalid dry run flag",
+		MsgDryRunUnsupported:     "dry run not supported",
+		MsgUnauthorized:          "unauthorized",
+		MsgTenantRequired:        "tenant required",
+		MsgInvalidTenant:         "invalid tenant",
+		MsgUnknownTenant:         "unknown tenant",
+		MsgJobNotFound:           "job not found",
+		MsgInvalidLimit:          "invalid limit",
+		MsgInvalidPagination:     "invalid pagination parameters",
+		MsgInvalidMonths:         "invalid months",
+		MsgInvalidCount:          "invalid count",
+		MsgInvalidSeed:           "invalid seed",
+		MsgInvalidSearchQuery:    "invalid search query",
+		MsgInvalidReportQuery:    "invalid report query",
+		MsgMergeUnsupported:      "merge not supported",
+		MsgResetUnsupported:      "reset not supported",
+		MsgSeedError:             "error seeding students",
+		MsgResetError:            "error resetting database",
+		MsgRetentionError:        "error computing retention report",
+		MsgDraining:              "draining",
+		MsgUnsupportedVersion:    "unsupported API version",
+		MsgRenderError:           "error rendering profile",
+		MsgProfilesNotReady:      "profiles not ready",
+		MsgRateLimited:           "rate limit exceeded",
+		MsgTooManyConcurrent:     "too many concurrent requests",
+		MsgServerBusy:            "server busy",
+		MsgInvalidFilter:         "invalid filter",
+		MsgFilterUnsupported:     "filtering not supported",
+		MsgRequestNotFound:       "request not found",
+		MsgRecordingNotFound:     "recording not found",
+		MsgInvalidLink:           "invalid relationship",
+		MsgLinksUnsupported:      "relationships not supported",
+		MsgAlreadyLinked:         "students already linked",
+		MsgLinkNotFound:          "relationship not found",
+		MsgInvalidExpand:         "invalid expand",
+		MsgBookNotFound:          "book not found",
+		MsgLibraryUnsupported:    "library not supported",
+		MsgBookOnLoan:            "book is on loan",
+		MsgBookNotOnLoan:         "book is not on loan",
+		MsgInvalidDueDate:        "invalid due date",
+		MsgHostelNotFound:        "hostel not found",
+		MsgRoomNotFound:          "room not found",
+		MsgHostelsUnsupported:    "hostels not supported",
+		MsgHostelExists:          "hostel already exists",
+		MsgRoomExists:            "room already exists",
+		MsgRoomFull:              "room is full",
+		MsgHasRoom:               "student already has a room",
+		MsgNotAllocated:          "student is not allocated this room",
+		MsgRouteNotFound:         "route not found",
+		MsgStopNotFound:          "stop not found",
+		MsgRoutesUnsupported:     "bus routes not supported",
+		MsgRouteExists:           "route already exists",
+		MsgRouteFull:             "route is full",
+		MsgHasRoute:              "student already rides a route",
+		MsgNotRiding:             "student does not ride this route",
+		MsgAlumnusNotFound:       "alumnus not found",
+		MsgAlumniUnsupported:     "alumni not supported",
+		MsgGraduated:             "student has already graduated",
+		MsgInvalidGraduation:     "invalid graduation date",
+		MsgInvalidClassOf:        "invalid class year",
+		MsgAppNotFound:           "application not found",
+		MsgAppsUnsupported:       "admissions not supported",
+		MsgInvalidAppStatus:      "invalid application status",
+		MsgStatusNotAllowed:      "status change not allowed",
+		MsgConverted:             "application already converted",
+		MsgNoticeNotFound:        "announcement not found",
+		MsgNoticesUnsupported:    "announcements not supported",
+		MsgDeliveryNotFound:      "delivery not found",
+		MsgBadSignature:          "invalid signature",
+		MsgSMSTooLong:            "announcement too long for a text message",
+		MsgFieldNotFound:         "custom field not found",
+		MsgFieldExists:           "custom field already exists",
+		MsgFieldsUnsupported:     "custom fields not supported",
+		MsgInvalidField:          "invalid custom field",
+		MsgViewNotFound:          "view not found",
+		MsgViewExists:            "view already exists",
+		MsgViewsUnsupported:      "saved views not supported",
+		MsgInvalidView:           "invalid view",
+		MsgUpdateUnsupported:     "update not supported",
+		MsgInvalidSince:          "invalid since",
+		MsgChangesUnsupported:    "changes feed not supported",
+		MsgChangesExpired:        "changes expired",
+		MsgBulkUpdateUnsupported: "bulk update not supported",
+		MsgConfirmationRequired:  "confirmation required",
+		MsgInvalidConfirm:        "invalid confirm",
+		MsgJobRunning:            "job already running",
+		MsgWebhookNotFound:       "webhook not found",
+		MsgWebhooksUnsupported:   "webhooks not supported",
+		MsgInvalidWebhook:        "invalid webhook",
+		MsgInvalidDeliveryStatus: "invalid delivery status",
+		MsgDeliveryNotFailed:     "delivery has not failed",
+		MsgEventLogUnsupported:   "event log not supported",
+		MsgInvalidEntity:         "invalid entity",
+		MsgInvalidAfterSeq:       "invalid after_seq",
+		MsgEventsExpired:         "events expired",
+		MsgQuotaExceeded:         "monthly quota exceeded",
+		MsgInvalidMonth:          "invalid month",
+		MsgUsageError:            "could not read usage",
+		MsgInvalidExportFormat:   "invalid export format",
+		MsgInvalidCompression:    "invalid compression",
+		MsgInvalidContentDigest:  "invalid Content-Digest",
+		MsgChecksumMismatch:      "checksum mismatch",
+		MsgNotFound:              "not found",
+		MsgMethodNotAllowed:      "method not allowed",
+		MsgInvalidPreview:        "invalid preview",
+		MsgPreviewUnsupported:    "import preview not supported",
+		MsgNothingStaged:         "nothing staged",
+		MsgPreviewNotFinished:    "preview not finished",
+
+		MsgOutOfRangef:        "%s must be between %d and %d",
+		MsgNotPositiveIntf:    "%s must be a positive integer",
+		MsgRepeatedParamf:     "%s must be given only once",
+		MsgNotUUIDf:           "%s must be a UUID",
+		MsgSearchQueryf:       "q is required and at most %d bytes",
+		MsgTooManyStudentsf:   "at most %d students per request",
+		MsgNoDryRunModef:      "%s has no dry run mode",
+		MsgAdminTokenInvalid:  "missing or invalid admin token",
+		MsgCannotMerge:        "storage backend cannot merge students",
+		MsgCannotReset:        "storage backend cannot be reset",
+		MsgServerShuttingDown: "server is shutting down",
+		MsgUnexpectedError:    "unexpected error",
+		MsgUnknownVersionf:    "API version %q is not supported",
+		MsgJobStatusf:         "job %d is %s",
+		MsgRetryAfterf:        "try again in %s seconds",
+		MsgNoRequestf:         "no request with ID %s is in flight",
+		MsgNoRecordingf:       "recording %s is not held; only the most recent ones are kept",
+		MsgJSONTypef:          "%s must be %s (byte %d)",
+		MsgJSONSyntaxf:        "malformed JSON at byte %d",
+		MsgJSONTruncated:      "the JSON ends before it is complete",
+		MsgJSONBody:           "the request body",
+		MsgJSONNumber:         "a number",
+		MsgJSONString:         "a string",
+		MsgJSONBoolean:        "true or false",
+		MsgJSONArray:          "an array",
+		MsgJSONObject:         "an object",
+		MsgCannotFilter:       "storage backend cannot filter students",
+		MsgCannotLink:         "storage backend cannot link students",
+		MsgLinkKindf:          "kind must be one of %s",
+		MsgSelfLink:           "a student cannot be linked to itself",
+		MsgUnknownExpandf:     "cannot expand %q; expandable: %s",
+		MsgNoLibrary:          "storage backend has no library",
+		MsgDueDateRule:        "due_date must be a date (YYYY-MM-DD), today or later",
+		MsgNoHostels:          "storage backend has no hostels",
+		MsgNoRoutes:           "storage backend has no bus routes",
+		MsgStopOffRoutef:      "stop %s is not on this route",
+		MsgNoAlumni:           "storage backend has no alumni",
+		MsgGraduatedOnRule:    "graduated_on must be a date (YYYY-MM-DD), today or earlier",
+		MsgGraduatedf:         "graduated with the class of %d",
+		MsgNoAdmissions:       "storage backend has no admissions",
+		MsgAppStatusf:         "status must be one of %s",
+		MsgTransitionf:        "cannot move an application from %s to %s",
+		MsgNotAcceptedf:       "only accepted applications can be converted; this one is %s",
+		MsgConvertedf:         "converted into student %s",
+		MsgNoNotices:          "storage backend has no announcements",
+		MsgSMSLengthf:         "subject and body may have at most %d characters together in a text message",
+		MsgNoCustomFields:     "storage backend has no custom fields",
+		MsgFieldNameRule:      "name must be a lowercase letter followed by at most 49 lowercase letters, digits and underscores",
+		MsgCustomRequiredf:    "custom_fields.%s is required",
+		MsgCustomTypef:        "custom_fields.%s must be a %s",
+		MsgCustomUnknownf:     "custom_fields.%s is not a defined custom field",
+		MsgNoViews:            "storage backend has no saved views",
+		MsgViewNameRule:       "name must be at most 64 lowercase letters, digits and hyphens, starting with a letter or digit",
+		MsgViewFilterf:        "view %s: %s",
+		MsgCannotUpdate:       "storage backend cannot update students",
+		MsgSinceRule:          "since must be an RFC 3339 time, e.g. 2024-01-01T00:00:00Z",
+		MsgNoChanges:          "storage backend does not record when students change",
+		MsgResyncFromExport:   "deletions since then are no longer kept; download every student with GET /students/export and sync from when it started",
+		MsgCannotBulkUpdate:   "storage backend cannot update students in bulk",
+		MsgConfirmAbovef:      "%d students match, more than %d; repeat the request with confirm=true to update them all",
+		MsgConfirmBoolean:     "confirm must be true or false",
+		MsgBulkFieldsRule:     "only custom_fields can be changed in bulk, as an object of at least one field",
+		MsgFilterRequired:     "filter is required; it picks the students to update",
+		MsgNoScheduledJobf:    "no scheduled job %q",
+		MsgJobStillRunningf:   "%s is still running; GET /admin/jobs shows when it finishes",
+		MsgNoWebhooks:         "storage backend has no webhook subscriptions",
+		MsgAPIKeyRequired:     "a valid X-API-Key header is required",
+		MsgWebhookURLRule:     "url must be an absolute http or https URL",
+		MsgWebhookEventf:      "events: unknown event %s (available: %s)",
+		MsgDeliveryStatusf:    "delivery %s is %s; only failed deliveries can be replayed",
+		MsgNoEventLog:         "this storage backend does not keep an event log",
+		MsgEntityRulef:        "entity must be one of %s",
+		MsgAfterSeqRule:       "after_seq must be a whole number, 0 to start from the first event",
+		MsgResyncEventsf:      "events after %d are no longer kept; resync from the database (GET /students/export) and continue with after_seq=%d",
+		MsgQuotaResetf:        "the monthly quota of %d requests is used up; it refills in %s seconds",
+		MsgMonthRule:          "month must be a calendar month as YYYY-MM, e.g. 2026-10",
+		MsgExportFormatRulef:  "format must be one of %s",
+		MsgCompressionRulef:   "compress must be one of %s",
+		MsgChecksumMismatchf:  "the upload's SHA-256 is %s but Content-Digest declares %s; the file is incomplete or corrupted and nothing was imported",
+		MsgNoRoutef:           "no route matches %s %s",
+		MsgAllowedMethodsf:    "%s supports only %s",
+		MsgPreviewBoolean:     "preview must be true or false",
+		MsgNoStaging:          "the storage can't stage imports for preview",
+		MsgNothingStagedf:     "job %d has no staged rows: it is not a preview, staged no valid rows, or was already confirmed or discarded",
+		MsgPreviewStatusf:     "preview job %d is %s; confirm it once it has succeeded",
+	},
+	LangHindi: {
+		MsgInvalidRequestBody:    "अमान्य अनुरोध बॉडी",
+		MsgEmptyRequestBody:      "अनुरोध बॉडी खाली है",
+		MsgValidationErrors:      "सत्यापन त्रुटियाँ",
+		MsgInvalidID:             "अमान्य ID",
+		MsgStudentNotFound:       "छात्र नहीं मिला",
+		MsgInternalError:         "आंतरिक सर्वर त्रुटि",
+		MsgDatabaseError:         "डेटाबेस त्रुटि",
+		MsgCreateStudentError:    "छात्र बनाने में त्रुटि",
+		MsgInvalidDryRun:         "अमान्य ड्राई-रन मान",
+		MsgDryRunUnsupported:     "ड्राई-रन समर्थित नहीं है",
+		MsgUnauthorized:          "अनधिकृत",
+		MsgTenantRequired:        "टेनेंट आवश्यक है",
+		MsgInvalidTenant:         "अमान्य टेनेंट",
+		MsgUnknownTenant:         "अज्ञात टेनेंट",
+		MsgJobNotFound:           "जॉब नहीं मिला",
+		MsgInvalidLimit:          "अमान्य सीमा",
+		MsgInvalidPagination:     "अमान्य पेजिनेशन पैरामीटर",
+		MsgInvalidMonths:         "अमान्य महीने",
+		MsgInvalidCount:          "अमान्य संख्या",
+		MsgInvalidSeed:           "अमान्य सीड",
+		MsgInvalidSearchQuery:    "अमान्य खोज क्वेरी",
+		MsgInvalidReportQuery:    "अमान्य रिपोर्ट क्वेरी",
+		MsgMergeUnsupported:      "मर्ज समर्थित नहीं है",
+		MsgResetUnsupported:      "रीसेट समर्थित नहीं है",
+		MsgSeedError:             "छात्र जोड़ने में त्रुटि",
+		MsgResetError:            "डेटाबेस रीसेट करने में त्रुटि",
+		MsgRetentionError:        "रिटेंशन रिपोर्ट बनाने में त्रुटि",
+		MsgDraining:              "बंद हो रहा है",
+		MsgUnsupportedVersion:    "असमर्थित API संस्करण",
+		MsgRenderError:           "प्रोफ़ाइल बनाने में त्रुटि",
+		MsgProfilesNotReady:      "प्रोफ़ाइल अभी तैयार नहीं हैं",
+		MsgRateLimited:           "अनुरोध सीमा पार हो गई",
+		MsgTooManyConcurrent:     "बहुत अधिक समवर्ती अनुरोध",
+		MsgServerBusy:            "सर्वर व्यस्त है",
+		MsgInvalidFilter:         "अमान्य फ़िल्टर",
+		MsgFilterUnsupported:     "फ़िल्टर समर्थित नहीं है",
+		MsgRequestNotFound:       "अनुरोध नहीं मिला",
+		MsgRecordingNotFound:     "रिकॉर्डिंग नहीं मिली",
+		MsgInvalidLink:           "अमान्य संबंध",
+		MsgLinksUnsupported:      "संबंध समर्थित नहीं हैं",
+		MsgAlreadyLinked:         "छात्र पहले से जुड़े हुए हैं",
+		MsgLinkNotFound:          "संबंध नहीं मिला",
+		MsgInvalidExpand:         "अमान्य expand",
+		MsgBookNotFound:          "किताब नहीं मिली",
+		MsgLibraryUnsupported:    "लाइब्रेरी समर्थित नहीं है",
+		MsgBookOnLoan:            "किताब उधार दी गई है",
+		MsgBookNotOnLoan:         "किताब उधार नहीं दी गई है",
+		MsgInvalidDueDate:        "अमान्य नियत तारीख",
+		MsgHostelNotFound:        "छात्रावास नहीं मिला",
+		MsgRoomNotFound:          "कमरा नहीं मिला",
+		MsgHostelsUnsupported:    "छात्रावास समर्थित नहीं हैं",
+		MsgHostelExists:          "छात्रावास पहले से मौजूद है",
+		MsgRoomExists:            "कमरा पहले से मौजूद है",
+		MsgRoomFull:              "कमरा भरा हुआ है",
+		MsgHasRoom:               "छात्र को पहले से कमरा मिला हुआ है",
+		MsgNotAllocated:          "छात्र को यह कमरा नहीं मिला है",
+		MsgRouteNotFound:         "रूट नहीं मिला",
+		MsgStopNotFound:          "स्टॉप नहीं मिला",
+		MsgRoutesUnsupported:     "बस रूट समर्थित नहीं हैं",
+		MsgRouteExists:           "रूट पहले से मौजूद है",
+		MsgRouteFull:             "रूट भरा हुआ है",
+		MsgHasRoute:              "छात्र पहले से एक रूट पर है",
+		MsgNotRiding:             "छात्र इस रूट पर नहीं है",
+		MsgAlumnusNotFound:       "पूर्व छात्र नहीं मिला",
+		MsgAlumniUnsupported:     "पूर्व छात्र समर्थित नहीं हैं",
+		MsgGraduated:             "छात्र पहले ही स्नातक हो चुका है",
+		MsgInvalidGraduation:     "अमान्य स्नातक तिथि",
+		MsgInvalidClassOf:        "अमान्य बैच वर्ष",
+		MsgAppNotFound:           "आवेदन नहीं मिला",
+		MsgAppsUnsupported:       "प्रवेश समर्थित नहीं है",
+		MsgInvalidAppStatus:      "अमान्य आवेदन स्थिति",
+		MsgStatusNotAllowed:      "स्थिति बदलने की अनुमति नहीं है",
+		MsgConverted:             "आवेदन पहले ही छात्र में बदला जा चुका है",
+		MsgNoticeNotFound:        "घोषणा नहीं मिली",
+		MsgNoticesUnsupported:    "घोषणाएँ समर्थित नहीं हैं",
+		MsgDeliveryNotFound:      "डिलीवरी नहीं मिली",
+		MsgBadSignature:          "अमान्य हस्ताक्षर",
+		MsgSMSTooLong:            "टेक्स्ट संदेश के लिए घोषणा बहुत लंबी है",
+		MsgFieldNotFound:         "कस्टम फ़ील्ड नहीं मिला",
+		MsgFieldExists:           "कस्टम फ़ील्ड पहले से मौजूद है",
+		MsgFieldsUnsupported:     "कस्टम फ़ील्ड समर्थित नहीं हैं",
+		MsgInvalidField:          "अमान्य कस्टम फ़ील्ड",
+		MsgViewNotFound:          "व्यू नहीं मिला",
+		MsgViewExists:            "व्यू पहले से मौजूद है",
+		MsgViewsUnsupported:      "सहेजे गए व्यू समर्थित नहीं हैं",
+		MsgInvalidView:           "अमान्य व्यू",
+		MsgUpdateUnsupported:     "अपडेट समर्थित नहीं है",
+		MsgInvalidSince:          "अमान्य since",
+		MsgChangesUnsupported:    "बदलावों की फ़ीड समर्थित नहीं है",
+		MsgChangesExpired:        "बदलाव अब उपलब्ध नहीं हैं",
+		MsgBulkUpdateUnsupported: "एक साथ अपडेट समर्थित नहीं है",
+		MsgConfirmationRequired:  "पुष्टि आवश्यक है",
+		MsgInvalidConfirm:        "अमान्य confirm",
+		MsgJobRunning:            "जॉब पहले से चल रहा है",
+		MsgWebhookNotFound:       "वेबहुक नहीं मिला",
+		MsgWebhooksUnsupported:   "वेबहुक समर्थित नहीं हैं",
+		MsgInvalidWebhook:        "अमान्य वेबहुक",
+		MsgInvalidDeliveryStatus: "अमान्य डिलीवरी स्थिति",
+		MsgDeliveryNotFailed:     "डिलीवरी विफल नहीं हुई है",
+		MsgEventLogUnsupported:   "इवेंट लॉग समर्थित नहीं है",
+		MsgInvalidEntity:         "अमान्य entity",
+		MsgInvalidAfterSeq:       "अमान्य after_seq",
+		MsgEventsExpired:         "इवेंट अब उपलब्ध नहीं हैं",
+		MsgQuotaExceeded:         "मासिक कोटा समाप्त हो गया",
+		MsgInvalidMonth:          "अमान्य महीना",
+		MsgUsageError:            "उपयोग नहीं पढ़ा जा सका",
+		MsgInvalidExportFormat:   "अमान्य export format",
+		MsgInvalidCompression:    "अमान्य compression",
+		MsgInvalidContentDigest:  "अमान्य Content-Digest",
+		MsgChecksumMismatch:      "checksum मेल नहीं खाता",
+		MsgNotFound:              "नहीं मिला",
+		MsgMethodNotAllowed:      "method की अनुमति नहीं है",
+		MsgInvalidPreview:        "अमान्य preview",
+		MsgPreviewUnsupported:    "import preview समर्थित नहीं है",
+		MsgNothingStaged:         "कुछ भी staged नहीं है",
+		MsgPreviewNotFinished:    "preview पूरा नहीं हुआ",
+
+		MsgOutOfRangef:        "%s %d और %d के बीच होना चाहिए",
+		MsgNotPositiveIntf:    "%s एक धनात्मक पूर्णांक होना चाहिए",
+		MsgRepeatedParamf:     "%s केवल एक बार दिया जाना चाहिए",
+		MsgNotUUIDf:           "%s एक UUID होना चाहिए",
+		MsgSearchQueryf:       "q आवश्यक है और अधिकतम %d बाइट का हो सकता है",
+		MsgTooManyStudentsf:   "प्रति अनुरोध अधिकतम %d छात्र",
+		MsgNoDryRunModef:      "%s में ड्राई-रन मोड नहीं है",
+		MsgAdminTokenInvalid:  "एडमिन टोकन नहीं है या अमान्य है",
+		MsgCannotMerge:        "स्टोरेज बैकएंड छात्रों को मर्ज नहीं कर सकता",
+		MsgCannotReset:        "स्टोरेज बैकएंड रीसेट नहीं हो सकता",
+		MsgServerShuttingDown: "सर्वर बंद हो रहा है",
+		MsgUnexpectedError:    "अनपेक्षित त्रुटि",
+		MsgUnknownVersionf:    "API संस्करण %q समर्थित नहीं है",
+		MsgJobStatusf:         "जॉब %d की स्थिति: %s",
+		MsgRetryAfterf:        "%s सेकंड बाद फिर से प्रयास करें",
+		MsgNoRequestf:         "ID %s वाला कोई अनुरोध चल नहीं रहा है",
+		MsgNoRecordingf:       "रिकॉर्डिंग %s उपलब्ध नहीं है; केवल सबसे हाल की रिकॉर्डिंग रखी जाती हैं",
+		MsgJSONTypef:          "%s %s होना चाहिए (बाइट %d)",
+		MsgJSONSyntaxf:        "बाइट %d पर JSON गलत है",
+		MsgJSONTruncated:      "JSON पूरा होने से पहले ही खत्म हो गया",
+		MsgJSONBody:           "अनुरोध बॉडी",
+		MsgJSONNumber:         "संख्या",
+		MsgJSONString:         "स्ट्रिंग",
+		MsgJSONBoolean:        "true या false",
+		MsgJSONArray:          "ऐरे",
+		MsgJSONObject:         "ऑब्जेक्ट",
+		MsgCannotFilter:       "स्टोरेज बैकएंड छात्रों को फ़िल्टर नहीं कर सकता",
+		MsgCannotLink:         "स्टोरेज बैकएंड छात्रों को नहीं जोड़ सकता",
+		MsgLinkKindf:          "kind इनमें से एक होना चाहिए: %s",
+		MsgSelfLink:           "किसी छात्र को स्वयं से नहीं जोड़ा जा सकता",
+		MsgUnknownExpandf:     "%q को expand नहीं किया जा सकता; संभव: %s",
+		MsgNoLibrary:          "स्टोरेज बैकएंड में लाइब्रेरी नहीं है",
+		MsgDueDateRule:        "due_date आज या उसके बाद की तारीख (YYYY-MM-DD) होनी चाहिए",
+		MsgNoHostels:          "स्टोरेज बैकएंड में छात्रावास नहीं हैं",
+		MsgNoRoutes:           "स्टोरेज बैकएंड में बस रूट नहीं हैं",
+		MsgStopOffRoutef:      "स्टॉप %s इस रूट पर नहीं है",
+		MsgNoAlumni:           "स्टोरेज बैकएंड में पूर्व छात्र नहीं हैं",
+		MsgGraduatedOnRule:    "graduated_on आज या उससे पहले की तारीख (YYYY-MM-DD) होनी चाहिए",
+		MsgGraduatedf:         "%d बैच के साथ स्नातक हुआ",
+		MsgNoAdmissions:       "स्टोरेज बैकएंड में प्रवेश नहीं हैं",
+		MsgAppStatusf:         "status इनमें से एक होना चाहिए: %s",
+		MsgTransitionf:        "आवेदन को %s से %s में नहीं बदला जा सकता",
+		MsgNotAcceptedf:       "केवल स्वीकृत आवेदन बदले जा सकते हैं; यह %s है",
+		MsgConvertedf:         "छात्र %s में बदला गया",
+		MsgNoNotices:          "स्टोरेज बैकएंड में घोषणाएँ नहीं हैं",
+		MsgSMSLengthf:         "टेक्स्ट संदेश में विषय और मुख्य भाग मिलाकर अधिकतम %d अक्षर हो सकते हैं",
+		MsgNoCustomFields:     "स्टोरेज बैकएंड में कस्टम फ़ील्ड नहीं हैं",
+		MsgFieldNameRule:      "name एक छोटे अक्षर से शुरू होना चाहिए, जिसके बाद अधिकतम 49 छोटे अक्षर, अंक और अंडरस्कोर हों",
+		MsgCustomRequiredf:    "custom_fields.%s आवश्यक है",
+		MsgCustomTypef:        "custom_fields.%s का प्रकार %s होना चाहिए",
+		MsgCustomUnknownf:     "custom_fields.%s कोई परिभाषित कस्टम फ़ील्ड नहीं है",
+		MsgNoViews:            "स्टोरेज बैकएंड में सहेजे गए व्यू नहीं हैं",
+		MsgViewNameRule:       "name में अधिकतम 64 छोटे अक्षर, अंक और हाइफ़न हो सकते हैं, और यह अक्षर या अंक से शुरू होना चाहिए",
+		MsgViewFilterf:        "व्यू %s: %s",
+		MsgCannotUpdate:       "स्टोरेज बैकएंड छात्रों को अपडेट नहीं कर सकता",
+		MsgSinceRule:          "since एक RFC 3339 समय होना चाहिए, जैसे 2024-01-01T00:00:00Z",
+		MsgNoChanges:          "स्टोरेज बैकएंड छात्रों के बदलने का समय दर्ज नहीं करता",
+		MsgResyncFromExport:   "तब से हुए विलोपन अब रखे नहीं गए हैं; GET /students/export से सभी छात्र डाउनलोड करें और उसके शुरू होने के समय से सिंक करें",
+		MsgCannotBulkUpdate:   "स्टोरेज बैकएंड छात्रों को एक साथ अपडेट नहीं कर सकता",
+		MsgConfirmAbovef:      "%d छात्र मेल खाते हैं, जो %d से अधिक हैं; सभी को अपडेट करने के लिए confirm=true के साथ अनुरोध दोहराएँ",
+		MsgConfirmBoolean:     "confirm true या false होना चाहिए",
+		MsgBulkFieldsRule:     "एक साथ केवल custom_fields बदले जा सकते हैं, कम से कम एक फ़ील्ड वाले ऑब्जेक्ट के रूप में",
+		MsgFilterRequired:     "filter आवश्यक है; यह अपडेट किए जाने वाले छात्रों को चुनता है",
+		MsgNoScheduledJobf:    "कोई निर्धारित जॉब %q नहीं है",
+		MsgJobStillRunningf:   "%s अभी चल रहा है; GET /admin/jobs दिखाता है कि यह कब पूरा होता है",
+		MsgNoWebhooks:         "स्टोरेज बैकएंड में वेबहुक सदस्यताएँ नहीं हैं",
+		MsgAPIKeyRequired:     "एक मान्य X-API-Key हेडर आवश्यक है",
+		MsgWebhookURLRule:     "url एक पूर्ण http या https URL होना चाहिए",
+		MsgWebhookEventf:      "events: अज्ञात इवेंट %s (उपलब्ध: %s)",
+		MsgDeliveryStatusf:    "डिलीवरी %s की स्थिति %s है; केवल विफल डिलीवरी दोबारा भेजी जा सकती हैं",
+		MsgNoEventLog:         "यह स्टोरेज बैकएंड इवेंट लॉग नहीं रखता",
+		MsgEntityRulef:        "entity इनमें से एक होना चाहिए: %s",
+		MsgAfterSeqRule:       "after_seq एक पूर्ण संख्या होनी चाहिए, पहले इवेंट से शुरू करने के लिए 0",
+		MsgResyncEventsf:      "%d के बाद के इवेंट अब रखे नहीं गए हैं; डेटाबेस से दोबारा सिंक करें (GET /students/export) और after_seq=%d से जारी रखें",
+		MsgQuotaResetf:        "%d अनुरोधों का मासिक कोटा समाप्त हो गया है; यह %s सेकंड में फिर से भरेगा",
+		MsgMonthRule:          "month YYYY-MM रूप में एक कैलेंडर महीना होना चाहिए, जैसे 2026-10",
+		MsgExportFormatRulef:  "format इनमें से एक होना चाहिए: %s",
+		MsgCompressionRulef:   "compress इनमें से एक होना चाहिए: %s",
+		MsgChecksumMismatchf:  "अपलोड का SHA-256 %s है, पर Content-Digest %s बताता है; फ़ाइल अधूरी या खराब है और कुछ भी import नहीं हुआ",
+		MsgNoRoutef:           "%s %s से कोई route मेल नहीं खाता",
+		MsgAllowedMethodsf:    "%s केवल %s समर्थित करता है",
+		MsgPreviewBoolean:     "preview true या false होना चाहिए",
+		MsgNoStaging:          "storage preview के लिए import stage नहीं कर सकता",
+		MsgNothingStagedf:     "जॉब %d में कोई staged पंक्ति नहीं है: यह preview नहीं है, इसमें कोई मान्य पंक्ति stage नहीं हुई, या इसे पहले ही confirm या discard किया जा चुका है",
+		MsgPreviewStatusf:     "preview जॉब %d की स्थिति %s है; सफल होने के बाद confirm करें",
+	},
+	LangMarathi: {
+		MsgInvalidRequestBody:    "अवैध विनंती बॉडी",
+		MsgEmptyRequestBody:      "विनंती बॉडी रिकामी आहे",
+		MsgValidationErrors:      "प्रमाणीकरण त्रुटी",
+		MsgInvalidID:             "अवैध ID",
+		MsgStudentNotFound:       "विद्यार्थी सापडला नाही",
+		MsgInternalError:         "अंतर्गत सर्व्हर त्रुटी",
+		MsgDatabaseError:         "डेटाबेस त्रुटी",
+		MsgCreateStudentError:    "विद्यार्थी तयार करताना त्रुटी",
+		MsgInvalidDryRun:         "अवैध ड्राय-रन मूल्य",
+		MsgDryRunUnsupported:     "ड्राय-रन समर्थित नाही",
+		MsgUnauthorized:          "अनधिकृत",
+		MsgTenantRequired:        "टेनंट आवश्यक आहे",
+		MsgInvalidTenant:         "अवैध टेनंट",
+		MsgUnknownTenant:         "अज्ञात टेनंट",
+		MsgJobNotFound:           "जॉब सापडला नाही",
+		MsgInvalidLimit:          "अवैध मर्यादा",
+		MsgInvalidPagination:     "अवैध पृष्ठांकन पॅरामीटर",
+		MsgInvalidMonths:         "अवैध महिने",
+		MsgInvalidCount:          "अवैध संख्या",
+		MsgInvalidSeed:           "अवैध सीड",
+		MsgInvalidSearchQuery:    "अवैध शोध क्वेरी",
+		MsgInvalidReportQuery:    "अवैध अहवाल क्वेरी",
+		MsgMergeUnsupported:      "मर्ज समर्थित नाही",
+		MsgResetUnsupported:      "रीसेट समर्थित नाही",
+		MsgSeedError:             "विद्यार्थी जोडताना त्रुटी",
+		MsgResetError:            "डेटाबेस रीसेट करताना त्रुटी",
+		MsgRetentionError:        "रिटेन्शन अहवाल तयार करताना त्रुटी",
+		MsgDraining:              "बंद होत आहे",
+		MsgUnsupportedVersion:    "असमर्थित API आवृत्ती",
+		MsgRenderError:           "प्रोफाइल तयार करताना त्रुटी",
+		MsgProfilesNotReady:      "प्रोफाइल अजून तयार नाहीत",
+		MsgRateLimited:           "विनंती मर्यादा ओलांडली",
+		MsgTooManyConcurrent:     "खूप जास्त एकाचवेळी विनंत्या",
+		MsgServerBusy:            "सर्व्हर व्यस्त आहे",
+		MsgInvalidFilter:         "अवैध फिल्टर",
+		MsgFilterUnsupported:     "फिल्टर समर्थित नाही",
+		MsgRequestNotFound:       "विनंती सापडली नाही",
+		MsgRecordingNotFound:     "रेकॉर्डिंग सापडले नाही",
+		MsgInvalidLink:           "अवैध नाते",
+		MsgLinksUnsupported:      "नाती समर्थित नाहीत",
+		MsgAlreadyLinked:         "विद्यार्थी आधीच जोडलेले आहेत",
+		MsgLinkNotFound:          "नाते सापडले नाही",
+		MsgInvalidExpand:         "अवैध expand",
+		MsgBookNotFound:          "पुस्तक सापडले नाही",
+		MsgLibraryUnsupported:    "ग्रंथालय समर्थित नाही",
+		MsgBookOnLoan:            "पुस्तक उधार दिले आहे",
+		MsgBookNotOnLoan:         "पुस्तक उधार दिलेले नाही",
+		MsgInvalidDueDate:        "अवैध देय तारीख",
+		MsgHostelNotFound:        "वसतिगृह सापडले नाही",
+		MsgRoomNotFound:          "खोली सापडली नाही",
+		MsgHostelsUnsupported:    "वसतिगृहे समर्थित नाहीत",
+		MsgHostelExists:          "वसतिगृह आधीच अस्तित्वात आहे",
+		MsgRoomExists:            "खोली आधीच अस्तित्वात आहे",
+		MsgRoomFull:              "खोली भरलेली आहे",
+		MsgHasRoom:               "विद्यार्थ्याला आधीच खोली मिळाली आहे",
+		MsgNotAllocated:          "विद्यार्थ्याला ही खोली मिळालेली नाही",
+		MsgRouteNotFound:         "मार्ग सापडला नाही",
+		MsgStopNotFound:          "थांबा सापडला नाही",
+		MsgRoutesUnsupported:     "बस मार्ग समर्थित नाहीत",
+		MsgRouteExists:           "मार्ग आधीच अस्तित्वात आहे",
+		MsgRouteFull:             "मार्ग भरलेला आहे",
+		MsgHasRoute:              "विद्यार्थी आधीच एका मार्गावर आहे",
+		MsgNotRiding:             "विद्यार्थी या मार्गावर नाही",
+		MsgAlumnusNotFound:       "माजी विद्यार्थी सापडला नाही",
+		MsgAlumniUnsupported:     "माजी विद्यार्थी समर्थित नाहीत",
+		MsgGraduated:             "विद्यार्थी आधीच पदवीधर झाला आहे",
+		MsgInvalidGraduation:     "अवैध पदवी तारीख",
+		MsgInvalidClassOf:        "अवैध तुकडी वर्ष",
+		MsgAppNotFound:           "अर्ज सापडला नाही",
+		MsgAppsUnsupported:       "प्रवेश समर्थित नाही",
+		MsgInvalidAppStatus:      "अवैध अर्ज स्थिती",
+		MsgStatusNotAllowed:      "स्थिती बदलण्याची परवानगी नाही",
+		MsgConverted:             "अर्ज आधीच विद्यार्थ्यात रूपांतरित झाला आहे",
+		MsgNoticeNotFound:        "घोषणा सापडली नाही",
+		MsgNoticesUnsupported:    "घोषणा समर्थित नाहीत",
+		MsgDeliveryNotFound:      "वितरण सापडले नाही",
+		MsgBadSignature:          "अवैध स्वाक्षरी",
+		MsgSMSTooLong:            "मजकूर संदेशासाठी घोषणा खूप मोठी आहे",
+		MsgFieldNotFound:         "कस्टम फील्ड सापडले नाही",
+		MsgFieldExists:           "कस्टम फील्ड आधीच अस्तित्वात आहे",
+		MsgFieldsUnsupported:     "कस्टम फील्ड समर्थित नाहीत",
+		MsgInvalidField:          "अवैध कस्टम फील्ड",
+		MsgViewNotFound:          "व्ह्यू सापडला नाही",
+		MsgViewExists:            "व्ह्यू आधीच अस्तित्वात आहे",
+		MsgViewsUnsupported:      "जतन केलेले व्ह्यू समर्थित नाहीत",
+		MsgInvalidView:           "अवैध व्ह्यू",
+		MsgUpdateUnsupported:     "अपडेट समर्थित नाही",
+		MsgInvalidSince:          "अवैध since",
+		MsgChangesUnsupported:    "बदलांची फीड समर्थित नाही",
+		MsgChangesExpired:        "बदल आता उपलब्ध नाहीत",
+		MsgBulkUpdateUnsupported: "एकत्रित अपडेट समर्थित नाही",
+		MsgConfirmationRequired:  "पुष्टी आवश्यक आहे",
+		MsgInvalidConfirm:        "अवैध confirm",
+		MsgJobRunning:            "जॉब आधीच चालू आहे",
+		MsgWebhookNotFound:       "वेबहुक सापडला नाही",
+		MsgWebhooksUnsupported:   "वेबहुक समर्थित नाहीत",
+		MsgInvalidWebhook:        "अवैध वेबहुक",
+		MsgInvalidDeliveryStatus: "अवैध वितरण स्थिती",
+		MsgDeliveryNotFailed:     "वितरण अयशस्वी झालेले नाही",
+		MsgEventLogUnsupported:   "इव्हेंट लॉग समर्थित नाही",
+		MsgInvalidEntity:         "अवैध entity",
+		MsgInvalidAfterSeq:       "अवैध after_seq",
+		MsgEventsExpired:         "इव्हेंट आता उपलब्ध नाहीत",
+		MsgQuotaExceeded:         "मासिक कोटा संपला",
+		MsgInvalidMonth:          "अवैध महिना",
+		MsgUsageError:            "वापर वाचता आला नाही",
+		MsgInvalidExportFormat:   "अवैध export format",
+		MsgInvalidCompression:    "अवैध compression",
+		MsgInvalidContentDigest:  "अवैध Content-Digest",
+		MsgChecksumMismatch:      "checksum जुळत नाही",
+		MsgNotFound:              "सापडले नाही",
+		MsgMethodNotAllowed:      "method ला परवानगी नाही",
+		MsgInvalidPreview:        "अवैध preview",
+		MsgPreviewUnsupported:    "import preview समर्थित नाही",
+		MsgNothingStaged:         "काहीही staged नाही",
+		MsgPreviewNotFinished:    "preview पूर्ण झाले नाही",
+
+		MsgOutOfRangef:        "%s %d ते %d दरम्यान असणे आवश्यक आहे",
+		MsgNotPositiveIntf:    "%s धन पूर्णांक असणे आवश्यक आहे",
+		MsgRepeatedParamf:     "%s फक्त एकदाच दिले पाहिजे",
+		MsgNotUUIDf:           "%s UUID असणे आवश्यक आहे",
+		MsgSearchQueryf:       "q आवश्यक आहे आणि जास्तीत जास्त %d बाइट असू शकतो",
+		MsgTooManyStudentsf:   "प्रति विनंती जास्तीत जास्त %d विद्यार्थी",
+		MsgNoDryRunModef:      "%s साठी ड्राय-रन मोड नाही",
+		MsgAdminTokenInvalid:  "ॲडमिन टोकन नाही किंवा अवैध आहे",
+		MsgCannotMerge:        "स्टोरेज बॅकएंड विद्यार्थी मर्ज करू शकत नाही",
+		MsgCannotReset:        "स्टोरेज बॅकएंड रीसेट करता येत नाही",
+		MsgServerShuttingDown: "सर्व्हर बंद होत आहे",
+		MsgUnexpectedError:    "अनपेक्षित त्रुटी",
+		MsgUnknownVersionf:    "API आवृत्ती %q समर्थित नाही",
+		MsgJobStatusf:         "जॉब %d ची स्थिती: %s",
+		MsgRetryAfterf:        "%s सेकंदांनंतर पुन्हा प्रयत्न करा",
+		MsgNoRequestf:         "ID %s असलेली कोणतीही विनंती चालू नाही",
+		MsgNoRecordingf:       "रेकॉर्डिंग %s उपलब्ध नाही; फक्त सर्वात अलीकडील रेकॉर्डिंग ठेवली जातात",
+		MsgJSONTypef:          "%s %s असणे आवश्यक आहे (बाइट %d)",
+		MsgJSONSyntaxf:        "बाइट %d वर JSON चुकीचे आहे",
+		MsgJSONTruncated:      "JSON पूर्ण होण्यापूर्वीच संपले",
+		MsgJSONBody:           "विनंती बॉडी",
+		MsgJSONNumber:         "संख्या",
+		MsgJSONString:         "स्ट्रिंग",
+		MsgJSONBoolean:        "true किंवा false",
+		MsgJSONArray:          "ॲरे",
+		MsgJSONObject:         "ऑब्जेक्ट",
+		MsgCannotFilter:       "स्टोरेज बॅकएंड विद्यार्थी फिल्टर करू शकत नाही",
+		MsgCannotLink:         "स्टोरेज बॅकएंड विद्यार्थी जोडू शकत नाही",
+		MsgLinkKindf:          "kind यापैकी एक असणे आवश्यक आहे: %s",
+		MsgSelfLink:           "विद्यार्थ्याला स्वतःशी जोडता येत नाही",
+		MsgUnknownExpandf:     "%q expand करता येत नाही; शक्य: %s",
+		MsgNoLibrary:          "स्टोरेज बॅकएंडमध्ये ग्रंथालय नाही",
+		MsgDueDateRule:        "due_date आजची किंवा नंतरची तारीख (YYYY-MM-DD) असणे आवश्यक आहे",
+		MsgNoHostels:          "स्टोरेज बॅकएंडमध्ये वसतिगृहे नाहीत",
+		MsgNoRoutes:           "स्टोरेज बॅकएंडमध्ये बस मार्ग नाहीत",
+		MsgStopOffRoutef:      "थांबा %s या मार्गावर नाही",
+		MsgNoAlumni:           "स्टोरेज बॅकएंडमध्ये माजी विद्यार्थी नाहीत",
+		MsgGraduatedOnRule:    "graduated_on आजची किंवा आधीची तारीख (YYYY-MM-DD) असणे आवश्यक आहे",
+		MsgGraduatedf:         "%d तुकडीसह पदवीधर झाला",
+		MsgNoAdmissions:       "स्टोरेज बॅकएंडमध्ये प्रवेश नाहीत",
+		MsgAppStatusf:         "status यापैकी एक असणे आवश्यक आहे: %s",
+		MsgTransitionf:        "अर्ज %s मधून %s मध्ये बदलता येत नाही",
+		MsgNotAcceptedf:       "फक्त स्वीकारलेले अर्ज रूपांतरित करता येतात; हा %s आहे",
+		MsgConvertedf:         "विद्यार्थी %s मध्ये रूपांतरित केला",
+		MsgNoNotices:          "स्टोरेज बॅकएंडमध्ये घोषणा नाहीत",
+		MsgSMSLengthf:         "मजकूर संदेशात विषय आणि मजकूर मिळून जास्तीत जास्त %d अक्षरे असू शकतात",
+		MsgNoCustomFields:     "स्टोरेज बॅकएंडमध्ये कस्टम फील्ड नाहीत",
+		MsgFieldNameRule:      "name लहान अक्षराने सुरू व्हावे आणि त्यानंतर जास्तीत जास्त 49 लहान अक्षरे, अंक आणि अंडरस्कोर असावेत",
+		MsgCustomRequiredf:    "custom_fields.%s आवश्यक आहे",
+		MsgCustomTypef:        "custom_fields.%s चा प्रकार %s असणे आवश्यक आहे",
+		MsgCustomUnknownf:     "custom_fields.%s हे परिभाषित कस्टम फील्ड नाही",
+		MsgNoViews:            "स्टोरेज बॅकएंडमध्ये जतन केलेले व्ह्यू नाहीत",
+		MsgViewNameRule:       "name मध्ये जास्तीत जास्त 64 लहान अक्षरे, अंक आणि हायफन असू शकतात, आणि ते अक्षर किंवा अंकाने सुरू झाले पाहिजे",
+		MsgViewFilterf:        "व्ह्यू %s: %s",
+		MsgCannotUpdate:       "स्टोरेज बॅकएंड विद्यार्थी अपडेट करू शकत नाही",
+		MsgSinceRule:          "since हा RFC 3339 वेळ असला पाहिजे, उदा. 2024-01-01T00:00:00Z",
+		MsgNoChanges:          "स्टोरेज बॅकएंड विद्यार्थी कधी बदलले ते नोंदवत नाही",
+		MsgResyncFromExport:   "तेव्हापासूनचे हटवलेले विद्यार्थी आता ठेवले जात नाहीत; GET /students/export ने सर्व विद्यार्थी डाउनलोड करा आणि ते सुरू झाल्याच्या वेळेपासून सिंक करा",
+		MsgCannotBulkUpdate:   "स्टोरेज बॅकएंड विद्यार्थी एकत्रित अपडेट करू शकत नाही",
+		MsgConfirmAbovef:      "%d विद्यार्थी जुळतात, %d पेक्षा जास्त; सर्वांना अपडेट करण्यासाठी confirm=true सह विनंती पुन्हा करा",
+		MsgConfirmBoolean:     "confirm true किंवा false असला पाहिजे",
+		MsgBulkFieldsRule:     "एकत्रित फक्त custom_fields बदलता येतात, किमान एका फील्डच्या ऑब्जेक्टच्या रूपात",
+		MsgFilterRequired:     "filter आवश्यक आहे; तो अपडेट करायचे विद्यार्थी निवडतो",
+		MsgNoScheduledJobf:    "%q नावाचा नियोजित जॉब नाही",
+		MsgJobStillRunningf:   "%s अजून चालू आहे; तो कधी पूर्ण होतो ते GET /admin/jobs दाखवते",
+		MsgNoWebhooks:         "स्टोरेज बॅकएंडमध्ये वेबहुक सदस्यता नाहीत",
+		MsgAPIKeyRequired:     "वैध X-API-Key हेडर आवश्यक आहे",
+		MsgWebhookURLRule:     "url हा पूर्ण http किंवा https URL असला पाहिजे",
+		MsgWebhookEventf:      "events: अज्ञात इव्हेंट %s (उपलब्ध: %s)",
+		MsgDeliveryStatusf:    "वितरण %s ची स्थिती %s आहे; फक्त अयशस्वी वितरणे पुन्हा पाठवता येतात",
+		MsgNoEventLog:         "हा स्टोरेज बॅकएंड इव्हेंट लॉग ठेवत नाही",
+		MsgEntityRulef:        "entity यापैकी एक असणे आवश्यक आहे: %s",
+		MsgAfterSeqRule:       "after_seq पूर्ण संख्या असणे आवश्यक आहे, पहिल्या इव्हेंटपासून सुरू करण्यासाठी 0",
+		MsgResyncEventsf:      "%d नंतरचे इव्हेंट आता ठेवलेले नाहीत; डेटाबेसमधून पुन्हा सिंक करा (GET /students/export) आणि after_seq=%d ने पुढे चालू ठेवा",
+		MsgQuotaResetf:        "%d विनंत्यांचा मासिक कोटा संपला आहे; तो %s सेकंदांत पुन्हा भरेल",
+		MsgMonthRule:          "month हा YYYY-MM स्वरूपातील कॅलेंडर महिना असावा, उदा. 2026-10",
+		MsgExportFormatRulef:  "format यापैकी एक असणे आवश्यक आहे: %s",
+		MsgCompressionRulef:   "compress यापैकी एक असणे आवश्यक आहे: %s",
+		MsgChecksumMismatchf:  "अपलोडचा SHA-256 %s आहे, पण Content-Digest %s सांगतो; फाइल अपूर्ण किंवा खराब आहे आणि काहीही import झाले नाही",
+		MsgNoRoutef:           "%s %s शी कोणताही route जुळत नाही",
+		MsgAllowedMethodsf:    "%s फक्त %s ला समर्थन देतो",
+		MsgPreviewBoolean:     "preview true किंवा false असला पाहिजे",
+		MsgNoStaging:          "storage preview साठी import stage करू शकत नाही",
+		MsgNothingStagedf:     "जॉब %d मध्ये कोणतीही staged ओळ नाही: हे preview नाही, कोणतीही वैध ओळ stage झाली नाही, किंवा ते आधीच confirm किंवा discard केले आहे",
+		MsgPreviewStatusf:     "preview जॉब %d ची स्थिती %s आहे; यशस्वी झाल्यावर confirm करा",
+	},
+}
+
+// T returns the message for key in lang, falling back to English and then to
+// the key itself so a missing translation never produces an empty error
+func T(lang, key string) string {
+	if msg, ok := catalog[lang][key]; ok {
+		return msg
+	}
+	if msg, ok := catalog[DefaultLang][key]; ok {
+		return msg
+	}
+	return key
+}
+
+// Tf formats the message for key in lang with args, like fmt.Sprintf
+func Tf(lang, key string, args ...any) string {
+	return fmt.Sprintf(T(lang, key), args...)
+}
+
+// FromRequest picks the best supported language from the Accept-Language header
+// Example: "mr-IN,mr;q=0.9,en;q=0.8" -> "mr"
+func FromRequest(r *http.Request) string {
+	return Match(r.Header.Get("Accept-Language"))
+}
+
+// Match parses an Accept-Language value and returns the supported language
+// with the highest quality, or DefaultLang if none match
+func Match(header string) string {
+	if header == "" {
+		return DefaultLang
+	}
+
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag, q := part, 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			tag = strings.TrimSpace(part[:i])
+			param := strings.TrimSpace(part[i+1:])
+			if v, ok := strings.CutPrefix(param, "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		// Only the primary subtag matters to us: "hi-IN" -> "hi"
+		base := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if q > 0 && isSupported(base) {
+			candidates = append(candidates, candidate{lang: base, q: q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return DefaultLang
+	}
+
+	// Stable sort keeps header order for equal q values
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+func isSupported(lang string) bool {
+	for _, l := range SupportedLangs {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
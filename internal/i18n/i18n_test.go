@@ -0,0 +1,44 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+// Every language must translate every message and label, so a new key can't ship in English only
+func TestCatalogsComplete(t *testing.T) {
+	for _, lang := range SupportedLangs {
+		for key, msg := range catalog[DefaultLang] {
+			got, ok := catalog[lang][key]
+			if !ok {
+				t.Errorf("%s: missing message %q", lang, key)
+				continue
+			}
+			if strings.Count(got, "%") != strings.Count(msg, "%") {
+				t.Errorf("%s: message %q has different format verbs than English", lang, key)
+			}
+		}
+		for enum, values := range labels[DefaultLang] {
+			for value := range values {
+				if _, ok := labels[lang][enum][value]; !ok {
+					t.Errorf("%s: missing label for %s %q", lang, enum, value)
+				}
+			}
+		}
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	if got := T("fr", MsgStudentNotFound); got != "student not found" {
+		t.Errorf("T(fr) = %q, want the English message", got)
+	}
+	if got := Tf(LangMarathi, MsgOutOfRangef, "limit", 1, 100); got != "limit 1 ते 100 दरम्यान असणे आवश्यक आहे" {
+		t.Errorf("Tf(mr) = %q", got)
+	}
+	if got := Label(LangHindi, EnumJobStatus, "succeeded"); got != "सफल" {
+		t.Errorf("Label(hi) = %q", got)
+	}
+	if got := Label(LangHindi, EnumAgeBucket, "18-21"); got != "18-21" {
+		t.Errorf("Label of an unlabelled value = %q, want the value", got)
+	}
+}
@@ -0,0 +1,63 @@
+package i18n
+
+import "github.com/prashantkumbhar2002/go_students_api/internal/types"
+
+// Enums with display labels. Responses keep the enum value (what clients filter and switch on)
+// and add its label next to it for showing to people.
+const (
+	EnumJobStatus       = "job_status"
+	EnumStudentStatus   = "student_status"
+	EnumDuplicateReason = "duplicate_reason"
+	EnumAgeBucket       = "age_bucket"
+	EnumYesNo           = "yes_no"
+	EnumLinkKind        = "relationship_kind"
+	EnumAppStatus       = "application_status"
+	EnumDeliveryStatus  = "delivery_status"
+)
+
+// labels holds the display labels: lang -> enum -> value -> label. Values without a label
+// (such as the "18-21" age bucket) read the same in every language and are shown as they are.
+var labels = map[string]map[string]map[string]string{
+	LangEnglish: {
+		EnumJobStatus:       {types.JobQueued: "Queued", types.JobRunning: "Running", types.JobSucceeded: "Succeeded", types.JobDead: "Failed"},
+		EnumStudentStatus:   {types.StudentActive: "Active", types.StudentAnonymized: "Anonymized"},
+		EnumDuplicateReason: {types.DuplicateSameEmail: "Same email", types.DuplicateSimilarName: "Similar name"},
+		EnumAgeBucket:       {"under 18": "Under 18", "over 40": "Over 40"},
+		EnumYesNo:           {"yes": "Yes", "no": "No"},
+		EnumLinkKind:        {types.RelationshipSibling: "Sibling", types.RelationshipTwin: "Twin"},
+		EnumAppStatus:       {types.ApplicationSubmitted: "Submitted", types.ApplicationReviewed: "Under review", types.ApplicationAccepted: "Accepted", types.ApplicationRejected: "Rejected"},
+		EnumDeliveryStatus:  {types.DeliveryQueued: "Queued", types.DeliverySent: "Sent", types.DeliveryDelivered: "Delivered", types.DeliveryFailed: "Failed"},
+	},
+	LangHindi: {
+		EnumJobStatus:       {types.JobQueued: "कतार में", types.JobRunning: "चल रहा है", types.JobSucceeded: "सफल", types.JobDead: "विफल"},
+		EnumStudentStatus:   {types.StudentActive: "सक्रिय", types.StudentAnonymized: "गुमनाम किया गया"},
+		EnumDuplicateReason: {types.DuplicateSameEmail: "समान ईमेल", types.DuplicateSimilarName: "मिलता-जुलता नाम"},
+		EnumAgeBucket:       {"under 18": "18 से कम", "over 40": "40 से अधिक"},
+		EnumYesNo:           {"yes": "हाँ", "no": "नहीं"},
+		EnumLinkKind:        {types.RelationshipSibling: "भाई-बहन", types.RelationshipTwin: "जुड़वाँ"},
+		EnumAppStatus:       {types.ApplicationSubmitted: "जमा किया गया", types.ApplicationReviewed: "समीक्षाधीन", types.ApplicationAccepted: "स्वीकृत", types.ApplicationRejected: "अस्वीकृत"},
+		EnumDeliveryStatus:  {types.DeliveryQueued: "कतार में", types.DeliverySent: "भेजा गया", types.DeliveryDelivered: "पहुँच गया", types.DeliveryFailed: "विफल"},
+	},
+	LangMarathi: {
+		EnumJobStatus:       {types.JobQueued: "रांगेत", types.JobRunning: "चालू आहे", types.JobSucceeded: "यशस्वी", types.JobDead: "अयशस्वी"},
+		EnumStudentStatus:   {types.StudentActive: "सक्रिय", types.StudentAnonymized: "अनामित"},
+		EnumDuplicateReason: {types.DuplicateSameEmail: "समान ईमेल", types.DuplicateSimilarName: "मिळतेजुळते नाव"},
+		EnumAgeBucket:       {"under 18": "18 पेक्षा कमी", "over 40": "40 पेक्षा जास्त"},
+		EnumYesNo:           {"yes": "होय", "no": "नाही"},
+		EnumLinkKind:        {types.RelationshipSibling: "भावंड", types.RelationshipTwin: "जुळे"},
+		EnumAppStatus:       {types.ApplicationSubmitted: "सादर केला", types.ApplicationReviewed: "पुनरावलोकनाधीन", types.ApplicationAccepted: "स्वीकारला", types.ApplicationRejected: "नाकारला"},
+		EnumDeliveryStatus:  {types.DeliveryQueued: "रांगेत", types.DeliverySent: "पाठवला", types.DeliveryDelivered: "पोहोचला", types.DeliveryFailed: "अयशस्वी"},
+	},
+}
+
+// Label returns the display label of an enum value in lang, falling back to English and then
+// to the value itself
+func Label(lang, enum, value string) string {
+	if l, ok := labels[lang][enum][value]; ok {
+		return l
+	}
+	if l, ok := labels[DefaultLang][enum][value]; ok {
+		return l
+	}
+	return value
+}
@@ -0,0 +1,145 @@
+// Package idgen makes up the IDs of new records. Which strategy is used is a deployment choice
+// (config ids.strategy): a database sequence only works while one database hands out every ID,
+// so deployments writing in several regions need IDs that can be made up without coordination.
+//
+// Every record has an internal row ID and a public ID, the UUID clients see as "id". A strategy
+// picks both: the row ID may be left to the database's sequence, and the public ID may be random
+// or ordered by time, which keeps inserts into its index local.
+package idgen
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+// Strategies ids.strategy can name
+const (
+	// AutoIncrement leaves row IDs to the database's sequence and makes random (v4) public IDs
+	AutoIncrement = "auto_increment"
+	// UUIDv7 leaves row IDs to the database's sequence and makes time-ordered (v7) public IDs
+	UUIDv7 = "uuidv7"
+	// Snowflake makes row IDs from the time, a node number and a counter, and v7 public IDs
+	Snowflake = "snowflake"
+)
+
+// Strategies lists the strategies New knows
+var Strategies = []string{AutoIncrement, UUIDv7, Snowflake}
+
+// Generator makes up the IDs of new records. Implementations are safe for concurrent use.
+type Generator interface {
+	// NextID returns the row ID of a new record, or 0 to leave it to the database's sequence
+	NextID() int64
+	// NewPublicID returns a new public ID: a UUID in canonical lowercase form
+	NewPublicID() string
+}
+
+// New returns the generator of strategy. node numbers this instance among those sharing the
+// snowflake ID space (0 to MaxNode, each its own); other strategies ignore it.
+func New(strategy string, node int64) (Generator, error) {
+	switch strategy {
+	case AutoIncrement:
+		return Sequence{}, nil
+	case UUIDv7:
+		return TimeOrdered{}, nil
+	case Snowflake:
+		return NewSnowflakes(node, nil)
+	}
+	return nil, fmt.Errorf("unknown ID strategy %q (want %s)", strategy, strings.Join(Strategies, ", "))
+}
+
+var (
+	mu      sync.RWMutex
+	current Generator = Sequence{}
+)
+
+// SetDefault makes g the generator every record is created with; call it once at startup
+func SetDefault(g Generator) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = g
+}
+
+// Default returns the generator set with SetDefault, or Sequence
+func Default() Generator {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Sequence is the AutoIncrement strategy
+type Sequence struct{}
+
+func (Sequence) NextID() int64       { return 0 }
+func (Sequence) NewPublicID() string { return uuid.NewString() }
+
+// TimeOrdered is the UUIDv7 strategy
+type TimeOrdered struct{}
+
+func (TimeOrdered) NextID() int64       { return 0 }
+func (TimeOrdered) NewPublicID() string { return newV7() }
+
+// newV7 returns a v7 UUID, or a random one in the unlikely case the system can't supply randomness
+func newV7() string {
+	u, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return u.String()
+}
+
+// Snowflake row IDs are, from the top: a zero sign bit, 41 bits of milliseconds since Epoch, 10
+// bits of node and 12 bits of counter within the millisecond
+const (
+	nodeBits    = 10
+	counterBits = 12
+	// MaxNode is the largest node number
+	MaxNode = 1<<nodeBits - 1
+)
+
+// Epoch is when snowflake time starts; 41 bits of milliseconds last until 2093
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflakes is the Snowflake strategy: row IDs unique across up to 1024 instances without any
+// coordination, and increasing on each instance
+type Snowflakes struct {
+	node  int64
+	clock clock.Clock
+
+	mu      sync.Mutex
+	last    int64 // milliseconds since Epoch of the last ID
+	counter int64
+}
+
+// NewSnowflakes returns the generator of node; clk nil means the system clock
+func NewSnowflakes(node int64, clk clock.Clock) (*Snowflakes, error) {
+	if node < 0 || node > MaxNode {
+		return nil, fmt.Errorf("snowflake node must be between 0 and %d, got %d", MaxNode, node)
+	}
+	return &Snowflakes{node: node, clock: clock.OrReal(clk)}, nil
+}
+
+// NextID never repeats on one node. If the clock steps back, IDs carry on from the last one's
+// time; once a millisecond's 4096 IDs are used up, they carry on in the next millisecond.
+func (s *Snowflakes) NextID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := max(s.clock.Now().Sub(Epoch).Milliseconds(), s.last)
+	if now == s.last {
+		s.counter = (s.counter + 1) & (1<<counterBits - 1)
+		if s.counter == 0 {
+			now++
+		}
+	} else {
+		s.counter = 0
+	}
+	s.last = now
+	return now<<(nodeBits+counterBits) | s.node<<counterBits | s.counter
+}
+
+func (s *Snowflakes) NewPublicID() string { return newV7() }
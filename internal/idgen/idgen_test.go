@@ -0,0 +1,69 @@
+package idgen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+func TestSnowflakes(t *testing.T) {
+	clk := clock.NewFake(Epoch.Add(time.Second))
+	g, err := NewSnowflakes(5, clk)
+	if err != nil {
+		t.Fatalf("NewSnowflakes: %v", err)
+	}
+
+	if got, want := g.NextID(), int64(1000<<22|5<<12); got != want {
+		t.Fatalf("first ID = %d, want %d (1000ms, node 5, counter 0)", got, want)
+	}
+	// The rest of the millisecond's 4096, then the next millisecond's first
+	var last int64
+	for range 4095 {
+		last = g.NextID()
+	}
+	if want := int64(1000<<22 | 5<<12 | 4095); last != want {
+		t.Fatalf("4096th ID = %d, want %d", last, want)
+	}
+	if got, want := g.NextID(), int64(1001<<22|5<<12); got != want {
+		t.Fatalf("ID past the counter = %d, want %d in the next millisecond", got, want)
+	}
+
+	// A clock stepping back doesn't repeat IDs
+	clk.Set(Epoch)
+	if got := g.NextID(); got <= 1001<<22|5<<12 {
+		t.Fatalf("ID after the clock stepped back = %d, want it above the last", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, strategy := range Strategies {
+		g, err := New(strategy, 1)
+		if err != nil {
+			t.Fatalf("New(%q): %v", strategy, err)
+		}
+		id := g.NewPublicID()
+		u, err := uuid.Parse(id)
+		if err != nil || u.String() != id {
+			t.Errorf("%s public ID %q, want a canonical UUID", strategy, id)
+		}
+		want := uuid.Version(7)
+		if strategy == AutoIncrement {
+			want = 4
+		}
+		if u.Version() != want {
+			t.Errorf("%s public ID is version %d, want %d", strategy, u.Version(), want)
+		}
+		if got := g.NextID(); (got == 0) != (strategy != Snowflake) {
+			t.Errorf("%s NextID() = %d", strategy, got)
+		}
+	}
+
+	if _, err := New("sequence", 0); err == nil {
+		t.Error("New(unknown strategy) error = nil")
+	}
+	if _, err := New(Snowflake, MaxNode+1); err == nil {
+		t.Error("New(snowflake, node 1024) error = nil")
+	}
+}
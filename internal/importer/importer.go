@@ -0,0 +1,463 @@
+// Package importer loads students from uploaded CSV or JSON files as a background job, so a
+// client uploading a 200k-row file gets a job ID back instead of holding the connection open.
+//
+// Where the storage can checkpoint (storage.ImportCheckpointer), students are committed a batch
+// at a time together with the job's progress, so an import of millions of rows that crashes or is
+// stopped by a shutdown picks up after its last batch when the job runs again.
+//
+// Where the storage can also stage (storage.ImportStager), an import can be previewed first: a
+// preview job loads the valid rows into a staging table and reports what importing them would
+// do, and a confirm job, queued only on request, imports the staged rows.
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	studentsvc "github.com/prashantkumbhar2002/go_students_api/internal/service/students"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+// Kind is the job kind import jobs are enqueued under
+const Kind = "student_import"
+
+// Supported upload formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// maxReportedErrors caps the row errors kept in the job result; the count is always exact
+const maxReportedErrors = 100
+
+// batchSize is how many students a checkpointed import commits at a time
+const batchSize = 1000
+
+// Payload is the job payload: where the upload was spooled and how to read it
+type Payload struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+	// Lang is the uploader's language, used for validation messages in the result
+	Lang string `json:"lang"`
+	// Preview stages the valid rows instead of importing them; the result is a PreviewResult
+	Preview bool `json:"preview,omitempty"`
+	// Confirm is the ID of the preview job whose staged rows this job imports; there is no upload
+	Confirm int64 `json:"confirm,omitempty"`
+}
+
+// RowError describes one rejected row. Row is 1-based and counts data rows only (not the CSV header).
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// Result is stored as the job result
+type Result struct {
+	Imported int `json:"imported"`
+	// Skipped counts valid rows whose email a student already had (checkpointed imports only)
+	Skipped int        `json:"skipped"`
+	Failed  int        `json:"failed"`
+	Errors  []RowError `json:"errors,omitempty"`
+}
+
+// PreviewResult is stored as a preview job's result: what confirming it would do, as of the
+// preview, and the rows that failed validation and weren't staged
+type PreviewResult struct {
+	types.ImportPreview
+	Failed int        `json:"failed"`
+	Errors []RowError `json:"errors,omitempty"`
+}
+
+// Handler returns the job handler for import jobs.
+//
+// A file that can't be parsed at all (bad header, broken JSON, more than maxRows rows) fails
+// permanently, having imported nothing: the whole file is read once before anything is
+// inserted. Invalid rows are reported in the result. Valid rows are inserted in batches, each
+// committed with the job's progress; a retried job skips the rows before its checkpoint, and rows
+// whose email a student already has are skipped too, so no row is imported twice. Storages that
+// can't checkpoint get every row in a single transaction instead. The spooled file is removed
+// once the job no longer needs it.
+//
+// A preview job stages the valid rows instead, replacing any a failed run left behind, and a
+// confirm job imports them in checkpointed batches like an upload, skipping the emails taken by
+// then, and discards them.
+func Handler(store storage.Storage, clk clock.Clock, maxRows int) jobs.Handler {
+	return func(ctx context.Context, job types.Job) (any, error) {
+		var p Payload
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+		if p.Confirm != 0 {
+			return confirm(ctx, store, job.ID, p.Confirm)
+		}
+
+		f, err := os.Open(p.Path)
+		if err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("opening upload: %w", err))
+		}
+		defer f.Close()
+
+		if p.Preview {
+			return stage(ctx, store, job.ID, f, p, clk, maxRows)
+		}
+
+		var result Result
+		if im, ok := storage.As[storage.ImportCheckpointer](store); ok {
+			result, err = importBatches(ctx, im, job.ID, f, p, clk, maxRows)
+		} else {
+			result, err = importAll(ctx, store, f, p, clk, maxRows)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.Remove(p.Path); err != nil {
+			slog.Warn("Error removing import upload", "path", p.Path, "error", err)
+		}
+		slog.Info("Students imported", "job_id", job.ID, "imported", result.Imported, "skipped", result.Skipped, "failed", result.Failed)
+		return result, nil
+	}
+}
+
+// importAll inserts every valid row of f in one transaction. An unreadable file is removed;
+// after a transient (database) failure it is kept for the retry.
+func importAll(ctx context.Context, store storage.Storage, f io.Reader, p Payload, clk clock.Clock, maxRows int) (Result, error) {
+	result, students, err := parse(f, p, clk, maxRows)
+	if err != nil {
+		os.Remove(p.Path)
+		return result, jobs.Permanent(err)
+	}
+	if len(students) > 0 {
+		if _, err := store.CreateStudents(ctx, students); err != nil {
+			return result, err
+		}
+	}
+	result.Imported = len(students)
+	return result, nil
+}
+
+// importBatches checks that f can be read to the end, then inserts its valid rows after the
+// job's checkpoint in batches of batchSize, each committed with the job's new progress. Like
+// importAll, it removes an unreadable file and keeps the file after any other failure.
+func importBatches(ctx context.Context, im storage.ImportCheckpointer, jobID int64, f io.ReadSeeker, p Payload, clk clock.Clock, maxRows int) (Result, error) {
+	if _, err := scan(f, p, clk, maxRows, func(int, types.Student) error { return nil }); err != nil {
+		os.Remove(p.Path)
+		return Result{}, jobs.Permanent(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return Result{}, err
+	}
+
+	progress, err := im.ImportProgress(ctx, jobID)
+	if err != nil {
+		return Result{}, err
+	}
+	if progress.Row > 0 {
+		slog.Info("Resuming student import", "job_id", jobID, "after_row", progress.Row, "imported", progress.Imported)
+	}
+
+	var (
+		batch []types.Student
+		last  int
+	)
+	commit := func() error {
+		// Stopping between batches loses nothing; the next run starts after this checkpoint
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		progress, _, err = im.ImportBatch(ctx, jobID, batch, last)
+		batch = batch[:0]
+		return err
+	}
+	// Rows were checked above, so any error now is the storage's (or a shutdown)
+	result, err := scan(f, p, clk, maxRows, func(row int, s types.Student) error {
+		if row <= progress.Row {
+			return nil
+		}
+		batch, last = append(batch, s), row
+		if len(batch) < batchSize {
+			return nil
+		}
+		return commit()
+	})
+	if err == nil && len(batch) > 0 {
+		err = commit()
+	}
+	if err != nil {
+		return result, err
+	}
+	result.Imported, result.Skipped = progress.Imported, progress.Skipped
+	return result, nil
+}
+
+// stage loads f's valid rows into the staging table in batches and previews them. The upload
+// is removed once its rows are staged; an unreadable one is removed too, with nothing staged.
+func stage(ctx context.Context, store storage.Storage, jobID int64, f io.Reader, p Payload, clk clock.Clock, maxRows int) (PreviewResult, error) {
+	st, ok := storage.As[storage.ImportStager](store)
+	if !ok {
+		os.Remove(p.Path)
+		return PreviewResult{}, jobs.Permanent(errors.New("storage does not support staged imports"))
+	}
+	// A run that failed part way left some rows staged
+	if err := st.DiscardImport(ctx, jobID); err != nil && !errors.Is(err, storage.ErrNothingStaged) {
+		return PreviewResult{}, err
+	}
+
+	var (
+		batch    []types.StagedStudent
+		stageErr error
+	)
+	flush := func() error {
+		stageErr = st.StageImport(ctx, jobID, batch)
+		batch = batch[:0]
+		return stageErr
+	}
+	result, err := scan(f, p, clk, maxRows, func(row int, s types.Student) error {
+		batch = append(batch, types.Stage(row, s))
+		if len(batch) < batchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+	if stageErr != nil {
+		// Retried: the next run discards what was staged and starts over
+		return PreviewResult{}, stageErr
+	}
+	if err != nil {
+		os.Remove(p.Path)
+		if derr := st.DiscardImport(ctx, jobID); derr != nil && !errors.Is(derr, storage.ErrNothingStaged) {
+			slog.Warn("Error discarding staged import", "job_id", jobID, "error", derr)
+		}
+		return PreviewResult{}, jobs.Permanent(err)
+	}
+
+	preview, err := st.PreviewImport(ctx, jobID, maxReportedErrors)
+	if errors.Is(err, storage.ErrNothingStaged) {
+		// Every row failed validation
+		preview, err = types.ImportPreview{Create: []types.StagedStudent{}, Duplicate: []types.ImportConflict{}, Conflict: []types.ImportConflict{}}, nil
+	}
+	if err != nil {
+		return PreviewResult{}, err
+	}
+	if err := os.Remove(p.Path); err != nil {
+		slog.Warn("Error removing import upload", "path", p.Path, "error", err)
+	}
+	slog.Info("Student import staged", "job_id", jobID, "new", preview.New, "duplicates", preview.Duplicates, "conflicts", preview.Conflicts, "failed", result.Failed)
+	return PreviewResult{ImportPreview: preview, Failed: result.Failed, Errors: result.Errors}, nil
+}
+
+// confirm imports preview job previewID's staged rows in batches of batchSize, each committed
+// with job jobID's progress, then discards them
+func confirm(ctx context.Context, store storage.Storage, jobID, previewID int64) (Result, error) {
+	st, ok := storage.As[storage.ImportStager](store)
+	im, ok2 := storage.As[storage.ImportCheckpointer](store)
+	if !ok || !ok2 {
+		return Result{}, jobs.Permanent(errors.New("storage does not support staged imports"))
+	}
+
+	progress, err := im.ImportProgress(ctx, jobID)
+	if err != nil {
+		return Result{}, err
+	}
+	for {
+		rows, err := st.StagedRows(ctx, previewID, progress.Row, batchSize)
+		if err != nil {
+			return Result{}, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		// Stopping between batches loses nothing; the next run starts after this checkpoint
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		students := make([]types.Student, len(rows))
+		for i, r := range rows {
+			students[i] = r.Student()
+		}
+		if progress, _, err = im.ImportBatch(ctx, jobID, students, rows[len(rows)-1].Row); err != nil {
+			return Result{}, err
+		}
+	}
+
+	if err := st.DiscardImport(ctx, previewID); err != nil && !errors.Is(err, storage.ErrNothingStaged) {
+		return Result{}, err
+	}
+	slog.Info("Staged students imported", "job_id", jobID, "preview_job_id", previewID, "imported", progress.Imported, "skipped", progress.Skipped)
+	return Result{Imported: progress.Imported, Skipped: progress.Skipped}, nil
+}
+
+// parse reads and validates every row, returning the valid students and a report of the rest
+func parse(r io.Reader, p Payload, clk clock.Clock, maxRows int) (Result, []types.Student, error) {
+	var students []types.Student
+	result, err := scan(r, p, clk, maxRows, func(_ int, s types.Student) error {
+		students = append(students, s)
+		return nil
+	})
+	return result, students, err
+}
+
+// scan reads and validates every row, passing the valid students to emit in row order and
+// reporting the rest; an error from emit stops the scan and is returned as is
+func scan(r io.Reader, p Payload, clk clock.Clock, maxRows int, emit func(row int, s types.Student) error) (Result, error) {
+	var (
+		result Result
+		now    = clock.OrReal(clk).Now()
+		trans  = validation.Translator(p.Lang)
+	)
+
+	reject := func(row int, msg string) {
+		result.Failed++
+		if len(result.Errors) < maxReportedErrors {
+			result.Errors = append(result.Errors, RowError{Row: row, Error: msg})
+		}
+	}
+
+	accept := func(row int, s types.Student) error {
+		if row > maxRows {
+			return fmt.Errorf("file has more than %d rows", maxRows)
+		}
+		if err := studentsvc.Prepare(&s, now); err != nil {
+			var verrs validator.ValidationErrors
+			if !errors.As(err, &verrs) {
+				reject(row, err.Error())
+				return nil
+			}
+			msgs := make([]string, 0, len(verrs))
+			for _, fe := range verrs {
+				msgs = append(msgs, fe.Translate(trans))
+			}
+			reject(row, strings.Join(msgs, "; "))
+			return nil
+		}
+		return emit(row, s)
+	}
+
+	var err error
+	switch p.Format {
+	case FormatCSV:
+		err = readCSV(r, accept, reject)
+	case FormatJSON:
+		err = readJSON(r, accept, reject)
+	default:
+		err = fmt.Errorf("unsupported format %q", p.Format)
+	}
+	return result, err
+}
+
+// csvColumns are the accepted CSV header names; name and email are required
+var csvColumns = map[string]bool{"name": true, "email": true, "age": true, "date_of_birth": true, "phone": true}
+
+func readCSV(r io.Reader, accept func(int, types.Student) error, reject func(int, string)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // row length is checked per row so one bad row doesn't stop the import
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("reading CSV header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		// Spreadsheet exports often start with a UTF-8 byte order mark
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if !csvColumns[h] {
+			return fmt.Errorf("unknown CSV column %q", h)
+		}
+		cols[h] = i
+	}
+	if _, ok := cols["name"]; !ok {
+		return errors.New(`CSV header must include "name"`)
+	}
+	if _, ok := cols["email"]; !ok {
+		return errors.New(`CSV header must include "email"`)
+	}
+
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			// Malformed quoting leaves the reader unable to find the next row reliably
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		if err != nil {
+			return err
+		}
+		if len(record) != len(header) {
+			reject(row, fmt.Sprintf("expected %d fields, got %d", len(header), len(record)))
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := cols[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		s := types.Student{
+			Name:        field("name"),
+			Email:       field("email"),
+			DateOfBirth: field("date_of_birth"),
+			Phone:       field("phone"),
+		}
+		if v := field("age"); v != "" {
+			if s.Age, err = strconv.Atoi(v); err != nil {
+				reject(row, fmt.Sprintf("age %q is not a number", v))
+				continue
+			}
+		}
+		if err := accept(row, s); err != nil {
+			return err
+		}
+	}
+}
+
+// readJSON streams a JSON array of students without decoding the whole array at once
+func readJSON(r io.Reader, accept func(int, types.Student) error, reject func(int, string)) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return errors.New("JSON import must be an array of students")
+	}
+
+	for row := 1; dec.More(); row++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		var s types.Student
+		if err := json.Unmarshal(raw, &s); err != nil {
+			reject(row, err.Error())
+			continue
+		}
+		s.ID, s.PublicID = 0, ""
+		if err := accept(row, s); err != nil {
+			return err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("reading end of array: %w", err)
+	}
+	return nil
+}
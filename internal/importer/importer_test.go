@@ -0,0 +1,165 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var fixedClock = clock.NewFake(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC))
+
+func TestParseCSV(t *testing.T) {
+	input := "\ufeffEmail,Name,date_of_birth\n" +
+		"a@example.com,Asha,2000-01-01\n" +
+		"not-an-email,Bad,2000-01-01\n" +
+		"b@example.com,Short\n" +
+		"c@example.com,Chen,2001-02-03\n"
+
+	result, students, err := parse(strings.NewReader(input), Payload{Format: FormatCSV, Lang: "en"}, fixedClock, 100)
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+	if len(students) != 2 || students[0].Name != "Asha" || students[0].Age != 26 || students[1].Name != "Chen" {
+		t.Fatalf("students = %+v", students)
+	}
+	if result.Failed != 2 || result.Errors[0].Row != 2 || result.Errors[1].Row != 3 {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	input := `[{"name":"Asha","email":"a@example.com","age":20},{"name":"NoEmail","age":20},{"name":"Id","email":"i@example.com","age":21,"id":"7c9e6679-7425-40de-944b-e07fc1f90ae7"}]`
+
+	result, students, err := parse(strings.NewReader(input), Payload{Format: FormatJSON, Lang: "en"}, fixedClock, 100)
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+	if len(students) != 2 || students[1].PublicID != "" {
+		t.Fatalf("students = %+v, want 2 with client IDs ignored", students)
+	}
+	if result.Failed != 1 || result.Errors[0].Row != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestParseRejectsUnreadableFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		maxRows int
+	}{
+		{"unknown column", FormatCSV, "name,email,grade\nA,a@example.com,5\n", 10},
+		{"missing email column", FormatCSV, "name,age\nA,20\n", 10},
+		{"not an array", FormatJSON, `{"name":"A"}`, 10},
+		{"truncated array", FormatJSON, `[{"name":"A","email":"a@example.com","age":20}`, 10},
+		{"too many rows", FormatCSV, "name,email,age\nA,a@example.com,20\nB,b@example.com,20\n", 1},
+		{"unknown format", "xml", "<students/>", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := parse(strings.NewReader(tt.input), Payload{Format: tt.format}, fixedClock, tt.maxRows); err == nil {
+				t.Fatal("parse() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestHandlerResumesFromCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upload.csv")
+	input := "name,email,age\n" +
+		"Asha,a@example.com,20\n" +
+		"Bad,not-an-email,20\n" +
+		"Chen,c@example.com,21\n" +
+		"Dev,d@example.com,22\n" +
+		"Eve,EVE@example.com,23\n"
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(Payload{Path: path, Format: FormatCSV, Lang: "en"})
+	job := types.Job{ID: 7, Payload: payload}
+
+	// An earlier attempt committed rows 1-3 and then died; Eve was created in the meantime
+	store := storagetest.NewFake()
+	ctx := context.Background()
+	if _, _, err := store.ImportBatch(ctx, job.ID, []types.Student{{Name: "Asha", Email: "a@example.com", Age: 20}, {Name: "Chen", Email: "c@example.com", Age: 21}}, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateStudent(types.Student{Name: "Eve", Email: "eve@example.com", Age: 23}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Handler(store, fixedClock, 100)(ctx, job)
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+	result := got.(Result)
+	if result.Imported != 3 || result.Skipped != 1 || result.Failed != 1 || result.Errors[0].Row != 2 {
+		t.Errorf("result = %+v, want 3 imported (2 before the crash), 1 skipped, row 2 failed", result)
+	}
+	if n, _ := store.GetStudentsCount(); n != 4 {
+		t.Errorf("students = %d, want Asha, Chen, Eve and Dev", n)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("upload still exists after the import: %v", err)
+	}
+}
+
+func TestPreviewThenConfirm(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upload.csv")
+	input := "name,email,age\n" +
+		"Asha,a@example.com,20\n" +
+		"Bad,not-an-email,20\n" +
+		"Chen,c@example.com,21\n" +
+		"Chen again,C@example.com,21\n" +
+		"Eve,eve@example.com,23\n"
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := storagetest.NewFake()
+	ctx := context.Background()
+	if _, err := store.CreateStudent(types.Student{Name: "Eve", Email: "EVE@example.com", Age: 23}); err != nil {
+		t.Fatal(err)
+	}
+	handle := Handler(store, fixedClock, 100)
+
+	payload, _ := json.Marshal(Payload{Path: path, Format: FormatCSV, Lang: "en", Preview: true})
+	got, err := handle(ctx, types.Job{ID: 7, Payload: payload})
+	if err != nil {
+		t.Fatalf("Handler(preview) error = %v", err)
+	}
+	preview := got.(PreviewResult)
+	if preview.Staged != 4 || preview.New != 2 || preview.Duplicates != 1 || preview.Conflicts != 1 || preview.Failed != 1 {
+		t.Errorf("preview = %+v, want 4 staged: 2 new, 1 duplicate, 1 conflict; 1 failed", preview)
+	}
+	if len(preview.Duplicate) != 1 || preview.Duplicate[0].FirstRow != 3 {
+		t.Errorf("duplicates = %+v, want row 4 repeating row 3", preview.Duplicate)
+	}
+	if n, _ := store.GetStudentsCount(); n != 1 {
+		t.Fatalf("students = %d after a preview, want 1", n)
+	}
+
+	// Chen signs up before the confirmation, so only Asha is left to import
+	if _, err := store.CreateStudent(types.Student{Name: "Chen", Email: "c@example.com", Age: 21}); err != nil {
+		t.Fatal(err)
+	}
+	payload, _ = json.Marshal(Payload{Lang: "en", Confirm: 7})
+	got, err = handle(ctx, types.Job{ID: 8, Payload: payload})
+	if err != nil {
+		t.Fatalf("Handler(confirm) error = %v", err)
+	}
+	if result := got.(Result); result.Imported != 1 || result.Skipped != 3 {
+		t.Errorf("result = %+v, want 1 imported, 3 skipped", result)
+	}
+	if rows, _ := store.StagedRows(ctx, 7, 0, 10); len(rows) != 0 {
+		t.Errorf("staged rows left after confirming: %+v", rows)
+	}
+}
@@ -0,0 +1,109 @@
+// Package inflight keeps track of the HTTP requests being served, so an operator can see what is
+// stuck (a slow import upload, an export that never finishes) and cancel it.
+package inflight
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+// ErrCancelled is the cause of the context of a request cancelled through the registry
+var ErrCancelled = errors.New("request cancelled by an administrator")
+
+// Request describes one request in flight
+type Request struct {
+	ID         string    `json:"id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	StartedAt  time.Time `json:"started_at"`
+	RunningFor string    `json:"running_for"`
+	Cancelled  bool      `json:"cancelled"`
+}
+
+// Registry holds the requests in flight. The zero value is not usable; call New.
+type Registry struct {
+	// Clock stamps requests and ages them; nil means the system clock
+	Clock clock.Clock
+
+	mu       sync.Mutex
+	requests map[*entry]struct{}
+}
+
+type entry struct {
+	id, method, path string
+	start            time.Time
+	cancel           context.CancelCauseFunc
+	cancelled        bool
+}
+
+// New returns an empty registry
+func New() *Registry {
+	return &Registry{requests: make(map[*entry]struct{})}
+}
+
+// Track registers r under id until done is called, and returns the context r must be served
+// with: Cancel cancels it.
+func (g *Registry) Track(r *http.Request, id string) (ctx context.Context, done func()) {
+	ctx, cancel := context.WithCancelCause(r.Context())
+	e := &entry{
+		id:     id,
+		method: r.Method,
+		path:   r.URL.Path,
+		start:  clock.OrReal(g.Clock).Now(),
+		cancel: cancel,
+	}
+	g.mu.Lock()
+	g.requests[e] = struct{}{}
+	g.mu.Unlock()
+
+	return ctx, func() {
+		g.mu.Lock()
+		delete(g.requests, e)
+		g.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// List returns the requests in flight, oldest first
+func (g *Registry) List() []Request {
+	now := clock.OrReal(g.Clock).Now()
+	g.mu.Lock()
+	list := make([]Request, 0, len(g.requests))
+	for e := range g.requests {
+		list = append(list, Request{
+			ID:         e.id,
+			Method:     e.method,
+			Path:       e.path,
+			StartedAt:  e.start,
+			RunningFor: now.Sub(e.start).Round(time.Millisecond).String(),
+			Cancelled:  e.cancelled,
+		})
+	}
+	g.mu.Unlock()
+
+	slices.SortFunc(list, func(a, b Request) int { return a.StartedAt.Compare(b.StartedAt) })
+	return list
+}
+
+// Cancel cancels every request in flight with the given ID (a client-supplied ID need not be
+// unique) and reports how many there were. A cancelled request stays listed until its handler
+// returns.
+func (g *Registry) Cancel(id string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := 0
+	for e := range g.requests {
+		if e.id == id {
+			e.cancelled = true
+			e.cancel(ErrCancelled)
+			n++
+		}
+	}
+	return n
+}
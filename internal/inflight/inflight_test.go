@@ -0,0 +1,53 @@
+package inflight
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+func TestRegistry(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC))
+	reg := New()
+	reg.Clock = clk
+
+	_, doneExport := reg.Track(httptest.NewRequest("GET", "/students/export", nil), "dup")
+	clk.Advance(time.Second)
+	ctxImport, doneImport := reg.Track(httptest.NewRequest("POST", "/students/import", nil), "dup")
+	clk.Advance(time.Second)
+	ctxList, doneList := reg.Track(httptest.NewRequest("GET", "/students", nil), "list")
+
+	list := reg.List()
+	if len(list) != 3 || list[0].Path != "/students/export" || list[0].RunningFor != "2s" || list[2].ID != "list" {
+		t.Fatalf("List() = %+v, want export, import, list, oldest first", list)
+	}
+
+	// A client-supplied ID can repeat; both requests go
+	if n := reg.Cancel("dup"); n != 2 {
+		t.Fatalf("Cancel(dup) = %d, want 2", n)
+	}
+	if !errors.Is(context.Cause(ctxImport), ErrCancelled) || ctxList.Err() != nil {
+		t.Fatalf("after Cancel: import cause = %v, list err = %v", context.Cause(ctxImport), ctxList.Err())
+	}
+	if list := reg.List(); !list[0].Cancelled || list[2].Cancelled {
+		t.Errorf("Cancelled flags = %+v, want the two dup requests only", list)
+	}
+
+	doneExport()
+	doneImport()
+	doneList()
+	if list := reg.List(); len(list) != 0 {
+		t.Errorf("List() after every request finished = %+v, want none", list)
+	}
+	// Finishing cancels the context, so anything derived from it stops too
+	if ctxList.Err() == nil {
+		t.Error("finished request's context is still live")
+	}
+	if n := reg.Cancel("list"); n != 0 {
+		t.Errorf("Cancel of a finished request = %d, want 0", n)
+	}
+}
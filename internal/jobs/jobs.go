@@ -0,0 +1,276 @@
+// Package jobs runs persistent background jobs: long-running work (imports, rendering,
+// deliveries) that must survive restarts and be retried on failure.
+//
+// Jobs are stored in a storage.JobQueue. Workers claim due jobs with a lease, run the handler
+// registered for the job's kind, and then mark the job succeeded, requeue it with exponential
+// backoff, or move it to the dead-letter state once its attempts are used up. A job whose worker
+// died is picked up again when its lease expires, so handlers must be safe to run more than once.
+//
+// Unlike internal/workerpool, nothing here is lost on restart; use the pool for cheap best-effort
+// side effects and this package for work a client may poll for via GET /jobs/{id}.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var ErrUnknownKind = errors.New("no handler registered for job kind")
+
+// Handler executes one job. The returned result (if any) is stored as JSON for status polling.
+// ctx is cancelled on shutdown; a handler that stops early should return ctx.Err().
+type Handler func(ctx context.Context, job types.Job) (result any, err error)
+
+// Options tune the runner; zero values take the defaults below
+type Options struct {
+	Workers      int
+	PollInterval time.Duration
+	// Lease is how long a claimed job is reserved for its worker; it must exceed the longest run
+	Lease       time.Duration
+	MaxAttempts int
+	// BackoffBase is the delay before the first retry; it doubles per attempt up to BackoffMax
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = 2
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.Lease <= 0 {
+		o.Lease = 10 * time.Minute
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.BackoffBase <= 0 {
+		o.BackoffBase = 2 * time.Second
+	}
+	if o.BackoffMax <= 0 {
+		o.BackoffMax = 5 * time.Minute
+	}
+	return o
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job goes straight to the dead-letter state (e.g. a malformed payload)
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Runner claims and executes jobs
+type Runner struct {
+	// Clock schedules retries and leases; nil means the system clock
+	Clock clock.Clock
+
+	queue storage.JobQueue
+	opts  Options
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	// ctx is passed to handlers and cancelled only when Stop's deadline passes;
+	// stopping is closed as soon as Stop is called so workers stop claiming
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopping chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func New(queue storage.JobQueue, opts Options) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		queue:    queue,
+		opts:     opts.withDefaults(),
+		handlers: make(map[string]Handler),
+		ctx:      ctx,
+		cancel:   cancel,
+		stopping: make(chan struct{}),
+	}
+}
+
+// Register sets the handler for a job kind. Register every kind before Start.
+func (r *Runner) Register(kind string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[kind] = h
+}
+
+// Enqueue stores a job to run as soon as a worker is free. payload is encoded as JSON.
+func (r *Runner) Enqueue(ctx context.Context, kind string, payload any) (int64, error) {
+	r.mu.RLock()
+	_, ok := r.handlers[kind]
+	r.mu.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("encoding %s job payload: %w", kind, err)
+	}
+	return r.queue.EnqueueJob(ctx, kind, data, r.opts.MaxAttempts, clock.OrReal(r.Clock).Now())
+}
+
+// Start launches the workers
+func (r *Runner) Start() {
+	for i := 0; i < r.opts.Workers; i++ {
+		r.wg.Add(1)
+		go r.worker()
+	}
+}
+
+func (r *Runner) worker() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.stopping:
+			return
+		default:
+		}
+
+		ran, err := r.RunOnce(r.ctx)
+		if err != nil {
+			slog.Error("Error claiming background job", "error", err)
+		}
+		if ran {
+			continue // more work may be waiting
+		}
+
+		select {
+		case <-r.stopping:
+			return
+		case <-time.After(r.opts.PollInterval):
+		}
+	}
+}
+
+// RunOnce claims and executes at most one due job. It reports whether a job ran.
+func (r *Runner) RunOnce(ctx context.Context) (bool, error) {
+	if ctx.Err() != nil {
+		return false, nil
+	}
+
+	clk := clock.OrReal(r.Clock)
+	now := clk.Now()
+	job, err := r.queue.ClaimJob(ctx, now, now.Add(r.opts.Lease))
+	if errors.Is(err, storage.ErrNoJobDue) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	handler, ok := r.handlers[job.Kind]
+	r.mu.RUnlock()
+
+	var result any
+	if !ok {
+		err = Permanent(fmt.Errorf("%w: %s", ErrUnknownKind, job.Kind))
+	} else {
+		result, err = runHandler(ctx, handler, job)
+	}
+
+	// Record the outcome even if ctx was cancelled during shutdown
+	r.finish(context.WithoutCancel(ctx), job, result, err)
+	return true, nil
+}
+
+func (r *Runner) finish(ctx context.Context, job types.Job, result any, runErr error) {
+	now := clock.OrReal(r.Clock).Now()
+	logger := slog.With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	var err error
+	var perm permanentError
+	switch {
+	case runErr == nil:
+		var data []byte
+		if result != nil {
+			if data, err = json.Marshal(result); err != nil {
+				logger.Error("Error encoding job result", "error", err)
+				data = nil
+			}
+		}
+		err = r.queue.CompleteJob(ctx, job.ID, job.Attempts, data, now)
+		logger.Info("Background job succeeded")
+
+	case errors.As(runErr, &perm) || job.Attempts >= job.MaxAttempts:
+		err = r.queue.KillJob(ctx, job.ID, job.Attempts, runErr.Error(), now)
+		logger.Error("Background job moved to dead letter", "error", runErr)
+
+	default:
+		retryAt := now.Add(r.backoff(job.Attempts))
+		err = r.queue.RetryJob(ctx, job.ID, job.Attempts, runErr.Error(), retryAt, now)
+		logger.Warn("Background job failed; will retry", "error", runErr, "retry_at", retryAt)
+	}
+
+	switch {
+	case errors.Is(err, storage.ErrLeaseLost):
+		// Another worker claimed the job after our lease ran out; its outcome is the one that counts
+		logger.Warn("Background job outcome dropped: lease lost", "error", err)
+	case err != nil:
+		// The lease will expire and the job will be claimed again
+		logger.Error("Error recording background job outcome", "error", err)
+	}
+}
+
+// backoff returns the delay before retrying after the given (1-based) attempt
+func (r *Runner) backoff(attempt int) time.Duration {
+	d := r.opts.BackoffBase
+	for i := 1; i < attempt && d < r.opts.BackoffMax; i++ {
+		d *= 2
+	}
+	return min(d, r.opts.BackoffMax)
+}
+
+// runHandler calls a handler, turning a panic into an error so the worker keeps going
+func runHandler(ctx context.Context, h Handler, job types.Job) (result any, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return h(ctx, job)
+}
+
+// Stop stops claiming new jobs and waits for running ones. If ctx expires first, running
+// handlers' contexts are cancelled and Stop returns ctx.Err() without waiting further; those jobs
+// are retried, or picked up again once their lease expires.
+func (r *Runner) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stopping) })
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/sqlite"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func newRunner(t *testing.T, opts Options) (*Runner, *sqlite.Sqlite, *clock.Fake) {
+	t.Helper()
+	db, err := sqlite.NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewSqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	db.Clock = clk
+	r := New(db, opts)
+	r.Clock = clk
+	return r, db, clk
+}
+
+func TestRunnerRetriesWithBackoffThenDeadLetters(t *testing.T) {
+	r, db, clk := newRunner(t, Options{MaxAttempts: 3, BackoffBase: time.Second})
+	ctx := context.Background()
+
+	calls := 0
+	r.Register("flaky", func(ctx context.Context, job types.Job) (any, error) {
+		calls++
+		return nil, errors.New("upstream unavailable")
+	})
+	id, err := r.Enqueue(ctx, "flaky", map[string]string{"k": "v"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	for attempt, wait := range []time.Duration{time.Second, 2 * time.Second} {
+		r.RunOnce(ctx)
+		job, _ := db.GetJob(ctx, id)
+		if job.Status != types.JobQueued || !job.RunAt.Equal(clk.Now().Add(wait)) {
+			t.Fatalf("after attempt %d: status %q run_at %v, want queued at +%v", attempt+1, job.Status, job.RunAt, wait)
+		}
+
+		// Not due until the backoff has passed
+		if ran, _ := r.RunOnce(ctx); ran {
+			t.Fatal("job ran before its backoff elapsed")
+		}
+		clk.Advance(wait)
+	}
+
+	r.RunOnce(ctx)
+	job, _ := db.GetJob(ctx, id)
+	if job.Status != types.JobDead || job.Attempts != 3 || calls != 3 || job.LastError != "upstream unavailable" {
+		t.Fatalf("final job %+v after %d calls, want dead after 3", job, calls)
+	}
+}
+
+func TestRunnerStoresResultAndHonoursPermanentErrors(t *testing.T) {
+	r, db, _ := newRunner(t, Options{})
+	ctx := context.Background()
+
+	r.Register("ok", func(ctx context.Context, job types.Job) (any, error) {
+		return map[string]int{"imported": 2}, nil
+	})
+	r.Register("bad", func(ctx context.Context, job types.Job) (any, error) {
+		return nil, Permanent(errors.New("malformed payload"))
+	})
+
+	okID, _ := r.Enqueue(ctx, "ok", nil)
+	badID, _ := r.Enqueue(ctx, "bad", nil)
+	r.RunOnce(ctx)
+	r.RunOnce(ctx)
+
+	if job, _ := db.GetJob(ctx, okID); job.Status != types.JobSucceeded || string(job.Result) != `{"imported":2}` {
+		t.Fatalf("ok job = %+v", job)
+	}
+	if job, _ := db.GetJob(ctx, badID); job.Status != types.JobDead || job.Attempts != 1 {
+		t.Fatalf("bad job = %+v, want dead after one attempt", job)
+	}
+
+	if _, err := r.Enqueue(ctx, "unregistered", nil); !errors.Is(err, ErrUnknownKind) {
+		t.Fatalf("Enqueue(unregistered) error = %v, want %v", err, ErrUnknownKind)
+	}
+}
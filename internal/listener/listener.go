@@ -0,0 +1,31 @@
+// Package listener creates the HTTP server's listening socket.
+//
+// With reuse_port enabled, several processes can bind the same address at once. That is what makes
+// zero-downtime upgrades on a single host possible: start the new binary, wait for its /readyz, then
+// send SIGTERM to the old one, which drains its in-flight requests while the kernel already routes
+// new connections to the new process.
+package listener
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// ErrReusePortUnsupported is returned when reuse_port is enabled on a platform without SO_REUSEPORT
+var ErrReusePortUnsupported = errors.New("SO_REUSEPORT is not supported on this platform")
+
+// Options controls how the socket is opened
+type Options struct {
+	// ReusePort sets SO_REUSEPORT so another process can bind the same address during an upgrade
+	ReusePort bool
+}
+
+// Listen opens a TCP listener on addr
+func Listen(ctx context.Context, addr string, opts Options) (net.Listener, error) {
+	var lc net.ListenConfig
+	if opts.ReusePort {
+		lc.Control = reusePortControl
+	}
+	return lc.Listen(ctx, "tcp", addr)
+}
@@ -0,0 +1,37 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReusePortAllowsSecondListener(t *testing.T) {
+	first, err := Listen(context.Background(), "127.0.0.1:0", Options{ReusePort: true})
+	if errors.Is(err, ErrReusePortUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer first.Close()
+
+	second, err := Listen(context.Background(), first.Addr().String(), Options{ReusePort: true})
+	if err != nil {
+		t.Fatalf("second Listen() on %s error = %v, want the address to be shared", first.Addr(), err)
+	}
+	second.Close()
+}
+
+func TestWithoutReusePortAddressIsExclusive(t *testing.T) {
+	first, err := Listen(context.Background(), "127.0.0.1:0", Options{})
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer first.Close()
+
+	if second, err := Listen(context.Background(), first.Addr().String(), Options{}); err == nil {
+		second.Close()
+		t.Fatal("second Listen() succeeded, want address in use")
+	}
+}
@@ -0,0 +1,41 @@
+// Package logging sets up the service's slog output.
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceHandler adds the OpenTelemetry trace and span IDs found in a record's context to it as
+// trace_id and span_id, so log lines can be joined to their traces (in Grafana, a derived field
+// on trace_id). Only records logged with a context carry them: use slog.InfoContext(ctx, ...)
+// and friends wherever a request context is at hand.
+type TraceHandler struct {
+	slog.Handler
+}
+
+// NewTraceHandler wraps next
+func NewTraceHandler(next slog.Handler) *TraceHandler {
+	return &TraceHandler{Handler: next}
+}
+
+// Handle implements slog.Handler
+func (h *TraceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r = r.Clone()
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler
+func (h *TraceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &TraceHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h *TraceHandler) WithGroup(name string) slog.Handler {
+	return &TraceHandler{Handler: h.Handler.WithGroup(name)}
+}
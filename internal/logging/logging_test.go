@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/middleware"
+)
+
+func TestTraceHandler(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(NewTraceHandler(slog.NewTextHandler(&out, nil))).With("component", "test")
+
+	h := middleware.TraceContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "handled")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/students", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := out.String()
+	for _, want := range []string{"component=test", "trace_id=4bf92f3577b34da6a3ce929d0e0e4736", "span_id=00f067aa0ba902b7"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("log line %q lacks %s", line, want)
+		}
+	}
+
+	// Without a trace there is nothing to add
+	out.Reset()
+	logger.InfoContext(context.Background(), "untraced")
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/students", nil))
+	if strings.Contains(out.String(), "trace_id") {
+		t.Errorf("untraced log lines %q carry a trace_id", out.String())
+	}
+}
@@ -0,0 +1,176 @@
+// Package mail sends templated notification emails in the background.
+//
+// Messages are rendered from the templates in templates/ and handed to the worker pool, which
+// retries delivery with backoff. The transport is SMTP in production and "log" in development,
+// which prints the message instead of sending it.
+package mail
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/events"
+	"github.com/prashantkumbhar2002/go_students_api/internal/workerpool"
+)
+
+// Template names (files in templates/ without the .tmpl extension)
+const (
+	TemplateWelcome = "welcome"
+	TemplateOverdue = "overdue"
+	// TemplateAnnouncement takes a Name, Subject and Body
+	TemplateAnnouncement = "announcement"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// templates maps template names to their parsed files. Each file is parsed on its own, since every
+// one defines the same "subject" and "body" blocks.
+var templates = parseTemplates()
+
+func parseTemplates() map[string]*template.Template {
+	files, err := fs.Glob(templateFS, "templates/*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+	parsed := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		parsed[strings.TrimSuffix(path.Base(file), ".tmpl")] = template.Must(template.ParseFS(templateFS, file))
+	}
+	return parsed
+}
+
+// Message is a rendered plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Transport delivers one message
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogTransport logs messages instead of sending them (development)
+type LogTransport struct{}
+
+func (LogTransport) Send(ctx context.Context, msg Message) error {
+	slog.Info("Email (log transport, not sent)", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// Render executes the named template's "subject" and "body" blocks with data
+func Render(name, to string, data any) (Message, error) {
+	t, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, body strings.Builder
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("rendering %s subject: %w", name, err)
+	}
+	if err := t.ExecuteTemplate(&body, "body", data); err != nil {
+		return Message{}, fmt.Errorf("rendering %s body: %w", name, err)
+	}
+	return Message{To: to, Subject: strings.TrimSpace(subject.String()), Body: body.String()}, nil
+}
+
+// Mailer renders messages and delivers them on the worker pool
+type Mailer struct {
+	transport   Transport
+	pool        *workerpool.Pool
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// New returns a Mailer. Each message is tried up to maxAttempts times, waiting backoff,
+// then twice that, and so on between attempts.
+func New(transport Transport, pool *workerpool.Pool, maxAttempts int, backoff time.Duration) *Mailer {
+	return &Mailer{
+		transport:   transport,
+		pool:        pool,
+		maxAttempts: max(maxAttempts, 1),
+		backoff:     backoff,
+	}
+}
+
+// Send renders the template and queues delivery. It only fails if rendering fails or the
+// pool rejects the task; delivery errors are logged by the pool.
+func (m *Mailer) Send(to, templateName string, data any) error {
+	msg, err := Render(templateName, to, data)
+	if err != nil {
+		return err
+	}
+	return m.pool.Submit("mail:"+templateName, func(ctx context.Context) error {
+		return m.deliver(ctx, msg)
+	})
+}
+
+// Deliver renders the template and sends it now, retrying like Send. It is for callers that
+// already run in the background, such as a job, and need to know whether the message went out.
+func (m *Mailer) Deliver(ctx context.Context, to, templateName string, data any) error {
+	msg, err := Render(templateName, to, data)
+	if err != nil {
+		return err
+	}
+	return m.deliver(ctx, msg)
+}
+
+// Subscribe sends the welcome email to every student created one at a time, and a reminder for
+// every overdue loan. Bulk creates and imports get no welcome email: a class list of hundreds would
+// flood the pool and the school's inbox quota.
+// Best effort: the student exists either way, so a full mail queue is only logged.
+func (m *Mailer) Subscribe(bus events.Subscriber) {
+	bus.Subscribe("welcome email", func(ctx context.Context, e events.Event) {
+		if e.Bulk {
+			return
+		}
+		for _, st := range e.Students {
+			if err := m.Send(st.Email, TemplateWelcome, st); err != nil {
+				slog.Warn("Could not queue welcome email", "id", st.PublicID, "error", err)
+			}
+		}
+	}, events.StudentCreated)
+
+	// Reminders for overdue library books; the overdue_loans schedule decides how often they repeat
+	bus.Subscribe("overdue reminder", func(ctx context.Context, e events.Event) {
+		for _, loan := range e.Loans {
+			if err := m.Send(loan.StudentEmail, TemplateOverdue, loan); err != nil {
+				slog.Warn("Could not queue overdue reminder", "student", loan.StudentPublicID, "book", loan.BookPublicID, "error", err)
+			}
+		}
+	}, events.LoanOverdue)
+}
+
+// deliver tries the transport until it succeeds, attempts run out, or ctx is cancelled
+func (m *Mailer) deliver(ctx context.Context, msg Message) error {
+	wait := m.backoff
+	var err error
+	for attempt := 1; attempt <= m.maxAttempts; attempt++ {
+		if err = m.transport.Send(ctx, msg); err == nil {
+			return nil
+		}
+		if attempt == m.maxAttempts {
+			break
+		}
+		slog.Warn("Email delivery failed; retrying", "to", msg.To, "subject", msg.Subject, "attempt", attempt, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	return fmt.Errorf("sending %q to %s after %d attempts: %w", msg.Subject, msg.To, m.maxAttempts, err)
+}
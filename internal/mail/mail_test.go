@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/workerpool"
+)
+
+func TestRenderWelcome(t *testing.T) {
+	msg, err := Render(TemplateWelcome, "asha@example.com", types.Student{PublicID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Name: "Asha", Email: "asha@example.com"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "Welcome, Asha!" || !strings.Contains(msg.Body, "Student ID: 7c9e6679-7425-40de-944b-e07fc1f90ae7") {
+		t.Fatalf("Render() = %+v", msg)
+	}
+
+	if _, err := Render("missing", "a@example.com", nil); err == nil {
+		t.Fatal("Render() of unknown template succeeded")
+	}
+}
+
+func TestRenderOverdue(t *testing.T) {
+	msg, err := Render(TemplateOverdue, "asha@example.com", types.Loan{Title: "Malgudi Days", StudentName: "Asha", DueDate: "2026-03-01", DaysOverdue: 4})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "Library book overdue: Malgudi Days" || !strings.Contains(msg.Body, "due back on 2026-03-01") ||
+		!strings.Contains(msg.Body, "Days overdue: 4") {
+		t.Fatalf("Render() = %+v", msg)
+	}
+}
+
+func TestRenderAnnouncement(t *testing.T) {
+	data := struct{ Name, Subject, Body string }{"Asha", "School closed on Friday", "The school is closed for the festival."}
+	msg, err := Render(TemplateAnnouncement, "asha@example.com", data)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "School closed on Friday" || !strings.HasPrefix(msg.Body, "Hello Asha,") || !strings.Contains(msg.Body, "closed for the festival") {
+		t.Fatalf("Render() = %+v", msg)
+	}
+}
+
+// flakyTransport fails the first failures sends
+type flakyTransport struct {
+	mu       sync.Mutex
+	failures int
+	sent     []Message
+	done     chan struct{}
+}
+
+func (f *flakyTransport) Send(ctx context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("connection refused")
+	}
+	f.sent = append(f.sent, msg)
+	close(f.done)
+	return nil
+}
+
+func TestMailerRetriesDelivery(t *testing.T) {
+	transport := &flakyTransport{failures: 2, done: make(chan struct{})}
+	pool := workerpool.New(1, 1)
+	defer pool.Shutdown(context.Background())
+
+	m := New(transport, pool, 3, time.Millisecond)
+	if err := m.Send("asha@example.com", TemplateWelcome, types.Student{Name: "Asha"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case <-transport.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not delivered after retries")
+	}
+	if len(transport.sent) != 1 || transport.sent[0].To != "asha@example.com" {
+		t.Fatalf("sent = %+v", transport.sent)
+	}
+}
+
+func TestSMTPFormatPreventsHeaderInjection(t *testing.T) {
+	tr := &SMTPTransport{From: "no-reply@example.com"}
+	raw := string(tr.format(Message{To: "a@example.com", Subject: "Welcome\r\nBcc: evil@example.com", Body: "line1\nline2\n"}))
+
+	if strings.Contains(raw, "\r\nBcc:") {
+		t.Fatalf("subject injected a header:\n%s", raw)
+	}
+	if !strings.Contains(raw, "\r\n\r\nline1\r\nline2\r\n") {
+		t.Fatalf("body not CRLF-normalised:\n%q", raw)
+	}
+}
@@ -0,0 +1,88 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// DefaultRegion is used to interpret numbers written in national format (without a "+")
+var DefaultRegion = "IN"
+
+var (
+	ErrInvalidNumber = errors.New("invalid phone number")
+	ErrUnknownRegion = errors.New("unknown phone region")
+)
+
+// Normalize converts a human-entered phone number into E.164 ("+919876543210").
+// Spaces, dashes, dots and parentheses are ignored; "00" is treated as the international prefix.
+// Numbers without a country code are interpreted in defaultRegion (DefaultRegion if empty).
+// The number must be valid in its country's numbering plan (libphonenumber's metadata), so a
+// known calling code with too few or too many digits is rejected.
+func Normalize(raw, defaultRegion string) (string, error) {
+	if defaultRegion == "" {
+		defaultRegion = DefaultRegion
+	}
+	defaultRegion = strings.ToUpper(defaultRegion)
+
+	digits, international, err := strip(raw)
+	if err != nil {
+		return "", err
+	}
+	if international {
+		digits = "+" + digits
+	} else if phonenumbers.GetCountryCodeForRegion(defaultRegion) == 0 {
+		return "", ErrUnknownRegion
+	}
+
+	num, err := phonenumbers.Parse(digits, defaultRegion)
+	if err != nil || !phonenumbers.IsValidNumber(num) {
+		return "", ErrInvalidNumber
+	}
+	return phonenumbers.Format(num, phonenumbers.E164), nil
+}
+
+// Valid reports whether raw can be normalised in defaultRegion
+func Valid(raw, defaultRegion string) bool {
+	_, err := Normalize(raw, defaultRegion)
+	return err == nil
+}
+
+// strip removes formatting characters and reports whether the number carried an international prefix.
+// Anything else (letters, extensions) is rejected rather than left to the parser to interpret.
+func strip(raw string) (digits string, international bool, err error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return "", false, ErrInvalidNumber
+	}
+
+	if strings.HasPrefix(s, "+") {
+		international = true
+		s = s[1:]
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			// formatting only
+		default:
+			return "", false, ErrInvalidNumber
+		}
+	}
+	digits = b.String()
+
+	// Whatever the default region dials abroad with, "00" means the same as "+"
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	if digits == "" {
+		return "", false, ErrInvalidNumber
+	}
+	return digits, international, nil
+}
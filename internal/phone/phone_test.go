@@ -0,0 +1,45 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		region  string
+		want    string
+		wantErr error
+	}{
+		{"national mobile", "98765 43210", "", "+919876543210", nil},
+		{"national with trunk prefix", "098765-43210", "", "+919876543210", nil},
+		{"national landline", "(022) 2345 6789", "IN", "+912223456789", nil},
+		{"national in another region", "(202) 555-0143", "us", "+12025550143", nil},
+		{"international", "+91 98765 43210", "", "+919876543210", nil},
+		{"international elsewhere", "+44 20 7946 0958", "", "+442079460958", nil},
+		{"international outside the old table", "+49 30 901820", "", "+4930901820", nil},
+		{"00 prefix", "0091 98765 43210", "", "+919876543210", nil},
+		{"00 prefix from a region dialling 011", "0044 20 7946 0958", "US", "+442079460958", nil},
+
+		{"known code, too short", "+91 12345678", "", "", ErrInvalidNumber},
+		{"known code, too long", "+91 98765 432101", "", "", ErrInvalidNumber},
+		{"US exchange too short", "+1 555 0100", "", "", ErrInvalidNumber},
+		{"national too short", "98765", "", "", ErrInvalidNumber},
+		{"unassigned calling code", "+999 1234 5678", "", "", ErrInvalidNumber},
+		{"letters", "+91 98765 CALL1", "", "", ErrInvalidNumber},
+		{"empty", "  ", "", "", ErrInvalidNumber},
+		{"only a prefix", "+", "", "", ErrInvalidNumber},
+		{"unknown region", "98765 43210", "XX", "", ErrUnknownRegion},
+		{"unknown region, international", "+91 98765 43210", "XX", "+919876543210", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.raw, tt.region)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Normalize(%q, %q) = %q, %v; want %q, %v", tt.raw, tt.region, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
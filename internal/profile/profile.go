@@ -0,0 +1,175 @@
+// Package profile renders printable student profiles: one A4 page per student, served directly
+// for a single student (GET /students/{id}/profile.pdf) or rendered by a background job when a
+// whole class is printed at once (POST /students/profiles).
+//
+// A profile shows what the service stores about a student. Photos, guardians and enrollments
+// aren't part of the data model yet; they get their own blocks on the page once they are.
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Kind is the job kind batch renders are enqueued under
+const Kind = "profile_batch"
+
+// MaxBatch caps the students in one batch; a section or a small school fits comfortably
+const MaxBatch = 500
+
+// ContentType is the media type of rendered profiles
+const ContentType = "application/pdf"
+
+// Renderer lays out profiles
+type Renderer struct {
+	// Font is a TrueType font file covering the scripts names are written in (e.g. Noto Sans).
+	// "" uses the built-in Helvetica, which only has Latin-1: other characters print as "?".
+	Font string
+	// Clock dates the "Generated" footer; nil means the system clock
+	Clock clock.Clock
+}
+
+// Render writes one page per student, in order, as a single PDF
+func (rd Renderer) Render(w io.Writer, students []types.Student) error {
+	now := clock.OrReal(rd.Clock).Now()
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(now)
+	pdf.SetCreator("go_students_api", false)
+	pdf.SetAutoPageBreak(false, 0)
+
+	family, tr := "Helvetica", pdf.UnicodeTranslatorFromDescriptor("")
+	if rd.Font != "" {
+		family, tr = "profile", func(s string) string { return s }
+		pdf.AddUTF8Font(family, "", rd.Font)
+		pdf.AddUTF8Font(family, "B", rd.Font)
+	}
+
+	for _, s := range students {
+		s.DeriveAge(now)
+		pdf.AddPage()
+
+		pdf.SetFont(family, "B", 20)
+		pdf.CellFormat(0, 12, tr("Student Profile"), "B", 1, "L", false, 0, "")
+		pdf.Ln(6)
+
+		pdf.SetFont(family, "B", 16)
+		pdf.CellFormat(0, 10, tr(s.Name), "", 1, "L", false, 0, "")
+		pdf.Ln(4)
+
+		for _, row := range details(s) {
+			pdf.SetFont(family, "B", 11)
+			pdf.CellFormat(45, 8, tr(row[0]), "", 0, "L", false, 0, "")
+			pdf.SetFont(family, "", 11)
+			pdf.CellFormat(0, 8, tr(row[1]), "", 1, "L", false, 0, "")
+		}
+
+		pdf.SetY(-20)
+		pdf.SetFont(family, "", 8)
+		pdf.CellFormat(0, 5, tr("Generated "+now.UTC().Format("2006-01-02 15:04 MST")), "T", 0, "R", false, 0, "")
+	}
+	return pdf.Output(w)
+}
+
+// details are the label/value rows of the details block; unknown values print as "-"
+func details(s types.Student) [][2]string {
+	or := func(v string) string {
+		if v == "" {
+			return "-"
+		}
+		return v
+	}
+	age := "-"
+	if s.Age > 0 {
+		age = strconv.Itoa(s.Age)
+	}
+	return [][2]string{
+		{"Student ID", s.PublicID},
+		{"Email", or(s.Email)},
+		{"Phone", or(s.Phone)},
+		{"Date of birth", or(s.DateOfBirth)},
+		{"Age", age},
+	}
+}
+
+// Payload is the job payload of a batch render
+type Payload struct {
+	// IDs are the students' public IDs, in print order
+	IDs []string `json:"ids"`
+	// Path is where the PDF is written. The file is created when the job is queued, so a
+	// retry overwrites it and concurrent batches never share one.
+	Path string `json:"path"`
+}
+
+// Result is stored as the job result
+type Result struct {
+	Pages int `json:"pages"`
+	// Missing lists requested students that no longer exist; they get no page
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Handler returns the job handler for batch renders. The PDF is written to a temporary file
+// and renamed into place, so a download never sees a half-written file.
+func Handler(store storage.Storage, rd Renderer) jobs.Handler {
+	return func(ctx context.Context, job types.Job) (any, error) {
+		var p Payload
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+
+		var result Result
+		students := make([]types.Student, 0, len(p.IDs))
+		for _, id := range p.IDs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			s, err := store.GetStudentByPublicID(id)
+			if errors.Is(err, storage.ErrNotFound) {
+				result.Missing = append(result.Missing, id)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			students = append(students, s)
+		}
+		if len(students) == 0 {
+			os.Remove(p.Path)
+			return nil, jobs.Permanent(errors.New("none of the requested students exist"))
+		}
+		result.Pages = len(students)
+
+		tmp := p.Path + ".tmp"
+		f, err := os.Create(tmp)
+		if err != nil {
+			return nil, fmt.Errorf("creating profile file: %w", err)
+		}
+		err = rd.Render(f, students)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp, p.Path)
+		}
+		if err != nil {
+			os.Remove(tmp)
+			return nil, fmt.Errorf("rendering profiles: %w", err)
+		}
+
+		slog.Info("Student profiles rendered", "job_id", job.ID, "pages", result.Pages, "missing", len(result.Missing))
+		return result, nil
+	}
+}
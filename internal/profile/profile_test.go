@@ -0,0 +1,83 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var renderer = Renderer{Clock: clock.NewFake(time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC))}
+
+// pages counts the page objects of a rendered PDF
+func pages(pdf []byte) int {
+	return bytes.Count(pdf, []byte("/Type /Page\n"))
+}
+
+func TestRenderOnePagePerStudent(t *testing.T) {
+	students := []types.Student{
+		{PublicID: types.NewPublicID(), Name: "Asha Rao", Email: "asha@example.com", DateOfBirth: "2005-03-04"},
+		// Outside Latin-1 and missing optional fields: still renders
+		{PublicID: types.NewPublicID(), Name: "आशा", Email: "a@example.com", Age: 20},
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, students); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("output is not a PDF: %q", buf.Bytes()[:min(buf.Len(), 16)])
+	}
+	if n := pages(buf.Bytes()); n != 2 {
+		t.Fatalf("pages = %d, want 2", n)
+	}
+}
+
+func TestHandlerWritesBatch(t *testing.T) {
+	store := storagetest.NewFake()
+	a := types.Student{PublicID: types.NewPublicID(), Name: "A", Email: "a@example.com", Age: 20}
+	b := types.Student{PublicID: types.NewPublicID(), Name: "B", Email: "b@example.com", Age: 21}
+	store.Put(a)
+	store.Put(b)
+	gone := types.NewPublicID()
+
+	path := filepath.Join(t.TempDir(), "profiles.pdf")
+	payload, _ := json.Marshal(Payload{IDs: []string{b.PublicID, gone, a.PublicID}, Path: path})
+	result, err := Handler(store, renderer)(context.Background(), types.Job{ID: 1, Payload: payload})
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+	if r := result.(Result); r.Pages != 2 || len(r.Missing) != 1 || r.Missing[0] != gone {
+		t.Fatalf("result = %+v, want 2 pages and %s missing", r, gone)
+	}
+
+	pdf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := pages(pdf); n != 2 {
+		t.Fatalf("pages = %d, want 2", n)
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("temporary file left behind: %v", err)
+	}
+}
+
+func TestHandlerFailsWithoutStudents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "p.pdf")
+	payload, _ := json.Marshal(Payload{IDs: []string{types.NewPublicID()}, Path: path})
+	if _, err := Handler(storagetest.NewFake(), renderer)(context.Background(), types.Job{ID: 1, Payload: payload}); err == nil {
+		t.Fatal("Handler() error = nil, want an error when no requested student exists")
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("empty batch was written: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Package quota caps how many requests each client makes per calendar month (UTC).
+//
+// Unlike the rate limiter's windows, which are kept in memory and start over on a restart, the
+// counts live in the database (storage.UsageCounter), so a month's allowance holds across
+// restarts and deploys. A client is one of the deployment's API keys; requests without a known
+// key all count against one anonymous allowance, so sending made-up keys gains nothing.
+package quota
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/tenant"
+)
+
+// Anonymous is the client of requests without a known API key
+const Anonymous = "anonymous"
+
+// Result is the outcome of counting one request, for the X-Quota headers
+type Result struct {
+	Allowed bool
+	// Limit and Remaining are the client's allowance for the month
+	Limit     int64
+	Remaining int64
+	// Reset is how long until the month ends and the allowance refills
+	Reset time.Duration
+}
+
+// Meter counts requests against a monthly allowance per client
+type Meter struct {
+	Store storage.UsageCounter
+	// Limit is each client's allowance per month
+	Limit int64
+	// Keys are the API keys counted as clients of their own
+	Keys []string
+	// Clock decides the month; nil means the system clock
+	Clock clock.Clock
+}
+
+// Take counts a request against its client's allowance for the current month
+func (m *Meter) Take(r *http.Request) (Result, error) {
+	now := m.Now()
+	n, counted, err := m.Store.CountRequest(r.Context(), m.Client(r), Month(now), m.Limit)
+	if err != nil {
+		return Result{}, err
+	}
+	end := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	return Result{Allowed: counted, Limit: m.Limit, Remaining: max(m.Limit-n, 0), Reset: end.Sub(now)}, nil
+}
+
+// Client names the client of r: the hex SHA-256 of its API key, so the keys themselves are never
+// stored, or Anonymous without one of Keys
+func (m *Meter) Client(r *http.Request) string {
+	got := r.Header.Get(tenant.APIKeyHeader)
+	known := false
+	for _, key := range m.Keys {
+		if subtle.ConstantTimeCompare([]byte(got), []byte(key)) == 1 {
+			known = true
+		}
+	}
+	if got == "" || !known {
+		return Anonymous
+	}
+	sum := sha256.Sum256([]byte(got))
+	return hex.EncodeToString(sum[:])
+}
+
+// Now is the current time in UTC, by which requests are counted
+func (m *Meter) Now() time.Time {
+	return clock.OrReal(m.Clock).Now().UTC()
+}
+
+// Month names t's calendar month as usage is counted by it, e.g. 2026-10
+func Month(t time.Time) string {
+	return t.UTC().Format(MonthLayout)
+}
+
+// MonthLayout is the time layout of Month
+const MonthLayout = "2006-01"
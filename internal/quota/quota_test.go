@@ -0,0 +1,42 @@
+package quota
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/tenant"
+)
+
+func TestMeterMonthsAndClients(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 31, 23, 0, 0, 0, time.UTC))
+	m := &Meter{Store: storagetest.NewFake(), Limit: 2, Keys: []string{"k1"}, Clock: clk}
+
+	keyed := httptest.NewRequest("GET", "/students", nil)
+	keyed.Header.Set(tenant.APIKeyHeader, "k1")
+	unknown := httptest.NewRequest("GET", "/students", nil)
+	unknown.Header.Set(tenant.APIKeyHeader, "made-up")
+
+	for i := int64(1); i >= 0; i-- {
+		if res, err := m.Take(keyed); err != nil || !res.Allowed || res.Limit != 2 || res.Remaining != i {
+			t.Fatalf("request %d: %+v, %v, want allowed with %d remaining", 2-i, res, err, i)
+		}
+	}
+	if res, _ := m.Take(keyed); res.Allowed || res.Remaining != 0 || res.Reset != time.Hour {
+		t.Fatalf("over quota: %+v, want denied until the month ends in an hour", res)
+	}
+	// An unknown key is anonymous, with its own allowance
+	if m.Client(unknown) != Anonymous {
+		t.Errorf("Client(unknown key) = %q, want %q", m.Client(unknown), Anonymous)
+	}
+	if res, _ := m.Take(unknown); !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("anonymous: %+v, want allowed with 1 remaining", res)
+	}
+
+	clk.Advance(time.Hour)
+	if res, _ := m.Take(keyed); !res.Allowed || res.Remaining != 1 || res.Reset != 30*24*time.Hour {
+		t.Fatalf("next month: %+v, want the allowance refilled for November", res)
+	}
+}
@@ -0,0 +1,134 @@
+// Package ratelimit caps how many requests each client makes in a fixed time window.
+//
+// Every route shares a default budget per client. Routes that are expensive or sensitive, such as
+// POST /students/import, can declare a stricter rule; requests to them count against that rule's
+// budget only. Rules are matched with the same patterns the router uses ("POST /students/import",
+// "GET /students/{id}/profile.pdf").
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+// Rule allows Requests per client in each Window
+type Rule struct {
+	Requests int
+	Window   time.Duration
+}
+
+func (r Rule) validate() error {
+	if r.Requests <= 0 || r.Window <= 0 {
+		return fmt.Errorf("requests and window must be positive, got %d per %s", r.Requests, r.Window)
+	}
+	return nil
+}
+
+// Result is the outcome of counting one request, for the X-RateLimit headers
+type Result struct {
+	Allowed bool
+	// Limit and Remaining are the budget of the window the request fell in
+	Limit     int
+	Remaining int
+	// Reset is how long until the window ends and the budget refills
+	Reset time.Duration
+}
+
+// Limiter counts requests per client and rule
+type Limiter struct {
+	// Clock measures windows; nil means the system clock
+	Clock clock.Clock
+
+	def    *budget
+	routes map[string]*budget
+	mux    *http.ServeMux
+}
+
+// New returns a limiter applying def everywhere except on the routes listed, which get their own
+// rule. It fails on an invalid rule or a route that isn't a valid, unique ServeMux pattern.
+func New(def Rule, routes map[string]Rule) (l *Limiter, err error) {
+	if err := def.validate(); err != nil {
+		return nil, fmt.Errorf("default rule: %w", err)
+	}
+	l = &Limiter{def: newBudget(def), routes: make(map[string]*budget), mux: http.NewServeMux()}
+
+	// ServeMux panics on malformed or conflicting patterns
+	defer func() {
+		if rec := recover(); rec != nil {
+			l, err = nil, fmt.Errorf("%v", rec)
+		}
+	}()
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for pattern, rule := range routes {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("route %q: %w", pattern, err)
+		}
+		if pattern == "" {
+			return nil, errors.New("route pattern is empty")
+		}
+		l.mux.Handle(pattern, noop)
+		l.routes[pattern] = newBudget(rule)
+	}
+	return l, nil
+}
+
+// Allow counts a request from client against the rule of its route
+func (l *Limiter) Allow(r *http.Request, client string) Result {
+	b := l.def
+	if _, pattern := l.mux.Handler(r); pattern != "" {
+		b = l.routes[pattern]
+	}
+	return b.take(client, clock.OrReal(l.Clock).Now())
+}
+
+// budget is one rule's counters, one per client
+type budget struct {
+	rule Rule
+
+	mu        sync.Mutex
+	windows   map[string]*window
+	lastSweep time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+func newBudget(rule Rule) *budget {
+	return &budget{rule: rule, windows: make(map[string]*window)}
+}
+
+func (b *budget) take(client string, now time.Time) Result {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Forget clients whose window has ended, at most once per window
+	if now.Sub(b.lastSweep) >= b.rule.Window {
+		for k, w := range b.windows {
+			if now.Sub(w.start) >= b.rule.Window {
+				delete(b.windows, k)
+			}
+		}
+		b.lastSweep = now
+	}
+
+	w, ok := b.windows[client]
+	if !ok || now.Sub(w.start) >= b.rule.Window {
+		w = &window{start: now}
+		b.windows[client] = w
+	}
+
+	res := Result{Limit: b.rule.Requests, Reset: w.start.Add(b.rule.Window).Sub(now)}
+	if w.count < b.rule.Requests {
+		w.count++
+		res.Allowed = true
+	}
+	res.Remaining = b.rule.Requests - w.count
+	return res
+}
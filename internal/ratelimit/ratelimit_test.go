@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+func TestLimiterRoutesAndWindows(t *testing.T) {
+	l, err := New(Rule{Requests: 3, Window: time.Minute}, map[string]Rule{
+		"POST /students/import": {Requests: 1, Window: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	clk := clock.NewFake(time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC))
+	l.Clock = clk
+
+	list := httptest.NewRequest("GET", "/students", nil)
+	imp := httptest.NewRequest("POST", "/students/import", nil)
+
+	for i := 2; i >= 0; i-- {
+		if res := l.Allow(list, "a"); !res.Allowed || res.Limit != 3 || res.Remaining != i {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", 3-i, res, i)
+		}
+	}
+	if res := l.Allow(list, "a"); res.Allowed || res.Remaining != 0 || res.Reset != time.Minute {
+		t.Fatalf("over budget: %+v, want denied, resetting in a minute", res)
+	}
+	// Budgets are per client and per rule
+	if res := l.Allow(list, "b"); !res.Allowed {
+		t.Fatalf("other client denied: %+v", res)
+	}
+	if res := l.Allow(imp, "a"); !res.Allowed || res.Limit != 1 || res.Remaining != 0 {
+		t.Fatalf("import: %+v, want allowed under its own rule", res)
+	}
+	if res := l.Allow(imp, "a"); res.Allowed || res.Reset != time.Hour {
+		t.Fatalf("second import: %+v, want denied for an hour", res)
+	}
+
+	clk.Advance(time.Minute)
+	if res := l.Allow(list, "a"); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("next window: %+v, want the budget refilled", res)
+	}
+	if res := l.Allow(imp, "a"); res.Allowed || res.Reset != 59*time.Minute {
+		t.Fatalf("import after a minute: %+v, want still denied", res)
+	}
+}
+
+func TestNewRejectsBadRules(t *testing.T) {
+	tests := map[string]map[string]Rule{
+		"zero requests":       {"GET /students": {Window: time.Minute}},
+		"malformed pattern":   {"GET /students/{id": {Requests: 1, Window: time.Minute}},
+		"conflicting pattern": {"GET /students/{id}/x": {Requests: 1, Window: time.Minute}, "GET /students/a/{y}": {Requests: 1, Window: time.Minute}},
+	}
+	for name, routes := range tests {
+		if _, err := New(Rule{Requests: 1, Window: time.Minute}, routes); err == nil {
+			t.Errorf("%s: New() error = nil", name)
+		}
+	}
+	if _, err := New(Rule{Requests: 1}, nil); err == nil {
+		t.Error("zero window: New() error = nil")
+	}
+}
@@ -0,0 +1,215 @@
+// Package recorder keeps full request/response pairs for debugging client bugs that are hard to
+// reproduce. It is a development tool: personal data in bodies, query strings and credentials in
+// headers is masked, but recordings are still verbose and kept in memory or written to disk.
+package recorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mask replaces masked values
+const Mask = "***"
+
+// maskedHeaders carry credentials
+var maskedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// maskedFields hold personal data; JSON fields and query parameters with these names are masked.
+// Search queries and filters are masked too, since they are usually names and email addresses,
+// and so are webhook signing secrets and custom fields, which can hold anything about a student.
+var maskedFields = []string{"name", "email", "phone", "date_of_birth", "q", "filter", "secret",
+	"student_name", "student_phone", "custom_fields"}
+
+// Recording is one request and the response it got
+type Recording struct {
+	Seq       int64     `json:"seq"`
+	RequestID string    `json:"request_id,omitempty"`
+	At        time.Time `json:"at"`
+	Duration  string    `json:"duration"`
+	Request   Message   `json:"request"`
+	Response  Message   `json:"response"`
+}
+
+// Message is one side of a recording. Status is set on responses, Method and URL on requests.
+type Message struct {
+	Method  string      `json:"method,omitempty"`
+	URL     string      `json:"url,omitempty"`
+	Status  int         `json:"status,omitempty"`
+	Headers http.Header `json:"headers"`
+	// Body is the masked JSON body; any other body is described, not kept
+	Body string `json:"body,omitempty"`
+	// Truncated means only the first MaxBody bytes were kept
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Recorder keeps the last recordings in a ring buffer and, with Dir set, also writes each one to
+// a file there. Use New.
+type Recorder struct {
+	// MaxBody is how many bytes of each body are kept
+	MaxBody int
+	// Dir receives one <seq>.json file per recording; "" keeps recordings in memory only
+	Dir string
+
+	mu   sync.Mutex
+	ring []Recording
+	next int
+	seq  int64
+}
+
+// New returns a recorder that keeps the last size recordings and maxBody bytes of each body
+func New(size, maxBody int, dir string) *Recorder {
+	return &Recorder{MaxBody: maxBody, Dir: dir, ring: make([]Recording, 0, size)}
+}
+
+// Add numbers rec and stores it. Build its messages with NewMessage so personal data is masked.
+func (r *Recorder) Add(rec Recording) error {
+	r.mu.Lock()
+	r.seq++
+	rec.Seq = r.seq
+	if len(r.ring) < cap(r.ring) {
+		r.ring = append(r.ring, rec)
+	} else if cap(r.ring) > 0 {
+		r.ring[r.next] = rec
+		r.next = (r.next + 1) % cap(r.ring)
+	}
+	r.mu.Unlock()
+
+	if r.Dir == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.Dir, fmt.Sprintf("%06d.json", rec.Seq)), data, 0o600)
+}
+
+// List returns the recordings held in memory, newest first
+func (r *Recorder) List() []Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]Recording, 0, len(r.ring))
+	for i := range r.ring {
+		list = append(list, r.ring[(r.next+len(r.ring)-1-i)%len(r.ring)])
+	}
+	return list
+}
+
+// Get returns the recording numbered seq, if it is still held in memory
+func (r *Recorder) Get(seq int64) (Recording, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rec := range r.ring {
+		if rec.Seq == seq {
+			return rec, true
+		}
+	}
+	return Recording{}, false
+}
+
+// NewMessage builds a masked Message from headers and the first bytes of a body
+func NewMessage(headers http.Header, body []byte, truncated bool) Message {
+	headers = headers.Clone()
+	for _, h := range maskedHeaders {
+		if headers.Get(h) != "" {
+			headers.Set(h, Mask)
+		}
+	}
+	return Message{Headers: headers, Body: maskBody(headers.Get("Content-Type"), body, truncated), Truncated: truncated}
+}
+
+// MaskURL masks the values of query parameters that carry personal data
+func MaskURL(u *url.URL) string {
+	query := u.Query()
+	masked := false
+	for param := range query {
+		if slices.Contains(maskedFields, strings.ToLower(param)) {
+			query[param] = []string{Mask}
+			masked = true
+		}
+	}
+	if !masked {
+		return u.RequestURI()
+	}
+	out := *u
+	out.RawQuery = query.Encode()
+	return out.RequestURI()
+}
+
+// maskBody returns a JSON body with personal data masked, and a description of anything else:
+// CSV uploads, PDFs and JSON cut off at MaxBody can't be masked field by field
+func maskBody(contentType string, body []byte, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if !truncated {
+		var v any
+		if err := json.Unmarshal(body, &v); err == nil {
+			out, _ := json.Marshal(maskValue(v))
+			return string(out)
+		}
+	}
+	if contentType == "" {
+		contentType = "unknown content"
+	}
+	return fmt.Sprintf("<%d bytes of %s omitted>", len(body), contentType)
+}
+
+// maskValue masks personal data fields anywhere in a decoded JSON value
+func maskValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if slices.Contains(maskedFields, strings.ToLower(k)) && field != nil {
+				v[k] = Mask
+				continue
+			}
+			v[k] = maskValue(field)
+		}
+	case []any:
+		for i := range v {
+			v[i] = maskValue(v[i])
+		}
+	}
+	return v
+}
+
+// replaySkipped are request headers a replay shouldn't send: the transport sets them, or they
+// would tie the replay to the original request
+var replaySkipped = []string{"Accept-Encoding", "Connection", "Content-Length", "User-Agent", "X-Request-Id"}
+
+// Curl returns a curl command that sends rec's request to the server at base. Masked values are
+// sent masked and a body that wasn't kept is left out, so adjust the command as needed.
+func (rec Recording) Curl(base string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "curl -X %s %s", rec.Request.Method, shellQuote(strings.TrimSuffix(base, "/")+rec.Request.URL))
+	names := slices.Sorted(maps.Keys(rec.Request.Headers))
+	for _, name := range names {
+		if slices.Contains(replaySkipped, name) {
+			continue
+		}
+		for _, v := range rec.Request.Headers[name] {
+			if v != Mask {
+				fmt.Fprintf(&b, " -H %s", shellQuote(name+": "+v))
+			}
+		}
+	}
+	if rec.Request.Body != "" && !strings.HasPrefix(rec.Request.Body, "<") {
+		fmt.Fprintf(&b, " --data-raw %s", shellQuote(rec.Request.Body))
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,80 @@
+package recorder
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMasking(t *testing.T) {
+	headers := http.Header{"Authorization": {"Bearer secret"}, "Content-Type": {"application/json"}}
+	msg := NewMessage(headers, []byte(`[{"name":"Asha","email":"asha@example.com","age":21,"address":{"phone":"+919876543210"}},{"student_name":"Ravi","student_phone":"+919876543211","custom_fields":{"guardian":"Meera"}}]`), false)
+	if got := msg.Headers.Get("Authorization"); got != Mask {
+		t.Errorf("Authorization = %q, want masked", got)
+	}
+	if headers.Get("Authorization") != "Bearer secret" {
+		t.Error("NewMessage changed the caller's headers")
+	}
+	want := `[{"address":{"phone":"***"},"age":21,"email":"***","name":"***"},{"custom_fields":"***","student_name":"***","student_phone":"***"}]`
+	if msg.Body != want {
+		t.Errorf("Body = %s, want %s", msg.Body, want)
+	}
+
+	// Bodies that can't be masked field by field aren't kept
+	csv := NewMessage(http.Header{"Content-Type": {"text/csv"}}, []byte("name,email\nAsha,asha@example.com\n"), false)
+	cut := NewMessage(headers, []byte(`{"name":"As`), true)
+	if csv.Body != "<33 bytes of text/csv omitted>" || !strings.HasPrefix(cut.Body, "<11 bytes") || !cut.Truncated {
+		t.Errorf("CSV body = %q, truncated body = %q", csv.Body, cut.Body)
+	}
+
+	// Webhook responses carry the signing secret
+	hook := NewMessage(headers, []byte(`{"id":1,"url":"https://example.com/hook","secret":"whsec_abc"}`), false)
+	if want := `{"id":1,"secret":"***","url":"https://example.com/hook"}`; hook.Body != want {
+		t.Errorf("webhook Body = %s, want %s", hook.Body, want)
+	}
+
+	u, _ := url.Parse("/students/search?q=asha&limit=5")
+	if got := MaskURL(u); got != "/students/search?limit=5&q=%2A%2A%2A" {
+		t.Errorf("MaskURL = %s", got)
+	}
+}
+
+func TestRingAndFiles(t *testing.T) {
+	dir := t.TempDir()
+	rec := New(2, 1024, dir)
+	for _, path := range []string{"/students/1", "/students/2", "/students/3"} {
+		r := Recording{Request: Message{Method: "GET", URL: path}}
+		if err := rec.Add(r); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	list := rec.List()
+	if len(list) != 2 || list[0].Seq != 3 || list[1].Seq != 2 {
+		t.Fatalf("List() = %+v, want recordings 3 and 2", list)
+	}
+	if _, ok := rec.Get(1); ok {
+		t.Error("Get(1) found a recording that left the ring")
+	}
+	// Files outlive the ring
+	if _, err := os.Stat(filepath.Join(dir, "000001.json")); err != nil {
+		t.Errorf("first recording file: %v", err)
+	}
+}
+
+func TestCurl(t *testing.T) {
+	rec := Recording{Request: NewMessage(http.Header{
+		"Content-Type":  {"application/json"},
+		"Authorization": {"Bearer secret"},
+		"X-Request-Id":  {"abc"},
+	}, []byte(`{"age":21,"note":"it's"}`), false)}
+	rec.Request.Method = "POST"
+	rec.Request.URL = "/students"
+
+	want := `curl -X POST 'http://localhost:8080/students' -H 'Content-Type: application/json' --data-raw '{"age":21,"note":"it'\''s"}'`
+	if got := rec.Curl("http://localhost:8080/"); got != want {
+		t.Errorf("Curl() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+// Package reports is the small query language behind GET /reports: a dashboard widget picks
+// dimensions to group students by and metrics to compute, from fixed allowlists.
+//
+//	GET /reports?group_by=status,age_bucket&metric=count,avg_age
+//
+// Only names listed here are accepted, and storages map each name to a fixed SQL expression,
+// so no client input ever reaches the query text.
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Dimensions students can be grouped by
+const (
+	DimAgeBucket      = "age_bucket"        // types.AgeBuckets label
+	DimStatus         = "status"            // types.StudentActive or types.StudentAnonymized
+	DimCreatedMonth   = "created_month"     // YYYY-MM (UTC)
+	DimCreatedYear    = "created_year"      // YYYY (UTC)
+	DimEmailDomain    = "email_domain"      // lowercased part after the @
+	DimHasPhone       = "has_phone"         // "yes" or "no"
+	DimHasDateOfBirth = "has_date_of_birth" // "yes" or "no"
+)
+
+// Metrics computed per group. Ages are derived from the date of birth where known.
+const (
+	MetricCount  = "count"
+	MetricAvgAge = "avg_age"
+	MetricMinAge = "min_age"
+	MetricMaxAge = "max_age"
+)
+
+// Dimensions and Metrics are the allowlists, in documentation order
+var (
+	Dimensions = []string{DimAgeBucket, DimStatus, DimCreatedMonth, DimCreatedYear, DimEmailDomain, DimHasPhone, DimHasDateOfBirth}
+	Metrics    = []string{MetricCount, MetricAvgAge, MetricMinAge, MetricMaxAge}
+)
+
+// Query limits
+const (
+	MaxGroupBy   = 2
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// ErrInvalidQuery wraps every parse error, so handlers can answer 400
+var ErrInvalidQuery = errors.New("invalid report query")
+
+// Parse reads group_by, metric and limit from the query string. group_by may be empty (one
+// row over all students); metric defaults to count. Both take comma-separated lists.
+func Parse(v url.Values) (types.ReportQuery, error) {
+	q := types.ReportQuery{Limit: DefaultLimit}
+
+	var err error
+	if q.GroupBy, err = parseList(v.Get("group_by"), Dimensions, "group_by"); err != nil {
+		return q, err
+	}
+	if len(q.GroupBy) > MaxGroupBy {
+		return q, fmt.Errorf("%w: at most %d group_by dimensions", ErrInvalidQuery, MaxGroupBy)
+	}
+
+	if q.Metrics, err = parseList(v.Get("metric"), Metrics, "metric"); err != nil {
+		return q, err
+	}
+	if len(q.Metrics) == 0 {
+		q.Metrics = []string{MetricCount}
+	}
+
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxLimit {
+			return q, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxLimit)
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+func parseList(s string, allowed []string, param string) ([]string, error) {
+	out := []string{}
+	if s == "" {
+		return out, nil
+	}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(allowed, name) {
+			return nil, fmt.Errorf("%w: unknown %s %q (allowed: %s)", ErrInvalidQuery, param, name, strings.Join(allowed, ", "))
+		}
+		if slices.Contains(out, name) {
+			return nil, fmt.Errorf("%w: %s %q listed twice", ErrInvalidQuery, param, name)
+		}
+		out = append(out, name)
+	}
+	return out, nil
+}
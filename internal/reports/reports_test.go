@@ -0,0 +1,31 @@
+package reports
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	q, err := Parse(url.Values{"group_by": {"status, age_bucket"}, "limit": {"10"}})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(q.GroupBy, []string{DimStatus, DimAgeBucket}) || !reflect.DeepEqual(q.Metrics, []string{MetricCount}) || q.Limit != 10 {
+		t.Fatalf("Parse() = %+v", q)
+	}
+
+	bad := []url.Values{
+		{"group_by": {"name"}},
+		{"group_by": {"status,status"}},
+		{"group_by": {"status,age_bucket,has_phone"}},
+		{"metric": {"sum(age)"}},
+		{"limit": {"0"}},
+	}
+	for _, v := range bad {
+		if _, err := Parse(v); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("Parse(%v) error = %v, want ErrInvalidQuery", v, err)
+		}
+	}
+}
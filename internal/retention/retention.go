@@ -0,0 +1,163 @@
+// Package retention applies configurable data retention rules: anonymizing or deleting old
+// student records, purging soft-deleted students for good and purging finished background jobs, the tombstones of deleted students and the event log. Rules run from the scheduler; a dry run
+// reports what each rule would affect without changing anything.
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+// Targets and actions a rule can combine
+const (
+	TargetStudents = "students"
+	// TargetDeletedStudents are soft-deleted students: merged duplicates and alumni. Their rule is
+	// the grace period during which a merge or graduation can still be looked into; after it the
+	// row and everything hanging off it is gone.
+	TargetDeletedStudents = "deleted_students"
+	TargetJobs            = "jobs"
+	// TargetTombstones are the deletions the changes feed (GET /students/changes) reports. Their
+	// rule is the feed's window: a client that last synced before it must sync from scratch.
+	TargetTombstones = "tombstones"
+	// TargetEvents is the event log (GET /events). Its rule is the log's window: a consumer that
+	// fell further behind must resync from the database.
+	TargetEvents = "events"
+
+	ActionAnonymize = "anonymize"
+	ActionDelete    = "delete"
+)
+
+// Store is implemented by storages that support retention. Each method affects rows older than
+// cutoff and returns how many rows were (or, with dryRun, would be) affected.
+type Store interface {
+	AnonymizeStudentsCreatedBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, error)
+	DeleteStudentsCreatedBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, error)
+	// PurgeStudentsDeletedBefore also returns, per dependent table, the rows that went with them
+	PurgeStudentsDeletedBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, map[string]int64, error)
+	DeleteFinishedJobsBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, error)
+	DeleteTombstonesBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, error)
+	DeleteEventsBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, error)
+}
+
+// Rule affects Target rows older than OlderThanDays.
+// Student age is measured from record creation; soft-deleted students from their soft delete;
+// jobs from when they finished; tombstones from when their student was deleted; events from when
+// they were published.
+type Rule struct {
+	Name          string
+	Target        string
+	Action        string
+	OlderThanDays int
+}
+
+// apply returns the rows affected and, for purges that cascade, the dependent rows affected per table
+func (r Rule) apply(ctx context.Context, store Store, cutoff time.Time, dryRun bool) (int64, map[string]int64, error) {
+	var (
+		n   int64
+		err error
+	)
+	switch {
+	case r.Target == TargetStudents && r.Action == ActionAnonymize:
+		n, err = store.AnonymizeStudentsCreatedBefore(ctx, cutoff, dryRun)
+	case r.Target == TargetStudents && r.Action == ActionDelete:
+		n, err = store.DeleteStudentsCreatedBefore(ctx, cutoff, dryRun)
+	case r.Target == TargetDeletedStudents && r.Action == ActionDelete:
+		return store.PurgeStudentsDeletedBefore(ctx, cutoff, dryRun)
+	case r.Target == TargetJobs && r.Action == ActionDelete:
+		n, err = store.DeleteFinishedJobsBefore(ctx, cutoff, dryRun)
+	case r.Target == TargetTombstones && r.Action == ActionDelete:
+		n, err = store.DeleteTombstonesBefore(ctx, cutoff, dryRun)
+	case r.Target == TargetEvents && r.Action == ActionDelete:
+		n, err = store.DeleteEventsBefore(ctx, cutoff, dryRun)
+	default:
+		err = fmt.Errorf("rule %s: unsupported %s on %s", r.Name, r.Action, r.Target)
+	}
+	return n, nil, err
+}
+
+// Validate checks rules before the engine is built, so a typo fails startup rather than the nightly run
+func Validate(rules []Rule) error {
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if r.Name == "" {
+			return errors.New("retention rule without a name")
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("duplicate retention rule %q", r.Name)
+		}
+		seen[r.Name] = true
+
+		if r.OlderThanDays < 1 {
+			return fmt.Errorf("rule %s: older_than_days must be at least 1", r.Name)
+		}
+		switch {
+		case r.Target == TargetStudents && (r.Action == ActionAnonymize || r.Action == ActionDelete):
+		case (r.Target == TargetDeletedStudents || r.Target == TargetJobs || r.Target == TargetTombstones || r.Target == TargetEvents) && r.Action == ActionDelete:
+		default:
+			return fmt.Errorf("rule %s: unsupported action %q on target %q", r.Name, r.Action, r.Target)
+		}
+	}
+	return nil
+}
+
+// RuleReport is the outcome of one rule
+type RuleReport struct {
+	Name     string    `json:"name"`
+	Target   string    `json:"target"`
+	Action   string    `json:"action"`
+	Cutoff   time.Time `json:"cutoff"`
+	Affected int64     `json:"affected"`
+	// Cascaded counts, per table, the dependent rows removed (or unlinked) along with the affected ones
+	Cascaded map[string]int64 `json:"cascaded,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// Report is the outcome of one run
+type Report struct {
+	DryRun bool         `json:"dry_run"`
+	RanAt  time.Time    `json:"ran_at"`
+	Rules  []RuleReport `json:"rules"`
+}
+
+// Engine runs rules against one store
+type Engine struct {
+	// Clock sets the cutoffs; nil means the system clock
+	Clock clock.Clock
+
+	store Store
+	rules []Rule
+}
+
+// New returns an engine; rules must have passed Validate
+func New(store Store, rules []Rule) *Engine {
+	return &Engine{store: store, rules: rules}
+}
+
+// Run applies every rule in order. A failing rule is reported and doesn't stop the others;
+// the returned error joins all rule errors.
+func (e *Engine) Run(ctx context.Context, dryRun bool) (Report, error) {
+	now := clock.OrReal(e.Clock).Now()
+	report := Report{DryRun: dryRun, RanAt: now, Rules: make([]RuleReport, 0, len(e.rules))}
+
+	var errs []error
+	for _, r := range e.rules {
+		cutoff := now.AddDate(0, 0, -r.OlderThanDays)
+		rr := RuleReport{Name: r.Name, Target: r.Target, Action: r.Action, Cutoff: cutoff}
+
+		n, cascaded, err := r.apply(ctx, e.store, cutoff, dryRun)
+		rr.Affected, rr.Cascaded = n, cascaded
+		if err != nil {
+			rr.Error = err.Error()
+			errs = append(errs, fmt.Errorf("rule %s: %w", r.Name, err))
+		} else if !dryRun && n > 0 {
+			slog.Warn("Retention rule applied", "rule", r.Name, "target", r.Target, "action", r.Action, "affected", n, "cascaded", cascaded)
+		}
+		report.Rules = append(report.Rules, rr)
+	}
+	return report, errors.Join(errs...)
+}
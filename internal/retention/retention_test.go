@@ -0,0 +1,191 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/sqlite"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func TestValidate(t *testing.T) {
+	valid := Rule{Name: "a", Target: TargetStudents, Action: ActionAnonymize, OlderThanDays: 30}
+	if err := Validate([]Rule{valid}); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	bad := [][]Rule{
+		{{Name: "", Target: TargetJobs, Action: ActionDelete, OlderThanDays: 1}},
+		{valid, valid},
+		{{Name: "b", Target: TargetJobs, Action: ActionAnonymize, OlderThanDays: 1}},
+		{{Name: "c", Target: "audit", Action: ActionDelete, OlderThanDays: 1}},
+		{{Name: "d", Target: TargetStudents, Action: ActionDelete, OlderThanDays: 0}},
+		{{Name: "e", Target: TargetTombstones, Action: ActionAnonymize, OlderThanDays: 1}},
+		{{Name: "f", Target: TargetDeletedStudents, Action: ActionAnonymize, OlderThanDays: 1}},
+	}
+	for i, rules := range bad {
+		if err := Validate(rules); err == nil {
+			t.Errorf("case %d: Validate(%+v) succeeded", i, rules)
+		}
+	}
+}
+
+func TestRunAnonymizesOnlyOldStudents(t *testing.T) {
+	db, err := sqlite.NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewSqlite: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	oldID, _ := db.CreateStudent(types.Student{Name: "Old", Email: "old@example.com", Age: 30, DateOfBirth: "1996-01-01", Phone: "+919876543210"})
+	newID, _ := db.CreateStudent(types.Student{Name: "New", Email: "new@example.com", Age: 20})
+	db.Db.Exec("UPDATE students SET created_at = '2019-01-01 00:00:00' WHERE id = ?", oldID)
+
+	e := New(db, []Rule{{Name: "anon", Target: TargetStudents, Action: ActionAnonymize, OlderThanDays: 365 * 6}})
+	e.Clock = clock.NewFake(time.Now())
+
+	report, err := e.Run(ctx, true)
+	if err != nil || report.Rules[0].Affected != 1 {
+		t.Fatalf("dry run = %+v, %v; want 1 affected", report, err)
+	}
+	if s, _ := db.GetStudent(oldID); s.Name != "Old" {
+		t.Fatal("dry run modified data")
+	}
+
+	if report, err = e.Run(ctx, false); err != nil || report.Rules[0].Affected != 1 {
+		t.Fatalf("run = %+v, %v", report, err)
+	}
+	old, _ := db.GetStudent(oldID)
+	if old.Name == "Old" || old.Email == "old@example.com" || old.Phone != "" || old.DateOfBirth != "" {
+		t.Fatalf("old student not anonymized: %+v", old)
+	}
+	if s, _ := db.GetStudent(newID); s.Name != "New" {
+		t.Fatalf("recent student was modified: %+v", s)
+	}
+
+	// Anonymized rows aren't counted again
+	if report, _ = e.Run(ctx, true); report.Rules[0].Affected != 0 {
+		t.Fatalf("second dry run affected %d rows, want 0", report.Rules[0].Affected)
+	}
+}
+
+func TestRunPurgesTombstones(t *testing.T) {
+	db, err := sqlite.NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewSqlite: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	id, _ := db.CreateStudent(types.Student{Name: "Old", Email: "old@example.com", Age: 30})
+	deleted, _ := db.GetStudent(id)
+	db.Db.Exec("UPDATE students SET created_at = '2019-01-01 00:00:00'")
+	now := time.Now()
+	e := New(db, []Rule{
+		{Name: "purge", Target: TargetStudents, Action: ActionDelete, OlderThanDays: 365},
+		{Name: "tombstones", Target: TargetTombstones, Action: ActionDelete, OlderThanDays: 90},
+	})
+	clk := clock.NewFake(now)
+	e.Clock, db.Clock = clk, clk
+	if _, err := e.Run(ctx, false); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	clk.Advance(time.Second)
+
+	// The deleted student's tombstone is in the feed until it is older than the window
+	since := now.AddDate(0, 0, -1)
+	changes, err := db.StudentChanges(ctx, since, "", 10)
+	if err != nil || len(changes) != 1 || changes[0].ID != deleted.PublicID || changes[0].Change != types.ChangeDeleted {
+		t.Fatalf("StudentChanges = %+v, %v, want %s deleted", changes, err, deleted.PublicID)
+	}
+
+	clk.Set(now.AddDate(0, 0, 91))
+	report, err := e.Run(ctx, false)
+	if err != nil || report.Rules[1].Affected != 1 {
+		t.Fatalf("Run after the window = %+v, %v, want 1 tombstone purged", report, err)
+	}
+	if _, err := db.StudentChanges(ctx, since, "", 10); !errors.Is(err, storage.ErrChangesExpired) {
+		t.Errorf("StudentChanges(before the purge) error = %v, want ErrChangesExpired", err)
+	}
+	if changes, err := db.StudentChanges(ctx, now.AddDate(0, 0, 2), "", 10); err != nil || len(changes) != 0 {
+		t.Errorf("StudentChanges(after the purge) = %+v, %v, want none", changes, err)
+	}
+}
+
+func TestRunPurgesEvents(t *testing.T) {
+	db, err := sqlite.NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewSqlite: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	for range 2 {
+		db.AppendEvent(ctx, types.FeedEvent{Entity: types.EntityStudent, Type: "student.created", Data: []byte(`{"students":[]}`)})
+	}
+	now := time.Now()
+	e := New(db, []Rule{{Name: "events", Target: TargetEvents, Action: ActionDelete, OlderThanDays: 30}})
+	e.Clock = clock.NewFake(now.AddDate(0, 0, 31))
+	report, err := e.Run(ctx, false)
+	if err != nil || report.Rules[0].Affected != 2 {
+		t.Fatalf("Run = %+v, %v, want 2 events purged", report, err)
+	}
+
+	// A consumer behind the purge must resync; numbering carries on where it was
+	if _, last, err := db.EventsAfter(ctx, types.EntityStudent, 0, 10); !errors.Is(err, storage.ErrEventsExpired) || last != 2 {
+		t.Errorf("EventsAfter(0) = %d, %v, want ErrEventsExpired at 2", last, err)
+	}
+	next, err := db.AppendEvent(ctx, types.FeedEvent{Entity: types.EntityStudent, Type: "student.updated", Data: []byte(`{"students":[]}`)})
+	if err != nil || next.Seq != 3 {
+		t.Errorf("AppendEvent after the purge = %+v, %v, want seq 3", next, err)
+	}
+	if events, _, err := db.EventsAfter(ctx, types.EntityStudent, 2, 10); err != nil || len(events) != 1 || events[0].Seq != 3 {
+		t.Errorf("EventsAfter(2) = %+v, %v, want seq 3", events, err)
+	}
+}
+
+func TestRunPurgesDeletedStudents(t *testing.T) {
+	db, err := sqlite.NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewSqlite: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	oldID, _ := db.CreateStudent(types.Student{Name: "Old", Email: "old@example.com", Age: 22})
+	recentID, _ := db.CreateStudent(types.Student{Name: "Recent", Email: "recent@example.com", Age: 22})
+	liveID, _ := db.CreateStudent(types.Student{Name: "Live", Email: "live@example.com", Age: 22})
+	old, _ := db.GraduateStudent(ctx, oldID, 2020, "2020-06-30")
+	recent, _ := db.GraduateStudent(ctx, recentID, 2026, "2026-06-30")
+	db.Db.Exec("UPDATE students SET deleted_at = '2020-06-30 00:00:00' WHERE id = ?", oldID)
+
+	e := New(db, []Rule{{Name: "purge-deleted", Target: TargetDeletedStudents, Action: ActionDelete, OlderThanDays: 365}})
+	report, err := e.Run(ctx, true)
+	if err != nil || report.Rules[0].Affected != 1 || report.Rules[0].Cascaded["alumni"] != 1 {
+		t.Fatalf("dry run = %+v, %v, want 1 student and its alumni record", report, err)
+	}
+	if _, err := db.GetAlumnusByPublicID(ctx, old.PublicID); err != nil {
+		t.Fatalf("dry run purged the alumnus: %v", err)
+	}
+
+	if report, err = e.Run(ctx, false); err != nil || report.Rules[0].Affected != 1 {
+		t.Fatalf("run = %+v, %v, want 1 purged", report, err)
+	}
+	if _, err := db.GetAlumnusByPublicID(ctx, old.PublicID); !errors.Is(err, storage.ErrAlumnusNotFound) {
+		t.Errorf("GetAlumnusByPublicID(purged) error = %v, want ErrAlumnusNotFound", err)
+	}
+	// Inside the grace period, and never deleted, stay
+	if _, err := db.GetAlumnusByPublicID(ctx, recent.PublicID); err != nil {
+		t.Errorf("GetAlumnusByPublicID(recent) error = %v, want the alumnus kept", err)
+	}
+	if _, err := db.GetStudent(liveID); err != nil {
+		t.Errorf("GetStudent(live) error = %v, want the student kept", err)
+	}
+}
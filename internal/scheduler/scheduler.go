@@ -0,0 +1,271 @@
+// Package scheduler runs maintenance tasks (backups, purges, retention trimming) on cron schedules
+// and records the outcome of each job's last run for the admin API.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+var (
+	ErrDuplicateJob = errors.New("job already registered")
+	ErrStarted      = errors.New("scheduler already started")
+)
+
+// Task is the work a job does. The context is cancelled when the scheduler stops.
+type Task func(ctx context.Context) error
+
+// Status is the last-run state of one job
+type Status struct {
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule"`
+	Running      bool       `json:"running"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+	LastStart    *time.Time `json:"last_start,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	Runs         uint64     `json:"runs"`
+	Failures     uint64     `json:"failures"`
+	Skipped      uint64     `json:"skipped"`
+}
+
+type job struct {
+	name     string
+	schedule string
+	task     Task
+	entry    cron.EntryID
+
+	// guarded by Scheduler.mu
+	running   bool
+	lastStart time.Time
+	lastDur   time.Duration
+	lastErr   error
+	runs      uint64
+	failures  uint64
+	skipped   uint64
+}
+
+// Scheduler runs registered jobs on their cron schedules (standard 5-field expressions, or
+// descriptors such as "@daily"). A job that is still running when it is due again is skipped
+// rather than run twice at once.
+type Scheduler struct {
+	// Clock timestamps job runs; nil means the system clock
+	Clock clock.Clock
+
+	cron   *cron.Cron
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	jobs     []*job
+	started  bool
+	stopping bool
+}
+
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		cron:   cron.New(),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Add registers a job. It must be called before Start.
+func (s *Scheduler) Add(name, schedule string, task Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return ErrStarted
+	}
+	for _, j := range s.jobs {
+		if j.name == name {
+			return fmt.Errorf("%w: %s", ErrDuplicateJob, name)
+		}
+	}
+
+	j := &job{name: name, schedule: schedule, task: task}
+	id, err := s.cron.AddFunc(schedule, func() { s.run(j) })
+	if err != nil {
+		return fmt.Errorf("job %s: invalid schedule %q: %w", name, schedule, err)
+	}
+	j.entry = id
+	s.jobs = append(s.jobs, j)
+	return nil
+}
+
+// Start begins running jobs on their schedules
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	s.started = true
+	s.mu.Unlock()
+	s.cron.Start()
+}
+
+// RunNow runs a job immediately (in the caller's goroutine), subject to the same
+// skip-if-running rule as scheduled runs. It reports whether the job was found.
+func (s *Scheduler) RunNow(name string) bool {
+	j := s.find(name)
+	if j == nil {
+		return false
+	}
+	s.run(j)
+	return true
+}
+
+// Trigger starts a job now in its own goroutine, for an admin who wants it run off schedule; its
+// outcome shows in Status once it finishes. It reports whether the job was found and whether it
+// started: a job that is still running, or a scheduler that is stopping, starts nothing.
+func (s *Scheduler) Trigger(name string) (found, started bool) {
+	j := s.find(name)
+	if j == nil {
+		return false, false
+	}
+	start, ok := s.begin(j)
+	if !ok {
+		return true, false
+	}
+	go s.finish(j, start)
+	return true, true
+}
+
+func (s *Scheduler) find(name string) *job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.name == name {
+			return j
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) run(j *job) {
+	if start, ok := s.begin(j); ok {
+		s.finish(j, start)
+	}
+}
+
+// begin marks j running and returns its start time, or counts a skipped run and returns false
+func (s *Scheduler) begin(j *job) (time.Time, bool) {
+	clk := clock.OrReal(s.Clock)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.running || s.stopping {
+		j.skipped++
+		slog.Warn("Skipping scheduled job", "job", j.name, "reason", "still running or shutting down")
+		return time.Time{}, false
+	}
+	j.running = true
+	start := clk.Now()
+	j.lastStart = start
+	s.wg.Add(1)
+	return start, true
+}
+
+// finish runs the task of a job begin has marked running and records the outcome
+func (s *Scheduler) finish(j *job, start time.Time) {
+	defer s.wg.Done()
+	clk := clock.OrReal(s.Clock)
+
+	err := runTask(s.ctx, j.task)
+
+	s.mu.Lock()
+	j.running = false
+	j.lastDur = clk.Now().Sub(start)
+	j.lastErr = err
+	j.runs++
+	if err != nil {
+		j.failures++
+	}
+	dur := j.lastDur
+	s.mu.Unlock()
+
+	if err != nil {
+		slog.Error("Scheduled job failed", "job", j.name, "duration", dur, "error", err)
+		return
+	}
+	slog.Info("Scheduled job completed", "job", j.name, "duration", dur)
+}
+
+// runTask calls a task, turning a panic into an error so one bad job can't crash the server
+func runTask(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return task(ctx)
+}
+
+// Status reports every job in registration order
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Status, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		st := Status{
+			Name:     j.name,
+			Schedule: j.schedule,
+			Running:  j.running,
+			Runs:     j.runs,
+			Failures: j.failures,
+			Skipped:  j.skipped,
+		}
+		if s.started {
+			if next := s.cron.Entry(j.entry).Next; !next.IsZero() {
+				st.NextRun = &next
+			}
+		}
+		if !j.lastStart.IsZero() {
+			start := j.lastStart
+			st.LastStart = &start
+			if !j.running {
+				st.LastDuration = j.lastDur.String()
+			}
+		}
+		if j.lastErr != nil {
+			st.LastError = j.lastErr.Error()
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// Stop stops scheduling new runs and waits for running jobs to finish. If ctx expires first,
+// running jobs' contexts are cancelled and Stop returns ctx.Err() without waiting further.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cron.Stop()
+
+	// Refuse new RunNow calls too, then wait for whatever is in flight
+	s.mu.Lock()
+	s.stopping = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+)
+
+func TestAddRejectsBadSchedulesAndDuplicates(t *testing.T) {
+	s := New()
+	noop := func(context.Context) error { return nil }
+
+	if err := s.Add("backup", "not a cron", noop); err == nil {
+		t.Fatal("Add() with invalid schedule succeeded")
+	}
+	if err := s.Add("backup", "@daily", noop); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add("backup", "@hourly", noop); !errors.Is(err, ErrDuplicateJob) {
+		t.Fatalf("Add() duplicate error = %v, want %v", err, ErrDuplicateJob)
+	}
+}
+
+func TestStatusRecordsLastRun(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC))
+	s := New()
+	s.Clock = clk
+
+	fail := true
+	s.Add("backup", "0 3 * * *", func(context.Context) error {
+		clk.Advance(2 * time.Second)
+		if fail {
+			return errors.New("disk full")
+		}
+		return nil
+	})
+
+	s.RunNow("backup")
+	st := s.Status()[0]
+	if st.Runs != 1 || st.Failures != 1 || st.LastError != "disk full" || st.LastDuration != "2s" {
+		t.Fatalf("after failed run: %+v", st)
+	}
+
+	fail = false
+	s.RunNow("backup")
+	st = s.Status()[0]
+	if st.Runs != 2 || st.Failures != 1 || st.LastError != "" {
+		t.Fatalf("after successful run: %+v", st)
+	}
+
+	if s.RunNow("missing") {
+		t.Fatal("RunNow() of unknown job reported success")
+	}
+}
+
+func TestStopWaitsForRunningJobs(t *testing.T) {
+	s := New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s.Add("slow", "@daily", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	s.Start()
+
+	go s.RunNow("slow")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		close(release)
+		t.Fatalf("Stop() error = %v, want deadline exceeded while job runs", err)
+	}
+	close(release)
+}
+
+func TestTriggerRunsInBackgroundOnce(t *testing.T) {
+	s := New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s.Add("export", "@daily", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	if found, ok := s.Trigger("export"); !found || !ok {
+		t.Fatalf("Trigger() = %v, %v, want the job started", found, ok)
+	}
+	<-started
+	// Still running, so a second trigger is refused rather than run twice at once
+	if found, ok := s.Trigger("export"); !found || ok {
+		t.Fatalf("Trigger() while running = %v, %v, want found but not started", found, ok)
+	}
+	if found, _ := s.Trigger("missing"); found {
+		t.Fatal("Trigger() of unknown job reported it found")
+	}
+	close(release)
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if st := s.Status()[0]; st.Runs != 1 || st.Skipped != 1 || st.Running {
+		t.Fatalf("after triggered run: %+v", st)
+	}
+}
@@ -0,0 +1,180 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/events"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Job kinds run by the persistent job runner
+const (
+	// KindIndex (re)indexes the students in an IndexPayload
+	KindIndex = "search_index"
+	// KindReindex rebuilds the whole index from the database
+	KindReindex = "search_reindex"
+)
+
+// batchSize bounds the students per index job and per bulk request
+const batchSize = 1000
+
+// IndexPayload is the payload of a KindIndex job
+type IndexPayload struct {
+	IDs []int64 `json:"ids"`
+}
+
+// Enqueuer is the part of jobs.Runner Subscribe needs
+type Enqueuer interface {
+	Enqueue(ctx context.Context, kind string, payload any) (int64, error)
+}
+
+// Subscribe keeps the index in step with the database: every change announced on bus queues an
+// index job, and StudentsChanged a full reindex. The job runner retries failed jobs, so the index
+// catches up after an OpenSearch outage without a write ever failing or waiting on it.
+func Subscribe(bus events.Subscriber, q Enqueuer) {
+	bus.Subscribe("search index", func(ctx context.Context, e events.Event) {
+		if e.Kind == events.StudentsChanged {
+			Reindex(ctx, q)
+			return
+		}
+		// Deleted students are queued too: the index job drops documents of students that no longer exist
+		ids := e.IDs()
+		for start := 0; start < len(ids); start += batchSize {
+			end := min(start+batchSize, len(ids))
+			enqueue(ctx, q, KindIndex, IndexPayload{IDs: ids[start:end]})
+		}
+	})
+}
+
+// Reindex queues a full rebuild, e.g. for a new index
+func Reindex(ctx context.Context, q Enqueuer) {
+	enqueue(ctx, q, KindReindex, struct{}{})
+}
+
+// enqueue logs instead of failing: the write has already happened, and a reindex repairs the gap
+func enqueue(ctx context.Context, q Enqueuer, kind string, payload any) {
+	if _, err := q.Enqueue(ctx, kind, payload); err != nil {
+		slog.Error("Error queueing search index job", "kind", kind, "error", err)
+	}
+}
+
+// IndexHandler indexes the students named in a KindIndex job. Documents of students that no
+// longer exist (merged, graduated, or deleted since the job was queued) are removed. If the
+// cluster was unreachable at startup the index may not exist yet; it is then created and filled
+// from the database instead.
+func IndexHandler(client *Client, store storage.Storage, clk clock.Clock) jobs.Handler {
+	return func(ctx context.Context, job types.Job) (any, error) {
+		var p IndexPayload
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+
+		if !client.ready.Load() {
+			created, err := client.EnsureIndex(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if created {
+				return reindex(ctx, client, store, clk)
+			}
+		}
+
+		var (
+			students []types.Student
+			gone     []int64
+		)
+		for _, id := range p.IDs {
+			st, err := store.GetStudent(id)
+			if errors.Is(err, storage.ErrNotFound) {
+				gone = append(gone, id)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			students = append(students, st)
+		}
+
+		if err := client.IndexStudents(ctx, students, clock.OrReal(clk).Now()); err != nil {
+			return nil, err
+		}
+		if err := client.DeleteStudents(ctx, gone); err != nil {
+			return nil, err
+		}
+		return map[string]int{"indexed": len(students), "removed": len(gone)}, nil
+	}
+}
+
+// ReindexHandler indexes every student in batches, then deletes documents the pass didn't touch
+// (students removed by retention or a reset). Searches keep working throughout.
+func ReindexHandler(client *Client, store storage.Storage, clk clock.Clock) jobs.Handler {
+	return func(ctx context.Context, job types.Job) (any, error) {
+		if _, err := client.EnsureIndex(ctx); err != nil {
+			return nil, err
+		}
+		return reindex(ctx, client, store, clk)
+	}
+}
+
+func reindex(ctx context.Context, client *Client, store storage.Storage, clk clock.Clock) (any, error) {
+	// Millisecond precision is all indexed_at stores; truncating keeps this pass's documents out of the delete
+	started := clock.OrReal(clk).Now().Truncate(time.Millisecond)
+
+	indexed := 0
+	batch := make([]types.Student, 0, batchSize)
+	flush := func() error {
+		if err := client.IndexStudents(ctx, batch, started); err != nil {
+			return err
+		}
+		indexed += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	err := store.StreamStudents(ctx, func(st types.Student) error {
+		batch = append(batch, st)
+		if len(batch) == batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.DeleteIndexedBefore(ctx, started); err != nil {
+		return nil, err
+	}
+	slog.Info("Search index rebuilt", "index", client.Index, "students", indexed)
+	return map[string]int{"indexed": indexed}, nil
+}
+
+// Fallback searches Primary and falls back to Secondary when it fails, so an OpenSearch outage
+// degrades search results instead of breaking the endpoint
+type Fallback struct {
+	Primary   storage.Searcher
+	Secondary storage.Searcher
+}
+
+func (f Fallback) SearchStudents(ctx context.Context, query string, limit int) ([]types.SearchHit, error) {
+	hits, err := f.Primary.SearchStudents(ctx, query, limit)
+	if err == nil {
+		return hits, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	slog.Warn("Search engine unavailable, using SQL search", "error", err)
+	return f.Secondary.SearchStudents(ctx, query, limit)
+}
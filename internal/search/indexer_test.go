@@ -0,0 +1,53 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/events"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+type queued struct {
+	kind    string
+	payload any
+}
+
+type fakeQueue []queued
+
+func (q *fakeQueue) Enqueue(ctx context.Context, kind string, payload any) (int64, error) {
+	*q = append(*q, queued{kind, payload})
+	return int64(len(*q)), nil
+}
+
+func TestSubscribeQueuesIndexJobs(t *testing.T) {
+	bus := events.New()
+	var q fakeQueue
+	Subscribe(bus, &q)
+	ctx := context.Background()
+
+	bulk := make([]types.Student, batchSize+1)
+	for i := range bulk {
+		bulk[i].ID = int64(i + 1)
+	}
+	bus.Publish(ctx, events.Event{Kind: events.StudentCreated, Students: bulk, Bulk: true})
+	bus.Publish(ctx, events.Event{Kind: events.StudentDeleted, Students: []types.Student{{ID: 7}}})
+	bus.Publish(ctx, events.Event{Kind: events.StudentsChanged})
+
+	if len(q) != 4 {
+		t.Fatalf("queued %d jobs, want 4: %+v", len(q), q)
+	}
+	if p := q[0].payload.(IndexPayload); q[0].kind != KindIndex || len(p.IDs) != batchSize {
+		t.Errorf("first job = %s with %d IDs, want %s with %d", q[0].kind, len(p.IDs), KindIndex, batchSize)
+	}
+	if p := q[1].payload.(IndexPayload); len(p.IDs) != 1 || p.IDs[0] != batchSize+1 {
+		t.Errorf("second job IDs = %v, want [%d]", p.IDs, batchSize+1)
+	}
+	// The index job removes the documents of students that no longer exist
+	if p := q[2].payload.(IndexPayload); q[2].kind != KindIndex || p.IDs[0] != 7 {
+		t.Errorf("deleted student: job %+v, want %s for [7]", q[2], KindIndex)
+	}
+	if q[3].kind != KindReindex {
+		t.Errorf("StudentsChanged queued %s, want %s", q[3].kind, KindReindex)
+	}
+}
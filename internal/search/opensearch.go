@@ -0,0 +1,307 @@
+// Package search mirrors students into OpenSearch (or Elasticsearch 7+) and serves ranked,
+// typo-tolerant search with highlighting from it. Writes reach the index through background
+// jobs (see Subscribe), so a slow or unavailable cluster never fails an API request.
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Client talks to one index over the REST API; no client library is needed for the few calls we make
+type Client struct {
+	// URL is the cluster address, e.g. "http://localhost:9200"
+	URL      string
+	Index    string
+	Username string
+	Password string
+	HTTP     *http.Client
+
+	// ready is set once the index is known to exist, so jobs only check for it until then
+	ready atomic.Bool
+}
+
+// document is what gets indexed for a student. IndexedAt lets a full reindex find the
+// documents of students that no longer exist.
+type document struct {
+	PublicID    string `json:"public_id"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Age         int    `json:"age"`
+	DateOfBirth string `json:"date_of_birth,omitempty"`
+	Phone       string `json:"phone,omitempty"`
+	IndexedAt   int64  `json:"indexed_at"`
+}
+
+// indexMapping keeps email searchable as words ("asha", "example") and exact as a keyword
+const indexMapping = `{
+  "mappings": {
+    "properties": {
+      "public_id":     { "type": "keyword" },
+      "name":          { "type": "text" },
+      "email":         { "type": "text", "analyzer": "simple", "fields": { "raw": { "type": "keyword" } } },
+      "age":           { "type": "integer" },
+      "date_of_birth": { "type": "keyword" },
+      "phone":         { "type": "keyword" },
+      "indexed_at":    { "type": "date", "format": "epoch_millis" }
+    }
+  }
+}`
+
+// EnsureIndex creates the index if it doesn't exist, or adds fields missing from an index created
+// by an older version. It reports whether either happened: the index then needs a full reindex.
+func (c *Client) EnsureIndex(ctx context.Context) (rebuild bool, err error) {
+	resp, err := c.do(ctx, http.MethodHead, "/"+c.Index, "", nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		updated, err := c.updateMapping(ctx)
+		if err != nil {
+			return false, err
+		}
+		c.ready.Store(true)
+		return updated, nil
+	}
+
+	resp, err = c.do(ctx, http.MethodPut, "/"+c.Index, "application/json", strings.NewReader(indexMapping))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return false, fmt.Errorf("creating index %s: %w", c.Index, err)
+	}
+	c.ready.Store(true)
+	return true, nil
+}
+
+// updateMapping adds the fields of indexMapping the existing index lacks (e.g. public_id,
+// added after the first release) and reports whether there were any
+func (c *Client) updateMapping(ctx context.Context) (bool, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/"+c.Index+"/_mapping", "", nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return false, fmt.Errorf("reading mapping of %s: %w", c.Index, err)
+	}
+
+	type mapping struct {
+		Mappings struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"mappings"`
+	}
+	var current map[string]mapping // keyed by the concrete index name, which may differ from an alias
+	if err := json.NewDecoder(resp.Body).Decode(&current); err != nil {
+		return false, fmt.Errorf("decoding mapping of %s: %w", c.Index, err)
+	}
+	var want mapping
+	json.Unmarshal([]byte(indexMapping), &want)
+
+	missing := make(map[string]json.RawMessage)
+	for field, def := range want.Mappings.Properties {
+		for _, m := range current {
+			if _, ok := m.Mappings.Properties[field]; !ok {
+				missing[field] = def
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return false, nil
+	}
+
+	body, _ := json.Marshal(map[string]any{"properties": missing})
+	resp, err = c.do(ctx, http.MethodPut, "/"+c.Index+"/_mapping", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return false, fmt.Errorf("updating mapping of %s: %w", c.Index, err)
+	}
+	return true, nil
+}
+
+// IndexStudents adds or replaces students' documents in one bulk request
+func (c *Client) IndexStudents(ctx context.Context, students []types.Student, now time.Time) error {
+	if len(students) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, st := range students {
+		enc.Encode(map[string]any{"index": map[string]string{"_index": c.Index, "_id": strconv.FormatInt(st.ID, 10)}})
+		enc.Encode(document{
+			PublicID: st.PublicID, Name: st.Name, Email: st.Email, Age: st.Age, DateOfBirth: st.DateOfBirth, Phone: st.Phone,
+			IndexedAt: now.UnixMilli(),
+		})
+	}
+
+	return c.bulk(ctx, &body)
+}
+
+// DeleteStudents removes students' documents; documents that don't exist are ignored
+func (c *Client) DeleteStudents(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, id := range ids {
+		enc.Encode(map[string]any{"delete": map[string]string{"_index": c.Index, "_id": strconv.FormatInt(id, 10)}})
+	}
+	return c.bulk(ctx, &body)
+}
+
+// bulk sends NDJSON actions to the _bulk API
+func (c *Client) bulk(ctx context.Context, body io.Reader) error {
+	resp, err := c.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return fmt.Errorf("bulk request: %w", err)
+	}
+
+	// A bulk request succeeds as a whole even when single items fail
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decoding bulk response: %w", err)
+	}
+	if result.Errors {
+		for _, item := range result.Items {
+			for _, op := range item {
+				// Deleting a document that isn't there is fine
+				if len(op.Error) > 0 && op.Status != http.StatusNotFound {
+					return fmt.Errorf("bulk request: %s", op.Error)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteIndexedBefore removes documents last indexed before t, i.e. students a full reindex
+// started at t didn't find any more
+func (c *Client) DeleteIndexedBefore(ctx context.Context, t time.Time) error {
+	query, _ := json.Marshal(map[string]any{
+		"query": map[string]any{"range": map[string]any{"indexed_at": map[string]any{"lt": t.UnixMilli()}}},
+	})
+	resp, err := c.do(ctx, http.MethodPost, "/"+c.Index+"/_delete_by_query?conflicts=proceed", "application/json", bytes.NewReader(query))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return fmt.Errorf("deleting stale documents: %w", err)
+	}
+	return nil
+}
+
+// SearchStudents implements storage.Searcher. Names weigh more than emails, and "AUTO"
+// fuzziness tolerates one typo in words of 3-5 characters and two in longer ones.
+func (c *Client) SearchStudents(ctx context.Context, query string, limit int) ([]types.SearchHit, error) {
+	body, _ := json.Marshal(map[string]any{
+		"size": limit,
+		"query": map[string]any{"multi_match": map[string]any{
+			"query":     query,
+			"fields":    []string{"name^3", "email"},
+			"fuzziness": "AUTO",
+		}},
+		"highlight": map[string]any{"fields": map[string]any{"name": map[string]any{}, "email": map[string]any{}}},
+	})
+
+	resp, err := c.do(ctx, http.MethodPost, "/"+c.Index+"/_search", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, fmt.Errorf("searching: %w", err)
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				ID        string              `json:"_id"`
+				Score     float64             `json:"_score"`
+				Source    document            `json:"_source"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding search response: %w", err)
+	}
+
+	hits := make([]types.SearchHit, 0, len(result.Hits.Hits))
+	for _, h := range result.Hits.Hits {
+		id, err := strconv.ParseInt(h.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		d := h.Source
+		hits = append(hits, types.SearchHit{
+			Student: types.Student{
+				ID: id, PublicID: d.PublicID, Name: d.Name, Email: d.Email, Age: d.Age, DateOfBirth: d.DateOfBirth, Phone: d.Phone,
+			},
+			Score:      h.Score,
+			Highlights: h.Highlight,
+		})
+	}
+	return hits, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.URL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("opensearch %s %s: %w", method, path, err)
+	}
+	return resp, nil
+}
+
+// checkStatus turns a non-2xx response into an error carrying the start of the body
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func TestClient(t *testing.T) {
+	var (
+		indexCreated bool
+		bulkLines    []string
+		searchBody   map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "api" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/students":
+			if !indexCreated {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPut && r.URL.Path == "/students":
+			indexCreated = true
+			io.WriteString(w, `{"acknowledged":true}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/students/_mapping":
+			io.WriteString(w, `{"students":`+indexMapping+`}`)
+		case r.URL.Path == "/_bulk":
+			sc := bufio.NewScanner(r.Body)
+			for sc.Scan() {
+				bulkLines = append(bulkLines, sc.Text())
+			}
+			io.WriteString(w, `{"errors":false,"items":[]}`)
+		case r.URL.Path == "/students/_search":
+			json.NewDecoder(r.Body).Decode(&searchBody)
+			io.WriteString(w, `{"hits":{"hits":[{"_id":"7","_score":2.5,
+				"_source":{"public_id":"7c9e6679-7425-40de-944b-e07fc1f90ae7","name":"Asha Patil","email":"asha@example.com","age":20},
+				"highlight":{"name":["<em>Asha</em> Patil"]}}]}}`)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := &Client{URL: srv.URL, Index: "students", Username: "api", Password: "secret"}
+
+	for i, want := range []bool{true, false} {
+		created, err := c.EnsureIndex(ctx)
+		if err != nil || created != want {
+			t.Fatalf("EnsureIndex() call %d = %v, %v; want %v", i+1, created, err, want)
+		}
+	}
+
+	err := c.IndexStudents(ctx, []types.Student{{ID: 7, Name: "Asha Patil", Email: "asha@example.com", Age: 20}}, time.UnixMilli(1000))
+	if err != nil {
+		t.Fatalf("IndexStudents() error = %v", err)
+	}
+	if len(bulkLines) != 2 || !strings.Contains(bulkLines[0], `"_id":"7"`) || !strings.Contains(bulkLines[1], `"indexed_at":1000`) {
+		t.Fatalf("bulk body = %q", bulkLines)
+	}
+
+	hits, err := c.SearchStudents(ctx, "asah", 5)
+	if err != nil {
+		t.Fatalf("SearchStudents() error = %v", err)
+	}
+	if len(hits) != 1 || hits[0].Student.ID != 7 || hits[0].Student.PublicID != "7c9e6679-7425-40de-944b-e07fc1f90ae7" || hits[0].Score != 2.5 || hits[0].Highlights["name"][0] != "<em>Asha</em> Patil" {
+		t.Fatalf("SearchStudents() = %+v", hits)
+	}
+	if searchBody["size"] != 5.0 {
+		t.Errorf("search size = %v, want 5", searchBody["size"])
+	}
+}
+
+func TestIndexStudentsReportsItemErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}}]}`)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, Index: "students"}
+	err := c.IndexStudents(context.Background(), []types.Student{{ID: 1}}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Fatalf("IndexStudents() error = %v, want the item error", err)
+	}
+}
+
+func TestEnsureIndexAddsMissingFields(t *testing.T) {
+	var added map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead:
+		case r.Method == http.MethodGet && r.URL.Path == "/students/_mapping":
+			// An index from before public IDs, reached through an alias
+			io.WriteString(w, `{"students-v1":{"mappings":{"properties":{"name":{"type":"text"},"email":{"type":"text"},
+				"age":{"type":"integer"},"date_of_birth":{"type":"keyword"},"phone":{"type":"keyword"},
+				"indexed_at":{"type":"date","format":"epoch_millis"}}}}}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/students/_mapping":
+			json.NewDecoder(r.Body).Decode(&added)
+			io.WriteString(w, `{"acknowledged":true}`)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, Index: "students"}
+	rebuild, err := c.EnsureIndex(context.Background())
+	if err != nil || !rebuild {
+		t.Fatalf("EnsureIndex() = %v, %v; want a rebuild", rebuild, err)
+	}
+	props, _ := added["properties"].(map[string]any)
+	if _, ok := props["public_id"]; !ok || len(props) != 1 {
+		t.Fatalf("added mapping = %v, want only public_id", added)
+	}
+}
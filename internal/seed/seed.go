@@ -0,0 +1,70 @@
+// Package seed generates realistic fake students for demos, manual testing, and load tests.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/phone"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// MaxCount caps a single seeding run
+const MaxCount = 100_000
+
+// Generate returns n fake students. The same non-zero seed always yields the same
+// students (on the same day), so demos and load tests are reproducible; seed 0 is random.
+// Public IDs are always random, so seeding twice with one seed doesn't collide.
+func Generate(n int, seed int64) []types.Student {
+	f := gofakeit.New(seed)
+	now := time.Now()
+
+	students := make([]types.Student, n)
+	for i := range students {
+		first, last := f.FirstName(), f.LastName()
+
+		// 18-25 years old, spread across the year so birthdays differ
+		dob := now.AddDate(-f.Number(18, 25), 0, -f.Number(1, 364))
+
+		// The index keeps emails unique within a run
+		email := fmt.Sprintf("%s.%s%d@%s", strings.ToLower(first), strings.ToLower(last), i+1, f.DomainName())
+
+		st := types.Student{
+			PublicID:    types.NewPublicID(),
+			Name:        first + " " + last,
+			Email:       email,
+			DateOfBirth: dob.Format(types.DateLayout),
+		}
+		st.DeriveAge(now)
+
+		// Roughly two thirds of students have a phone number on file. f.Phone() is any ten digits,
+		// which often isn't a number India assigns; its mobiles start with 9 (among others).
+		if f.Number(1, 3) != 1 {
+			st.Phone, _ = phone.Normalize(f.Numerify("9#########"), "")
+		}
+
+		students[i] = st
+	}
+	return students
+}
+
+// Run generates n students and inserts them in one transaction, returning them with their IDs set
+func Run(ctx context.Context, store storage.Storage, n int, seed int64) ([]types.Student, error) {
+	if n < 1 || n > MaxCount {
+		return nil, fmt.Errorf("count must be between 1 and %d", MaxCount)
+	}
+	students := Generate(n, seed)
+	ids, err := store.CreateStudents(ctx, students)
+	if err != nil {
+		return nil, err
+	}
+	for i := range students {
+		students[i].ID = ids[i]
+	}
+	return students, nil
+}
@@ -0,0 +1,189 @@
+// Package students holds the rules for creating and changing students, apart from any transport.
+// HTTP handlers decode the request and write the response; everything in between (deriving ages,
+// validation, custom field checks, canonical phone numbers, assigning public IDs, the storage
+// calls) happens here, so another API in front of the same storage (gRPC, GraphQL, the importer)
+// applies the same rules.
+//
+// Errors are the storage's sentinels (storage.ErrNotFound, storage.ErrDatabase, ...) plus the ones
+// below; callers map them to their own status codes.
+package students
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/phone"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"github.com/prashantkumbhar2002/go_students_api/internal/validation"
+)
+
+var (
+	// ErrInvalidID means a student ID is not a UUID
+	ErrInvalidID = errors.New("student ID is not a UUID")
+	// ErrUpdateUnsupported means the storage can't update students
+	ErrUpdateUnsupported = errors.New("storage does not support updating students")
+)
+
+// InvalidError reports the students that break the rules, by their index in the request; a
+// single student is index 0
+type InvalidError struct {
+	// Fields are the students failing struct validation. While there are any, custom fields
+	// aren't checked.
+	Fields map[int]validator.ValidationErrors
+	// CustomFields are the students whose custom field values don't match their definitions, in
+	// the caller's language
+	CustomFields map[int][]string
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("%d invalid student(s)", len(e.Fields)+len(e.CustomFields))
+}
+
+// Service creates, reads and changes students in Store
+type Service struct {
+	Store storage.Storage
+	// Clock derives ages from dates of birth; nil means the system clock
+	Clock clock.Clock
+}
+
+// Prepare derives s's age from its date of birth, so the age bounds apply to it too, validates it
+// and puts its phone number in canonical form. Custom fields are left to the Service, which knows
+// their definitions.
+func Prepare(s *types.Student, now time.Time) error {
+	s.DeriveAge(now)
+	if err := validation.Struct(*s); err != nil {
+		return err
+	}
+	// Validation guarantees this succeeds
+	if s.Phone != "" {
+		s.Phone, _ = phone.Normalize(s.Phone, "")
+	}
+	return nil
+}
+
+// Get returns the student publicID names
+func (s *Service) Get(ctx context.Context, publicID string) (types.Student, error) {
+	if !types.ValidPublicID(publicID) {
+		return types.Student{}, ErrInvalidID
+	}
+	return s.Store.GetStudentByPublicID(publicID)
+}
+
+// Create checks student and stores it under a public ID of ours; one in student is ignored. With
+// storage.WithDryRun in ctx the insert is rolled back, so database constraints are checked but
+// nothing is stored. It returns the student as stored, or *InvalidError.
+func (s *Service) Create(ctx context.Context, student types.Student, lang string) (types.Student, error) {
+	students := []types.Student{student}
+	if err := s.check(ctx, students, lang); err != nil {
+		return types.Student{}, err
+	}
+	student = students[0]
+	student.PublicID = types.NewPublicID()
+
+	if storage.IsDryRun(ctx) {
+		// A one-student batch is the insert that can be rolled back
+		_, err := s.Store.CreateStudents(ctx, []types.Student{student})
+		return student, err
+	}
+	id, err := s.Store.CreateStudent(student)
+	if err != nil {
+		return types.Student{}, err
+	}
+	student.ID = id
+	return student, nil
+}
+
+// CreateMany checks every student and then stores them all in one transaction, or none if any is
+// invalid (*InvalidError). Dry runs and public IDs are as for Create. It returns the students as
+// stored, in order.
+func (s *Service) CreateMany(ctx context.Context, students []types.Student, lang string) ([]types.Student, error) {
+	students = append([]types.Student(nil), students...)
+	if err := s.check(ctx, students, lang); err != nil {
+		return nil, err
+	}
+	for i := range students {
+		students[i].PublicID = types.NewPublicID()
+	}
+	ids, err := s.Store.CreateStudents(ctx, students)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		students[i].ID = id
+	}
+	return students, nil
+}
+
+// Current returns the student publicID names, for Update to replace
+func (s *Service) Current(ctx context.Context, publicID string) (types.Student, error) {
+	if _, ok := storage.As[storage.Updater](s.Store); !ok {
+		return types.Student{}, ErrUpdateUnsupported
+	}
+	return s.Get(ctx, publicID)
+}
+
+// Update checks student as a replacement for current and stores it. The IDs aren't writable:
+// current's are kept, as on create. It returns the student as stored and the fields that changed,
+// or *InvalidError; storage.ErrNotFound if current was deleted in the meantime. With
+// storage.WithDryRun in ctx the update is rolled back and the result is what would have been stored.
+func (s *Service) Update(ctx context.Context, current, student types.Student, lang string) (types.Student, []string, error) {
+	updater, ok := storage.As[storage.Updater](s.Store)
+	if !ok {
+		return types.Student{}, nil, ErrUpdateUnsupported
+	}
+	student.ID, student.PublicID = current.ID, current.PublicID
+
+	students := []types.Student{student}
+	if err := s.check(ctx, students, lang); err != nil {
+		return types.Student{}, nil, err
+	}
+	return updater.UpdateStudent(ctx, students[0])
+}
+
+// check prepares every student in place, then checks their custom fields against the storage's
+// definitions after dropping null and empty values so they aren't stored
+func (s *Service) check(ctx context.Context, students []types.Student, lang string) error {
+	now := clock.OrReal(s.Clock).Now()
+	invalid := make(map[int]validator.ValidationErrors)
+	for i := range students {
+		if err := Prepare(&students[i], now); err != nil {
+			var verrs validator.ValidationErrors
+			if !errors.As(err, &verrs) {
+				return err
+			}
+			invalid[i] = verrs
+		}
+	}
+	if len(invalid) > 0 {
+		return &InvalidError{Fields: invalid}
+	}
+
+	var defs []types.CustomField
+	if fields, ok := storage.As[storage.CustomFields](s.Store); ok {
+		var err error
+		if defs, err = fields.ListCustomFields(ctx); err != nil {
+			return fmt.Errorf("listing custom fields: %w", err)
+		}
+	}
+	custom := make(map[int][]string)
+	for i := range students {
+		maps.DeleteFunc(students[i].CustomFields, func(_ string, v any) bool { return v == nil || v == "" })
+		if len(students[i].CustomFields) == 0 {
+			students[i].CustomFields = nil
+		}
+		if errs := validation.CustomFields(defs, students[i].CustomFields, lang); len(errs) > 0 {
+			custom[i] = errs
+		}
+	}
+	if len(custom) > 0 {
+		return &InvalidError{CustomFields: custom}
+	}
+	return nil
+}
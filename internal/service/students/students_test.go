@@ -0,0 +1,111 @@
+package students
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func newService(t *testing.T) (*Service, *storagetest.Fake) {
+	t.Helper()
+	store := storagetest.NewFake()
+	return &Service{Store: store, Clock: clock.NewFake(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC))}, store
+}
+
+func TestCreate(t *testing.T) {
+	svc, store := newService(t)
+	ctx := context.Background()
+
+	st, err := svc.Create(ctx, types.Student{Name: "Asha", Email: "asha@example.com", DateOfBirth: "2000-01-01", Phone: "+91 98765 43210", PublicID: "ignored"}, "en")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if st.ID == 0 || !types.ValidPublicID(st.PublicID) || st.Age != 26 || st.Phone != "+919876543210" {
+		t.Fatalf("Create() = %+v, want IDs of ours, the derived age and a canonical phone", st)
+	}
+	got, err := svc.Get(ctx, st.PublicID)
+	if err != nil || got.Name != "Asha" {
+		t.Errorf("Get(%s) = %+v, %v", st.PublicID, got, err)
+	}
+	if _, err := svc.Get(ctx, "42"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Get(42) error = %v, want ErrInvalidID", err)
+	}
+
+	// A dry run checks everything and stores nothing
+	if _, err := svc.Create(storage.WithDryRun(ctx), types.Student{Name: "Ravi", Email: "ravi@example.com", Age: 20}, "en"); err != nil {
+		t.Fatalf("Create(dry run) error = %v", err)
+	}
+	if n, _ := store.GetStudentsCount(); n != 1 {
+		t.Errorf("students = %d after a dry run, want 1", n)
+	}
+}
+
+func TestCreateManyIsAllOrNothing(t *testing.T) {
+	svc, store := newService(t)
+	ctx := context.Background()
+	if _, err := store.DefineCustomField(ctx, types.CustomField{Name: "locker", Type: "number"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := svc.CreateMany(ctx, []types.Student{
+		{Name: "Asha", Email: "asha@example.com", Age: 20},
+		{Name: "Ravi", Email: "not-an-email", Age: 20},
+	}, "en")
+	var invalid *InvalidError
+	if !errors.As(err, &invalid) || len(invalid.Fields) != 1 || invalid.Fields[1] == nil {
+		t.Fatalf("CreateMany() error = %v, want student 1 invalid", err)
+	}
+
+	_, err = svc.CreateMany(ctx, []types.Student{
+		{Name: "Asha", Email: "asha@example.com", Age: 20, CustomFields: map[string]any{"locker": "A7"}},
+	}, "en")
+	if !errors.As(err, &invalid) || len(invalid.CustomFields[0]) != 1 {
+		t.Fatalf("CreateMany() error = %v, want student 0's locker rejected", err)
+	}
+	if n, _ := store.GetStudentsCount(); n != 0 {
+		t.Fatalf("students = %d after invalid batches, want 0", n)
+	}
+
+	created, err := svc.CreateMany(ctx, []types.Student{
+		{Name: "Asha", Email: "asha@example.com", Age: 20, CustomFields: map[string]any{"locker": float64(7), "note": nil}},
+		{Name: "Ravi", Email: "ravi@example.com", Age: 21},
+	}, "en")
+	if err != nil {
+		t.Fatalf("CreateMany() error = %v", err)
+	}
+	if len(created) != 2 || created[1].ID == 0 || created[0].PublicID == created[1].PublicID || len(created[0].CustomFields) != 1 {
+		t.Errorf("CreateMany() = %+v, want both stored with their own IDs and nulls dropped", created)
+	}
+}
+
+func TestUpdateKeepsIDs(t *testing.T) {
+	svc, _ := newService(t)
+	ctx := context.Background()
+	st, err := svc.Create(ctx, types.Student{Name: "Asha", Email: "asha@example.com", Age: 20}, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := svc.Current(ctx, st.PublicID)
+	if err != nil {
+		t.Fatalf("Current() error = %v", err)
+	}
+	updated, changed, err := svc.Update(ctx, current, types.Student{Name: "Asha Rao", Email: "asha@example.com", Age: 20, PublicID: types.NewPublicID()}, "en")
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.PublicID != st.PublicID || updated.Name != "Asha Rao" || len(changed) != 1 || changed[0] != "name" {
+		t.Errorf("Update() = %+v, %v, want the name changed under the same ID", updated, changed)
+	}
+
+	var invalid *InvalidError
+	if _, _, err := svc.Update(ctx, current, types.Student{Name: "Asha"}, "en"); !errors.As(err, &invalid) {
+		t.Errorf("Update(no email) error = %v, want *InvalidError", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package shutdown orchestrates graceful shutdown. Components register Close/Drain hooks
+// as they are created, and main runs them all, in phase order, within one shutdown budget.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phase orders hooks: lower phases run first. Hooks in the same phase run in registration order.
+type Phase int
+
+const (
+	// PhaseListeners stops accepting new work (HTTP servers, schedulers)
+	PhaseListeners Phase = iota * 10
+	// PhaseDrain lets in-flight and queued background work finish (worker pools, queues)
+	PhaseDrain
+	// PhaseClose releases resources nothing should use anymore (database handles, files)
+	PhaseClose
+)
+
+// Hook releases or drains one component. It should return promptly once ctx is done.
+type Hook func(ctx context.Context) error
+
+type hook struct {
+	name  string
+	phase Phase
+	seq   int
+	fn    Hook
+}
+
+// Manager is a registry of shutdown hooks. The zero value is not usable; call New.
+type Manager struct {
+	mu    sync.Mutex
+	hooks []hook
+	done  bool
+}
+
+func New() *Manager {
+	return &Manager{}
+}
+
+// Register adds a hook. Registering after Shutdown has started is a no-op.
+func (m *Manager) Register(name string, phase Phase, fn Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.done {
+		slog.Warn("Shutdown hook registered after shutdown started; ignoring", "hook", name)
+		return
+	}
+	m.hooks = append(m.hooks, hook{name: name, phase: phase, seq: len(m.hooks), fn: fn})
+}
+
+// Shutdown runs every hook in phase order. A failing hook doesn't stop later ones - the
+// database must still be closed even if the worker pool didn't drain in time - and even after
+// ctx expires the remaining hooks run so they can release resources. All errors are joined.
+// It only runs once; later calls return nil.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+		return nil
+	}
+	m.done = true
+	hooks := append([]hook(nil), m.hooks...)
+	m.mu.Unlock()
+
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].phase != hooks[j].phase {
+			return hooks[i].phase < hooks[j].phase
+		}
+		return hooks[i].seq < hooks[j].seq
+	})
+
+	var errs []error
+	for _, h := range hooks {
+		start := time.Now()
+		err := runHook(ctx, h)
+		if err != nil {
+			slog.Error("Shutdown hook failed", "hook", h.name, "duration", time.Since(start), "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		slog.Info("Shutdown hook completed", "hook", h.name, "duration", time.Since(start))
+	}
+
+	return errors.Join(errs...)
+}
+
+// runHook calls a hook, turning a panic into an error so the remaining hooks still run
+func runHook(ctx context.Context, h hook) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h.fn(ctx)
+}
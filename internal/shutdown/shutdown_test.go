@@ -0,0 +1,58 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestShutdownRunsHooksInPhaseOrder(t *testing.T) {
+	m := New()
+	var order []string
+	record := func(name string) Hook {
+		return func(context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	m.Register("db", PhaseClose, record("db"))
+	m.Register("pool", PhaseDrain, record("pool"))
+	m.Register("http", PhaseListeners, record("http"))
+	m.Register("queue", PhaseDrain, record("queue"))
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := []string{"http", "pool", "queue", "db"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+}
+
+func TestShutdownContinuesAfterFailures(t *testing.T) {
+	m := New()
+	boom := errors.New("boom")
+	closed := false
+
+	m.Register("failing", PhaseDrain, func(context.Context) error { return boom })
+	m.Register("panicking", PhaseDrain, func(context.Context) error { panic("oops") })
+	m.Register("db", PhaseClose, func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	err := m.Shutdown(context.Background())
+	if !errors.Is(err, boom) {
+		t.Fatalf("Shutdown() error = %v, want it to wrap %v", err, boom)
+	}
+	if !closed {
+		t.Fatal("later hooks should still run after a failure")
+	}
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown() error = %v, want nil", err)
+	}
+}
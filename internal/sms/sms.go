@@ -0,0 +1,60 @@
+// Package sms sends text messages through a pluggable provider and reads the delivery reports
+// providers post back.
+//
+// Twilio, and the providers that copy its API, are one implementation (see Twilio); LogSender
+// prints messages instead of sending them, for development. Sending only hands a message to the
+// provider: whether it reached the phone arrives later, as a callback the provider posts to the
+// status callback URL, which a Receiver authenticates and reads.
+package sms
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// MaxLength is the longest message sent. Providers split long messages into segments and refuse
+// those of more than ten or so.
+const MaxLength = 1600
+
+var (
+	// ErrSignature is returned for a callback that isn't signed by the provider
+	ErrSignature = errors.New("sms: callback signature does not match")
+	// ErrMalformed is returned for a callback without a message ID
+	ErrMalformed = errors.New("sms: callback names no message")
+)
+
+// Sender hands one text message to the provider and returns the provider's ID for it, which
+// the delivery reports for it refer to
+type Sender interface {
+	Send(ctx context.Context, to, body string) (id string, err error)
+}
+
+// Report is a delivery report posted back by the provider
+type Report struct {
+	MessageID string
+	// Status is types.DeliveryDelivered or types.DeliveryFailed once the outcome is known, and ""
+	// for the reports sent while the message is still on its way
+	Status string
+	// Error says why a failed message wasn't delivered
+	Error string
+}
+
+// Receiver reads the delivery reports a provider posts to the status callback URL, refusing
+// requests it can't authenticate with ErrSignature
+type Receiver interface {
+	Receive(r *http.Request) (Report, error)
+}
+
+// LogSender logs messages instead of sending them (development). Nothing is delivered, so no
+// reports follow and the messages stay sent.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, to, body string) (string, error) {
+	id := "log-" + types.NewPublicID()
+	slog.InfoContext(ctx, "SMS (log provider, not sent)", "to", to, "body", body, "id", id)
+	return id, nil
+}
@@ -0,0 +1,130 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// SignatureHeader carries the signature of a Twilio callback
+const SignatureHeader = "X-Twilio-Signature"
+
+// Twilio sends messages through Twilio's Messages API, or that of any provider copying it, and
+// receives its status callbacks. No client library is needed for the one call we make.
+type Twilio struct {
+	// BaseURL is "https://api.twilio.com" for Twilio itself
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	// From is the sending number (E.164) or alphanumeric sender ID
+	From string
+	// StatusCallback is the URL the provider posts delivery reports to; "" asks for none. It must
+	// be the URL exactly as the provider calls it, since the reports are signed over it.
+	StatusCallback string
+	HTTP           *http.Client
+}
+
+// Send implements Sender
+func (t *Twilio) Send(ctx context.Context, to, body string) (string, error) {
+	form := url.Values{"To": {to}, "From": {t.From}, "Body": {body}}
+	if t.StatusCallback != "" {
+		form.Set("StatusCallback", t.StatusCallback)
+	}
+	endpoint := strings.TrimRight(t.BaseURL, "/") + "/2010-04-01/Accounts/" + url.PathEscape(t.AccountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+
+	httpClient := t.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sms: sending to %s: %w", to, err)
+	}
+	defer resp.Body.Close()
+
+	// Errors come back as {"code": 21211, "message": "The 'To' number is not a valid phone number."}
+	var out struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&out)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if out.Message != "" {
+			return "", fmt.Errorf("sms: status %d: %s (code %d)", resp.StatusCode, out.Message, out.Code)
+		}
+		return "", fmt.Errorf("sms: status %d", resp.StatusCode)
+	}
+	if decodeErr != nil || out.SID == "" {
+		return "", fmt.Errorf("sms: reading the provider's response: no message sid (%v)", decodeErr)
+	}
+	return out.SID, nil
+}
+
+// Receive implements Receiver for status callbacks: form posts signed in SignatureHeader over
+// StatusCallback and the posted fields. Only the final statuses make a report with a Status;
+// "queued", "sending" and "sent" are progress the delivery already shows.
+func (t *Twilio) Receive(r *http.Request) (Report, error) {
+	if err := r.ParseForm(); err != nil {
+		return Report{}, fmt.Errorf("sms: reading callback: %w", err)
+	}
+	got, err := base64.StdEncoding.DecodeString(r.Header.Get(SignatureHeader))
+	if err != nil || !hmac.Equal(got, sign(t.AuthToken, t.StatusCallback, r.PostForm)) {
+		return Report{}, ErrSignature
+	}
+
+	report := Report{MessageID: r.PostForm.Get("MessageSid")}
+	if report.MessageID == "" {
+		return Report{}, ErrMalformed
+	}
+	switch status := r.PostForm.Get("MessageStatus"); status {
+	case "delivered":
+		report.Status = types.DeliveryDelivered
+	case "failed", "undelivered":
+		report.Status = types.DeliveryFailed
+		report.Error = status
+		if code := r.PostForm.Get("ErrorCode"); code != "" {
+			report.Error += " (error code " + code + ")"
+		}
+	}
+	return report, nil
+}
+
+// Signature returns the SignatureHeader value of a callback to callbackURL posting params
+func Signature(authToken, callbackURL string, params url.Values) string {
+	return base64.StdEncoding.EncodeToString(sign(authToken, callbackURL, params))
+}
+
+// sign is the HMAC-SHA1, keyed by the auth token, of the URL followed by every posted field's
+// name and value, in field name order
+func sign(authToken, callbackURL string, params url.Values) []byte {
+	mac := hmac.New(sha1.New, []byte(authToken))
+	io.WriteString(mac, callbackURL)
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			io.WriteString(mac, k+v)
+		}
+	}
+	return mac.Sum(nil)
+}
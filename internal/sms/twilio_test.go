@@ -0,0 +1,96 @@
+package sms
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func TestTwilioSend(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "AC123" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		r.ParseForm()
+		form = r.PostForm
+		if form.Get("To") == "+10000000000" {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"code": 21211, "message": "The 'To' number is not a valid phone number."}`)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"sid": "SM42", "status": "queued"}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	tw := &Twilio{BaseURL: srv.URL, AccountSID: "AC123", AuthToken: "secret", From: "+15550001111", StatusCallback: "https://greenwood.example.com/webhooks/sms"}
+	id, err := tw.Send(ctx, "+919812345678", "Route 4 is late.")
+	if err != nil || id != "SM42" {
+		t.Fatalf("Send = %q, %v, want SM42", id, err)
+	}
+	if form.Get("From") != "+15550001111" || form.Get("Body") != "Route 4 is late." || form.Get("StatusCallback") != tw.StatusCallback {
+		t.Errorf("posted %v", form)
+	}
+
+	if _, err := tw.Send(ctx, "+10000000000", "Hello"); err == nil || !strings.Contains(err.Error(), "21211") {
+		t.Errorf("Send(invalid number) error = %v, want the provider's error", err)
+	}
+	tw.AuthToken = "wrong"
+	if _, err := tw.Send(ctx, "+919812345678", "Hello"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Send(bad credentials) error = %v, want status 401", err)
+	}
+}
+
+func TestTwilioReceive(t *testing.T) {
+	tw := &Twilio{AuthToken: "secret", StatusCallback: "https://greenwood.example.com/webhooks/sms"}
+	callback := func(params url.Values, signature string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/webhooks/sms", strings.NewReader(params.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set(SignatureHeader, signature)
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		params url.Values
+		want   Report
+	}{
+		{"delivered", url.Values{"MessageSid": {"SM42"}, "MessageStatus": {"delivered"}}, Report{MessageID: "SM42", Status: types.DeliveryDelivered}},
+		{"undelivered", url.Values{"MessageSid": {"SM42"}, "MessageStatus": {"undelivered"}, "ErrorCode": {"30003"}},
+			Report{MessageID: "SM42", Status: types.DeliveryFailed, Error: "undelivered (error code 30003)"}},
+		{"in progress", url.Values{"MessageSid": {"SM42"}, "MessageStatus": {"sent"}}, Report{MessageID: "SM42"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tw.Receive(callback(tt.params, Signature("secret", tw.StatusCallback, tt.params)))
+			if err != nil || got != tt.want {
+				t.Errorf("Receive = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+
+	params := url.Values{"MessageSid": {"SM42"}, "MessageStatus": {"delivered"}}
+	for name, signature := range map[string]string{
+		"missing":       "",
+		"other token":   Signature("other", tw.StatusCallback, params),
+		"other url":     Signature("secret", "https://attacker.example.com/", params),
+		"other payload": Signature("secret", tw.StatusCallback, url.Values{"MessageSid": {"SM42"}, "MessageStatus": {"failed"}}),
+	} {
+		if _, err := tw.Receive(callback(params, signature)); err != ErrSignature {
+			t.Errorf("Receive(%s signature) error = %v, want ErrSignature", name, err)
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache is a storage.Storage decorator that caches the first few pages of the unfiltered
+// student list and the student count. Dashboards poll those pages constantly, and every page
+// needs the count; serving them from memory for a short TTL takes most of that load off SQLite.
+// Any write clears the cache.
+//
+// Identical reads that arrive while one is already querying the database (a dashboard refresh
+// storm) wait for it and share its answer instead of each running the same query. That covers
+// single students, which aren't cached, and every page of the list, cached or not.
+//
+// Cache has methods for every capability whose writes change students, so it can drop its pages
+// after them, but those methods only forward to the wrapped storage; Forwards tells storage.As so,
+// and As only returns the cache for them when the wrapped storage really has the capability. As
+// reaches the other capabilities on the wrapped storage.
+type Cache struct {
+	storage.Storage
+
+	// Clock decides when entries expire; replace it with a clock.Fake in tests
+	Clock clock.Clock
+	// CountTTL is how long a count is served; 0 counts on every call
+	CountTTL time.Duration
+
+	ttl      time.Duration
+	maxPages int
+
+	mu         sync.Mutex
+	pages      map[pageKey]pageEntry
+	count      countEntry
+	generation uint64 // bumped on every write; results computed under an older generation are discarded
+
+	// flights are the reads in progress, keyed by generation and query
+	flights singleflight.Group
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	coalesced atomic.Uint64
+}
+
+type pageKey struct {
+	offset, limit int
+}
+
+type pageEntry struct {
+	students []types.Student
+	expires  time.Time
+}
+
+type countEntry struct {
+	n       int64
+	at      time.Time // when it was counted
+	expires time.Time
+	ok      bool
+}
+
+// Stats reports cache effectiveness
+type Stats struct {
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	// Coalesced counts reads that shared a database query with identical concurrent ones
+	Coalesced uint64 `json:"coalesced"`
+}
+
+// New wraps next. Pages at index >= maxPages (e.g. offset/limit >= 5) always go to the database.
+func New(next storage.Storage, ttl time.Duration, maxPages int) *Cache {
+	return &Cache{
+		Storage:  next,
+		Clock:    clock.Real{},
+		ttl:      ttl,
+		maxPages: maxPages,
+		pages:    make(map[pageKey]pageEntry),
+	}
+}
+
+// Unwrap returns the wrapped storage
+func (c *Cache) Unwrap() storage.Storage {
+	return c.Storage
+}
+
+// Forwards implements storage.Forwarder: every capability the cache has apart from
+// storage.CachedCounter is the wrapped storage's
+func (c *Cache) Forwards(capability any) bool {
+	switch capability.(type) {
+	case *storage.Resetter, *storage.Merger, *storage.Alumni, *storage.Admissions, *storage.CustomFields,
+		*storage.Updater, *storage.BulkUpdater, *storage.ImportCheckpointer:
+		return true
+	}
+	return false
+}
+
+// GetStudentsList serves hot pages from memory and falls through to storage otherwise
+func (c *Cache) GetStudentsList(offset, limit int) ([]types.Student, error) {
+	cached := limit > 0 && offset/limit < c.maxPages
+	key := pageKey{offset: offset, limit: limit}
+
+	c.mu.Lock()
+	entry, ok := c.pages[key]
+	gen := c.generation
+	c.mu.Unlock()
+
+	if cached {
+		if ok && c.Clock.Now().Before(entry.expires) {
+			c.hits.Add(1)
+			return clone(entry.students), nil
+		}
+		c.misses.Add(1)
+	}
+
+	v, err := c.share(gen, fmt.Sprintf("list:%d:%d", offset, limit), func() (any, error) {
+		students, err := c.Storage.GetStudentsList(offset, limit)
+		if err != nil || !cached {
+			return students, err
+		}
+		c.mu.Lock()
+		// A write may have happened while we were querying; don't cache a result that predates it
+		if c.generation == gen {
+			c.pages[key] = pageEntry{students: clone(students), expires: c.Clock.Now().Add(c.ttl)}
+		}
+		c.mu.Unlock()
+		return students, nil
+	})
+	students, _ := v.([]types.Student)
+	return clone(students), err
+}
+
+// GetStudentsCount serves the count from memory while it is fresh
+func (c *Cache) GetStudentsCount() (int64, error) {
+	n, _, err := c.CachedStudentsCount()
+	return n, err
+}
+
+// CachedStudentsCount implements storage.CachedCounter
+func (c *Cache) CachedStudentsCount() (int64, time.Time, error) {
+	now := c.Clock.Now()
+	c.mu.Lock()
+	entry := c.count
+	gen := c.generation
+	c.mu.Unlock()
+
+	if c.CountTTL > 0 {
+		if entry.ok && now.Before(entry.expires) {
+			c.hits.Add(1)
+			return entry.n, entry.at, nil
+		}
+		c.misses.Add(1)
+	}
+
+	v, err := c.share(gen, "count", func() (any, error) {
+		n, err := c.Storage.GetStudentsCount()
+		if err != nil || c.CountTTL <= 0 {
+			return n, err
+		}
+		c.mu.Lock()
+		// As with pages, a count that raced a write is returned but not kept
+		if c.generation == gen {
+			c.count = countEntry{n: n, at: now, expires: now.Add(c.CountTTL), ok: true}
+		}
+		c.mu.Unlock()
+		return n, nil
+	})
+	n, _ := v.(int64)
+	return n, time.Time{}, err
+}
+
+// GetStudent shares the lookup with identical concurrent ones; students aren't cached
+func (c *Cache) GetStudent(id int64) (types.Student, error) {
+	v, err := c.share(c.currentGeneration(), "student:"+strconv.FormatInt(id, 10), func() (any, error) {
+		return c.Storage.GetStudent(id)
+	})
+	return cloneStudent(v), err
+}
+
+// GetStudentByPublicID shares the lookup with identical concurrent ones
+func (c *Cache) GetStudentByPublicID(publicID string) (types.Student, error) {
+	v, err := c.share(c.currentGeneration(), "public:"+publicID, func() (any, error) {
+		return c.Storage.GetStudentByPublicID(publicID)
+	})
+	return cloneStudent(v), err
+}
+
+// share runs fn once for all concurrent calls with the same key under generation gen, and
+// gives each their answer. The key carries the generation so a read that starts after a write
+// never shares a query that may have started before it.
+func (c *Cache) share(gen uint64, key string, fn func() (any, error)) (any, error) {
+	v, err, shared := c.flights.Do(strconv.FormatUint(gen, 10)+":"+key, fn)
+	if shared {
+		c.coalesced.Add(1)
+	}
+	return v, err
+}
+
+func (c *Cache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// CreateStudent writes through and invalidates cached pages
+func (c *Cache) CreateStudent(student types.Student) (int64, error) {
+	id, err := c.Storage.CreateStudent(student)
+	c.Invalidate()
+	return id, err
+}
+
+// CreateStudents writes through and invalidates cached pages; dry runs change nothing, so they keep them
+func (c *Cache) CreateStudents(ctx context.Context, students []types.Student) ([]int64, error) {
+	ids, err := c.Storage.CreateStudents(ctx, students)
+	if !storage.IsDryRun(ctx) {
+		c.Invalidate()
+	}
+	return ids, err
+}
+
+// Reset forwards to the wrapped storage (if it supports it) and drops every cached page
+func (c *Cache) Reset(ctx context.Context) error {
+	r, ok := storage.As[storage.Resetter](c.Storage)
+	if !ok {
+		return errors.New("storage does not support reset")
+	}
+	err := r.Reset(ctx)
+	c.Invalidate()
+	return err
+}
+
+// MergeStudents forwards to the wrapped storage (if it supports it) and drops every cached page
+func (c *Cache) MergeStudents(ctx context.Context, keepID, mergeID int64) (types.Student, error) {
+	m, ok := storage.As[storage.Merger](c.Storage)
+	if !ok {
+		return types.Student{}, errors.New("storage does not support merging")
+	}
+	student, err := m.MergeStudents(ctx, keepID, mergeID)
+	c.Invalidate()
+	return student, err
+}
+
+// GraduateStudent forwards to the wrapped storage (if it supports it) and drops every cached
+// page, since the graduate leaves the student list
+func (c *Cache) GraduateStudent(ctx context.Context, studentID int64, classOf int, graduatedOn string) (types.Alumnus, error) {
+	al, ok := storage.As[storage.Alumni](c.Storage)
+	if !ok {
+		return types.Alumnus{}, errors.New("storage does not support alumni")
+	}
+	alumnus, err := al.GraduateStudent(ctx, studentID, classOf, graduatedOn)
+	c.Invalidate()
+	return alumnus, err
+}
+
+// GetAlumnusByPublicID forwards to the wrapped storage (if it supports it)
+func (c *Cache) GetAlumnusByPublicID(ctx context.Context, publicID string) (types.Alumnus, error) {
+	al, ok := storage.As[storage.Alumni](c.Storage)
+	if !ok {
+		return types.Alumnus{}, errors.New("storage does not support alumni")
+	}
+	return al.GetAlumnusByPublicID(ctx, publicID)
+}
+
+// ListAlumni forwards to the wrapped storage (if it supports it)
+func (c *Cache) ListAlumni(ctx context.Context, f types.AlumniFilter, offset, limit int) ([]types.Alumnus, int64, error) {
+	al, ok := storage.As[storage.Alumni](c.Storage)
+	if !ok {
+		return nil, 0, errors.New("storage does not support alumni")
+	}
+	return al.ListAlumni(ctx, f, offset, limit)
+}
+
+// SubmitApplication forwards to the wrapped storage (if it supports it)
+func (c *Cache) SubmitApplication(ctx context.Context, app types.Application) (types.Application, error) {
+	ad, ok := storage.As[storage.Admissions](c.Storage)
+	if !ok {
+		return types.Application{}, errors.New("storage does not support admissions")
+	}
+	return ad.SubmitApplication(ctx, app)
+}
+
+// GetApplicationByPublicID forwards to the wrapped storage (if it supports it)
+func (c *Cache) GetApplicationByPublicID(ctx context.Context, publicID string) (types.Application, error) {
+	ad, ok := storage.As[storage.Admissions](c.Storage)
+	if !ok {
+		return types.Application{}, errors.New("storage does not support admissions")
+	}
+	return ad.GetApplicationByPublicID(ctx, publicID)
+}
+
+// ListApplications forwards to the wrapped storage (if it supports it)
+func (c *Cache) ListApplications(ctx context.Context, status string, offset, limit int) ([]types.Application, int64, error) {
+	ad, ok := storage.As[storage.Admissions](c.Storage)
+	if !ok {
+		return nil, 0, errors.New("storage does not support admissions")
+	}
+	return ad.ListApplications(ctx, status, offset, limit)
+}
+
+// SetApplicationStatus forwards to the wrapped storage (if it supports it)
+func (c *Cache) SetApplicationStatus(ctx context.Context, id int64, status string) (types.Application, error) {
+	ad, ok := storage.As[storage.Admissions](c.Storage)
+	if !ok {
+		return types.Application{}, errors.New("storage does not support admissions")
+	}
+	return ad.SetApplicationStatus(ctx, id, status)
+}
+
+// ConvertApplication forwards to the wrapped storage (if it supports it) and drops every
+// cached page, since the new student joins the list
+func (c *Cache) ConvertApplication(ctx context.Context, id int64, student types.Student) (types.Application, types.Student, error) {
+	ad, ok := storage.As[storage.Admissions](c.Storage)
+	if !ok {
+		return types.Application{}, types.Student{}, errors.New("storage does not support admissions")
+	}
+	app, student, err := ad.ConvertApplication(ctx, id, student)
+	c.Invalidate()
+	return app, student, err
+}
+
+// DefineCustomField forwards to the wrapped storage (if it supports it)
+func (c *Cache) DefineCustomField(ctx context.Context, field types.CustomField) (types.CustomField, error) {
+	cf, ok := storage.As[storage.CustomFields](c.Storage)
+	if !ok {
+		return types.CustomField{}, errors.New("storage does not support custom fields")
+	}
+	return cf.DefineCustomField(ctx, field)
+}
+
+// ListCustomFields forwards to the wrapped storage (if it supports it)
+func (c *Cache) ListCustomFields(ctx context.Context) ([]types.CustomField, error) {
+	cf, ok := storage.As[storage.CustomFields](c.Storage)
+	if !ok {
+		return nil, errors.New("storage does not support custom fields")
+	}
+	return cf.ListCustomFields(ctx)
+}
+
+// DeleteCustomField forwards to the wrapped storage (if it supports it) and drops every cached
+// page, since the field's values leave every student
+func (c *Cache) DeleteCustomField(ctx context.Context, name string) error {
+	cf, ok := storage.As[storage.CustomFields](c.Storage)
+	if !ok {
+		return errors.New("storage does not support custom fields")
+	}
+	err := cf.DeleteCustomField(ctx, name)
+	c.Invalidate()
+	return err
+}
+
+// UpdateStudent forwards to the wrapped storage (if it supports it) and drops every cached
+// page, since the student may be on any of them; dry runs change nothing, so they keep them
+func (c *Cache) UpdateStudent(ctx context.Context, student types.Student) (types.Student, []string, error) {
+	u, ok := storage.As[storage.Updater](c.Storage)
+	if !ok {
+		return types.Student{}, nil, errors.New("storage does not support updates")
+	}
+	updated, changed, err := u.UpdateStudent(ctx, student)
+	if !storage.IsDryRun(ctx) {
+		c.Invalidate()
+	}
+	return updated, changed, err
+}
+
+// Invalidate drops every cached page
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	c.generation++
+	clear(c.pages)
+	c.count = countEntry{}
+	c.mu.Unlock()
+}
+
+// Stats returns hit/miss counters and the number of cached pages
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	entries := len(c.pages)
+	c.mu.Unlock()
+	return Stats{Entries: entries, Hits: c.hits.Load(), Misses: c.misses.Load(), Coalesced: c.coalesced.Load()}
+}
+
+// clone copies a page so callers can't mutate what's cached
+func clone(students []types.Student) []types.Student {
+	if students == nil {
+		return nil
+	}
+	return append([]types.Student(nil), students...)
+}
+
+// cloneStudent copies a shared student, custom fields included, so one caller changing it can't
+// affect the others
+func cloneStudent(v any) types.Student {
+	st, _ := v.(types.Student)
+	st.CustomFields = maps.Clone(st.CustomFields)
+	return st
+}
+
+// PatchStudents forwards to the wrapped storage (if it supports it) and drops every cached page
+func (c *Cache) PatchStudents(ctx context.Context, f *types.Filter, customFields map[string]any, max int) (types.BulkUpdate, error) {
+	b, ok := storage.As[storage.BulkUpdater](c.Storage)
+	if !ok {
+		return types.BulkUpdate{}, errors.New("storage does not support bulk updates")
+	}
+	result, err := b.PatchStudents(ctx, f, customFields, max)
+	if result.Updated > 0 {
+		c.Invalidate()
+	}
+	return result, err
+}
+
+// ImportProgress forwards to the wrapped storage (if it supports it)
+func (c *Cache) ImportProgress(ctx context.Context, jobID int64) (types.ImportProgress, error) {
+	i, ok := storage.As[storage.ImportCheckpointer](c.Storage)
+	if !ok {
+		return types.ImportProgress{}, errors.New("storage does not support import checkpoints")
+	}
+	return i.ImportProgress(ctx, jobID)
+}
+
+// ImportBatch forwards to the wrapped storage (if it supports it) and drops every cached page
+func (c *Cache) ImportBatch(ctx context.Context, jobID int64, students []types.Student, row int) (types.ImportProgress, []types.Student, error) {
+	i, ok := storage.As[storage.ImportCheckpointer](c.Storage)
+	if !ok {
+		return types.ImportProgress{}, nil, errors.New("storage does not support import checkpoints")
+	}
+	p, written, err := i.ImportBatch(ctx, jobID, students, row)
+	if len(written) > 0 {
+		c.Invalidate()
+	}
+	return p, written, err
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func TestCacheExpiresAfterTTL(t *testing.T) {
+	fake := storagetest.NewFake()
+	fake.Put(types.Student{Name: "A", Email: "a@example.com", Age: 20})
+
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := New(fake, 5*time.Second, 5)
+	c.Clock = clk
+
+	c.GetStudentsList(0, 20)
+	c.GetStudentsList(0, 20)
+	if got := fake.CallCount(storagetest.MethodGetStudentsList); got != 1 {
+		t.Fatalf("backend calls within TTL = %d, want 1", got)
+	}
+
+	clk.Advance(6 * time.Second)
+	c.GetStudentsList(0, 20)
+	if got := fake.CallCount(storagetest.MethodGetStudentsList); got != 2 {
+		t.Fatalf("backend calls after TTL = %d, want 2", got)
+	}
+}
+
+func TestCacheInvalidatedByWrite(t *testing.T) {
+	fake := storagetest.NewFake()
+	c := New(fake, time.Minute, 5)
+
+	c.GetStudentsList(0, 20)
+	if _, err := c.CreateStudent(types.Student{Name: "B", Email: "b@example.com", Age: 21}); err != nil {
+		t.Fatal(err)
+	}
+
+	page, _ := c.GetStudentsList(0, 20)
+	if len(page) != 1 {
+		t.Fatalf("page after write has %d students, want 1", len(page))
+	}
+}
+
+func TestCacheCapabilities(t *testing.T) {
+	fake := storagetest.NewFake()
+	c := New(fake, time.Minute, 5)
+
+	// Lending changes no student, so the cache leaves it to the wrapped storage
+	if library, ok := storage.As[storage.Library](c); !ok || library != storage.Library(fake) {
+		t.Fatalf("As[Library] = %T, %v, want the wrapped storage", library, ok)
+	}
+	if _, ok := storage.As[storage.Searcher](c); ok {
+		t.Fatal("As[Searcher] found a capability neither the cache nor the fake has")
+	}
+
+	// Graduation removes a student, so it has to go through the cache
+	alumni, ok := storage.As[storage.Alumni](c)
+	if !ok || alumni != storage.Alumni(c) {
+		t.Fatalf("As[Alumni] = %T, %v, want the cache", alumni, ok)
+	}
+	fake.Put(types.Student{ID: 1, Name: "A", Email: "a@example.com", Age: 20})
+	c.GetStudentsList(0, 20)
+	if _, err := alumni.GraduateStudent(context.Background(), 1, 2026, "2026-06-30"); err != nil {
+		t.Fatal(err)
+	}
+	if page, _ := c.GetStudentsList(0, 20); len(page) != 0 {
+		t.Fatalf("page after graduation has %d students, want 0", len(page))
+	}
+
+	// The cache only forwards graduation, so over a storage without alumni it has none either;
+	// the cached count is the cache's own
+	basic := New(struct{ storage.Storage }{fake}, time.Minute, 5)
+	if _, ok := storage.As[storage.Alumni](basic); ok {
+		t.Fatal("As[Alumni] found alumni the wrapped storage doesn't have")
+	}
+	if counter, ok := storage.As[storage.CachedCounter](basic); !ok || counter != storage.CachedCounter(basic) {
+		t.Fatalf("As[CachedCounter] = %T, %v, want the cache", counter, ok)
+	}
+}
+
+func TestCacheCountsWithStaleness(t *testing.T) {
+	fake := storagetest.NewFake()
+	fake.Put(types.Student{Name: "A", Email: "a@example.com", Age: 20})
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	c := New(fake, time.Minute, 5)
+	c.Clock = clk
+	c.CountTTL = 2 * time.Second
+
+	// A fresh count has no timestamp; the cached one says when it was taken
+	if n, asOf, err := c.CachedStudentsCount(); err != nil || n != 1 || !asOf.IsZero() {
+		t.Fatalf("first count = %d, %v, %v; want 1 counted now", n, asOf, err)
+	}
+	clk.Advance(time.Second)
+	if n, asOf, _ := c.CachedStudentsCount(); n != 1 || !asOf.Equal(start) {
+		t.Fatalf("cached count = %d as of %v, want 1 as of %v", n, asOf, start)
+	}
+	if got := fake.CallCount(storagetest.MethodGetStudentsCount); got != 1 {
+		t.Fatalf("backend counts within TTL = %d, want 1", got)
+	}
+
+	if _, err := c.CreateStudent(types.Student{Name: "B", Email: "b@example.com", Age: 21}); err != nil {
+		t.Fatal(err)
+	}
+	if n, asOf, _ := c.CachedStudentsCount(); n != 2 || !asOf.IsZero() {
+		t.Fatalf("count after write = %d as of %v, want 2 counted now", n, asOf)
+	}
+
+	clk.Advance(3 * time.Second)
+	c.GetStudentsCount()
+	if got := fake.CallCount(storagetest.MethodGetStudentsCount); got != 3 {
+		t.Fatalf("backend counts after TTL = %d, want 3", got)
+	}
+}
+
+func TestCacheCoalescesConcurrentReads(t *testing.T) {
+	fake := storagetest.NewFake()
+	id, _ := fake.CreateStudent(types.Student{Name: "A", Email: "a@example.com", Age: 20, CustomFields: map[string]any{"house": "red"}})
+	c := New(fake, time.Minute, 1)
+	fake.SetLatency(100 * time.Millisecond)
+
+	// Ten identical lookups and ten reads of an uncached page, all in flight at once
+	var wg sync.WaitGroup
+	students := make([]types.Student, 10)
+	for i := range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			students[i], _ = c.GetStudent(id)
+		}()
+		go func() {
+			defer wg.Done()
+			c.GetStudentsList(20, 20)
+		}()
+	}
+	wg.Wait()
+
+	if got := fake.CallCount(storagetest.MethodGetStudent); got != 1 {
+		t.Errorf("backend lookups = %d, want 1", got)
+	}
+	if got := fake.CallCount(storagetest.MethodGetStudentsList); got != 1 {
+		t.Errorf("backend list queries = %d, want 1", got)
+	}
+	if got := c.Stats().Coalesced; got != 20 {
+		t.Errorf("coalesced reads = %d, want 20", got)
+	}
+	// Each caller gets its own copy
+	students[0].CustomFields["house"] = "blue"
+	if students[1].Name != "A" || students[1].CustomFields["house"] != "red" {
+		t.Errorf("second caller's student = %+v, want it unaffected by the first's change", students[1])
+	}
+}
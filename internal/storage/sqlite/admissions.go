@@ -0,0 +1,198 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Admissions = (*Sqlite)(nil)
+
+// applicationQuery selects the columns scanned by scanApplication
+const applicationQuery = `SELECT a.id, a.public_id, a.name, a.email, a.age, a.date_of_birth, a.phone, a.status,
+		s.public_id, a.submitted_at, a.updated_at
+	FROM applications a
+	LEFT JOIN students s ON s.id = a.student_id`
+
+// SubmitApplication implements storage.Admissions
+func (s *Sqlite) SubmitApplication(ctx context.Context, app types.Application) (types.Application, error) {
+	if app.PublicID == "" {
+		app.PublicID = types.NewPublicID()
+	}
+	now := s.Clock.Now().UTC().Format(sqliteTime)
+	result, err := s.Db.ExecContext(ctx, `INSERT INTO applications
+		(public_id, name, email, age, date_of_birth, phone, status, submitted_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		app.PublicID, app.Name, app.Email, app.Age, nullString(app.DateOfBirth), nullString(app.Phone),
+		types.ApplicationSubmitted, now, now)
+	if err != nil {
+		return types.Application{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return types.Application{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return s.application(ctx, s.Db, "a.id = ?", id)
+}
+
+// GetApplicationByPublicID implements storage.Admissions
+func (s *Sqlite) GetApplicationByPublicID(ctx context.Context, publicID string) (types.Application, error) {
+	return s.application(ctx, s.Db, "a.public_id = ?", publicID)
+}
+
+// ListApplications implements storage.Admissions
+func (s *Sqlite) ListApplications(ctx context.Context, status string, offset, limit int) ([]types.Application, int64, error) {
+	where, args := "", []any{}
+	if status != "" {
+		where, args = " WHERE a.status = ?", append(args, status)
+	}
+
+	var total int64
+	if err := s.Db.QueryRowContext(ctx, "SELECT COUNT(*) FROM applications a"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	rows, err := s.Db.QueryContext(ctx, applicationQuery+where+" ORDER BY a.id LIMIT ? OFFSET ?", append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var apps []types.Application
+	for rows.Next() {
+		app, err := scanApplication(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		apps = append(apps, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return apps, total, nil
+}
+
+// SetApplicationStatus implements storage.Admissions. The update only matches an application in a
+// status the move is allowed from, so two reviewers acting at once can't both succeed.
+func (s *Sqlite) SetApplicationStatus(ctx context.Context, id int64, status string) (types.Application, error) {
+	var from []any
+	for prev, next := range types.ApplicationTransitions {
+		if slices.Contains(next, status) {
+			from = append(from, prev)
+		}
+	}
+	if len(from) > 0 {
+		n, err := s.exec(ctx, `UPDATE applications SET status = ?, updated_at = ?
+			WHERE id = ? AND status IN (?`+strings.Repeat(", ?", len(from)-1)+`)`,
+			append([]any{status, s.Clock.Now().UTC().Format(sqliteTime), id}, from...)...)
+		if err != nil {
+			return types.Application{}, err
+		}
+		if n == 1 {
+			return s.application(ctx, s.Db, "a.id = ?", id)
+		}
+	}
+	// Nothing changed: either there is no such application or its status doesn't allow the move
+	if _, err := s.application(ctx, s.Db, "a.id = ?", id); err != nil {
+		return types.Application{}, err
+	}
+	return types.Application{}, storage.ErrApplicationStatus
+}
+
+// ConvertApplication implements storage.Admissions
+func (s *Sqlite) ConvertApplication(ctx context.Context, id int64, student types.Student) (types.Application, types.Student, error) {
+	if student.PublicID == "" {
+		student.PublicID = s.ids().NewPublicID()
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return types.Application{}, types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	var (
+		status    string
+		converted sql.NullString
+	)
+	err = tx.QueryRowContext(ctx, "SELECT status, converted_at FROM applications WHERE id = ?", id).Scan(&status, &converted)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return types.Application{}, types.Student{}, storage.ErrApplicationNotFound
+	case err != nil:
+		return types.Application{}, types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	case converted.Valid:
+		return types.Application{}, types.Student{}, storage.ErrConverted
+	case status != types.ApplicationAccepted:
+		return types.Application{}, types.Student{}, storage.ErrApplicationStatus
+	}
+
+	now := s.Clock.Now().UTC().Format(sqliteTime)
+	result, err := tx.ExecContext(ctx, "INSERT INTO students (id, name, email, age, date_of_birth, phone, public_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		nullID(s.ids().NextID()), student.Name, student.Email, student.Age, nullString(student.DateOfBirth), nullString(student.Phone), student.PublicID, now, now)
+	if err != nil {
+		return types.Application{}, types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if student.ID, err = result.LastInsertId(); err != nil {
+		return types.Application{}, types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE applications SET student_id = ?, converted_at = ?, updated_at = ? WHERE id = ?",
+		student.ID, now, now, id)
+	if err != nil {
+		return types.Application{}, types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	app, err := s.application(ctx, tx, "a.id = ?", id)
+	if err != nil {
+		return types.Application{}, types.Student{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return types.Application{}, types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	student.DeriveAge(s.Clock.Now())
+	return app, student, nil
+}
+
+// querier is a *sql.DB or a *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// application reads the one application matching where, through q
+func (s *Sqlite) application(ctx context.Context, q querier, where string, arg any) (types.Application, error) {
+	app, err := scanApplication(q.QueryRowContext(ctx, applicationQuery+" WHERE "+where, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Application{}, storage.ErrApplicationNotFound
+	}
+	if err != nil {
+		return types.Application{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return app, nil
+}
+
+func scanApplication(row interface{ Scan(...any) error }) (types.Application, error) {
+	var (
+		a                      types.Application
+		dob, phone, student    sql.NullString
+		submittedAt, updatedAt string
+	)
+	err := row.Scan(&a.ID, &a.PublicID, &a.Name, &a.Email, &a.Age, &dob, &phone, &a.Status, &student, &submittedAt, &updatedAt)
+	if err != nil {
+		return types.Application{}, err
+	}
+	a.DateOfBirth, a.Phone, a.StudentPublicID = dob.String, phone.String, student.String
+	if a.SubmittedAt, err = time.Parse(sqliteTime, submittedAt); err != nil {
+		return types.Application{}, err
+	}
+	if a.UpdatedAt, err = time.Parse(sqliteTime, updatedAt); err != nil {
+		return types.Application{}, err
+	}
+	return a, nil
+}
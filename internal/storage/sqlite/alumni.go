@@ -0,0 +1,140 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Alumni = (*Sqlite)(nil)
+
+// alumnusQuery selects the columns scanned by scanAlumnus. The students row of an alumnus is
+// soft-deleted, so it is read without the deleted_at filter.
+const alumnusQuery = `SELECT s.id, s.public_id, s.name, s.email, s.age, s.date_of_birth, s.phone, a.class_of, a.graduated_on
+	FROM alumni a
+	JOIN students s ON s.id = a.student_id`
+
+// GraduateStudent implements storage.Alumni. The students row is soft-deleted like a merged
+// duplicate, so every student read, count and export skips it without a filter of its own.
+func (s *Sqlite) GraduateStudent(ctx context.Context, studentID int64, classOf int, graduatedOn string) (types.Alumnus, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return types.Alumnus{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	now := s.Clock.Now().UTC().Format(sqliteTime)
+	result, err := tx.ExecContext(ctx, "UPDATE students SET deleted_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL", now, now, studentID)
+	if err != nil {
+		return types.Alumnus{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return types.Alumnus{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return types.Alumnus{}, storage.ErrNotFound
+	}
+
+	stmts := []struct {
+		query string
+		args  []any
+	}{
+		{"INSERT INTO alumni (student_id, class_of, graduated_on) VALUES (?, ?, ?)", []any{studentID, classOf, graduatedOn}},
+		// Graduates leave their bed and bus seat to someone else
+		{"DELETE FROM allocations WHERE student_id = ?", []any{studentID}},
+		{"DELETE FROM bus_riders WHERE student_id = ?", []any{studentID}},
+	}
+	for _, st := range stmts {
+		if _, err := tx.ExecContext(ctx, st.query, st.args...); err != nil {
+			return types.Alumnus{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+	}
+
+	alumnus, err := scanAlumnus(tx.QueryRowContext(ctx, alumnusQuery+" WHERE a.student_id = ?", studentID), s.Clock.Now())
+	if err != nil {
+		return types.Alumnus{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return types.Alumnus{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return alumnus, nil
+}
+
+// GetAlumnusByPublicID implements storage.Alumni
+func (s *Sqlite) GetAlumnusByPublicID(ctx context.Context, publicID string) (types.Alumnus, error) {
+	alumnus, err := scanAlumnus(s.Db.QueryRowContext(ctx, alumnusQuery+" WHERE s.public_id = ?", publicID), s.Clock.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Alumnus{}, storage.ErrAlumnusNotFound
+	}
+	if err != nil {
+		return types.Alumnus{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return alumnus, nil
+}
+
+// ListAlumni implements storage.Alumni
+func (s *Sqlite) ListAlumni(ctx context.Context, f types.AlumniFilter, offset, limit int) ([]types.Alumnus, int64, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	if f.ClassOf != 0 {
+		conds = append(conds, "a.class_of = ?")
+		args = append(args, f.ClassOf)
+	}
+	if f.Query != "" {
+		conds = append(conds, "(instr(lower(s.name), lower(?)) > 0 OR instr(lower(s.email), lower(?)) > 0)")
+		args = append(args, f.Query, f.Query)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int64
+	err := s.Db.QueryRowContext(ctx, "SELECT COUNT(*) FROM alumni a JOIN students s ON s.id = a.student_id"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	rows, err := s.Db.QueryContext(ctx, alumnusQuery+where+" ORDER BY a.class_of DESC, s.name, s.id LIMIT ? OFFSET ?",
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	now := s.Clock.Now()
+	var alumni []types.Alumnus
+	for rows.Next() {
+		alumnus, err := scanAlumnus(rows, now)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		alumni = append(alumni, alumnus)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return alumni, total, nil
+}
+
+func scanAlumnus(row interface{ Scan(...any) error }, now time.Time) (types.Alumnus, error) {
+	var (
+		a          types.Alumnus
+		dob, phone sql.NullString
+	)
+	err := row.Scan(&a.ID, &a.PublicID, &a.Name, &a.Email, &a.Age, &dob, &phone, &a.ClassOf, &a.GraduatedOn)
+	if err != nil {
+		return types.Alumnus{}, err
+	}
+	a.DateOfBirth = dob.String
+	a.Phone = phone.String
+	a.DeriveAge(now)
+	return a, nil
+}
@@ -0,0 +1,200 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Announcements = (*Sqlite)(nil)
+
+// PostAnnouncement implements storage.Announcements. Recipients are chosen by the same SQL as
+// FilterStudents, inside the transaction, so a student created meanwhile is either in or out.
+func (s *Sqlite) PostAnnouncement(ctx context.Context, a types.Announcement, f *types.Filter) (types.Announcement, error) {
+	if a.PublicID == "" {
+		a.PublicID = types.NewPublicID()
+	}
+	if a.Channel == "" {
+		a.Channel = types.ChannelEmail
+	}
+	now := s.Clock.Now()
+	args := []any{sql.Named("today", now.UTC().Format(types.DateLayout))}
+	where := "deleted_at IS NULL"
+	if f != nil {
+		cond, err := filterSQL(*f, &args)
+		if err != nil {
+			return types.Announcement{}, err
+		}
+		where += " AND " + cond
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return types.Announcement{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	stamp := now.UTC().Format(sqliteTime)
+	result, err := tx.ExecContext(ctx, "INSERT INTO announcements (public_id, subject, body, channel, filter, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		a.PublicID, a.Subject, a.Body, a.Channel, a.Filter, stamp)
+	if err != nil {
+		return types.Announcement{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if a.ID, err = result.LastInsertId(); err != nil {
+		return types.Announcement{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO announcement_deliveries (announcement_id, student_id, status, updated_at)
+		SELECT :announcement, id, :status, :now FROM students WHERE `+where,
+		append(args, sql.Named("announcement", a.ID), sql.Named("status", types.DeliveryQueued), sql.Named("now", stamp))...)
+	if err != nil {
+		return types.Announcement{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	a, err = s.announcement(ctx, tx, "id = ?", a.ID)
+	if err != nil {
+		return types.Announcement{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return types.Announcement{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return a, nil
+}
+
+// GetAnnouncementByPublicID implements storage.Announcements
+func (s *Sqlite) GetAnnouncementByPublicID(ctx context.Context, publicID string) (types.Announcement, error) {
+	return s.announcement(ctx, s.Db, "public_id = ?", publicID)
+}
+
+// PendingDeliveries implements storage.Announcements. Recipients who graduated or were merged
+// since keep their delivery, so the students row is read without the deleted_at filter.
+func (s *Sqlite) PendingDeliveries(ctx context.Context, announcementID int64) ([]types.Delivery, error) {
+	rows, err := s.Db.QueryContext(ctx, `SELECT d.student_id, s.name, s.email, s.phone
+		FROM announcement_deliveries d
+		JOIN students s ON s.id = d.student_id
+		WHERE d.announcement_id = ? AND d.status = ?
+		ORDER BY d.student_id`, announcementID, types.DeliveryQueued)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var deliveries []types.Delivery
+	for rows.Next() {
+		var phone sql.NullString
+		d := types.Delivery{AnnouncementID: announcementID, Status: types.DeliveryQueued}
+		if err := rows.Scan(&d.StudentID, &d.Name, &d.Email, &phone); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		d.Phone = phone.String
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return deliveries, nil
+}
+
+// SetDeliveryStatus implements storage.Announcements
+func (s *Sqlite) SetDeliveryStatus(ctx context.Context, d types.Delivery) error {
+	_, err := s.exec(ctx, "UPDATE announcement_deliveries SET status = ?, message_id = ?, error = ?, updated_at = ? WHERE announcement_id = ? AND student_id = ?",
+		d.Status, nullString(d.MessageID), nullString(d.Error), s.Clock.Now().UTC().Format(sqliteTime), d.AnnouncementID, d.StudentID)
+	return err
+}
+
+// ReportDelivery implements storage.Announcements
+func (s *Sqlite) ReportDelivery(ctx context.Context, messageID, status, detail string) error {
+	n, err := s.exec(ctx, "UPDATE announcement_deliveries SET status = ?, error = ?, updated_at = ? WHERE message_id = ? AND status = ?",
+		status, nullString(detail), s.Clock.Now().UTC().Format(sqliteTime), messageID, types.DeliverySent)
+	if err != nil || n > 0 {
+		return err
+	}
+	// Nothing changed: either the outcome is recorded already, or the message isn't ours
+	var exists bool
+	if err := s.Db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM announcement_deliveries WHERE message_id = ?)", messageID).Scan(&exists); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if !exists {
+		return storage.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// StudentAnnouncements implements storage.Announcements
+func (s *Sqlite) StudentAnnouncements(ctx context.Context, studentID int64) ([]types.StudentAnnouncement, error) {
+	rows, err := s.Db.QueryContext(ctx, `SELECT a.public_id, a.subject, a.body, a.channel, a.created_at, d.status, d.error
+		FROM announcement_deliveries d
+		JOIN announcements a ON a.id = d.announcement_id
+		WHERE d.student_id = ?
+		ORDER BY a.id DESC`, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var list []types.StudentAnnouncement
+	for rows.Next() {
+		var (
+			a         types.StudentAnnouncement
+			createdAt string
+			detail    sql.NullString
+		)
+		if err := rows.Scan(&a.PublicID, &a.Subject, &a.Body, &a.Channel, &createdAt, &a.Status, &detail); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		if a.CreatedAt, err = time.Parse(sqliteTime, createdAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		a.Error = detail.String
+		list = append(list, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return list, nil
+}
+
+// announcement reads the one announcement matching where, through q, with its deliveries counted
+func (s *Sqlite) announcement(ctx context.Context, q querier, where string, arg any) (types.Announcement, error) {
+	var (
+		a         types.Announcement
+		createdAt string
+	)
+	err := q.QueryRowContext(ctx, "SELECT id, public_id, subject, body, channel, filter, created_at FROM announcements WHERE "+where, arg).
+		Scan(&a.ID, &a.PublicID, &a.Subject, &a.Body, &a.Channel, &a.Filter, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Announcement{}, storage.ErrAnnouncementNotFound
+	}
+	if err != nil {
+		return types.Announcement{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if a.CreatedAt, err = time.Parse(sqliteTime, createdAt); err != nil {
+		return types.Announcement{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT status, COUNT(*) FROM announcement_deliveries WHERE announcement_id = ? GROUP BY status", a.ID)
+	if err != nil {
+		return types.Announcement{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+	a.Deliveries = map[string]int{types.DeliveryQueued: 0, types.DeliverySent: 0, types.DeliveryDelivered: 0, types.DeliveryFailed: 0}
+	for rows.Next() {
+		var (
+			status string
+			n      int
+		)
+		if err := rows.Scan(&status, &n); err != nil {
+			return types.Announcement{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		a.Deliveries[status] = n
+		a.Recipients += n
+	}
+	if err := rows.Err(); err != nil {
+		return types.Announcement{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return a, nil
+}
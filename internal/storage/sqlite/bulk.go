@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.BulkUpdater = (*Sqlite)(nil)
+
+// PatchStudents implements storage.BulkUpdater with json_patch, which is RFC 7396. Only students
+// whose values change are written, so the rest keep their updated_at.
+func (s *Sqlite) PatchStudents(ctx context.Context, f *types.Filter, customFields map[string]any, max int) (types.BulkUpdate, error) {
+	patch, err := json.Marshal(customFields)
+	if err != nil {
+		return types.BulkUpdate{}, fmt.Errorf("%w: custom fields: %v", storage.ErrInvalidData, err)
+	}
+	where := "deleted_at IS NULL"
+	var args []any
+	if f != nil {
+		cond, err := filterSQL(*f, &args)
+		if err != nil {
+			return types.BulkUpdate{}, err
+		}
+		where += " AND " + cond
+	}
+	args = append(args, sql.Named("today", s.Clock.Now().UTC().Format(types.DateLayout)))
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return types.BulkUpdate{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	var result types.BulkUpdate
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM students WHERE "+where, args...).Scan(&result.Matched); err != nil {
+		return types.BulkUpdate{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if max >= 0 && result.Matched > int64(max) {
+		return result, storage.ErrConfirmationRequired
+	}
+
+	const patched = "NULLIF(json_patch(COALESCE(custom_fields, '{}'), :patch), '{}')"
+	res, err := tx.ExecContext(ctx, "UPDATE students SET custom_fields = "+patched+", updated_at = :now"+
+		" WHERE "+where+" AND "+patched+" IS NOT custom_fields",
+		append(args, sql.Named("patch", string(patch)), sql.Named("now", s.Clock.Now().UTC().Format(sqliteTime)))...)
+	if err != nil {
+		return types.BulkUpdate{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if result.Updated, err = res.RowsAffected(); err != nil {
+		return types.BulkUpdate{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return types.BulkUpdate{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Changes = (*Sqlite)(nil)
+
+// StudentChanges implements storage.Changes. Soft-deleted students (merged away or graduated) are
+// reported as deleted, and so are the tombstones of hard-deleted ones. The current second, as
+// Clock tells it, is left out.
+func (s *Sqlite) StudentChanges(ctx context.Context, since time.Time, after string, limit int) ([]types.StudentChange, error) {
+	tx, err := s.Db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	now := s.Clock.Now()
+	from := since.UTC().Format(sqliteTime)
+	var purged bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tombstones_purged WHERE before > ?)", from).Scan(&purged)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if purged {
+		return nil, storage.ErrChangesExpired
+	}
+
+	// Tombstones are padded to studentCols; only their public ID is read
+	rows, err := tx.QueryContext(ctx, `SELECT * FROM (
+			SELECT `+studentCols+`, created_at, updated_at, deleted_at IS NOT NULL FROM students
+			WHERE (updated_at > :since OR (updated_at = :since AND public_id > :after)) AND updated_at < :until
+			UNION ALL
+			SELECT 0, public_id, '', '', 0, NULL, NULL, NULL, deleted_at, deleted_at, 1 FROM student_tombstones
+			WHERE (deleted_at > :since OR (deleted_at = :since AND public_id > :after)) AND deleted_at < :until
+		)
+		ORDER BY updated_at, public_id
+		LIMIT :limit`,
+		sql.Named("since", from), sql.Named("after", after), sql.Named("until", now.UTC().Format(sqliteTime)), sql.Named("limit", limit))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var changes []types.StudentChange
+	for rows.Next() {
+		change, err := scanChange(rows, since, now)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return changes, nil
+}
+
+// scanChange reads a row of studentCols, created_at, updated_at and whether the student is deleted
+func scanChange(row interface{ Scan(...any) error }, since, now time.Time) (types.StudentChange, error) {
+	var (
+		createdAt, updatedAt string
+		deleted              bool
+	)
+	student, err := scanStudent(row, now, &createdAt, &updatedAt, &deleted)
+	if err != nil {
+		return types.StudentChange{}, err
+	}
+	change := types.StudentChange{ID: student.PublicID}
+	if change.ChangedAt, err = time.Parse(sqliteTime, updatedAt); err != nil {
+		return types.StudentChange{}, err
+	}
+	if deleted {
+		change.Change = types.ChangeDeleted
+		return change, nil
+	}
+	created, err := time.Parse(sqliteTime, createdAt)
+	if err != nil {
+		return types.StudentChange{}, err
+	}
+	change.Change = types.ChangeUpdated
+	if !created.Before(since.UTC().Truncate(time.Second)) {
+		change.Change = types.ChangeCreated
+	}
+	change.Student = &student
+	return change, nil
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.CustomFields = (*Sqlite)(nil)
+
+// DefineCustomField implements storage.CustomFields
+func (s *Sqlite) DefineCustomField(ctx context.Context, field types.CustomField) (types.CustomField, error) {
+	result, err := s.Db.ExecContext(ctx, "INSERT INTO custom_fields (name, type, required) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+		field.Name, field.Type, field.Required)
+	if err != nil {
+		return types.CustomField{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return types.CustomField{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return types.CustomField{}, storage.ErrDuplicate
+	}
+	return field, nil
+}
+
+// ListCustomFields implements storage.CustomFields
+func (s *Sqlite) ListCustomFields(ctx context.Context) ([]types.CustomField, error) {
+	rows, err := s.Db.QueryContext(ctx, "SELECT name, type, required FROM custom_fields ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var fields []types.CustomField
+	for rows.Next() {
+		var f types.CustomField
+		if err := rows.Scan(&f.Name, &f.Type, &f.Required); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		fields = append(fields, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return fields, nil
+}
+
+// DeleteCustomField implements storage.CustomFields. The definition and the values go in one
+// transaction, so no student is left with a value for a field that doesn't exist.
+func (s *Sqlite) DeleteCustomField(ctx context.Context, name string) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM custom_fields WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return storage.ErrCustomFieldNotFound
+	}
+
+	// The path is bound, not spliced in; a student left without any value goes back to NULL
+	if _, err := tx.ExecContext(ctx, `UPDATE students SET custom_fields = NULLIF(json_remove(custom_fields, :path), '{}'), updated_at = :now
+		WHERE json_type(custom_fields, :path) IS NOT NULL`,
+		sql.Named("path", customFieldPath(name)), sql.Named("now", s.Clock.Now().UTC().Format(sqliteTime))); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return nil
+}
+
+// customFieldPath is the JSON path of a custom field's value in students.custom_fields. It is
+// always bound as a parameter; names are checked on definition, and quoting keeps any name a key.
+func customFieldPath(name string) string {
+	return `$."` + name + `"`
+}
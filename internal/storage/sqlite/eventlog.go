@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.EventLog = (*Sqlite)(nil)
+
+// AppendEvent implements storage.EventLog. The entity's counter and the event are written in one
+// transaction, so a number is never handed out without its event; within InTx, in the write's.
+func (s *Sqlite) AppendEvent(ctx context.Context, e types.FeedEvent) (types.FeedEvent, error) {
+	e.CreatedAt = s.Clock.Now().UTC().Truncate(time.Second)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return types.FeedEvent{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO event_sequences (entity, seq) VALUES (?, 1)
+		ON CONFLICT (entity) DO UPDATE SET seq = seq + 1 RETURNING seq`, e.Entity).Scan(&e.Seq)
+	if err != nil {
+		return types.FeedEvent{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO events (entity, seq, type, data, created_at) VALUES (?, ?, ?, ?, ?)",
+		e.Entity, e.Seq, e.Type, string(e.Data), e.CreatedAt.Format(sqliteTime))
+	if err != nil {
+		return types.FeedEvent{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return types.FeedEvent{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return e, nil
+}
+
+// EventsAfter implements storage.EventLog
+func (s *Sqlite) EventsAfter(ctx context.Context, entity string, afterSeq int64, limit int) ([]types.FeedEvent, int64, error) {
+	tx, err := s.Db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	var last int64
+	err = tx.QueryRowContext(ctx, "SELECT seq FROM event_sequences WHERE entity = ?", entity).Scan(&last)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT entity, seq, type, data, created_at FROM events WHERE entity = ? AND seq > ? ORDER BY seq LIMIT ?",
+		entity, afterSeq, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var events []types.FeedEvent
+	for rows.Next() {
+		var (
+			e         types.FeedEvent
+			data      string
+			createdAt string
+		)
+		if err := rows.Scan(&e.Entity, &e.Seq, &e.Type, &data, &createdAt); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		e.Data = json.RawMessage(data)
+		if e.CreatedAt, err = time.Parse(sqliteTime, createdAt); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	// Numbers are handed out without gaps, so a missing next one has been purged
+	if afterSeq < last && (len(events) == 0 || events[0].Seq != afterSeq+1) {
+		return nil, last, storage.ErrEventsExpired
+	}
+	return events, last, nil
+}
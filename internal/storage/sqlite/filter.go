@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/filter"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// filterColumns translate the filter allowlist. Optional columns are NULL when empty, so they
+// compare through COALESCE to let `phone = ""` find students without a phone.
+var filterColumns = map[string]string{
+	filter.FieldName:        "name",
+	filter.FieldEmail:       "email",
+	filter.FieldPhone:       "COALESCE(phone, '')",
+	filter.FieldAge:         "(" + ageExpr + ")",
+	filter.FieldDateOfBirth: "COALESCE(date_of_birth, '')",
+}
+
+var filterOps = map[string]string{
+	filter.OpEq: "=", filter.OpNe: "<>", filter.OpLt: "<", filter.OpLe: "<=", filter.OpGt: ">", filter.OpGe: ">=",
+}
+
+// FilterStudents implements storage.Filterer
+func (s *Sqlite) FilterStudents(ctx context.Context, f types.Filter, offset, limit int) ([]types.Student, int64, error) {
+	var args []any
+	where, err := filterSQL(f, &args)
+	if err != nil {
+		return nil, 0, err
+	}
+	where = "deleted_at IS NULL AND " + where
+	now := s.Clock.Now()
+	args = append(args, sql.Named("today", now.UTC().Format(types.DateLayout)))
+
+	var total int64
+	if err := s.Db.QueryRowContext(ctx, "SELECT COUNT(*) FROM students WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	rows, err := s.Db.QueryContext(ctx, "SELECT "+studentCols+" FROM students WHERE "+where+" ORDER BY id LIMIT :limit OFFSET :offset",
+		append(args, sql.Named("limit", limit), sql.Named("offset", offset))...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var students []types.Student
+	for rows.Next() {
+		student, err := scanStudent(rows, now)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		students = append(students, student)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return students, total, nil
+}
+
+// filterSQL translates f into a WHERE condition built only from the fixed strings above; every
+// value is appended to args and bound as a named parameter (:f0, :f1, ...). Named parameters
+// because ageExpr already binds :today, and SQLite numbers ? and :name parameters together.
+func filterSQL(f types.Filter, args *[]any) (string, error) {
+	switch f.Op {
+	case filter.OpAnd, filter.OpOr:
+		parts := make([]string, len(f.Args))
+		for i, a := range f.Args {
+			part, err := filterSQL(a, args)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(f.Op)+" ") + ")", nil
+	case filter.OpNot:
+		if len(f.Args) != 1 {
+			return "", fmt.Errorf("%w: not takes one operand", storage.ErrInvalidData)
+		}
+		part, err := filterSQL(f.Args[0], args)
+		if err != nil {
+			return "", err
+		}
+		return "(NOT " + part + ")", nil
+	}
+
+	if name, ok := strings.CutPrefix(f.Field, filter.CustomPrefix); ok {
+		return customFilterSQL(f, name, args)
+	}
+
+	col, ok := filterColumns[f.Field]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown filter field %q", storage.ErrInvalidData, f.Field)
+	}
+	param := fmt.Sprintf(":f%d", len(*args))
+	*args = append(*args, sql.Named(param[1:], f.Value))
+	if f.Op == filter.OpContains {
+		return "(instr(lower(" + col + "), lower(" + param + ")) > 0)", nil
+	}
+	op, ok := filterOps[f.Op]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown filter operator %q", storage.ErrInvalidData, f.Op)
+	}
+	// Ordering never matches a missing date of birth, which COALESCE turns into ""
+	if f.Field == filter.FieldDateOfBirth && f.Op != filter.OpEq && f.Op != filter.OpNe {
+		return "(date_of_birth IS NOT NULL AND " + col + " " + op + " " + param + ")", nil
+	}
+	return "(" + col + " " + op + " " + param + ")", nil
+}
+
+// customFilterSQL translates a comparison of a custom field. Both the field's JSON path and the
+// value are bound, so neither reaches the query text.
+func customFilterSQL(f types.Filter, name string, args *[]any) (string, error) {
+	path := fmt.Sprintf(":f%d", len(*args))
+	*args = append(*args, sql.Named(path[1:], customFieldPath(name)))
+	param := fmt.Sprintf(":f%d", len(*args))
+	*args = append(*args, sql.Named(param[1:], f.Value))
+
+	value := "json_extract(custom_fields, " + path + ")"
+	present := "json_type(custom_fields, " + path + ") IS NOT NULL"
+	if _, ok := f.Value.(string); ok {
+		// Like phone, a missing string or date compares as ""
+		col := "COALESCE(" + value + ", '')"
+		if f.Op == filter.OpContains {
+			return "(instr(lower(" + col + "), lower(" + param + ")) > 0)", nil
+		}
+		op, ok := filterOps[f.Op]
+		if !ok {
+			return "", fmt.Errorf("%w: unknown filter operator %q", storage.ErrInvalidData, f.Op)
+		}
+		if f.Op != filter.OpEq && f.Op != filter.OpNe {
+			return "(" + present + " AND " + col + " " + op + " " + param + ")", nil
+		}
+		return "(" + col + " " + op + " " + param + ")", nil
+	}
+
+	// Numbers and booleans (stored by SQLite's JSON functions as 1 and 0) never match a missing value
+	op, ok := filterOps[f.Op]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown filter operator %q", storage.ErrInvalidData, f.Op)
+	}
+	return "(" + present + " AND " + value + " " + op + " " + param + ")", nil
+}
@@ -0,0 +1,267 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Housing = (*Sqlite)(nil)
+
+// roomColumns are scanned by scanRoom
+const roomColumns = `r.id, r.public_id, h.id, h.public_id, r.number, r.capacity,
+	(SELECT COUNT(*) FROM allocations WHERE room_id = r.id)`
+
+// allocationQuery selects the columns scanned by scanAllocation
+const allocationQuery = `SELECT r.id, r.public_id, r.number, s.id, s.public_id, s.name, a.allocated_at
+	FROM allocations a
+	JOIN rooms r ON r.id = a.room_id
+	JOIN students s ON s.id = a.student_id`
+
+// AddHostel implements storage.Housing
+func (s *Sqlite) AddHostel(ctx context.Context, hostel types.Hostel) (types.Hostel, error) {
+	if hostel.PublicID == "" {
+		hostel.PublicID = types.NewPublicID()
+	}
+	result, err := s.Db.ExecContext(ctx, "INSERT INTO hostels (public_id, name) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		hostel.PublicID, hostel.Name)
+	if err != nil {
+		return types.Hostel{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return types.Hostel{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return types.Hostel{}, storage.ErrDuplicate
+	}
+	if hostel.ID, err = result.LastInsertId(); err != nil {
+		return types.Hostel{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return hostel, nil
+}
+
+// GetHostelByPublicID implements storage.Housing
+func (s *Sqlite) GetHostelByPublicID(ctx context.Context, publicID string) (types.Hostel, error) {
+	var h types.Hostel
+	err := s.Db.QueryRowContext(ctx, "SELECT id, public_id, name FROM hostels WHERE public_id = ?", publicID).
+		Scan(&h.ID, &h.PublicID, &h.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Hostel{}, storage.ErrHostelNotFound
+	}
+	if err != nil {
+		return types.Hostel{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return h, nil
+}
+
+// AddRoom implements storage.Housing
+func (s *Sqlite) AddRoom(ctx context.Context, hostelID int64, room types.Room) (types.Room, error) {
+	if room.PublicID == "" {
+		room.PublicID = types.NewPublicID()
+	}
+	var hostels int
+	if err := s.Db.QueryRowContext(ctx, "SELECT COUNT(*) FROM hostels WHERE id = ?", hostelID).Scan(&hostels); err != nil {
+		return types.Room{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if hostels == 0 {
+		return types.Room{}, storage.ErrHostelNotFound
+	}
+
+	result, err := s.Db.ExecContext(ctx, `INSERT INTO rooms (public_id, hostel_id, number, capacity) VALUES (?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`, room.PublicID, hostelID, room.Number, room.Capacity)
+	if err != nil {
+		return types.Room{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return types.Room{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return types.Room{}, storage.ErrDuplicate
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return types.Room{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return s.room(ctx, "r.id = ?", id)
+}
+
+// GetRoomByPublicID implements storage.Housing
+func (s *Sqlite) GetRoomByPublicID(ctx context.Context, publicID string) (types.Room, error) {
+	return s.room(ctx, "r.public_id = ?", publicID)
+}
+
+// HostelRooms implements storage.Housing
+func (s *Sqlite) HostelRooms(ctx context.Context, hostelID int64) ([]types.Room, error) {
+	rows, err := s.Db.QueryContext(ctx, "SELECT "+roomColumns+`
+		FROM rooms r JOIN hostels h ON h.id = r.hostel_id
+		WHERE r.hostel_id = ? ORDER BY r.number`, hostelID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var rooms []types.Room
+	for rows.Next() {
+		room, err := scanRoom(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		rooms = append(rooms, room)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return rooms, nil
+}
+
+// AllocateRoom implements storage.Housing. The insert only happens while the room has a free bed,
+// and the transaction begins IMMEDIATE (see dsn), so two allocations racing for the last bed run
+// one after the other: one wins and the other sees the room full.
+func (s *Sqlite) AllocateRoom(ctx context.Context, roomID, studentID int64) (types.Allocation, error) {
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Allocation{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	var rooms, students, allocated int
+	err = tx.QueryRowContext(ctx, `SELECT
+			(SELECT COUNT(*) FROM rooms WHERE id = ?),
+			(SELECT COUNT(*) FROM students WHERE id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM allocations WHERE student_id = ?)`, roomID, studentID, studentID).
+		Scan(&rooms, &students, &allocated)
+	if err != nil {
+		return types.Allocation{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	switch {
+	case rooms == 0:
+		return types.Allocation{}, storage.ErrRoomNotFound
+	case students == 0:
+		return types.Allocation{}, storage.ErrNotFound
+	case allocated > 0:
+		return types.Allocation{}, storage.ErrHasRoom
+	}
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO allocations (room_id, student_id, allocated_at)
+		SELECT r.id, ?, ? FROM rooms r
+		WHERE r.id = ? AND (SELECT COUNT(*) FROM allocations WHERE room_id = r.id) < r.capacity`,
+		studentID, s.Clock.Now().UTC().Format(sqliteTime), roomID)
+	if err != nil {
+		return types.Allocation{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return types.Allocation{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n == 0 {
+		return types.Allocation{}, storage.ErrRoomFull
+	}
+
+	allocation, err := scanAllocation(tx.QueryRowContext(ctx, allocationQuery+" WHERE a.student_id = ?", studentID))
+	if err != nil {
+		return types.Allocation{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return types.Allocation{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return allocation, nil
+}
+
+// VacateRoom implements storage.Housing
+func (s *Sqlite) VacateRoom(ctx context.Context, roomID, studentID int64) error {
+	n, err := s.exec(ctx, "DELETE FROM allocations WHERE room_id = ? AND student_id = ?", roomID, studentID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// RoomAllocations implements storage.Housing
+func (s *Sqlite) RoomAllocations(ctx context.Context, roomID int64) ([]types.Allocation, error) {
+	rows, err := s.Db.QueryContext(ctx, allocationQuery+" WHERE a.room_id = ? ORDER BY a.allocated_at, a.rowid", roomID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var allocations []types.Allocation
+	for rows.Next() {
+		allocation, err := scanAllocation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		allocations = append(allocations, allocation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return allocations, nil
+}
+
+// HostelOccupancy implements storage.Housing with one grouping query; hostels without rooms are listed too
+func (s *Sqlite) HostelOccupancy(ctx context.Context) ([]types.HostelOccupancy, error) {
+	rows, err := s.Db.QueryContext(ctx, `
+		SELECT h.public_id, h.name, COUNT(r.id), COALESCE(SUM(r.capacity), 0),
+			(SELECT COUNT(*) FROM allocations a JOIN rooms ar ON ar.id = a.room_id WHERE ar.hostel_id = h.id)
+		FROM hostels h LEFT JOIN rooms r ON r.hostel_id = h.id
+		GROUP BY h.id
+		ORDER BY h.name`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var report []types.HostelOccupancy
+	for rows.Next() {
+		var o types.HostelOccupancy
+		if err := rows.Scan(&o.HostelPublicID, &o.Name, &o.Rooms, &o.Capacity, &o.Occupied); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		o.Vacant = o.Capacity - o.Occupied
+		report = append(report, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return report, nil
+}
+
+// room reads the one room matching where
+func (s *Sqlite) room(ctx context.Context, where string, arg any) (types.Room, error) {
+	room, err := scanRoom(s.Db.QueryRowContext(ctx, "SELECT "+roomColumns+`
+		FROM rooms r JOIN hostels h ON h.id = r.hostel_id WHERE `+where, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Room{}, storage.ErrRoomNotFound
+	}
+	if err != nil {
+		return types.Room{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return room, nil
+}
+
+func scanRoom(row interface{ Scan(...any) error }) (types.Room, error) {
+	var r types.Room
+	err := row.Scan(&r.ID, &r.PublicID, &r.HostelID, &r.HostelPublicID, &r.Number, &r.Capacity, &r.Occupied)
+	return r, err
+}
+
+func scanAllocation(row interface{ Scan(...any) error }) (types.Allocation, error) {
+	var (
+		a         types.Allocation
+		allocated string
+	)
+	err := row.Scan(&a.RoomID, &a.RoomPublicID, &a.RoomNumber, &a.StudentID, &a.StudentPublicID, &a.StudentName, &allocated)
+	if err != nil {
+		return types.Allocation{}, err
+	}
+	if a.AllocatedAt, err = time.Parse(sqliteTime, allocated); err != nil {
+		return types.Allocation{}, err
+	}
+	return a, nil
+}
@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.ImportCheckpointer = (*Sqlite)(nil)
+
+// ImportProgress reads the checkpoint ImportBatch keeps in the job's row
+func (s *Sqlite) ImportProgress(ctx context.Context, jobID int64) (types.ImportProgress, error) {
+	return importProgress(ctx, s.Db, jobID)
+}
+
+// ImportBatch implements storage.ImportCheckpointer. Existing emails are looked up through the
+// students_email index, so a batch costs the same however many students there already are.
+func (s *Sqlite) ImportBatch(ctx context.Context, jobID int64, students []types.Student, row int) (types.ImportProgress, []types.Student, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return types.ImportProgress{}, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	p, err := importProgress(ctx, tx, jobID)
+	if err != nil {
+		return types.ImportProgress{}, nil, err
+	}
+
+	seen, err := existingEmails(ctx, tx.Tx, students)
+	if err != nil {
+		return types.ImportProgress{}, nil, err
+	}
+	fresh := make([]types.Student, 0, len(students))
+	for _, st := range students {
+		email := strings.ToLower(st.Email)
+		if seen[email] {
+			p.Skipped++
+			continue
+		}
+		seen[email] = true
+		if st.PublicID == "" {
+			st.PublicID = s.ids().NewPublicID()
+		}
+		fresh = append(fresh, st)
+	}
+
+	ids, err := insertStudents(ctx, tx.Tx, s.ids(), fresh, s.Clock.Now())
+	if err != nil {
+		return types.ImportProgress{}, nil, err
+	}
+	for i := range fresh {
+		fresh[i].ID = ids[i]
+	}
+	p.Row = row
+	p.Imported += len(fresh)
+
+	progress, err := json.Marshal(p)
+	if err != nil {
+		return types.ImportProgress{}, nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE jobs SET progress = ?, updated_at = ? WHERE id = ?",
+		string(progress), s.Clock.Now().UnixMilli(), jobID); err != nil {
+		return types.ImportProgress{}, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return types.ImportProgress{}, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return p, fresh, nil
+}
+
+// importProgress reads a job's checkpoint through q; ErrJobNotFound if there is no such job
+func importProgress(ctx context.Context, q querier, jobID int64) (types.ImportProgress, error) {
+	var (
+		p        types.ImportProgress
+		progress sql.NullString
+	)
+	err := q.QueryRowContext(ctx, "SELECT progress FROM jobs WHERE id = ?", jobID).Scan(&progress)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, fmt.Errorf("%w: id %d", storage.ErrJobNotFound, jobID)
+	}
+	if err != nil {
+		return p, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if progress.Valid {
+		if err := json.Unmarshal([]byte(progress.String), &p); err != nil {
+			return p, fmt.Errorf("%w: job %d progress: %v", storage.ErrDatabase, jobID, err)
+		}
+	}
+	return p, nil
+}
+
+// existingEmails returns which of the students' emails, lowercased, live students already have
+func existingEmails(ctx context.Context, tx *sql.Tx, students []types.Student) (map[string]bool, error) {
+	seen := make(map[string]bool, len(students))
+	for start := 0; start < len(students); start += maxSQLParams {
+		chunk := students[start:min(start+maxSQLParams, len(students))]
+		args := make([]any, len(chunk))
+		for i, st := range chunk {
+			args[i] = strings.ToLower(st.Email)
+		}
+		rows, err := tx.QueryContext(ctx, "SELECT DISTINCT lower(email) FROM students WHERE deleted_at IS NULL AND lower(email) IN (?"+
+			strings.Repeat(", ?", len(chunk)-1)+")", args...)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		for rows.Next() {
+			var email string
+			if err := rows.Scan(&email); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+			}
+			seen[email] = true
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+	}
+	return seen, nil
+}
@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.JobQueue = (*Sqlite)(nil)
+
+const jobColumns = "id, kind, payload, status, attempts, max_attempts, run_at, last_error, result, progress, created_at, updated_at"
+
+func (s *Sqlite) EnqueueJob(ctx context.Context, kind string, payload []byte, maxAttempts int, runAt time.Time) (int64, error) {
+	now := s.Clock.Now().UnixMilli()
+	result, err := s.Db.ExecContext(ctx,
+		"INSERT INTO jobs (kind, payload, max_attempts, run_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		kind, payload, maxAttempts, runAt.UnixMilli(), now, now)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return result.LastInsertId()
+}
+
+func (s *Sqlite) GetJob(ctx context.Context, id int64) (types.Job, error) {
+	job, err := scanJob(s.Db.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Job{}, fmt.Errorf("%w: id %d", storage.ErrJobNotFound, id)
+	}
+	if err != nil {
+		return types.Job{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return job, nil
+}
+
+func (s *Sqlite) ClaimJob(ctx context.Context, now, leaseUntil time.Time) (types.Job, error) {
+	nowMs := now.UnixMilli()
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Job{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	// A job whose worker died mid-run on its last attempt has nobody left to retry it
+	if _, err := tx.ExecContext(ctx, `UPDATE jobs SET status = 'dead', last_error = 'lease expired on final attempt', locked_until = NULL, updated_at = ?
+		WHERE status = 'running' AND locked_until <= ? AND attempts >= max_attempts`, nowMs, nowMs); err != nil {
+		return types.Job{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	// Select and lease in one statement, so two workers can never claim the same job
+	job, err := scanJob(tx.QueryRowContext(ctx, `UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_until = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = 'queued' AND run_at <= ?) OR (status = 'running' AND locked_until <= ?)
+			ORDER BY run_at, id LIMIT 1
+		)
+		RETURNING `+jobColumns, leaseUntil.UnixMilli(), nowMs, nowMs, nowMs))
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := tx.Commit(); err != nil {
+			return types.Job{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		return types.Job{}, storage.ErrNoJobDue
+	}
+	if err != nil {
+		return types.Job{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.Job{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return job, nil
+}
+
+func (s *Sqlite) CompleteJob(ctx context.Context, id int64, attempt int, result []byte, now time.Time) error {
+	return s.updateJob(ctx, id, attempt, `UPDATE jobs SET status = 'succeeded', result = ?, last_error = NULL, locked_until = NULL, updated_at = ?`+leased,
+		result, now.UnixMilli(), id, attempt)
+}
+
+func (s *Sqlite) RetryJob(ctx context.Context, id int64, attempt int, errMsg string, runAt, now time.Time) error {
+	return s.updateJob(ctx, id, attempt, `UPDATE jobs SET status = 'queued', last_error = ?, run_at = ?, locked_until = NULL, updated_at = ?`+leased,
+		errMsg, runAt.UnixMilli(), now.UnixMilli(), id, attempt)
+}
+
+func (s *Sqlite) KillJob(ctx context.Context, id int64, attempt int, errMsg string, now time.Time) error {
+	return s.updateJob(ctx, id, attempt, `UPDATE jobs SET status = 'dead', last_error = ?, locked_until = NULL, updated_at = ?`+leased,
+		errMsg, now.UnixMilli(), id, attempt)
+}
+
+// leased limits a job update to the run that holds the lease: ClaimJob counts every attempt, so
+// a worker whose lease expired and was claimed again has a stale attempt
+const leased = ` WHERE id = ? AND status = 'running' AND attempts = ?`
+
+// updateJob runs a job update restricted by leased; ErrJobNotFound or ErrLeaseLost if it changed
+// nothing
+func (s *Sqlite) updateJob(ctx context.Context, id int64, attempt int, query string, args ...any) error {
+	result, err := s.Db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		return nil
+	}
+	if _, err := s.GetJob(ctx, id); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: job %d attempt %d", storage.ErrLeaseLost, id, attempt)
+}
+
+// scanJob reads one row of jobColumns
+func scanJob(row interface{ Scan(...any) error }) (types.Job, error) {
+	var (
+		job                         types.Job
+		runAt, createdAt, updatedAt int64
+		lastError                   sql.NullString
+		payload, result             []byte
+		progress                    sql.NullString
+	)
+	err := row.Scan(&job.ID, &job.Kind, &payload, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&runAt, &lastError, &result, &progress, &createdAt, &updatedAt)
+	if err != nil {
+		return types.Job{}, err
+	}
+	job.Payload = payload
+	job.Result = result
+	if progress.Valid {
+		job.Progress = json.RawMessage(progress.String)
+	}
+	job.LastError = lastError.String
+	job.RunAt = time.UnixMilli(runAt).UTC()
+	job.CreatedAt = time.UnixMilli(createdAt).UTC()
+	job.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+	return job, nil
+}
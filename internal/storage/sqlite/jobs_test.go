@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func newTestSqlite(t *testing.T) *Sqlite {
+	t.Helper()
+	s, err := NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewSqlite: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestJobQueueLifecycle(t *testing.T) {
+	s := newTestSqlite(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	id, err := s.EnqueueJob(ctx, "import", []byte(`{"file":"a.csv"}`), 2, now)
+	if err != nil {
+		t.Fatalf("EnqueueJob: %v", err)
+	}
+	if _, err := s.EnqueueJob(ctx, "import", []byte(`{}`), 2, now.Add(time.Hour)); err != nil {
+		t.Fatalf("EnqueueJob: %v", err)
+	}
+
+	job, err := s.ClaimJob(ctx, now, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("ClaimJob: %v", err)
+	}
+	if job.ID != id || job.Status != types.JobRunning || job.Attempts != 1 || string(job.Payload) != `{"file":"a.csv"}` {
+		t.Fatalf("claimed %+v", job)
+	}
+
+	// The other job isn't due and the first is leased
+	if _, err := s.ClaimJob(ctx, now, now.Add(time.Minute)); !errors.Is(err, storage.ErrNoJobDue) {
+		t.Fatalf("second ClaimJob error = %v, want %v", err, storage.ErrNoJobDue)
+	}
+
+	if err := s.RetryJob(ctx, id, 1, "timeout", now.Add(time.Second), now); err != nil {
+		t.Fatalf("RetryJob: %v", err)
+	}
+	job, _ = s.ClaimJob(ctx, now.Add(2*time.Second), now.Add(time.Minute))
+	if job.ID != id || job.Attempts != 2 || job.LastError != "timeout" {
+		t.Fatalf("reclaimed %+v", job)
+	}
+
+	if err := s.CompleteJob(ctx, id, 2, []byte(`{"imported":3}`), now); err != nil {
+		t.Fatalf("CompleteJob: %v", err)
+	}
+	job, err = s.GetJob(ctx, id)
+	if err != nil || job.Status != types.JobSucceeded || string(job.Result) != `{"imported":3}` || job.LastError != "" {
+		t.Fatalf("GetJob = %+v, %v", job, err)
+	}
+
+	if _, err := s.GetJob(ctx, 999); !errors.Is(err, storage.ErrJobNotFound) {
+		t.Fatalf("GetJob(999) error = %v, want %v", err, storage.ErrJobNotFound)
+	}
+}
+
+func TestClaimJobRecoversExpiredLeases(t *testing.T) {
+	s := newTestSqlite(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	retryable, _ := s.EnqueueJob(ctx, "a", []byte(`{}`), 2, now)
+	final, _ := s.EnqueueJob(ctx, "b", []byte(`{}`), 1, now)
+	s.ClaimJob(ctx, now, now.Add(time.Minute))
+	s.ClaimJob(ctx, now, now.Add(time.Minute))
+
+	// Both workers "died"; after the lease the job with attempts left is claimed again
+	later := now.Add(2 * time.Minute)
+	job, err := s.ClaimJob(ctx, later, later.Add(time.Minute))
+	if err != nil || job.ID != retryable || job.Attempts != 2 {
+		t.Fatalf("ClaimJob after lease = %+v, %v", job, err)
+	}
+
+	job, _ = s.GetJob(ctx, final)
+	if job.Status != types.JobDead {
+		t.Fatalf("job out of attempts has status %q, want %q", job.Status, types.JobDead)
+	}
+}
+
+func TestStaleWorkerCannotFinishReleasedJob(t *testing.T) {
+	s := newTestSqlite(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	id, _ := s.EnqueueJob(ctx, "import", []byte(`{}`), 3, now)
+	stale, _ := s.ClaimJob(ctx, now, now.Add(time.Minute))
+
+	// The first worker's lease runs out and a second worker claims the job
+	later := now.Add(2 * time.Minute)
+	current, err := s.ClaimJob(ctx, later, later.Add(time.Minute))
+	if err != nil || current.ID != id || current.Attempts != 2 {
+		t.Fatalf("ClaimJob after lease = %+v, %v", current, err)
+	}
+
+	if err := s.CompleteJob(ctx, id, stale.Attempts, []byte(`{}`), later); !errors.Is(err, storage.ErrLeaseLost) {
+		t.Fatalf("stale CompleteJob error = %v, want %v", err, storage.ErrLeaseLost)
+	}
+	if err := s.RetryJob(ctx, id, stale.Attempts, "boom", later, later); !errors.Is(err, storage.ErrLeaseLost) {
+		t.Fatalf("stale RetryJob error = %v, want %v", err, storage.ErrLeaseLost)
+	}
+	if err := s.KillJob(ctx, id, stale.Attempts, "boom", later); !errors.Is(err, storage.ErrLeaseLost) {
+		t.Fatalf("stale KillJob error = %v, want %v", err, storage.ErrLeaseLost)
+	}
+	if job, _ := s.GetJob(ctx, id); job.Status != types.JobRunning || job.Attempts != 2 || job.LastError != "" {
+		t.Fatalf("job after stale updates = %+v", job)
+	}
+
+	// The current worker's outcome is recorded, and only once
+	if err := s.CompleteJob(ctx, id, current.Attempts, []byte(`{"ok":true}`), later); err != nil {
+		t.Fatalf("CompleteJob: %v", err)
+	}
+	if err := s.KillJob(ctx, id, current.Attempts, "boom", later); !errors.Is(err, storage.ErrLeaseLost) {
+		t.Fatalf("KillJob after completion error = %v, want %v", err, storage.ErrLeaseLost)
+	}
+	if err := s.CompleteJob(ctx, 999, 1, nil, later); !errors.Is(err, storage.ErrJobNotFound) {
+		t.Fatalf("CompleteJob(999) error = %v, want %v", err, storage.ErrJobNotFound)
+	}
+}
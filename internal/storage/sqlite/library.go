@@ -0,0 +1,196 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Library = (*Sqlite)(nil)
+
+// bookColumns are scanned by scanBook
+const bookColumns = `b.id, b.public_id, b.title, b.author, b.isbn,
+	EXISTS (SELECT 1 FROM loans WHERE book_id = b.id AND returned_at IS NULL)`
+
+// loanQuery selects the columns scanned by scanLoan
+const loanQuery = `SELECT l.id, b.id, b.public_id, b.title, s.id, s.public_id, s.name, s.email,
+		l.checked_out_at, l.due_date, l.returned_at
+	FROM loans l
+	JOIN books b ON b.id = l.book_id
+	JOIN students s ON s.id = l.student_id`
+
+// AddBook implements storage.Library
+func (s *Sqlite) AddBook(ctx context.Context, book types.Book) (types.Book, error) {
+	if book.PublicID == "" {
+		book.PublicID = types.NewPublicID()
+	}
+	result, err := s.Db.ExecContext(ctx, "INSERT INTO books (public_id, title, author, isbn) VALUES (?, ?, ?, ?)",
+		book.PublicID, book.Title, book.Author, book.ISBN)
+	if err != nil {
+		return types.Book{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if book.ID, err = result.LastInsertId(); err != nil {
+		return types.Book{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	book.OnLoan = false
+	return book, nil
+}
+
+// GetBookByPublicID implements storage.Library
+func (s *Sqlite) GetBookByPublicID(ctx context.Context, publicID string) (types.Book, error) {
+	book, err := scanBook(s.Db.QueryRowContext(ctx, "SELECT "+bookColumns+" FROM books b WHERE b.public_id = ?", publicID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Book{}, storage.ErrBookNotFound
+	}
+	if err != nil {
+		return types.Book{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return book, nil
+}
+
+// ListBooks implements storage.Library
+func (s *Sqlite) ListBooks(ctx context.Context, offset, limit int) ([]types.Book, int64, error) {
+	var total int64
+	if err := s.Db.QueryRowContext(ctx, "SELECT COUNT(*) FROM books").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	rows, err := s.Db.QueryContext(ctx, "SELECT "+bookColumns+" FROM books b ORDER BY b.id LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var books []types.Book
+	for rows.Next() {
+		book, err := scanBook(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return books, total, nil
+}
+
+// CheckOutBook implements storage.Library. The partial unique index on open loans decides a race
+// between two checkouts of the same book.
+func (s *Sqlite) CheckOutBook(ctx context.Context, bookID, studentID int64, due string) (types.Loan, error) {
+	var books, students int
+	err := s.Db.QueryRowContext(ctx, `SELECT
+			(SELECT COUNT(*) FROM books WHERE id = ?),
+			(SELECT COUNT(*) FROM students WHERE id = ? AND deleted_at IS NULL)`, bookID, studentID).Scan(&books, &students)
+	if err != nil {
+		return types.Loan{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if books == 0 {
+		return types.Loan{}, storage.ErrBookNotFound
+	}
+	if students == 0 {
+		return types.Loan{}, storage.ErrNotFound
+	}
+
+	result, err := s.Db.ExecContext(ctx, `INSERT INTO loans (book_id, student_id, checked_out_at, due_date)
+		VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+		bookID, studentID, s.Clock.Now().UTC().Format(sqliteTime), due)
+	if err != nil {
+		return types.Loan{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return types.Loan{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n == 0 {
+		return types.Loan{}, storage.ErrOnLoan
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return types.Loan{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return s.loan(ctx, id)
+}
+
+// ReturnBook implements storage.Library
+func (s *Sqlite) ReturnBook(ctx context.Context, bookID int64) (types.Loan, error) {
+	var id int64
+	err := s.Db.QueryRowContext(ctx, "UPDATE loans SET returned_at = ? WHERE book_id = ? AND returned_at IS NULL RETURNING id",
+		s.Clock.Now().UTC().Format(sqliteTime), bookID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Loan{}, storage.ErrNotOnLoan
+	}
+	if err != nil {
+		return types.Loan{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return s.loan(ctx, id)
+}
+
+// OverdueLoans implements storage.Library
+func (s *Sqlite) OverdueLoans(ctx context.Context, asOf time.Time) ([]types.Loan, error) {
+	today := asOf.UTC().Format(types.DateLayout)
+	rows, err := s.Db.QueryContext(ctx, loanQuery+`
+		WHERE l.returned_at IS NULL AND l.due_date < ?
+		ORDER BY l.due_date, l.id`, today)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var loans []types.Loan
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		loan.DaysOverdue = types.DaysOverdue(loan.DueDate, asOf)
+		loans = append(loans, loan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return loans, nil
+}
+
+// loan reads one loan by ID
+func (s *Sqlite) loan(ctx context.Context, id int64) (types.Loan, error) {
+	loan, err := scanLoan(s.Db.QueryRowContext(ctx, loanQuery+" WHERE l.id = ?", id))
+	if err != nil {
+		return types.Loan{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return loan, nil
+}
+
+func scanBook(row interface{ Scan(...any) error }) (types.Book, error) {
+	var b types.Book
+	err := row.Scan(&b.ID, &b.PublicID, &b.Title, &b.Author, &b.ISBN, &b.OnLoan)
+	return b, err
+}
+
+func scanLoan(row interface{ Scan(...any) error }) (types.Loan, error) {
+	var (
+		l          types.Loan
+		checkedOut string
+		returned   sql.NullString
+	)
+	err := row.Scan(&l.ID, &l.BookID, &l.BookPublicID, &l.Title, &l.StudentID, &l.StudentPublicID, &l.StudentName,
+		&l.StudentEmail, &checkedOut, &l.DueDate, &returned)
+	if err != nil {
+		return types.Loan{}, err
+	}
+	if l.CheckedOutAt, err = time.Parse(sqliteTime, checkedOut); err != nil {
+		return types.Loan{}, err
+	}
+	if returned.Valid {
+		t, err := time.Parse(sqliteTime, returned.String)
+		if err != nil {
+			return types.Loan{}, err
+		}
+		l.ReturnedAt = &t
+	}
+	return l, nil
+}
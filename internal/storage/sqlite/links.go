@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Relater = (*Sqlite)(nil)
+
+// LinkStudents implements storage.Relater. The pair is stored once, smaller ID first.
+func (s *Sqlite) LinkStudents(ctx context.Context, a, b int64, kind string) error {
+	if a == b {
+		return fmt.Errorf("%w: cannot link a student to itself", storage.ErrInvalidData)
+	}
+	if !slices.Contains(types.RelationshipKinds, kind) {
+		return fmt.Errorf("%w: unknown relationship kind %q", storage.ErrInvalidData, kind)
+	}
+	a, b = min(a, b), max(a, b)
+
+	var live int
+	err := s.Db.QueryRowContext(ctx, "SELECT COUNT(*) FROM students WHERE id IN (?, ?) AND deleted_at IS NULL", a, b).Scan(&live)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if live != 2 {
+		return storage.ErrNotFound
+	}
+
+	n, err := s.exec(ctx, "INSERT INTO student_links (student_id, linked_id, kind) VALUES (?, ?, ?) ON CONFLICT DO NOTHING", a, b, kind)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrDuplicate
+	}
+	return nil
+}
+
+// UnlinkStudents implements storage.Relater
+func (s *Sqlite) UnlinkStudents(ctx context.Context, a, b int64) error {
+	n, err := s.exec(ctx, "DELETE FROM student_links WHERE student_id = ? AND linked_id = ?", min(a, b), max(a, b))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// StudentRelationships implements storage.Relater with one query for the whole page of students.
+// Links to merged students are skipped; the merge re-pointed them to the kept student.
+func (s *Sqlite) StudentRelationships(ctx context.Context, ids []int64) (map[int64][]types.Relationship, error) {
+	links := make(map[int64][]types.Relationship)
+	if len(ids) == 0 {
+		return links, nil
+	}
+	idList, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.Db.QueryContext(ctx, `
+		SELECT l.id, l.other, l.kind, s.public_id, s.name
+		FROM (
+			SELECT student_id AS id, linked_id AS other, kind FROM student_links
+			WHERE student_id IN (SELECT value FROM json_each(:ids))
+			UNION ALL
+			SELECT linked_id, student_id, kind FROM student_links
+			WHERE linked_id IN (SELECT value FROM json_each(:ids))
+		) l
+		JOIN students s ON s.id = l.other AND s.deleted_at IS NULL
+		ORDER BY l.id, l.other`, sql.Named("ids", string(idList)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var rel types.Relationship
+		if err := rows.Scan(&id, &rel.StudentID, &rel.Kind, &rel.PublicID, &rel.Name); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		links[id] = append(links[id], rel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return links, nil
+}
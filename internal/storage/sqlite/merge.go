@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// MergeStudents implements storage.Merger. The merged row keeps its data and points at the kept
+// one through merged_into, so a merge can be traced (or undone by hand) later.
+func (s *Sqlite) MergeStudents(ctx context.Context, keepID, mergeID int64) (types.Student, error) {
+	if keepID == mergeID {
+		return types.Student{}, fmt.Errorf("%w: cannot merge a student into itself", storage.ErrInvalidData)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	var live int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM students WHERE id IN (?, ?) AND deleted_at IS NULL", keepID, mergeID).Scan(&live)
+	if err != nil {
+		return types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if live != 2 {
+		return types.Student{}, storage.ErrNotFound
+	}
+
+	stmts := []string{
+		// Optional fields the kept record lacks come from the duplicate; so do custom field values,
+		// with the kept record's winning where both have one
+		`UPDATE students SET
+			date_of_birth = COALESCE(date_of_birth, (SELECT date_of_birth FROM students WHERE id = :merge)),
+			phone = COALESCE(phone, (SELECT phone FROM students WHERE id = :merge)),
+			custom_fields = NULLIF(json_patch(COALESCE((SELECT custom_fields FROM students WHERE id = :merge), '{}'), COALESCE(custom_fields, '{}')), '{}'),
+			updated_at = :now
+		WHERE id = :keep`,
+		// Everything that pointed at the duplicate now points at the kept record: earlier merges, links,
+		// loans, room allocations, bus seats, admission applications and announcements. Enrollments,
+		// notes and documents belong here too once they exist.
+		`UPDATE students SET merged_into = :keep, updated_at = :now WHERE merged_into = :merge`,
+		// A link between the two would become a link to itself; links both had are kept once
+		`DELETE FROM student_links WHERE student_id IN (:keep, :merge) AND linked_id IN (:keep, :merge)`,
+		`INSERT OR IGNORE INTO student_links (student_id, linked_id, kind, created_at)
+			SELECT min(:keep, other), max(:keep, other), kind, created_at FROM (
+				SELECT CASE student_id WHEN :merge THEN linked_id ELSE student_id END AS other, kind, created_at
+				FROM student_links WHERE :merge IN (student_id, linked_id)
+			)`,
+		`DELETE FROM student_links WHERE :merge IN (student_id, linked_id)`,
+		`UPDATE loans SET student_id = :keep WHERE student_id = :merge`,
+		`UPDATE applications SET student_id = :keep WHERE student_id = :merge`,
+		// An announcement both were sent stays with the kept record's delivery
+		`UPDATE OR IGNORE announcement_deliveries SET student_id = :keep WHERE student_id = :merge`,
+		`DELETE FROM announcement_deliveries WHERE student_id = :merge`,
+		// A student has one bed and one bus seat: the kept record keeps theirs, or takes over the duplicate's
+		`DELETE FROM allocations WHERE student_id = :merge AND EXISTS (SELECT 1 FROM allocations WHERE student_id = :keep)`,
+		`UPDATE allocations SET student_id = :keep WHERE student_id = :merge`,
+		`DELETE FROM bus_riders WHERE student_id = :merge AND EXISTS (SELECT 1 FROM bus_riders WHERE student_id = :keep)`,
+		`UPDATE bus_riders SET student_id = :keep WHERE student_id = :merge`,
+		`UPDATE students SET merged_into = :keep, deleted_at = :now, updated_at = :now WHERE id = :merge`,
+	}
+	now := sql.Named("now", s.Clock.Now().UTC().Format(sqliteTime))
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt, sql.Named("keep", keepID), sql.Named("merge", mergeID), now); err != nil {
+			return types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+	}
+
+	// Read within the transaction: joined to InTx's, it isn't committed yet
+	kept, err := scanStudent(tx.QueryRowContext(ctx, "SELECT "+studentCols+" FROM students WHERE id = ?", keepID), s.Clock.Now())
+	if err != nil {
+		return types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return types.Student{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	slog.Info("Students merged", "kept", keepID, "merged", mergeID)
+	return kept, nil
+}
@@ -0,0 +1,609 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// migration is a single, append-only schema change.
+// Never edit a migration once it has shipped - add a new one instead,
+// because existing databases have already recorded its version as applied.
+type migration struct {
+	version int
+	name    string
+	stmts   []string
+}
+
+// migrations are applied in order; versions must be strictly increasing
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create students table",
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS students (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				age INTEGER NOT NULL,
+				email TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "add students.date_of_birth",
+		stmts: []string{
+			`ALTER TABLE students ADD COLUMN date_of_birth TEXT`,
+			// Backfill an approximate DOB from the stored age so every row can derive its age on read.
+			// We can't know the real birthday, so assume today's month/day.
+			`UPDATE students SET date_of_birth = date('now', '-' || age || ' years') WHERE date_of_birth IS NULL`,
+		},
+	},
+	{
+		version: 3,
+		name:    "add students.phone",
+		stmts: []string{
+			`ALTER TABLE students ADD COLUMN phone TEXT`,
+		},
+	},
+	{
+		version: 4,
+		name:    "create jobs table",
+		stmts: []string{
+			// Times are unix milliseconds so due-job lookups compare integers
+			`CREATE TABLE jobs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				kind TEXT NOT NULL,
+				payload BLOB NOT NULL,
+				status TEXT NOT NULL DEFAULT 'queued',
+				attempts INTEGER NOT NULL DEFAULT 0,
+				max_attempts INTEGER NOT NULL,
+				run_at INTEGER NOT NULL,
+				locked_until INTEGER,
+				last_error TEXT,
+				result BLOB,
+				created_at INTEGER NOT NULL,
+				updated_at INTEGER NOT NULL
+			)`,
+			`CREATE INDEX jobs_due ON jobs (status, run_at)`,
+		},
+	},
+	{
+		version: 5,
+		name:    "add students.created_at and students.anonymized_at",
+		stmts: []string{
+			`ALTER TABLE students ADD COLUMN created_at TEXT`,
+			`ALTER TABLE students ADD COLUMN anonymized_at TEXT`,
+			// Existing rows predate tracking; the migration time is the best lower bound we have
+			`UPDATE students SET created_at = datetime('now')`,
+			// SQLite can't ADD COLUMN with a non-constant default, so stamp new rows with a trigger.
+			// This also covers every insert path (single, bulk, import) without touching their SQL.
+			`CREATE TRIGGER students_created_at AFTER INSERT ON students
+				WHEN NEW.created_at IS NULL
+				BEGIN
+					UPDATE students SET created_at = datetime('now') WHERE id = NEW.id;
+				END`,
+			`CREATE INDEX students_created_at ON students (created_at)`,
+		},
+	},
+	{
+		version: 6,
+		name:    "add students.merged_into and students.deleted_at",
+		stmts: []string{
+			// Merged duplicates are soft-deleted: reads skip rows with deleted_at set, but retention
+			// still anonymizes and purges them like any other row
+			`ALTER TABLE students ADD COLUMN merged_into INTEGER`,
+			`ALTER TABLE students ADD COLUMN deleted_at TEXT`,
+		},
+	},
+	{
+		version: 7,
+		name:    "add students.public_id",
+		stmts: []string{
+			`ALTER TABLE students ADD COLUMN public_id TEXT`,
+			// New rows get their UUID from the application; existing ones get a random version 4 UUID here
+			`UPDATE students SET public_id =
+				lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' ||
+				substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) ||
+				substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))`,
+			`CREATE UNIQUE INDEX students_public_id ON students (public_id)`,
+		},
+	},
+	{
+		version: 8,
+		name:    "create student_links table",
+		stmts: []string{
+			// One row per linked pair, smaller ID first, so a pair can't be linked twice in opposite directions
+			`CREATE TABLE student_links (
+				student_id INTEGER NOT NULL,
+				linked_id INTEGER NOT NULL,
+				kind TEXT NOT NULL,
+				created_at TEXT NOT NULL DEFAULT (datetime('now')),
+				PRIMARY KEY (student_id, linked_id),
+				CHECK (student_id < linked_id)
+			)`,
+			`CREATE INDEX student_links_linked ON student_links (linked_id)`,
+			// Retention purges students by deleting their rows; their links go with them
+			`CREATE TRIGGER students_delete_links AFTER DELETE ON students
+				BEGIN
+					DELETE FROM student_links WHERE student_id = OLD.id OR linked_id = OLD.id;
+				END`,
+		},
+	},
+	{
+		version: 9,
+		name:    "create books and loans tables",
+		stmts: []string{
+			`CREATE TABLE books (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				public_id TEXT NOT NULL UNIQUE,
+				title TEXT NOT NULL,
+				author TEXT NOT NULL DEFAULT '',
+				isbn TEXT NOT NULL DEFAULT '',
+				created_at TEXT NOT NULL DEFAULT (datetime('now'))
+			)`,
+			// Times are sqliteTime in UTC; due_date is a plain date, so dates compare as strings
+			`CREATE TABLE loans (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id INTEGER NOT NULL,
+				student_id INTEGER NOT NULL,
+				checked_out_at TEXT NOT NULL,
+				due_date TEXT NOT NULL,
+				returned_at TEXT
+			)`,
+			// A book has at most one open loan; checking it out again conflicts here
+			`CREATE UNIQUE INDEX loans_open_book ON loans (book_id) WHERE returned_at IS NULL`,
+			`CREATE INDEX loans_open_due ON loans (due_date) WHERE returned_at IS NULL`,
+			`CREATE INDEX loans_student ON loans (student_id)`,
+			// A purged student's loan history is personal data too
+			`CREATE TRIGGER students_delete_loans AFTER DELETE ON students
+				BEGIN
+					DELETE FROM loans WHERE student_id = OLD.id;
+				END`,
+		},
+	},
+	{
+		version: 10,
+		name:    "create hostels, rooms and allocations tables",
+		stmts: []string{
+			`CREATE TABLE hostels (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				public_id TEXT NOT NULL UNIQUE,
+				name TEXT NOT NULL UNIQUE,
+				created_at TEXT NOT NULL DEFAULT (datetime('now'))
+			)`,
+			`CREATE TABLE rooms (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				public_id TEXT NOT NULL UNIQUE,
+				hostel_id INTEGER NOT NULL,
+				number TEXT NOT NULL,
+				capacity INTEGER NOT NULL CHECK (capacity > 0),
+				UNIQUE (hostel_id, number)
+			)`,
+			// A student has at most one bed; allocated_at is sqliteTime in UTC
+			`CREATE TABLE allocations (
+				room_id INTEGER NOT NULL,
+				student_id INTEGER NOT NULL UNIQUE,
+				allocated_at TEXT NOT NULL
+			)`,
+			`CREATE INDEX allocations_room ON allocations (room_id)`,
+			`CREATE TRIGGER students_delete_allocations AFTER DELETE ON students
+				BEGIN
+					DELETE FROM allocations WHERE student_id = OLD.id;
+				END`,
+		},
+	},
+	{
+		version: 11,
+		name:    "create bus routes, stops and riders tables",
+		stmts: []string{
+			`CREATE TABLE bus_routes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				public_id TEXT NOT NULL UNIQUE,
+				name TEXT NOT NULL UNIQUE,
+				capacity INTEGER NOT NULL CHECK (capacity > 0),
+				created_at TEXT NOT NULL DEFAULT (datetime('now'))
+			)`,
+			`CREATE TABLE bus_stops (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				public_id TEXT NOT NULL UNIQUE,
+				route_id INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				position INTEGER NOT NULL,
+				UNIQUE (route_id, position)
+			)`,
+			// A student rides one route; assigned_at is sqliteTime in UTC
+			`CREATE TABLE bus_riders (
+				route_id INTEGER NOT NULL,
+				stop_id INTEGER NOT NULL,
+				student_id INTEGER NOT NULL UNIQUE,
+				assigned_at TEXT NOT NULL
+			)`,
+			`CREATE INDEX bus_riders_route ON bus_riders (route_id)`,
+			`CREATE TRIGGER students_delete_bus_riders AFTER DELETE ON students
+				BEGIN
+					DELETE FROM bus_riders WHERE student_id = OLD.id;
+				END`,
+		},
+	},
+	{
+		version: 12,
+		name:    "create alumni table",
+		stmts: []string{
+			// Graduated students keep their students row, soft-deleted, so retention still
+			// anonymizes and purges them; this table only adds the class they left with
+			`CREATE TABLE alumni (
+				student_id INTEGER PRIMARY KEY,
+				class_of INTEGER NOT NULL,
+				graduated_on TEXT NOT NULL
+			)`,
+			`CREATE INDEX alumni_class_of ON alumni (class_of)`,
+			`CREATE TRIGGER students_delete_alumni AFTER DELETE ON students
+				BEGIN
+					DELETE FROM alumni WHERE student_id = OLD.id;
+				END`,
+		},
+	},
+	{
+		version: 13,
+		name:    "create applications table",
+		stmts: []string{
+			// student_id is the student an accepted application became. converted_at stays set when
+			// retention deletes that student, so the application can't be converted twice.
+			`CREATE TABLE applications (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				public_id TEXT NOT NULL UNIQUE,
+				name TEXT NOT NULL,
+				email TEXT NOT NULL,
+				age INTEGER NOT NULL,
+				date_of_birth TEXT,
+				phone TEXT,
+				status TEXT NOT NULL,
+				student_id INTEGER,
+				converted_at TEXT,
+				submitted_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			)`,
+			`CREATE INDEX applications_status ON applications (status, id)`,
+			`CREATE TRIGGER students_delete_applications AFTER DELETE ON students
+				BEGIN
+					UPDATE applications SET student_id = NULL WHERE student_id = OLD.id;
+				END`,
+		},
+	},
+	{
+		version: 14,
+		name:    "create announcements and deliveries tables",
+		stmts: []string{
+			`CREATE TABLE announcements (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				public_id TEXT NOT NULL UNIQUE,
+				subject TEXT NOT NULL,
+				body TEXT NOT NULL,
+				filter TEXT NOT NULL,
+				created_at TEXT NOT NULL
+			)`,
+			// One row per recipient; updated_at is sqliteTime in UTC
+			`CREATE TABLE announcement_deliveries (
+				announcement_id INTEGER NOT NULL,
+				student_id INTEGER NOT NULL,
+				status TEXT NOT NULL,
+				error TEXT,
+				updated_at TEXT NOT NULL,
+				PRIMARY KEY (announcement_id, student_id)
+			)`,
+			`CREATE INDEX announcement_deliveries_student ON announcement_deliveries (student_id)`,
+			`CREATE TRIGGER students_delete_announcement_deliveries AFTER DELETE ON students
+				BEGIN
+					DELETE FROM announcement_deliveries WHERE student_id = OLD.id;
+				END`,
+		},
+	},
+	{
+		version: 15,
+		name:    "add announcements.channel and announcement_deliveries.message_id",
+		stmts: []string{
+			`ALTER TABLE announcements ADD COLUMN channel TEXT NOT NULL DEFAULT 'email'`,
+			// The SMS provider's ID for a text message; its delivery reports find the row by it
+			`ALTER TABLE announcement_deliveries ADD COLUMN message_id TEXT`,
+			`CREATE UNIQUE INDEX announcement_deliveries_message ON announcement_deliveries (message_id) WHERE message_id IS NOT NULL`,
+		},
+	},
+	{
+		version: 16,
+		name:    "create custom_fields table and add students.custom_fields",
+		stmts: []string{
+			`CREATE TABLE custom_fields (
+				name TEXT PRIMARY KEY,
+				type TEXT NOT NULL,
+				required INTEGER NOT NULL DEFAULT 0,
+				created_at TEXT NOT NULL DEFAULT (datetime('now'))
+			)`,
+			// A JSON object of the student's values keyed by field name; NULL when they have none.
+			// Filters read it with json_extract.
+			`ALTER TABLE students ADD COLUMN custom_fields TEXT`,
+		},
+	},
+	{
+		version: 17,
+		name:    "create views table",
+		stmts: []string{
+			// expand is comma-separated, as in ?expand=; created_at is sqliteTime in UTC
+			`CREATE TABLE views (
+				name TEXT PRIMARY KEY,
+				filter TEXT NOT NULL DEFAULT '',
+				expand TEXT NOT NULL DEFAULT '',
+				created_at TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 18,
+		name:    "add students.updated_at",
+		stmts: []string{
+			`ALTER TABLE students ADD COLUMN updated_at TEXT`,
+			// The latest change we know of; edits before this migration weren't tracked
+			`UPDATE students SET updated_at = max(created_at, coalesce(anonymized_at, ''), coalesce(deleted_at, ''))`,
+			// Stamped by triggers like created_at, so every write path (updates, merges, graduation,
+			// anonymization, custom field deletion) counts as a change without touching its SQL.
+			// The inner UPDATE doesn't fire students_updated_at again: recursive triggers are off.
+			`CREATE TRIGGER students_updated_at_insert AFTER INSERT ON students
+				WHEN NEW.updated_at IS NULL
+				BEGIN
+					UPDATE students SET updated_at = datetime('now') WHERE id = NEW.id;
+				END`,
+			`CREATE TRIGGER students_updated_at AFTER UPDATE ON students
+				WHEN NEW.updated_at IS OLD.updated_at
+				BEGIN
+					UPDATE students SET updated_at = datetime('now') WHERE id = NEW.id;
+				END`,
+			`CREATE INDEX students_updated_at ON students (updated_at, public_id)`,
+		},
+	},
+	{
+		version: 19,
+		name:    "create student_tombstones table",
+		stmts: []string{
+			// Hard-deleted students (retention, Reset) leave their public ID behind for the changes
+			// feed, until a "tombstones" retention rule purges it
+			`CREATE TABLE student_tombstones (
+				public_id TEXT PRIMARY KEY,
+				deleted_at TEXT NOT NULL
+			)`,
+			`CREATE INDEX student_tombstones_deleted_at ON student_tombstones (deleted_at, public_id)`,
+			`CREATE TRIGGER students_tombstone AFTER DELETE ON students
+				BEGIN
+					INSERT OR REPLACE INTO student_tombstones (public_id, deleted_at) VALUES (OLD.public_id, datetime('now'));
+				END`,
+			// The feed can't report deletions from before the latest purge's cutoff; one row
+			`CREATE TABLE tombstones_purged (
+				id INTEGER PRIMARY KEY CHECK (id = 1),
+				before TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 20,
+		name:    "create webhooks table",
+		stmts: []string{
+			// events is a comma-separated list of event kinds
+			`CREATE TABLE webhooks (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				public_id TEXT NOT NULL UNIQUE,
+				owner TEXT NOT NULL,
+				url TEXT NOT NULL,
+				events TEXT NOT NULL,
+				secret TEXT NOT NULL,
+				previous_secret TEXT NOT NULL DEFAULT '',
+				previous_secret_expires_at TEXT,
+				created_at TEXT NOT NULL
+			)`,
+			`CREATE INDEX webhooks_owner ON webhooks (owner, id)`,
+		},
+	},
+	{
+		version: 21,
+		name:    "create webhook_deliveries table",
+		stmts: []string{
+			// A row lives until its event is delivered; failed rows are the dead letters
+			`CREATE TABLE webhook_deliveries (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				webhook_id INTEGER NOT NULL REFERENCES webhooks(id),
+				event TEXT NOT NULL,
+				event_id TEXT NOT NULL,
+				body TEXT NOT NULL,
+				status TEXT NOT NULL,
+				attempts INTEGER NOT NULL DEFAULT 0,
+				response_status INTEGER,
+				response_body TEXT,
+				error TEXT,
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			)`,
+			`CREATE INDEX webhook_deliveries_webhook ON webhook_deliveries (webhook_id, status, id)`,
+		},
+	},
+	{
+		version: 22,
+		name:    "create event log tables",
+		stmts: []string{
+			`CREATE TABLE events (
+				entity TEXT NOT NULL,
+				seq INTEGER NOT NULL,
+				type TEXT NOT NULL,
+				data TEXT NOT NULL,
+				created_at TEXT NOT NULL,
+				PRIMARY KEY (entity, seq)
+			)`,
+			`CREATE INDEX events_created_at ON events (created_at)`,
+			// The last seq of each entity, kept apart from events so purging them never lets a
+			// number be handed out twice
+			`CREATE TABLE event_sequences (
+				entity TEXT PRIMARY KEY,
+				seq INTEGER NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 23,
+		name:    "create request_usage table",
+		stmts: []string{
+			`CREATE TABLE request_usage (
+				client TEXT NOT NULL,
+				month TEXT NOT NULL,
+				requests INTEGER NOT NULL,
+				PRIMARY KEY (month, client)
+			)`,
+		},
+	},
+	{
+		version: 24,
+		name:    "add jobs.progress and index students by email",
+		stmts: []string{
+			// Import jobs checkpoint here, in the transaction of each batch they commit
+			`ALTER TABLE jobs ADD COLUMN progress TEXT`,
+			// Imports skip emails a live student already has
+			`CREATE INDEX students_email ON students (lower(email)) WHERE deleted_at IS NULL`,
+		},
+	},
+	{
+		version: 25,
+		name:    "create import_staging table",
+		stmts: []string{
+			// A previewed import's valid rows wait here until it is confirmed or discarded
+			`CREATE TABLE import_staging (
+				job_id INTEGER NOT NULL,
+				data_row INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				email TEXT NOT NULL,
+				age INTEGER NOT NULL,
+				date_of_birth TEXT,
+				phone TEXT,
+				custom_fields TEXT,
+				PRIMARY KEY (job_id, data_row)
+			)`,
+			// Finds each email's first row in the file
+			`CREATE INDEX import_staging_email ON import_staging (job_id, lower(email), data_row)`,
+		},
+	},
+	{
+		version: 26,
+		name:    "add webhook_deliveries.public_id",
+		stmts: []string{
+			`ALTER TABLE webhook_deliveries ADD COLUMN public_id TEXT`,
+			// New rows get their UUID from the application; queued and failed ones get a random version 4 UUID here
+			`UPDATE webhook_deliveries SET public_id =
+				lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' ||
+				substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) ||
+				substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))`,
+			`CREATE UNIQUE INDEX webhook_deliveries_public_id ON webhook_deliveries (public_id)`,
+		},
+	},
+	{
+		version: 27,
+		name:    "stamp student changes from the application clock",
+		stmts: []string{
+			// Every write path now sets updated_at (and deleted_at, anonymized_at) itself, and the
+			// deleting ones leave tombstones, all from Sqlite.Clock. An UPDATE stamping the same second
+			// again would fire these, and datetime('now') would overwrite an injected clock's time.
+			`DROP TRIGGER students_updated_at`,
+			`DROP TRIGGER students_tombstone`,
+		},
+	},
+}
+
+// migrate brings the database schema up to date.
+// Each migration runs in its own transaction together with its version bookkeeping,
+// so a failure leaves the database at the last fully applied version.
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL DEFAULT (datetime('now'))
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations table: %w", err)
+	}
+
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("migration %d: begin: %w", m.version, err)
+		}
+
+		for _, stmt := range m.stmts {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+			}
+		}
+
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: recording version: %w", m.version, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: commit: %w", m.version, err)
+		}
+
+		slog.Info("Applied database migration", "version", m.version, "name", m.name)
+	}
+
+	return nil
+}
+
+// schemaVersion returns the highest applied migration version (0 for a fresh database)
+func schemaVersion(db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// SchemaStatus compares a database's schema version with the migrations this binary ships
+type SchemaStatus struct {
+	Current int
+	Latest  int
+}
+
+// Pending is the number of migrations the next NewSqlite will apply
+func (s SchemaStatus) Pending() int {
+	return max(s.Latest-s.Current, 0)
+}
+
+// InspectSchema opens the database at path read-only and reports its schema version.
+// Unlike NewSqlite it never creates the file or applies migrations.
+func InspectSchema(ctx context.Context, path string) (SchemaStatus, error) {
+	status := SchemaStatus{Latest: migrations[len(migrations)-1].version}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return status, err
+	}
+	defer db.Close()
+
+	var tables int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'").Scan(&tables); err != nil {
+		return status, fmt.Errorf("opening %s: %w", path, err)
+	}
+	if tables == 0 {
+		return status, nil
+	}
+
+	status.Current, err = schemaVersion(db)
+	return status, err
+}
@@ -0,0 +1,217 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/reports"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// ageExpr is a student's age in completed years as of the date bound to :today, matching
+// types.AgeOn; students without a date of birth keep the age they were created with
+const ageExpr = `CASE WHEN date_of_birth IS NULL THEN age ELSE
+	CAST(substr(:today, 1, 4) AS INTEGER) - CAST(substr(date_of_birth, 1, 4) AS INTEGER)
+	- (substr(:today, 6, 5) < substr(date_of_birth, 6, 5)) END`
+
+// StudentStats implements storage.Reporter
+func (s *Sqlite) StudentStats(ctx context.Context, now time.Time, months int) (types.StudentStats, error) {
+	stats := types.StudentStats{}
+	now = now.UTC()
+
+	if err := s.Db.QueryRowContext(ctx, "SELECT COUNT(*) FROM students WHERE deleted_at IS NULL").Scan(&stats.Total); err != nil {
+		return stats, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	// Bucket in SQL so only one row per bucket comes back
+	byAge, err := s.groupCount(ctx,
+		"SELECT "+ageBucketCase("a", false)+" AS k, COUNT(*) FROM (SELECT "+ageExpr+" AS a FROM students WHERE deleted_at IS NULL) GROUP BY k",
+		map[string]any{"today": now.Format(types.DateLayout)})
+	if err != nil {
+		return stats, err
+	}
+	for i, b := range types.AgeBuckets {
+		stats.ByAge = append(stats.ByAge, types.Count{Key: b.Label, Count: byAge[fmt.Sprint(i)]})
+	}
+
+	byStatus, err := s.groupCount(ctx, fmt.Sprintf(
+		"SELECT CASE WHEN anonymized_at IS NULL THEN '%s' ELSE '%s' END AS k, COUNT(*) FROM students WHERE deleted_at IS NULL GROUP BY k",
+		types.StudentActive, types.StudentAnonymized), nil)
+	if err != nil {
+		return stats, err
+	}
+	for _, status := range []string{types.StudentActive, types.StudentAnonymized} {
+		stats.ByStatus = append(stats.ByStatus, types.Count{Key: status, Count: byStatus[status]})
+	}
+
+	first := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+	byMonth, err := s.groupCount(ctx,
+		"SELECT strftime('%Y-%m', created_at) AS k, COUNT(*) FROM students WHERE created_at >= :from AND deleted_at IS NULL GROUP BY k",
+		map[string]any{"from": first.Format(sqliteTime)})
+	if err != nil {
+		return stats, err
+	}
+	for m := first; !m.After(now); m = m.AddDate(0, 1, 0) {
+		key := m.Format("2006-01")
+		stats.CreatedPerMonth = append(stats.CreatedPerMonth, types.Count{Key: key, Count: byMonth[key]})
+	}
+
+	return stats, nil
+}
+
+// ageBucketCase maps the age expression age to its types.AgeBuckets label, or with labels false to its index
+func ageBucketCase(age string, labels bool) string {
+	var b strings.Builder
+	b.WriteString("CASE")
+	for i, bucket := range types.AgeBuckets {
+		result := strconv.Itoa(i)
+		if labels {
+			result = "'" + bucket.Label + "'"
+		}
+		if bucket.Max == 0 {
+			fmt.Fprintf(&b, " WHEN %s >= %d THEN %s", age, bucket.Min, result)
+		} else {
+			fmt.Fprintf(&b, " WHEN %s BETWEEN %d AND %d THEN %s", age, bucket.Min, bucket.Max, result)
+		}
+	}
+	b.WriteString(" END")
+	return b.String()
+}
+
+// reportDimension is the SQL behind a reports dimension; order sorts its groups when the
+// value itself doesn't (age buckets sort by age, not alphabetically)
+type reportDimension struct {
+	expr  string
+	order string
+}
+
+// reportDimensions and reportMetrics translate the reports allowlists; the queries below are
+// built only from these fixed strings
+var (
+	reportDimensions = map[string]reportDimension{
+		reports.DimAgeBucket: {expr: ageBucketCase("("+ageExpr+")", true), order: ageBucketCase("("+ageExpr+")", false)},
+		reports.DimStatus: {expr: fmt.Sprintf("CASE WHEN anonymized_at IS NULL THEN '%s' ELSE '%s' END",
+			types.StudentActive, types.StudentAnonymized)},
+		reports.DimCreatedMonth:   {expr: "strftime('%Y-%m', created_at)"},
+		reports.DimCreatedYear:    {expr: "strftime('%Y', created_at)"},
+		reports.DimEmailDomain:    {expr: "lower(substr(email, instr(email, '@') + 1))"},
+		reports.DimHasPhone:       {expr: "CASE WHEN phone IS NULL THEN 'no' ELSE 'yes' END"},
+		reports.DimHasDateOfBirth: {expr: "CASE WHEN date_of_birth IS NULL THEN 'no' ELSE 'yes' END"},
+	}
+	reportMetrics = map[string]string{
+		reports.MetricCount:  "COUNT(*)",
+		reports.MetricAvgAge: "ROUND(AVG(" + ageExpr + "), 1)",
+		reports.MetricMinAge: "MIN(" + ageExpr + ")",
+		reports.MetricMaxAge: "MAX(" + ageExpr + ")",
+	}
+)
+
+// Report implements storage.Reporter
+func (s *Sqlite) Report(ctx context.Context, q types.ReportQuery, now time.Time) (types.Report, error) {
+	report := types.Report{ReportQuery: q, Rows: []map[string]any{}}
+
+	var cols, groups, order []string
+	for i, name := range q.GroupBy {
+		dim, ok := reportDimensions[name]
+		if !ok {
+			return report, fmt.Errorf("%w: unknown dimension %q", storage.ErrInvalidData, name)
+		}
+		alias := "d" + strconv.Itoa(i)
+		cols = append(cols, dim.expr+" AS "+alias)
+		groups = append(groups, alias)
+		if dim.order != "" {
+			order = append(order, dim.order)
+		} else {
+			order = append(order, alias)
+		}
+	}
+	for _, name := range q.Metrics {
+		expr, ok := reportMetrics[name]
+		if !ok {
+			return report, fmt.Errorf("%w: unknown metric %q", storage.ErrInvalidData, name)
+		}
+		cols = append(cols, expr)
+	}
+
+	query := "SELECT " + strings.Join(cols, ", ") + " FROM students WHERE deleted_at IS NULL"
+	if len(groups) > 0 {
+		query += " GROUP BY " + strings.Join(groups, ", ") + " ORDER BY " + strings.Join(order, ", ")
+	}
+	// One extra row tells us whether the limit cut anything off
+	query += " LIMIT " + strconv.Itoa(q.Limit+1)
+
+	rows, err := s.Db.QueryContext(ctx, query, sql.Named("today", now.UTC().Format(types.DateLayout)))
+	if err != nil {
+		return report, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if len(report.Rows) == q.Limit {
+			report.Truncated = true
+			break
+		}
+		keys := make([]sql.NullString, len(q.GroupBy))
+		values := make([]sql.NullFloat64, len(q.Metrics))
+		dest := make([]any, 0, len(keys)+len(values))
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return report, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+
+		row := make(map[string]any, len(dest))
+		for i, name := range q.GroupBy {
+			row[name] = keys[i].String
+		}
+		for i, name := range q.Metrics {
+			// AVG/MIN/MAX of no rows is NULL; report it as null rather than 0
+			if values[i].Valid {
+				row[name] = values[i].Float64
+			} else {
+				row[name] = nil
+			}
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return report, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return report, nil
+}
+
+// groupCount runs a "SELECT key, COUNT(*) ... GROUP BY key" query; named are :name arguments
+func (s *Sqlite) groupCount(ctx context.Context, query string, named map[string]any) (map[string]int64, error) {
+	args := make([]any, 0, len(named))
+	for name, v := range named {
+		args = append(args, sql.Named(name, v))
+	}
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var key sql.NullString
+		var n int64
+		if err := rows.Scan(&key, &n); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		counts[key.String] += n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,198 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+)
+
+// sqliteTime matches datetime('now'), which the created_at trigger falls back to
+const sqliteTime = "2006-01-02 15:04:05"
+
+// AnonymizeStudentsCreatedBefore replaces the personal data of students created before cutoff
+// with placeholders, keeping the row (and its ID) for statistics. Already anonymized rows are
+// skipped. With dryRun nothing changes and the number of matching rows is returned.
+func (s *Sqlite) AnonymizeStudentsCreatedBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, error) {
+	where := "created_at < :cutoff AND anonymized_at IS NULL"
+	at := sql.Named("cutoff", cutoff.UTC().Format(sqliteTime))
+	if dryRun {
+		return s.count(ctx, "students", where, at)
+	}
+	// Email stays unique and syntactically valid; .invalid is reserved and never routable
+	return s.exec(ctx, `UPDATE students SET
+			name = 'Anonymized student',
+			email = 'anonymized+' || id || '@example.invalid',
+			phone = NULL,
+			date_of_birth = NULL,
+			custom_fields = NULL,
+			anonymized_at = :now,
+			updated_at = :now
+		WHERE `+where, at, sql.Named("now", s.Clock.Now().UTC().Format(sqliteTime)))
+}
+
+// DeleteStudentsCreatedBefore deletes students created before cutoff
+func (s *Sqlite) DeleteStudentsCreatedBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, error) {
+	where := "created_at < :cutoff"
+	at := sql.Named("cutoff", cutoff.UTC().Format(sqliteTime))
+	if dryRun {
+		return s.count(ctx, "students", where, at)
+	}
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+	n, err := s.deleteStudents(ctx, tx, where, at)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return n, nil
+}
+
+// deleteStudents deletes the students matching where within tx, leaving their public IDs behind
+// as tombstones for the changes feed. Its arguments must be named: the tombstones' time is :now.
+func (s *Sqlite) deleteStudents(ctx context.Context, tx *sql.Tx, where string, args ...any) (int64, error) {
+	now := sql.Named("now", s.Clock.Now().UTC().Format(sqliteTime))
+	_, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO student_tombstones (public_id, deleted_at)
+		SELECT public_id, :now FROM students WHERE `+where, append(args, now)...)
+	if err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM students WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// purgedStudents selects the students PurgeStudentsDeletedBefore removes
+const purgedStudents = "SELECT id FROM students WHERE deleted_at IS NOT NULL AND deleted_at < :cutoff"
+
+// purgeDependents are the rows the students_delete_* triggers remove (or, for applications,
+// unlink) along with purged students, per table
+var purgeDependents = []struct{ table, where string }{
+	{"alumni", "student_id IN (" + purgedStudents + ")"},
+	{"loans", "student_id IN (" + purgedStudents + ")"},
+	{"allocations", "student_id IN (" + purgedStudents + ")"},
+	{"bus_riders", "student_id IN (" + purgedStudents + ")"},
+	{"student_links", "student_id IN (" + purgedStudents + ") OR linked_id IN (" + purgedStudents + ")"},
+	{"announcement_deliveries", "student_id IN (" + purgedStudents + ")"},
+	{"applications", "student_id IN (" + purgedStudents + ")"},
+}
+
+// PurgeStudentsDeletedBefore permanently deletes students soft-deleted (merged or graduated)
+// before cutoff. Their loans, bed, bus seat, links, deliveries and alumni record go with them,
+// and the applications they came from are unlinked; the counts per table are returned with the
+// number of students. Live students are never touched.
+func (s *Sqlite) PurgeStudentsDeletedBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, map[string]int64, error) {
+	at := sql.Named("cutoff", cutoff.UTC().Format(sqliteTime))
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	// Counted first: once the students are gone the triggers leave nothing to count
+	cascaded := make(map[string]int64)
+	for _, d := range purgeDependents {
+		var n int64
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+d.table+" WHERE "+d.where, at).Scan(&n); err != nil {
+			return 0, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		if n > 0 {
+			cascaded[d.table] = n
+		}
+	}
+
+	var n int64
+	if dryRun {
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+purgedStudents+")", at).Scan(&n)
+	} else {
+		if n, err = s.deleteStudents(ctx, tx, "id IN ("+purgedStudents+")", at); err == nil {
+			err = tx.Commit()
+		}
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return n, cascaded, nil
+}
+
+// DeleteFinishedJobsBefore deletes succeeded and dead jobs last updated before cutoff.
+// Queued and running jobs are never touched.
+func (s *Sqlite) DeleteFinishedJobsBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, error) {
+	where := "status IN ('succeeded', 'dead') AND updated_at < ?"
+	if dryRun {
+		return s.count(ctx, "jobs", where, cutoff.UnixMilli())
+	}
+	return s.exec(ctx, "DELETE FROM jobs WHERE "+where, cutoff.UnixMilli())
+}
+
+// DeleteTombstonesBefore deletes the tombstones of students deleted before cutoff, and remembers
+// cutoff so the changes feed refuses to start before it
+func (s *Sqlite) DeleteTombstonesBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, error) {
+	where := "deleted_at < ?"
+	at := cutoff.UTC().Format(sqliteTime)
+	if dryRun {
+		return s.count(ctx, "student_tombstones", where, at)
+	}
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx, "DELETE FROM student_tombstones WHERE "+where, at)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n > 0 {
+		_, err = tx.ExecContext(ctx, `INSERT INTO tombstones_purged (id, before) VALUES (1, ?)
+			ON CONFLICT (id) DO UPDATE SET before = max(before, excluded.before)`, at)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return n, nil
+}
+
+// DeleteEventsBefore deletes the event log's entries published before cutoff
+func (s *Sqlite) DeleteEventsBefore(ctx context.Context, cutoff time.Time, dryRun bool) (int64, error) {
+	where := "created_at < ?"
+	at := cutoff.UTC().Format(sqliteTime)
+	if dryRun {
+		return s.count(ctx, "events", where, at)
+	}
+	return s.exec(ctx, "DELETE FROM events WHERE "+where, at)
+}
+
+func (s *Sqlite) count(ctx context.Context, table, where string, args ...any) (int64, error) {
+	var n int64
+	if err := s.Db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+" WHERE "+where, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return n, nil
+}
+
+func (s *Sqlite) exec(ctx context.Context, query string, args ...any) (int64, error) {
+	result, err := s.Db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// likeEscaper escapes LIKE wildcards so user input only ever matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchStudents implements storage.Searcher with case-insensitive substring matching on name
+// and email. Names starting with the query rank first. It is the fallback when OpenSearch is
+// disabled: no typo tolerance, scores or highlights.
+func (s *Sqlite) SearchStudents(ctx context.Context, query string, limit int) ([]types.SearchHit, error) {
+	q := likeEscaper.Replace(strings.ToLower(query))
+	rows, err := s.Db.QueryContext(ctx, `
+		SELECT `+studentCols+` FROM students
+		WHERE (lower(name) LIKE ?1 ESCAPE '\' OR lower(email) LIKE ?1 ESCAPE '\') AND deleted_at IS NULL
+		ORDER BY lower(name) LIKE ?2 ESCAPE '\' DESC, id
+		LIMIT ?3`,
+		"%"+q+"%", q+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	now := s.Clock.Now()
+	hits := []types.SearchHit{}
+	for rows.Next() {
+		student, err := scanStudent(rows, now)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		hits = append(hits, types.SearchHit{Student: student})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return hits, nil
+}
@@ -1,25 +1,44 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3" // We are using _ to import the sqlite3 driver (Why? Because we are not using the sqlite3 driver in this file,)
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
 	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/idgen"
 	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
 	"github.com/prashantkumbhar2002/go_students_api/internal/types"
 )
 
 type Sqlite struct {
 	Db *sql.DB
+	// Clock derives ages on read and stamps when students are created, changed and deleted;
+	// replace it with a clock.Fake in tests (or SetClock)
+	Clock clock.Clock
+	// IDs makes up new students' row and public IDs; nil means idgen.Default()
+	IDs idgen.Generator
+
+	// Hot statements are prepared once in NewSqlite and reused for every request.
+	// *sql.Stmt is safe for concurrent use and re-prepares itself on new connections as needed.
+	insertStudentStmt *sql.Stmt
+	getStudentStmt    *sql.Stmt
+	getByPublicIDStmt *sql.Stmt
+	listStudentsStmt  *sql.Stmt
+	countStudentsStmt *sql.Stmt
 }
 
 func NewSqlite(cfg *config.Config) (*Sqlite, error) {
 
 	// Open the SQLite database
-	db, err := sql.Open("sqlite3", cfg.StoragePath)
+	db, err := sql.Open("sqlite3", dsn(cfg.StoragePath))
 
 	if err != nil {
 		slog.Error("Error opening SQLite database", "error", err)
@@ -32,38 +51,90 @@ func NewSqlite(cfg *config.Config) (*Sqlite, error) {
 		return nil, err
 	}
 
-	// Create the students table if it doesn't exist
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS students (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			age INTEGER NOT NULL,
-			email TEXT NOT NULL
-		)
-	`)
+	// Create/upgrade the schema (students table and later migrations)
+	if err := migrate(db); err != nil {
+		slog.Error("Error migrating SQLite database", "error", err)
+		return nil, err
+	}
+	slog.Info("SQLite database schema is up to date")
 
-	if err != nil {
-		slog.Error("Error creating students table in SQLite database", "error", err)
+	s := &Sqlite{Db: db, Clock: clock.Real{}}
+	if err := s.prepareStatements(); err != nil {
+		slog.Error("Error preparing SQLite statements", "error", err)
+		s.Close()
 		return nil, err
 	}
-	slog.Info("Students table created successfully in SQLite database")
 
 	// Return the Sqlite struct
-	return &Sqlite{Db: db}, nil
+	return s, nil
+}
+
+// dsn adds the connection parameters every database needs to path. Transactions begin
+// IMMEDIATE, taking the write lock up front: a deferred transaction that reads before it writes
+// fails with SQLITE_BUSY when another writer holds the lock by the time it upgrades, while an
+// immediate one waits for the lock like any other write.
+func dsn(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_txlock=immediate"
+}
+
+// prepareStatements prepares the statements used on every request
+func (s *Sqlite) prepareStatements() error {
+	statements := []struct {
+		dst   **sql.Stmt
+		query string
+	}{
+		{&s.insertStudentStmt, "INSERT INTO students (id, name, email, age, date_of_birth, phone, public_id, custom_fields, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"}, // ? is a placeholder for the values
+		{&s.getStudentStmt, "SELECT " + studentCols + " FROM students WHERE id = ? AND deleted_at IS NULL"},
+		{&s.getByPublicIDStmt, "SELECT " + studentCols + " FROM students WHERE public_id = ? AND deleted_at IS NULL"},
+		{&s.listStudentsStmt, "SELECT " + studentCols + " FROM students WHERE deleted_at IS NULL ORDER BY id LIMIT ? OFFSET ?"},
+		{&s.countStudentsStmt, "SELECT COUNT(*) FROM students WHERE deleted_at IS NULL"},
+	}
+
+	for _, st := range statements {
+		stmt, err := s.Db.Prepare(st.query)
+		if err != nil {
+			return fmt.Errorf("preparing %q: %w", st.query, err)
+		}
+		*st.dst = stmt
+	}
+	return nil
+}
+
+// Close releases the prepared statements and closes the database
+func (s *Sqlite) Close() error {
+	for _, stmt := range []*sql.Stmt{s.insertStudentStmt, s.getStudentStmt, s.getByPublicIDStmt, s.listStudentsStmt, s.countStudentsStmt} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+	return s.Db.Close()
+}
+
+// SetClock replaces Clock, for tests that only hold the storage as a storage.Storage
+func (s *Sqlite) SetClock(c clock.Clock) {
+	s.Clock = clock.OrReal(c)
 }
 
-func (s *Sqlite) CreateStudent(name string, email string, age int) (int64, error) {
+func (s *Sqlite) CreateStudent(student types.Student) (int64, error) {
+	publicID := student.PublicID
+	if publicID == "" {
+		publicID = s.ids().NewPublicID()
+	}
 
-	// Prepare the SQL statement - why? Because it is more efficient to prepare the statement once and then execute it multiple times. and also helps to prevent SQL injection.
-	stmt, err := s.Db.Prepare("INSERT INTO students (name, email, age) VALUES (?, ?, ?)") // ? is a placeholder for the values
+	custom, err := customFieldsJSON(student.CustomFields)
 	if err != nil {
-		slog.Error("Error preparing SQL statement to create student", "error", err)
 		return 0, err
 	}
-	defer stmt.Close()
 
-	// Execute the SQL statement
-	result, err := stmt.Exec(name, email, age)
+	// Execute the prepared SQL statement - why prepared? Because it is more efficient to prepare the statement once and then execute it multiple times. and also helps to prevent SQL injection.
+	// Store NULL rather than "" for optional fields that weren't given
+	// A NULL id is assigned by the table's sequence
+	now := s.Clock.Now().UTC().Format(sqliteTime)
+	result, err := s.insertStudentStmt.Exec(nullID(s.ids().NextID()), student.Name, student.Email, student.Age, nullString(student.DateOfBirth), nullString(student.Phone), publicID, custom, now, now)
 	if err != nil {
 		slog.Error("Error executing SQL statement to create student", "error", err)
 		return 0, err
@@ -82,19 +153,18 @@ func (s *Sqlite) CreateStudent(name string, email string, age int) (int64, error
 }
 
 func (s *Sqlite) GetStudent(id int64) (types.Student, error) {
-	student := types.Student{}
+	return s.getStudent(s.getStudentStmt, id)
+}
 
-	stmt, err := s.Db.Prepare("SELECT id, name, email, age FROM students WHERE id = ?")
-	if err != nil {
-		slog.Error("Error preparing SQL statement to get student", "error", err)
-		// Wrap the database error with our domain error using fmt.Errorf with %w
-		return student, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
-	}
-	// close the statement after the execution
-	defer stmt.Close() // This is a good practice to close the statement after the execution, it helps to free up the resources.
+// GetStudentByPublicID returns the student with the given UUID
+func (s *Sqlite) GetStudentByPublicID(publicID string) (types.Student, error) {
+	return s.getStudent(s.getByPublicIDStmt, publicID)
+}
 
-	// Execute the SQL statement
-	err = stmt.QueryRow(id).Scan(&student.ID, &student.Name, &student.Email, &student.Age)
+// getStudent runs one of the single-student lookups
+func (s *Sqlite) getStudent(stmt *sql.Stmt, key any) (types.Student, error) {
+	// Execute the prepared SQL statement; the age is derived from the date of birth so it never goes stale
+	student, err := scanStudent(stmt.QueryRow(key), s.Clock.Now())
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			slog.Error("Student not found", "error", err)
@@ -114,17 +184,11 @@ func (s *Sqlite) GetStudent(id int64) (types.Student, error) {
 // offset: number of records to skip, limit: max number of records to return
 func (s *Sqlite) GetStudentsList(offset, limit int) ([]types.Student, error) {
 	var students []types.Student
+	now := s.Clock.Now()
 
 	// Use LIMIT and OFFSET for pagination
 	// ORDER BY id ensures consistent ordering across pages
-	stmt, err := s.Db.Prepare("SELECT id, name, email, age FROM students ORDER BY id LIMIT ? OFFSET ?")
-	if err != nil {
-		slog.Error("Error preparing SQL statement to get students list", "error", err)
-		return students, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
-	}
-	defer stmt.Close()
-
-	rows, err := stmt.Query(limit, offset)
+	rows, err := s.listStudentsStmt.Query(limit, offset)
 	if err != nil {
 		slog.Error("Error executing SQL statement to get students list", "error", err)
 		return students, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
@@ -132,8 +196,7 @@ func (s *Sqlite) GetStudentsList(offset, limit int) ([]types.Student, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var student types.Student
-		err = rows.Scan(&student.ID, &student.Name, &student.Email, &student.Age)
+		student, err := scanStudent(rows, now)
 		if err != nil {
 			slog.Error("Error scanning row to get students list", "error", err)
 			return students, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
@@ -154,7 +217,7 @@ func (s *Sqlite) GetStudentsList(offset, limit int) ([]types.Student, error) {
 func (s *Sqlite) GetStudentsCount() (int64, error) {
 	var count int64
 
-	err := s.Db.QueryRow("SELECT COUNT(*) FROM students").Scan(&count)
+	err := s.countStudentsStmt.QueryRow().Scan(&count)
 	if err != nil {
 		slog.Error("Error getting students count", "error", err)
 		return 0, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
@@ -162,3 +225,247 @@ func (s *Sqlite) GetStudentsCount() (int64, error) {
 
 	return count, nil
 }
+
+// StreamStudents iterates over all students using a single row cursor
+// Memory use stays constant regardless of table size, which makes it suitable for exports
+func (s *Sqlite) StreamStudents(ctx context.Context, fn func(types.Student) error) error {
+	rows, err := s.Db.QueryContext(ctx, "SELECT "+studentCols+" FROM students WHERE deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		slog.Error("Error executing SQL statement to stream students", "error", err)
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	now := s.Clock.Now()
+	for rows.Next() {
+		student, err := scanStudent(rows, now)
+		if err != nil {
+			slog.Error("Error scanning row to stream students", "error", err)
+			return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		// Errors from fn (e.g. client went away) are not database errors, so return them unwrapped
+		if err := fn(student); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Error iterating over rows to stream students", "error", err)
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	return nil
+}
+
+// SQLite limits the number of bound parameters per statement (999 on older builds),
+// so bulk inserts are split into chunks that stay under it.
+const (
+	maxSQLParams      = 999
+	studentInsertCols = 10
+	bulkInsertChunk   = maxSQLParams / studentInsertCols
+)
+
+// CreateStudents inserts students using multi-row INSERT statements inside one transaction
+// This is far faster than one prepared INSERT per row because SQLite only syncs once per transaction
+func (s *Sqlite) CreateStudents(ctx context.Context, students []types.Student) ([]int64, error) {
+	ids := make([]int64, 0, len(students))
+	if len(students) == 0 {
+		return ids, nil
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		slog.Error("Error beginning transaction to create students", "error", err)
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback() // no-op after a successful commit
+
+	ids, err = insertStudents(ctx, tx.Tx, s.ids(), students, s.Clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Constraints have been checked by now; a dry run stops short of making the rows visible
+	if storage.IsDryRun(ctx) {
+		if err := tx.Rollback(); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		slog.Info("Dry run: insert of students rolled back", "count", len(ids))
+		return ids, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Error committing bulk insert of students", "error", err)
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	slog.Info("Students created in bulk in SQLite database", "count", len(ids))
+	return ids, nil
+}
+
+// insertStudents inserts students created at now within tx with multi-row INSERT statements and
+// returns their IDs
+func insertStudents(ctx context.Context, tx *sql.Tx, gen idgen.Generator, students []types.Student, now time.Time) ([]int64, error) {
+	at := now.UTC().Format(sqliteTime)
+	ids := make([]int64, 0, len(students))
+	for start := 0; start < len(students); start += bulkInsertChunk {
+		end := min(start+bulkInsertChunk, len(students))
+		chunk := students[start:end]
+
+		var query strings.Builder
+		query.WriteString("INSERT INTO students (id, name, email, age, date_of_birth, phone, public_id, custom_fields, created_at, updated_at) VALUES ")
+		args := make([]any, 0, len(chunk)*studentInsertCols)
+		generated := make([]int64, len(chunk))
+		for i, st := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+			publicID := st.PublicID
+			if publicID == "" {
+				publicID = gen.NewPublicID()
+			}
+			custom, err := customFieldsJSON(st.CustomFields)
+			if err != nil {
+				return nil, err
+			}
+			generated[i] = gen.NextID()
+			args = append(args, nullID(generated[i]), st.Name, st.Email, st.Age, nullString(st.DateOfBirth), nullString(st.Phone), publicID, custom, at, at)
+		}
+
+		result, err := tx.ExecContext(ctx, query.String(), args...)
+		if err != nil {
+			slog.Error("Error executing bulk insert of students", "error", err, "offset", start)
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		// The generator made up every ID or none of them
+		if generated[0] != 0 {
+			ids = append(ids, generated...)
+			continue
+		}
+
+		// Within one statement in a write transaction, AUTOINCREMENT rowids are assigned consecutively,
+		// so the IDs of the chunk are the last inserted ID and the ones right before it.
+		lastID, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		firstID := lastID - int64(len(chunk)) + 1
+		for i := range chunk {
+			ids = append(ids, firstID+int64(i))
+		}
+	}
+	return ids, nil
+}
+
+// Backup writes a consistent, compacted copy of the database to path (which must not exist yet).
+// VACUUM INTO reads inside a transaction, so writers are not blocked for the duration.
+func (s *Sqlite) Backup(ctx context.Context, path string) error {
+	if _, err := s.Db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return nil
+}
+
+// Optimize lets SQLite refresh query planner statistics; cheap enough to run periodically
+func (s *Sqlite) Optimize(ctx context.Context) error {
+	if _, err := s.Db.ExecContext(ctx, "PRAGMA optimize"); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return nil
+}
+
+// Reset truncates every data table (schema_migrations excluded) and resets AUTOINCREMENT counters
+func (s *Sqlite) Reset(ctx context.Context) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'`)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	for _, table := range tables {
+		// Table names come from sqlite_master, not user input, so quoting them is safe
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM "%s"`, table)); err != nil {
+			return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM sqlite_sequence"); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	slog.Warn("SQLite database reset", "tables", tables)
+	return nil
+}
+
+// studentCols is the column list scanStudent expects
+const studentCols = "id, public_id, name, email, age, date_of_birth, phone, custom_fields"
+
+// scanStudent scans one row of studentCols, followed by any columns scanned into extra, and
+// derives the age
+func scanStudent(rows interface{ Scan(...any) error }, now time.Time, extra ...any) (types.Student, error) {
+	var student types.Student
+	var dob, phone, custom sql.NullString
+	dest := append([]any{&student.ID, &student.PublicID, &student.Name, &student.Email, &student.Age, &dob, &phone, &custom}, extra...)
+	if err := rows.Scan(dest...); err != nil {
+		return student, err
+	}
+	student.DateOfBirth = dob.String
+	student.Phone = phone.String
+	if custom.Valid {
+		if err := json.Unmarshal([]byte(custom.String), &student.CustomFields); err != nil {
+			return student, err
+		}
+	}
+	student.DeriveAge(now)
+	return student, nil
+}
+
+// customFieldsJSON encodes custom field values for the custom_fields column; a student without
+// any stores NULL
+func customFieldsJSON(values map[string]any) (sql.NullString, error) {
+	if len(values) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("%w: custom fields: %v", storage.ErrInvalidData, err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// nullString maps "" to SQL NULL so optional columns stay NULL instead of empty strings
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullID is NULL for the zero ID, which leaves the row ID to the table's sequence
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+// ids returns the generator of new students' IDs
+func (s *Sqlite) ids() idgen.Generator {
+	if s.IDs != nil {
+		return s.IDs
+	}
+	return idgen.Default()
+}
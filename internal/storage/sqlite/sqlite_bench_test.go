@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// newBenchStore opens a fresh database in a temp dir, optionally pre-filled with n students
+func newBenchStore(b *testing.B, n int) *Sqlite {
+	b.Helper()
+
+	s, err := NewSqlite(&config.Config{StoragePath: filepath.Join(b.TempDir(), "bench.db")})
+	if err != nil {
+		b.Fatalf("NewSqlite: %v", err)
+	}
+	b.Cleanup(func() { s.Close() })
+
+	if n > 0 {
+		if _, err := s.CreateStudents(context.Background(), benchStudents(n)); err != nil {
+			b.Fatalf("seeding: %v", err)
+		}
+	}
+	return s
+}
+
+func benchStudents(n int) []types.Student {
+	students := make([]types.Student, n)
+	for i := range students {
+		students[i] = types.Student{
+			Name:  fmt.Sprintf("Student %d", i),
+			Email: fmt.Sprintf("student%d@example.com", i),
+			Age:   18 + i%40,
+		}
+	}
+	return students
+}
+
+func BenchmarkCreateStudent(b *testing.B) {
+	s := newBenchStore(b, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.CreateStudent(types.Student{Name: "Bench", Email: "bench@example.com", Age: 20}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCreateStudentsBulk(b *testing.B) {
+	for _, size := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("rows=%d", size), func(b *testing.B) {
+			s := newBenchStore(b, 0)
+			students := benchStudents(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.CreateStudents(context.Background(), students); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetStudent(b *testing.B) {
+	s := newBenchStore(b, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.GetStudent(int64(i%1000) + 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetStudentsList(b *testing.B) {
+	s := newBenchStore(b, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.GetStudentsList((i%50)*20, 20); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetStudentsCount(b *testing.B) {
+	s := newBenchStore(b, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.GetStudentsCount(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/config"
+	"github.com/prashantkumbhar2002/go_students_api/internal/idgen"
+	"github.com/prashantkumbhar2002/go_students_api/internal/reports"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.TestStorage(t, func(t *testing.T) storage.Storage {
+		s, err := NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "test.db")})
+		if err != nil {
+			t.Fatalf("NewSqlite: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	})
+}
+
+func TestSnowflakeStudentIDs(t *testing.T) {
+	s := newTestSqlite(t)
+	s.IDs, _ = idgen.NewSnowflakes(7, nil)
+	ctx := context.Background()
+
+	id, err := s.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 20})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	ids, err := s.CreateStudents(ctx, []types.Student{{Name: "Ravi", Email: "ravi@example.com", Age: 21}, {Name: "Meera", Email: "meera@example.com", Age: 22}})
+	if err != nil {
+		t.Fatalf("CreateStudents: %v", err)
+	}
+	// Snowflakes carry their node in bits 12-21 and increase on one node
+	for i, got := range append([]int64{id}, ids...) {
+		if got>>12&1023 != 7 || (i > 0 && got <= id) {
+			t.Errorf("ID %d = %d, want a snowflake of node 7 after %d", i, got, id)
+		}
+		st, err := s.GetStudent(got)
+		if err != nil || !types.ValidPublicID(st.PublicID) {
+			t.Errorf("GetStudent(%d) = %+v, %v, want the student with a UUID", got, st, err)
+		}
+	}
+}
+
+func TestInspectSchema(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	if _, err := InspectSchema(ctx, path); err == nil {
+		t.Fatal("InspectSchema() on a missing file succeeded")
+	}
+
+	s, err := NewSqlite(&config.Config{StoragePath: path})
+	if err != nil {
+		t.Fatalf("NewSqlite: %v", err)
+	}
+	s.Close()
+
+	status, err := InspectSchema(ctx, path)
+	if err != nil {
+		t.Fatalf("InspectSchema() error = %v", err)
+	}
+	if status.Current != status.Latest || status.Pending() != 0 {
+		t.Fatalf("status = %+v, want the migrated database to be current", status)
+	}
+}
+
+func TestStudentStats(t *testing.T) {
+	s := newTestSqlite(t)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	s.CreateStudent(types.Student{Name: "A", Email: "a@example.com", Age: 19})
+	s.CreateStudent(types.Student{Name: "B", Email: "b@example.com", Age: 45})
+	// Turns 22 tomorrow, so still 21 and in the 18-21 bucket
+	s.CreateStudent(types.Student{Name: "C", Email: "c@example.com", Age: 21, DateOfBirth: "2004-03-11"})
+	s.Db.Exec("UPDATE students SET created_at = '2026-01-15 08:00:00', anonymized_at = '2026-02-01 00:00:00' WHERE name = 'B'")
+	s.Db.Exec("UPDATE students SET created_at = '2026-03-01 08:00:00' WHERE name != 'B'")
+
+	stats, err := s.StudentStats(ctx, now, 3)
+	if err != nil {
+		t.Fatalf("StudentStats() error = %v", err)
+	}
+
+	if stats.Total != 3 {
+		t.Errorf("Total = %d, want 3", stats.Total)
+	}
+	if len(stats.ByAge) != len(types.AgeBuckets) || stats.ByAge[1].Count != 2 || stats.ByAge[5].Count != 1 {
+		t.Errorf("ByAge = %+v, want 2 in 18-21 and 1 over 40", stats.ByAge)
+	}
+	want := []types.Count{{Key: types.StudentActive, Count: 2}, {Key: types.StudentAnonymized, Count: 1}}
+	if !reflect.DeepEqual(stats.ByStatus, want) {
+		t.Errorf("ByStatus = %+v, want %+v", stats.ByStatus, want)
+	}
+	want = []types.Count{{Key: "2026-01", Count: 1}, {Key: "2026-02", Count: 0}, {Key: "2026-03", Count: 2}}
+	if !reflect.DeepEqual(stats.CreatedPerMonth, want) {
+		t.Errorf("CreatedPerMonth = %+v, want %+v", stats.CreatedPerMonth, want)
+	}
+}
+
+func TestReport(t *testing.T) {
+	s := newTestSqlite(t)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	s.CreateStudent(types.Student{Name: "A", Email: "a@school.edu", Age: 19})
+	s.CreateStudent(types.Student{Name: "B", Email: "b@School.edu", Age: 45, Phone: "+919876543210"})
+	s.CreateStudent(types.Student{Name: "C", Email: "c@example.com", Age: 21, DateOfBirth: "2004-03-11"})
+
+	report, err := s.Report(context.Background(), types.ReportQuery{
+		GroupBy: []string{reports.DimEmailDomain},
+		Metrics: []string{reports.MetricCount, reports.MetricMaxAge},
+		Limit:   1,
+	}, now)
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	want := []map[string]any{{"email_domain": "example.com", "count": 1.0, "max_age": 21.0}}
+	if !reflect.DeepEqual(report.Rows, want) || !report.Truncated {
+		t.Fatalf("Report() = %+v, want rows %v truncated", report, want)
+	}
+
+	// Age buckets sort by age, not by label
+	report, err = s.Report(context.Background(), types.ReportQuery{
+		GroupBy: []string{reports.DimAgeBucket}, Metrics: []string{reports.MetricCount}, Limit: 10,
+	}, now)
+	if err != nil || len(report.Rows) != 2 || report.Rows[0]["age_bucket"] != "18-21" || report.Rows[0]["count"] != 2.0 {
+		t.Fatalf("Report() = %+v, %v", report, err)
+	}
+}
+
+func TestSearchStudents(t *testing.T) {
+	s := newTestSqlite(t)
+	ctx := context.Background()
+
+	s.CreateStudent(types.Student{Name: "Ravi Asha", Email: "ravi@example.com", Age: 20})
+	s.CreateStudent(types.Student{Name: "Asha Patil", Email: "patil@example.com", Age: 20})
+	s.CreateStudent(types.Student{Name: "Meera", Email: "asha.m@example.com", Age: 20})
+
+	hits, err := s.SearchStudents(ctx, "ASHA", 10)
+	if err != nil {
+		t.Fatalf("SearchStudents() error = %v", err)
+	}
+	var names []string
+	for _, h := range hits {
+		names = append(names, h.Student.Name)
+	}
+	// Name prefix matches first, then by ID
+	if want := []string{"Asha Patil", "Ravi Asha", "Meera"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("SearchStudents() = %v, want %v", names, want)
+	}
+
+	// Wildcards in the query match literally: "a_p" would match "Asha Patil" as a pattern
+	hits, err = s.SearchStudents(ctx, "a_p", 10)
+	if err != nil || len(hits) != 0 {
+		t.Errorf("SearchStudents(a_p) = %v, %v; want no hits", hits, err)
+	}
+}
+
+func TestPublicIDBackfill(t *testing.T) {
+	s, err := NewSqlite(&config.Config{StoragePath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewSqlite: %v", err)
+	}
+	defer s.Close()
+
+	for range 50 {
+		if _, err := s.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 20}); err != nil {
+			t.Fatalf("CreateStudent: %v", err)
+		}
+	}
+
+	// Rerun the backfill over every row, as if they predated the column
+	var backfill string
+	for _, m := range migrations {
+		if m.name == "add students.public_id" {
+			backfill = m.stmts[1]
+		}
+	}
+	if _, err := s.Db.Exec(backfill); err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	err = s.StreamStudents(context.Background(), func(st types.Student) error {
+		if !types.ValidPublicID(st.PublicID) || seen[st.PublicID] {
+			t.Errorf("student %d has public ID %q, want a new UUID", st.ID, st.PublicID)
+		}
+		seen[st.PublicID] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamStudents: %v", err)
+	}
+}
+
+func TestStudentChanges(t *testing.T) {
+	s := newTestSqlite(t)
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	s.Clock = clk
+
+	id, _ := s.CreateStudent(types.Student{Name: "A", Email: "a@example.com", Age: 19})
+	s.Db.Exec("UPDATE students SET created_at = '2026-01-15 08:00:00', updated_at = '2026-01-15 08:00:00'")
+	since := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	// Writing the same values is no change
+	student, _ := s.GetStudent(id)
+	if _, changed, err := s.UpdateStudent(ctx, student); err != nil || len(changed) != 0 {
+		t.Fatalf("UpdateStudent(same) = %v, %v, want no changes", changed, err)
+	}
+	if changes, err := s.StudentChanges(ctx, since, "", 10); err != nil || len(changes) != 0 {
+		t.Fatalf("StudentChanges after a no-op = %+v, %v, want none", changes, err)
+	}
+
+	student.Phone = "+919876543210"
+	if _, _, err := s.UpdateStudent(ctx, student); err != nil {
+		t.Fatalf("UpdateStudent: %v", err)
+	}
+	clk.Advance(time.Second)
+	changes, err := s.StudentChanges(ctx, since, "", 10)
+	if err != nil || len(changes) != 1 || changes[0].Change != types.ChangeUpdated || changes[0].Student.Phone != student.Phone {
+		t.Fatalf("StudentChanges = %+v, %v, want A updated", changes, err)
+	}
+	// Anonymizing is a change too
+	s.Db.Exec("UPDATE students SET updated_at = '2026-01-15 08:00:00'")
+	if _, err := s.AnonymizeStudentsCreatedBefore(ctx, since, false); err != nil {
+		t.Fatalf("AnonymizeStudentsCreatedBefore: %v", err)
+	}
+	clk.Advance(time.Second)
+	if changes, err := s.StudentChanges(ctx, since, "", 10); err != nil || len(changes) != 1 || changes[0].Student.Name != "Anonymized student" {
+		t.Errorf("StudentChanges after anonymizing = %+v, %v, want A updated", changes, err)
+	}
+}
+
+func TestStudentChangesStampedFromClock(t *testing.T) {
+	s := newTestSqlite(t)
+	ctx := context.Background()
+	created := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	c := clock.NewFake(created)
+	s.Clock = c
+
+	id, err := s.CreateStudent(types.Student{Name: "A", Email: "a@example.com", Age: 19})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	c.Advance(time.Second)
+	changes, err := s.StudentChanges(ctx, created.Add(-time.Hour), "", 10)
+	if err != nil || len(changes) != 1 || !changes[0].ChangedAt.Equal(created) {
+		t.Fatalf("StudentChanges after creating = %+v, %v, want A changed at %v", changes, err, created)
+	}
+
+	c.Advance(time.Hour)
+	updated := c.Now()
+	student, _ := s.GetStudent(id)
+	student.Name = "B"
+	if _, _, err := s.UpdateStudent(ctx, student); err != nil {
+		t.Fatalf("UpdateStudent: %v", err)
+	}
+	c.Advance(time.Second)
+	changes, err = s.StudentChanges(ctx, created, "", 10)
+	if err != nil || len(changes) != 1 || !changes[0].ChangedAt.Equal(updated) {
+		t.Fatalf("StudentChanges after updating = %+v, %v, want A changed at %v", changes, err, updated)
+	}
+
+	c.Advance(time.Hour)
+	deleted := c.Now()
+	if n, err := s.DeleteStudentsCreatedBefore(ctx, created.Add(time.Second), false); err != nil || n != 1 {
+		t.Fatalf("DeleteStudentsCreatedBefore = %d, %v, want 1", n, err)
+	}
+	c.Advance(time.Second)
+	changes, err = s.StudentChanges(ctx, created, "", 10)
+	if err != nil || len(changes) != 1 || changes[0].Change != types.ChangeDeleted || !changes[0].ChangedAt.Equal(deleted) {
+		t.Fatalf("StudentChanges after deleting = %+v, %v, want A deleted at %v", changes, err, deleted)
+	}
+}
@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.ImportStager = (*Sqlite)(nil)
+
+// stagedInsertCols is the number of columns StageImport binds per row
+const stagedInsertCols = 8
+
+// stagedCols is the column list scanStaged expects
+const stagedCols = "data_row, name, email, age, date_of_birth, phone, custom_fields"
+
+// StageImport implements storage.ImportStager with multi-row inserts in one transaction, like
+// CreateStudents
+func (s *Sqlite) StageImport(ctx context.Context, jobID int64, rows []types.StagedStudent) error {
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	chunkSize := maxSQLParams / stagedInsertCols
+	for start := 0; start < len(rows); start += chunkSize {
+		chunk := rows[start:min(start+chunkSize, len(rows))]
+		args := make([]any, 0, len(chunk)*stagedInsertCols)
+		for _, r := range chunk {
+			custom, err := customFieldsJSON(r.CustomFields)
+			if err != nil {
+				return err
+			}
+			args = append(args, jobID, r.Row, r.Name, r.Email, r.Age, nullString(r.DateOfBirth), nullString(r.Phone), custom)
+		}
+		query := "INSERT INTO import_staging (job_id, " + stagedCols + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?)" +
+			strings.Repeat(", (?, ?, ?, ?, ?, ?, ?, ?)", len(chunk)-1)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return nil
+}
+
+// PreviewImport implements storage.ImportStager in one pass over the staged rows. Each row's
+// first occurrence in the file and any live student with its email come from the
+// import_staging_email and students_email indexes.
+func (s *Sqlite) PreviewImport(ctx context.Context, jobID int64, limit int) (types.ImportPreview, error) {
+	preview := types.ImportPreview{Create: []types.StagedStudent{}, Duplicate: []types.ImportConflict{}, Conflict: []types.ImportConflict{}}
+	rows, err := s.Db.QueryContext(ctx, `SELECT `+stagedCols+`,
+			(SELECT MIN(f.data_row) FROM import_staging f WHERE f.job_id = s.job_id AND lower(f.email) = lower(s.email)),
+			(SELECT st.public_id FROM students st WHERE st.deleted_at IS NULL AND lower(st.email) = lower(s.email) LIMIT 1)
+		FROM import_staging s WHERE s.job_id = ? ORDER BY s.data_row`, jobID)
+	if err != nil {
+		return preview, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			firstRow  int
+			studentID sql.NullString
+		)
+		r, err := scanStaged(rows, &firstRow, &studentID)
+		if err != nil {
+			return preview, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		preview.Staged++
+		switch {
+		case studentID.Valid:
+			preview.Conflicts++
+			if len(preview.Conflict) < limit {
+				preview.Conflict = append(preview.Conflict, types.ImportConflict{Row: r.Row, Email: r.Email, StudentID: studentID.String})
+			}
+		case firstRow < r.Row:
+			preview.Duplicates++
+			if len(preview.Duplicate) < limit {
+				preview.Duplicate = append(preview.Duplicate, types.ImportConflict{Row: r.Row, Email: r.Email, FirstRow: firstRow})
+			}
+		default:
+			preview.New++
+			if len(preview.Create) < limit {
+				preview.Create = append(preview.Create, r)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return preview, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if preview.Staged == 0 {
+		return preview, fmt.Errorf("%w: job %d", storage.ErrNothingStaged, jobID)
+	}
+	return preview, nil
+}
+
+// StagedRows implements storage.ImportStager
+func (s *Sqlite) StagedRows(ctx context.Context, jobID int64, afterRow, limit int) ([]types.StagedStudent, error) {
+	rows, err := s.Db.QueryContext(ctx, "SELECT "+stagedCols+" FROM import_staging WHERE job_id = ? AND data_row > ? ORDER BY data_row LIMIT ?",
+		jobID, afterRow, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var staged []types.StagedStudent
+	for rows.Next() {
+		r, err := scanStaged(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		staged = append(staged, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return staged, nil
+}
+
+// DiscardImport implements storage.ImportStager
+func (s *Sqlite) DiscardImport(ctx context.Context, jobID int64) error {
+	result, err := s.Db.ExecContext(ctx, "DELETE FROM import_staging WHERE job_id = ?", jobID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return fmt.Errorf("%w: job %d", storage.ErrNothingStaged, jobID)
+	}
+	return nil
+}
+
+// scanStaged scans one row of stagedCols, followed by any columns scanned into extra
+func scanStaged(rows interface{ Scan(...any) error }, extra ...any) (types.StagedStudent, error) {
+	var (
+		r                  types.StagedStudent
+		dob, phone, custom sql.NullString
+	)
+	dest := append([]any{&r.Row, &r.Name, &r.Email, &r.Age, &dob, &phone, &custom}, extra...)
+	if err := rows.Scan(dest...); err != nil {
+		return r, err
+	}
+	r.DateOfBirth, r.Phone = dob.String, phone.String
+	if custom.Valid {
+		if err := json.Unmarshal([]byte(custom.String), &r.CustomFields); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
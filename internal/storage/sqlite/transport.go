@@ -0,0 +1,304 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Transport = (*Sqlite)(nil)
+
+// routeColumns are scanned by scanRoute
+const routeColumns = `r.id, r.public_id, r.name, r.capacity,
+	(SELECT COUNT(*) FROM bus_riders WHERE route_id = r.id)`
+
+// riderQuery selects the columns scanned by scanRider
+const riderQuery = `SELECT r.id, r.public_id, st.id, st.public_id, st.name, st.position,
+		s.id, s.public_id, s.name, COALESCE(s.phone, ''), b.assigned_at
+	FROM bus_riders b
+	JOIN bus_routes r ON r.id = b.route_id
+	JOIN bus_stops st ON st.id = b.stop_id
+	JOIN students s ON s.id = b.student_id`
+
+// AddRoute implements storage.Transport. The route and its stops are inserted in one transaction.
+func (s *Sqlite) AddRoute(ctx context.Context, route types.BusRoute) (types.BusRoute, error) {
+	if route.PublicID == "" {
+		route.PublicID = types.NewPublicID()
+	}
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.BusRoute{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO bus_routes (public_id, name, capacity) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+		route.PublicID, route.Name, route.Capacity)
+	if err != nil {
+		return types.BusRoute{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return types.BusRoute{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return types.BusRoute{}, storage.ErrDuplicate
+	}
+	if route.ID, err = result.LastInsertId(); err != nil {
+		return types.BusRoute{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	stops := make([]types.Stop, len(route.Stops))
+	for i, stop := range route.Stops {
+		if stops[i], err = insertStop(ctx, tx, route.ID, stop, i+1); err != nil {
+			return types.BusRoute{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return types.BusRoute{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	route.Stops, route.Riders = stops, 0
+	return route, nil
+}
+
+// GetRouteByPublicID implements storage.Transport
+func (s *Sqlite) GetRouteByPublicID(ctx context.Context, publicID string) (types.BusRoute, error) {
+	route, err := scanRoute(s.Db.QueryRowContext(ctx, "SELECT "+routeColumns+" FROM bus_routes r WHERE r.public_id = ?", publicID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.BusRoute{}, storage.ErrRouteNotFound
+	}
+	if err != nil {
+		return types.BusRoute{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	routes := []types.BusRoute{route}
+	if err := s.loadStops(ctx, routes); err != nil {
+		return types.BusRoute{}, err
+	}
+	return routes[0], nil
+}
+
+// ListRoutes implements storage.Transport with one query for the routes and one for all their stops
+func (s *Sqlite) ListRoutes(ctx context.Context) ([]types.BusRoute, error) {
+	rows, err := s.Db.QueryContext(ctx, "SELECT "+routeColumns+" FROM bus_routes r ORDER BY r.name")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var routes []types.BusRoute
+	for rows.Next() {
+		route, err := scanRoute(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		routes = append(routes, route)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if err := s.loadStops(ctx, routes); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// AddStop implements storage.Transport
+func (s *Sqlite) AddStop(ctx context.Context, routeID int64, stop types.Stop) (types.Stop, error) {
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Stop{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	var routes, last int
+	err = tx.QueryRowContext(ctx, `SELECT
+			(SELECT COUNT(*) FROM bus_routes WHERE id = ?),
+			(SELECT COALESCE(MAX(position), 0) FROM bus_stops WHERE route_id = ?)`, routeID, routeID).Scan(&routes, &last)
+	if err != nil {
+		return types.Stop{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if routes == 0 {
+		return types.Stop{}, storage.ErrRouteNotFound
+	}
+	if stop, err = insertStop(ctx, tx, routeID, stop, last+1); err != nil {
+		return types.Stop{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return types.Stop{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return stop, nil
+}
+
+// AssignRoute implements storage.Transport. As in AllocateRoom, the insert only happens while the
+// bus has a free seat and the transaction begins IMMEDIATE, so of two assignments racing for the
+// last seat one wins and the other sees the bus full.
+func (s *Sqlite) AssignRoute(ctx context.Context, routeID, stopID, studentID int64) (types.RouteAssignment, error) {
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.RouteAssignment{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	var routes, stops, students, riding int
+	err = tx.QueryRowContext(ctx, `SELECT
+			(SELECT COUNT(*) FROM bus_routes WHERE id = ?),
+			(SELECT COUNT(*) FROM bus_stops WHERE id = ? AND route_id = ?),
+			(SELECT COUNT(*) FROM students WHERE id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM bus_riders WHERE student_id = ?)`, routeID, stopID, routeID, studentID, studentID).
+		Scan(&routes, &stops, &students, &riding)
+	if err != nil {
+		return types.RouteAssignment{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	switch {
+	case routes == 0:
+		return types.RouteAssignment{}, storage.ErrRouteNotFound
+	case stops == 0:
+		return types.RouteAssignment{}, storage.ErrStopNotFound
+	case students == 0:
+		return types.RouteAssignment{}, storage.ErrNotFound
+	case riding > 0:
+		return types.RouteAssignment{}, storage.ErrHasRoute
+	}
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO bus_riders (route_id, stop_id, student_id, assigned_at)
+		SELECT r.id, ?, ?, ? FROM bus_routes r
+		WHERE r.id = ? AND (SELECT COUNT(*) FROM bus_riders WHERE route_id = r.id) < r.capacity`,
+		stopID, studentID, s.Clock.Now().UTC().Format(sqliteTime), routeID)
+	if err != nil {
+		return types.RouteAssignment{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return types.RouteAssignment{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n == 0 {
+		return types.RouteAssignment{}, storage.ErrRouteFull
+	}
+
+	assignment, err := scanRider(tx.QueryRowContext(ctx, riderQuery+" WHERE b.student_id = ?", studentID))
+	if err != nil {
+		return types.RouteAssignment{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return types.RouteAssignment{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return assignment, nil
+}
+
+// UnassignRoute implements storage.Transport
+func (s *Sqlite) UnassignRoute(ctx context.Context, routeID, studentID int64) error {
+	n, err := s.exec(ctx, "DELETE FROM bus_riders WHERE route_id = ? AND student_id = ?", routeID, studentID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// RouteManifest implements storage.Transport
+func (s *Sqlite) RouteManifest(ctx context.Context, routeID int64) ([]types.RouteAssignment, error) {
+	rows, err := s.Db.QueryContext(ctx, riderQuery+" WHERE b.route_id = ? ORDER BY st.position, s.name, s.id", routeID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var manifest []types.RouteAssignment
+	for rows.Next() {
+		rider, err := scanRider(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		manifest = append(manifest, rider)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return manifest, nil
+}
+
+// loadStops fills in the stops of routes, in position order
+func (s *Sqlite) loadStops(ctx context.Context, routes []types.BusRoute) error {
+	if len(routes) == 0 {
+		return nil
+	}
+	index := make(map[int64]int, len(routes))
+	ids := make([]int64, len(routes))
+	for i, route := range routes {
+		index[route.ID] = i
+		ids[i] = route.ID
+		routes[i].Stops = []types.Stop{}
+	}
+	idList, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	rows, err := s.Db.QueryContext(ctx, `SELECT route_id, id, public_id, name, position FROM bus_stops
+		WHERE route_id IN (SELECT value FROM json_each(?))
+		ORDER BY route_id, position`, string(idList))
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			routeID int64
+			stop    types.Stop
+		)
+		if err := rows.Scan(&routeID, &stop.ID, &stop.PublicID, &stop.Name, &stop.Position); err != nil {
+			return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		i := index[routeID]
+		routes[i].Stops = append(routes[i].Stops, stop)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return nil
+}
+
+// insertStop inserts a stop of the route at position
+func insertStop(ctx context.Context, tx *sql.Tx, routeID int64, stop types.Stop, position int) (types.Stop, error) {
+	if stop.PublicID == "" {
+		stop.PublicID = types.NewPublicID()
+	}
+	result, err := tx.ExecContext(ctx, "INSERT INTO bus_stops (public_id, route_id, name, position) VALUES (?, ?, ?, ?)",
+		stop.PublicID, routeID, stop.Name, position)
+	if err != nil {
+		return types.Stop{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if stop.ID, err = result.LastInsertId(); err != nil {
+		return types.Stop{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	stop.Position = position
+	return stop, nil
+}
+
+func scanRoute(row interface{ Scan(...any) error }) (types.BusRoute, error) {
+	var r types.BusRoute
+	err := row.Scan(&r.ID, &r.PublicID, &r.Name, &r.Capacity, &r.Riders)
+	return r, err
+}
+
+func scanRider(row interface{ Scan(...any) error }) (types.RouteAssignment, error) {
+	var (
+		a        types.RouteAssignment
+		assigned string
+	)
+	err := row.Scan(&a.RouteID, &a.RoutePublicID, &a.StopID, &a.StopPublicID, &a.StopName, &a.StopPosition,
+		&a.StudentID, &a.StudentPublicID, &a.StudentName, &a.StudentPhone, &assigned)
+	if err != nil {
+		return types.RouteAssignment{}, err
+	}
+	if a.AssignedAt, err = time.Parse(sqliteTime, assigned); err != nil {
+		return types.RouteAssignment{}, err
+	}
+	return a, nil
+}
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+)
+
+var _ storage.Transactor = (*Sqlite)(nil)
+
+// txKey carries the transaction InTx runs fn's writes in
+type txKey struct{}
+
+type joinedTx struct {
+	s  *Sqlite
+	tx *sql.Tx
+}
+
+// InTx implements storage.Transactor. The write methods and AppendEvent join the transaction;
+// everything else, reads included, runs outside it.
+func (s *Sqlite) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := s.joined(ctx); ok || storage.IsDryRun(ctx) {
+		return fn(ctx)
+	}
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+	if err := fn(context.WithValue(ctx, txKey{}, joinedTx{s: s, tx: tx})); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return nil
+}
+
+// txn is a write transaction of one method. Joined to InTx's, it leaves Commit and Rollback to
+// InTx: the method's work is committed, or not, with the rest of fn's.
+type txn struct {
+	*sql.Tx
+	joined bool
+}
+
+func (t *txn) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t *txn) Rollback() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+// begin starts a write transaction, or joins the one InTx runs ctx in
+func (s *Sqlite) begin(ctx context.Context) (*txn, error) {
+	if tx, ok := s.joined(ctx); ok {
+		return &txn{Tx: tx, joined: true}, nil
+	}
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx}, nil
+}
+
+// joined returns the transaction InTx runs ctx in, if it is one of s's
+func (s *Sqlite) joined(ctx context.Context) (*sql.Tx, bool) {
+	t, ok := ctx.Value(txKey{}).(joinedTx)
+	if !ok || t.s != s {
+		return nil, false
+	}
+	return t.tx, true
+}
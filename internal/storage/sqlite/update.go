@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Updater = (*Sqlite)(nil)
+
+// UpdateStudent implements storage.Updater
+func (s *Sqlite) UpdateStudent(ctx context.Context, student types.Student) (types.Student, []string, error) {
+	custom, err := customFieldsJSON(student.CustomFields)
+	if err != nil {
+		return types.Student{}, nil, err
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return types.Student{}, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	now := s.Clock.Now()
+	const query = "SELECT " + studentCols + " FROM students WHERE id = ? AND deleted_at IS NULL"
+	old, err := scanStudent(tx.QueryRowContext(ctx, query, student.ID), now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Student{}, nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return types.Student{}, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE students SET name = ?, email = ?, age = ?, date_of_birth = ?, phone = ?, custom_fields = ?, updated_at = ? WHERE id = ?",
+		student.Name, student.Email, student.Age, nullString(student.DateOfBirth), nullString(student.Phone), custom, now.UTC().Format(sqliteTime), student.ID)
+	if err != nil {
+		return types.Student{}, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	updated, err := scanStudent(tx.QueryRowContext(ctx, query, student.ID), now)
+	if err != nil {
+		return types.Student{}, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	changed := types.ChangedFields(old, updated)
+	if len(changed) == 0 || storage.IsDryRun(ctx) {
+		// Rolled back, so a no-op keeps updated_at and stays out of the changes feed
+		return updated, changed, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return types.Student{}, nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return updated, changed, nil
+}
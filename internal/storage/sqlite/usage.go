@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.UsageCounter = (*Sqlite)(nil)
+
+// CountRequest implements storage.UsageCounter. The increment is conditional on the count, so
+// concurrent requests can't take a client past limit.
+func (s *Sqlite) CountRequest(ctx context.Context, client, month string, limit int64) (int64, bool, error) {
+	var n int64
+	err := s.Db.QueryRowContext(ctx, `INSERT INTO request_usage (client, month, requests) VALUES (?, ?, 1)
+		ON CONFLICT (month, client) DO UPDATE SET requests = requests + 1 WHERE requests < ? RETURNING requests`,
+		client, month, limit).Scan(&n)
+	if err == nil {
+		return n, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+
+	// Nothing was updated: the client is at its limit
+	err = s.Db.QueryRowContext(ctx, "SELECT requests FROM request_usage WHERE month = ? AND client = ?", month, client).Scan(&n)
+	if err != nil {
+		return 0, false, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return n, false, nil
+}
+
+// ListUsage implements storage.UsageCounter
+func (s *Sqlite) ListUsage(ctx context.Context, month string) ([]types.Usage, error) {
+	rows, err := s.Db.QueryContext(ctx, "SELECT client, month, requests FROM request_usage WHERE month = ? ORDER BY requests DESC, client", month)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var usage []types.Usage
+	for rows.Next() {
+		var u types.Usage
+		if err := rows.Scan(&u.Client, &u.Month, &u.Requests); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Views = (*Sqlite)(nil)
+
+// SaveView implements storage.Views
+func (s *Sqlite) SaveView(ctx context.Context, view types.View) (types.View, error) {
+	view.CreatedAt = s.Clock.Now().UTC().Truncate(time.Second)
+	result, err := s.Db.ExecContext(ctx, "INSERT INTO views (name, filter, expand, created_at) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING",
+		view.Name, view.Filter, strings.Join(view.Expand, ","), view.CreatedAt.Format(sqliteTime))
+	if err != nil {
+		return types.View{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return types.View{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return types.View{}, storage.ErrDuplicate
+	}
+	return view, nil
+}
+
+// GetView implements storage.Views
+func (s *Sqlite) GetView(ctx context.Context, name string) (types.View, error) {
+	view, err := scanView(s.Db.QueryRowContext(ctx, "SELECT name, filter, expand, created_at FROM views WHERE name = ?", name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.View{}, storage.ErrViewNotFound
+	}
+	if err != nil {
+		return types.View{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return view, nil
+}
+
+// ListViews implements storage.Views
+func (s *Sqlite) ListViews(ctx context.Context) ([]types.View, error) {
+	rows, err := s.Db.QueryContext(ctx, "SELECT name, filter, expand, created_at FROM views ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var views []types.View
+	for rows.Next() {
+		view, err := scanView(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		views = append(views, view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return views, nil
+}
+
+// DeleteView implements storage.Views
+func (s *Sqlite) DeleteView(ctx context.Context, name string) error {
+	result, err := s.Db.ExecContext(ctx, "DELETE FROM views WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return storage.ErrViewNotFound
+	}
+	return nil
+}
+
+// scanView reads a row of name, filter, expand and created_at
+func scanView(row interface{ Scan(...any) error }) (types.View, error) {
+	var (
+		view              types.View
+		expand, createdAt string
+	)
+	if err := row.Scan(&view.Name, &view.Filter, &expand, &createdAt); err != nil {
+		return types.View{}, err
+	}
+	if expand != "" {
+		view.Expand = strings.Split(expand, ",")
+	}
+	var err error
+	view.CreatedAt, err = time.Parse(sqliteTime, createdAt)
+	return view, err
+}
@@ -0,0 +1,277 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+var _ storage.Webhooks = (*Sqlite)(nil)
+
+const webhookColumns = "id, public_id, owner, url, events, secret, previous_secret, previous_secret_expires_at, created_at"
+
+// CreateWebhook implements storage.Webhooks
+func (s *Sqlite) CreateWebhook(ctx context.Context, hook types.Webhook) (types.Webhook, error) {
+	if hook.PublicID == "" {
+		hook.PublicID = types.NewPublicID()
+	}
+	hook.PreviousSecret, hook.PreviousSecretExpiresAt = "", nil
+	hook.CreatedAt = s.Clock.Now().UTC().Truncate(time.Second)
+	result, err := s.Db.ExecContext(ctx, "INSERT INTO webhooks (public_id, owner, url, events, secret, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		hook.PublicID, hook.Owner, hook.URL, strings.Join(hook.Events, ","), hook.Secret, hook.CreatedAt.Format(sqliteTime))
+	if err != nil {
+		return types.Webhook{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if hook.ID, err = result.LastInsertId(); err != nil {
+		return types.Webhook{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return hook, nil
+}
+
+// GetWebhook implements storage.Webhooks
+func (s *Sqlite) GetWebhook(ctx context.Context, publicID string) (types.Webhook, error) {
+	hook, err := s.scanWebhook(s.Db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE public_id = ?", publicID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Webhook{}, storage.ErrWebhookNotFound
+	}
+	if err != nil {
+		return types.Webhook{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return hook, nil
+}
+
+// ListWebhooks implements storage.Webhooks
+func (s *Sqlite) ListWebhooks(ctx context.Context, owner string) ([]types.Webhook, error) {
+	return s.queryWebhooks(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE owner = ? ORDER BY id", owner)
+}
+
+// WebhooksFor implements storage.Webhooks
+func (s *Sqlite) WebhooksFor(ctx context.Context, event string) ([]types.Webhook, error) {
+	return s.queryWebhooks(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE ',' || events || ',' LIKE '%,' || ? || ',%' ORDER BY id", event)
+}
+
+// RotateWebhookSecret implements storage.Webhooks
+func (s *Sqlite) RotateWebhookSecret(ctx context.Context, publicID, secret string, expires time.Time) (types.Webhook, error) {
+	result, err := s.Db.ExecContext(ctx, "UPDATE webhooks SET previous_secret = secret, previous_secret_expires_at = ?, secret = ? WHERE public_id = ?",
+		expires.UTC().Format(sqliteTime), secret, publicID)
+	if err != nil {
+		return types.Webhook{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return types.Webhook{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return types.Webhook{}, storage.ErrWebhookNotFound
+	}
+	return s.GetWebhook(ctx, publicID)
+}
+
+// DeleteWebhook implements storage.Webhooks
+func (s *Sqlite) DeleteWebhook(ctx context.Context, publicID string) error {
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE webhook_id = (SELECT id FROM webhooks WHERE public_id = ?)", publicID); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM webhooks WHERE public_id = ?", publicID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return storage.ErrWebhookNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return nil
+}
+
+const webhookDeliveryColumns = "d.id, d.public_id, w.public_id, d.event, d.event_id, d.body, d.status, d.attempts, d.response_status, d.response_body, d.error, d.created_at, d.updated_at"
+
+// CreateWebhookDelivery implements storage.Webhooks
+func (s *Sqlite) CreateWebhookDelivery(ctx context.Context, d types.WebhookDelivery) (types.WebhookDelivery, error) {
+	if d.PublicID == "" {
+		d.PublicID = types.NewPublicID()
+	}
+	now := s.Clock.Now().UTC().Truncate(time.Second)
+	d.Status, d.Attempts = types.DeliveryQueued, 0
+	d.ResponseStatus, d.ResponseBody, d.Error = 0, "", ""
+	d.CreatedAt, d.UpdatedAt = now, now
+	result, err := s.Db.ExecContext(ctx, `INSERT INTO webhook_deliveries (public_id, webhook_id, event, event_id, body, status, created_at, updated_at)
+		SELECT ?, id, ?, ?, ?, ?, ?, ? FROM webhooks WHERE public_id = ?`,
+		d.PublicID, d.Event, d.EventID, string(d.Body), d.Status, now.Format(sqliteTime), now.Format(sqliteTime), d.WebhookID)
+	if err != nil {
+		return types.WebhookDelivery{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return types.WebhookDelivery{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	} else if n == 0 {
+		return types.WebhookDelivery{}, storage.ErrWebhookNotFound
+	}
+	if d.ID, err = result.LastInsertId(); err != nil {
+		return types.WebhookDelivery{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return d, nil
+}
+
+// GetWebhookDelivery implements storage.Webhooks
+func (s *Sqlite) GetWebhookDelivery(ctx context.Context, id int64) (types.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(s.Db.QueryRowContext(ctx, "SELECT "+webhookDeliveryColumns+
+		" FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id WHERE d.id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.WebhookDelivery{}, storage.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return types.WebhookDelivery{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return d, nil
+}
+
+// GetWebhookDeliveryByPublicID implements storage.Webhooks
+func (s *Sqlite) GetWebhookDeliveryByPublicID(ctx context.Context, publicID string) (types.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(s.Db.QueryRowContext(ctx, "SELECT "+webhookDeliveryColumns+
+		" FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id WHERE d.public_id = ?", publicID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.WebhookDelivery{}, storage.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return types.WebhookDelivery{}, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return d, nil
+}
+
+// ListWebhookDeliveries implements storage.Webhooks
+func (s *Sqlite) ListWebhookDeliveries(ctx context.Context, webhookID, status string, beforeID int64, limit int) ([]types.WebhookDelivery, error) {
+	rows, err := s.Db.QueryContext(ctx, "SELECT "+webhookDeliveryColumns+
+		" FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id WHERE w.public_id = ? AND (? = '' OR d.status = ?) AND (? = 0 OR d.id < ?) ORDER BY d.id DESC LIMIT ?",
+		webhookID, status, status, beforeID, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var deliveries []types.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery implements storage.Webhooks
+func (s *Sqlite) UpdateWebhookDelivery(ctx context.Context, d types.WebhookDelivery) error {
+	n, err := s.exec(ctx, "UPDATE webhook_deliveries SET status = ?, attempts = ?, response_status = ?, response_body = ?, error = ?, updated_at = ? WHERE id = ?",
+		d.Status, d.Attempts, sql.NullInt64{Int64: int64(d.ResponseStatus), Valid: d.ResponseStatus != 0}, nullString(d.ResponseBody), nullString(d.Error),
+		s.Clock.Now().UTC().Format(sqliteTime), d.ID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// DeleteWebhookDelivery implements storage.Webhooks
+func (s *Sqlite) DeleteWebhookDelivery(ctx context.Context, id int64) error {
+	n, err := s.exec(ctx, "DELETE FROM webhook_deliveries WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// scanWebhookDelivery reads a row of webhookDeliveryColumns
+func scanWebhookDelivery(row interface{ Scan(...any) error }) (types.WebhookDelivery, error) {
+	var (
+		d                    types.WebhookDelivery
+		body                 string
+		responseStatus       sql.NullInt64
+		responseBody, errMsg sql.NullString
+		createdAt, updatedAt string
+	)
+	if err := row.Scan(&d.ID, &d.PublicID, &d.WebhookID, &d.Event, &d.EventID, &body, &d.Status, &d.Attempts,
+		&responseStatus, &responseBody, &errMsg, &createdAt, &updatedAt); err != nil {
+		return types.WebhookDelivery{}, err
+	}
+	d.Body = json.RawMessage(body)
+	d.ResponseStatus, d.ResponseBody, d.Error = int(responseStatus.Int64), responseBody.String, errMsg.String
+	var err error
+	if d.CreatedAt, err = time.Parse(sqliteTime, createdAt); err != nil {
+		return types.WebhookDelivery{}, err
+	}
+	d.UpdatedAt, err = time.Parse(sqliteTime, updatedAt)
+	return d, err
+}
+
+func (s *Sqlite) queryWebhooks(ctx context.Context, query string, args ...any) ([]types.Webhook, error) {
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	defer rows.Close()
+
+	var hooks []types.Webhook
+	for rows.Next() {
+		hook, err := s.scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+		}
+		hooks = append(hooks, hook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrDatabase, err)
+	}
+	return hooks, nil
+}
+
+// scanWebhook reads a row of webhookColumns. A previous secret past its expiry is left out, as
+// if it were gone.
+func (s *Sqlite) scanWebhook(row interface{ Scan(...any) error }) (types.Webhook, error) {
+	var (
+		hook              types.Webhook
+		events, createdAt string
+		expires           sql.NullString
+	)
+	if err := row.Scan(&hook.ID, &hook.PublicID, &hook.Owner, &hook.URL, &events, &hook.Secret, &hook.PreviousSecret, &expires, &createdAt); err != nil {
+		return types.Webhook{}, err
+	}
+	hook.Events = strings.Split(events, ",")
+	var err error
+	if hook.CreatedAt, err = time.Parse(sqliteTime, createdAt); err != nil {
+		return types.Webhook{}, err
+	}
+	if expires.Valid && hook.PreviousSecret != "" {
+		t, err := time.Parse(sqliteTime, expires.String)
+		if err != nil {
+			return types.Webhook{}, err
+		}
+		if t.After(s.Clock.Now()) {
+			hook.PreviousSecretExpiresAt = &t
+			return hook, nil
+		}
+	}
+	hook.PreviousSecret = ""
+	return hook, nil
+}
@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/prashantkumbhar2002/go_students_api/internal/types"
 )
@@ -12,14 +14,480 @@ var (
 	ErrDuplicate   = errors.New("student already exists")
 	ErrInvalidData = errors.New("invalid student data")
 	ErrDatabase    = errors.New("database error")
+
+	ErrJobNotFound = errors.New("job not found")
+	// ErrLeaseLost means a worker's claim on a job ran out and the job was claimed again, or
+	// finished, since: the worker's outcome is not recorded
+	ErrLeaseLost = errors.New("job lease lost")
+	// ErrNothingStaged means a job has no staged import rows to preview, confirm or discard
+	ErrNothingStaged = errors.New("no staged import")
+
+	ErrBookNotFound = errors.New("book not found")
+	// ErrOnLoan means a book can't be lent because it hasn't been returned
+	ErrOnLoan = errors.New("book is on loan")
+	// ErrNotOnLoan means a book can't be returned because it isn't lent
+	ErrNotOnLoan = errors.New("book is not on loan")
+
+	ErrHostelNotFound = errors.New("hostel not found")
+	ErrRoomNotFound   = errors.New("room not found")
+	// ErrRoomFull means a room has as many students as beds
+	ErrRoomFull = errors.New("room is full")
+	// ErrHasRoom means a student can't be allocated a room because they have one
+	ErrHasRoom = errors.New("student already has a room")
+
+	ErrRouteNotFound = errors.New("route not found")
+	// ErrStopNotFound means the stop doesn't exist on the route
+	ErrStopNotFound = errors.New("stop not found")
+	// ErrRouteFull means a route has as many riders as seats
+	ErrRouteFull = errors.New("route is full")
+	// ErrHasRoute means a student can't be assigned a route because they ride one
+	ErrHasRoute = errors.New("student already rides a route")
+
+	ErrAlumnusNotFound = errors.New("alumnus not found")
+
+	ErrApplicationNotFound = errors.New("application not found")
+	// ErrApplicationStatus means the application's status doesn't allow the change
+	ErrApplicationStatus = errors.New("application status does not allow this")
+	// ErrConverted means an application has been made into a student already
+	ErrConverted = errors.New("application already converted")
+
+	ErrAnnouncementNotFound = errors.New("announcement not found")
+	ErrDeliveryNotFound     = errors.New("delivery not found")
+
+	ErrCustomFieldNotFound = errors.New("custom field not found")
+	ErrViewNotFound        = errors.New("view not found")
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	// ErrChangesExpired means deletions since the requested time have been purged, so the changes
+	// feed would be incomplete
+	ErrChangesExpired = errors.New("changes since then are no longer kept")
+	// ErrEventsExpired means events after the requested sequence number have been purged, so the
+	// event log would have a gap
+	ErrEventsExpired = errors.New("events since then are no longer kept")
+	// ErrConfirmationRequired means more students match a bulk update than may change without
+	// the caller confirming
+	ErrConfirmationRequired = errors.New("bulk update needs confirmation")
+	// ErrNoJobDue is returned by ClaimJob when no job is ready to run
+	ErrNoJobDue = errors.New("no job due")
 )
 
 type Storage interface {
-	CreateStudent(name string, email string, age int) (int64, error)
+	// CreateStudent inserts student and returns its ID; student.ID is ignored. DateOfBirth
+	// (YYYY-MM-DD) and Phone (E.164) may be empty. PublicID is the student's UUID (see
+	// types.NewPublicID); "" generates one. CustomFields are already validated; nil means none.
+	CreateStudent(student types.Student) (int64, error)
 	GetStudent(id int64) (types.Student, error)
+	// GetStudentByPublicID looks a student up by the UUID clients know it by
+	GetStudentByPublicID(publicID string) (types.Student, error)
 	// GetStudentsList returns paginated list of students
 	// offset: number of records to skip, limit: max number of records to return
 	GetStudentsList(offset, limit int) ([]types.Student, error)
 	// GetStudentsCount returns total count of students in database
 	GetStudentsCount() (int64, error)
+	// StreamStudents calls fn for every student in id order without loading them all into memory.
+	// Iteration stops at the first error returned by fn (which is returned as-is) or when ctx is cancelled.
+	StreamStudents(ctx context.Context, fn func(types.Student) error) error
+	// CreateStudents inserts all students in one transaction (all or nothing) and returns their IDs in input order.
+	// Students without a PublicID get a generated one.
+	// With a WithDryRun context the transaction is rolled back: errors are reported as usual but nothing is stored.
+	CreateStudents(ctx context.Context, students []types.Student) ([]int64, error)
+}
+
+type dryRunKey struct{}
+
+// WithDryRun marks ctx so writes made with it are validated by the storage and then discarded
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether ctx was marked with WithDryRun
+func IsDryRun(ctx context.Context) bool {
+	dry, _ := ctx.Value(dryRunKey{}).(bool)
+	return dry
+}
+
+// As finds the capability T (Resetter, Library, ...) on s or on the storage it decorates.
+// Decorators such as the cache expose the storage they wrap with Unwrap; As unwraps until it finds
+// one that implements T, like errors.As. A decorator that implements T only to forward it to the
+// storage it wraps says so with Forwards, and As returns it only if something beneath really has
+// T, so handlers still see a missing capability (and answer 501) through the decorators.
+func As[T any](s Storage) (T, bool) {
+	var outer T
+	found := false
+	for s != nil {
+		if c, ok := s.(T); ok {
+			if !found {
+				outer, found = c, true
+			}
+			if f, ok := s.(Forwarder); !ok || !f.Forwards((*T)(nil)) {
+				return outer, true
+			}
+		}
+		w, ok := s.(interface{ Unwrap() Storage })
+		if !ok {
+			break
+		}
+		s = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// Forwarder is implemented by decorators whose methods for some capabilities only pass calls on to
+// the storage they wrap. Forwards reports whether that's so for the capability capability points
+// to (a *Resetter, *Alumni, ...).
+type Forwarder interface {
+	Forwards(capability any) bool
+}
+
+// Resetter is implemented by storages that can wipe all data (dev tooling only)
+type Resetter interface {
+	// Reset deletes every row from every data table and restarts ID sequences, keeping the schema
+	Reset(ctx context.Context) error
+}
+
+// Merger is implemented by storages that can fold a duplicate student record into another
+type Merger interface {
+	// MergeStudents soft-deletes mergeID in favour of keepID in one transaction: records pointing at
+	// mergeID are re-pointed to keepID, and fields keepID lacks are copied over. It returns the kept
+	// student. ErrNotFound means either student doesn't exist (or was merged already);
+	// ErrInvalidData means the IDs are equal.
+	MergeStudents(ctx context.Context, keepID, mergeID int64) (types.Student, error)
+}
+
+// Relater is implemented by storages that can link students to each other (siblings, twins)
+type Relater interface {
+	// LinkStudents links two students with one of types.RelationshipKinds. The link is symmetric.
+	// ErrNotFound means either student doesn't exist; ErrInvalidData means the IDs are equal or the
+	// kind is unknown; ErrDuplicate means they are linked already.
+	LinkStudents(ctx context.Context, a, b int64, kind string) error
+	// UnlinkStudents removes the link between two students; ErrNotFound means there is none
+	UnlinkStudents(ctx context.Context, a, b int64) error
+	// StudentRelationships returns the links of each of ids, keyed by ID, ordered by linked student
+	// ID. Students without links are absent from the map.
+	StudentRelationships(ctx context.Context, ids []int64) (map[int64][]types.Relationship, error)
+}
+
+// Library is implemented by storages that keep the school library: books and the loans of books
+// to students
+type Library interface {
+	// AddBook stores a book and returns it as stored. A book without a PublicID gets a generated one.
+	AddBook(ctx context.Context, book types.Book) (types.Book, error)
+	// GetBookByPublicID looks a book up by its UUID; ErrBookNotFound if there is none
+	GetBookByPublicID(ctx context.Context, publicID string) (types.Book, error)
+	// ListBooks returns a page of books in ID order and the total number of books
+	ListBooks(ctx context.Context, offset, limit int) ([]types.Book, int64, error)
+	// CheckOutBook lends a book to a student until due (a types.DateLayout date). ErrBookNotFound or
+	// ErrNotFound means the book or the student doesn't exist; ErrOnLoan means the book is lent already.
+	CheckOutBook(ctx context.Context, bookID, studentID int64, due string) (types.Loan, error)
+	// ReturnBook closes the book's open loan and returns it; ErrNotOnLoan means there is none
+	ReturnBook(ctx context.Context, bookID int64) (types.Loan, error)
+	// OverdueLoans returns the open loans due before asOf's date (in UTC), most overdue first, with
+	// DaysOverdue set
+	OverdueLoans(ctx context.Context, asOf time.Time) ([]types.Loan, error)
+}
+
+// Housing is implemented by storages that keep hostels, their rooms and which students live in them
+type Housing interface {
+	// AddHostel stores a hostel and returns it as stored. A hostel without a PublicID gets a
+	// generated one; ErrDuplicate means another hostel has the name.
+	AddHostel(ctx context.Context, hostel types.Hostel) (types.Hostel, error)
+	// GetHostelByPublicID looks a hostel up by its UUID; ErrHostelNotFound if there is none
+	GetHostelByPublicID(ctx context.Context, publicID string) (types.Hostel, error)
+	// AddRoom stores a room of the hostel. ErrHostelNotFound means the hostel doesn't exist;
+	// ErrDuplicate means it has a room with the number.
+	AddRoom(ctx context.Context, hostelID int64, room types.Room) (types.Room, error)
+	// GetRoomByPublicID looks a room up by its UUID; ErrRoomNotFound if there is none
+	GetRoomByPublicID(ctx context.Context, publicID string) (types.Room, error)
+	// HostelRooms returns the hostel's rooms in number order
+	HostelRooms(ctx context.Context, hostelID int64) ([]types.Room, error)
+	// AllocateRoom gives a student a bed in a room. The capacity check and the allocation are one
+	// transaction, so concurrent allocations can't overfill a room. ErrRoomNotFound or ErrNotFound
+	// means the room or the student doesn't exist; ErrRoomFull and ErrHasRoom say why it can't.
+	AllocateRoom(ctx context.Context, roomID, studentID int64) (types.Allocation, error)
+	// VacateRoom ends a student's allocation to a room; ErrNotFound means there is none
+	VacateRoom(ctx context.Context, roomID, studentID int64) error
+	// RoomAllocations returns the students allocated a room, earliest first
+	RoomAllocations(ctx context.Context, roomID int64) ([]types.Allocation, error)
+	// HostelOccupancy sums up every hostel's rooms, in name order
+	HostelOccupancy(ctx context.Context) ([]types.HostelOccupancy, error)
+}
+
+// Transport is implemented by storages that keep school bus routes, their stops and their riders
+type Transport interface {
+	// AddRoute stores a route with its stops, numbering their positions in order from 1, and
+	// returns it as stored. Missing public IDs are generated; ErrDuplicate means another route has
+	// the name.
+	AddRoute(ctx context.Context, route types.BusRoute) (types.BusRoute, error)
+	// GetRouteByPublicID looks a route up by its UUID, with its stops; ErrRouteNotFound if there is none
+	GetRouteByPublicID(ctx context.Context, publicID string) (types.BusRoute, error)
+	// ListRoutes returns every route with its stops, in name order
+	ListRoutes(ctx context.Context) ([]types.BusRoute, error)
+	// AddStop appends a stop to the end of a route; ErrRouteNotFound means the route doesn't exist
+	AddStop(ctx context.Context, routeID int64, stop types.Stop) (types.Stop, error)
+	// AssignRoute has a student ride a route from one of its stops. The seat check and the
+	// assignment are one transaction, so concurrent assignments can't overfill a bus.
+	// ErrRouteNotFound, ErrStopNotFound or ErrNotFound means the route, the stop on it or the
+	// student doesn't exist; ErrRouteFull and ErrHasRoute say why it can't.
+	AssignRoute(ctx context.Context, routeID, stopID, studentID int64) (types.RouteAssignment, error)
+	// UnassignRoute takes a student off a route; ErrNotFound means they don't ride it
+	UnassignRoute(ctx context.Context, routeID, studentID int64) error
+	// RouteManifest returns a route's riders in pick-up order: by stop position, then name
+	RouteManifest(ctx context.Context, routeID int64) ([]types.RouteAssignment, error)
+}
+
+// Alumni is implemented by storages that keep graduated students apart from the active ones
+type Alumni interface {
+	// GraduateStudent makes a student an alumnus of classOf as of graduatedOn (a types.DateLayout
+	// date) in one transaction. The student leaves every student read, and their room and bus seat
+	// are released; loans stay open until the books come back. ErrNotFound means the student
+	// doesn't exist or has graduated already.
+	GraduateStudent(ctx context.Context, studentID int64, classOf int, graduatedOn string) (types.Alumnus, error)
+	// GetAlumnusByPublicID looks an alumnus up by their student UUID; ErrAlumnusNotFound if there is none
+	GetAlumnusByPublicID(ctx context.Context, publicID string) (types.Alumnus, error)
+	// ListAlumni returns a page of the alumni matching f, latest class first and then by name, and
+	// how many match in total
+	ListAlumni(ctx context.Context, f types.AlumniFilter, offset, limit int) ([]types.Alumnus, int64, error)
+}
+
+// Admissions is implemented by storages that keep applications for admission
+type Admissions interface {
+	// SubmitApplication stores an application as types.ApplicationSubmitted and returns it as
+	// stored. An application without a PublicID gets a generated one.
+	SubmitApplication(ctx context.Context, app types.Application) (types.Application, error)
+	// GetApplicationByPublicID looks an application up by its UUID; ErrApplicationNotFound if there is none
+	GetApplicationByPublicID(ctx context.Context, publicID string) (types.Application, error)
+	// ListApplications returns a page of the applications with status ("" for every status),
+	// oldest first, and how many there are in total
+	ListApplications(ctx context.Context, status string, offset, limit int) ([]types.Application, int64, error)
+	// SetApplicationStatus moves an application to status. ErrApplicationStatus means
+	// types.ApplicationTransitions doesn't allow the move from its current status.
+	SetApplicationStatus(ctx context.Context, id int64, status string) (types.Application, error)
+	// ConvertApplication creates student from an accepted application and records it on the
+	// application, in one transaction. It returns both as stored. ErrApplicationStatus means the
+	// application isn't accepted; ErrConverted means it was converted already.
+	ConvertApplication(ctx context.Context, id int64, student types.Student) (types.Application, types.Student, error)
+}
+
+// Announcements is implemented by storages that keep announcements and their deliveries
+type Announcements interface {
+	// PostAnnouncement stores a and queues a delivery to every student matching f (every student
+	// if f is nil), in one transaction. It returns a as stored, with its recipients counted. An
+	// announcement without a PublicID gets a generated one.
+	PostAnnouncement(ctx context.Context, a types.Announcement, f *types.Filter) (types.Announcement, error)
+	// GetAnnouncementByPublicID looks an announcement up by its UUID; ErrAnnouncementNotFound if there is none
+	GetAnnouncementByPublicID(ctx context.Context, publicID string) (types.Announcement, error)
+	// PendingDeliveries returns the deliveries of an announcement still types.DeliveryQueued, in student id order
+	PendingDeliveries(ctx context.Context, announcementID int64) ([]types.Delivery, error)
+	// SetDeliveryStatus records how a delivery went: its Status, the MessageID of a text message,
+	// and the Error of a failed one
+	SetDeliveryStatus(ctx context.Context, d types.Delivery) error
+	// ReportDelivery records the outcome the SMS provider reported for a text message still
+	// types.DeliverySent; a repeated or late report changes nothing. ErrDeliveryNotFound if no
+	// delivery has the message ID.
+	ReportDelivery(ctx context.Context, messageID, status, detail string) error
+	// StudentAnnouncements returns the announcements sent to a student, newest first
+	StudentAnnouncements(ctx context.Context, studentID int64) ([]types.StudentAnnouncement, error)
+}
+
+// CustomFields is implemented by storages that keep admin-defined custom fields on students. The
+// values themselves are written with the student (types.Student.CustomFields).
+type CustomFields interface {
+	// DefineCustomField adds a custom field and returns it as stored; ErrDuplicate means a field
+	// has the name already
+	DefineCustomField(ctx context.Context, field types.CustomField) (types.CustomField, error)
+	// ListCustomFields returns every custom field, in name order
+	ListCustomFields(ctx context.Context) ([]types.CustomField, error)
+	// DeleteCustomField removes a custom field and every student's value for it;
+	// ErrCustomFieldNotFound if there is none with the name
+	DeleteCustomField(ctx context.Context, name string) error
+}
+
+// Updater is implemented by storages that can change a student in place
+type Updater interface {
+	// UpdateStudent replaces the writable fields (name, email, age, date of birth, phone and custom
+	// fields) of the student with student.ID, and returns it as stored together with the fields that
+	// changed (see types.ChangedFields). The old row is read and compared in the same transaction as
+	// the write. ErrNotFound if there is no such student.
+	// With a WithDryRun context the transaction is rolled back: the result is what would have been
+	// stored, but nothing is.
+	UpdateStudent(ctx context.Context, student types.Student) (types.Student, []string, error)
+}
+
+// Views is implemented by storages that keep saved views of the student list
+type Views interface {
+	// SaveView stores a view and returns it as stored, with its creation time; ErrDuplicate means
+	// a view has the name already
+	SaveView(ctx context.Context, view types.View) (types.View, error)
+	// GetView looks a view up by name; ErrViewNotFound if there is none
+	GetView(ctx context.Context, name string) (types.View, error)
+	// ListViews returns every view, in name order
+	ListViews(ctx context.Context) ([]types.View, error)
+	// DeleteView removes a view; ErrViewNotFound if there is none with the name
+	DeleteView(ctx context.Context, name string) error
+}
+
+// Webhooks is implemented by storages that keep webhook subscriptions
+type Webhooks interface {
+	// CreateWebhook stores a subscription and returns it as stored, with a public ID (a new UUID
+	// unless hook has one) and its creation time
+	CreateWebhook(ctx context.Context, hook types.Webhook) (types.Webhook, error)
+	// GetWebhook looks a subscription up by public ID; ErrWebhookNotFound if there is none
+	GetWebhook(ctx context.Context, publicID string) (types.Webhook, error)
+	// ListWebhooks returns owner's subscriptions, oldest first
+	ListWebhooks(ctx context.Context, owner string) ([]types.Webhook, error)
+	// WebhooksFor returns every subscription to the event kind, oldest first
+	WebhooksFor(ctx context.Context, event string) ([]types.Webhook, error)
+	// RotateWebhookSecret replaces a subscription's secret with secret, keeping the current one as
+	// its previous secret until expires, and returns it as stored; ErrWebhookNotFound if there is none
+	RotateWebhookSecret(ctx context.Context, publicID, secret string, expires time.Time) (types.Webhook, error)
+	// DeleteWebhook removes a subscription and its deliveries; ErrWebhookNotFound if there is none
+	DeleteWebhook(ctx context.Context, publicID string) error
+
+	// CreateWebhookDelivery stores a queued delivery to the subscription d.WebhookID and returns
+	// it as stored; ErrWebhookNotFound if there is no such subscription
+	CreateWebhookDelivery(ctx context.Context, d types.WebhookDelivery) (types.WebhookDelivery, error)
+	// GetWebhookDelivery looks a delivery up; ErrDeliveryNotFound if there is none
+	GetWebhookDelivery(ctx context.Context, id int64) (types.WebhookDelivery, error)
+	// GetWebhookDeliveryByPublicID looks a delivery up by its UUID; ErrDeliveryNotFound if there is none
+	GetWebhookDeliveryByPublicID(ctx context.Context, publicID string) (types.WebhookDelivery, error)
+	// ListWebhookDeliveries returns up to limit of a subscription's deliveries, newest first, with
+	// status or any status when it is "". A beforeID other than 0 skips the deliveries from it on,
+	// so passing the last ID of one page reads the next.
+	ListWebhookDeliveries(ctx context.Context, webhookID, status string, beforeID int64, limit int) ([]types.WebhookDelivery, error)
+	// UpdateWebhookDelivery stores d's status, attempts, response and error; ErrDeliveryNotFound
+	// if there is no such delivery
+	UpdateWebhookDelivery(ctx context.Context, d types.WebhookDelivery) error
+	// DeleteWebhookDelivery forgets a delivery; ErrDeliveryNotFound if there is none
+	DeleteWebhookDelivery(ctx context.Context, id int64) error
+}
+
+// BulkUpdater is implemented by storages that can change many students in one transaction
+type BulkUpdater interface {
+	// PatchStudents applies customFields as a JSON merge patch (RFC 7396) to the custom fields of
+	// every student matching f, or every student when f is nil: a nil value removes the field.
+	// The students are counted and updated in one transaction; when more than max match (a
+	// negative max has no limit) nothing changes, and ErrConfirmationRequired comes with the count.
+	PatchStudents(ctx context.Context, f *types.Filter, customFields map[string]any, max int) (types.BulkUpdate, error)
+}
+
+// ImportCheckpointer is implemented by storages that can commit an import job's students together
+// with its progress, so a job that crashes or is stopped resumes after its last committed batch
+// instead of starting over
+type ImportCheckpointer interface {
+	// ImportProgress returns the progress job jobID last committed; the zero value before its
+	// first batch
+	ImportProgress(ctx context.Context, jobID int64) (types.ImportProgress, error)
+	// ImportBatch inserts students in one transaction, skipping those whose email (ignoring
+	// case) a live student or an earlier student of the batch already has, and records in the
+	// same transaction that the job has got to data row row. It returns the job's progress so
+	// far and the students it inserted, with their IDs.
+	ImportBatch(ctx context.Context, jobID int64, students []types.Student, row int) (types.ImportProgress, []types.Student, error)
+}
+
+// ImportStager is implemented by storages that can hold an import's rows in a staging table, so
+// the import can be previewed before anything reaches the students table and committed later
+// with ImportCheckpointer. Staged rows are keyed by the import job that staged them.
+type ImportStager interface {
+	// StageImport adds rows to job jobID's staged import
+	StageImport(ctx context.Context, jobID int64, rows []types.StagedStudent) error
+	// PreviewImport compares job jobID's staged rows with each other and with the live students
+	// by email (ignoring case), listing up to limit rows of each outcome; ErrNothingStaged if
+	// there are none
+	PreviewImport(ctx context.Context, jobID int64, limit int) (types.ImportPreview, error)
+	// StagedRows returns up to limit of job jobID's staged rows after data row afterRow, in row
+	// order
+	StagedRows(ctx context.Context, jobID int64, afterRow, limit int) ([]types.StagedStudent, error)
+	// DiscardImport deletes job jobID's staged rows; ErrNothingStaged if there are none
+	DiscardImport(ctx context.Context, jobID int64) error
+}
+
+// Changes is implemented by storages that record when each student last changed, for clients
+// that sync incrementally
+type Changes interface {
+	// StudentChanges returns up to limit students created, updated or deleted at or after since,
+	// each once with its latest change, in (changed at, public ID) order. Changes at exactly since
+	// are skipped up to and including public ID after, so a client can resume from the last
+	// entry it saw. Times are kept to the second, and changes from the current one are left out
+	// until it is over: a cursor inside it would skip a later write there with a lower public ID.
+	// Deletions are only kept for a while (see retention.TargetTombstones); ErrChangesExpired
+	// means some from after since are gone.
+	StudentChanges(ctx context.Context, since time.Time, after string, limit int) ([]types.StudentChange, error)
+}
+
+// EventLog is implemented by storages that keep the events published on the bus, numbered per
+// entity, for consumers that must not miss one
+type EventLog interface {
+	// AppendEvent stores e as the next of e.Entity's events and returns it with its Seq and
+	// CreatedAt
+	AppendEvent(ctx context.Context, e types.FeedEvent) (types.FeedEvent, error)
+	// EventsAfter returns up to limit of entity's events numbered after afterSeq, oldest first,
+	// and the last Seq the entity's events have reached (0 before the first). Events are only
+	// kept for a while (see retention.TargetEvents); ErrEventsExpired, with the last Seq, means
+	// some after afterSeq are gone.
+	EventsAfter(ctx context.Context, entity string, afterSeq int64, limit int) ([]types.FeedEvent, int64, error)
+}
+
+// Transactor is implemented by storages that can make several writes one. events.Store uses it
+// as an outbox: a write and the events reporting it are appended to the EventLog together.
+type Transactor interface {
+	// InTx calls fn with a context in which the storage's writes and AppendEvent join one
+	// transaction, committed if fn returns nil and rolled back otherwise. Dry runs roll back
+	// their own transactions, so with a WithDryRun context fn runs without one.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// UsageCounter is implemented by storages that count each client's requests per calendar month,
+// for monthly quotas (see internal/quota)
+type UsageCounter interface {
+	// CountRequest counts a request of client in month ("2026-10") unless client has already
+	// made limit, and returns client's count for the month and whether this one was counted
+	CountRequest(ctx context.Context, client, month string, limit int64) (int64, bool, error)
+	// ListUsage returns every client's count for month, most requests first
+	ListUsage(ctx context.Context, month string) ([]types.Usage, error)
+}
+
+// Reporter computes aggregates for dashboards with GROUP BY queries instead of loading every student
+type Reporter interface {
+	// StudentStats counts students overall, by age bucket and status (ages as of now),
+	// and by creation month for the last months months including now's month
+	StudentStats(ctx context.Context, now time.Time, months int) (types.StudentStats, error)
+	// Report runs an allowlisted grouping query (see internal/reports); ages are as of now
+	Report(ctx context.Context, q types.ReportQuery, now time.Time) (types.Report, error)
+}
+
+// Filterer lists students matching a filter expression (see internal/filter)
+type Filterer interface {
+	// FilterStudents returns one page of the students matching f, in id order, and how many match in total
+	FilterStudents(ctx context.Context, f types.Filter, offset, limit int) ([]types.Student, int64, error)
+}
+
+// CachedCounter is implemented by storages that may answer GetStudentsCount from a cache
+type CachedCounter interface {
+	// CachedStudentsCount is GetStudentsCount plus when the count was taken if it came from a
+	// cache; asOf is the zero time for a count taken just now
+	CachedStudentsCount() (count int64, asOf time.Time, err error)
+}
+
+// Searcher finds students matching free text, best matches first
+type Searcher interface {
+	SearchStudents(ctx context.Context, query string, limit int) ([]types.SearchHit, error)
+}
+
+// JobQueue persists background jobs so they survive restarts (see internal/jobs for the worker loop)
+type JobQueue interface {
+	// EnqueueJob stores a queued job that becomes due at runAt
+	EnqueueJob(ctx context.Context, kind string, payload []byte, maxAttempts int, runAt time.Time) (int64, error)
+	GetJob(ctx context.Context, id int64) (types.Job, error)
+	// ClaimJob atomically marks the oldest due job as running, leased until leaseUntil, and counts
+	// the attempt. Running jobs whose lease expired (their worker died) are due again.
+	// It returns ErrNoJobDue when there is nothing to do.
+	ClaimJob(ctx context.Context, now, leaseUntil time.Time) (types.Job, error)
+	// CompleteJob records that attempt of the job succeeded with result. Like RetryJob and KillJob,
+	// it only applies while the job is running the attempt ClaimJob counted; once the job has been
+	// claimed again or finished, ErrLeaseLost.
+	CompleteJob(ctx context.Context, id int64, attempt int, result []byte, now time.Time) error
+	// RetryJob records a failed attempt and requeues the job to run at runAt
+	RetryJob(ctx context.Context, id int64, attempt int, errMsg string, runAt, now time.Time) error
+	// KillJob records a failed attempt and moves the job to the dead-letter state
+	KillJob(ctx context.Context, id int64, attempt int, errMsg string, now time.Time) error
 }
@@ -0,0 +1,1881 @@
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/filter"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Factory returns a new, empty Storage for one subtest. Register any cleanup with t.Cleanup.
+type Factory func(t *testing.T) storage.Storage
+
+// TestStorage runs the conformance suite against the backend produced by factory.
+// Every storage.Storage implementation should call it from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		storagetest.TestStorage(t, func(t *testing.T) storage.Storage { ... })
+//	}
+func TestStorage(t *testing.T, factory Factory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s storage.Storage)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"GetNotFound", testGetNotFound},
+		{"PublicIDsGenerated", testPublicIDsGenerated},
+		{"DateOfBirthDerivesAge", testDateOfBirthDerivesAge},
+		{"ListPagination", testListPagination},
+		{"Count", testCount},
+		{"CreateStudentsBulk", testCreateStudentsBulk},
+		{"CreateStudentsDryRun", testCreateStudentsDryRun},
+		{"StreamStudents", testStreamStudents},
+		{"StreamStopsOnCallbackError", testStreamStopsOnCallbackError},
+		{"MergeStudents", testMergeStudents},
+		{"FilterStudents", testFilterStudents},
+		{"StudentRelationships", testStudentRelationships},
+		{"Library", testLibrary},
+		{"Housing", testHousing},
+		{"Transport", testTransport},
+		{"Alumni", testAlumni},
+		{"Applications", testApplications},
+		{"Announcements", testAnnouncements},
+		{"CustomFields", testCustomFields},
+		{"Views", testViews},
+		{"UpdateStudent", testUpdateStudent},
+		{"StudentChanges", testStudentChanges},
+		{"PatchStudents", testPatchStudents},
+		{"Webhooks", testWebhooks},
+		{"WebhookDeliveries", testWebhookDeliveries},
+		{"EventLog", testEventLog},
+		{"UsageCounter", testUsageCounter},
+		{"ImportBatch", testImportBatch},
+		{"ImportStaging", testImportStaging},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, factory(t))
+		})
+	}
+}
+
+func testCreateAndGet(t *testing.T, s storage.Storage) {
+	const publicID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	id, err := s.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 21, Phone: "+919876543210", PublicID: publicID})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	if id <= 0 {
+		t.Fatalf("CreateStudent returned id %d, want > 0", id)
+	}
+
+	got, err := s.GetStudent(id)
+	if err != nil {
+		t.Fatalf("GetStudent(%d): %v", id, err)
+	}
+	want := types.Student{ID: id, PublicID: publicID, Name: "Asha", Email: "asha@example.com", Age: 21, Phone: "+919876543210"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetStudent(%d) = %+v, want %+v", id, got, want)
+	}
+
+	got, err = s.GetStudentByPublicID(publicID)
+	if err != nil {
+		t.Fatalf("GetStudentByPublicID(%s): %v", publicID, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetStudentByPublicID(%s) = %+v, want %+v", publicID, got, want)
+	}
+}
+
+func testPublicIDsGenerated(t *testing.T, s storage.Storage) {
+	id, err := s.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 21})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	ids, err := s.CreateStudents(context.Background(), []types.Student{
+		{Name: "Ravi", Email: "ravi@example.com", Age: 22},
+		{Name: "Chen", Email: "chen@example.com", Age: 23},
+	})
+	if err != nil {
+		t.Fatalf("CreateStudents: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range append([]int64{id}, ids...) {
+		st, err := s.GetStudent(id)
+		if err != nil {
+			t.Fatalf("GetStudent(%d): %v", id, err)
+		}
+		if !types.ValidPublicID(st.PublicID) || seen[st.PublicID] {
+			t.Errorf("student %d has public ID %q, want a new UUID", id, st.PublicID)
+		}
+		seen[st.PublicID] = true
+	}
+
+	if _, err := s.GetStudentByPublicID(types.NewPublicID()); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetStudentByPublicID(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func testGetNotFound(t *testing.T, s storage.Storage) {
+	_, err := s.GetStudent(987654)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetStudent(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func testDateOfBirthDerivesAge(t *testing.T, s storage.Storage) {
+	dob := time.Now().AddDate(-30, 0, -1).Format(types.DateLayout)
+	// The stored age is deliberately wrong: reads must derive it from the date of birth
+	id, err := s.CreateStudent(types.Student{Name: "Ravi", Email: "ravi@example.com", Age: 99, DateOfBirth: dob})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+
+	got, err := s.GetStudent(id)
+	if err != nil {
+		t.Fatalf("GetStudent: %v", err)
+	}
+	if got.Age != 30 || got.DateOfBirth != dob {
+		t.Errorf("GetStudent = age %d dob %q, want age 30 dob %q", got.Age, got.DateOfBirth, dob)
+	}
+}
+
+func testListPagination(t *testing.T, s storage.Storage) {
+	ids := createN(t, s, 5)
+
+	page, err := s.GetStudentsList(1, 2)
+	if err != nil {
+		t.Fatalf("GetStudentsList: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != ids[1] || page[1].ID != ids[2] {
+		t.Errorf("GetStudentsList(1, 2) = %v, want ids %v", studentIDs(page), ids[1:3])
+	}
+
+	past, err := s.GetStudentsList(10, 2)
+	if err != nil {
+		t.Fatalf("GetStudentsList past end: %v", err)
+	}
+	if len(past) != 0 {
+		t.Errorf("GetStudentsList(10, 2) returned %d students, want 0", len(past))
+	}
+}
+
+func testCount(t *testing.T, s storage.Storage) {
+	count, err := s.GetStudentsCount()
+	if err != nil {
+		t.Fatalf("GetStudentsCount: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("GetStudentsCount on empty storage = %d, want 0", count)
+	}
+
+	createN(t, s, 3)
+	count, err = s.GetStudentsCount()
+	if err != nil {
+		t.Fatalf("GetStudentsCount: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("GetStudentsCount = %d, want 3", count)
+	}
+}
+
+func testCreateStudentsBulk(t *testing.T, s storage.Storage) {
+	in := make([]types.Student, 250)
+	for i := range in {
+		in[i] = types.Student{Name: fmt.Sprintf("Bulk %d", i), Email: fmt.Sprintf("bulk%d@example.com", i), Age: 20}
+	}
+
+	ids, err := s.CreateStudents(context.Background(), in)
+	if err != nil {
+		t.Fatalf("CreateStudents: %v", err)
+	}
+	if len(ids) != len(in) {
+		t.Fatalf("CreateStudents returned %d ids, want %d", len(ids), len(in))
+	}
+
+	for i, id := range ids {
+		got, err := s.GetStudent(id)
+		if err != nil {
+			t.Fatalf("GetStudent(%d): %v", id, err)
+		}
+		if got.Name != in[i].Name {
+			t.Fatalf("id %d has name %q, want %q (ids must be in input order)", id, got.Name, in[i].Name)
+		}
+	}
+}
+
+func testCreateStudentsDryRun(t *testing.T, s storage.Storage) {
+	in := []types.Student{{Name: "Dry", Email: "dry@example.com", Age: 20}}
+
+	ids, err := s.CreateStudents(storage.WithDryRun(context.Background()), in)
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("CreateStudents (dry run) = %v, %v; want one id", ids, err)
+	}
+	if count, _ := s.GetStudentsCount(); count != 0 {
+		t.Fatalf("GetStudentsCount after dry run = %d, want 0", count)
+	}
+
+	// The ID a dry run reports is the one a real insert gets next
+	real, err := s.CreateStudents(context.Background(), in)
+	if err != nil || real[0] != ids[0] {
+		t.Fatalf("CreateStudents = %v, %v; want id %d", real, err, ids[0])
+	}
+}
+
+func testStreamStudents(t *testing.T, s storage.Storage) {
+	ids := createN(t, s, 4)
+
+	var seen []int64
+	err := s.StreamStudents(context.Background(), func(st types.Student) error {
+		seen = append(seen, st.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamStudents: %v", err)
+	}
+	if fmt.Sprint(seen) != fmt.Sprint(ids) {
+		t.Errorf("StreamStudents visited %v, want %v", seen, ids)
+	}
+}
+
+func testStreamStopsOnCallbackError(t *testing.T, s storage.Storage) {
+	createN(t, s, 4)
+	stop := errors.New("stop")
+
+	calls := 0
+	err := s.StreamStudents(context.Background(), func(types.Student) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("StreamStudents error = %v, want the callback's error", err)
+	}
+	if calls != 1 {
+		t.Errorf("callback called %d times after returning an error, want 1", calls)
+	}
+}
+
+func testMergeStudents(t *testing.T, s storage.Storage) {
+	m, ok := storage.As[storage.Merger](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Merger")
+	}
+	ctx := context.Background()
+	keep, _ := s.CreateStudent(types.Student{Name: "Asha Patil", Email: "asha@example.com", Age: 20})
+	dup, _ := s.CreateStudent(types.Student{Name: "Asha Patil", Email: "Asha@Example.com", Age: 20, DateOfBirth: "2005-01-02", Phone: "+919876543210"})
+	other := createN(t, s, 1)[0]
+
+	got, err := m.MergeStudents(ctx, keep, dup)
+	if err != nil {
+		t.Fatalf("MergeStudents: %v", err)
+	}
+	if got.ID != keep || got.DateOfBirth != "2005-01-02" || got.Phone != "+919876543210" {
+		t.Errorf("MergeStudents = %+v, want student %d with the duplicate's date of birth and phone", got, keep)
+	}
+
+	// The duplicate is gone from every read
+	if _, err := s.GetStudent(dup); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetStudent(merged) error = %v, want ErrNotFound", err)
+	}
+	if count, _ := s.GetStudentsCount(); count != 2 {
+		t.Errorf("GetStudentsCount = %d, want 2", count)
+	}
+	list, _ := s.GetStudentsList(0, 10)
+	if len(list) != 2 || list[0].ID != keep || list[1].ID != other {
+		t.Errorf("GetStudentsList = %+v, want students %d and %d", list, keep, other)
+	}
+
+	if _, err := m.MergeStudents(ctx, keep, dup); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("merging an already merged student: error = %v, want ErrNotFound", err)
+	}
+	if _, err := m.MergeStudents(ctx, keep, keep); !errors.Is(err, storage.ErrInvalidData) {
+		t.Errorf("merging a student into itself: error = %v, want ErrInvalidData", err)
+	}
+}
+
+func testFilterStudents(t *testing.T, s storage.Storage) {
+	fs, ok := storage.As[storage.Filterer](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Filterer")
+	}
+	ctx := context.Background()
+	dob := time.Now().AddDate(-17, 0, -1).Format(types.DateLayout)
+	asha, _ := s.CreateStudent(types.Student{Name: "Asha Kumbhar", Email: "asha@example.com", Age: 21, Phone: "+919876543210"})
+	ravi, _ := s.CreateStudent(types.Student{Name: "Ravi", Email: "ravi@school.in", Age: 40, DateOfBirth: dob})
+	meera, _ := s.CreateStudent(types.Student{Name: "Meera KUMAR", Email: "meera@example.com", Age: 18})
+
+	tests := []struct {
+		expr string
+		want []int64
+	}{
+		{`age >= 18 AND name ~ "kum"`, []int64{asha, meera}},
+		{`age < 18`, []int64{ravi}},
+		{`phone = ""`, []int64{ravi, meera}},
+		{`NOT email ~ "@EXAMPLE.com"`, []int64{ravi}},
+		{`date_of_birth = ""`, []int64{asha, meera}},
+		{`date_of_birth > "2000-01-01"`, []int64{ravi}},
+		{`name = "Ravi" OR age = 21 AND phone ~ "+91"`, []int64{asha, ravi}},
+		{`(name = "Ravi" OR age = 21) AND phone != ""`, []int64{asha}},
+		{`name = "nobody"`, nil},
+	}
+	for _, tc := range tests {
+		f, err := filter.Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", tc.expr, err)
+		}
+		got, total, err := fs.FilterStudents(ctx, f, 0, 10)
+		if err != nil {
+			t.Fatalf("FilterStudents(%s): %v", tc.expr, err)
+		}
+		if ids := studentIDs(got); fmt.Sprint(ids) != fmt.Sprint(tc.want) || total != int64(len(tc.want)) {
+			t.Errorf("FilterStudents(%s) = %v (total %d), want %v", tc.expr, ids, total, tc.want)
+		}
+	}
+
+	// The total counts every match, not just the page
+	f, _ := filter.Parse(`email ~ "example"`)
+	page, total, err := fs.FilterStudents(ctx, f, 1, 1)
+	if err != nil {
+		t.Fatalf("FilterStudents page: %v", err)
+	}
+	if len(page) != 1 || page[0].ID != meera || total != 2 {
+		t.Errorf("FilterStudents(offset 1, limit 1) = %v (total %d), want [%d] (total 2)", studentIDs(page), total, meera)
+	}
+}
+
+func createN(t *testing.T, s storage.Storage, n int) []int64 {
+	t.Helper()
+	ids := make([]int64, n)
+	for i := range ids {
+		id, err := s.CreateStudent(types.Student{Name: fmt.Sprintf("Student %d", i), Email: fmt.Sprintf("s%d@example.com", i), Age: 20 + i})
+		if err != nil {
+			t.Fatalf("CreateStudent: %v", err)
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+func studentIDs(students []types.Student) []int64 {
+	ids := make([]int64, len(students))
+	for i, st := range students {
+		ids[i] = st.ID
+	}
+	return ids
+}
+
+func testStudentRelationships(t *testing.T, s storage.Storage) {
+	rl, ok := storage.As[storage.Relater](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Relater")
+	}
+	ctx := context.Background()
+	ids := createN(t, s, 4)
+	a, b, c, d := ids[0], ids[1], ids[2], ids[3]
+
+	if err := rl.LinkStudents(ctx, b, a, types.RelationshipTwin); err != nil {
+		t.Fatalf("LinkStudents: %v", err)
+	}
+	if err := rl.LinkStudents(ctx, a, c, types.RelationshipSibling); err != nil {
+		t.Fatalf("LinkStudents: %v", err)
+	}
+	if err := rl.LinkStudents(ctx, a, b, types.RelationshipSibling); !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("linking linked students: error = %v, want ErrDuplicate", err)
+	}
+	if err := rl.LinkStudents(ctx, a, a, types.RelationshipSibling); !errors.Is(err, storage.ErrInvalidData) {
+		t.Errorf("linking a student to itself: error = %v, want ErrInvalidData", err)
+	}
+	if err := rl.LinkStudents(ctx, a, d, "cousin"); !errors.Is(err, storage.ErrInvalidData) {
+		t.Errorf("linking with an unknown kind: error = %v, want ErrInvalidData", err)
+	}
+	if err := rl.LinkStudents(ctx, a, 999999, types.RelationshipSibling); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("linking a missing student: error = %v, want ErrNotFound", err)
+	}
+
+	// Links read the same from either side
+	links, err := rl.StudentRelationships(ctx, []int64{a, b, d})
+	if err != nil {
+		t.Fatalf("StudentRelationships: %v", err)
+	}
+	if got := relationshipIDs(links[a]); got != fmt.Sprintf("[%d:twin %d:sibling]", b, c) {
+		t.Errorf("relationships of %d = %s", a, got)
+	}
+	if got := relationshipIDs(links[b]); got != fmt.Sprintf("[%d:twin]", a) {
+		t.Errorf("relationships of %d = %s", b, got)
+	}
+	if len(links[b]) == 1 && links[b][0].PublicID == "" {
+		t.Errorf("relationship %+v has no public ID", links[b][0])
+	}
+	if _, ok := links[d]; ok {
+		t.Errorf("unlinked student %d has relationships %+v", d, links[d])
+	}
+
+	// Merging c into d moves c's link to d
+	if m, ok := storage.As[storage.Merger](s); ok {
+		if _, err := m.MergeStudents(ctx, d, c); err != nil {
+			t.Fatalf("MergeStudents: %v", err)
+		}
+		links, _ = rl.StudentRelationships(ctx, []int64{d})
+		if got := relationshipIDs(links[d]); got != fmt.Sprintf("[%d:sibling]", a) {
+			t.Errorf("relationships of %d after merge = %s", d, got)
+		}
+	}
+
+	if err := rl.UnlinkStudents(ctx, a, b); err != nil {
+		t.Fatalf("UnlinkStudents: %v", err)
+	}
+	if err := rl.UnlinkStudents(ctx, b, a); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("unlinking unlinked students: error = %v, want ErrNotFound", err)
+	}
+}
+
+// relationshipIDs renders relationships as [id:kind ...] for comparison
+func relationshipIDs(rels []types.Relationship) string {
+	out := make([]string, len(rels))
+	for i, rel := range rels {
+		out[i] = fmt.Sprintf("%d:%s", rel.StudentID, rel.Kind)
+	}
+	return fmt.Sprint(out)
+}
+
+func testLibrary(t *testing.T, s storage.Storage) {
+	lib, ok := storage.As[storage.Library](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Library")
+	}
+	ctx := context.Background()
+	students := createN(t, s, 2)
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1).UTC().Format(types.DateLayout)
+	nextWeek := now.AddDate(0, 0, 7).UTC().Format(types.DateLayout)
+
+	var books []types.Book
+	for _, title := range []string{"Panchatantra", "Malgudi Days", "Wings of Fire"} {
+		book, err := lib.AddBook(ctx, types.Book{Title: title, Author: "Various"})
+		if err != nil {
+			t.Fatalf("AddBook: %v", err)
+		}
+		if !types.ValidPublicID(book.PublicID) || book.ID <= 0 {
+			t.Fatalf("AddBook = %+v, want an ID and a public ID", book)
+		}
+		books = append(books, book)
+	}
+
+	loan, err := lib.CheckOutBook(ctx, books[0].ID, students[0], yesterday)
+	if err != nil {
+		t.Fatalf("CheckOutBook: %v", err)
+	}
+	if loan.BookPublicID != books[0].PublicID || loan.Title != "Panchatantra" || loan.StudentID != students[0] ||
+		loan.DueDate != yesterday || loan.CheckedOutAt.IsZero() || loan.ReturnedAt != nil {
+		t.Errorf("CheckOutBook = %+v", loan)
+	}
+	if _, err := lib.CheckOutBook(ctx, books[1].ID, students[1], nextWeek); err != nil {
+		t.Fatalf("CheckOutBook: %v", err)
+	}
+	if _, err := lib.CheckOutBook(ctx, books[0].ID, students[1], nextWeek); !errors.Is(err, storage.ErrOnLoan) {
+		t.Errorf("checking out a lent book: error = %v, want ErrOnLoan", err)
+	}
+	if _, err := lib.CheckOutBook(ctx, 999999, students[1], nextWeek); !errors.Is(err, storage.ErrBookNotFound) {
+		t.Errorf("checking out a missing book: error = %v, want ErrBookNotFound", err)
+	}
+	if _, err := lib.CheckOutBook(ctx, books[2].ID, 999999, nextWeek); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("checking out to a missing student: error = %v, want ErrNotFound", err)
+	}
+
+	page, total, err := lib.ListBooks(ctx, 1, 5)
+	if err != nil {
+		t.Fatalf("ListBooks: %v", err)
+	}
+	if total != 3 || len(page) != 2 || page[0].ID != books[1].ID || !page[0].OnLoan || page[1].OnLoan {
+		t.Errorf("ListBooks(1, 5) = %+v (total %d)", page, total)
+	}
+	got, err := lib.GetBookByPublicID(ctx, books[0].PublicID)
+	if err != nil || !got.OnLoan || got.Title != "Panchatantra" {
+		t.Errorf("GetBookByPublicID = %+v, %v", got, err)
+	}
+	if _, err := lib.GetBookByPublicID(ctx, types.NewPublicID()); !errors.Is(err, storage.ErrBookNotFound) {
+		t.Errorf("GetBookByPublicID(unknown) error = %v, want ErrBookNotFound", err)
+	}
+
+	// Only the loan due yesterday is overdue today; tomorrow it is two days overdue
+	overdue, err := lib.OverdueLoans(ctx, now.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("OverdueLoans: %v", err)
+	}
+	if len(overdue) != 1 || overdue[0].BookID != books[0].ID || overdue[0].DaysOverdue != 2 || overdue[0].StudentEmail == "" {
+		t.Errorf("OverdueLoans = %+v, want book %d two days overdue", overdue, books[0].ID)
+	}
+
+	returned, err := lib.ReturnBook(ctx, books[0].ID)
+	if err != nil {
+		t.Fatalf("ReturnBook: %v", err)
+	}
+	if returned.ReturnedAt == nil || returned.DueDate != yesterday {
+		t.Errorf("ReturnBook = %+v, want the loan with returned_at set", returned)
+	}
+	if _, err := lib.ReturnBook(ctx, books[0].ID); !errors.Is(err, storage.ErrNotOnLoan) {
+		t.Errorf("returning a returned book: error = %v, want ErrNotOnLoan", err)
+	}
+	if overdue, _ := lib.OverdueLoans(ctx, now); len(overdue) != 0 {
+		t.Errorf("OverdueLoans after return = %+v, want none", overdue)
+	}
+	// A returned book can be lent again
+	if _, err := lib.CheckOutBook(ctx, books[0].ID, students[1], nextWeek); err != nil {
+		t.Errorf("checking out a returned book: %v", err)
+	}
+}
+
+func testHousing(t *testing.T, s storage.Storage) {
+	h, ok := storage.As[storage.Housing](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Housing")
+	}
+	ctx := context.Background()
+	students := createN(t, s, 8)
+
+	hostel, err := h.AddHostel(ctx, types.Hostel{Name: "Narmada"})
+	if err != nil || !types.ValidPublicID(hostel.PublicID) {
+		t.Fatalf("AddHostel = %+v, %v", hostel, err)
+	}
+	if _, err := h.AddHostel(ctx, types.Hostel{Name: "Narmada"}); !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("adding a hostel with a taken name: error = %v, want ErrDuplicate", err)
+	}
+	empty, err := h.AddHostel(ctx, types.Hostel{Name: "Kaveri"})
+	if err != nil {
+		t.Fatalf("AddHostel: %v", err)
+	}
+	if got, err := h.GetHostelByPublicID(ctx, hostel.PublicID); err != nil || got.ID != hostel.ID {
+		t.Errorf("GetHostelByPublicID = %+v, %v", got, err)
+	}
+
+	double, err := h.AddRoom(ctx, hostel.ID, types.Room{Number: "102", Capacity: 2})
+	if err != nil {
+		t.Fatalf("AddRoom: %v", err)
+	}
+	if double.HostelPublicID != hostel.PublicID || double.Capacity != 2 || double.Occupied != 0 {
+		t.Errorf("AddRoom = %+v", double)
+	}
+	single, err := h.AddRoom(ctx, hostel.ID, types.Room{Number: "101", Capacity: 1})
+	if err != nil {
+		t.Fatalf("AddRoom: %v", err)
+	}
+	if _, err := h.AddRoom(ctx, hostel.ID, types.Room{Number: "101", Capacity: 3}); !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("adding a room with a taken number: error = %v, want ErrDuplicate", err)
+	}
+	if _, err := h.AddRoom(ctx, empty.ID, types.Room{Number: "101", Capacity: 3}); err != nil {
+		t.Errorf("the same number in another hostel: %v", err)
+	}
+	if _, err := h.AddRoom(ctx, 999999, types.Room{Number: "1", Capacity: 1}); !errors.Is(err, storage.ErrHostelNotFound) {
+		t.Errorf("adding a room to a missing hostel: error = %v, want ErrHostelNotFound", err)
+	}
+
+	alloc, err := h.AllocateRoom(ctx, single.ID, students[0])
+	if err != nil {
+		t.Fatalf("AllocateRoom: %v", err)
+	}
+	if alloc.RoomPublicID != single.PublicID || alloc.RoomNumber != "101" || alloc.StudentID != students[0] || alloc.AllocatedAt.IsZero() {
+		t.Errorf("AllocateRoom = %+v", alloc)
+	}
+	if _, err := h.AllocateRoom(ctx, single.ID, students[1]); !errors.Is(err, storage.ErrRoomFull) {
+		t.Errorf("allocating a full room: error = %v, want ErrRoomFull", err)
+	}
+	if _, err := h.AllocateRoom(ctx, double.ID, students[0]); !errors.Is(err, storage.ErrHasRoom) {
+		t.Errorf("allocating a second room: error = %v, want ErrHasRoom", err)
+	}
+	if _, err := h.AllocateRoom(ctx, double.ID, 999999); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("allocating a missing student: error = %v, want ErrNotFound", err)
+	}
+	if _, err := h.AllocateRoom(ctx, 999999, students[1]); !errors.Is(err, storage.ErrRoomNotFound) {
+		t.Errorf("allocating a missing room: error = %v, want ErrRoomNotFound", err)
+	}
+
+	// Students racing for the double room fill both beds and no more: every other racer is told
+	// the room is full, never that the database failed
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		won int
+	)
+	for _, id := range students[1:] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := h.AllocateRoom(ctx, double.ID, id)
+			if err != nil && !errors.Is(err, storage.ErrRoomFull) {
+				t.Errorf("racing for the double room: error = %v, want nil or ErrRoomFull", err)
+			}
+			if err == nil {
+				mu.Lock()
+				won++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	occupants, err := h.RoomAllocations(ctx, double.ID)
+	if err != nil {
+		t.Fatalf("RoomAllocations: %v", err)
+	}
+	if won != 2 || len(occupants) != 2 {
+		t.Errorf("%d concurrent allocations won and the room has %d occupants, want 2 of each", won, len(occupants))
+	}
+
+	rooms, err := h.HostelRooms(ctx, hostel.ID)
+	if err != nil {
+		t.Fatalf("HostelRooms: %v", err)
+	}
+	if len(rooms) != 2 || rooms[0].Number != "101" || rooms[0].Occupied != 1 || rooms[1].Occupied != won {
+		t.Errorf("HostelRooms = %+v", rooms)
+	}
+
+	report, err := h.HostelOccupancy(ctx)
+	if err != nil {
+		t.Fatalf("HostelOccupancy: %v", err)
+	}
+	want := []types.HostelOccupancy{
+		{HostelPublicID: empty.PublicID, Name: "Kaveri", Rooms: 1, Capacity: 3, Occupied: 0, Vacant: 3},
+		{HostelPublicID: hostel.PublicID, Name: "Narmada", Rooms: 2, Capacity: 3, Occupied: 1 + won, Vacant: 2 - won},
+	}
+	if fmt.Sprint(report) != fmt.Sprint(want) {
+		t.Errorf("HostelOccupancy = %+v, want %+v", report, want)
+	}
+
+	if err := h.VacateRoom(ctx, double.ID, students[0]); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("vacating another student's room: error = %v, want ErrNotFound", err)
+	}
+	if err := h.VacateRoom(ctx, single.ID, students[0]); err != nil {
+		t.Fatalf("VacateRoom: %v", err)
+	}
+	if _, err := h.AllocateRoom(ctx, single.ID, students[0]); err != nil {
+		t.Errorf("allocating a vacated bed: %v", err)
+	}
+}
+
+func testTransport(t *testing.T, s storage.Storage) {
+	tr, ok := storage.As[storage.Transport](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Transport")
+	}
+	ctx := context.Background()
+	students := createN(t, s, 6)
+
+	route, err := tr.AddRoute(ctx, types.BusRoute{Name: "Route 4", Capacity: 3, Stops: []types.Stop{{Name: "Depot"}, {Name: "Market"}}})
+	if err != nil {
+		t.Fatalf("AddRoute: %v", err)
+	}
+	if !types.ValidPublicID(route.PublicID) || len(route.Stops) != 2 || route.Stops[1].Position != 2 || !types.ValidPublicID(route.Stops[1].PublicID) {
+		t.Fatalf("AddRoute = %+v", route)
+	}
+	if _, err := tr.AddRoute(ctx, types.BusRoute{Name: "Route 4", Capacity: 1, Stops: []types.Stop{{Name: "Depot"}}}); !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("adding a route with a taken name: error = %v, want ErrDuplicate", err)
+	}
+	other, err := tr.AddRoute(ctx, types.BusRoute{Name: "Route 1", Capacity: 10, Stops: []types.Stop{{Name: "Station"}}})
+	if err != nil {
+		t.Fatalf("AddRoute: %v", err)
+	}
+	school, err := tr.AddStop(ctx, route.ID, types.Stop{Name: "School Gate"})
+	if err != nil || school.Position != 3 {
+		t.Fatalf("AddStop = %+v, %v, want position 3", school, err)
+	}
+	if _, err := tr.AddStop(ctx, 999999, types.Stop{Name: "Nowhere"}); !errors.Is(err, storage.ErrRouteNotFound) {
+		t.Errorf("adding a stop to a missing route: error = %v, want ErrRouteNotFound", err)
+	}
+	depot, market := route.Stops[0], route.Stops[1]
+
+	// Students 0 and 1 board at the market, student 2 at the depot
+	for i, stop := range []types.Stop{market, market, depot} {
+		a, err := tr.AssignRoute(ctx, route.ID, stop.ID, students[i])
+		if err != nil {
+			t.Fatalf("AssignRoute: %v", err)
+		}
+		if a.RoutePublicID != route.PublicID || a.StopPublicID != stop.PublicID || a.StopName != stop.Name || a.StudentID != students[i] || a.AssignedAt.IsZero() {
+			t.Errorf("AssignRoute = %+v", a)
+		}
+	}
+	if _, err := tr.AssignRoute(ctx, route.ID, depot.ID, students[3]); !errors.Is(err, storage.ErrRouteFull) {
+		t.Errorf("assigning a full route: error = %v, want ErrRouteFull", err)
+	}
+	if _, err := tr.AssignRoute(ctx, other.ID, other.Stops[0].ID, students[0]); !errors.Is(err, storage.ErrHasRoute) {
+		t.Errorf("assigning a second route: error = %v, want ErrHasRoute", err)
+	}
+	if _, err := tr.AssignRoute(ctx, other.ID, depot.ID, students[3]); !errors.Is(err, storage.ErrStopNotFound) {
+		t.Errorf("assigning another route's stop: error = %v, want ErrStopNotFound", err)
+	}
+	if _, err := tr.AssignRoute(ctx, other.ID, other.Stops[0].ID, 999999); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("assigning a missing student: error = %v, want ErrNotFound", err)
+	}
+
+	manifest, err := tr.RouteManifest(ctx, route.ID)
+	if err != nil {
+		t.Fatalf("RouteManifest: %v", err)
+	}
+	var order []int64
+	for _, a := range manifest {
+		order = append(order, a.StudentID)
+	}
+	if want := []int64{students[2], students[0], students[1]}; fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Errorf("RouteManifest riders = %v, want %v (by stop, then name)", order, want)
+	}
+
+	got, err := tr.GetRouteByPublicID(ctx, route.PublicID)
+	if err != nil || got.Riders != 3 || len(got.Stops) != 3 || got.Stops[2].Name != "School Gate" {
+		t.Errorf("GetRouteByPublicID = %+v, %v", got, err)
+	}
+	if _, err := tr.GetRouteByPublicID(ctx, types.NewPublicID()); !errors.Is(err, storage.ErrRouteNotFound) {
+		t.Errorf("GetRouteByPublicID(unknown) error = %v, want ErrRouteNotFound", err)
+	}
+	routes, err := tr.ListRoutes(ctx)
+	if err != nil || len(routes) != 2 || routes[0].Name != "Route 1" || len(routes[0].Stops) != 1 || routes[1].Riders != 3 {
+		t.Errorf("ListRoutes = %+v, %v", routes, err)
+	}
+
+	if err := tr.UnassignRoute(ctx, other.ID, students[0]); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("unassigning a route the student doesn't ride: error = %v, want ErrNotFound", err)
+	}
+	if err := tr.UnassignRoute(ctx, route.ID, students[0]); err != nil {
+		t.Fatalf("UnassignRoute: %v", err)
+	}
+	if _, err := tr.AssignRoute(ctx, route.ID, school.ID, students[3]); err != nil {
+		t.Errorf("assigning a freed seat: %v", err)
+	}
+
+	// Students racing for a two-seat bus fill it and no more: the other racer is told the route
+	// is full, never that the database failed
+	minibus, err := tr.AddRoute(ctx, types.BusRoute{Name: "Route 9", Capacity: 2, Stops: []types.Stop{{Name: "Temple"}}})
+	if err != nil {
+		t.Fatalf("AddRoute: %v", err)
+	}
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		won int
+	)
+	for _, id := range []int64{students[0], students[4], students[5]} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := tr.AssignRoute(ctx, minibus.ID, minibus.Stops[0].ID, id)
+			if err != nil && !errors.Is(err, storage.ErrRouteFull) {
+				t.Errorf("racing for the minibus: error = %v, want nil or ErrRouteFull", err)
+			}
+			if err == nil {
+				mu.Lock()
+				won++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if riders, err := tr.RouteManifest(ctx, minibus.ID); err != nil || won != 2 || len(riders) != 2 {
+		t.Errorf("%d concurrent assignments won and the route has %d riders (%v), want 2 of each", won, len(riders), err)
+	}
+}
+
+func testAlumni(t *testing.T, s storage.Storage) {
+	al, ok := storage.As[storage.Alumni](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Alumni")
+	}
+	ctx := context.Background()
+	ids := createN(t, s, 4)
+	students := make([]types.Student, len(ids))
+	for i, id := range ids {
+		st, err := s.GetStudent(id)
+		if err != nil {
+			t.Fatalf("GetStudent: %v", err)
+		}
+		students[i] = st
+	}
+
+	// Student 0 holds a bed and a library book when they graduate
+	var room types.Room
+	if h, ok := storage.As[storage.Housing](s); ok {
+		hostel, err := h.AddHostel(ctx, types.Hostel{Name: "North Wing"})
+		if err != nil {
+			t.Fatalf("AddHostel: %v", err)
+		}
+		if room, err = h.AddRoom(ctx, hostel.ID, types.Room{Number: "101", Capacity: 1}); err != nil {
+			t.Fatalf("AddRoom: %v", err)
+		}
+		if _, err := h.AllocateRoom(ctx, room.ID, ids[0]); err != nil {
+			t.Fatalf("AllocateRoom: %v", err)
+		}
+	}
+	lib, hasLibrary := storage.As[storage.Library](s)
+	if hasLibrary {
+		book, err := lib.AddBook(ctx, types.Book{Title: "Godan"})
+		if err != nil {
+			t.Fatalf("AddBook: %v", err)
+		}
+		if _, err := lib.CheckOutBook(ctx, book.ID, ids[0], "2000-01-01"); err != nil {
+			t.Fatalf("CheckOutBook: %v", err)
+		}
+	}
+
+	for i, classOf := range []int{2025, 2025, 2024} {
+		a, err := al.GraduateStudent(ctx, ids[i], classOf, fmt.Sprintf("%d-04-30", classOf))
+		if err != nil {
+			t.Fatalf("GraduateStudent: %v", err)
+		}
+		if a.PublicID != students[i].PublicID || a.Name != students[i].Name || a.ClassOf != classOf || a.GraduatedOn != fmt.Sprintf("%d-04-30", classOf) {
+			t.Errorf("GraduateStudent = %+v", a)
+		}
+	}
+	if _, err := al.GraduateStudent(ctx, ids[0], 2026, "2026-04-30"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("graduating an alumnus again: error = %v, want ErrNotFound", err)
+	}
+
+	// Graduates leave every student read
+	if _, err := s.GetStudent(ids[0]); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetStudent(graduate) error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetStudentByPublicID(students[0].PublicID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetStudentByPublicID(graduate) error = %v, want ErrNotFound", err)
+	}
+	if n, err := s.GetStudentsCount(); err != nil || n != 1 {
+		t.Errorf("GetStudentsCount = %d, %v, want 1", n, err)
+	}
+	if h, ok := storage.As[storage.Housing](s); ok {
+		if allocations, err := h.RoomAllocations(ctx, room.ID); err != nil || len(allocations) != 0 {
+			t.Errorf("RoomAllocations after graduation = %+v, %v, want the bed released", allocations, err)
+		}
+	}
+	if hasLibrary {
+		loans, err := lib.OverdueLoans(ctx, time.Now())
+		if err != nil || len(loans) != 1 || loans[0].StudentEmail != students[0].Email {
+			t.Errorf("OverdueLoans after graduation = %+v, %v, want the graduate's loan", loans, err)
+		}
+	}
+
+	got, err := al.GetAlumnusByPublicID(ctx, students[2].PublicID)
+	if err != nil || got.ID != ids[2] || got.ClassOf != 2024 || got.Email != students[2].Email {
+		t.Errorf("GetAlumnusByPublicID = %+v, %v", got, err)
+	}
+	if _, err := al.GetAlumnusByPublicID(ctx, students[3].PublicID); !errors.Is(err, storage.ErrAlumnusNotFound) {
+		t.Errorf("GetAlumnusByPublicID(active student) error = %v, want ErrAlumnusNotFound", err)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		f             types.AlumniFilter
+		offset, limit int
+		want          []int64
+		total         int64
+	}{
+		{"all, latest class first", types.AlumniFilter{}, 0, 10, []int64{ids[0], ids[1], ids[2]}, 3},
+		{"second page", types.AlumniFilter{}, 1, 1, []int64{ids[1]}, 3},
+		{"class", types.AlumniFilter{ClassOf: 2024}, 0, 10, []int64{ids[2]}, 1},
+		{"query ignores case", types.AlumniFilter{Query: "S1@EXAMPLE"}, 0, 10, []int64{ids[1]}, 1},
+		{"no match", types.AlumniFilter{ClassOf: 2025, Query: "Student 2"}, 0, 10, nil, 0},
+	} {
+		alumni, total, err := al.ListAlumni(ctx, tc.f, tc.offset, tc.limit)
+		if err != nil {
+			t.Fatalf("ListAlumni(%s): %v", tc.name, err)
+		}
+		var got []int64
+		for _, a := range alumni {
+			got = append(got, a.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) || total != tc.total {
+			t.Errorf("ListAlumni(%s) = %v (total %d), want %v (total %d)", tc.name, got, total, tc.want, tc.total)
+		}
+	}
+}
+
+func testApplications(t *testing.T, s storage.Storage) {
+	ad, ok := storage.As[storage.Admissions](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Admissions")
+	}
+	ctx := context.Background()
+
+	var apps []types.Application
+	for i, name := range []string{"Asha", "Ravi", "Meera"} {
+		app, err := ad.SubmitApplication(ctx, types.Application{
+			Name:  name,
+			Email: fmt.Sprintf("applicant%d@example.com", i),
+			Age:   17,
+			Phone: "+919876543210",
+		})
+		if err != nil {
+			t.Fatalf("SubmitApplication: %v", err)
+		}
+		if app.ID <= 0 || !types.ValidPublicID(app.PublicID) || app.Status != types.ApplicationSubmitted || app.SubmittedAt.IsZero() {
+			t.Errorf("SubmitApplication = %+v", app)
+		}
+		apps = append(apps, app)
+	}
+
+	// Applicants aren't students until they are converted
+	if n, err := s.GetStudentsCount(); err != nil || n != 0 {
+		t.Errorf("GetStudentsCount = %d, %v, want 0", n, err)
+	}
+
+	got, err := ad.GetApplicationByPublicID(ctx, apps[1].PublicID)
+	if err != nil || got.ID != apps[1].ID || got.Name != "Ravi" || got.Phone != "+919876543210" {
+		t.Errorf("GetApplicationByPublicID = %+v, %v", got, err)
+	}
+	if _, err := ad.GetApplicationByPublicID(ctx, types.NewPublicID()); !errors.Is(err, storage.ErrApplicationNotFound) {
+		t.Errorf("GetApplicationByPublicID(unknown) error = %v, want ErrApplicationNotFound", err)
+	}
+
+	// Asha is accepted after review, Ravi is rejected straight away, Meera is left waiting
+	for _, step := range []struct {
+		app    int
+		status string
+	}{
+		{0, types.ApplicationReviewed},
+		{0, types.ApplicationAccepted},
+		{1, types.ApplicationRejected},
+	} {
+		app, err := ad.SetApplicationStatus(ctx, apps[step.app].ID, step.status)
+		if err != nil || app.Status != step.status {
+			t.Fatalf("SetApplicationStatus(%s) = %+v, %v", step.status, app, err)
+		}
+	}
+	for _, tc := range []struct {
+		app    int
+		status string
+	}{
+		{2, types.ApplicationAccepted}, // must be reviewed first
+		{1, types.ApplicationReviewed}, // rejections are final
+		{0, types.ApplicationSubmitted},
+	} {
+		if _, err := ad.SetApplicationStatus(ctx, apps[tc.app].ID, tc.status); !errors.Is(err, storage.ErrApplicationStatus) {
+			t.Errorf("SetApplicationStatus(%d, %s) error = %v, want ErrApplicationStatus", tc.app, tc.status, err)
+		}
+	}
+	if _, err := ad.SetApplicationStatus(ctx, 1<<40, types.ApplicationReviewed); !errors.Is(err, storage.ErrApplicationNotFound) {
+		t.Errorf("SetApplicationStatus(unknown) error = %v, want ErrApplicationNotFound", err)
+	}
+
+	if _, _, err := ad.ConvertApplication(ctx, apps[2].ID, apps[2].Student()); !errors.Is(err, storage.ErrApplicationStatus) {
+		t.Errorf("ConvertApplication(submitted) error = %v, want ErrApplicationStatus", err)
+	}
+	app, student, err := ad.ConvertApplication(ctx, apps[0].ID, apps[0].Student())
+	if err != nil {
+		t.Fatalf("ConvertApplication: %v", err)
+	}
+	if student.ID <= 0 || student.PublicID == "" || student.Name != "Asha" || app.StudentPublicID != student.PublicID {
+		t.Errorf("ConvertApplication = %+v, %+v", app, student)
+	}
+	if stored, err := s.GetStudentByPublicID(student.PublicID); err != nil || stored.Email != "applicant0@example.com" {
+		t.Errorf("GetStudentByPublicID(converted) = %+v, %v", stored, err)
+	}
+	if _, _, err := ad.ConvertApplication(ctx, apps[0].ID, apps[0].Student()); !errors.Is(err, storage.ErrConverted) {
+		t.Errorf("ConvertApplication again: error = %v, want ErrConverted", err)
+	}
+	if n, err := s.GetStudentsCount(); err != nil || n != 1 {
+		t.Errorf("GetStudentsCount after conversion = %d, %v, want 1", n, err)
+	}
+
+	for _, tc := range []struct {
+		status        string
+		offset, limit int
+		want          []string
+		total         int64
+	}{
+		{"", 0, 10, []string{"Asha", "Ravi", "Meera"}, 3},
+		{"", 1, 1, []string{"Ravi"}, 3},
+		{types.ApplicationAccepted, 0, 10, []string{"Asha"}, 1},
+		{types.ApplicationSubmitted, 0, 10, []string{"Meera"}, 1},
+		{types.ApplicationReviewed, 0, 10, nil, 0},
+	} {
+		list, total, err := ad.ListApplications(ctx, tc.status, tc.offset, tc.limit)
+		if err != nil {
+			t.Fatalf("ListApplications(%q): %v", tc.status, err)
+		}
+		var names []string
+		for _, a := range list {
+			names = append(names, a.Name)
+		}
+		if fmt.Sprint(names) != fmt.Sprint(tc.want) || total != tc.total {
+			t.Errorf("ListApplications(%q, %d, %d) = %v (total %d), want %v (total %d)", tc.status, tc.offset, tc.limit, names, total, tc.want, tc.total)
+		}
+	}
+}
+
+func testAnnouncements(t *testing.T, s storage.Storage) {
+	an, ok := storage.As[storage.Announcements](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Announcements")
+	}
+	ctx := context.Background()
+	ids := createN(t, s, 3) // aged 20, 21 and 22
+
+	everyone, err := an.PostAnnouncement(ctx, types.Announcement{Subject: "Holiday", Body: "School is closed on Friday."}, nil)
+	if err != nil {
+		t.Fatalf("PostAnnouncement: %v", err)
+	}
+	if !types.ValidPublicID(everyone.PublicID) || everyone.Recipients != 3 || everyone.Deliveries[types.DeliveryQueued] != 3 ||
+		everyone.Channel != types.ChannelEmail || everyone.CreatedAt.IsZero() {
+		t.Errorf("PostAnnouncement(everyone) = %+v", everyone)
+	}
+	f, err := filter.Parse("age >= 21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	older, err := an.PostAnnouncement(ctx, types.Announcement{Subject: "Exams", Body: "Timetable attached.", Filter: "age >= 21"}, &f)
+	if err != nil {
+		t.Fatalf("PostAnnouncement(filtered): %v", err)
+	}
+	if older.Recipients != 2 || older.Filter != "age >= 21" {
+		t.Errorf("PostAnnouncement(filtered) = %+v, want 2 recipients", older)
+	}
+
+	pending, err := an.PendingDeliveries(ctx, older.ID)
+	if err != nil {
+		t.Fatalf("PendingDeliveries: %v", err)
+	}
+	if len(pending) != 2 || pending[0].StudentID != ids[1] || pending[1].StudentID != ids[2] || pending[0].Email != "s1@example.com" || pending[0].Name != "Student 1" {
+		t.Fatalf("PendingDeliveries = %+v, want students 1 and 2", pending)
+	}
+	if err := an.SetDeliveryStatus(ctx, types.Delivery{AnnouncementID: older.ID, StudentID: ids[1], Status: types.DeliverySent}); err != nil {
+		t.Fatalf("SetDeliveryStatus: %v", err)
+	}
+	if err := an.SetDeliveryStatus(ctx, types.Delivery{AnnouncementID: older.ID, StudentID: ids[2], Status: types.DeliveryFailed, Error: "mailbox full"}); err != nil {
+		t.Fatalf("SetDeliveryStatus: %v", err)
+	}
+	if pending, err := an.PendingDeliveries(ctx, older.ID); err != nil || len(pending) != 0 {
+		t.Errorf("PendingDeliveries after sending = %+v, %v, want none", pending, err)
+	}
+
+	got, err := an.GetAnnouncementByPublicID(ctx, older.PublicID)
+	if err != nil || got.ID != older.ID || got.Subject != "Exams" || got.Recipients != 2 ||
+		got.Deliveries[types.DeliverySent] != 1 || got.Deliveries[types.DeliveryFailed] != 1 || got.Deliveries[types.DeliveryQueued] != 0 {
+		t.Errorf("GetAnnouncementByPublicID = %+v, %v", got, err)
+	}
+	if _, err := an.GetAnnouncementByPublicID(ctx, types.NewPublicID()); !errors.Is(err, storage.ErrAnnouncementNotFound) {
+		t.Errorf("GetAnnouncementByPublicID(unknown) error = %v, want ErrAnnouncementNotFound", err)
+	}
+
+	// Newest first, each with this student's delivery
+	list, err := an.StudentAnnouncements(ctx, ids[2])
+	if err != nil {
+		t.Fatalf("StudentAnnouncements: %v", err)
+	}
+	if len(list) != 2 || list[0].PublicID != older.PublicID || list[0].Status != types.DeliveryFailed || list[0].Error != "mailbox full" ||
+		list[1].PublicID != everyone.PublicID || list[1].Status != types.DeliveryQueued {
+		t.Errorf("StudentAnnouncements = %+v", list)
+	}
+	if list, err := an.StudentAnnouncements(ctx, ids[0]); err != nil || len(list) != 1 {
+		t.Errorf("StudentAnnouncements(not targeted) = %+v, %v, want only the first", list, err)
+	}
+
+	// A merge keeps one delivery per announcement for the kept student
+	if m, ok := storage.As[storage.Merger](s); ok {
+		if _, err := m.MergeStudents(ctx, ids[0], ids[2]); err != nil {
+			t.Fatalf("MergeStudents: %v", err)
+		}
+		list, err := an.StudentAnnouncements(ctx, ids[0])
+		if err != nil || len(list) != 2 || list[0].PublicID != older.PublicID || list[1].Status != types.DeliveryQueued {
+			t.Errorf("StudentAnnouncements after merge = %+v, %v", list, err)
+		}
+		if got, err := an.GetAnnouncementByPublicID(ctx, everyone.PublicID); err != nil || got.Recipients != 2 {
+			t.Errorf("GetAnnouncementByPublicID after merge = %+v, %v, want 2 recipients", got, err)
+		}
+	}
+
+	// Text messages stay sent until the provider reports how they went
+	text, err := an.PostAnnouncement(ctx, types.Announcement{Subject: "Bus", Body: "Route 4 is late.", Channel: types.ChannelSMS}, nil)
+	if err != nil || text.Channel != types.ChannelSMS {
+		t.Fatalf("PostAnnouncement(sms) = %+v, %v", text, err)
+	}
+	pending, err = an.PendingDeliveries(ctx, text.ID)
+	if err != nil || len(pending) < 2 {
+		t.Fatalf("PendingDeliveries(sms) = %+v, %v", pending, err)
+	}
+	for i, d := range pending[:2] {
+		d.Status, d.MessageID = types.DeliverySent, fmt.Sprintf("SM%d", i)
+		if err := an.SetDeliveryStatus(ctx, d); err != nil {
+			t.Fatalf("SetDeliveryStatus(sms): %v", err)
+		}
+	}
+	if err := an.ReportDelivery(ctx, "SM0", types.DeliveryDelivered, ""); err != nil {
+		t.Errorf("ReportDelivery: %v", err)
+	}
+	if err := an.ReportDelivery(ctx, "SM1", types.DeliveryFailed, "undelivered (error code 30003)"); err != nil {
+		t.Errorf("ReportDelivery: %v", err)
+	}
+	// A repeated or contradicting report for a finished delivery changes nothing
+	if err := an.ReportDelivery(ctx, "SM0", types.DeliveryFailed, "late"); err != nil {
+		t.Errorf("ReportDelivery(again): %v", err)
+	}
+	if err := an.ReportDelivery(ctx, "SM9", types.DeliveryDelivered, ""); !errors.Is(err, storage.ErrDeliveryNotFound) {
+		t.Errorf("ReportDelivery(unknown) error = %v, want ErrDeliveryNotFound", err)
+	}
+	got, err = an.GetAnnouncementByPublicID(ctx, text.PublicID)
+	if err != nil || got.Deliveries[types.DeliveryDelivered] != 1 || got.Deliveries[types.DeliveryFailed] != 1 {
+		t.Errorf("GetAnnouncementByPublicID(sms) = %+v, %v, want 1 delivered and 1 failed", got.Deliveries, err)
+	}
+	list, err = an.StudentAnnouncements(ctx, pending[1].StudentID)
+	if err != nil || len(list) == 0 || list[0].Channel != types.ChannelSMS || list[0].Error != "undelivered (error code 30003)" {
+		t.Errorf("StudentAnnouncements(sms) = %+v, %v", list, err)
+	}
+}
+
+func testCustomFields(t *testing.T, s storage.Storage) {
+	cf, ok := storage.As[storage.CustomFields](s)
+	if !ok {
+		t.Skip("storage does not implement storage.CustomFields")
+	}
+	ctx := context.Background()
+
+	defs := []types.CustomField{
+		{Name: "locker", Type: types.CustomNumber},
+		{Name: "hosteller", Type: types.CustomBoolean},
+		{Name: "previous_school", Type: types.CustomString, Required: true},
+		{Name: "joined", Type: types.CustomDate},
+	}
+	for _, def := range defs {
+		if got, err := cf.DefineCustomField(ctx, def); err != nil || got != def {
+			t.Fatalf("DefineCustomField(%+v) = %+v, %v", def, got, err)
+		}
+	}
+	if _, err := cf.DefineCustomField(ctx, types.CustomField{Name: "locker", Type: types.CustomString}); !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("DefineCustomField(duplicate) error = %v, want ErrDuplicate", err)
+	}
+	list, err := cf.ListCustomFields(ctx)
+	if err != nil {
+		t.Fatalf("ListCustomFields: %v", err)
+	}
+	if names := fmt.Sprint(list); names != fmt.Sprint([]types.CustomField{defs[1], defs[3], defs[0], defs[2]}) {
+		t.Errorf("ListCustomFields = %s, want them by name", names)
+	}
+
+	// Values read back as they decode from JSON
+	values := map[string]any{"locker": float64(42), "hosteller": true, "previous_school": "St. Mary's", "joined": "2024-06-01"}
+	asha, err := s.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 16, CustomFields: values})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	got, err := s.GetStudent(asha)
+	if err != nil || !reflect.DeepEqual(got.CustomFields, values) {
+		t.Fatalf("GetStudent(%d).CustomFields = %v, %v, want %v", asha, got.CustomFields, err, values)
+	}
+	ids, err := s.CreateStudents(ctx, []types.Student{
+		{Name: "Ravi", Email: "ravi@example.com", Age: 17, CustomFields: map[string]any{"locker": float64(7), "hosteller": false, "previous_school": "DPS"}},
+		{Name: "Meera", Email: "meera@example.com", Age: 18},
+	})
+	if err != nil {
+		t.Fatalf("CreateStudents: %v", err)
+	}
+	ravi, meera := ids[0], ids[1]
+	if got, _ := s.GetStudent(meera); got.CustomFields != nil {
+		t.Errorf("GetStudent(%d).CustomFields = %v, want none", meera, got.CustomFields)
+	}
+
+	if fs, ok := storage.As[storage.Filterer](s); ok {
+		tests := []struct {
+			expr string
+			want []int64
+		}{
+			{`custom_fields.locker > 10`, []int64{asha}},
+			{`custom_fields.locker != 42`, []int64{ravi}},
+			{`custom_fields.hosteller = false`, []int64{ravi}},
+			{`custom_fields.hosteller != true`, []int64{ravi}},
+			{`custom_fields.previous_school ~ "mary"`, []int64{asha}},
+			{`custom_fields.previous_school = ""`, []int64{meera}},
+			{`custom_fields.joined >= "2024-01-01"`, []int64{asha}},
+			{`NOT custom_fields.joined < "2024-01-01"`, []int64{asha, ravi, meera}},
+		}
+		for _, tc := range tests {
+			f, err := filter.ParseWith(tc.expr, list)
+			if err != nil {
+				t.Fatalf("ParseWith(%s): %v", tc.expr, err)
+			}
+			got, total, err := fs.FilterStudents(ctx, f, 0, 10)
+			if err != nil {
+				t.Fatalf("FilterStudents(%s): %v", tc.expr, err)
+			}
+			if ids := studentIDs(got); fmt.Sprint(ids) != fmt.Sprint(tc.want) || total != int64(len(tc.want)) {
+				t.Errorf("FilterStudents(%s) = %v (total %d), want %v", tc.expr, ids, total, tc.want)
+			}
+		}
+	}
+
+	// Deleting a field takes its values with it
+	if err := cf.DeleteCustomField(ctx, "locker"); err != nil {
+		t.Fatalf("DeleteCustomField: %v", err)
+	}
+	if err := cf.DeleteCustomField(ctx, "locker"); !errors.Is(err, storage.ErrCustomFieldNotFound) {
+		t.Errorf("DeleteCustomField(again) error = %v, want ErrCustomFieldNotFound", err)
+	}
+	got, _ = s.GetStudent(ravi)
+	if want := map[string]any{"hosteller": false, "previous_school": "DPS"}; !reflect.DeepEqual(got.CustomFields, want) {
+		t.Errorf("GetStudent(%d).CustomFields after delete = %v, want %v", ravi, got.CustomFields, want)
+	}
+	list, _ = cf.ListCustomFields(ctx)
+	if len(list) != 3 {
+		t.Errorf("ListCustomFields after delete = %v, want 3 fields", list)
+	}
+
+	// A merge keeps the kept student's values and fills in the rest from the duplicate
+	if m, ok := storage.As[storage.Merger](s); ok {
+		kept, err := m.MergeStudents(ctx, meera, asha)
+		if err != nil {
+			t.Fatalf("MergeStudents: %v", err)
+		}
+		if want := map[string]any{"hosteller": true, "previous_school": "St. Mary's", "joined": "2024-06-01"}; !reflect.DeepEqual(kept.CustomFields, want) {
+			t.Errorf("MergeStudents().CustomFields = %v, want %v", kept.CustomFields, want)
+		}
+		kept, err = m.MergeStudents(ctx, ravi, meera)
+		if err != nil {
+			t.Fatalf("MergeStudents: %v", err)
+		}
+		if want := map[string]any{"hosteller": false, "previous_school": "DPS", "joined": "2024-06-01"}; !reflect.DeepEqual(kept.CustomFields, want) {
+			t.Errorf("MergeStudents().CustomFields = %v, want %v", kept.CustomFields, want)
+		}
+	}
+}
+
+func testViews(t *testing.T, s storage.Storage) {
+	vs, ok := storage.As[storage.Views](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Views")
+	}
+	ctx := context.Background()
+
+	seniors, err := vs.SaveView(ctx, types.View{Name: "seniors", Filter: "age >= 17", Expand: []string{"relationships"}})
+	if err != nil {
+		t.Fatalf("SaveView: %v", err)
+	}
+	if seniors.CreatedAt.IsZero() {
+		t.Errorf("SaveView().CreatedAt is zero")
+	}
+	if _, err := vs.SaveView(ctx, types.View{Name: "all"}); err != nil {
+		t.Fatalf("SaveView(all): %v", err)
+	}
+	if _, err := vs.SaveView(ctx, types.View{Name: "seniors", Filter: "age >= 18"}); !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("SaveView(duplicate) error = %v, want ErrDuplicate", err)
+	}
+
+	got, err := vs.GetView(ctx, "seniors")
+	if err != nil || !reflect.DeepEqual(got, seniors) {
+		t.Errorf("GetView(seniors) = %+v, %v, want %+v", got, err, seniors)
+	}
+	if _, err := vs.GetView(ctx, "juniors"); !errors.Is(err, storage.ErrViewNotFound) {
+		t.Errorf("GetView(juniors) error = %v, want ErrViewNotFound", err)
+	}
+	list, err := vs.ListViews(ctx)
+	if err != nil || len(list) != 2 || list[0].Name != "all" || list[0].Filter != "" || list[0].Expand != nil || list[1].Name != "seniors" {
+		t.Errorf("ListViews = %+v, %v, want all and seniors", list, err)
+	}
+
+	if err := vs.DeleteView(ctx, "seniors"); err != nil {
+		t.Fatalf("DeleteView: %v", err)
+	}
+	if err := vs.DeleteView(ctx, "seniors"); !errors.Is(err, storage.ErrViewNotFound) {
+		t.Errorf("DeleteView(again) error = %v, want ErrViewNotFound", err)
+	}
+	if _, err := vs.GetView(ctx, "seniors"); !errors.Is(err, storage.ErrViewNotFound) {
+		t.Errorf("GetView(deleted) error = %v, want ErrViewNotFound", err)
+	}
+}
+
+func testUpdateStudent(t *testing.T, s storage.Storage) {
+	u, ok := storage.As[storage.Updater](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Updater")
+	}
+	ctx := context.Background()
+
+	id, err := s.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 18, Phone: "+919876543210", PublicID: types.NewPublicID(), CustomFields: map[string]any{"locker": float64(42)}})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	before, err := s.GetStudent(id)
+	if err != nil {
+		t.Fatalf("GetStudent: %v", err)
+	}
+
+	// Nothing changes: the list is empty, not nil, so it encodes as []
+	same := before
+	same.CustomFields = map[string]any{"locker": float64(42)}
+	got, changed, err := u.UpdateStudent(ctx, same)
+	if err != nil {
+		t.Fatalf("UpdateStudent(same): %v", err)
+	}
+	if changed == nil || len(changed) != 0 || !reflect.DeepEqual(got, before) {
+		t.Errorf("UpdateStudent(same) = %+v, %#v, want %+v, []", got, changed, before)
+	}
+
+	next := types.Student{ID: id, Name: "Asha Patil", Email: "asha@example.com", Age: 19, CustomFields: map[string]any{"hosteller": true}}
+
+	// A dry run reports the update it would make and stores nothing
+	got, changed, err = u.UpdateStudent(storage.WithDryRun(ctx), next)
+	if err != nil || got.Name != "Asha Patil" || len(changed) != 5 {
+		t.Errorf("UpdateStudent(dry run) = %+v, %v, %v, want the update and its 5 changed fields", got, changed, err)
+	}
+	if stored, err := s.GetStudent(id); err != nil || !reflect.DeepEqual(stored, before) {
+		t.Errorf("GetStudent after a dry run = %+v, %v, want %+v", stored, err, before)
+	}
+
+	got, changed, err = u.UpdateStudent(ctx, next)
+	if err != nil {
+		t.Fatalf("UpdateStudent: %v", err)
+	}
+	want := []string{"name", "age", "phone", "custom_fields.hosteller", "custom_fields.locker"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("UpdateStudent changed = %v, want %v", changed, want)
+	}
+	next.PublicID = before.PublicID
+	if !reflect.DeepEqual(got, next) {
+		t.Errorf("UpdateStudent = %+v, want %+v", got, next)
+	}
+	if stored, err := s.GetStudent(id); err != nil || !reflect.DeepEqual(stored, next) {
+		t.Errorf("GetStudent after update = %+v, %v, want %+v", stored, err, next)
+	}
+
+	if _, _, err := u.UpdateStudent(ctx, types.Student{ID: id + 1000, Name: "Nobody", Email: "nobody@example.com", Age: 18}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateStudent(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func testPatchStudents(t *testing.T, s storage.Storage) {
+	b, ok := storage.As[storage.BulkUpdater](s)
+	if !ok {
+		t.Skip("storage does not implement storage.BulkUpdater")
+	}
+	ctx := context.Background()
+
+	students := []struct {
+		name   string
+		age    int
+		custom map[string]any
+	}{
+		{"Asha", 18, map[string]any{"locker": float64(42)}},
+		{"Ravi", 20, nil},
+		{"Meera", 22, map[string]any{"section": "B"}},
+	}
+	ids := make(map[string]int64)
+	for _, st := range students {
+		id, err := s.CreateStudent(types.Student{Name: st.name, Email: strings.ToLower(st.name) + "@example.com", Age: st.age, PublicID: types.NewPublicID(), CustomFields: st.custom})
+		if err != nil {
+			t.Fatalf("CreateStudent(%s): %v", st.name, err)
+		}
+		ids[st.name] = id
+	}
+	custom := func(name string) map[string]any {
+		t.Helper()
+		st, err := s.GetStudent(ids[name])
+		if err != nil {
+			t.Fatalf("GetStudent(%s): %v", name, err)
+		}
+		return st.CustomFields
+	}
+
+	f, err := filter.Parse("age >= 20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Over max: the count comes back and nothing changes
+	got, err := b.PatchStudents(ctx, &f, map[string]any{"section": "B"}, 1)
+	if !errors.Is(err, storage.ErrConfirmationRequired) || got.Matched != 2 {
+		t.Fatalf("PatchStudents(max 1) = %+v, %v, want 2 matched and ErrConfirmationRequired", got, err)
+	}
+	if c := custom("Ravi"); c != nil {
+		t.Errorf("Ravi's custom fields after a refused patch = %v, want none", c)
+	}
+
+	// Meera already has the value, so only Ravi is updated
+	got, err = b.PatchStudents(ctx, &f, map[string]any{"section": "B"}, -1)
+	if err != nil || got != (types.BulkUpdate{Matched: 2, Updated: 1}) {
+		t.Fatalf("PatchStudents = %+v, %v, want 2 matched, 1 updated", got, err)
+	}
+	if c := custom("Ravi"); !reflect.DeepEqual(c, map[string]any{"section": "B"}) {
+		t.Errorf("Ravi's custom fields = %v, want section B", c)
+	}
+	if c := custom("Asha"); !reflect.DeepEqual(c, map[string]any{"locker": float64(42)}) {
+		t.Errorf("Asha's custom fields = %v, want them untouched", c)
+	}
+
+	// nil means every student; null clears a field and clearing the last leaves none
+	got, err = b.PatchStudents(ctx, nil, map[string]any{"section": nil, "locker": nil}, 3)
+	if err != nil || got != (types.BulkUpdate{Matched: 3, Updated: 3}) {
+		t.Fatalf("PatchStudents(clear) = %+v, %v, want 3 matched, 3 updated", got, err)
+	}
+	for name := range ids {
+		if c := custom(name); c != nil {
+			t.Errorf("%s's custom fields after clearing = %v, want none", name, c)
+		}
+	}
+}
+
+func testStudentChanges(t *testing.T, s storage.Storage) {
+	ch, ok := storage.As[storage.Changes](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Changes")
+	}
+	clocked, ok := storage.As[interface{ SetClock(clock.Clock) }](s)
+	if !ok {
+		t.Skip("storage's clock can't be replaced")
+	}
+	clk := clock.NewFake(time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC))
+	clocked.SetClock(clk)
+	ctx := context.Background()
+	since := clk.Now().Add(-time.Minute)
+
+	var ids []int64
+	for _, name := range []string{"Asha", "Ravi", "Meera"} {
+		id, err := s.CreateStudent(types.Student{Name: name, Email: strings.ToLower(name) + "@example.com", Age: 18, PublicID: types.NewPublicID()})
+		if err != nil {
+			t.Fatalf("CreateStudent: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	// The current second can still be written to, so it waits: a cursor inside it would skip a
+	// later change there to a student sorting before the cursor
+	early, err := ch.StudentChanges(ctx, since, "", 10)
+	if err != nil || len(early) != 0 {
+		t.Fatalf("StudentChanges within the second = %+v, %v, want none yet", early, err)
+	}
+	var renamed types.Student
+	if u, ok := storage.As[storage.Updater](s); ok {
+		first := slices.MinFunc(ids, func(a, b int64) int {
+			x, _ := s.GetStudent(a)
+			y, _ := s.GetStudent(b)
+			return strings.Compare(x.PublicID, y.PublicID)
+		})
+		renamed, _ = s.GetStudent(first)
+		renamed.Name += " Rao"
+		if _, _, err := u.UpdateStudent(ctx, renamed); err != nil {
+			t.Fatalf("UpdateStudent: %v", err)
+		}
+	}
+	clk.Advance(time.Second)
+
+	all, err := ch.StudentChanges(ctx, since, "", 10)
+	if err != nil {
+		t.Fatalf("StudentChanges: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("StudentChanges = %+v, want 3 changes", all)
+	}
+	for i, c := range all {
+		if c.Change != types.ChangeCreated || c.Student == nil || c.Student.PublicID != c.ID || c.ChangedAt.Before(since.Truncate(time.Second)) {
+			t.Errorf("StudentChanges[%d] = %+v, want a created student", i, c)
+		}
+		if i > 0 && (c.ChangedAt.Before(all[i-1].ChangedAt) || c.ChangedAt.Equal(all[i-1].ChangedAt) && c.ID <= all[i-1].ID) {
+			t.Errorf("StudentChanges not in (changed at, id) order: %+v", all)
+		}
+	}
+
+	// Resuming after the first page returns the rest
+	page, err := ch.StudentChanges(ctx, since, "", 1)
+	if err != nil || len(page) != 1 || page[0].ID != all[0].ID {
+		t.Fatalf("StudentChanges(limit 1) = %+v, %v, want %s", page, err, all[0].ID)
+	}
+	rest, err := ch.StudentChanges(ctx, page[0].ChangedAt, page[0].ID, 10)
+	if err != nil || len(rest) != 2 || rest[0].ID != all[1].ID || rest[1].ID != all[2].ID {
+		t.Errorf("StudentChanges(after %s) = %+v, %v, want the other two", page[0].ID, rest, err)
+	}
+	if renamed.PublicID != "" && (all[0].ID != renamed.PublicID || all[0].Student.Name != renamed.Name) {
+		t.Errorf("StudentChanges[0] = %+v, want %s renamed", all[0], renamed.PublicID)
+	}
+	if later, err := ch.StudentChanges(ctx, clk.Now().Add(time.Minute), "", 10); err != nil || len(later) != 0 {
+		t.Errorf("StudentChanges(future) = %+v, %v, want none", later, err)
+	}
+
+	// A merged-away student is reported deleted, without its data
+	if m, ok := storage.As[storage.Merger](s); ok {
+		merged, _ := s.GetStudent(ids[2])
+		if _, err := m.MergeStudents(ctx, ids[0], ids[2]); err != nil {
+			t.Fatalf("MergeStudents: %v", err)
+		}
+		clk.Advance(time.Second)
+		all, err := ch.StudentChanges(ctx, since, "", 10)
+		if err != nil || len(all) != 3 {
+			t.Fatalf("StudentChanges after merge = %+v, %v, want 3 changes", all, err)
+		}
+		i := slices.IndexFunc(all, func(c types.StudentChange) bool { return c.ID == merged.PublicID })
+		if i < 0 || all[i].Change != types.ChangeDeleted || all[i].Student != nil {
+			t.Errorf("StudentChanges after merge = %+v, want %s deleted", all, merged.PublicID)
+		}
+	}
+}
+
+func testWebhooks(t *testing.T, s storage.Storage) {
+	ws, ok := storage.As[storage.Webhooks](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Webhooks")
+	}
+	ctx := context.Background()
+
+	created, err := ws.CreateWebhook(ctx, types.Webhook{Owner: "alice", URL: "https://example.com/a", Events: []string{"student.created", "student.deleted"}, Secret: "s1"})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	if !types.ValidPublicID(created.PublicID) || created.CreatedAt.IsZero() {
+		t.Errorf("CreateWebhook() = %+v, want a public ID and creation time", created)
+	}
+	other, err := ws.CreateWebhook(ctx, types.Webhook{Owner: "bob", URL: "https://example.com/b", Events: []string{"student.created"}, Secret: "s2"})
+	if err != nil {
+		t.Fatalf("CreateWebhook(bob): %v", err)
+	}
+
+	got, err := ws.GetWebhook(ctx, created.PublicID)
+	if err != nil || !reflect.DeepEqual(got, created) {
+		t.Errorf("GetWebhook = %+v, %v, want %+v", got, err, created)
+	}
+	if _, err := ws.GetWebhook(ctx, types.NewPublicID()); !errors.Is(err, storage.ErrWebhookNotFound) {
+		t.Errorf("GetWebhook(unknown) error = %v, want ErrWebhookNotFound", err)
+	}
+	list, err := ws.ListWebhooks(ctx, "alice")
+	if err != nil || len(list) != 1 || list[0].PublicID != created.PublicID {
+		t.Errorf("ListWebhooks(alice) = %+v, %v, want only alice's", list, err)
+	}
+
+	// "student.create" is a prefix of a subscribed kind, not one
+	for event, want := range map[string][]string{
+		"student.created": {created.PublicID, other.PublicID},
+		"student.deleted": {created.PublicID},
+		"student.create":  nil,
+	} {
+		hooks, err := ws.WebhooksFor(ctx, event)
+		var ids []string
+		for _, h := range hooks {
+			ids = append(ids, h.PublicID)
+		}
+		if err != nil || !slices.Equal(ids, want) {
+			t.Errorf("WebhooksFor(%s) = %v, %v, want %v", event, ids, err, want)
+		}
+	}
+
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	rotated, err := ws.RotateWebhookSecret(ctx, created.PublicID, "s3", expires)
+	if err != nil {
+		t.Fatalf("RotateWebhookSecret: %v", err)
+	}
+	if rotated.Secret != "s3" || rotated.PreviousSecret != "s1" || rotated.PreviousSecretExpiresAt == nil || !rotated.PreviousSecretExpiresAt.Equal(expires) {
+		t.Errorf("RotateWebhookSecret() = %+v, want secret s3 and s1 until %v", rotated, expires)
+	}
+	if got, err := ws.GetWebhook(ctx, created.PublicID); err != nil || !reflect.DeepEqual(got, rotated) {
+		t.Errorf("GetWebhook(rotated) = %+v, %v, want %+v", got, err, rotated)
+	}
+	// A previous secret past its expiry no longer signs anything
+	rotated, err = ws.RotateWebhookSecret(ctx, created.PublicID, "s4", time.Now().Add(-time.Hour))
+	if err != nil || rotated.Secret != "s4" || rotated.PreviousSecret != "" || rotated.PreviousSecretExpiresAt != nil {
+		t.Errorf("RotateWebhookSecret(expired) = %+v, %v, want secret s4 alone", rotated, err)
+	}
+	if _, err := ws.RotateWebhookSecret(ctx, types.NewPublicID(), "s5", expires); !errors.Is(err, storage.ErrWebhookNotFound) {
+		t.Errorf("RotateWebhookSecret(unknown) error = %v, want ErrWebhookNotFound", err)
+	}
+
+	if err := ws.DeleteWebhook(ctx, created.PublicID); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if err := ws.DeleteWebhook(ctx, created.PublicID); !errors.Is(err, storage.ErrWebhookNotFound) {
+		t.Errorf("DeleteWebhook(again) error = %v, want ErrWebhookNotFound", err)
+	}
+	if list, err := ws.ListWebhooks(ctx, "alice"); err != nil || len(list) != 0 {
+		t.Errorf("ListWebhooks(alice) after delete = %+v, %v, want none", list, err)
+	}
+}
+
+func testWebhookDeliveries(t *testing.T, s storage.Storage) {
+	ws, ok := storage.As[storage.Webhooks](s)
+	if !ok {
+		t.Skip("storage does not implement storage.Webhooks")
+	}
+	ctx := context.Background()
+
+	hook, err := ws.CreateWebhook(ctx, types.Webhook{Owner: "alice", URL: "https://example.com/a", Events: []string{"student.created"}, Secret: "s1"})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	first, err := ws.CreateWebhookDelivery(ctx, types.WebhookDelivery{WebhookID: hook.PublicID, Event: "student.created", EventID: "e1", Body: json.RawMessage(`{"id":"e1"}`)})
+	if err != nil {
+		t.Fatalf("CreateWebhookDelivery: %v", err)
+	}
+	if first.ID == 0 || !types.ValidPublicID(first.PublicID) || first.Status != types.DeliveryQueued || first.Attempts != 0 || first.CreatedAt.IsZero() {
+		t.Errorf("CreateWebhookDelivery() = %+v, want a queued delivery with an ID and a UUID", first)
+	}
+	second, err := ws.CreateWebhookDelivery(ctx, types.WebhookDelivery{WebhookID: hook.PublicID, Event: "student.created", EventID: "e2", Body: json.RawMessage(`{"id":"e2"}`)})
+	if err != nil {
+		t.Fatalf("CreateWebhookDelivery(second): %v", err)
+	}
+	if _, err := ws.CreateWebhookDelivery(ctx, types.WebhookDelivery{WebhookID: types.NewPublicID(), Event: "student.created", EventID: "e3", Body: json.RawMessage(`{}`)}); !errors.Is(err, storage.ErrWebhookNotFound) {
+		t.Errorf("CreateWebhookDelivery(unknown webhook) error = %v, want ErrWebhookNotFound", err)
+	}
+
+	got, err := ws.GetWebhookDelivery(ctx, first.ID)
+	if err != nil || !reflect.DeepEqual(got, first) {
+		t.Errorf("GetWebhookDelivery = %+v, %v, want %+v", got, err, first)
+	}
+	if _, err := ws.GetWebhookDelivery(ctx, second.ID+100); !errors.Is(err, storage.ErrDeliveryNotFound) {
+		t.Errorf("GetWebhookDelivery(unknown) error = %v, want ErrDeliveryNotFound", err)
+	}
+	if got, err := ws.GetWebhookDeliveryByPublicID(ctx, second.PublicID); err != nil || !reflect.DeepEqual(got, second) {
+		t.Errorf("GetWebhookDeliveryByPublicID = %+v, %v, want %+v", got, err, second)
+	}
+	if _, err := ws.GetWebhookDeliveryByPublicID(ctx, types.NewPublicID()); !errors.Is(err, storage.ErrDeliveryNotFound) {
+		t.Errorf("GetWebhookDeliveryByPublicID(unknown) error = %v, want ErrDeliveryNotFound", err)
+	}
+
+	first.Status, first.Attempts = types.DeliveryFailed, 3
+	first.ResponseStatus, first.ResponseBody, first.Error = 503, "down", "answered 503"
+	if err := ws.UpdateWebhookDelivery(ctx, first); err != nil {
+		t.Fatalf("UpdateWebhookDelivery: %v", err)
+	}
+	got, err = ws.GetWebhookDelivery(ctx, first.ID)
+	if err != nil || got.Status != types.DeliveryFailed || got.Attempts != 3 || got.ResponseStatus != 503 || got.ResponseBody != "down" || got.Error != "answered 503" || string(got.Body) != `{"id":"e1"}` {
+		t.Errorf("GetWebhookDelivery(updated) = %+v, %v, want the failure recorded", got, err)
+	}
+	if err := ws.UpdateWebhookDelivery(ctx, types.WebhookDelivery{ID: second.ID + 100, Status: types.DeliveryFailed}); !errors.Is(err, storage.ErrDeliveryNotFound) {
+		t.Errorf("UpdateWebhookDelivery(unknown) error = %v, want ErrDeliveryNotFound", err)
+	}
+
+	for _, tc := range []struct {
+		status string
+		before int64
+		limit  int
+		want   []int64
+	}{
+		{"", 0, 10, []int64{second.ID, first.ID}},
+		{"", 0, 1, []int64{second.ID}},
+		{"", second.ID, 10, []int64{first.ID}},
+		{"", first.ID, 10, nil},
+		{types.DeliveryFailed, 0, 10, []int64{first.ID}},
+		{types.DeliveryQueued, 0, 10, []int64{second.ID}},
+	} {
+		list, err := ws.ListWebhookDeliveries(ctx, hook.PublicID, tc.status, tc.before, tc.limit)
+		var ids []int64
+		for _, d := range list {
+			ids = append(ids, d.ID)
+		}
+		if err != nil || !slices.Equal(ids, tc.want) {
+			t.Errorf("ListWebhookDeliveries(%q, %d, %d) = %v, %v, want %v", tc.status, tc.before, tc.limit, ids, err, tc.want)
+		}
+	}
+
+	if err := ws.DeleteWebhookDelivery(ctx, second.ID); err != nil {
+		t.Fatalf("DeleteWebhookDelivery: %v", err)
+	}
+	if err := ws.DeleteWebhookDelivery(ctx, second.ID); !errors.Is(err, storage.ErrDeliveryNotFound) {
+		t.Errorf("DeleteWebhookDelivery(again) error = %v, want ErrDeliveryNotFound", err)
+	}
+
+	// Unsubscribing drops the subscription's dead letters with it
+	if err := ws.DeleteWebhook(ctx, hook.PublicID); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if _, err := ws.GetWebhookDelivery(ctx, first.ID); !errors.Is(err, storage.ErrDeliveryNotFound) {
+		t.Errorf("GetWebhookDelivery(after DeleteWebhook) error = %v, want ErrDeliveryNotFound", err)
+	}
+}
+
+func testEventLog(t *testing.T, s storage.Storage) {
+	log, ok := storage.As[storage.EventLog](s)
+	if !ok {
+		t.Skip("storage does not implement storage.EventLog")
+	}
+	ctx := context.Background()
+
+	if events, last, err := log.EventsAfter(ctx, types.EntityStudent, 0, 10); err != nil || len(events) != 0 || last != 0 {
+		t.Errorf("EventsAfter(empty) = %+v, %d, %v, want nothing", events, last, err)
+	}
+	// Each entity is numbered on its own
+	for i, e := range []types.FeedEvent{
+		{Entity: types.EntityStudent, Type: "student.created", Data: json.RawMessage(`{"students":[]}`)},
+		{Entity: types.EntityLoan, Type: "loan.overdue", Data: json.RawMessage(`{"loans":[]}`)},
+		{Entity: types.EntityStudent, Type: "student.updated", Data: json.RawMessage(`{"students":[]}`)},
+		{Entity: types.EntityStudent, Type: "student.deleted", Data: json.RawMessage(`{"students":[]}`)},
+	} {
+		appended, err := log.AppendEvent(ctx, e)
+		if want := []int64{1, 1, 2, 3}[i]; err != nil || appended.Seq != want || appended.CreatedAt.IsZero() {
+			t.Fatalf("AppendEvent(%s) = %+v, %v, want seq %d", e.Type, appended, err, want)
+		}
+	}
+
+	events, last, err := log.EventsAfter(ctx, types.EntityStudent, 1, 1)
+	if err != nil || last != 3 || len(events) != 1 || events[0].Seq != 2 || events[0].Type != "student.updated" || string(events[0].Data) != `{"students":[]}` {
+		t.Errorf("EventsAfter(1, limit 1) = %+v, %d, %v, want student.updated as seq 2 of 3", events, last, err)
+	}
+	if events, last, err := log.EventsAfter(ctx, types.EntityStudent, 3, 10); err != nil || len(events) != 0 || last != 3 {
+		t.Errorf("EventsAfter(3) = %+v, %d, %v, want none of 3", events, last, err)
+	}
+	if events, last, err := log.EventsAfter(ctx, types.EntityLoan, 0, 10); err != nil || len(events) != 1 || last != 1 {
+		t.Errorf("EventsAfter(loan) = %+v, %d, %v, want the loan event", events, last, err)
+	}
+}
+
+func testUsageCounter(t *testing.T, s storage.Storage) {
+	u, ok := storage.As[storage.UsageCounter](s)
+	if !ok {
+		t.Skip("storage does not implement storage.UsageCounter")
+	}
+	ctx := context.Background()
+
+	for i := range 3 {
+		n, counted, err := u.CountRequest(ctx, "a", "2026-10", 2)
+		if want := min(int64(i+1), 2); err != nil || n != want || counted != (i < 2) {
+			t.Fatalf("CountRequest #%d = %d, %v, %v, want %d, counted %v", i+1, n, counted, err, want, i < 2)
+		}
+	}
+	// Clients and months are counted apart
+	if n, counted, err := u.CountRequest(ctx, "b", "2026-10", 2); err != nil || n != 1 || !counted {
+		t.Errorf("CountRequest(b) = %d, %v, %v, want 1, counted", n, counted, err)
+	}
+	if n, counted, err := u.CountRequest(ctx, "a", "2026-11", 2); err != nil || n != 1 || !counted {
+		t.Errorf("CountRequest(next month) = %d, %v, %v, want 1, counted", n, counted, err)
+	}
+
+	usage, err := u.ListUsage(ctx, "2026-10")
+	want := []types.Usage{{Client: "a", Month: "2026-10", Requests: 2}, {Client: "b", Month: "2026-10", Requests: 1}}
+	if err != nil || !slices.Equal(usage, want) {
+		t.Errorf("ListUsage = %+v, %v, want %+v", usage, err, want)
+	}
+	if usage, err := u.ListUsage(ctx, "2026-09"); err != nil || len(usage) != 0 {
+		t.Errorf("ListUsage(empty month) = %+v, %v, want none", usage, err)
+	}
+}
+
+func testImportBatch(t *testing.T, s storage.Storage) {
+	im, ok := storage.As[storage.ImportCheckpointer](s)
+	if !ok {
+		t.Skip("storage does not implement storage.ImportCheckpointer")
+	}
+	ctx := context.Background()
+
+	// Progress is kept with the job where the storage has a queue
+	jobID := int64(1)
+	if q, ok := storage.As[storage.JobQueue](s); ok {
+		var err error
+		if jobID, err = q.EnqueueJob(ctx, "student_import", []byte(`{}`), 3, time.Now()); err != nil {
+			t.Fatalf("EnqueueJob: %v", err)
+		}
+	}
+	if p, err := im.ImportProgress(ctx, jobID); err != nil || p != (types.ImportProgress{}) {
+		t.Fatalf("ImportProgress before any batch = %+v, %v, want zero", p, err)
+	}
+	if _, err := s.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 20, PublicID: types.NewPublicID()}); err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+
+	// An email already taken, in any case, and a repeat within the batch are skipped
+	p, written, err := im.ImportBatch(ctx, jobID, []types.Student{
+		{Name: "Asha again", Email: "ASHA@example.com", Age: 20},
+		{Name: "Ravi", Email: "ravi@example.com", Age: 21},
+		{Name: "Ravi again", Email: "ravi@example.com", Age: 21},
+	}, 4)
+	if err != nil {
+		t.Fatalf("ImportBatch: %v", err)
+	}
+	if p != (types.ImportProgress{Row: 4, Imported: 1, Skipped: 2}) {
+		t.Errorf("ImportBatch progress = %+v, want row 4, 1 imported, 2 skipped", p)
+	}
+	if len(written) != 1 || written[0].Name != "Ravi" || written[0].ID == 0 || written[0].PublicID == "" {
+		t.Fatalf("ImportBatch wrote %+v, want Ravi with IDs", written)
+	}
+	if got, err := s.GetStudent(written[0].ID); err != nil || got.Email != "ravi@example.com" {
+		t.Errorf("GetStudent(%d) = %+v, %v", written[0].ID, got, err)
+	}
+
+	// The next batch adds to the progress, which reads back as committed
+	if _, _, err := im.ImportBatch(ctx, jobID, []types.Student{{Name: "Meera", Email: "meera@example.com", Age: 22}}, 6); err != nil {
+		t.Fatalf("ImportBatch: %v", err)
+	}
+	want := types.ImportProgress{Row: 6, Imported: 2, Skipped: 2}
+	if p, err := im.ImportProgress(ctx, jobID); err != nil || p != want {
+		t.Errorf("ImportProgress = %+v, %v, want %+v", p, err, want)
+	}
+	if n, err := s.GetStudentsCount(); err != nil || n != 3 {
+		t.Errorf("GetStudentsCount = %d, %v, want 3", n, err)
+	}
+}
+
+func testImportStaging(t *testing.T, s storage.Storage) {
+	st, ok := storage.As[storage.ImportStager](s)
+	if !ok {
+		t.Skip("storage does not implement storage.ImportStager")
+	}
+	ctx := context.Background()
+	const jobID = 7
+
+	if _, err := st.PreviewImport(ctx, jobID, 10); !errors.Is(err, storage.ErrNothingStaged) {
+		t.Fatalf("PreviewImport with nothing staged error = %v, want ErrNothingStaged", err)
+	}
+	asha, err := s.CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 20, PublicID: types.NewPublicID()})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	ashaStudent, _ := s.GetStudent(asha)
+
+	// Staged in two batches, not in row order
+	if err := st.StageImport(ctx, jobID, []types.StagedStudent{
+		{Row: 5, Name: "Ravi again", Email: "RAVI@example.com", Age: 21},
+		{Row: 2, Name: "Ravi", Email: "ravi@example.com", Age: 21, Phone: "+919876543210", CustomFields: map[string]any{"house": "red"}},
+	}); err != nil {
+		t.Fatalf("StageImport: %v", err)
+	}
+	if err := st.StageImport(ctx, jobID, []types.StagedStudent{
+		{Row: 1, Name: "Asha again", Email: "Asha@example.com", Age: 20},
+		{Row: 7, Name: "Meera", Email: "meera@example.com", DateOfBirth: "2004-05-06"},
+	}); err != nil {
+		t.Fatalf("StageImport: %v", err)
+	}
+	// Another job's rows are its own
+	if err := st.StageImport(ctx, jobID+1, []types.StagedStudent{{Row: 1, Name: "Ravi", Email: "ravi@example.com", Age: 21}}); err != nil {
+		t.Fatalf("StageImport: %v", err)
+	}
+
+	preview, err := st.PreviewImport(ctx, jobID, 1)
+	if err != nil {
+		t.Fatalf("PreviewImport: %v", err)
+	}
+	if preview.Staged != 4 || preview.New != 2 || preview.Duplicates != 1 || preview.Conflicts != 1 {
+		t.Errorf("PreviewImport counts = %d staged, %d new, %d duplicates, %d conflicts, want 4, 2, 1, 1",
+			preview.Staged, preview.New, preview.Duplicates, preview.Conflicts)
+	}
+	// Lists are capped at limit, in row order
+	if len(preview.Create) != 1 || preview.Create[0].Row != 2 || preview.Create[0].Phone != "+919876543210" || preview.Create[0].CustomFields["house"] != "red" {
+		t.Errorf("PreviewImport create = %+v, want row 2 only, as staged", preview.Create)
+	}
+	if want := []types.ImportConflict{{Row: 5, Email: "RAVI@example.com", FirstRow: 2}}; !reflect.DeepEqual(preview.Duplicate, want) {
+		t.Errorf("PreviewImport duplicate = %+v, want %+v", preview.Duplicate, want)
+	}
+	if want := []types.ImportConflict{{Row: 1, Email: "Asha@example.com", StudentID: ashaStudent.PublicID}}; !reflect.DeepEqual(preview.Conflict, want) {
+		t.Errorf("PreviewImport conflict = %+v, want %+v", preview.Conflict, want)
+	}
+
+	rows, err := st.StagedRows(ctx, jobID, 1, 2)
+	if err != nil {
+		t.Fatalf("StagedRows: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Row != 2 || rows[1].Row != 5 {
+		t.Errorf("StagedRows after row 1 = %+v, want rows 2 and 5", rows)
+	}
+	if rows, err := st.StagedRows(ctx, jobID, 5, 10); err != nil || len(rows) != 1 || rows[0].DateOfBirth != "2004-05-06" {
+		t.Errorf("StagedRows after row 5 = %+v, %v, want Meera's row", rows, err)
+	}
+
+	if err := st.DiscardImport(ctx, jobID); err != nil {
+		t.Fatalf("DiscardImport: %v", err)
+	}
+	if err := st.DiscardImport(ctx, jobID); !errors.Is(err, storage.ErrNothingStaged) {
+		t.Errorf("DiscardImport again error = %v, want ErrNothingStaged", err)
+	}
+	if preview, err := st.PreviewImport(ctx, jobID+1, 10); err != nil || preview.New != 1 {
+		t.Errorf("PreviewImport of the other job = %+v, %v, want its row left", preview, err)
+	}
+	if n, err := s.GetStudentsCount(); err != nil || n != 1 {
+		t.Errorf("GetStudentsCount = %d, %v, want 1: staging imports nothing", n, err)
+	}
+}
@@ -0,0 +1,2161 @@
+// Package storagetest provides a configurable in-memory storage.Storage for tests, and a
+// conformance suite that every Storage backend must pass.
+package storagetest
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/filter"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Method names used for error programming and call recording
+const (
+	MethodCreateStudent    = "CreateStudent"
+	MethodCreateStudents   = "CreateStudents"
+	MethodGetStudent       = "GetStudent"
+	MethodGetByPublicID    = "GetStudentByPublicID"
+	MethodGetStudentsList  = "GetStudentsList"
+	MethodGetStudentsCount = "GetStudentsCount"
+	MethodStreamStudents   = "StreamStudents"
+	MethodMergeStudents    = "MergeStudents"
+	MethodFilterStudents   = "FilterStudents"
+	MethodLinkStudents     = "LinkStudents"
+	MethodUnlinkStudents   = "UnlinkStudents"
+	MethodRelationships    = "StudentRelationships"
+	MethodAddBook          = "AddBook"
+	MethodGetBook          = "GetBookByPublicID"
+	MethodListBooks        = "ListBooks"
+	MethodCheckOutBook     = "CheckOutBook"
+	MethodReturnBook       = "ReturnBook"
+	MethodOverdueLoans     = "OverdueLoans"
+	MethodAddHostel        = "AddHostel"
+	MethodGetHostel        = "GetHostelByPublicID"
+	MethodAddRoom          = "AddRoom"
+	MethodGetRoom          = "GetRoomByPublicID"
+	MethodHostelRooms      = "HostelRooms"
+	MethodAllocateRoom     = "AllocateRoom"
+	MethodVacateRoom       = "VacateRoom"
+	MethodRoomAllocations  = "RoomAllocations"
+	MethodHostelOccupancy  = "HostelOccupancy"
+	MethodAddRoute         = "AddRoute"
+	MethodGetRoute         = "GetRouteByPublicID"
+	MethodListRoutes       = "ListRoutes"
+	MethodAddStop          = "AddStop"
+	MethodAssignRoute      = "AssignRoute"
+	MethodUnassignRoute    = "UnassignRoute"
+	MethodRouteManifest    = "RouteManifest"
+	MethodGraduateStudent  = "GraduateStudent"
+	MethodGetAlumnus       = "GetAlumnusByPublicID"
+	MethodListAlumni       = "ListAlumni"
+	MethodSubmitApp        = "SubmitApplication"
+	MethodGetApp           = "GetApplicationByPublicID"
+	MethodListApps         = "ListApplications"
+	MethodSetAppStatus     = "SetApplicationStatus"
+	MethodConvertApp       = "ConvertApplication"
+	MethodPostAnnouncement = "PostAnnouncement"
+	MethodGetAnnouncement  = "GetAnnouncementByPublicID"
+	MethodPendingDelivery  = "PendingDeliveries"
+	MethodSetDelivery      = "SetDeliveryStatus"
+	MethodReportDelivery   = "ReportDelivery"
+	MethodStudentAnnounce  = "StudentAnnouncements"
+	MethodDefineField      = "DefineCustomField"
+	MethodListFields       = "ListCustomFields"
+	MethodDeleteField      = "DeleteCustomField"
+	MethodUpdateStudent    = "UpdateStudent"
+	MethodSaveView         = "SaveView"
+	MethodGetView          = "GetView"
+	MethodListViews        = "ListViews"
+	MethodDeleteView       = "DeleteView"
+	MethodStudentChanges   = "StudentChanges"
+	MethodAppendEvent      = "AppendEvent"
+	MethodEventsAfter      = "EventsAfter"
+	MethodPatchStudents    = "PatchStudents"
+	MethodCreateWebhook    = "CreateWebhook"
+	MethodGetWebhook       = "GetWebhook"
+	MethodListWebhooks     = "ListWebhooks"
+	MethodWebhooksFor      = "WebhooksFor"
+	MethodRotateSecret     = "RotateWebhookSecret"
+	MethodDeleteWebhook    = "DeleteWebhook"
+	MethodCreateDelivery   = "CreateWebhookDelivery"
+	MethodGetDelivery      = "GetWebhookDelivery"
+	MethodGetDeliveryByID  = "GetWebhookDeliveryByPublicID"
+	MethodListDeliveries   = "ListWebhookDeliveries"
+	MethodUpdateDelivery   = "UpdateWebhookDelivery"
+	MethodDeleteDelivery   = "DeleteWebhookDelivery"
+	MethodCountRequest     = "CountRequest"
+	MethodListUsage        = "ListUsage"
+	MethodImportProgress   = "ImportProgress"
+	MethodImportBatch      = "ImportBatch"
+	MethodStageImport      = "StageImport"
+	MethodPreviewImport    = "PreviewImport"
+	MethodStagedRows       = "StagedRows"
+	MethodDiscardImport    = "DiscardImport"
+)
+
+// Call records one invocation of a Fake method
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// Fake is an in-memory storage.Storage.
+// Errors can be programmed per method, latency can be injected, and every call is recorded.
+// The zero value is not usable; create one with NewFake.
+type Fake struct {
+	mu       sync.Mutex
+	students map[int64]types.Student
+	// merged maps merged (removed) student IDs to the ID they were merged into
+	merged map[int64]int64
+	// links holds each link's kind under its pair of IDs, smaller first
+	links map[[2]int64]string
+	books map[int64]types.Book
+	// loans are kept in the order they were made; a loan's ID is its index plus one
+	loans    []types.Loan
+	nextID   int64
+	errs     map[string]error
+	failNext map[string][]error
+	latency  time.Duration
+	calls    []Call
+	clock    clock.Clock
+
+	hostels map[int64]types.Hostel
+	rooms   map[int64]types.Room
+	// allocations are kept in the order they were made
+	allocations []types.Allocation
+
+	// routes hold their stops; stop IDs are numbered across all routes
+	routes     map[int64]types.BusRoute
+	lastStopID int64
+	riders     []types.RouteAssignment
+
+	// alumni are graduated students, removed from students
+	alumni map[int64]types.Alumnus
+
+	// applications are numbered from 1 in the order they were submitted; converted maps an
+	// application's ID to the student it became
+	applications []types.Application
+	converted    map[int64]int64
+
+	// announcements are numbered from 1 in the order they were posted; deliveries maps
+	// (announcement, student) to the delivery's status, message ID and error
+	announcements []types.Announcement
+	deliveries    map[[2]int64]types.Delivery
+
+	// fields are the custom field definitions by name
+	fields map[string]types.CustomField
+	// views are the saved views by name
+	views map[string]types.View
+	// webhooks are kept in the order they were created; IDs are never reused
+	webhooks      []types.Webhook
+	lastWebhookID int64
+	// webhookDeliveries are kept in id order
+	webhookDeliveries   []types.WebhookDelivery
+	lastWebhookDelivery int64
+
+	// created and changed are when each student was created and last changed, to the second;
+	// removed keeps the deletion of students merged away or graduated
+	created map[int64]time.Time
+	changed map[int64]time.Time
+	removed map[int64]types.StudentChange
+	// feed is the event log by entity, each in seq order from 1
+	feed map[string][]types.FeedEvent
+	// usage counts requests by month and client
+	usage map[[2]string]int64
+	// imports holds each import job's progress; the Fake keeps no jobs, so any ID will do
+	imports map[int64]types.ImportProgress
+	// staged holds each previewed import's rows, in the order they were staged
+	staged map[int64][]types.StagedStudent
+}
+
+var (
+	_ storage.Storage            = (*Fake)(nil)
+	_ storage.Resetter           = (*Fake)(nil)
+	_ storage.Merger             = (*Fake)(nil)
+	_ storage.Filterer           = (*Fake)(nil)
+	_ storage.Relater            = (*Fake)(nil)
+	_ storage.Library            = (*Fake)(nil)
+	_ storage.Housing            = (*Fake)(nil)
+	_ storage.Transport          = (*Fake)(nil)
+	_ storage.Alumni             = (*Fake)(nil)
+	_ storage.Admissions         = (*Fake)(nil)
+	_ storage.Announcements      = (*Fake)(nil)
+	_ storage.CustomFields       = (*Fake)(nil)
+	_ storage.Views              = (*Fake)(nil)
+	_ storage.Updater            = (*Fake)(nil)
+	_ storage.Changes            = (*Fake)(nil)
+	_ storage.BulkUpdater        = (*Fake)(nil)
+	_ storage.Webhooks           = (*Fake)(nil)
+	_ storage.EventLog           = (*Fake)(nil)
+	_ storage.UsageCounter       = (*Fake)(nil)
+	_ storage.ImportCheckpointer = (*Fake)(nil)
+	_ storage.ImportStager       = (*Fake)(nil)
+)
+
+// NewFake returns an empty Fake
+func NewFake() *Fake {
+	return &Fake{
+		students:   make(map[int64]types.Student),
+		merged:     make(map[int64]int64),
+		links:      make(map[[2]int64]string),
+		books:      make(map[int64]types.Book),
+		hostels:    make(map[int64]types.Hostel),
+		rooms:      make(map[int64]types.Room),
+		routes:     make(map[int64]types.BusRoute),
+		alumni:     make(map[int64]types.Alumnus),
+		converted:  make(map[int64]int64),
+		deliveries: make(map[[2]int64]types.Delivery),
+		fields:     make(map[string]types.CustomField),
+		views:      make(map[string]types.View),
+		created:    make(map[int64]time.Time),
+		changed:    make(map[int64]time.Time),
+		removed:    make(map[int64]types.StudentChange),
+		feed:       make(map[string][]types.FeedEvent),
+		usage:      make(map[[2]string]int64),
+		imports:    make(map[int64]types.ImportProgress),
+		staged:     make(map[int64][]types.StagedStudent),
+		errs:       make(map[string]error),
+		failNext:   make(map[string][]error),
+		clock:      clock.Real{},
+	}
+}
+
+// SetError makes every call to method fail with err until cleared with SetError(method, nil)
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// FailNext makes the next call to method fail with err (queued; call repeatedly to fail several)
+func (f *Fake) FailNext(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failNext[method] = append(f.failNext[method], err)
+}
+
+// SetLatency delays every call by d, to exercise timeouts and slow-backend handling
+func (f *Fake) SetLatency(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = d
+}
+
+// SetClock replaces the clock used to derive ages on read and to stamp changes
+func (f *Fake) SetClock(c clock.Clock) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clock = clock.OrReal(c)
+}
+
+// Calls returns a copy of the recorded calls in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallCount returns how many times method was called
+func (f *Fake) CallCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if c.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// Put stores a student as-is (including its ID), bypassing error programming and recording.
+// Useful to arrange test fixtures.
+func (f *Fake) Put(student types.Student) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if student.ID == 0 {
+		f.nextID++
+		student.ID = f.nextID
+	}
+	if student.ID > f.nextID {
+		f.nextID = student.ID
+	}
+	if student.PublicID == "" {
+		student.PublicID = types.NewPublicID()
+	}
+	f.students[student.ID] = student
+	f.touch(student.ID, true)
+}
+
+// enter records the call, sleeps for the configured latency, and returns the programmed error (if any).
+// The lock is not held while sleeping so concurrent calls overlap like they would against a real backend.
+func (f *Fake) enter(method string, args ...any) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	latency := f.latency
+
+	var err error
+	if queued := f.failNext[method]; len(queued) > 0 {
+		err = queued[0]
+		f.failNext[method] = queued[1:]
+	} else {
+		err = f.errs[method]
+	}
+	f.mu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	return err
+}
+
+func (f *Fake) CreateStudent(student types.Student) (int64, error) {
+	if err := f.enter(MethodCreateStudent, student); err != nil {
+		return 0, err
+	}
+	if student.PublicID == "" {
+		student.PublicID = types.NewPublicID()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	student.ID = f.nextID
+	student.CustomFields = maps.Clone(student.CustomFields)
+	f.students[f.nextID] = student
+	f.touch(f.nextID, true)
+	return f.nextID, nil
+}
+
+func (f *Fake) CreateStudents(ctx context.Context, students []types.Student) ([]int64, error) {
+	if err := f.enter(MethodCreateStudents, students); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make([]int64, 0, len(students))
+	for i, st := range students {
+		st.ID = f.nextID + int64(i) + 1
+		if st.PublicID == "" {
+			st.PublicID = types.NewPublicID()
+		}
+		ids = append(ids, st.ID)
+		if !storage.IsDryRun(ctx) {
+			f.students[st.ID] = st
+			f.touch(st.ID, true)
+		}
+	}
+	if !storage.IsDryRun(ctx) {
+		f.nextID += int64(len(students))
+	}
+	return ids, nil
+}
+
+func (f *Fake) GetStudent(id int64) (types.Student, error) {
+	if err := f.enter(MethodGetStudent, id); err != nil {
+		return types.Student{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	student, ok := f.students[id]
+	if !ok {
+		return types.Student{}, storage.ErrNotFound
+	}
+	student.DeriveAge(f.clock.Now())
+	return student, nil
+}
+
+func (f *Fake) GetStudentByPublicID(publicID string) (types.Student, error) {
+	if err := f.enter(MethodGetByPublicID, publicID); err != nil {
+		return types.Student{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, student := range f.students {
+		if student.PublicID == publicID {
+			student.DeriveAge(f.clock.Now())
+			return student, nil
+		}
+	}
+	return types.Student{}, storage.ErrNotFound
+}
+
+func (f *Fake) GetStudentsList(offset, limit int) ([]types.Student, error) {
+	if err := f.enter(MethodGetStudentsList, offset, limit); err != nil {
+		return nil, err
+	}
+
+	sorted := f.sorted()
+	if offset >= len(sorted) {
+		return nil, nil
+	}
+	end := min(offset+limit, len(sorted))
+	return sorted[offset:end], nil
+}
+
+func (f *Fake) GetStudentsCount() (int64, error) {
+	if err := f.enter(MethodGetStudentsCount); err != nil {
+		return 0, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int64(len(f.students)), nil
+}
+
+func (f *Fake) StreamStudents(ctx context.Context, fn func(types.Student) error) error {
+	if err := f.enter(MethodStreamStudents); err != nil {
+		return err
+	}
+
+	for _, student := range f.sorted() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(student); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FilterStudents evaluates the filter in memory with filter.Match
+func (f *Fake) FilterStudents(ctx context.Context, flt types.Filter, offset, limit int) ([]types.Student, int64, error) {
+	if err := f.enter(MethodFilterStudents, flt, offset, limit); err != nil {
+		return nil, 0, err
+	}
+
+	now := f.clock.Now()
+	var matched []types.Student
+	for _, student := range f.sorted() {
+		if filter.Match(flt, student, now) {
+			matched = append(matched, student)
+		}
+	}
+	if offset >= len(matched) {
+		return nil, int64(len(matched)), nil
+	}
+	return matched[offset:min(offset+limit, len(matched))], int64(len(matched)), nil
+}
+
+// MergeStudents removes mergeID, copying a date of birth, phone or custom field value keepID lacks
+func (f *Fake) MergeStudents(ctx context.Context, keepID, mergeID int64) (types.Student, error) {
+	if err := f.enter(MethodMergeStudents, keepID, mergeID); err != nil {
+		return types.Student{}, err
+	}
+	if keepID == mergeID {
+		return types.Student{}, storage.ErrInvalidData
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keep, ok1 := f.students[keepID]
+	dup, ok2 := f.students[mergeID]
+	if !ok1 || !ok2 {
+		return types.Student{}, storage.ErrNotFound
+	}
+	if keep.DateOfBirth == "" {
+		keep.DateOfBirth = dup.DateOfBirth
+	}
+	if keep.Phone == "" {
+		keep.Phone = dup.Phone
+	}
+	for name, v := range dup.CustomFields {
+		if _, ok := keep.CustomFields[name]; !ok {
+			if keep.CustomFields == nil {
+				keep.CustomFields = make(map[string]any)
+			}
+			keep.CustomFields[name] = v
+		}
+	}
+	f.students[keepID] = keep
+	f.touch(keepID, false)
+	f.remove(mergeID)
+	for id, into := range f.merged {
+		if into == mergeID {
+			f.merged[id] = keepID
+		}
+	}
+	f.merged[mergeID] = keepID
+	for pair, kind := range f.links {
+		if pair[0] != mergeID && pair[1] != mergeID {
+			continue
+		}
+		delete(f.links, pair)
+		other := pair[0] + pair[1] - mergeID
+		if other == keepID {
+			continue
+		}
+		if _, ok := f.links[linkKey(keepID, other)]; !ok {
+			f.links[linkKey(keepID, other)] = kind
+		}
+	}
+
+	for i := range f.loans {
+		if f.loans[i].StudentID == mergeID {
+			f.loans[i].StudentID = keepID
+		}
+	}
+	for app, student := range f.converted {
+		if student == mergeID {
+			f.converted[app] = keepID
+		}
+	}
+	for key, d := range f.deliveries {
+		if key[1] == mergeID {
+			delete(f.deliveries, key)
+			if _, ok := f.deliveries[[2]int64{key[0], keepID}]; !ok {
+				d.StudentID = keepID
+				f.deliveries[[2]int64{key[0], keepID}] = d
+			}
+		}
+	}
+	if f.allocation(keepID) >= 0 {
+		f.allocations = slices.DeleteFunc(f.allocations, func(a types.Allocation) bool { return a.StudentID == mergeID })
+	} else if i := f.allocation(mergeID); i >= 0 {
+		f.allocations[i].StudentID = keepID
+	}
+	if f.rider(keepID) >= 0 {
+		f.riders = slices.DeleteFunc(f.riders, func(r types.RouteAssignment) bool { return r.StudentID == mergeID })
+	} else if i := f.rider(mergeID); i >= 0 {
+		f.riders[i].StudentID = keepID
+	}
+
+	keep.DeriveAge(f.clock.Now())
+	return keep, nil
+}
+
+// UpdateStudent keeps the student's ID and public ID and replaces the rest
+func (f *Fake) UpdateStudent(ctx context.Context, student types.Student) (types.Student, []string, error) {
+	if err := f.enter(MethodUpdateStudent, student); err != nil {
+		return types.Student{}, nil, err
+	}
+
+	now := f.clock.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	old, ok := f.students[student.ID]
+	if !ok {
+		return types.Student{}, nil, storage.ErrNotFound
+	}
+	updated := types.Student{
+		ID:           old.ID,
+		PublicID:     old.PublicID,
+		Name:         student.Name,
+		Email:        student.Email,
+		Age:          student.Age,
+		DateOfBirth:  student.DateOfBirth,
+		Phone:        student.Phone,
+		CustomFields: maps.Clone(student.CustomFields),
+	}
+	if len(updated.CustomFields) == 0 {
+		updated.CustomFields = nil
+	}
+	if !storage.IsDryRun(ctx) {
+		f.students[student.ID] = updated
+	}
+
+	old.DeriveAge(now)
+	updated.DeriveAge(now)
+	changed := types.ChangedFields(old, updated)
+	if len(changed) > 0 && !storage.IsDryRun(ctx) {
+		f.touch(student.ID, false)
+	}
+	return updated, changed, nil
+}
+
+// MergedInto reports the student id was merged into, if it was
+func (f *Fake) MergedInto(id int64) (int64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	into, ok := f.merged[id]
+	return into, ok
+}
+
+// Reset removes every student and restarts IDs at 1
+func (f *Fake) Reset(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clear(f.students)
+	clear(f.merged)
+	clear(f.links)
+	clear(f.books)
+	f.loans = nil
+	clear(f.hostels)
+	clear(f.rooms)
+	f.allocations = nil
+	clear(f.routes)
+	f.lastStopID = 0
+	f.riders = nil
+	clear(f.alumni)
+	f.applications = nil
+	clear(f.converted)
+	f.announcements = nil
+	clear(f.deliveries)
+	clear(f.fields)
+	clear(f.views)
+	f.webhooks = nil
+	f.lastWebhookID = 0
+	f.webhookDeliveries = nil
+	f.lastWebhookDelivery = 0
+	clear(f.created)
+	clear(f.changed)
+	clear(f.removed)
+	clear(f.feed)
+	clear(f.usage)
+	clear(f.imports)
+	clear(f.staged)
+	f.nextID = 0
+	return nil
+}
+
+// LinkStudents stores the link once under linkKey
+func (f *Fake) LinkStudents(ctx context.Context, a, b int64, kind string) error {
+	if err := f.enter(MethodLinkStudents, a, b, kind); err != nil {
+		return err
+	}
+	if a == b || !slices.Contains(types.RelationshipKinds, kind) {
+		return storage.ErrInvalidData
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok1 := f.students[a]
+	_, ok2 := f.students[b]
+	if !ok1 || !ok2 {
+		return storage.ErrNotFound
+	}
+	if _, ok := f.links[linkKey(a, b)]; ok {
+		return storage.ErrDuplicate
+	}
+	f.links[linkKey(a, b)] = kind
+	return nil
+}
+
+func (f *Fake) UnlinkStudents(ctx context.Context, a, b int64) error {
+	if err := f.enter(MethodUnlinkStudents, a, b); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.links[linkKey(a, b)]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(f.links, linkKey(a, b))
+	return nil
+}
+
+func (f *Fake) StudentRelationships(ctx context.Context, ids []int64) (map[int64][]types.Relationship, error) {
+	if err := f.enter(MethodRelationships, ids); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	links := make(map[int64][]types.Relationship)
+	for _, id := range ids {
+		for pair, kind := range f.links {
+			if pair[0] != id && pair[1] != id {
+				continue
+			}
+			other := f.students[pair[0]+pair[1]-id]
+			links[id] = append(links[id], types.Relationship{Kind: kind, StudentID: other.ID, PublicID: other.PublicID, Name: other.Name})
+		}
+		slices.SortFunc(links[id], func(a, b types.Relationship) int { return cmp.Compare(a.StudentID, b.StudentID) })
+	}
+	return links, nil
+}
+
+// AddBook numbers books like students, from 1
+func (f *Fake) AddBook(ctx context.Context, book types.Book) (types.Book, error) {
+	if err := f.enter(MethodAddBook, book); err != nil {
+		return types.Book{}, err
+	}
+	if book.PublicID == "" {
+		book.PublicID = types.NewPublicID()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	book.ID = int64(len(f.books)) + 1
+	book.OnLoan = false
+	f.books[book.ID] = book
+	return book, nil
+}
+
+func (f *Fake) GetBookByPublicID(ctx context.Context, publicID string) (types.Book, error) {
+	if err := f.enter(MethodGetBook, publicID); err != nil {
+		return types.Book{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, book := range f.books {
+		if book.PublicID == publicID {
+			book.OnLoan = f.openLoan(book.ID) >= 0
+			return book, nil
+		}
+	}
+	return types.Book{}, storage.ErrBookNotFound
+}
+
+func (f *Fake) ListBooks(ctx context.Context, offset, limit int) ([]types.Book, int64, error) {
+	if err := f.enter(MethodListBooks, offset, limit); err != nil {
+		return nil, 0, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	total := int64(len(f.books))
+	var books []types.Book
+	for id := int64(offset) + 1; id <= min(int64(offset+limit), total); id++ {
+		book := f.books[id]
+		book.OnLoan = f.openLoan(id) >= 0
+		books = append(books, book)
+	}
+	return books, total, nil
+}
+
+func (f *Fake) CheckOutBook(ctx context.Context, bookID, studentID int64, due string) (types.Loan, error) {
+	if err := f.enter(MethodCheckOutBook, bookID, studentID, due); err != nil {
+		return types.Loan{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.books[bookID]; !ok {
+		return types.Loan{}, storage.ErrBookNotFound
+	}
+	if _, ok := f.students[studentID]; !ok {
+		return types.Loan{}, storage.ErrNotFound
+	}
+	if f.openLoan(bookID) >= 0 {
+		return types.Loan{}, storage.ErrOnLoan
+	}
+	f.loans = append(f.loans, types.Loan{
+		ID:           int64(len(f.loans)) + 1,
+		BookID:       bookID,
+		StudentID:    studentID,
+		CheckedOutAt: f.clock.Now().UTC().Truncate(time.Second),
+		DueDate:      due,
+	})
+	return f.loanView(len(f.loans) - 1), nil
+}
+
+func (f *Fake) ReturnBook(ctx context.Context, bookID int64) (types.Loan, error) {
+	if err := f.enter(MethodReturnBook, bookID); err != nil {
+		return types.Loan{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.openLoan(bookID)
+	if i < 0 {
+		return types.Loan{}, storage.ErrNotOnLoan
+	}
+	now := f.clock.Now().UTC().Truncate(time.Second)
+	f.loans[i].ReturnedAt = &now
+	return f.loanView(i), nil
+}
+
+func (f *Fake) OverdueLoans(ctx context.Context, asOf time.Time) ([]types.Loan, error) {
+	if err := f.enter(MethodOverdueLoans, asOf); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	today := asOf.UTC().Format(types.DateLayout)
+	var loans []types.Loan
+	for i, loan := range f.loans {
+		if loan.ReturnedAt == nil && loan.DueDate < today {
+			loan = f.loanView(i)
+			loan.DaysOverdue = types.DaysOverdue(loan.DueDate, asOf)
+			loans = append(loans, loan)
+		}
+	}
+	slices.SortStableFunc(loans, func(a, b types.Loan) int { return cmp.Compare(a.DueDate, b.DueDate) })
+	return loans, nil
+}
+
+// openLoan returns the index of the book's open loan, or -1. f.mu must be held.
+func (f *Fake) openLoan(bookID int64) int {
+	for i, loan := range f.loans {
+		if loan.BookID == bookID && loan.ReturnedAt == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// loanView returns loan i with its book and student filled in. f.mu must be held.
+func (f *Fake) loanView(i int) types.Loan {
+	loan := f.loans[i]
+	book, student := f.books[loan.BookID], f.students[loan.StudentID]
+	if alumnus, ok := f.alumni[loan.StudentID]; ok {
+		// Graduates keep their loans until the books come back
+		student = alumnus.Student
+	}
+	loan.BookPublicID, loan.Title = book.PublicID, book.Title
+	loan.StudentPublicID, loan.StudentName, loan.StudentEmail = student.PublicID, student.Name, student.Email
+	return loan
+}
+
+// AddHostel numbers hostels like students, from 1
+func (f *Fake) AddHostel(ctx context.Context, hostel types.Hostel) (types.Hostel, error) {
+	if err := f.enter(MethodAddHostel, hostel); err != nil {
+		return types.Hostel{}, err
+	}
+	if hostel.PublicID == "" {
+		hostel.PublicID = types.NewPublicID()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, h := range f.hostels {
+		if h.Name == hostel.Name {
+			return types.Hostel{}, storage.ErrDuplicate
+		}
+	}
+	hostel.ID = int64(len(f.hostels)) + 1
+	f.hostels[hostel.ID] = hostel
+	return hostel, nil
+}
+
+func (f *Fake) GetHostelByPublicID(ctx context.Context, publicID string) (types.Hostel, error) {
+	if err := f.enter(MethodGetHostel, publicID); err != nil {
+		return types.Hostel{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, h := range f.hostels {
+		if h.PublicID == publicID {
+			return h, nil
+		}
+	}
+	return types.Hostel{}, storage.ErrHostelNotFound
+}
+
+// AddRoom numbers rooms like students, from 1
+func (f *Fake) AddRoom(ctx context.Context, hostelID int64, room types.Room) (types.Room, error) {
+	if err := f.enter(MethodAddRoom, hostelID, room); err != nil {
+		return types.Room{}, err
+	}
+	if room.PublicID == "" {
+		room.PublicID = types.NewPublicID()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.hostels[hostelID]; !ok {
+		return types.Room{}, storage.ErrHostelNotFound
+	}
+	for _, r := range f.rooms {
+		if r.HostelID == hostelID && r.Number == room.Number {
+			return types.Room{}, storage.ErrDuplicate
+		}
+	}
+	room.ID = int64(len(f.rooms)) + 1
+	room.HostelID = hostelID
+	f.rooms[room.ID] = room
+	return f.roomView(room.ID), nil
+}
+
+func (f *Fake) GetRoomByPublicID(ctx context.Context, publicID string) (types.Room, error) {
+	if err := f.enter(MethodGetRoom, publicID); err != nil {
+		return types.Room{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, r := range f.rooms {
+		if r.PublicID == publicID {
+			return f.roomView(id), nil
+		}
+	}
+	return types.Room{}, storage.ErrRoomNotFound
+}
+
+func (f *Fake) HostelRooms(ctx context.Context, hostelID int64) ([]types.Room, error) {
+	if err := f.enter(MethodHostelRooms, hostelID); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var rooms []types.Room
+	for id, r := range f.rooms {
+		if r.HostelID == hostelID {
+			rooms = append(rooms, f.roomView(id))
+		}
+	}
+	slices.SortFunc(rooms, func(a, b types.Room) int { return cmp.Compare(a.Number, b.Number) })
+	return rooms, nil
+}
+
+// AllocateRoom checks capacity and allocates under f.mu, so it is as atomic as the real thing
+func (f *Fake) AllocateRoom(ctx context.Context, roomID, studentID int64) (types.Allocation, error) {
+	if err := f.enter(MethodAllocateRoom, roomID, studentID); err != nil {
+		return types.Allocation{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	room, ok := f.rooms[roomID]
+	if !ok {
+		return types.Allocation{}, storage.ErrRoomNotFound
+	}
+	if _, ok := f.students[studentID]; !ok {
+		return types.Allocation{}, storage.ErrNotFound
+	}
+	if f.allocation(studentID) >= 0 {
+		return types.Allocation{}, storage.ErrHasRoom
+	}
+	if f.roomView(roomID).Occupied >= room.Capacity {
+		return types.Allocation{}, storage.ErrRoomFull
+	}
+	f.allocations = append(f.allocations, types.Allocation{
+		RoomID:      roomID,
+		StudentID:   studentID,
+		AllocatedAt: f.clock.Now().UTC().Truncate(time.Second),
+	})
+	return f.allocationView(len(f.allocations) - 1), nil
+}
+
+func (f *Fake) VacateRoom(ctx context.Context, roomID, studentID int64) error {
+	if err := f.enter(MethodVacateRoom, roomID, studentID); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.allocation(studentID)
+	if i < 0 || f.allocations[i].RoomID != roomID {
+		return storage.ErrNotFound
+	}
+	f.allocations = slices.Delete(f.allocations, i, i+1)
+	return nil
+}
+
+func (f *Fake) RoomAllocations(ctx context.Context, roomID int64) ([]types.Allocation, error) {
+	if err := f.enter(MethodRoomAllocations, roomID); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var allocations []types.Allocation
+	for i, a := range f.allocations {
+		if a.RoomID == roomID {
+			allocations = append(allocations, f.allocationView(i))
+		}
+	}
+	return allocations, nil
+}
+
+func (f *Fake) HostelOccupancy(ctx context.Context) ([]types.HostelOccupancy, error) {
+	if err := f.enter(MethodHostelOccupancy); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var report []types.HostelOccupancy
+	for _, h := range f.hostels {
+		o := types.HostelOccupancy{HostelPublicID: h.PublicID, Name: h.Name}
+		for id, r := range f.rooms {
+			if r.HostelID == h.ID {
+				o.Rooms++
+				o.Capacity += r.Capacity
+				o.Occupied += f.roomView(id).Occupied
+			}
+		}
+		o.Vacant = o.Capacity - o.Occupied
+		report = append(report, o)
+	}
+	slices.SortFunc(report, func(a, b types.HostelOccupancy) int { return cmp.Compare(a.Name, b.Name) })
+	return report, nil
+}
+
+// allocation returns the index of the student's allocation, or -1. f.mu must be held.
+func (f *Fake) allocation(studentID int64) int {
+	return slices.IndexFunc(f.allocations, func(a types.Allocation) bool { return a.StudentID == studentID })
+}
+
+// roomView returns room id with its hostel and occupancy filled in. f.mu must be held.
+func (f *Fake) roomView(id int64) types.Room {
+	room := f.rooms[id]
+	room.HostelPublicID = f.hostels[room.HostelID].PublicID
+	room.Occupied = 0
+	for _, a := range f.allocations {
+		if a.RoomID == id {
+			room.Occupied++
+		}
+	}
+	return room
+}
+
+// allocationView returns allocation i with its room and student filled in. f.mu must be held.
+func (f *Fake) allocationView(i int) types.Allocation {
+	a := f.allocations[i]
+	room, student := f.rooms[a.RoomID], f.students[a.StudentID]
+	a.RoomPublicID, a.RoomNumber = room.PublicID, room.Number
+	a.StudentPublicID, a.StudentName = student.PublicID, student.Name
+	return a
+}
+
+// AddRoute numbers routes like students, from 1
+func (f *Fake) AddRoute(ctx context.Context, route types.BusRoute) (types.BusRoute, error) {
+	if err := f.enter(MethodAddRoute, route); err != nil {
+		return types.BusRoute{}, err
+	}
+	if route.PublicID == "" {
+		route.PublicID = types.NewPublicID()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.routes {
+		if r.Name == route.Name {
+			return types.BusRoute{}, storage.ErrDuplicate
+		}
+	}
+	route.ID = int64(len(f.routes)) + 1
+	stops := route.Stops
+	route.Stops = nil
+	for _, stop := range stops {
+		route.Stops = append(route.Stops, f.newStop(stop, len(route.Stops)+1))
+	}
+	route.Riders = 0
+	f.routes[route.ID] = route
+	return f.routeView(route.ID), nil
+}
+
+func (f *Fake) GetRouteByPublicID(ctx context.Context, publicID string) (types.BusRoute, error) {
+	if err := f.enter(MethodGetRoute, publicID); err != nil {
+		return types.BusRoute{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, r := range f.routes {
+		if r.PublicID == publicID {
+			return f.routeView(id), nil
+		}
+	}
+	return types.BusRoute{}, storage.ErrRouteNotFound
+}
+
+func (f *Fake) ListRoutes(ctx context.Context) ([]types.BusRoute, error) {
+	if err := f.enter(MethodListRoutes); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var routes []types.BusRoute
+	for id := range f.routes {
+		routes = append(routes, f.routeView(id))
+	}
+	slices.SortFunc(routes, func(a, b types.BusRoute) int { return cmp.Compare(a.Name, b.Name) })
+	return routes, nil
+}
+
+func (f *Fake) AddStop(ctx context.Context, routeID int64, stop types.Stop) (types.Stop, error) {
+	if err := f.enter(MethodAddStop, routeID, stop); err != nil {
+		return types.Stop{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	route, ok := f.routes[routeID]
+	if !ok {
+		return types.Stop{}, storage.ErrRouteNotFound
+	}
+	stop = f.newStop(stop, len(route.Stops)+1)
+	route.Stops = append(route.Stops, stop)
+	f.routes[routeID] = route
+	return stop, nil
+}
+
+// AssignRoute checks seats and assigns under f.mu, so it is as atomic as the real thing
+func (f *Fake) AssignRoute(ctx context.Context, routeID, stopID, studentID int64) (types.RouteAssignment, error) {
+	if err := f.enter(MethodAssignRoute, routeID, stopID, studentID); err != nil {
+		return types.RouteAssignment{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	route, ok := f.routes[routeID]
+	if !ok {
+		return types.RouteAssignment{}, storage.ErrRouteNotFound
+	}
+	if !slices.ContainsFunc(route.Stops, func(st types.Stop) bool { return st.ID == stopID }) {
+		return types.RouteAssignment{}, storage.ErrStopNotFound
+	}
+	if _, ok := f.students[studentID]; !ok {
+		return types.RouteAssignment{}, storage.ErrNotFound
+	}
+	if f.rider(studentID) >= 0 {
+		return types.RouteAssignment{}, storage.ErrHasRoute
+	}
+	if f.routeView(routeID).Riders >= route.Capacity {
+		return types.RouteAssignment{}, storage.ErrRouteFull
+	}
+	f.riders = append(f.riders, types.RouteAssignment{
+		RouteID:    routeID,
+		StopID:     stopID,
+		StudentID:  studentID,
+		AssignedAt: f.clock.Now().UTC().Truncate(time.Second),
+	})
+	return f.riderView(len(f.riders) - 1), nil
+}
+
+func (f *Fake) UnassignRoute(ctx context.Context, routeID, studentID int64) error {
+	if err := f.enter(MethodUnassignRoute, routeID, studentID); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.rider(studentID)
+	if i < 0 || f.riders[i].RouteID != routeID {
+		return storage.ErrNotFound
+	}
+	f.riders = slices.Delete(f.riders, i, i+1)
+	return nil
+}
+
+func (f *Fake) RouteManifest(ctx context.Context, routeID int64) ([]types.RouteAssignment, error) {
+	if err := f.enter(MethodRouteManifest, routeID); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var manifest []types.RouteAssignment
+	for i, r := range f.riders {
+		if r.RouteID == routeID {
+			manifest = append(manifest, f.riderView(i))
+		}
+	}
+	slices.SortFunc(manifest, func(a, b types.RouteAssignment) int {
+		return cmp.Or(cmp.Compare(a.StopPosition, b.StopPosition), cmp.Compare(a.StudentName, b.StudentName), cmp.Compare(a.StudentID, b.StudentID))
+	})
+	return manifest, nil
+}
+
+// newStop numbers a stop at position. f.mu must be held.
+func (f *Fake) newStop(stop types.Stop, position int) types.Stop {
+	if stop.PublicID == "" {
+		stop.PublicID = types.NewPublicID()
+	}
+	f.lastStopID++
+	stop.ID, stop.Position = f.lastStopID, position
+	return stop
+}
+
+// rider returns the index of the student's route assignment, or -1. f.mu must be held.
+func (f *Fake) rider(studentID int64) int {
+	return slices.IndexFunc(f.riders, func(r types.RouteAssignment) bool { return r.StudentID == studentID })
+}
+
+// routeView returns route id with a copy of its stops and its riders counted. f.mu must be held.
+func (f *Fake) routeView(id int64) types.BusRoute {
+	route := f.routes[id]
+	route.Stops = append([]types.Stop{}, route.Stops...)
+	route.Riders = 0
+	for _, r := range f.riders {
+		if r.RouteID == id {
+			route.Riders++
+		}
+	}
+	return route
+}
+
+// riderView returns assignment i with its route, stop and student filled in. f.mu must be held.
+func (f *Fake) riderView(i int) types.RouteAssignment {
+	a := f.riders[i]
+	route, student := f.routes[a.RouteID], f.students[a.StudentID]
+	a.RoutePublicID = route.PublicID
+	for _, st := range route.Stops {
+		if st.ID == a.StopID {
+			a.StopPublicID, a.StopName, a.StopPosition = st.PublicID, st.Name, st.Position
+		}
+	}
+	a.StudentPublicID, a.StudentName, a.StudentPhone = student.PublicID, student.Name, student.Phone
+	return a
+}
+
+// GraduateStudent moves the student from students to alumni
+func (f *Fake) GraduateStudent(ctx context.Context, studentID int64, classOf int, graduatedOn string) (types.Alumnus, error) {
+	if err := f.enter(MethodGraduateStudent, studentID, classOf, graduatedOn); err != nil {
+		return types.Alumnus{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	student, ok := f.students[studentID]
+	if !ok {
+		return types.Alumnus{}, storage.ErrNotFound
+	}
+	f.remove(studentID)
+	alumnus := types.Alumnus{Student: student, ClassOf: classOf, GraduatedOn: graduatedOn}
+	f.alumni[studentID] = alumnus
+	f.allocations = slices.DeleteFunc(f.allocations, func(a types.Allocation) bool { return a.StudentID == studentID })
+	f.riders = slices.DeleteFunc(f.riders, func(r types.RouteAssignment) bool { return r.StudentID == studentID })
+
+	alumnus.DeriveAge(f.clock.Now())
+	return alumnus, nil
+}
+
+func (f *Fake) GetAlumnusByPublicID(ctx context.Context, publicID string) (types.Alumnus, error) {
+	if err := f.enter(MethodGetAlumnus, publicID); err != nil {
+		return types.Alumnus{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, alumnus := range f.alumni {
+		if alumnus.PublicID == publicID {
+			alumnus.DeriveAge(f.clock.Now())
+			return alumnus, nil
+		}
+	}
+	return types.Alumnus{}, storage.ErrAlumnusNotFound
+}
+
+func (f *Fake) ListAlumni(ctx context.Context, flt types.AlumniFilter, offset, limit int) ([]types.Alumnus, int64, error) {
+	if err := f.enter(MethodListAlumni, flt, offset, limit); err != nil {
+		return nil, 0, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	query := strings.ToLower(flt.Query)
+	var matches []types.Alumnus
+	for _, alumnus := range f.alumni {
+		if flt.ClassOf != 0 && alumnus.ClassOf != flt.ClassOf {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(alumnus.Name), query) && !strings.Contains(strings.ToLower(alumnus.Email), query) {
+			continue
+		}
+		alumnus.DeriveAge(f.clock.Now())
+		matches = append(matches, alumnus)
+	}
+	slices.SortFunc(matches, func(a, b types.Alumnus) int {
+		return cmp.Or(cmp.Compare(b.ClassOf, a.ClassOf), cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	total := int64(len(matches))
+	if offset >= len(matches) {
+		return nil, total, nil
+	}
+	return matches[offset:min(offset+limit, len(matches))], total, nil
+}
+
+// SubmitApplication numbers applications from 1
+func (f *Fake) SubmitApplication(ctx context.Context, app types.Application) (types.Application, error) {
+	if err := f.enter(MethodSubmitApp, app); err != nil {
+		return types.Application{}, err
+	}
+	if app.PublicID == "" {
+		app.PublicID = types.NewPublicID()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.clock.Now().UTC().Truncate(time.Second)
+	app.ID = int64(len(f.applications)) + 1
+	app.Status, app.StudentPublicID, app.SubmittedAt, app.UpdatedAt = types.ApplicationSubmitted, "", now, now
+	f.applications = append(f.applications, app)
+	return app, nil
+}
+
+func (f *Fake) GetApplicationByPublicID(ctx context.Context, publicID string) (types.Application, error) {
+	if err := f.enter(MethodGetApp, publicID); err != nil {
+		return types.Application{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, app := range f.applications {
+		if app.PublicID == publicID {
+			return f.applicationView(app.ID), nil
+		}
+	}
+	return types.Application{}, storage.ErrApplicationNotFound
+}
+
+func (f *Fake) ListApplications(ctx context.Context, status string, offset, limit int) ([]types.Application, int64, error) {
+	if err := f.enter(MethodListApps, status, offset, limit); err != nil {
+		return nil, 0, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matches []types.Application
+	for _, app := range f.applications {
+		if status == "" || app.Status == status {
+			matches = append(matches, f.applicationView(app.ID))
+		}
+	}
+	total := int64(len(matches))
+	if offset >= len(matches) {
+		return nil, total, nil
+	}
+	return matches[offset:min(offset+limit, len(matches))], total, nil
+}
+
+func (f *Fake) SetApplicationStatus(ctx context.Context, id int64, status string) (types.Application, error) {
+	if err := f.enter(MethodSetAppStatus, id, status); err != nil {
+		return types.Application{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id < 1 || id > int64(len(f.applications)) {
+		return types.Application{}, storage.ErrApplicationNotFound
+	}
+	app := &f.applications[id-1]
+	if !slices.Contains(types.ApplicationTransitions[app.Status], status) {
+		return types.Application{}, storage.ErrApplicationStatus
+	}
+	app.Status, app.UpdatedAt = status, f.clock.Now().UTC().Truncate(time.Second)
+	return f.applicationView(id), nil
+}
+
+func (f *Fake) ConvertApplication(ctx context.Context, id int64, student types.Student) (types.Application, types.Student, error) {
+	if err := f.enter(MethodConvertApp, id, student); err != nil {
+		return types.Application{}, types.Student{}, err
+	}
+	if student.PublicID == "" {
+		student.PublicID = types.NewPublicID()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case id < 1 || id > int64(len(f.applications)):
+		return types.Application{}, types.Student{}, storage.ErrApplicationNotFound
+	case f.converted[id] != 0:
+		return types.Application{}, types.Student{}, storage.ErrConverted
+	case f.applications[id-1].Status != types.ApplicationAccepted:
+		return types.Application{}, types.Student{}, storage.ErrApplicationStatus
+	}
+	f.nextID++
+	student.ID = f.nextID
+	f.students[student.ID] = student
+	f.touch(student.ID, true)
+	f.converted[id] = student.ID
+	f.applications[id-1].UpdatedAt = f.clock.Now().UTC().Truncate(time.Second)
+
+	student.DeriveAge(f.clock.Now())
+	return f.applicationView(id), student, nil
+}
+
+// PostAnnouncement queues a delivery to every student f matches, numbering announcements from 1
+func (f *Fake) PostAnnouncement(ctx context.Context, a types.Announcement, flt *types.Filter) (types.Announcement, error) {
+	if err := f.enter(MethodPostAnnouncement, a, flt); err != nil {
+		return types.Announcement{}, err
+	}
+	if a.PublicID == "" {
+		a.PublicID = types.NewPublicID()
+	}
+	if a.Channel == "" {
+		a.Channel = types.ChannelEmail
+	}
+
+	students := f.sorted()
+	now := f.clock.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a.ID = int64(len(f.announcements)) + 1
+	a.CreatedAt = now.UTC().Truncate(time.Second)
+	a.Recipients, a.Deliveries = 0, nil
+	f.announcements = append(f.announcements, a)
+	for _, st := range students {
+		if flt == nil || filter.Match(*flt, st, now) {
+			f.deliveries[[2]int64{a.ID, st.ID}] = types.Delivery{AnnouncementID: a.ID, StudentID: st.ID, Status: types.DeliveryQueued}
+		}
+	}
+	return f.announcementView(a.ID), nil
+}
+
+func (f *Fake) GetAnnouncementByPublicID(ctx context.Context, publicID string) (types.Announcement, error) {
+	if err := f.enter(MethodGetAnnouncement, publicID); err != nil {
+		return types.Announcement{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, a := range f.announcements {
+		if a.PublicID == publicID {
+			return f.announcementView(a.ID), nil
+		}
+	}
+	return types.Announcement{}, storage.ErrAnnouncementNotFound
+}
+
+// PendingDeliveries reads graduates' details from their alumni record
+func (f *Fake) PendingDeliveries(ctx context.Context, announcementID int64) ([]types.Delivery, error) {
+	if err := f.enter(MethodPendingDelivery, announcementID); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var pending []types.Delivery
+	for key, d := range f.deliveries {
+		if key[0] != announcementID || d.Status != types.DeliveryQueued {
+			continue
+		}
+		st, ok := f.students[key[1]]
+		if !ok {
+			st = f.alumni[key[1]].Student
+		}
+		d.Name, d.Email, d.Phone = st.Name, st.Email, st.Phone
+		pending = append(pending, d)
+	}
+	slices.SortFunc(pending, func(a, b types.Delivery) int { return cmp.Compare(a.StudentID, b.StudentID) })
+	return pending, nil
+}
+
+// SetDeliveryStatus ignores deliveries that don't exist, like an UPDATE matching no row
+func (f *Fake) SetDeliveryStatus(ctx context.Context, d types.Delivery) error {
+	if err := f.enter(MethodSetDelivery, d); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := [2]int64{d.AnnouncementID, d.StudentID}
+	if _, ok := f.deliveries[key]; ok {
+		f.deliveries[key] = types.Delivery{AnnouncementID: d.AnnouncementID, StudentID: d.StudentID, Status: d.Status, MessageID: d.MessageID, Error: d.Error}
+	}
+	return nil
+}
+
+func (f *Fake) ReportDelivery(ctx context.Context, messageID, status, detail string) error {
+	if err := f.enter(MethodReportDelivery, messageID, status, detail); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, d := range f.deliveries {
+		if d.MessageID != messageID {
+			continue
+		}
+		if d.Status == types.DeliverySent {
+			d.Status, d.Error = status, detail
+			f.deliveries[key] = d
+		}
+		return nil
+	}
+	return storage.ErrDeliveryNotFound
+}
+
+func (f *Fake) StudentAnnouncements(ctx context.Context, studentID int64) ([]types.StudentAnnouncement, error) {
+	if err := f.enter(MethodStudentAnnounce, studentID); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var list []types.StudentAnnouncement
+	for i := len(f.announcements) - 1; i >= 0; i-- {
+		a := f.announcements[i]
+		if d, ok := f.deliveries[[2]int64{a.ID, studentID}]; ok {
+			list = append(list, types.StudentAnnouncement{
+				PublicID:  a.PublicID,
+				Subject:   a.Subject,
+				Body:      a.Body,
+				Channel:   a.Channel,
+				CreatedAt: a.CreatedAt,
+				Status:    d.Status,
+				Error:     d.Error,
+			})
+		}
+	}
+	return list, nil
+}
+
+func (f *Fake) DefineCustomField(ctx context.Context, field types.CustomField) (types.CustomField, error) {
+	if err := f.enter(MethodDefineField, field); err != nil {
+		return types.CustomField{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.fields[field.Name]; ok {
+		return types.CustomField{}, storage.ErrDuplicate
+	}
+	f.fields[field.Name] = field
+	return field, nil
+}
+
+func (f *Fake) ListCustomFields(ctx context.Context) ([]types.CustomField, error) {
+	if err := f.enter(MethodListFields); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fields := slices.Collect(maps.Values(f.fields))
+	slices.SortFunc(fields, func(a, b types.CustomField) int { return cmp.Compare(a.Name, b.Name) })
+	return fields, nil
+}
+
+// DeleteCustomField also removes the field's value from every student
+func (f *Fake) DeleteCustomField(ctx context.Context, name string) error {
+	if err := f.enter(MethodDeleteField, name); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.fields[name]; !ok {
+		return storage.ErrCustomFieldNotFound
+	}
+	delete(f.fields, name)
+	for id, st := range f.students {
+		if _, ok := st.CustomFields[name]; !ok {
+			continue
+		}
+		st.CustomFields = maps.Clone(st.CustomFields)
+		delete(st.CustomFields, name)
+		if len(st.CustomFields) == 0 {
+			st.CustomFields = nil
+		}
+		f.students[id] = st
+		f.touch(id, false)
+	}
+	return nil
+}
+
+func (f *Fake) SaveView(ctx context.Context, view types.View) (types.View, error) {
+	if err := f.enter(MethodSaveView, view); err != nil {
+		return types.View{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.views[view.Name]; ok {
+		return types.View{}, storage.ErrDuplicate
+	}
+	view.CreatedAt = f.clock.Now().UTC().Truncate(time.Second)
+	view.Expand = slices.Clone(view.Expand)
+	f.views[view.Name] = view
+	return view, nil
+}
+
+func (f *Fake) GetView(ctx context.Context, name string) (types.View, error) {
+	if err := f.enter(MethodGetView, name); err != nil {
+		return types.View{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	view, ok := f.views[name]
+	if !ok {
+		return types.View{}, storage.ErrViewNotFound
+	}
+	return view, nil
+}
+
+func (f *Fake) ListViews(ctx context.Context) ([]types.View, error) {
+	if err := f.enter(MethodListViews); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	views := slices.Collect(maps.Values(f.views))
+	slices.SortFunc(views, func(a, b types.View) int { return cmp.Compare(a.Name, b.Name) })
+	return views, nil
+}
+
+func (f *Fake) DeleteView(ctx context.Context, name string) error {
+	if err := f.enter(MethodDeleteView, name); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.views[name]; !ok {
+		return storage.ErrViewNotFound
+	}
+	delete(f.views, name)
+	return nil
+}
+
+func (f *Fake) CreateWebhook(ctx context.Context, hook types.Webhook) (types.Webhook, error) {
+	if err := f.enter(MethodCreateWebhook, hook); err != nil {
+		return types.Webhook{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if hook.PublicID == "" {
+		hook.PublicID = types.NewPublicID()
+	}
+	f.lastWebhookID++
+	hook.ID = f.lastWebhookID
+	hook.Events = slices.Clone(hook.Events)
+	hook.PreviousSecret, hook.PreviousSecretExpiresAt = "", nil
+	hook.CreatedAt = f.clock.Now().UTC().Truncate(time.Second)
+	f.webhooks = append(f.webhooks, hook)
+	return hook, nil
+}
+
+func (f *Fake) GetWebhook(ctx context.Context, publicID string) (types.Webhook, error) {
+	if err := f.enter(MethodGetWebhook, publicID); err != nil {
+		return types.Webhook{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.IndexFunc(f.webhooks, func(h types.Webhook) bool { return h.PublicID == publicID })
+	if i < 0 {
+		return types.Webhook{}, storage.ErrWebhookNotFound
+	}
+	return f.webhookView(i), nil
+}
+
+func (f *Fake) ListWebhooks(ctx context.Context, owner string) ([]types.Webhook, error) {
+	if err := f.enter(MethodListWebhooks, owner); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var hooks []types.Webhook
+	for i, h := range f.webhooks {
+		if h.Owner == owner {
+			hooks = append(hooks, f.webhookView(i))
+		}
+	}
+	return hooks, nil
+}
+
+func (f *Fake) WebhooksFor(ctx context.Context, event string) ([]types.Webhook, error) {
+	if err := f.enter(MethodWebhooksFor, event); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var hooks []types.Webhook
+	for i, h := range f.webhooks {
+		if slices.Contains(h.Events, event) {
+			hooks = append(hooks, f.webhookView(i))
+		}
+	}
+	return hooks, nil
+}
+
+func (f *Fake) RotateWebhookSecret(ctx context.Context, publicID, secret string, expires time.Time) (types.Webhook, error) {
+	if err := f.enter(MethodRotateSecret, publicID, secret, expires); err != nil {
+		return types.Webhook{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.IndexFunc(f.webhooks, func(h types.Webhook) bool { return h.PublicID == publicID })
+	if i < 0 {
+		return types.Webhook{}, storage.ErrWebhookNotFound
+	}
+	expires = expires.UTC().Truncate(time.Second)
+	h := &f.webhooks[i]
+	h.PreviousSecret, h.PreviousSecretExpiresAt, h.Secret = h.Secret, &expires, secret
+	return f.webhookView(i), nil
+}
+
+func (f *Fake) DeleteWebhook(ctx context.Context, publicID string) error {
+	if err := f.enter(MethodDeleteWebhook, publicID); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.IndexFunc(f.webhooks, func(h types.Webhook) bool { return h.PublicID == publicID })
+	if i < 0 {
+		return storage.ErrWebhookNotFound
+	}
+	f.webhooks = slices.Delete(f.webhooks, i, i+1)
+	f.webhookDeliveries = slices.DeleteFunc(f.webhookDeliveries, func(d types.WebhookDelivery) bool { return d.WebhookID == publicID })
+	return nil
+}
+
+func (f *Fake) CreateWebhookDelivery(ctx context.Context, d types.WebhookDelivery) (types.WebhookDelivery, error) {
+	if err := f.enter(MethodCreateDelivery, d); err != nil {
+		return types.WebhookDelivery{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !slices.ContainsFunc(f.webhooks, func(h types.Webhook) bool { return h.PublicID == d.WebhookID }) {
+		return types.WebhookDelivery{}, storage.ErrWebhookNotFound
+	}
+	f.lastWebhookDelivery++
+	now := f.clock.Now().UTC().Truncate(time.Second)
+	d.ID = f.lastWebhookDelivery
+	if d.PublicID == "" {
+		d.PublicID = types.NewPublicID()
+	}
+	d.Body = slices.Clone(d.Body)
+	d.Status, d.Attempts = types.DeliveryQueued, 0
+	d.ResponseStatus, d.ResponseBody, d.Error = 0, "", ""
+	d.CreatedAt, d.UpdatedAt = now, now
+	f.webhookDeliveries = append(f.webhookDeliveries, d)
+	return d, nil
+}
+
+func (f *Fake) GetWebhookDelivery(ctx context.Context, id int64) (types.WebhookDelivery, error) {
+	if err := f.enter(MethodGetDelivery, id); err != nil {
+		return types.WebhookDelivery{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.IndexFunc(f.webhookDeliveries, func(d types.WebhookDelivery) bool { return d.ID == id })
+	if i < 0 {
+		return types.WebhookDelivery{}, storage.ErrDeliveryNotFound
+	}
+	return f.webhookDeliveries[i], nil
+}
+
+func (f *Fake) GetWebhookDeliveryByPublicID(ctx context.Context, publicID string) (types.WebhookDelivery, error) {
+	if err := f.enter(MethodGetDeliveryByID, publicID); err != nil {
+		return types.WebhookDelivery{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.IndexFunc(f.webhookDeliveries, func(d types.WebhookDelivery) bool { return d.PublicID == publicID })
+	if i < 0 {
+		return types.WebhookDelivery{}, storage.ErrDeliveryNotFound
+	}
+	return f.webhookDeliveries[i], nil
+}
+
+func (f *Fake) ListWebhookDeliveries(ctx context.Context, webhookID, status string, beforeID int64, limit int) ([]types.WebhookDelivery, error) {
+	if err := f.enter(MethodListDeliveries, webhookID, status, beforeID, limit); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var deliveries []types.WebhookDelivery
+	for i := len(f.webhookDeliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		d := f.webhookDeliveries[i]
+		if d.WebhookID == webhookID && (status == "" || d.Status == status) && (beforeID == 0 || d.ID < beforeID) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (f *Fake) UpdateWebhookDelivery(ctx context.Context, d types.WebhookDelivery) error {
+	if err := f.enter(MethodUpdateDelivery, d); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.IndexFunc(f.webhookDeliveries, func(s types.WebhookDelivery) bool { return s.ID == d.ID })
+	if i < 0 {
+		return storage.ErrDeliveryNotFound
+	}
+	stored := &f.webhookDeliveries[i]
+	stored.Status, stored.Attempts = d.Status, d.Attempts
+	stored.ResponseStatus, stored.ResponseBody, stored.Error = d.ResponseStatus, d.ResponseBody, d.Error
+	stored.UpdatedAt = f.clock.Now().UTC().Truncate(time.Second)
+	return nil
+}
+
+func (f *Fake) DeleteWebhookDelivery(ctx context.Context, id int64) error {
+	if err := f.enter(MethodDeleteDelivery, id); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.IndexFunc(f.webhookDeliveries, func(d types.WebhookDelivery) bool { return d.ID == id })
+	if i < 0 {
+		return storage.ErrDeliveryNotFound
+	}
+	f.webhookDeliveries = slices.Delete(f.webhookDeliveries, i, i+1)
+	return nil
+}
+
+// webhookView returns a copy of webhook i without its previous secret once that has expired.
+// f.mu must be held.
+func (f *Fake) webhookView(i int) types.Webhook {
+	h := f.webhooks[i]
+	h.Events = slices.Clone(h.Events)
+	if h.PreviousSecretExpiresAt == nil || !h.PreviousSecretExpiresAt.After(f.clock.Now()) {
+		h.PreviousSecret, h.PreviousSecretExpiresAt = "", nil
+	}
+	return h
+}
+
+// announcementView returns announcement id with its deliveries counted. f.mu must be held.
+func (f *Fake) announcementView(id int64) types.Announcement {
+	a := f.announcements[id-1]
+	a.Deliveries = map[string]int{types.DeliveryQueued: 0, types.DeliverySent: 0, types.DeliveryDelivered: 0, types.DeliveryFailed: 0}
+	for key, d := range f.deliveries {
+		if key[0] == id {
+			a.Deliveries[d.Status]++
+			a.Recipients++
+		}
+	}
+	return a
+}
+
+// applicationView returns application id with the public ID of the student it became filled in.
+// f.mu must be held.
+func (f *Fake) applicationView(id int64) types.Application {
+	app := f.applications[id-1]
+	if studentID, ok := f.converted[id]; ok {
+		if st, ok := f.students[studentID]; ok {
+			app.StudentPublicID = st.PublicID
+		} else {
+			app.StudentPublicID = f.alumni[studentID].PublicID
+		}
+	}
+	return app
+}
+
+// linkKey orders a pair of student IDs so a link has one key whichever side it is seen from
+func linkKey(a, b int64) [2]int64 {
+	return [2]int64{min(a, b), max(a, b)}
+}
+
+// sorted returns a snapshot of all students in ID order with ages derived
+func (f *Fake) sorted() []types.Student {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.clock.Now()
+	students := make([]types.Student, 0, len(f.students))
+	for _, st := range f.students {
+		st.DeriveAge(now)
+		students = append(students, st)
+	}
+	sort.Slice(students, func(i, j int) bool { return students[i].ID < students[j].ID })
+	return students
+}
+
+// PatchStudents merges customFields into the matching students' values
+func (f *Fake) PatchStudents(ctx context.Context, flt *types.Filter, customFields map[string]any, max int) (types.BulkUpdate, error) {
+	if err := f.enter(MethodPatchStudents, flt, customFields, max); err != nil {
+		return types.BulkUpdate{}, err
+	}
+
+	now := f.clock.Now()
+	var matched []int64
+	for _, st := range f.sorted() {
+		if flt == nil || filter.Match(*flt, st, now) {
+			matched = append(matched, st.ID)
+		}
+	}
+	result := types.BulkUpdate{Matched: int64(len(matched))}
+	if max >= 0 && len(matched) > max {
+		return result, storage.ErrConfirmationRequired
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, id := range matched {
+		st, ok := f.students[id]
+		if !ok {
+			continue
+		}
+		values := maps.Clone(st.CustomFields)
+		if values == nil {
+			values = make(map[string]any)
+		}
+		for name, v := range customFields {
+			if v == nil {
+				delete(values, name)
+			} else {
+				values[name] = v
+			}
+		}
+		if len(values) == 0 {
+			values = nil
+		}
+		if maps.EqualFunc(values, st.CustomFields, func(a, b any) bool { return a == b }) {
+			continue
+		}
+		st.CustomFields = values
+		f.students[st.ID] = st
+		f.touch(st.ID, false)
+		result.Updated++
+	}
+	return result, nil
+}
+
+// StudentChanges orders changes as the sqlite backend does
+func (f *Fake) StudentChanges(ctx context.Context, since time.Time, after string, limit int) ([]types.StudentChange, error) {
+	if err := f.enter(MethodStudentChanges, since, after, limit); err != nil {
+		return nil, err
+	}
+
+	now := f.clock.Now()
+	since = since.UTC().Truncate(time.Second)
+	until := now.UTC().Truncate(time.Second)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var changes []types.StudentChange
+	for id, at := range f.changed {
+		st := f.students[id]
+		st.DeriveAge(now)
+		change := types.StudentChange{ID: st.PublicID, Change: types.ChangeUpdated, ChangedAt: at, Student: &st}
+		if !f.created[id].Before(since) {
+			change.Change = types.ChangeCreated
+		}
+		changes = append(changes, change)
+	}
+	for _, change := range f.removed {
+		changes = append(changes, change)
+	}
+	changes = slices.DeleteFunc(changes, func(c types.StudentChange) bool {
+		return c.ChangedAt.Before(since) || c.ChangedAt.Equal(since) && c.ID <= after || !c.ChangedAt.Before(until)
+	})
+	slices.SortFunc(changes, func(a, b types.StudentChange) int {
+		return cmp.Or(a.ChangedAt.Compare(b.ChangedAt), strings.Compare(a.ID, b.ID))
+	})
+	return changes[:min(limit, len(changes))], nil
+}
+
+func (f *Fake) AppendEvent(ctx context.Context, e types.FeedEvent) (types.FeedEvent, error) {
+	if err := f.enter(MethodAppendEvent, e); err != nil {
+		return types.FeedEvent{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e.Seq = int64(len(f.feed[e.Entity])) + 1
+	e.Data = slices.Clone(e.Data)
+	e.CreatedAt = f.clock.Now().UTC().Truncate(time.Second)
+	f.feed[e.Entity] = append(f.feed[e.Entity], e)
+	return e, nil
+}
+
+// EventsAfter never reports ErrEventsExpired: the Fake keeps every event
+func (f *Fake) EventsAfter(ctx context.Context, entity string, afterSeq int64, limit int) ([]types.FeedEvent, int64, error) {
+	if err := f.enter(MethodEventsAfter, entity, afterSeq, limit); err != nil {
+		return nil, 0, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	log := f.feed[entity]
+	from := min(max(afterSeq, 0), int64(len(log)))
+	return slices.Clone(log[from:min(from+int64(limit), int64(len(log)))]), int64(len(log)), nil
+}
+
+func (f *Fake) CountRequest(ctx context.Context, client, month string, limit int64) (int64, bool, error) {
+	if err := f.enter(MethodCountRequest, client, month, limit); err != nil {
+		return 0, false, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := [2]string{month, client}
+	if f.usage[key] >= limit {
+		return f.usage[key], false, nil
+	}
+	f.usage[key]++
+	return f.usage[key], true, nil
+}
+
+func (f *Fake) ListUsage(ctx context.Context, month string) ([]types.Usage, error) {
+	if err := f.enter(MethodListUsage, month); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var usage []types.Usage
+	for key, n := range f.usage {
+		if key[0] == month {
+			usage = append(usage, types.Usage{Client: key[1], Month: month, Requests: n})
+		}
+	}
+	slices.SortFunc(usage, func(a, b types.Usage) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), strings.Compare(a.Client, b.Client))
+	})
+	return usage, nil
+}
+
+func (f *Fake) ImportProgress(ctx context.Context, jobID int64) (types.ImportProgress, error) {
+	if err := f.enter(MethodImportProgress, jobID); err != nil {
+		return types.ImportProgress{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.imports[jobID], nil
+}
+
+func (f *Fake) ImportBatch(ctx context.Context, jobID int64, students []types.Student, row int) (types.ImportProgress, []types.Student, error) {
+	if err := f.enter(MethodImportBatch, jobID, students, row); err != nil {
+		return types.ImportProgress{}, nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := f.imports[jobID]
+	seen := make(map[string]bool, len(f.students)+len(students))
+	for _, st := range f.students {
+		seen[strings.ToLower(st.Email)] = true
+	}
+	var fresh []types.Student
+	for _, st := range students {
+		email := strings.ToLower(st.Email)
+		if seen[email] {
+			p.Skipped++
+			continue
+		}
+		seen[email] = true
+		f.nextID++
+		st.ID = f.nextID
+		if st.PublicID == "" {
+			st.PublicID = types.NewPublicID()
+		}
+		f.students[st.ID] = st
+		f.touch(st.ID, true)
+		fresh = append(fresh, st)
+	}
+	p.Row = row
+	p.Imported += len(fresh)
+	f.imports[jobID] = p
+	return p, fresh, nil
+}
+
+func (f *Fake) StageImport(ctx context.Context, jobID int64, rows []types.StagedStudent) error {
+	if err := f.enter(MethodStageImport, jobID, rows); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.staged[jobID] = append(f.staged[jobID], rows...)
+	return nil
+}
+
+func (f *Fake) PreviewImport(ctx context.Context, jobID int64, limit int) (types.ImportPreview, error) {
+	if err := f.enter(MethodPreviewImport, jobID, limit); err != nil {
+		return types.ImportPreview{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := slices.SortedFunc(slices.Values(f.staged[jobID]), func(a, b types.StagedStudent) int { return cmp.Compare(a.Row, b.Row) })
+	if len(rows) == 0 {
+		return types.ImportPreview{}, storage.ErrNothingStaged
+	}
+	students := make(map[string]string, len(f.students))
+	for _, st := range f.students {
+		students[strings.ToLower(st.Email)] = st.PublicID
+	}
+	first := make(map[string]int, len(rows))
+	preview := types.ImportPreview{Staged: len(rows), Create: []types.StagedStudent{}, Duplicate: []types.ImportConflict{}, Conflict: []types.ImportConflict{}}
+	for _, r := range rows {
+		email := strings.ToLower(r.Email)
+		if _, ok := first[email]; !ok {
+			first[email] = r.Row
+		}
+		switch id, taken := students[email]; {
+		case taken:
+			preview.Conflicts++
+			if len(preview.Conflict) < limit {
+				preview.Conflict = append(preview.Conflict, types.ImportConflict{Row: r.Row, Email: r.Email, StudentID: id})
+			}
+		case first[email] < r.Row:
+			preview.Duplicates++
+			if len(preview.Duplicate) < limit {
+				preview.Duplicate = append(preview.Duplicate, types.ImportConflict{Row: r.Row, Email: r.Email, FirstRow: first[email]})
+			}
+		default:
+			preview.New++
+			if len(preview.Create) < limit {
+				preview.Create = append(preview.Create, r)
+			}
+		}
+	}
+	return preview, nil
+}
+
+func (f *Fake) StagedRows(ctx context.Context, jobID int64, afterRow, limit int) ([]types.StagedStudent, error) {
+	if err := f.enter(MethodStagedRows, jobID, afterRow, limit); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var rows []types.StagedStudent
+	for _, r := range f.staged[jobID] {
+		if r.Row > afterRow {
+			rows = append(rows, r)
+		}
+	}
+	slices.SortFunc(rows, func(a, b types.StagedStudent) int { return cmp.Compare(a.Row, b.Row) })
+	return rows[:min(limit, len(rows))], nil
+}
+
+func (f *Fake) DiscardImport(ctx context.Context, jobID int64) error {
+	if err := f.enter(MethodDiscardImport, jobID); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.staged[jobID]) == 0 {
+		return storage.ErrNothingStaged
+	}
+	delete(f.staged, jobID)
+	return nil
+}
+
+// touch records that student id changed just now; f.mu must be held
+func (f *Fake) touch(id int64, created bool) {
+	now := f.clock.Now().UTC().Truncate(time.Second)
+	f.changed[id] = now
+	if created {
+		f.created[id] = now
+	}
+}
+
+// remove deletes student id, keeping its deletion for the changes feed; f.mu must be held
+func (f *Fake) remove(id int64) {
+	f.removed[id] = types.StudentChange{ID: f.students[id].PublicID, Change: types.ChangeDeleted, ChangedAt: f.clock.Now().UTC().Truncate(time.Second)}
+	delete(f.students, id)
+	delete(f.created, id)
+	delete(f.changed, id)
+}
@@ -0,0 +1,35 @@
+package storagetest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func TestFakeConformance(t *testing.T) {
+	TestStorage(t, func(t *testing.T) storage.Storage { return NewFake() })
+}
+
+func TestFakeProgrammedErrors(t *testing.T) {
+	f := NewFake()
+	boom := errors.New("boom")
+
+	f.FailNext(MethodGetStudentsCount, boom)
+	if _, err := f.GetStudentsCount(); !errors.Is(err, boom) {
+		t.Fatalf("first call error = %v, want boom", err)
+	}
+	if _, err := f.GetStudentsCount(); err != nil {
+		t.Fatalf("second call error = %v, want nil", err)
+	}
+
+	f.SetError(MethodCreateStudent, storage.ErrDatabase)
+	if _, err := f.CreateStudent(types.Student{Name: "a", Email: "a@example.com", Age: 20}); !errors.Is(err, storage.ErrDatabase) {
+		t.Fatalf("CreateStudent error = %v, want ErrDatabase", err)
+	}
+
+	if got := f.CallCount(MethodGetStudentsCount); got != 2 {
+		t.Errorf("CallCount = %d, want 2", got)
+	}
+}
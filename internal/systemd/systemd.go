@@ -0,0 +1,80 @@
+// Package systemd integrates the server with systemd: socket activation (LISTEN_FDS) and
+// sd_notify readiness/watchdog messages. Everything is a no-op when not started by systemd.
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/activation"
+	"github.com/coreos/go-systemd/v22/daemon"
+)
+
+// Listener returns the socket systemd passed in via LISTEN_FDS, or nil if the process was not
+// socket-activated. Only a single TCP socket is supported; the .socket unit should declare one.
+func Listener() (net.Listener, error) {
+	listeners, err := activation.Listeners()
+	if err != nil {
+		return nil, fmt.Errorf("socket activation: %w", err)
+	}
+	if len(listeners) == 0 {
+		return nil, nil
+	}
+	if len(listeners) > 1 {
+		for _, ln := range listeners[1:] {
+			if ln != nil {
+				ln.Close()
+			}
+		}
+		slog.Warn("systemd passed more than one socket; only the first is used", "count", len(listeners))
+	}
+	if listeners[0] == nil {
+		return nil, fmt.Errorf("socket activation: first socket is not a stream socket")
+	}
+	return listeners[0], nil
+}
+
+// Ready tells systemd startup has finished (Type=notify units)
+func Ready() {
+	notify(daemon.SdNotifyReady)
+}
+
+// Stopping tells systemd a graceful shutdown has started
+func Stopping() {
+	notify(daemon.SdNotifyStopping)
+}
+
+// RunWatchdog pings the systemd watchdog at half of WatchdogSec until ctx is done.
+// It returns immediately when the unit has no watchdog configured.
+func RunWatchdog(ctx context.Context) {
+	interval, err := daemon.SdWatchdogEnabled(false)
+	if err != nil {
+		slog.Warn("Invalid systemd watchdog configuration", "error", err)
+		return
+	}
+	if interval == 0 {
+		return
+	}
+
+	slog.Info("systemd watchdog enabled", "interval", interval)
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			notify(daemon.SdNotifyWatchdog)
+		}
+	}
+}
+
+func notify(state string) {
+	if _, err := daemon.SdNotify(false, state); err != nil {
+		slog.Warn("sd_notify failed", "state", state, "error", err)
+	}
+}
@@ -0,0 +1,131 @@
+// Package tenant resolves which school (tenant) a request belongs to and routes it to that
+// tenant's handler. Every tenant has its own database file, so data can never leak between
+// tenants through a missing WHERE clause.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+)
+
+var (
+	ErrNoTenant       = errors.New("no tenant in request")
+	ErrUnknownTenant  = errors.New("unknown tenant")
+	ErrTenantMismatch = errors.New("tenant header does not match API key")
+)
+
+// DefaultHeader carries the tenant ID when it isn't implied by the API key or host
+const DefaultHeader = "X-Tenant-ID"
+
+// APIKeyHeader identifies a client; keys are bound to exactly one tenant
+const APIKeyHeader = "X-API-Key"
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidID reports whether id is usable as a tenant ID (it appears in hostnames and file paths)
+func ValidID(id string) bool {
+	return validID.MatchString(id)
+}
+
+type ctxKey struct{}
+
+// WithID returns ctx carrying the tenant ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request's tenant ID, or "" outside a tenant
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Resolver finds the tenant of a request. In order of precedence:
+//  1. an API key (X-API-Key) bound to a tenant
+//  2. the tenant header (X-Tenant-ID by default)
+//  3. the subdomain of BaseDomain, e.g. "greenwood" for greenwood.students.example.com
+//
+// A tenant header that contradicts the API key's tenant is rejected rather than ignored.
+type Resolver struct {
+	Header     string
+	BaseDomain string
+	// APIKeys maps API key -> tenant ID
+	APIKeys map[string]string
+}
+
+func (res Resolver) Resolve(r *http.Request) (string, error) {
+	header := res.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+	fromHeader := strings.TrimSpace(r.Header.Get(header))
+
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		if id, ok := res.APIKeys[key]; ok {
+			if fromHeader != "" && fromHeader != id {
+				return "", ErrTenantMismatch
+			}
+			return id, nil
+		}
+	}
+
+	if fromHeader != "" {
+		return fromHeader, nil
+	}
+
+	if res.BaseDomain != "" {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(host)
+		if sub, ok := strings.CutSuffix(host, "."+strings.ToLower(res.BaseDomain)); ok && !strings.Contains(sub, ".") {
+			return sub, nil
+		}
+	}
+
+	return "", ErrNoTenant
+}
+
+// Handler dispatches each request to its tenant's handler. Requests without a tenant are
+// served by Public if their path is one of PublicPaths (health checks, the home page) and are
+// rejected otherwise.
+type Handler struct {
+	Resolver    Resolver
+	Tenants     map[string]http.Handler
+	Public      http.Handler
+	PublicPaths []string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lang := i18n.FromRequest(r)
+	id, err := h.Resolver.Resolve(r)
+	switch {
+	case errors.Is(err, ErrNoTenant) && h.Public != nil && slices.Contains(h.PublicPaths, r.URL.Path):
+		h.Public.ServeHTTP(w, r)
+		return
+	case errors.Is(err, ErrNoTenant):
+		response.WriteError(w, http.StatusBadRequest, i18n.T(lang, i18n.MsgTenantRequired), err.Error())
+		return
+	case err != nil:
+		response.WriteError(w, http.StatusForbidden, i18n.T(lang, i18n.MsgInvalidTenant), err.Error())
+		return
+	}
+
+	next, ok := h.Tenants[id]
+	if !ok {
+		slog.Warn("Request for unknown tenant", "tenant", id, "path", r.URL.Path)
+		response.WriteError(w, http.StatusNotFound, i18n.T(lang, i18n.MsgUnknownTenant), ErrUnknownTenant.Error())
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(WithID(r.Context(), id)))
+}
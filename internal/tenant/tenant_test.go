@@ -0,0 +1,79 @@
+package tenant
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	res := Resolver{BaseDomain: "students.example.com", APIKeys: map[string]string{"key-green": "greenwood"}}
+
+	tests := []struct {
+		name    string
+		host    string
+		headers map[string]string
+		want    string
+		wantErr error
+	}{
+		{"api key", "api.local", map[string]string{"X-API-Key": "key-green"}, "greenwood", nil},
+		{"api key and matching header", "api.local", map[string]string{"X-API-Key": "key-green", "X-Tenant-ID": "greenwood"}, "greenwood", nil},
+		{"api key and other tenant header", "api.local", map[string]string{"X-API-Key": "key-green", "X-Tenant-ID": "riverside"}, "", ErrTenantMismatch},
+		{"header", "api.local", map[string]string{"X-Tenant-ID": "riverside"}, "riverside", nil},
+		{"subdomain with port", "Riverside.students.example.com:8080", nil, "riverside", nil},
+		{"nested subdomain", "a.b.students.example.com", nil, "", ErrNoTenant},
+		{"bare base domain", "students.example.com", nil, "", ErrNoTenant},
+		{"nothing", "localhost", nil, "", ErrNoTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/students", nil)
+			r.Host = tt.host
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			got, err := res.Resolve(r)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() = %q, %v; want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandlerDispatchesByTenant(t *testing.T) {
+	serve := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name + ":" + FromContext(r.Context())))
+		})
+	}
+	h := &Handler{
+		Tenants:     map[string]http.Handler{"greenwood": serve("green"), "riverside": serve("river")},
+		Public:      serve("public"),
+		PublicPaths: []string{"/readyz"},
+	}
+
+	tests := []struct {
+		tenant     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"greenwood", "/students", http.StatusOK, "green:greenwood"},
+		{"riverside", "/students", http.StatusOK, "river:riverside"},
+		{"", "/readyz", http.StatusOK, "public:"},
+		{"", "/students", http.StatusBadRequest, ""},
+		{"unknown", "/students", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.tenant != "" {
+			r.Header.Set(DefaultHeader, tt.tenant)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.wantStatus || (tt.wantBody != "" && w.Body.String() != tt.wantBody) {
+			t.Errorf("tenant %q %s: got %d %q, want %d %q", tt.tenant, tt.path, w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Package testutil runs the complete HTTP stack (router, middleware, handlers) against
+// in-memory storage inside an httptest.Server, for end-to-end tests of endpoints.
+//
+//	srv := testutil.NewServer(t)
+//	resp := srv.Do(http.MethodPost, "/students", map[string]any{"name": "A", "email": "a@example.com", "age": 20})
+//	resp.AssertStatus(http.StatusCreated)
+//	resp.AssertJSON("name", "A")
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/contract"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/middleware"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/response"
+	"github.com/prashantkumbhar2002/go_students_api/internal/http/router"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+)
+
+// Server is a running API backed by a storagetest.Fake
+type Server struct {
+	*httptest.Server
+
+	// Store is the in-memory storage behind the server; use it to arrange data or program failures
+	Store *storagetest.Fake
+
+	t        testing.TB
+	defaults []RequestOption
+	contract *contract.Validator
+	// envelope lists the API versions whose bodies the contract sees unwrapped
+	envelope []int
+}
+
+// Option customises the server before it starts
+type Option func(*serverConfig)
+
+type serverConfig struct {
+	deps       router.Deps
+	handler    func(router.Deps) http.Handler
+	defaults   []RequestOption
+	noContract bool
+}
+
+// WithDeps overrides router dependencies. Store is replaced by the server's Fake if left nil.
+func WithDeps(fn func(d *router.Deps)) Option {
+	return func(c *serverConfig) { fn(&c.deps) }
+}
+
+// WithHandler serves the handler built by fn instead of router.New, e.g. router.NewAdmin
+func WithHandler(fn func(router.Deps) http.Handler) Option {
+	return func(c *serverConfig) { c.handler = fn }
+}
+
+// WithDefaultRequestOptions applies opts to every request, e.g. WithBearerToken for an authenticated client
+func WithDefaultRequestOptions(opts ...RequestOption) Option {
+	return func(c *serverConfig) { c.defaults = append(c.defaults, opts...) }
+}
+
+// WithoutContractValidation disables checking responses against the OpenAPI document,
+// for tests that deliberately provoke undocumented behaviour
+func WithoutContractValidation() Option {
+	return func(c *serverConfig) { c.noContract = true }
+}
+
+// AdminToken guards the operational routes (/admin, /jobs) that NewServer serves; its requests
+// send it unless they set their own Authorization header
+const AdminToken = "test-admin-token"
+
+// NewServer starts the full router against a fresh in-memory store and closes it when the test ends
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+
+	fake := storagetest.NewFake()
+	cfg := serverConfig{
+		deps:     router.Deps{Store: fake, AdminToken: AdminToken},
+		handler:  router.New,
+		defaults: []RequestOption{WithBearerToken(AdminToken)},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.deps.Store == nil {
+		cfg.deps.Store = fake
+	}
+
+	srv := &Server{
+		Server:   httptest.NewServer(cfg.handler(cfg.deps)),
+		Store:    fake,
+		t:        t,
+		defaults: cfg.defaults,
+		envelope: cfg.deps.API.Envelope,
+	}
+	if !cfg.noContract {
+		v, err := contract.New()
+		if err != nil {
+			t.Fatalf("loading OpenAPI contract: %v", err)
+		}
+		srv.contract = v
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Storage returns the store as the interface handlers see, for passing to other components
+func (s *Server) Storage() storage.Storage {
+	return s.Store
+}
+
+// RequestOption customises a single request
+type RequestOption func(*http.Request)
+
+// WithHeader sets a request header
+func WithHeader(key, value string) RequestOption {
+	return func(r *http.Request) { r.Header.Set(key, value) }
+}
+
+// WithBearerToken authenticates the request with an Authorization: Bearer header
+func WithBearerToken(token string) RequestOption {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithAPIKey authenticates the request with an X-API-Key header
+func WithAPIKey(key string) RequestOption {
+	return WithHeader("X-API-Key", key)
+}
+
+// Do sends a request to the server. body may be nil, a string/[]byte (sent as-is), or any
+// value (JSON-encoded). Transport errors fail the test immediately.
+func (s *Server) Do(method, path string, body any, opts ...RequestOption) *Response {
+	s.t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			s.t.Fatalf("encoding request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		s.t.Fatalf("building request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, opt := range append(append([]RequestOption(nil), s.defaults...), opts...) {
+		opt(req)
+	}
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.t.Fatalf("reading response body: %v", err)
+	}
+
+	// Every response must match the OpenAPI document, so spec drift fails the test that caused it
+	if s.contract != nil {
+		body := data
+		if v, err := strconv.Atoi(resp.Header.Get(middleware.APIVersionHeader)); err == nil && slices.Contains(s.envelope, v) {
+			body = response.Unenvelope(body)
+		}
+		if err := s.contract.ValidateResponse(method, req.URL.Path, resp.StatusCode, resp.Header, body); err != nil {
+			s.t.Errorf("contract violation: %v", err)
+		}
+	}
+
+	return &Response{Response: resp, Body: data, t: s.t}
+}
+
+// Response is a fully read HTTP response with assertion helpers
+type Response struct {
+	*http.Response
+	Body []byte
+
+	t testing.TB
+}
+
+// AssertStatus fails the test if the status code isn't want
+func (r *Response) AssertStatus(want int) *Response {
+	r.t.Helper()
+	if r.StatusCode != want {
+		r.t.Fatalf("%s %s: status = %d, want %d; body: %s", r.Request.Method, r.Request.URL.Path, r.StatusCode, want, r.Body)
+	}
+	return r
+}
+
+// AssertHeader fails the test if header key doesn't equal want
+func (r *Response) AssertHeader(key, want string) *Response {
+	r.t.Helper()
+	if got := r.Header.Get(key); got != want {
+		r.t.Fatalf("header %s = %q, want %q", key, got, want)
+	}
+	return r
+}
+
+// DecodeJSON unmarshals the body into v
+func (r *Response) DecodeJSON(v any) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		r.t.Fatalf("decoding JSON response: %v; body: %s", err, r.Body)
+	}
+}
+
+// JSON returns the value at a dot-separated path in the JSON body ("data.0.name").
+// Numbers decode as float64, following encoding/json.
+func (r *Response) JSON(path string) any {
+	r.t.Helper()
+
+	var v any
+	r.DecodeJSON(&v)
+	if path == "" {
+		return v
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			val, ok := node[key]
+			if !ok {
+				r.t.Fatalf("JSON path %q: key %q not found; body: %s", path, key, r.Body)
+			}
+			v = val
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				r.t.Fatalf("JSON path %q: invalid index %q; body: %s", path, key, r.Body)
+			}
+			v = node[i]
+		default:
+			r.t.Fatalf("JSON path %q: cannot descend into %T at %q", path, v, key)
+		}
+	}
+	return v
+}
+
+// AssertJSON fails the test if the value at path doesn't deep-equal want
+func (r *Response) AssertJSON(path string, want any) *Response {
+	r.t.Helper()
+	if got := r.JSON(path); !reflect.DeepEqual(got, want) {
+		r.t.Fatalf("JSON path %q = %#v, want %#v; body: %s", path, got, want, r.Body)
+	}
+	return r
+}
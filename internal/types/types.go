@@ -1,10 +1,202 @@
 package types
 
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prashantkumbhar2002/go_students_api/internal/idgen"
+)
+
+// DateLayout is the wire and storage format for calendar dates (ISO 8601, no time part)
+const DateLayout = "2006-01-02"
+
 type Student struct {
-	ID    int64  `json:"id"`
-	Name  string `json:"name" validate:"required"`
-	Email string `json:"email" validate:"required,email"`
-	Age   int    `json:"age" validate:"required,min=18,max=100"`
+	// ID is the internal row ID. It never leaves the service: sequential IDs would reveal
+	// enrollment volume and let clients enumerate students.
+	ID int64 `json:"-"`
+	// PublicID is the UUID clients see as "id" and use in URLs
+	PublicID string `json:"id"`
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	// Age is derived from DateOfBirth on read when a date of birth is known.
+	// It is still accepted on create for clients that don't send a date of birth yet.
+	// Age bounds come from the configured validation policy, not struct tags.
+	Age         int    `json:"age" validate:"required_without=DateOfBirth"`
+	DateOfBirth string `json:"date_of_birth,omitempty" validate:"required_without=Age,omitempty,datetime=2006-01-02,past_date"`
+	// Phone is optional and stored normalised to E.164 (e.g. "+919876543210")
+	Phone string `json:"phone,omitempty" validate:"omitempty,phone"`
+	// CustomFields holds the values of the deployment's custom fields (see CustomField), keyed by
+	// field name. They are checked against the definitions on write, not by struct tags.
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+}
+
+// NewPublicID returns a new public ID from the deployment's ID strategy: a random (version 4) or
+// time-ordered (version 7) UUID (see idgen)
+func NewPublicID() string {
+	return idgen.Default().NewPublicID()
+}
+
+// ValidPublicID reports whether s is a UUID in the canonical lowercase form NewPublicID produces
+func ValidPublicID(s string) bool {
+	u, err := uuid.Parse(s)
+	return err == nil && u.String() == s
+}
+
+// AgeOn returns the age in completed years of someone born on dob at the given time
+func AgeOn(dob, now time.Time) int {
+	age := now.Year() - dob.Year()
+	// Birthday hasn't happened yet this year
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// DeriveAge sets Age from DateOfBirth (if present and well-formed) as of now
+func (s *Student) DeriveAge(now time.Time) {
+	if s.DateOfBirth == "" {
+		return
+	}
+	dob, err := time.Parse(DateLayout, s.DateOfBirth)
+	if err != nil {
+		return
+	}
+	s.Age = AgeOn(dob, now)
+}
+
+// ChangedFields lists the fields that differ between old and new by their JSON names, in the
+// order Student declares them. A custom field is listed as "custom_fields.<name>", in name order.
+// Ages are compared as given, so derive both first.
+func ChangedFields(old, new Student) []string {
+	changed := []string{}
+	for _, f := range []struct {
+		name string
+		same bool
+	}{
+		{"name", old.Name == new.Name},
+		{"email", old.Email == new.Email},
+		{"age", old.Age == new.Age},
+		{"date_of_birth", old.DateOfBirth == new.DateOfBirth},
+		{"phone", old.Phone == new.Phone},
+	} {
+		if !f.same {
+			changed = append(changed, f.name)
+		}
+	}
+
+	var custom []string
+	for name, v := range new.CustomFields {
+		if was, ok := old.CustomFields[name]; !ok || !reflect.DeepEqual(was, v) {
+			custom = append(custom, name)
+		}
+	}
+	for name := range old.CustomFields {
+		if _, ok := new.CustomFields[name]; !ok {
+			custom = append(custom, name)
+		}
+	}
+	slices.Sort(custom)
+	for _, name := range custom {
+		changed = append(changed, "custom_fields."+name)
+	}
+	return changed
+}
+
+// UpdatedStudent is the response to PUT and PATCH /students/{id}: the student as stored, and what
+// the request changed, so clients can skip downstream syncs when nothing did
+type UpdatedStudent struct {
+	Student
+	ChangedFields []string `json:"changed_fields"`
+}
+
+// BulkUpdate is the outcome of PATCH /students?filter=: how many students matched the filter, and
+// how many of them the patch changed
+type BulkUpdate struct {
+	Matched int64 `json:"matched"`
+	Updated int64 `json:"updated"`
+}
+
+// Kinds of StudentChange
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// StudentChange is one entry of the changes feed (GET /students/changes): a student created,
+// updated or deleted since the client last synced. Created and updated entries carry the
+// student as it is now; a deleted one only its ID.
+type StudentChange struct {
+	ID        string    `json:"id"`
+	Change    string    `json:"change"`
+	ChangedAt time.Time `json:"changed_at"`
+	Student   *Student  `json:"student,omitempty"`
+}
+
+// Entities events are numbered per (see FeedEvent)
+const (
+	EntityStudent      = "student"
+	EntityLoan         = "loan"
+	EntityAnnouncement = "announcement"
+)
+
+// EventEntities lists the entities GET /events serves
+var EventEntities = []string{EntityStudent, EntityLoan, EntityAnnouncement}
+
+// FeedEvent is a published event as the event log keeps it (GET /events). Seq numbers an
+// entity's events from 1 without gaps, so a consumer that saw seq n of an entity has missed
+// nothing up to it.
+type FeedEvent struct {
+	Seq    int64  `json:"seq"`
+	Entity string `json:"entity"`
+	// Type is the event's kind, e.g. student.created
+	Type string `json:"type"`
+	// Data holds what the event is about: {"students": [...]}, {"loans": [...]} or {"announcements": [...]}
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Usage is how many requests a client made in a calendar month (GET /admin/usage)
+type Usage struct {
+	// Client is the hex SHA-256 of the client's API key, or "anonymous" for requests without a known key
+	Client string `json:"client"`
+	// Month is the UTC calendar month, e.g. 2026-10
+	Month    string `json:"month"`
+	Requests int64  `json:"requests"`
+}
+
+// Types a custom field can have. Number values are JSON numbers and date values are strings in
+// DateLayout.
+const (
+	CustomString  = "string"
+	CustomNumber  = "number"
+	CustomBoolean = "boolean"
+	CustomDate    = "date"
+)
+
+// CustomFieldTypes lists the types a custom field can have
+var CustomFieldTypes = []string{CustomString, CustomNumber, CustomBoolean, CustomDate}
+
+// CustomField is an extra attribute an admin defines for every student, such as a locker number
+// or the previous school. Names are lowercase snake_case; a required field must be given on every
+// new student.
+type CustomField struct {
+	Name     string `json:"name" validate:"required,max=50"`
+	Type     string `json:"type" validate:"required,oneof=string number boolean date"`
+	Required bool   `json:"required"`
+}
+
+// View is a saved, named cohort of students: GET /students?view=<name> lists students as if its
+// filter and expand had been given. Names are lowercase with hyphens, like "scholarship-seniors".
+type View struct {
+	Name string `json:"name" validate:"required,max=64"`
+	// Filter is a GET /students?filter= expression; empty means every student
+	Filter    string    `json:"filter,omitempty" validate:"max=1000"`
+	Expand    []string  `json:"expand,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // PaginationParams holds pagination query parameters
@@ -19,15 +211,507 @@ type PaginatedResponse struct {
 	Page       int         `json:"page"`        // Current page
 	Limit      int         `json:"limit"`       // Items per page
 	TotalItems int64       `json:"total_items"` // Total number of items
-	TotalPages int         `json:"total_pages"` // Total number of pages
-	HasNext    bool        `json:"has_next"`    // Whether there's a next page
-	HasPrev    bool        `json:"has_prev"`    // Whether there's a previous page
+	// TotalItemsAsOf is when TotalItems was counted, set only when it came from a cache and may be a few seconds stale
+	TotalItemsAsOf *time.Time `json:"total_items_as_of,omitempty"`
+	TotalPages     int        `json:"total_pages"` // Total number of pages
+	HasNext        bool       `json:"has_next"`    // Whether there's a next page
+	HasPrev        bool       `json:"has_prev"`    // Whether there's a previous page
+}
+
+// Fixed pagination values; page sizes are per deployment (see PaginationLimits)
+const (
+	DefaultPage = 1
+	MinLimit    = 1
+)
+
+// PaginationLimits bound the page size (?limit=) of list endpoints
+type PaginationLimits struct {
+	Default int // used when the request gives no limit
+	Max     int // prevents clients from requesting too many records
+}
+
+// DefaultPaginationLimits apply when a deployment configures none
+var DefaultPaginationLimits = PaginationLimits{Default: 20, Max: 100}
+
+// Background job statuses. A failed attempt that will be retried goes back to JobQueued;
+// JobDead is the dead-letter state for jobs that exhausted their attempts.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+)
+
+// Job is a unit of persistent background work (see internal/jobs)
+type Job struct {
+	ID     int64  `json:"id"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	// StatusLabel is Status for display, in the request's language
+	StatusLabel string `json:"status_label,omitempty"`
+	// Payload is the handler's input; it isn't exposed over the API since it may be large
+	Payload     json.RawMessage `json:"-"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	// Progress is where a job that checkpoints (imports do) got to; it survives retries
+	Progress  json.RawMessage `json:"progress,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ImportProgress is an import job's checkpoint: the last data row it committed and what became
+// of the rows up to it. Rows are 1-based, like importer.RowError's.
+type ImportProgress struct {
+	Row      int `json:"row"`
+	Imported int `json:"imported"`
+	// Skipped counts rows whose email a student already had
+	Skipped int `json:"skipped"`
+}
+
+// StagedStudent is a valid row of a previewed import, waiting in the staging table. Row is its
+// 1-based data row in the file. It has no IDs until it is imported.
+type StagedStudent struct {
+	Row          int            `json:"row"`
+	Name         string         `json:"name"`
+	Email        string         `json:"email"`
+	Age          int            `json:"age"`
+	DateOfBirth  string         `json:"date_of_birth,omitempty"`
+	Phone        string         `json:"phone,omitempty"`
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+}
+
+// Stage returns s as data row row of a staged import
+func Stage(row int, s Student) StagedStudent {
+	return StagedStudent{Row: row, Name: s.Name, Email: s.Email, Age: s.Age, DateOfBirth: s.DateOfBirth, Phone: s.Phone, CustomFields: s.CustomFields}
+}
+
+// Student returns the student s would import
+func (s StagedStudent) Student() Student {
+	return Student{Name: s.Name, Email: s.Email, Age: s.Age, DateOfBirth: s.DateOfBirth, Phone: s.Phone, CustomFields: s.CustomFields}
+}
+
+// ImportConflict is a staged row that confirming won't import: its email is taken by an earlier
+// row of the file (FirstRow) or by a live student (StudentID)
+type ImportConflict struct {
+	Row       int    `json:"row"`
+	Email     string `json:"email"`
+	FirstRow  int    `json:"first_row,omitempty"`
+	StudentID string `json:"student_id,omitempty"`
+}
+
+// ImportPreview is what confirming a staged import would do, as it stands: the rows it would
+// create and the ones it would skip. The lists hold the first rows of each; the counts are exact.
+type ImportPreview struct {
+	Staged     int              `json:"staged"`
+	New        int              `json:"new"`
+	Duplicates int              `json:"duplicates"`
+	Conflicts  int              `json:"conflicts"`
+	Create     []StagedStudent  `json:"create"`
+	Duplicate  []ImportConflict `json:"duplicate"`
+	Conflict   []ImportConflict `json:"conflict"`
 }
 
-// Default pagination values
+// Student statuses reported by GET /stats/students. A student is anonymized once a retention
+// rule has scrubbed their personal data (see internal/retention).
 const (
-	DefaultPage  = 1
-	DefaultLimit = 20
-	MaxLimit     = 100 // Prevent clients from requesting too many records
-	MinLimit     = 1
+	StudentActive     = "active"
+	StudentAnonymized = "anonymized"
 )
+
+// AgeBucket is an inclusive age range; Max 0 means no upper bound
+type AgeBucket struct {
+	Label string
+	Min   int
+	Max   int
+}
+
+// AgeBuckets are the age ranges statistics are grouped by, youngest first
+var AgeBuckets = []AgeBucket{
+	{Label: "under 18", Min: 0, Max: 17},
+	{Label: "18-21", Min: 18, Max: 21},
+	{Label: "22-25", Min: 22, Max: 25},
+	{Label: "26-30", Min: 26, Max: 30},
+	{Label: "31-40", Min: 31, Max: 40},
+	{Label: "over 40", Min: 41},
+}
+
+// Count is one group of a grouped count
+type Count struct {
+	Key string `json:"key"`
+	// Label is Key for display, in the request's language; only set when Key is an enum value
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// StudentStats are aggregate figures for dashboards. Every bucket is listed, including empty ones.
+type StudentStats struct {
+	Total int64 `json:"total"`
+	// ByAge follows AgeBuckets; age is derived from the date of birth where known
+	ByAge    []Count `json:"by_age"`
+	ByStatus []Count `json:"by_status"`
+	// CreatedPerMonth is keyed "YYYY-MM" (UTC), oldest first, ending with the current month
+	CreatedPerMonth []Count `json:"created_per_month"`
+}
+
+// ReportQuery is a parsed GET /reports request (see internal/reports for the allowed names)
+type ReportQuery struct {
+	GroupBy []string `json:"group_by"`
+	Metrics []string `json:"metrics"`
+	Limit   int      `json:"-"`
+}
+
+// Filter is a parsed ?filter= expression (see internal/filter for the language): a comparison of
+// Field with Value using Op, or, when Op is "and", "or" or "not", a combination of Args
+type Filter struct {
+	Op    string `json:"op"`
+	Field string `json:"field,omitempty"`
+	// Value is an int for numeric fields and a string otherwise
+	Value any      `json:"value,omitempty"`
+	Args  []Filter `json:"args,omitempty"`
+}
+
+// Report is the result of a ReportQuery. Each row maps every group_by dimension to its value
+// and every metric to its number; rows are ordered by the dimensions.
+type Report struct {
+	ReportQuery
+	Rows []map[string]any `json:"rows"`
+	// Truncated is set when there were more groups than the limit
+	Truncated bool `json:"truncated"`
+}
+
+// SearchHit is one GET /students/search result. Score and Highlights are only set by
+// engines that rank and highlight (OpenSearch); highlighted fragments wrap matches in <em>.
+type SearchHit struct {
+	Student    Student             `json:"student"`
+	Score      float64             `json:"score,omitempty"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+// Reasons two students are reported as probable duplicates
+const (
+	DuplicateSameEmail   = "same_email"
+	DuplicateSimilarName = "similar_name"
+)
+
+// DuplicateGroup is a set of students that probably describe the same person, oldest first
+type DuplicateGroup struct {
+	Reasons []string `json:"reasons"`
+	// ReasonLabels are Reasons for display, in the request's language
+	ReasonLabels []string  `json:"reason_labels,omitempty"`
+	Students     []Student `json:"students"`
+}
+
+// Kinds of link between two students. Both are symmetric: a twin link reads the same from either side.
+const (
+	RelationshipSibling = "sibling"
+	RelationshipTwin    = "twin"
+)
+
+// RelationshipKinds lists the kinds a link can have
+var RelationshipKinds = []string{RelationshipSibling, RelationshipTwin}
+
+// Relationship is a link from one student to another, as seen from the first
+type Relationship struct {
+	Kind string `json:"kind"`
+	// KindLabel is Kind for display, in the request's language
+	KindLabel string `json:"kind_label,omitempty"`
+	// StudentID is the linked student's row ID; clients know them by PublicID
+	StudentID int64  `json:"-"`
+	PublicID  string `json:"student_id"`
+	Name      string `json:"name"`
+}
+
+// ExpandedStudent is a student with the related data a client asked for with ?expand=
+type ExpandedStudent struct {
+	Student
+	Relationships []Relationship `json:"relationships"`
+}
+
+// Book is one copy in the school library. Copies of the same title are separate books, so each
+// can be lent on its own.
+type Book struct {
+	ID       int64  `json:"-"`
+	PublicID string `json:"id"`
+	Title    string `json:"title" validate:"required,max=200"`
+	Author   string `json:"author,omitempty" validate:"max=200"`
+	ISBN     string `json:"isbn,omitempty" validate:"max=20"`
+	// OnLoan is set on reads: the book has a loan that isn't returned yet
+	OnLoan bool `json:"on_loan"`
+}
+
+// Loan is a book lent to a student. DueDate is a date in DateLayout; the book is overdue from the
+// day after it.
+type Loan struct {
+	ID              int64  `json:"-"`
+	BookID          int64  `json:"-"`
+	BookPublicID    string `json:"book_id"`
+	Title           string `json:"title"`
+	StudentID       int64  `json:"-"`
+	StudentPublicID string `json:"student_id"`
+	StudentName     string `json:"student_name"`
+	// StudentEmail is where overdue reminders go; it isn't part of any response
+	StudentEmail string     `json:"-"`
+	CheckedOutAt time.Time  `json:"checked_out_at"`
+	DueDate      string     `json:"due_date"`
+	ReturnedAt   *time.Time `json:"returned_at,omitempty"`
+	// DaysOverdue is set by overdue listings
+	DaysOverdue int `json:"days_overdue,omitempty"`
+}
+
+// DaysOverdue returns how many days after due (a DateLayout date) asOf's date is, in UTC: 1 on the
+// day after it. It is 0 when asOf isn't past due or due doesn't parse.
+func DaysOverdue(due string, asOf time.Time) int {
+	d, err := time.Parse(DateLayout, due)
+	if err != nil {
+		return 0
+	}
+	y, m, day := asOf.UTC().Date()
+	today := time.Date(y, m, day, 0, 0, 0, 0, time.UTC)
+	return max(int(today.Sub(d).Hours()/24), 0)
+}
+
+// Hostel is a residence building; students live in its rooms
+type Hostel struct {
+	ID       int64  `json:"-"`
+	PublicID string `json:"id"`
+	Name     string `json:"name" validate:"required,max=100"`
+}
+
+// Room is a hostel room with beds for Capacity students
+type Room struct {
+	ID             int64  `json:"-"`
+	PublicID       string `json:"id"`
+	HostelID       int64  `json:"-"`
+	HostelPublicID string `json:"hostel_id"`
+	Number         string `json:"number" validate:"required,max=20"`
+	Capacity       int    `json:"capacity" validate:"required,min=1,max=50"`
+	// Occupied is set on reads: how many students are allocated the room
+	Occupied int `json:"occupied"`
+}
+
+// Allocation is a student's bed in a room. A student has at most one.
+type Allocation struct {
+	RoomID          int64     `json:"-"`
+	RoomPublicID    string    `json:"room_id"`
+	RoomNumber      string    `json:"room_number"`
+	StudentID       int64     `json:"-"`
+	StudentPublicID string    `json:"student_id"`
+	StudentName     string    `json:"student_name"`
+	AllocatedAt     time.Time `json:"allocated_at"`
+}
+
+// HostelOccupancy sums up one hostel's rooms for the occupancy report
+type HostelOccupancy struct {
+	HostelPublicID string `json:"hostel_id"`
+	Name           string `json:"name"`
+	Rooms          int    `json:"rooms"`
+	Capacity       int    `json:"capacity"`
+	Occupied       int    `json:"occupied"`
+	Vacant         int    `json:"vacant"`
+}
+
+// BusRoute is a school bus route: its stops in the order the bus reaches them, and seats for
+// Capacity riders
+type BusRoute struct {
+	ID       int64  `json:"-"`
+	PublicID string `json:"id"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Capacity int    `json:"capacity" validate:"required,min=1,max=200"`
+	Stops    []Stop `json:"stops" validate:"required,min=1,max=100,dive"`
+	// Riders is set on reads: how many students are assigned the route
+	Riders int `json:"riders"`
+}
+
+// Stop is where a route picks students up. Position orders a route's stops, from 1.
+type Stop struct {
+	ID       int64  `json:"-"`
+	PublicID string `json:"id"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Position int    `json:"position"`
+}
+
+// RouteAssignment is a student riding a route from one of its stops. A student rides at most one
+// route.
+type RouteAssignment struct {
+	RouteID         int64     `json:"-"`
+	RoutePublicID   string    `json:"route_id"`
+	StopID          int64     `json:"-"`
+	StopPublicID    string    `json:"stop_id"`
+	StopName        string    `json:"stop_name"`
+	StopPosition    int       `json:"stop_position"`
+	StudentID       int64     `json:"-"`
+	StudentPublicID string    `json:"student_id"`
+	StudentName     string    `json:"student_name"`
+	StudentPhone    string    `json:"student_phone,omitempty"`
+	AssignedAt      time.Time `json:"assigned_at"`
+}
+
+// Alumnus is a graduated student: their record as it stands, and the class they left with.
+// Alumni are out of every student read; GET /alumni lists them.
+type Alumnus struct {
+	Student
+	ClassOf int `json:"class_of"`
+	// GraduatedOn is a date in DateLayout
+	GraduatedOn string `json:"graduated_on"`
+}
+
+// AlumniFilter narrows GET /alumni; zero fields match everyone
+type AlumniFilter struct {
+	ClassOf int
+	// Query matches a substring of the name or email, ignoring case
+	Query string
+}
+
+// Application statuses. An application is reviewed and then accepted, and can be rejected until
+// it is accepted; ApplicationTransitions lists the allowed moves.
+const (
+	ApplicationSubmitted = "submitted"
+	ApplicationReviewed  = "reviewed"
+	ApplicationAccepted  = "accepted"
+	ApplicationRejected  = "rejected"
+)
+
+// ApplicationStatuses lists every application status in pipeline order
+var ApplicationStatuses = []string{ApplicationSubmitted, ApplicationReviewed, ApplicationAccepted, ApplicationRejected}
+
+// ApplicationTransitions maps a status to the statuses an application can move to from it.
+// Accepted and rejected applications stay as they are.
+var ApplicationTransitions = map[string][]string{
+	ApplicationSubmitted: {ApplicationReviewed, ApplicationRejected},
+	ApplicationReviewed:  {ApplicationAccepted, ApplicationRejected},
+}
+
+// Application is a request for admission. It holds the details the student record will be made
+// from, and stays apart from students until it is accepted and converted.
+type Application struct {
+	ID          int64  `json:"-"`
+	PublicID    string `json:"id"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Age         int    `json:"age,omitempty"`
+	DateOfBirth string `json:"date_of_birth,omitempty"`
+	Phone       string `json:"phone,omitempty"`
+	Status      string `json:"status"`
+	// StatusLabel is Status for display, in the request's language
+	StatusLabel string `json:"status_label,omitempty"`
+	// StudentPublicID is the student made from the application, once it is converted
+	StudentPublicID string    `json:"student_id,omitempty"`
+	SubmittedAt     time.Time `json:"submitted_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Student returns the applicant's details as a new student record
+func (a Application) Student() Student {
+	return Student{Name: a.Name, Email: a.Email, Age: a.Age, DateOfBirth: a.DateOfBirth, Phone: a.Phone}
+}
+
+// Delivery statuses of an announcement to one student. A delivery is queued until the
+// announcement job has tried it. Text messages move on from sent to delivered or failed when
+// the SMS provider reports back.
+const (
+	DeliveryQueued    = "queued"
+	DeliverySent      = "sent"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Channels an announcement can be sent on
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Announcement is a message emailed, or texted, to every student matching Filter
+type Announcement struct {
+	ID       int64  `json:"-"`
+	PublicID string `json:"id"`
+	Subject  string `json:"subject" validate:"required,max=200"`
+	Body     string `json:"body" validate:"required,max=10000"`
+	// Channel is ChannelEmail or ChannelSMS; "" means email
+	Channel string `json:"channel" validate:"omitempty,oneof=email sms"`
+	// Filter is the filter expression (see internal/filter) that chose the recipients; "" means every student
+	Filter string `json:"filter,omitempty"`
+	// Recipients is how many students the announcement went to
+	Recipients int `json:"recipients"`
+	// Deliveries counts the recipients by delivery status
+	Deliveries map[string]int `json:"deliveries"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// Delivery is an announcement on its way to one student
+type Delivery struct {
+	AnnouncementID int64
+	StudentID      int64
+	Name           string
+	Email          string
+	Phone          string
+	Status         string
+	// MessageID is the SMS provider's ID for a text message, which its delivery reports refer to
+	MessageID string
+	// Error says why a failed delivery failed
+	Error string
+}
+
+// StudentAnnouncement is an announcement as one student received it
+type StudentAnnouncement struct {
+	PublicID  string    `json:"id"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	Channel   string    `json:"channel"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+	// StatusLabel is Status for display, in the request's language
+	StatusLabel string `json:"status_label,omitempty"`
+	// Error says why a failed delivery failed
+	Error string `json:"error,omitempty"`
+}
+
+// Webhook is a subscription to events, POSTed to URL as they happen and signed with Secret.
+// A subscription belongs to the API key that created it, and only that key sees it.
+type Webhook struct {
+	ID       int64  `json:"-"`
+	PublicID string `json:"id"`
+	// Owner is the hex SHA-256 of the API key that created the subscription; keys aren't stored
+	Owner  string   `json:"-"`
+	URL    string   `json:"url" validate:"required,url,max=2000"`
+	Events []string `json:"events" validate:"required,min=1"`
+	// Secret signs the deliveries. Responses only carry it when it is new: on creation and rotation.
+	Secret string `json:"secret,omitempty"`
+	// PreviousSecret signs deliveries too until PreviousSecretExpiresAt, so a receiver can switch
+	// to a rotated secret without rejecting any
+	PreviousSecret          string     `json:"-"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+}
+
+// WebhookDeliveryStatuses lists the statuses of a webhook delivery: DeliveryQueued while it is
+// being tried, and DeliveryFailed once its attempts are used up (dead-lettered). A delivered one
+// is forgotten.
+var WebhookDeliveryStatuses = []string{DeliveryQueued, DeliveryFailed}
+
+// WebhookDelivery is one event on its way to one subscription, kept until the receiver accepts it
+// so a failed one can be inspected and replayed
+type WebhookDelivery struct {
+	ID       int64  `json:"-"`
+	PublicID string `json:"id"`
+	// WebhookID is the subscription's public ID
+	WebhookID string `json:"webhook_id"`
+	Event     string `json:"event"`
+	// EventID is the id in Body, which every attempt and replay repeats
+	EventID string `json:"event_id"`
+	// Body is the JSON POSTed
+	Body   json.RawMessage `json:"body,omitempty"`
+	Status string          `json:"status"`
+	// Attempts counts every try, replays included
+	Attempts int `json:"attempts"`
+	// ResponseStatus and ResponseBody (its first 4 KiB) are what the receiver last answered;
+	// Error says why the last attempt failed
+	ResponseStatus int       `json:"response_status,omitempty"`
+	ResponseBody   string    `json:"response_body,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -0,0 +1,78 @@
+package validation
+
+import (
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// MaxCustomStringLength caps a string custom field's value, so custom fields can't become a
+// document store
+const MaxCustomStringLength = 500
+
+// customFieldName is the form of a custom field's name: it is used as a JSON key and in filters
+var customFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// ValidCustomFieldName reports whether name can name a custom field: a lowercase letter followed
+// by up to 49 lowercase letters, digits and underscores
+func ValidCustomFieldName(name string) bool {
+	return customFieldName.MatchString(name)
+}
+
+// CustomFields checks a student's custom field values against the definitions and returns what
+// is wrong, in lang, in definition order and then by field name. Every value must be defined and
+// have its field's type, and required fields must have a value. An empty result means the values
+// are valid.
+func CustomFields(defs []types.CustomField, values map[string]any, lang string) []string {
+	var errs []string
+	for _, def := range defs {
+		v, ok := values[def.Name]
+		if !ok || v == nil || v == "" {
+			if def.Required {
+				errs = append(errs, i18n.Tf(lang, i18n.MsgCustomRequiredf, def.Name))
+			}
+			continue
+		}
+		if !customValueOK(def.Type, v) {
+			errs = append(errs, i18n.Tf(lang, i18n.MsgCustomTypef, def.Name, def.Type))
+		}
+	}
+
+	var unknown []string
+	for name := range values {
+		if !slices.ContainsFunc(defs, func(d types.CustomField) bool { return d.Name == name }) {
+			unknown = append(unknown, name)
+		}
+	}
+	slices.Sort(unknown)
+	for _, name := range unknown {
+		errs = append(errs, i18n.Tf(lang, i18n.MsgCustomUnknownf, name))
+	}
+	return errs
+}
+
+// customValueOK reports whether v, as decoded from JSON, is a value of the custom field type typ
+func customValueOK(typ string, v any) bool {
+	switch typ {
+	case types.CustomString:
+		s, ok := v.(string)
+		return ok && len(s) <= MaxCustomStringLength
+	case types.CustomNumber:
+		_, ok := v.(float64)
+		return ok
+	case types.CustomBoolean:
+		_, ok := v.(bool)
+		return ok
+	case types.CustomDate:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		_, err := time.Parse(types.DateLayout, s)
+		return err == nil
+	}
+	return false
+}
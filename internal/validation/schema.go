@@ -0,0 +1,167 @@
+package validation
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/prashantkumbhar2002/go_students_api/api"
+)
+
+// Mode selects how request bodies are validated
+type Mode string
+
+const (
+	// ModeStruct validates decoded structs using validator tags (default)
+	ModeStruct Mode = "struct"
+	// ModeSchema validates raw bodies against the embedded JSON Schemas first
+	ModeSchema Mode = "jsonschema"
+)
+
+// Schema names, relative to api/schemas
+const SchemaStudent = "student.json"
+
+var mode = ModeStruct
+
+// SetMode selects the validation mode. Call it once at startup before serving requests.
+func SetMode(m Mode) {
+	mode = m
+}
+
+// SchemaMode reports whether request bodies should be validated against JSON Schemas
+func SchemaMode() bool {
+	return mode == ModeSchema
+}
+
+// SchemaError is a single schema violation. Pointer is a JSON pointer into the request body (e.g. "/age").
+type SchemaError struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+func (e SchemaError) String() string {
+	if e.Pointer == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Pointer, e.Message)
+}
+
+// SchemaBaseURL is the base URL the embedded API documents are registered under.
+// A fixed absolute URL keeps $ref resolution independent of the working directory.
+const SchemaBaseURL = "https://students-api.local/api/"
+
+// OpenAPIURL is the URL of the embedded OpenAPI document; append a JSON pointer fragment
+// to compile one of its schemas (e.g. OpenAPIURL + "#/components/schemas/Error")
+const OpenAPIURL = SchemaBaseURL + "openapi.json"
+
+var (
+	schemasOnce sync.Once
+	schemas     map[string]*jsonschema.Schema
+	schemasErr  error
+)
+
+// NewSchemaCompiler returns a compiler with every embedded API document (schemas/*.json and
+// openapi.json) registered under SchemaBaseURL, with format assertions enabled
+func NewSchemaCompiler() (*jsonschema.Compiler, error) {
+	c := jsonschema.NewCompiler()
+	c.AssertFormat()
+
+	names, err := fs.Glob(api.Schemas, "schemas/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		data, err := api.Schemas.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := addResource(c, name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := addResource(c, "openapi.json", api.OpenAPI); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func addResource(c *jsonschema.Compiler, name string, data []byte) error {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", name, err)
+	}
+	if err := c.AddResource(SchemaBaseURL+name, doc); err != nil {
+		return fmt.Errorf("adding %s: %w", name, err)
+	}
+	return nil
+}
+
+// compileSchemas compiles every embedded schema once, on first use
+func compileSchemas() {
+	c, err := NewSchemaCompiler()
+	if err != nil {
+		schemasErr = err
+		return
+	}
+
+	names, err := fs.Glob(api.Schemas, "schemas/*.json")
+	if err != nil {
+		schemasErr = err
+		return
+	}
+
+	schemas = make(map[string]*jsonschema.Schema, len(names))
+	for _, name := range names {
+		sch, err := c.Compile(SchemaBaseURL + name)
+		if err != nil {
+			schemasErr = fmt.Errorf("compiling schema %s: %w", name, err)
+			return
+		}
+		schemas[path.Base(name)] = sch
+	}
+}
+
+// ValidateJSON validates body against the named schema.
+// It returns the list of violations (nil if valid), or an error if the body isn't JSON or the schema is unknown.
+func ValidateJSON(schema string, body []byte) ([]SchemaError, error) {
+	schemasOnce.Do(compileSchemas)
+	if schemasErr != nil {
+		return nil, schemasErr
+	}
+
+	sch, ok := schemas[schema]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q", schema)
+	}
+
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	err = sch.Validate(inst)
+	if err == nil {
+		return nil, nil
+	}
+
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+
+	// Basic output flattens the error tree into leaf errors with instance pointers
+	var errs []SchemaError
+	for _, unit := range ve.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		errs = append(errs, SchemaError{Pointer: unit.InstanceLocation, Message: unit.Error.String()})
+	}
+	return errs, nil
+}
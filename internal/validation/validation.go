@@ -0,0 +1,198 @@
+package validation
+
+import (
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/hi"
+	"github.com/go-playground/locales/mr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/clock"
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/phone"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// validate is the shared validator instance.
+// validator.New() caches struct metadata, so building one per request (as we used to) throws that cache away.
+var validate *validator.Validate
+
+// policy holds the deployment-specific rules enforced by validateStudentPolicy
+var policy = DefaultPolicy()
+
+// clk is used by past_date; tests can swap it with SetClock
+var clk clock.Clock = clock.Real{}
+
+// uni holds one translator per supported language
+var uni *ut.UniversalTranslator
+
+func init() {
+	validate = validator.New()
+
+	// Report field names as the client sees them (json tag) rather than Go field names
+	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	if err := validate.RegisterValidation("past_date", isPastDate); err != nil {
+		log.Fatalf("cannot register past_date validation: %v", err)
+	}
+	if err := validate.RegisterValidation("phone", isPhone); err != nil {
+		log.Fatalf("cannot register phone validation: %v", err)
+	}
+
+	// Rules that vary per deployment can't live in struct tags, so they're checked at struct level
+	validate.RegisterStructValidation(validateStudentPolicy, types.Student{})
+
+	enLocale := en.New()
+	uni = ut.New(enLocale, enLocale, hi.New(), mr.New())
+
+	enTrans, _ := uni.GetTranslator(i18n.LangEnglish)
+	if err := en_translations.RegisterDefaultTranslations(validate, enTrans); err != nil {
+		log.Fatalf("cannot register english validation translations: %v", err)
+	}
+	registerTranslations(i18n.LangEnglish, map[string]string{
+		"past_date": "{0} must be a date in the past",
+		"phone":     "{0} must be a valid phone number",
+	})
+
+	// validator ships no Hindi/Marathi translations, so we register the tags we use ourselves
+	registerTranslations(i18n.LangHindi, map[string]string{
+		"required": "{0} आवश्यक है",
+		"email":    "{0} एक मान्य ईमेल होना चाहिए",
+		"min":      "{0} कम से कम {1} होना चाहिए",
+		"max":      "{0} अधिकतम {1} हो सकता है",
+
+		"required_without": "{0} आवश्यक है",
+		"datetime":         "{0} का प्रारूप {1} होना चाहिए",
+		"past_date":        "{0} अतीत की तारीख होनी चाहिए",
+		"phone":            "{0} एक मान्य फ़ोन नंबर होना चाहिए",
+		"url":              "{0} एक मान्य URL होना चाहिए",
+		"oneof":            "{0} इनमें से एक होना चाहिए: {1}",
+	})
+	registerTranslations(i18n.LangMarathi, map[string]string{
+		"required": "{0} आवश्यक आहे",
+		"email":    "{0} वैध ईमेल असणे आवश्यक आहे",
+		"min":      "{0} किमान {1} असणे आवश्यक आहे",
+		"max":      "{0} कमाल {1} असू शकते",
+
+		"required_without": "{0} आवश्यक आहे",
+		"datetime":         "{0} चे स्वरूप {1} असणे आवश्यक आहे",
+		"past_date":        "{0} भूतकाळातील तारीख असणे आवश्यक आहे",
+		"phone":            "{0} वैध फोन नंबर असणे आवश्यक आहे",
+		"url":              "{0} वैध URL असणे आवश्यक आहे",
+		"oneof":            "{0} यांपैकी एक असणे आवश्यक आहे: {1}",
+	})
+}
+
+func registerTranslations(lang string, messages map[string]string) {
+	trans, _ := uni.GetTranslator(lang)
+	for tag, text := range messages {
+		err := validate.RegisterTranslation(tag, trans,
+			func(ut ut.Translator) error {
+				return ut.Add(tag, text, true)
+			},
+			func(ut ut.Translator, fe validator.FieldError) string {
+				msg, err := ut.T(fe.Tag(), fe.Field(), fe.Param())
+				if err != nil {
+					return fe.Error()
+				}
+				return msg
+			},
+		)
+		if err != nil {
+			log.Fatalf("cannot register %s validation translation for %s: %v", lang, tag, err)
+		}
+	}
+}
+
+// Policy holds validation rules that differ between deployments
+type Policy struct {
+	MinAge             int
+	MaxAge             int
+	RequireDateOfBirth bool
+	RequirePhone       bool
+}
+
+// DefaultPolicy returns the rules used when no policy is configured
+func DefaultPolicy() Policy {
+	return Policy{MinAge: 18, MaxAge: 100}
+}
+
+// SetPolicy replaces the active policy. Call it once at startup before serving requests.
+func SetPolicy(p Policy) {
+	policy = p
+}
+
+// validateStudentPolicy enforces the configured policy on a Student.
+// Errors reuse the standard tags (min, max, required) so they get the same translations.
+func validateStudentPolicy(sl validator.StructLevel) {
+	student := sl.Current().Interface().(types.Student)
+
+	// Age == 0 means it was omitted; required_without already reports that
+	if student.Age != 0 {
+		if student.Age < policy.MinAge {
+			sl.ReportError(student.Age, "age", "Age", "min", strconv.Itoa(policy.MinAge))
+		}
+		if policy.MaxAge > 0 && student.Age > policy.MaxAge {
+			sl.ReportError(student.Age, "age", "Age", "max", strconv.Itoa(policy.MaxAge))
+		}
+	}
+
+	if policy.RequireDateOfBirth && student.DateOfBirth == "" {
+		sl.ReportError(student.DateOfBirth, "date_of_birth", "DateOfBirth", "required", "")
+	}
+	if policy.RequirePhone && student.Phone == "" {
+		sl.ReportError(student.Phone, "phone", "Phone", "required", "")
+	}
+}
+
+// SetClock replaces the clock used by time-dependent rules such as past_date
+func SetClock(c clock.Clock) {
+	clk = clock.OrReal(c)
+}
+
+// isPastDate validates a YYYY-MM-DD string that lies strictly before today.
+// Malformed dates are left to the datetime tag so the client gets one clear error.
+func isPastDate(fl validator.FieldLevel) bool {
+	dob, err := time.Parse(types.DateLayout, fl.Field().String())
+	if err != nil {
+		return true
+	}
+	return dob.Before(clk.Now().Truncate(24 * time.Hour))
+}
+
+// isPhone validates a phone number in international or default-region national format
+func isPhone(fl validator.FieldLevel) bool {
+	return phone.Valid(fl.Field().String(), "")
+}
+
+// Validator returns the shared validator instance
+func Validator() *validator.Validate {
+	return validate
+}
+
+// Struct validates s using the shared validator
+func Struct(s any) error {
+	return validate.Struct(s)
+}
+
+// Translator returns the translator for lang, falling back to English
+func Translator(lang string) ut.Translator {
+	trans, found := uni.GetTranslator(lang)
+	if !found {
+		trans, _ = uni.GetTranslator(i18n.DefaultLang)
+	}
+	return trans
+}
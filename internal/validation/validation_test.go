@@ -0,0 +1,44 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/i18n"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+func TestTranslations(t *testing.T) {
+	webhook := types.Webhook{URL: "not a url", Events: []string{"student.created"}}
+	announcement := types.Announcement{Subject: "Exams", Body: "Exams start Monday", Channel: "fax"}
+	tests := []struct {
+		name  string
+		value any
+		lang  string
+		want  string
+	}{
+		{"required en", types.CustomField{Type: "string"}, i18n.LangEnglish, "name is a required field"},
+		{"required hi", types.CustomField{Type: "string"}, i18n.LangHindi, "name आवश्यक है"},
+		{"url en", webhook, i18n.LangEnglish, "url must be a valid URL"},
+		{"url hi", webhook, i18n.LangHindi, "url एक मान्य URL होना चाहिए"},
+		{"url mr", webhook, i18n.LangMarathi, "url वैध URL असणे आवश्यक आहे"},
+		{"oneof en", types.CustomField{Name: "locker", Type: "text"}, i18n.LangEnglish, "type must be one of [string number boolean date]"},
+		{"oneof hi", types.CustomField{Name: "locker", Type: "text"}, i18n.LangHindi, "type इनमें से एक होना चाहिए: string number boolean date"},
+		{"oneof mr", types.CustomField{Name: "locker", Type: "text"}, i18n.LangMarathi, "type यांपैकी एक असणे आवश्यक आहे: string number boolean date"},
+		{"channel hi", announcement, i18n.LangHindi, "channel इनमें से एक होना चाहिए: email sms"},
+		{"channel mr", announcement, i18n.LangMarathi, "channel यांपैकी एक असणे आवश्यक आहे: email sms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verrs validator.ValidationErrors
+			if err := Struct(tt.value); !errors.As(err, &verrs) || len(verrs) != 1 {
+				t.Fatalf("Struct(%+v) = %v, want one validation error", tt.value, err)
+			}
+			if got := verrs[0].Translate(Translator(tt.lang)); got != tt.want {
+				t.Errorf("Translate(%s) = %q, want %q", tt.lang, got, tt.want)
+			}
+		})
+	}
+}
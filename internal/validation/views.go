@@ -0,0 +1,12 @@
+package validation
+
+import "regexp"
+
+// viewName is the form of a saved view's name: it goes in ?view= unescaped
+var viewName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// ValidViewName reports whether name can name a saved view: up to 64 lowercase letters, digits
+// and hyphens, starting with a letter or digit
+func ValidViewName(name string) bool {
+	return viewName.MatchString(name)
+}
@@ -0,0 +1,259 @@
+// Package webhooks delivers events to the URLs API-key holders subscribe (POST /webhooks).
+//
+// Subscribe turns every event a subscription names into a job per subscription, and the job POSTs
+// the event to the subscription's URL, so a receiver that is down gets it once it is back up. A
+// delivery is signed with the subscription's secret, and also with its previous one for a while
+// after a rotation, so receivers can check it came from us:
+//
+//	X-Webhook-Timestamp: 1718000000
+//	X-Webhook-Signature: v1=<hex HMAC-SHA256 of "1718000000.<body>">[,v1=<...with the previous secret>]
+//
+// A retried delivery sends the same body, whose id receivers can use to drop duplicates.
+//
+// Every delivery is stored until the receiver accepts it. One still failing after its last attempt
+// is kept as failed, with what the receiver last answered, for its subscriber to inspect and
+// Replay (GET /webhooks/{id}/deliveries?status=failed).
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/events"
+	"github.com/prashantkumbhar2002/go_students_api/internal/jobs"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+// Kind is the job kind webhook deliveries are enqueued under
+const Kind = "webhook"
+
+// EventTest is the event POST /webhooks/{id}/test sends; subscriptions can't name it
+const EventTest = "webhook.test"
+
+// Events lists the event kinds a subscription can name
+var Events = []string{
+	string(events.StudentCreated),
+	string(events.StudentUpdated),
+	string(events.StudentDeleted),
+	string(events.StudentGraduated),
+	string(events.StudentsChanged),
+}
+
+// Delivery headers
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// responseLimit caps how much of a receiver's answer a delivery keeps
+const responseLimit = 4 << 10
+
+// Payload is the payload of a Kind job
+type Payload struct {
+	// DeliveryID names the stored delivery, whose body was fixed when the event happened so
+	// every attempt and replay sends the same
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// Result is stored as the job's result
+type Result struct {
+	// Status is the receiver's HTTP status
+	Status int `json:"status"`
+}
+
+// body is what a delivery POSTs
+type body struct {
+	// ID identifies the event; retries of a delivery repeat it
+	ID string `json:"id"`
+	// Seq is the event's number in the event log (GET /events), when there is one
+	Seq       int64     `json:"seq,omitempty"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Enqueuer is the part of jobs.Runner Subscribe, Test and Replay need
+type Enqueuer interface {
+	Enqueue(ctx context.Context, kind string, payload any) (int64, error)
+}
+
+// NewSecret returns a new random signing secret
+func NewSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Subscribe queues a delivery job per subscription to every event published on bus
+func Subscribe(bus events.Subscriber, store storage.Webhooks, q Enqueuer) {
+	kinds := make([]events.Kind, len(Events))
+	for i, k := range Events {
+		kinds[i] = events.Kind(k)
+	}
+	bus.Subscribe("webhooks", func(ctx context.Context, e events.Event) {
+		hooks, err := store.WebhooksFor(ctx, string(e.Kind))
+		if err != nil {
+			slog.Error("Error listing webhooks", "event", e.Kind, "error", err)
+			return
+		}
+		if len(hooks) == 0 {
+			return
+		}
+		students := e.Students
+		if students == nil {
+			students = []types.Student{}
+		}
+		b, err := json.Marshal(body{ID: types.NewPublicID(), Seq: e.Seq, Type: string(e.Kind), CreatedAt: e.At.UTC(), Data: map[string]any{"students": students}})
+		if err != nil {
+			slog.Error("Error encoding webhook event", "event", e.Kind, "error", err)
+			return
+		}
+		for _, h := range hooks {
+			if _, err := queue(ctx, store, q, h, string(e.Kind), b); err != nil {
+				slog.Error("Error queueing webhook delivery", "id", h.PublicID, "event", e.Kind, "error", err)
+			}
+		}
+	}, kinds...)
+}
+
+// Test queues a EventTest delivery to hook and returns the job's ID
+func Test(ctx context.Context, store storage.Webhooks, q Enqueuer, hook types.Webhook, now time.Time) (int64, error) {
+	b, err := json.Marshal(body{ID: types.NewPublicID(), Type: EventTest, CreatedAt: now.UTC(), Data: map[string]any{"webhook_id": hook.PublicID}})
+	if err != nil {
+		return 0, err
+	}
+	return queue(ctx, store, q, hook, EventTest, b)
+}
+
+// Replay queues failed delivery d again, with the body it was first sent with, and returns the
+// job's ID. Its response and error stay until the next attempt replaces them.
+func Replay(ctx context.Context, store storage.Webhooks, q Enqueuer, d types.WebhookDelivery) (int64, error) {
+	d.Status = types.DeliveryQueued
+	if err := store.UpdateWebhookDelivery(ctx, d); err != nil {
+		return 0, err
+	}
+	return q.Enqueue(ctx, Kind, Payload{DeliveryID: d.ID})
+}
+
+// queue stores a delivery of event with body b to hook and enqueues its job
+func queue(ctx context.Context, store storage.Webhooks, q Enqueuer, hook types.Webhook, event string, b []byte) (int64, error) {
+	var id struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(b, &id)
+	d, err := store.CreateWebhookDelivery(ctx, types.WebhookDelivery{WebhookID: hook.PublicID, Event: event, EventID: id.ID, Body: b})
+	if err != nil {
+		return 0, err
+	}
+	return q.Enqueue(ctx, Kind, Payload{DeliveryID: d.ID})
+}
+
+// Sign returns the v1 signature of body sent at timestamp (Unix seconds) with secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handler returns the job handler for Kind jobs. A delivery fails, and is retried, unless the
+// receiver answers 2xx; once accepted it is forgotten, and once its job has no attempts left it
+// is marked failed. A test delivery is tried once, so GET /jobs/{id} has its outcome straight
+// away. Deliveries to a subscription deleted since they were queued are dropped.
+func Handler(store storage.Webhooks, client *http.Client) jobs.Handler {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context, job types.Job) (any, error) {
+		var p Payload
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+		d, err := store.GetWebhookDelivery(ctx, p.DeliveryID)
+		if errors.Is(err, storage.ErrDeliveryNotFound) {
+			slog.Info("Webhook delivery dropped, subscription deleted", "delivery_id", p.DeliveryID, "job_id", job.ID)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		hook, err := store.GetWebhook(ctx, d.WebhookID)
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			slog.Info("Webhook delivery dropped, subscription deleted", "id", d.WebhookID, "job_id", job.ID)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		status, answer, err := deliver(ctx, client, hook, d)
+		if err == nil {
+			if err := store.DeleteWebhookDelivery(ctx, d.ID); err != nil && !errors.Is(err, storage.ErrDeliveryNotFound) {
+				slog.Error("Error forgetting webhook delivery", "delivery_id", d.ID, "error", err)
+			}
+			slog.Info("Webhook delivered", "id", hook.PublicID, "event", d.Event, "job_id", job.ID, "status", status)
+			return Result{Status: status}, nil
+		}
+
+		if d.Event == EventTest {
+			err = jobs.Permanent(err)
+		}
+		d.Attempts++
+		d.ResponseStatus, d.ResponseBody, d.Error = status, answer, err.Error()
+		if d.Event == EventTest || job.Attempts >= job.MaxAttempts {
+			d.Status = types.DeliveryFailed
+		}
+		if uerr := store.UpdateWebhookDelivery(ctx, d); uerr != nil && !errors.Is(uerr, storage.ErrDeliveryNotFound) {
+			slog.Error("Error recording webhook delivery failure", "delivery_id", d.ID, "error", uerr)
+		}
+		return nil, err
+	}
+}
+
+// deliver POSTs d's body to hook's URL and returns the receiver's status and the start of its
+// answer
+func deliver(ctx context.Context, client *http.Client, hook types.Webhook, d types.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, "", jobs.Permanent(err)
+	}
+	now := time.Now().Unix()
+	signatures := []string{Sign(hook.Secret, now, d.Body)}
+	if hook.PreviousSecret != "" {
+		signatures = append(signatures, Sign(hook.PreviousSecret, now, d.Body))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, d.EventID)
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now, 10))
+	req.Header.Set(HeaderSignature, strings.Join(signatures, ","))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("delivering to %s: %w", hook.URL, err)
+	}
+	defer resp.Body.Close()
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, responseLimit))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, strings.ToValidUTF8(string(answer), ""), fmt.Errorf("%s answered %d", hook.URL, resp.StatusCode)
+	}
+	return resp.StatusCode, "", nil
+}
@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prashantkumbhar2002/go_students_api/internal/events"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage"
+	"github.com/prashantkumbhar2002/go_students_api/internal/storage/storagetest"
+	"github.com/prashantkumbhar2002/go_students_api/internal/types"
+)
+
+type fakeQueue []Payload
+
+func (q *fakeQueue) Enqueue(ctx context.Context, kind string, payload any) (int64, error) {
+	*q = append(*q, payload.(Payload))
+	return int64(len(*q)), nil
+}
+
+// receiver records the deliveries it gets and answers them with status
+type receiver struct {
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, _ := io.ReadAll(r.Body)
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, b)
+	w.WriteHeader(rc.status)
+	io.WriteString(w, http.StatusText(rc.status))
+}
+
+// job is p's job on its attempt of three
+func job(p Payload, attempt int) types.Job {
+	b, _ := json.Marshal(p)
+	return types.Job{ID: 1, Kind: Kind, Payload: b, Attempts: attempt, MaxAttempts: 3}
+}
+
+func TestSubscribeQueuesPerSubscription(t *testing.T) {
+	ctx := context.Background()
+	store := storagetest.NewFake()
+	created, _ := store.CreateWebhook(ctx, types.Webhook{Owner: "a", URL: "https://a.example.com", Events: []string{"student.created"}, Secret: "s"})
+	store.CreateWebhook(ctx, types.Webhook{Owner: "b", URL: "https://b.example.com", Events: []string{"student.deleted"}, Secret: "s"})
+
+	bus := events.New()
+	var q fakeQueue
+	Subscribe(bus, store, &q)
+	if _, err := events.NewStore(store, bus).CreateStudent(types.Student{Name: "Asha", Email: "asha@example.com", Age: 20}); err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	if len(q) != 1 {
+		t.Fatalf("queued %+v, want one delivery", q)
+	}
+	d, err := store.GetWebhookDelivery(ctx, q[0].DeliveryID)
+	if err != nil || d.WebhookID != created.PublicID || d.Event != "student.created" || d.Status != types.DeliveryQueued {
+		t.Fatalf("delivery = %+v, %v, want a queued student.created delivery to %s", d, err, created.PublicID)
+	}
+	var b struct {
+		ID   string
+		Type string
+		Data struct{ Students []types.Student }
+	}
+	if err := json.Unmarshal(d.Body, &b); err != nil || b.ID != d.EventID || b.Type != "student.created" || len(b.Data.Students) != 1 || b.Data.Students[0].Name != "Asha" {
+		t.Errorf("body = %s, %v, want the created student", d.Body, err)
+	}
+}
+
+func TestHandlerSignsWithBothSecrets(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{status: http.StatusNoContent}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	store := storagetest.NewFake()
+	hook, _ := store.CreateWebhook(ctx, types.Webhook{Owner: "a", URL: srv.URL, Events: []string{"student.created"}, Secret: "old"})
+	hook, _ = store.RotateWebhookSecret(ctx, hook.PublicID, "new", time.Now().Add(time.Hour))
+
+	var q fakeQueue
+	if _, err := Test(ctx, store, &q, hook, time.Now()); err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+	result, err := Handler(store, srv.Client())(ctx, job(q[0], 1))
+	if err != nil || result != (Result{Status: http.StatusNoContent}) {
+		t.Fatalf("Handler = %v, %v, want status 204", result, err)
+	}
+	// A delivery is forgotten once accepted
+	if _, err := store.GetWebhookDelivery(ctx, q[0].DeliveryID); !errors.Is(err, storage.ErrDeliveryNotFound) {
+		t.Errorf("GetWebhookDelivery(delivered) error = %v, want ErrDeliveryNotFound", err)
+	}
+
+	r := rc.requests[0]
+	ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	want := Sign("new", ts, rc.bodies[0]) + "," + Sign("old", ts, rc.bodies[0])
+	if got := r.Header.Get(HeaderSignature); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+	if r.Header.Get(HeaderEvent) != EventTest || r.Header.Get(HeaderID) == "" {
+		t.Errorf("headers = %v, want a %s event with an ID", r.Header, EventTest)
+	}
+}
+
+func TestHandlerFailures(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{status: http.StatusServiceUnavailable}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	store := storagetest.NewFake()
+	hook, _ := store.CreateWebhook(ctx, types.Webhook{Owner: "a", URL: srv.URL, Events: []string{"student.created"}, Secret: "s"})
+	handler := Handler(store, srv.Client())
+	var q fakeQueue
+	if _, err := queue(ctx, store, &q, hook, "student.created", []byte(`{"id":"e1"}`)); err != nil {
+		t.Fatalf("queue: %v", err)
+	}
+	if _, err := Test(ctx, store, &q, hook, time.Now()); err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+
+	// An event is retried, a test isn't: jobs.Permanent wraps the cause, a plain failure wraps nothing
+	_, err := handler(ctx, job(q[0], 1))
+	if err == nil || !strings.Contains(err.Error(), "503") || errors.Unwrap(err) != nil {
+		t.Errorf("event delivery error = %v, want a retryable 503", err)
+	}
+	d, _ := store.GetWebhookDelivery(ctx, q[0].DeliveryID)
+	if d.Status != types.DeliveryQueued || d.Attempts != 1 || d.ResponseStatus != 503 || d.ResponseBody != "Service Unavailable" || d.Error == "" {
+		t.Errorf("delivery after a retryable failure = %+v, want it queued with the answer recorded", d)
+	}
+	_, err = handler(ctx, job(q[1], 1))
+	if err == nil || errors.Unwrap(err) == nil {
+		t.Errorf("test delivery error = %v, want a permanent error", err)
+	}
+	if d, _ := store.GetWebhookDelivery(ctx, q[1].DeliveryID); d.Status != types.DeliveryFailed {
+		t.Errorf("test delivery status = %q, want failed", d.Status)
+	}
+
+	// The last attempt dead-letters the delivery, and a replay sends it again
+	handler(ctx, job(q[0], 3))
+	d, _ = store.GetWebhookDelivery(ctx, q[0].DeliveryID)
+	if d.Status != types.DeliveryFailed || d.Attempts != 2 {
+		t.Errorf("delivery after its last attempt = %+v, want it failed after 2 attempts", d)
+	}
+	rc.status = http.StatusOK
+	if _, err := Replay(ctx, store, &q, d); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if result, err := handler(ctx, job(q[2], 1)); err != nil || result != (Result{Status: http.StatusOK}) {
+		t.Errorf("replayed delivery = %v, %v, want status 200", result, err)
+	}
+	if got := rc.bodies[len(rc.bodies)-1]; string(got) != `{"id":"e1"}` || rc.requests[len(rc.requests)-1].Header.Get(HeaderID) != "e1" {
+		t.Errorf("replay sent %s, want the original body", got)
+	}
+
+	// A deleted subscription's deliveries are dropped
+	rc.status = http.StatusServiceUnavailable
+	queue(ctx, store, &q, hook, "student.created", []byte(`{"id":"e2"}`))
+	store.DeleteWebhook(ctx, hook.PublicID)
+	if result, err := handler(ctx, job(q[3], 1)); result != nil || err != nil {
+		t.Errorf("delivery to deleted webhook = %v, %v, want nothing", result, err)
+	}
+	if len(rc.requests) != 4 {
+		t.Errorf("receiver got %d requests, want 4", len(rc.requests))
+	}
+}
@@ -0,0 +1,171 @@
+// Package workerpool runs cheap, best-effort side effects (notification emails) on a fixed number
+// of goroutines fed by a bounded queue.
+//
+// Webhook deliveries and search-index updates don't use it: they must survive a restart and be
+// retried, so they are persistent jobs (internal/jobs). Queueing one is a row insert made by an
+// event subscriber, and the job runner's fixed workers run them, so a burst of writes doesn't
+// start a goroutine per delivery there either.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when the queue is at capacity.
+	// Callers decide whether to drop the work, retry later, or do it inline.
+	ErrQueueFull = errors.New("worker pool queue is full")
+	ErrClosed    = errors.New("worker pool is closed")
+)
+
+// Task is a unit of asynchronous work. The context is cancelled when the pool is shut down.
+type Task func(ctx context.Context) error
+
+type job struct {
+	name string
+	task Task
+}
+
+// Stats is a point-in-time snapshot of pool activity
+type Stats struct {
+	Workers    int    `json:"workers"`
+	QueueSize  int    `json:"queue_size"`
+	QueueDepth int    `json:"queue_depth"`
+	Submitted  uint64 `json:"submitted"`
+	Rejected   uint64 `json:"rejected"`
+	Completed  uint64 `json:"completed"`
+	Failed     uint64 `json:"failed"`
+}
+
+// Pool runs tasks on a fixed number of goroutines fed by a bounded queue.
+// Bursts of writes enqueue work instead of spawning a goroutine each, and once the
+// queue is full new work is rejected rather than growing memory without limit.
+type Pool struct {
+	workers int
+	queue   chan job
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex // guards closed and sends on queue
+	closed bool
+
+	submitted atomic.Uint64
+	rejected  atomic.Uint64
+	completed atomic.Uint64
+	failed    atomic.Uint64
+}
+
+// New starts a pool with the given number of workers and queue capacity
+func New(workers, queueSize int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		workers: workers,
+		queue:   make(chan job, queueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	p.wg.Add(workers)
+	for range workers {
+		go p.work()
+	}
+
+	return p
+}
+
+// Submit enqueues a task without blocking
+func (p *Pool) Submit(name string, task Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.rejected.Add(1)
+		return ErrClosed
+	}
+
+	select {
+	case p.queue <- job{name: name, task: task}:
+		p.submitted.Add(1)
+		return nil
+	default:
+		p.rejected.Add(1)
+		slog.Warn("Worker pool queue full, rejecting task", "task", name, "queue_size", cap(p.queue))
+		return ErrQueueFull
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for j := range p.queue {
+		p.run(j)
+	}
+}
+
+func (p *Pool) run(j job) {
+	// A panicking task must not take a worker (or the process) down with it
+	defer func() {
+		if r := recover(); r != nil {
+			p.failed.Add(1)
+			slog.Error("Worker pool task panicked", "task", j.name, "panic", r)
+		}
+	}()
+
+	if err := j.task(p.ctx); err != nil {
+		p.failed.Add(1)
+		slog.Error("Worker pool task failed", "task", j.name, "error", err)
+		return
+	}
+	p.completed.Add(1)
+}
+
+// Stats returns current counters and queue depth
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Workers:    p.workers,
+		QueueSize:  cap(p.queue),
+		QueueDepth: len(p.queue),
+		Submitted:  p.submitted.Load(),
+		Rejected:   p.rejected.Load(),
+		Completed:  p.completed.Load(),
+		Failed:     p.failed.Load(),
+	}
+}
+
+// Shutdown stops accepting tasks and waits for queued ones to finish.
+// If ctx expires first, running tasks are cancelled and the remaining queue is abandoned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,129 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// block submits a task that holds the pool's only worker until release is closed
+func block(t *testing.T, p *Pool) (release chan struct{}) {
+	t.Helper()
+	started, release := make(chan struct{}), make(chan struct{})
+	if err := p.Submit("block", func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}); err != nil {
+		t.Fatalf("Submit(block) error = %v", err)
+	}
+	<-started
+	return release
+}
+
+func TestSubmitRejectsWhenQueueFull(t *testing.T) {
+	p := New(1, 1)
+	release := block(t, p)
+
+	if err := p.Submit("queued", func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("Submit(queued) error = %v, want nil", err)
+	}
+	if err := p.Submit("rejected", func(context.Context) error { return nil }); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Submit(rejected) error = %v, want ErrQueueFull", err)
+	}
+	if got := p.Stats(); got.QueueDepth != 1 || got.QueueSize != 1 || got.Submitted != 2 || got.Rejected != 1 {
+		t.Fatalf("Stats() = %+v, want 1 of 1 queued, 2 submitted, 1 rejected", got)
+	}
+
+	close(release)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if err := p.Submit("late", func(context.Context) error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Submit after Shutdown error = %v, want ErrClosed", err)
+	}
+	if got := p.Stats(); got.Rejected != 2 || got.Completed != 2 {
+		t.Fatalf("Stats() = %+v, want 2 rejected, 2 completed", got)
+	}
+}
+
+func TestStats(t *testing.T) {
+	p := New(2, 10)
+	tasks := []Task{
+		func(context.Context) error { return nil },
+		func(context.Context) error { return nil },
+		func(context.Context) error { return errors.New("smtp down") },
+		func(context.Context) error { panic("template missing") },
+	}
+	for _, task := range tasks {
+		if err := p.Submit("task", task); err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := Stats{Workers: 2, QueueSize: 10, Submitted: 4, Completed: 2, Failed: 2}
+	if got := p.Stats(); got != want {
+		t.Fatalf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestShutdownDrainsQueue(t *testing.T) {
+	p := New(1, 10)
+	release := block(t, p)
+
+	var ran atomic.Int32
+	for range 5 {
+		if err := p.Submit("queued", func(context.Context) error {
+			ran.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- p.Shutdown(context.Background()) }()
+	close(release)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Shutdown() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown did not return after the queue drained")
+	}
+	if got := ran.Load(); got != 5 {
+		t.Fatalf("%d queued tasks ran before Shutdown returned, want 5", got)
+	}
+}
+
+func TestShutdownDeadlineCancelsTasks(t *testing.T) {
+	p := New(1, 1)
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	if err := p.Submit("slow", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want DeadlineExceeded", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("running task's context was not cancelled")
+	}
+}